@@ -2,18 +2,29 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/health"
+	"github.com/codecrafted007/autozap/internal/httpclient"
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/mqtt"
+	"github.com/codecrafted007/autozap/internal/notify"
 	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/pidfile"
+	"github.com/codecrafted007/autozap/internal/remediation"
 	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/source"
+	"github.com/codecrafted007/autozap/internal/templating"
 	"github.com/codecrafted007/autozap/internal/trigger"
 	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/fsnotify/fsnotify"
@@ -27,15 +38,22 @@ var agentCmd = &cobra.Command{
 	Long: `Agent mode watches a directory for workflow files and runs them all concurrently.
 
 AutoZap will:
-- Discover all .yaml and .yml files in the directory
+- Discover all .yaml, .yml and .json files in the directory
 - Parse and validate each workflow
 - Start all triggers concurrently
 - Hot-reload when new workflows are added
 - Gracefully shutdown on SIGTERM/SIGINT
 
+Workflows can also be loaded from additional sources that are polled for
+changes (--source, repeatable):
+- https://host/path/workflow.yaml   a single workflow served over HTTP(S)
+- s3://bucket/prefix/               all YAML and JSON objects under an S3 prefix
+- configmap:/etc/autozap/workflows  a mounted Kubernetes ConfigMap directory
+
 Example:
   autozap agent ./workflows
-  autozap agent ./workflows --watch=false  # Disable hot-reload`,
+  autozap agent ./workflows --watch=false  # Disable hot-reload
+  autozap agent ./workflows --source s3://ops-bucket/workflows/ --source-interval 1m`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// Default to ./workflows directory
@@ -49,21 +67,87 @@ Example:
 		logDir, _ := cmd.Flags().GetString("log-dir")
 		httpPort, _ := cmd.Flags().GetInt("http-port")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		dbPath, _ := cmd.Flags().GetString("db")
+		dbDriver, _ := cmd.Flags().GetString("db-driver")
+		sourceSpecs, _ := cmd.Flags().GetStringArray("source")
+		sourceInterval, _ := cmd.Flags().GetDuration("source-interval")
+		retentionFlag, _ := cmd.Flags().GetString("retention")
+		configPath, _ := cmd.Flags().GetString("config")
+		failOnInvalid, _ := cmd.Flags().GetBool("fail-on-invalid")
+		startupSpread, _ := cmd.Flags().GetDuration("startup-spread")
+		shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+		once, _ := cmd.Flags().GetBool("once")
+		force, _ := cmd.Flags().GetBool("force")
+		apiToken, _ := cmd.Flags().GetString("api-token")
+
+		retention, err := config.ParseRetention(retentionFlag)
+		if err != nil {
+			logger.L().Errorw("Invalid retention",
+				"error", err,
+			)
+			return
+		}
+		if sourceInterval <= 0 {
+			logger.L().Errorw("Invalid source interval, it must be positive",
+				"source_interval", sourceInterval.String(),
+			)
+			return
+		}
+
+		// Let actions execute the team's shared message templates
+		if err := templating.LoadTemplates(agentConfig.TemplatesDir); err != nil {
+			logger.L().Errorw("Failed to load templates",
+				"error", err,
+			)
+			return
+		}
+
+		// Refuse to start with broken workflows instead of skipping them
+		if failOnInvalid {
+			invalid := invalidWorkflows(workflowDir, sourceSpecs)
+			if len(invalid) > 0 {
+				for _, err := range invalid {
+					logger.L().Errorw("Invalid workflow", "error", err)
+				}
+				logger.L().Errorw("Refusing to start, workflows failed validation (--fail-on-invalid)",
+					"invalid", len(invalid),
+				)
+				os.Exit(1)
+			}
+		}
 
 		if dryRun {
 			logger.L().Info("[DRY RUN MODE] No workflows will be executed")
 		}
 
+		// Refuse to run the same workflows twice next to another agent
+		if !dryRun && !once {
+			dbPath, _ := cmd.Flags().GetString("db")
+			release, err := lockAgent(workflowDir, dbDriver, dbPath, force)
+			var held *pidfile.HeldError
+			if errors.As(err, &held) {
+				logger.L().Errorw("Another agent is already running on this workflow directory or database; stop it or start with --force",
+					"lock_file", held.Path,
+					"pid", held.PID,
+				)
+				os.Exit(1)
+			}
+			if err != nil {
+				logger.L().Errorw("Failed to create lock file",
+					"error", err,
+				)
+				os.Exit(1)
+			}
+			defer release()
+		}
+
 		// Initialize database
-		if err := database.InitDB(dbPath); err != nil {
+		if err := openDatabase(cmd); err != nil {
 			logger.L().Errorw("Failed to initialize database",
 				"error", err,
-				"db_path", dbPath,
 			)
 			return
 		}
-		defer database.CloseDB()
+		defer closeDatabase()
 
 		logger.L().Infow("Starting AutoZap Agent",
 			"workflow_directory", workflowDir,
@@ -71,16 +155,32 @@ Example:
 			"log_directory", logDir,
 			"http_port", httpPort,
 			"dry_run", dryRun,
-			"db_path", dbPath,
+			"db_driver", dbDriver,
+			"sources", sourceSpecs,
+			"retention", retentionFlag,
+			"config", configPath,
+			"startup_spread", startupSpread,
+			"shutdown_timeout", shutdownTimeout,
+			"once", once,
 		)
 
+		// Let the API run loaded workflows on demand
+		server.SetManualTriggerFunc(runManualTrigger)
+		server.SetAPIToken(apiToken)
+		if apiToken == "" && !once {
+			logger.L().Warnw("No API token set, so the API endpoints that change the agent only accept requests from this host; set apiToken in the agent config or --api-token to allow remote requests")
+		}
+
 		// Start HTTP server for metrics and health endpoints
+		// A one-shot run doesn't serve the API, so it can run next to an agent
 		srv := server.NewServer(httpPort)
-		if err := srv.Start(); err != nil {
-			logger.L().Errorw("Failed to start HTTP server",
-				"error", err,
-			)
-			return
+		if !once {
+			if err := srv.Start(); err != nil {
+				logger.L().Errorw("Failed to start HTTP server",
+					"error", err,
+				)
+				return
+			}
 		}
 
 		// Track agent start time for uptime metric
@@ -100,13 +200,20 @@ Example:
 			)
 		}
 
-		// Check if directory exists
+		// Check if directory exists. It is optional when workflows come from other sources.
+		localDir := true
 		if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
-			logger.L().Errorw("Workflow directory does not exist",
+			if len(sourceSpecs) == 0 {
+				logger.L().Errorw("Workflow directory does not exist",
+					"directory", workflowDir,
+					"error", err,
+				)
+				return
+			}
+			logger.L().Warnw("Workflow directory does not exist, using only additional sources",
 				"directory", workflowDir,
-				"error", err,
 			)
-			return
+			localDir = false
 		}
 
 		// Create context for graceful shutdown
@@ -117,15 +224,76 @@ Example:
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-		// Load and start all workflows
-		activeWorkflows := &sync.Map{} // map[string]context.CancelFunc
-		if err := loadWorkflows(ctx, workflowDir, logDir, activeWorkflows, dryRun); err != nil {
-			logger.L().Errorw("Failed to load workflows",
+		// Pool the connections of HTTP actions and health checks
+		if err := httpclient.Configure(agentConfig.HTTPClient); err != nil {
+			logger.L().Errorw("Invalid HTTP client settings",
 				"error", err,
 			)
 			return
 		}
 
+		// Check the services workflows depend on before any of them runs
+		if !dryRun {
+			health.Start(ctx, agentConfig.Services)
+		}
+
+		// Bound the memory used to capture the output of bash actions
+		action.ConfigureOutput(agentConfig.Output)
+
+		// Run remediation workflows and notify webhooks when other workflows
+		// fail; both are registered so a reloaded configuration can add rules
+		remediation.Configure(agentConfig.Remediations)
+		notify.Configure(agentConfig.Notifications)
+		executor.SetFailureHandler(func(wf *workflow.Workflow, triggerType string, workflowExecID int64, status, errMsg string) {
+			remediation.HandleFailure(wf, triggerType, workflowExecID, status, errMsg)
+			notify.HandleFailure(wf, triggerType, workflowExecID, status, errMsg)
+		})
+
+		// Connect to the MQTT broker shared by mqtt triggers and actions
+		if agentConfig.MQTT != nil && !dryRun {
+			if err := mqtt.Connect(*agentConfig.MQTT); err != nil {
+				logger.L().Errorw("Failed to connect to MQTT broker",
+					"error", err,
+				)
+				return
+			}
+		}
+
+		// Run every workflow once and exit, e.g. when scheduled externally or
+		// as a CI smoke test
+		if once && !dryRun {
+			code := runWorkflowsOnce(workflowDir, localDir, sourceSpecs)
+			waitForAsyncActions()
+			mqtt.Disconnect()
+			closeDatabase()
+			os.Exit(code)
+		}
+
+		// Load and start all workflows
+		activeWorkflows := &sync.Map{} // map[string]context.CancelFunc
+		if localDir {
+			if err := loadWorkflows(ctx, workflowDir, logDir, activeWorkflows, dryRun, startupSpread); err != nil {
+				logger.L().Errorw("Failed to load workflows",
+					"error", err,
+				)
+				return
+			}
+		}
+
+		// In dry-run mode, only list the additional sources
+		if dryRun {
+			for _, spec := range sourceSpecs {
+				logger.L().Infof("[DRY RUN] Would poll workflow source every %s: %s", sourceInterval, spec)
+			}
+		} else if len(sourceSpecs) > 0 {
+			if err := startWorkflowSources(ctx, sourceSpecs, sourceInterval, logDir, activeWorkflows); err != nil {
+				logger.L().Errorw("Failed to start workflow sources",
+					"error", err,
+				)
+				return
+			}
+		}
+
 		// In dry-run mode, exit after showing what would be done
 		if dryRun {
 			logger.L().Info("[DRY RUN] Dry run complete. No workflows were started.")
@@ -154,10 +322,44 @@ Example:
 			}
 		}()
 
+		// Periodically delete executions outside the retention window, which
+		// a reload of the agent configuration may change
+		retentionChanges := make(chan time.Duration, 1)
+		go pruneLoop(ctx, retention, retentionChanges)
+
+		// Reload the agent configuration when its file changes or on SIGHUP
+		if agentConfigPath != "" {
+			retentionLocked := cmd.Flags().Changed("retention") && !flagsFromConfig["retention"]
+			reload := func() { reloadAgentConfig(agentConfigPath, retentionChanges, retentionLocked) }
+
+			configWatcher, err := watchAgentConfig(ctx, agentConfigPath, reload)
+			if err != nil {
+				logger.L().Errorw("Failed to watch agent configuration, reload it with SIGHUP",
+					"config", agentConfigPath,
+					"error", err,
+				)
+			} else {
+				defer configWatcher.Close()
+			}
+
+			hupChan := make(chan os.Signal, 1)
+			signal.Notify(hupChan, syscall.SIGHUP)
+			defer signal.Stop(hupChan)
+			go func() {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-hupChan:
+						reload()
+					}
+				}
+			}()
+		}
+
 		// Setup file watcher for hot-reload
 		var watcher *fsnotify.Watcher
-		var err error
-		if watch {
+		if watch && localDir {
 			watcher, err = setupWorkflowWatcher(ctx, workflowDir, logDir, activeWorkflows)
 			if err != nil {
 				logger.L().Errorw("Failed to setup workflow watcher",
@@ -174,11 +376,14 @@ Example:
 		<-sigChan
 		logger.L().Info("Received shutdown signal. Gracefully stopping all workflows...")
 
-		// Cancel all workflows
-		cancel()
+		// Run shutdown workflows while the others are still loaded
+		trigger.RunShutdownTriggers()
 
-		// Give workflows time to cleanup
-		time.Sleep(2 * time.Second)
+		// Stop the triggers and let the runs in progress finish
+		cancel()
+		drainRuns(shutdownTimeout)
+		waitForAsyncActions()
+		mqtt.Disconnect()
 
 		// Shutdown HTTP server
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -191,22 +396,48 @@ Example:
 	},
 }
 
-// loadWorkflows discovers and starts all workflow files in a directory
-func loadWorkflows(ctx context.Context, workflowDir, logDir string, activeWorkflows *sync.Map, dryRun bool) error {
-	// Find all YAML files
-	pattern := filepath.Join(workflowDir, "*.yaml")
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		return err
+// pruneLoop deletes executions outside the retention window hourly, and right
+// away at startup and when the retention changes; 0 keeps them forever
+func pruneLoop(ctx context.Context, retention time.Duration, changes <-chan time.Duration) {
+	if retention > 0 {
+		pruneExecutions(retention)
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case retention = <-changes:
+			logger.L().Infow("Retention changed", "retention", retention.String())
+			if retention > 0 {
+				pruneExecutions(retention)
+			}
+		case <-ticker.C:
+			if retention > 0 {
+				pruneExecutions(retention)
+			}
+		}
 	}
+}
+
+// runManualTrigger runs a workflow fired through the API in the background,
+// exposing the request payload to action templates as {{ .payload }} and to
+// bash actions as AUTOZAP_EVENT_PAYLOAD
+func runManualTrigger(wf *workflow.Workflow, payload map[string]interface{}, source map[string]string) {
+	metrics.RecordTriggerFire(wf.Name, executor.TriggerTypeManual)
+	data := executor.WithEvent(templating.Data{"payload": payload}, executor.Event{Payload: payload, Time: time.Now(), Source: source})
+	go executor.ExecuteWithData(wf, executor.TriggerTypeManual, data)
+}
 
-	// Also find .yml files
-	pattern2 := filepath.Join(workflowDir, "*.yml")
-	ymlFiles, err := filepath.Glob(pattern2)
+// loadWorkflows discovers and starts all workflow files in a directory and its
+// subdirectories
+func loadWorkflows(ctx context.Context, workflowDir, logDir string, activeWorkflows *sync.Map, dryRun bool, startupSpread time.Duration) error {
+	files, err := workflowFiles(workflowDir)
 	if err != nil {
 		return err
 	}
-	files = append(files, ymlFiles...)
 
 	if len(files) == 0 {
 		logger.L().Warnw("No workflow files found in directory",
@@ -232,12 +463,12 @@ func loadWorkflows(ctx context.Context, workflowDir, logDir string, activeWorkfl
 			logger.L().Infof("[DRY RUN]   %d. %s", i+1, wf.Name)
 			logger.L().Infof("[DRY RUN]      File: %s", file)
 			logger.L().Infof("[DRY RUN]      Trigger: %s", wf.Trigger.Type)
+			if !wf.IsEnabled() {
+				logger.L().Info("[DRY RUN]      Disabled: would not be started")
+			}
 
-			switch wf.Trigger.Type {
-			case workflow.TriggerTypeCron:
-				logger.L().Infof("[DRY RUN]      Schedule: %s", wf.Trigger.Schedule)
-			case workflow.TriggerTypeFileWatch:
-				logger.L().Infof("[DRY RUN]      Watch: %s", wf.Trigger.Path)
+			for _, line := range trigger.Describe(wf.Trigger) {
+				logger.L().Infof("[DRY RUN]      %s", line)
 			}
 
 			logger.L().Infof("[DRY RUN]      Actions: %d", len(wf.Actions))
@@ -245,28 +476,140 @@ func loadWorkflows(ctx context.Context, workflowDir, logDir string, activeWorkfl
 		return nil
 	}
 
+	// Parse every workflow first, so the cron workflows' first runs can be
+	// staggered over the startup spread in file order
+	parsed := make(map[string]*workflow.Workflow, len(files))
+	var cronWorkflows []string
+	for _, file := range files {
+		wf, err := parser.ParseWorkflowFile(file)
+		if err != nil {
+			logger.L().Errorw("Failed to start workflow",
+				"file", file,
+				"error", err,
+			)
+			continue
+		}
+		parsed[file] = wf
+		if wf.Trigger.Type == workflow.TriggerTypeCron && wf.IsEnabled() {
+			cronWorkflows = append(cronWorkflows, wf.Name)
+		}
+	}
+	if startupSpread > 0 && len(cronWorkflows) > 1 {
+		logger.L().Infow("Staggering first runs of cron workflows",
+			"workflows", len(cronWorkflows),
+			"spread", startupSpread,
+		)
+	}
+	trigger.StaggerStartup(cronWorkflows, startupSpread)
+
 	// Load each workflow
-	successCount := 0
+	successCount, disabledCount := 0, 0
 	for _, file := range files {
-		if err := startWorkflow(ctx, file, logDir, activeWorkflows); err != nil {
+		wf, ok := parsed[file]
+		if !ok {
+			continue
+		}
+		if err := runWorkflow(ctx, file, wf, logDir, activeWorkflows); err != nil {
 			logger.L().Errorw("Failed to start workflow",
 				"file", file,
 				"error", err,
 			)
 			continue
 		}
+		if !wf.IsEnabled() {
+			disabledCount++
+			continue
+		}
 		successCount++
 	}
 
 	logger.L().Infow("Workflows started",
 		"total", len(files),
 		"successful", successCount,
-		"failed", len(files)-successCount,
+		"disabled", disabledCount,
+		"failed", len(files)-successCount-disabledCount,
 	)
 
 	return nil
 }
 
+// workflowFiles returns the workflow files in a workflow directory and its
+// subdirectories, leaving out files of shared actions for include
+func workflowFiles(workflowDir string) ([]string, error) {
+	files, err := source.WorkflowFiles(workflowDir)
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := files[:0]
+	for _, file := range files {
+		if data, err := os.ReadFile(file); err == nil && parser.IsSharedActions(data) {
+			continue
+		}
+		workflows = append(workflows, file)
+	}
+	return workflows, nil
+}
+
+// invalidWorkflows parses every workflow the agent would load at startup,
+// from the workflow directory and the additional sources, and returns the
+// validation errors and name conflicts. Sources that can't be fetched are only
+// logged, since they are retried while the agent runs.
+func invalidWorkflows(workflowDir string, sourceSpecs []string) []error {
+	var invalid []error
+	names := make(map[string]string) // workflow name to the first file defining it
+	checkName := func(wf *workflow.Workflow, key string) {
+		if owner, taken := names[wf.Name]; taken {
+			invalid = append(invalid, fmt.Errorf("workflow name '%s' of %s is already used by %s", wf.Name, key, owner))
+			return
+		}
+		names[wf.Name] = key
+	}
+
+	if _, err := os.Stat(workflowDir); err == nil {
+		files, err := workflowFiles(workflowDir)
+		if err != nil {
+			return []error{err}
+		}
+		for _, file := range files {
+			wf, err := parser.ParseWorkflowFile(file)
+			if err != nil {
+				invalid = append(invalid, err)
+				continue
+			}
+			checkName(wf, file)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, spec := range sourceSpecs {
+		src, err := source.New(spec)
+		if err != nil {
+			invalid = append(invalid, err)
+			continue
+		}
+		docs, err := src.Fetch(ctx)
+		if err != nil {
+			logger.L().Warnw("Failed to fetch workflow source for validation",
+				"source", src.Name(),
+				"error", err,
+			)
+			continue
+		}
+		for _, doc := range docs {
+			wf, err := parser.ParseWorkflow(doc.Data, doc.ID)
+			if err != nil {
+				invalid = append(invalid, err)
+				continue
+			}
+			checkName(wf, doc.ID)
+		}
+	}
+
+	return invalid
+}
+
 // startWorkflow parses and starts a single workflow
 func startWorkflow(ctx context.Context, filePath, logDir string, activeWorkflows *sync.Map) error {
 	// Parse workflow
@@ -275,6 +618,37 @@ func startWorkflow(ctx context.Context, filePath, logDir string, activeWorkflows
 		return err
 	}
 
+	return runWorkflow(ctx, filePath, wf, logDir, activeWorkflows)
+}
+
+// runWorkflow starts the trigger of a parsed workflow. key identifies where the
+// workflow came from (file path, URL, ...) and is used to stop it later.
+func runWorkflow(ctx context.Context, key string, wf *workflow.Workflow, logDir string, activeWorkflows *sync.Map) error {
+	// Two workflows of the same name would share their registry entry,
+	// metrics and history, so the first one loaded keeps the name
+	if owner, ok := claimWorkflowName(wf.Name, key); !ok {
+		server.AddConflict(server.WorkflowConflict{Name: wf.Name, File: key, ConflictsWith: owner})
+		return fmt.Errorf("workflow name '%s' is already used by %s", wf.Name, owner)
+	}
+	server.RemoveConflict(key)
+
+	// A disabled workflow is listed, but its trigger isn't started
+	if !wf.IsEnabled() {
+		logger.L().Infow("Workflow disabled, not starting it",
+			"workflow_name", wf.Name,
+			"file", key,
+		)
+		server.GetRegistry().RegisterWorkflow(wf)
+		disabledWorkflows.Store(key, wf.Name)
+		return nil
+	}
+
+	t, err := trigger.New(wf)
+	if err != nil {
+		releaseWorkflowName(wf.Name, key)
+		return err
+	}
+
 	// Create workflow-specific logger
 	workflowLogger, err := logger.NewWorkflowLogger(wf.Name, logDir)
 	if err != nil {
@@ -287,164 +661,149 @@ func startWorkflow(ctx context.Context, filePath, logDir string, activeWorkflows
 	}
 
 	workflowLogger.Infow("Starting workflow",
-		"file", filePath,
+		"file", key,
 		"trigger_type", wf.Trigger.Type,
 		"actions_count", len(wf.Actions),
 	)
 
+	for _, name := range wf.DependsOnServices {
+		if !health.Defined(name) {
+			workflowLogger.Warnw("Workflow depends on a service that is not defined in the agent config; its runs will be blocked",
+				"service", name,
+			)
+		}
+	}
+
 	// Create a context for this workflow
 	workflowCtx, workflowCancel := context.WithCancel(ctx)
 
-	// Store the cancel function
-	activeWorkflows.Store(filePath, workflowCancel)
+	// Store the cancel function, which also frees the workflow's name
+	activeWorkflows.Store(key, context.CancelFunc(func() {
+		releaseWorkflowName(wf.Name, key)
+		workflowCancel()
+	}))
 
 	// Start the workflow in a goroutine
 	go func() {
 		defer workflowCancel()
 
-		switch wf.Trigger.Type {
-		case workflow.TriggerTypeCron:
-			if err := trigger.StartCronTrigger(workflowCtx, wf); err != nil {
-				workflowLogger.Errorw("Failed to start cron trigger",
-					"file", filePath,
-					"error", err,
-				)
-				return
-			}
-		case workflow.TriggerTypeFileWatch:
-			if err := trigger.StartFileWatchTrigger(workflowCtx, wf); err != nil {
-				workflowLogger.Errorw("Failed to start file watch trigger",
-					"file", filePath,
-					"error", err,
-				)
-				return
-			}
-		default:
-			workflowLogger.Errorw("Unsupported trigger type",
+		if err := t.Start(workflowCtx); err != nil {
+			workflowLogger.Errorw("Failed to start trigger",
+				"file", key,
 				"trigger_type", wf.Trigger.Type,
+				"error", err,
 			)
 			return
 		}
+		defer t.Stop()
 
 		// Wait for context cancellation
 		<-workflowCtx.Done()
 		workflowLogger.Infow("Workflow stopped",
-			"file", filePath,
+			"file", key,
 		)
 	}()
 
 	return nil
 }
 
-// setupWorkflowWatcher sets up file system watcher for hot-reload
-func setupWorkflowWatcher(ctx context.Context, workflowDir, logDir string, activeWorkflows *sync.Map) (*fsnotify.Watcher, error) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, err
-	}
-
-	// Add workflow directory to watcher
-	if err := watcher.Add(workflowDir); err != nil {
-		watcher.Close()
-		return nil, err
-	}
+// workflowNames maps the name of each started workflow to the file or source
+// document that defines it
+var (
+	workflowNamesMu sync.Mutex
+	workflowNames   = make(map[string]string)
+)
 
-	logger.L().Infow("Workflow hot-reload enabled",
-		"directory", workflowDir,
-	)
+// claimWorkflowName records that key defines the workflow called name. If
+// another file or document already does, it returns that one and false.
+func claimWorkflowName(name, key string) (string, bool) {
+	workflowNamesMu.Lock()
+	defer workflowNamesMu.Unlock()
 
-	// Watch for file changes in a goroutine
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case event, ok := <-watcher.Events:
-				if !ok {
-					return
-				}
+	if owner, taken := workflowNames[name]; taken && owner != key {
+		return owner, false
+	}
+	workflowNames[name] = key
+	return key, true
+}
 
-				// Only handle YAML files
-				if filepath.Ext(event.Name) != ".yaml" && filepath.Ext(event.Name) != ".yml" {
-					continue
-				}
+// releaseWorkflowName frees a workflow name claimed by key
+func releaseWorkflowName(name, key string) {
+	workflowNamesMu.Lock()
+	defer workflowNamesMu.Unlock()
 
-				// Handle create events
-				if event.Op&fsnotify.Create == fsnotify.Create {
-					logger.L().Infow("New workflow detected",
-						"file", event.Name,
-						"operation", "create",
-					)
-
-					// Wait a bit for file to be fully written
-					time.Sleep(500 * time.Millisecond)
-
-					if err := startWorkflow(ctx, event.Name, logDir, activeWorkflows); err != nil {
-						logger.L().Errorw("Failed to start new workflow",
-							"file", event.Name,
-							"error", err,
-						)
-					}
-				}
+	if workflowNames[name] == key {
+		delete(workflowNames, name)
+	}
+}
 
-				// Handle write events (workflow file updated)
-				if event.Op&fsnotify.Write == fsnotify.Write {
-					logger.L().Infow("Workflow file modified",
-						"file", event.Name,
-						"operation", "write",
-					)
-
-					// Stop existing workflow
-					if cancel, ok := activeWorkflows.Load(event.Name); ok {
-						if cancelFunc, ok := cancel.(context.CancelFunc); ok {
-							cancelFunc()
-						}
-						activeWorkflows.Delete(event.Name)
-					}
+// disabledWorkflows maps the file or source document of each workflow with
+// enabled: false to its name
+var disabledWorkflows sync.Map
+
+// stopWorkflow stops the workflow loaded from key, if any, and forgets its
+// name conflict. It reports whether a workflow was running.
+func stopWorkflow(activeWorkflows *sync.Map, key string) bool {
+	server.RemoveConflict(key)
+	if name, disabled := disabledWorkflows.LoadAndDelete(key); disabled {
+		releaseWorkflowName(name.(string), key)
+		server.GetRegistry().UnregisterWorkflow(name.(string))
+		return true
+	}
+	cancel, running := activeWorkflows.LoadAndDelete(key)
+	if running {
+		if cancelFunc, ok := cancel.(context.CancelFunc); ok {
+			cancelFunc()
+		}
+	}
+	return running
+}
 
-					// Wait a bit for file to be fully written
-					time.Sleep(500 * time.Millisecond)
-
-					// Start updated workflow
-					if err := startWorkflow(ctx, event.Name, logDir, activeWorkflows); err != nil {
-						logger.L().Errorw("Failed to reload workflow",
-							"file", event.Name,
-							"error", err,
-						)
-					} else {
-						logger.L().Infow("Workflow reloaded successfully",
-							"file", event.Name,
-						)
-					}
-				}
+// setupWorkflowWatcher sets up file system watcher for hot-reload. A workflow
+// is restarted, started or stopped once its file has settled, however the
+// editor saved it.
+func setupWorkflowWatcher(ctx context.Context, workflowDir, logDir string, activeWorkflows *sync.Map) (*fsnotify.Watcher, error) {
+	watcher, err := source.Watch(ctx, workflowDir, source.DefaultWatchDelay, func(change source.Change) {
+		// Stop the previous version of the workflow, if any
+		running := stopWorkflow(activeWorkflows, change.ID)
 
-				// Handle delete events
-				if event.Op&fsnotify.Remove == fsnotify.Remove {
-					logger.L().Infow("Workflow file removed",
-						"file", event.Name,
-						"operation", "remove",
-					)
-
-					// Stop workflow
-					if cancel, ok := activeWorkflows.Load(event.Name); ok {
-						if cancelFunc, ok := cancel.(context.CancelFunc); ok {
-							cancelFunc()
-						}
-						activeWorkflows.Delete(event.Name)
-					}
-				}
+		if change.Removed {
+			logger.L().Infow("Workflow file removed",
+				"file", change.ID,
+			)
+			return
+		}
+		if parser.IsSharedActions(change.Data) {
+			return
+		}
 
-			case err, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-				logger.L().Errorw("Workflow watcher error",
-					"error", err,
-				)
-			}
+		if running {
+			logger.L().Infow("Workflow file modified",
+				"file", change.ID,
+			)
+		} else {
+			logger.L().Infow("New workflow detected",
+				"file", change.ID,
+			)
 		}
-	}()
+		if err := startWorkflow(ctx, change.ID, logDir, activeWorkflows); err != nil {
+			logger.L().Errorw("Failed to reload workflow",
+				"file", change.ID,
+				"error", err,
+			)
+			return
+		}
+		logger.L().Infow("Workflow reloaded successfully",
+			"file", change.ID,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	logger.L().Infow("Workflow hot-reload enabled",
+		"directory", workflowDir,
+	)
 	return watcher, nil
 }
 
@@ -455,6 +814,74 @@ func init() {
 	agentCmd.Flags().Bool("watch", true, "Enable hot-reload for workflow changes")
 	agentCmd.Flags().String("log-dir", "", "Directory for per-workflow log files (default: stdout)")
 	agentCmd.Flags().Int("http-port", 8080, "HTTP port for metrics and health endpoints")
+	agentCmd.Flags().String("api-token", "", "Bearer token required by the API endpoints that change the agent, e.g. triggering a workflow (default: only requests from this host)")
 	agentCmd.Flags().Bool("dry-run", false, "Show what would be executed without starting workflows")
-	agentCmd.Flags().String("db", "./data/autozap.db", "Database file path")
+	addDBFlags(agentCmd.Flags())
+	agentCmd.Flags().StringArray("source", nil, "Additional workflow source: URL, s3://bucket/prefix or configmap:/path (repeatable)")
+	agentCmd.Flags().Duration("source-interval", 30*time.Second, "How often additional workflow sources are polled for changes")
+	agentCmd.Flags().Duration("startup-spread", 0, "Spread the first runs of cron workflows evenly over this window at startup, e.g. 1m (default: no spreading)")
+	agentCmd.Flags().Bool("fail-on-invalid", false, "Refuse to start (exit 1) if any workflow fails validation instead of skipping it")
+	agentCmd.Flags().String("retention", "", "Delete executions older than this from the database, checked hourly (e.g. 30d, 72h; default: keep forever)")
+	agentCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "How long shutdown waits for running workflows to finish before aborting them")
+	agentCmd.Flags().Bool("force", false, "Start even if another agent holds the lock of the workflow directory or database")
+	agentCmd.Flags().Bool("once", false, "Run every workflow once now, ignoring triggers, and exit (1 if any failed)")
+}
+
+// startWorkflowSources polls each additional workflow source and starts,
+// restarts or stops its workflows as their definitions change
+func startWorkflowSources(ctx context.Context, specs []string, interval time.Duration, logDir string, activeWorkflows *sync.Map) error {
+	sources := make([]source.WorkflowSource, 0, len(specs))
+	for _, spec := range specs {
+		src, err := source.New(spec)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, src)
+	}
+
+	for _, src := range sources {
+		logger.L().Infow("Polling workflow source",
+			"source", src.Name(),
+			"interval", interval,
+		)
+
+		go source.Poll(ctx, src, interval, func(change source.Change) {
+			// Stop the previous version of the workflow, if any
+			stopWorkflow(activeWorkflows, change.ID)
+
+			if change.Removed {
+				logger.L().Infow("Workflow removed from source",
+					"source", src.Name(),
+					"workflow_id", change.ID,
+				)
+				return
+			}
+
+			wf, err := parser.ParseWorkflow(change.Data, change.ID)
+			if err != nil {
+				logger.L().Errorw("Failed to parse workflow from source",
+					"source", src.Name(),
+					"workflow_id", change.ID,
+					"error", err,
+				)
+				return
+			}
+
+			if err := runWorkflow(ctx, change.ID, wf, logDir, activeWorkflows); err != nil {
+				logger.L().Errorw("Failed to start workflow from source",
+					"source", src.Name(),
+					"workflow_id", change.ID,
+					"error", err,
+				)
+				return
+			}
+			logger.L().Infow("Workflow loaded from source",
+				"source", src.Name(),
+				"workflow_id", change.ID,
+				"workflow_name", wf.Name,
+			)
+		})
+	}
+
+	return nil
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/approval"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+)
+
+var approvalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "List the approval actions waiting for a decision",
+	Long: `List the approval actions of running workflows that are waiting to be
+approved or rejected, with the ID to pass to 'autozap approve' or
+'autozap reject'.
+
+Examples:
+  autozap approvals
+  autozap approve 3f9a0c2b7d1e4a58 --comment "change ticket CHG-1042"
+  autozap reject 3f9a0c2b7d1e4a58 --comment "not during business hours"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var pending []approval.Request
+		if err := json.Unmarshal(callApprovalAPI(cmd, http.MethodGet, "/api/approvals", nil), &pending); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unexpected response from agent: %v\n", err)
+			os.Exit(1)
+		}
+		if len(pending) == 0 {
+			fmt.Println("✓ No approvals pending")
+			return
+		}
+		for _, req := range pending {
+			fmt.Printf("✋ %s  %s → %s (expires in %s)\n", req.ID, req.Workflow, req.Action, time.Until(req.ExpiresAt).Round(time.Second))
+			fmt.Printf("  Requested: %s\n", timezone.Format(req.RequestedAt))
+			if req.Message != "" {
+				fmt.Printf("  Message:   %s\n", req.Message)
+			}
+		}
+	},
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [approval_id]",
+	Short: "Approve a pending approval action, continuing its run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		decideApproval(cmd, args[0], "approve")
+	},
+}
+
+var rejectCmd = &cobra.Command{
+	Use:   "reject [approval_id]",
+	Short: "Reject a pending approval action, failing its run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		decideApproval(cmd, args[0], "reject")
+	},
+}
+
+// decideApproval posts the decision to the agent, signed with --by or the
+// current user
+func decideApproval(cmd *cobra.Command, id, decision string) {
+	by, _ := cmd.Flags().GetString("by")
+	if by == "" {
+		if u, err := user.Current(); err == nil {
+			by = u.Username
+		}
+	}
+	comment, _ := cmd.Flags().GetString("comment")
+	body, _ := json.Marshal(map[string]string{"by": by, "comment": comment})
+
+	var result struct {
+		Workflow string `json:"workflow"`
+		Action   string `json:"action"`
+		Status   string `json:"status"`
+	}
+	path := fmt.Sprintf("/api/approvals/%s/%s", url.PathEscape(id), decision)
+	if err := json.Unmarshal(callApprovalAPI(cmd, http.MethodPost, path, body), &result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unexpected response from agent: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Action '%s' of workflow '%s' %s\n", result.Action, result.Workflow, result.Status)
+}
+
+// callApprovalAPI calls an approval endpoint on the agent and returns the
+// response body, exiting on errors
+func callApprovalAPI(cmd *cobra.Command, method, path string, body []byte) []byte {
+	agentURL := agentBaseURL(cmd)
+	endpoint := strings.TrimRight(agentURL, "/") + path
+
+	req, err := newAgentRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to reach agent at %s: %v\n", agentURL, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		fmt.Fprintf(os.Stderr, "Error: agent returned %s: %s\n", resp.Status, strings.TrimSpace(string(respBody)))
+		os.Exit(1)
+	}
+	return respBody
+}
+
+func init() {
+	rootCmd.AddCommand(approvalsCmd, approveCmd, rejectCmd)
+
+	for _, c := range []*cobra.Command{approvalsCmd, approveCmd, rejectCmd} {
+		c.Flags().String("url", "http://localhost:8080", "Base URL of the agent's HTTP server")
+	}
+	for _, c := range []*cobra.Command{approveCmd, rejectCmd} {
+		c.Flags().String("by", "", "Who decided, recorded in the action's output (default: the current user)")
+		c.Flags().String("comment", "", "Why, recorded in the action's output")
+	}
+}
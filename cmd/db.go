@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Maintain the execution history database",
+}
+
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old workflow and action executions",
+	Long: `Delete workflow executions, and their action executions, that started
+before the retention window, and dead letters added before it. Output of
+bash actions spilled to disk before then is deleted too; pass the agent's
+--config if it sets output.spillDir. Use --vacuum to shrink the database file afterwards.
+
+Examples:
+  autozap db prune --older-than 30d
+  autozap db prune --older-than 72h --vacuum`,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		vacuum, _ := cmd.Flags().GetBool("vacuum")
+
+		retention, err := config.ParseRetention(olderThan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if retention <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --older-than must be greater than zero")
+			return
+		}
+
+		// Initialize database
+		if err := openDatabase(cmd); err != nil {
+			logger.L().Errorw("Failed to initialize database", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			return
+		}
+		defer database.CloseDB()
+
+		// Find the output spill directory of the agent
+		action.ConfigureOutput(agentConfig.Output)
+
+		cutoff := time.Now().Add(-retention)
+		workflowsDeleted, actionsDeleted, err := database.PruneExecutions(cutoff)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to prune executions: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✓ Deleted %d workflow executions and %d action executions started before %s\n",
+			workflowsDeleted, actionsDeleted, timezone.Format(cutoff))
+
+		deadLettersDeleted, err := database.PruneDeadLetters(cutoff)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to prune dead letters: %v\n", err)
+			return
+		}
+		if deadLettersDeleted > 0 {
+			fmt.Printf("✓ Deleted %d dead letters\n", deadLettersDeleted)
+		}
+
+		spillFilesDeleted, err := action.PruneSpillFiles(cutoff)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to prune spill files: %v\n", err)
+			return
+		}
+		if spillFilesDeleted > 0 {
+			fmt.Printf("✓ Deleted %d spill files from %s\n", spillFilesDeleted, action.SpillDir())
+		}
+
+		if vacuum {
+			if err := database.Vacuum(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return
+			}
+			fmt.Println("✓ Database vacuumed")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbPruneCmd)
+
+	addDBFlags(dbCmd.PersistentFlags())
+	dbPruneCmd.Flags().String("older-than", "30d", "Delete executions older than this (e.g. 30d, 12h)")
+	dbPruneCmd.Flags().Bool("vacuum", false, "Reclaim disk space after pruning")
+}
+
+// addDBFlags registers the --db and --db-driver flags shared by every command
+// that reads or writes execution history
+func addDBFlags(flags *pflag.FlagSet) {
+	flags.String("db", "./data/autozap.db", "Database file path (sqlite) or DSN (postgres, mysql)")
+	flags.String("db-driver", database.DriverSQLite, "Database driver: sqlite, postgres or mysql")
+}
+
+// openDatabase opens the database selected by the --db and --db-driver flags
+func openDatabase(cmd *cobra.Command) error {
+	dsn, _ := cmd.Flags().GetString("db")
+	driver, _ := cmd.Flags().GetString("db-driver")
+	return database.Open(driver, dsn)
+}
+
+// closeDatabase closes the database on shutdown, logging instead of returning errors
+func closeDatabase() {
+	if err := database.CloseDB(); err != nil {
+		logger.L().Errorw("Failed to close database", "error", err)
+		return
+	}
+	logger.L().Info("Database closed")
+}
+
+// pruneExecutions deletes executions, dead letters and spilled output older
+// than retention and logs the result
+func pruneExecutions(retention time.Duration) {
+	cutoff := time.Now().Add(-retention)
+	workflowsDeleted, actionsDeleted, err := database.PruneExecutions(cutoff)
+	if err != nil {
+		logger.L().Errorw("Failed to prune old executions",
+			"retention", retention,
+			"error", err,
+		)
+		return
+	}
+
+	if workflowsDeleted > 0 {
+		logger.L().Infow("Pruned old executions",
+			"retention", retention,
+			"workflow_executions_deleted", workflowsDeleted,
+			"action_executions_deleted", actionsDeleted,
+		)
+	}
+
+	deadLettersDeleted, err := database.PruneDeadLetters(cutoff)
+	if err != nil {
+		logger.L().Errorw("Failed to prune old dead letters",
+			"retention", retention,
+			"error", err,
+		)
+		return
+	}
+	if deadLettersDeleted > 0 {
+		logger.L().Infow("Pruned old dead letters",
+			"retention", retention,
+			"dead_letters_deleted", deadLettersDeleted,
+		)
+	}
+
+	// Spilled output is kept as long as the executions it belongs to
+	spillFilesDeleted, err := action.PruneSpillFiles(cutoff)
+	if err != nil {
+		logger.L().Errorw("Failed to prune old spill files",
+			"retention", retention,
+			"error", err,
+		)
+		return
+	}
+	if spillFilesDeleted > 0 {
+		logger.L().Infow("Pruned old spill files",
+			"retention", retention,
+			"spill_dir", action.SpillDir(),
+			"spill_files_deleted", spillFilesDeleted,
+		)
+	}
+}
@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug [workflow_file]",
+	Short: "Step through a workflow's actions interactively",
+	Long: `Debug runs a workflow one action at a time. Before each action it shows the
+rendered command or request and waits for a command:
+
+  c, Enter        run the action and show its output
+  s               skip the action
+  e key=value     set a template variable, e.g. e payload.version=1.4.2
+                  (JSON objects, arrays and quoted strings are parsed)
+  v               show the template variables, including earlier step results
+  q               quit
+
+Runs are not recorded in the execution history.
+
+Examples:
+  autozap debug ./workflows/deploy.yaml
+  autozap debug ./workflows/deploy.yaml --payload '{"version": "1.4.2"}'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		payloadJSON, _ := cmd.Flags().GetString("payload")
+
+		payload := map[string]interface{}{}
+		if payloadJSON != "" {
+			if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --payload must be a JSON object: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		wf, err := parser.ParseWorkflowFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// kv and seen template helpers need the database
+		if err := openDatabase(cmd); err != nil {
+			logger.L().Errorw("Failed to initialize database", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			os.Exit(1)
+		}
+		defer closeDatabase()
+
+		steps := map[string]interface{}{}
+		data := templating.Data{"payload": payload, "steps": steps, "actions": steps}
+		vars, err := templating.RenderVars(wf.Vars, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		data["vars"] = vars
+
+		d := &debugger{
+			wf:   wf,
+			in:   bufio.NewReader(cmd.InOrStdin()),
+			out:  cmd.OutOrStdout(),
+			data: data,
+		}
+		d.run()
+	},
+}
+
+// debugger steps through the actions of a workflow
+type debugger struct {
+	wf   *workflow.Workflow
+	in   *bufio.Reader
+	out  io.Writer
+	data templating.Data
+}
+
+func (d *debugger) run() {
+	fmt.Fprintf(d.out, "Debugging workflow '%s' (%d actions)\n", d.wf.Name, len(d.wf.Actions))
+
+	ran, skipped, failed := 0, 0, 0
+	for i := range d.wf.Actions {
+		act := &d.wf.Actions[i]
+
+		switch d.step(i, act) {
+		case stepRan:
+			ran++
+		case stepFailed:
+			ran++
+			failed++
+		case stepSkipped:
+			skipped++
+		case stepQuit:
+			fmt.Fprintln(d.out, "Stopped.")
+			return
+		}
+	}
+
+	fmt.Fprintf(d.out, "\nDone: %d ran (%d failed), %d skipped\n", ran, failed, skipped)
+}
+
+type stepOutcome int
+
+const (
+	stepRan stepOutcome = iota
+	stepFailed
+	stepSkipped
+	stepQuit
+)
+
+// step shows one action and handles commands until it is run, skipped or the user quits
+func (d *debugger) step(index int, act *workflow.Action) stepOutcome {
+	fmt.Fprintf(d.out, "\n[%d/%d] %s (%s)\n", index+1, len(d.wf.Actions), act.Name, act.Type)
+	d.show(act)
+
+	for {
+		fmt.Fprint(d.out, "(c)ontinue, (s)kip, (e)dit key=value, (v)ars, (q)uit > ")
+		line, err := d.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(d.out)
+			return stepQuit // end of input
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch command {
+		case "", "c", "continue":
+			return d.execute(index, act)
+		case "s", "skip":
+			fmt.Fprintln(d.out, "Skipped.")
+			return stepSkipped
+		case "e", "edit":
+			key, value, ok := strings.Cut(strings.TrimSpace(arg), "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				fmt.Fprintln(d.out, "Usage: e key=value, e.g. e payload.version=1.4.2")
+				continue
+			}
+			if err := setVariable(d.data, strings.TrimSpace(key), parseValue(value)); err != nil {
+				fmt.Fprintf(d.out, "Error: %v\n", err)
+				continue
+			}
+			d.show(act)
+		case "v", "vars":
+			vars, _ := json.MarshalIndent(d.data, "", "  ")
+			fmt.Fprintln(d.out, string(vars))
+		case "q", "quit":
+			return stepQuit
+		default:
+			fmt.Fprintf(d.out, "Unknown command '%s'\n", command)
+		}
+	}
+}
+
+// show prints the action as it would run with the current variables, without
+// the changes kv.incr and seen.add make when it runs
+func (d *debugger) show(act *workflow.Action) {
+	rendered, err := templating.PreviewAction(act, d.data)
+	if err != nil {
+		fmt.Fprintf(d.out, "  ✗ %v\n", err)
+		return
+	}
+
+	if rendered.When != "" {
+		fmt.Fprintf(d.out, "  When: %s\n", rendered.When)
+	}
+	switch rendered.Type {
+	case workflow.ActionTypeBash:
+		switch {
+		case len(rendered.Exec) > 0:
+			fmt.Fprintf(d.out, "  Exec: %q\n", rendered.Exec)
+		case rendered.ScriptFile != "":
+			fmt.Fprintf(d.out, "  Script: %s\n", rendered.ScriptFile)
+		default:
+			fmt.Fprintf(d.out, "  Command: %s\n", indentLines(rendered.Command))
+		}
+		if rendered.Shell != "" {
+			fmt.Fprintf(d.out, "  Shell: %s\n", rendered.Shell)
+		}
+		if rendered.Stdin != "" {
+			fmt.Fprintf(d.out, "  Stdin: %s\n", indentLines(rendered.Stdin))
+		}
+		if rendered.StdinFile != "" {
+			fmt.Fprintf(d.out, "  Stdin file: %s\n", rendered.StdinFile)
+		}
+		if rendered.WorkingDir != "" {
+			fmt.Fprintf(d.out, "  Working dir: %s\n", rendered.WorkingDir)
+		}
+		if rendered.RunAsUser != "" || rendered.RunAsGroup != "" {
+			fmt.Fprintf(d.out, "  Run as: %s\n", runAsLabel(rendered.RunAsUser, rendered.RunAsGroup))
+		}
+	case workflow.ActionTypeHTTP:
+		fmt.Fprintf(d.out, "  Request: %s %s\n", rendered.Method, rendered.URL)
+		if rendered.UnixSocket != "" {
+			fmt.Fprintf(d.out, "  Unix socket: %s\n", rendered.UnixSocket)
+		}
+		names := make([]string, 0, len(rendered.Headers))
+		for name := range rendered.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(d.out, "  Header: %s: %s\n", name, rendered.Headers[name])
+		}
+		if rendered.Body != "" {
+			fmt.Fprintf(d.out, "  Body: %s\n", indentLines(rendered.Body))
+		}
+		for _, name := range sortedKeys(rendered.FormData) {
+			fmt.Fprintf(d.out, "  Form field: %s=%s\n", name, rendered.FormData[name])
+		}
+		for _, name := range sortedKeys(rendered.Files) {
+			fmt.Fprintf(d.out, "  File: %s=%s\n", name, rendered.Files[name])
+		}
+	case workflow.ActionTypeKV:
+		operation := rendered.Operation
+		if operation == "" {
+			operation = "set"
+		}
+		fmt.Fprintf(d.out, "  %s %s %s\n", operation, rendered.Key, rendered.Value)
+	case workflow.ActionTypeVerify:
+		fmt.Fprintf(d.out, "  Manifest: %s\n", rendered.Manifest)
+	case workflow.ActionTypeDownload:
+		fmt.Fprintf(d.out, "  Download: %s -> %s\n", rendered.URL, rendered.Path)
+		if rendered.Checksum != "" {
+			fmt.Fprintf(d.out, "  Checksum: %s\n", rendered.Checksum)
+		}
+	case workflow.ActionTypeFile:
+		if rendered.Source != "" {
+			fmt.Fprintf(d.out, "  File: %s %s -> %s\n", rendered.Operation, rendered.Source, rendered.Path)
+		} else {
+			fmt.Fprintf(d.out, "  File: %s %s\n", rendered.Operation, rendered.Path)
+		}
+	case workflow.ActionTypeStorage:
+		if rendered.Operation == "upload" {
+			fmt.Fprintf(d.out, "  Upload: %s -> %s\n", rendered.Path, rendered.URL)
+		} else {
+			fmt.Fprintf(d.out, "  Download: %s -> %s\n", rendered.URL, rendered.Path)
+		}
+	case workflow.ActionTypeGit:
+		fmt.Fprintf(d.out, "  Git: %s %s %s\n", rendered.Operation, rendered.URL, rendered.Path)
+		if rendered.Git != nil && rendered.Git.Ref != "" {
+			fmt.Fprintf(d.out, "  Ref: %s\n", rendered.Git.Ref)
+		}
+	case workflow.ActionTypeTemplate:
+		fmt.Fprintf(d.out, "  Template: %s -> %s\n", rendered.Template, rendered.Path)
+		fmt.Fprintf(d.out, "  Content: %s\n", indentLines(rendered.Content))
+	case workflow.ActionTypeWait:
+		if rendered.Until != "" {
+			fmt.Fprintf(d.out, "  Wait until: %s\n", rendered.Until)
+		} else {
+			fmt.Fprintf(d.out, "  Wait: %s\n", rendered.Duration)
+		}
+	case workflow.ActionTypeApproval:
+		fmt.Fprintf(d.out, "  Approval: %s\n", indentLines(rendered.Message))
+	case workflow.ActionTypeWorkflow:
+		if rendered.WorkflowFile != "" {
+			fmt.Fprintf(d.out, "  Workflow: %s (wait %t)\n", rendered.WorkflowFile, rendered.WaitsForWorkflow())
+		} else {
+			fmt.Fprintf(d.out, "  Workflow: %s (wait %t)\n", rendered.Workflow, rendered.WaitsForWorkflow())
+		}
+		keys := make([]string, 0, len(rendered.Payload))
+		for key := range rendered.Payload {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(d.out, "  Payload: %s: %s\n", key, rendered.Payload[key])
+		}
+	case workflow.ActionTypeMQTT:
+		fmt.Fprintf(d.out, "  Publish: %s (qos %d, retain %t)\n", rendered.Topic, rendered.QoS, rendered.Retain)
+		fmt.Fprintf(d.out, "  Message: %s\n", rendered.Message)
+	case workflow.ActionTypeCustom:
+		fmt.Fprintf(d.out, "  Function: %s\n", rendered.FunctionName)
+	}
+}
+
+// runAsLabel formats the user and group a bash action runs as like chown,
+// e.g. "deploy:www-data" or ":www-data"
+func runAsLabel(user, group string) string {
+	if group == "" {
+		return user
+	}
+	return user + ":" + group
+}
+
+// execute runs the action and stores its result for later steps
+func (d *debugger) execute(index int, act *workflow.Action) stepOutcome {
+	output, err := executor.ExecuteAction(d.wf, act, index, d.data)
+
+	steps, ok := d.data["steps"].(map[string]interface{})
+	if !ok {
+		steps = map[string]interface{}{} // replaced through "e steps=..."
+		d.data["steps"] = steps
+	}
+	d.data["actions"] = steps
+	step := executor.StepResult(output, err)
+	if err == nil {
+		captured, ok := d.data["captured"].(map[string]interface{})
+		if !ok {
+			captured = map[string]interface{}{}
+			d.data["captured"] = captured
+		}
+		executor.CaptureResponse(act, output, step, captured)
+	}
+	steps[act.Name] = step
+
+	if output != "" {
+		fmt.Fprintf(d.out, "  Output:\n%s\n", indentBlock(strings.TrimRight(output, "\n"), "    "))
+	}
+	if err != nil {
+		fmt.Fprintf(d.out, "  ✗ Failed: %v\n", err)
+		return stepFailed
+	}
+	fmt.Fprintln(d.out, "  ✓ Success")
+	return stepRan
+}
+
+// setVariable sets a dotted key such as payload.version in data, creating
+// intermediate maps as needed
+func setVariable(data templating.Data, key string, value interface{}) error {
+	parts := strings.Split(key, ".")
+	current := map[string]interface{}(data)
+	for _, part := range parts[:len(parts)-1] {
+		next, exists := current[part]
+		if !exists {
+			created := map[string]interface{}{}
+			current[part] = created
+			current = created
+			continue
+		}
+		m, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("'%s' in '%s' is not an object", part, key)
+		}
+		current = m
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
+
+// parseValue parses JSON objects, arrays and quoted strings; anything else,
+// including numbers such as 2.0, is kept as the literal string
+func parseValue(s string) interface{} {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") || strings.HasPrefix(s, `"`) {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// indentLines keeps multi-line values readable below their label
+func indentLines(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+	return "\n" + indentBlock(s, "    ")
+}
+
+func indentBlock(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+
+	addDBFlags(debugCmd.Flags())
+	debugCmd.Flags().String("payload", "", "JSON object available to action templates as {{ .payload }}")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+)
+
+// maxDiffLines bounds the outputs compared line by line; longer outputs are
+// only reported as changed
+const maxDiffLines = 500
+
+var diffRunsCmd = &cobra.Command{
+	Use:   "diff-runs [execution-id] [execution-id]",
+	Short: "Compare two executions of the same workflow",
+	Long: `Compare two executions of the same workflow action by action: status,
+duration, output and errors. Useful to find out what changed since a workflow
+last worked.
+
+Examples:
+  autozap diff-runs 41 42
+  autozap diff-runs 41 42 --output json`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ids := make([]int64, 2)
+		for i, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid execution ID '%s'\n", arg)
+				return
+			}
+			ids[i] = id
+		}
+		format, err := getOutputFormat(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+
+		// Initialize database
+		if err := openDatabase(cmd); err != nil {
+			logger.L().Errorw("Failed to initialize database", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			return
+		}
+		defer database.CloseDB()
+
+		runs := make([]executionDetail, 2)
+		for i, id := range ids {
+			exec, err := database.GetWorkflowExecution(id)
+			if err != nil {
+				logger.L().Errorw("Failed to get workflow execution", "error", err, "workflow_exec_id", id)
+				fmt.Fprintf(os.Stderr, "Error: Failed to get workflow execution %d: %v\n", id, err)
+				return
+			}
+			actions, err := database.GetActionExecutions(id)
+			if err != nil {
+				logger.L().Errorw("Failed to get action executions", "error", err, "workflow_exec_id", id)
+				fmt.Fprintf(os.Stderr, "Error: Failed to get action executions of %d: %v\n", id, err)
+				return
+			}
+			runs[i] = executionDetail{WorkflowExecution: exec.In(timezone.Location()), Actions: displayActions(actions)}
+		}
+
+		if runs[0].WorkflowName != runs[1].WorkflowName {
+			fmt.Fprintf(os.Stderr, "Error: Executions %d and %d belong to different workflows ('%s' and '%s')\n",
+				ids[0], ids[1], runs[0].WorkflowName, runs[1].WorkflowName)
+			return
+		}
+
+		diff := diffRuns(runs[0], runs[1])
+
+		switch format {
+		case outputJSON:
+			err = printJSON(diff)
+		case outputCSV:
+			err = printCSV(actionDiffCSVHeader, actionDiffCSVRows(diff.Actions))
+		default:
+			printRunDiff(diff)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write output: %v\n", err)
+		}
+	},
+}
+
+// runDiff is the comparison of two executions of a workflow
+type runDiff struct {
+	WorkflowName string
+	Left         database.WorkflowExecution
+	Right        database.WorkflowExecution
+	Actions      []actionDiff
+}
+
+// actionDiff compares one action across two executions. Left or Right is nil
+// when the action only ran in one of them.
+type actionDiff struct {
+	ActionName       string
+	Left             *database.ActionExecution
+	Right            *database.ActionExecution
+	DurationChangeMs *int64
+	StatusChanged    bool
+	OutputChanged    bool
+	ErrorChanged     bool
+}
+
+// diffRuns matches actions by name, in the order of the left execution
+// followed by actions that only ran in the right one
+func diffRuns(left, right executionDetail) runDiff {
+	diff := runDiff{WorkflowName: left.WorkflowName, Left: left.WorkflowExecution, Right: right.WorkflowExecution}
+
+	rightByName := make(map[string]*database.ActionExecution, len(right.Actions))
+	for i := range right.Actions {
+		rightByName[right.Actions[i].ActionName] = &right.Actions[i]
+	}
+
+	seen := make(map[string]bool, len(left.Actions))
+	for i := range left.Actions {
+		l := &left.Actions[i]
+		seen[l.ActionName] = true
+		diff.Actions = append(diff.Actions, compareActions(l.ActionName, l, rightByName[l.ActionName]))
+	}
+	for i := range right.Actions {
+		r := &right.Actions[i]
+		if !seen[r.ActionName] {
+			diff.Actions = append(diff.Actions, compareActions(r.ActionName, nil, r))
+		}
+	}
+	return diff
+}
+
+func compareActions(name string, left, right *database.ActionExecution) actionDiff {
+	d := actionDiff{ActionName: name, Left: left, Right: right}
+	if left == nil || right == nil {
+		d.StatusChanged = true
+		return d
+	}
+
+	if left.DurationMs != nil && right.DurationMs != nil {
+		change := *right.DurationMs - *left.DurationMs
+		d.DurationChangeMs = &change
+	}
+	d.StatusChanged = left.Status != right.Status
+	d.OutputChanged = optionalString(left.Output) != optionalString(right.Output)
+	d.ErrorChanged = optionalString(left.Error) != optionalString(right.Error)
+	return d
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+var actionDiffCSVHeader = []string{"action_name", "left_status", "right_status", "left_duration_ms", "right_duration_ms", "duration_change_ms", "output_changed", "left_error", "right_error"}
+
+// actionDiffCSVRows converts action comparisons to CSV rows matching actionDiffCSVHeader
+func actionDiffCSVRows(actions []actionDiff) [][]string {
+	rows := make([][]string, 0, len(actions))
+	for _, d := range actions {
+		var leftStatus, rightStatus, leftDuration, rightDuration, leftError, rightError string
+		if d.Left != nil {
+			leftStatus, leftDuration, leftError = d.Left.Status, csvOptionalInt(d.Left.DurationMs), csvOptionalString(d.Left.Error)
+		}
+		if d.Right != nil {
+			rightStatus, rightDuration, rightError = d.Right.Status, csvOptionalInt(d.Right.DurationMs), csvOptionalString(d.Right.Error)
+		}
+		rows = append(rows, []string{
+			d.ActionName,
+			leftStatus,
+			rightStatus,
+			leftDuration,
+			rightDuration,
+			csvOptionalInt(d.DurationChangeMs),
+			strconv.FormatBool(d.OutputChanged),
+			leftError,
+			rightError,
+		})
+	}
+	return rows
+}
+
+// printRunDiff prints both executions side by side, then the output and
+// error changes of each action
+func printRunDiff(diff runDiff) {
+	fmt.Printf("\nWorkflow: %s\n\n", diff.WorkflowName)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t#%d\t#%d\n", diff.Left.ID, diff.Right.ID)
+	fmt.Fprintf(w, "Status\t%s\t%s\n", formatStatus(diff.Left.Status), formatStatus(diff.Right.Status))
+	fmt.Fprintf(w, "Trigger\t%s\t%s\n", diff.Left.TriggerType, diff.Right.TriggerType)
+	fmt.Fprintf(w, "Started\t%s\t%s\n", timezone.Format(diff.Left.StartedAt), timezone.Format(diff.Right.StartedAt))
+	fmt.Fprintf(w, "Duration\t%s\t%s\n", formatDurationMs(diff.Left.DurationMs), formatDurationMs(diff.Right.DurationMs))
+	fmt.Fprintf(w, "Error\t%s\t%s\n", formatOptional(diff.Left.Error, 50), formatOptional(diff.Right.Error, 50))
+	w.Flush()
+	fmt.Println()
+
+	if len(diff.Actions) == 0 {
+		fmt.Println("No action executions recorded.")
+		return
+	}
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ACTION\tSTATUS #%d\tSTATUS #%d\tDURATION #%d\tDURATION #%d\tCHANGE\tOUTPUT\n", diff.Left.ID, diff.Right.ID, diff.Left.ID, diff.Right.ID)
+	fmt.Fprintln(w, "------\t---------\t---------\t-----------\t-----------\t------\t------")
+	for _, d := range diff.Actions {
+		output := "same"
+		if d.OutputChanged {
+			output = "changed"
+		}
+		if d.Left == nil || d.Right == nil {
+			output = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.ActionName,
+			actionStatus(d.Left),
+			actionStatus(d.Right),
+			actionDuration(d.Left),
+			actionDuration(d.Right),
+			formatDurationChange(d.DurationChangeMs),
+			output,
+		)
+	}
+	w.Flush()
+
+	for _, d := range diff.Actions {
+		if d.Left == nil || d.Right == nil || (!d.OutputChanged && !d.ErrorChanged) {
+			continue
+		}
+		fmt.Printf("\n%s:\n", d.ActionName)
+		if d.ErrorChanged {
+			fmt.Printf("  Error #%d: %s\n", diff.Left.ID, formatOptional(d.Left.Error, 200))
+			fmt.Printf("  Error #%d: %s\n", diff.Right.ID, formatOptional(d.Right.Error, 200))
+		}
+		if d.OutputChanged {
+			fmt.Printf("  Output (- #%d, + #%d):\n", diff.Left.ID, diff.Right.ID)
+			for _, line := range diffLines(optionalString(d.Left.Output), optionalString(d.Right.Output)) {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+func actionStatus(act *database.ActionExecution) string {
+	if act == nil {
+		return "(not run)"
+	}
+	return formatStatus(act.Status)
+}
+
+func actionDuration(act *database.ActionExecution) string {
+	if act == nil {
+		return "-"
+	}
+	return formatDurationMs(act.DurationMs)
+}
+
+// formatDurationChange renders a duration change with its sign, e.g. +120ms
+func formatDurationChange(changeMs *int64) string {
+	if changeMs == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+dms", *changeMs)
+}
+
+// diffLines returns the lines removed from a ("- ") and added in b ("+ "),
+// based on their longest common subsequence. Outputs longer than
+// maxDiffLines are not compared line by line.
+func diffLines(a, b string) []string {
+	left := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	right := strings.Split(strings.TrimRight(b, "\n"), "\n")
+	if len(left) > maxDiffLines || len(right) > maxDiffLines {
+		return []string{fmt.Sprintf("(output too long to compare: %d and %d lines)", len(left), len(right))}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of left[i:] and right[j:]
+	lcs := make([][]int, len(left)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(left) || j < len(right) {
+		switch {
+		case i < len(left) && j < len(right) && left[i] == right[j]:
+			i++
+			j++
+		case i < len(left) && (j == len(right) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+left[i])
+			i++
+		default:
+			lines = append(lines, "+ "+right[j])
+			j++
+		}
+	}
+	return lines
+}
+
+func init() {
+	rootCmd.AddCommand(diffRunsCmd)
+
+	addDBFlags(diffRunsCmd.Flags())
+	addOutputFlag(diffRunsCmd)
+}
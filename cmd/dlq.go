@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "Inspect and replay failed runs in the dead-letter queue",
+	Long: `Runs that fail once their actions' retries are exhausted are added to the
+dead-letter queue with the workflow definition they ran with, the data they
+were triggered with (event, payload, files) and the inputs and results of
+their actions. Replaying an entry re-runs the workflow with the same trigger
+data; a successful replay resolves it.
+
+Examples:
+  autozap dlq list
+  autozap dlq list --workflow nightly-backup --all
+  autozap dlq show 12
+  autozap dlq replay 12
+  autozap dlq replay 12 --workflow workflows/nightly-backup.yaml`,
+}
+
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List unresolved dead letters",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		workflowName, _ := cmd.Flags().GetString("workflow")
+		all, _ := cmd.Flags().GetBool("all")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		if !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		letters, err := database.ListDeadLetters(workflowName, all, limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if len(letters) == 0 {
+			fmt.Println("✓ The dead-letter queue is empty.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tWORKFLOW\tEXECUTION\tTRIGGER\tFAILED\tFAILED ACTIONS\tREPLAYS\tERROR")
+		fmt.Fprintln(w, "--\t--------\t---------\t-------\t------\t--------------\t-------\t-----")
+		for _, letter := range letters {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+				letter.ID,
+				letter.WorkflowName,
+				letter.WorkflowExecutionID,
+				letter.TriggerType,
+				timezone.Format(letter.CreatedAt),
+				strings.Join(letter.FailedActions, ", "),
+				formatReplays(letter),
+				formatOptional(letter.Error, 60),
+			)
+		}
+		w.Flush()
+	},
+}
+
+var dlqShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show a dead letter with its trigger data and action results",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, ok := parseDeadLetterID(args[0])
+		if !ok || !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		letter, err := database.GetDeadLetter(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Dead letter #%d\n\n", letter.ID)
+		fmt.Printf("  Workflow:        %s\n", letter.WorkflowName)
+		fmt.Printf("  Execution:       %d\n", letter.WorkflowExecutionID)
+		fmt.Printf("  Trigger:         %s\n", letter.TriggerType)
+		fmt.Printf("  Failed:          %s\n", timezone.Format(letter.CreatedAt))
+		fmt.Printf("  Failed actions:  %s\n", strings.Join(letter.FailedActions, ", "))
+		if letter.Error != nil {
+			fmt.Printf("  Error:           %s\n", *letter.Error)
+		}
+		fmt.Printf("  Replays:         %s\n", formatReplays(*letter))
+		if letter.ReplayExecutionID != nil {
+			fmt.Printf("  Last replay:     execution %d at %s\n", *letter.ReplayExecutionID, timezone.Format(*letter.ReplayedAt))
+		}
+		fmt.Printf("\nTrigger data:\n%s\n", indentJSON(letter.TriggerData))
+		fmt.Printf("\nAction results:\n%s\n", indentJSON(letter.Steps))
+		fmt.Printf("\nWorkflow definition:\n%s", letter.WorkflowDefinition)
+	},
+}
+
+var dlqReplayCmd = &cobra.Command{
+	Use:   "replay [id]",
+	Short: "Re-run a dead letter with its original trigger data",
+	Long: `Re-run the workflow of a dead letter with the data it was originally triggered
+with. By default the workflow definition stored with the dead letter is used;
+pass --workflow to replay with the current version of the file instead, e.g.
+after fixing the cause of the failure.
+
+The replay is recorded in the execution history with the 'replay' trigger
+type, and its outcome on the dead letter. Actions can read the dead letter as
+{{ .replay.deadLetterId }}, {{ .replay.workflowExecutionId }} and
+{{ .replay.failedActions }}. The exit code is 0 on success, 2 on
+partial-success and 1 otherwise.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workflowFile, _ := cmd.Flags().GetString("workflow")
+
+		id, ok := parseDeadLetterID(args[0])
+		if !ok || !initKVDB(cmd) {
+			return
+		}
+		defer closeDatabase()
+
+		letter, err := database.GetDeadLetter(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			closeDatabase()
+			os.Exit(1)
+		}
+
+		var wf *workflow.Workflow
+		if workflowFile != "" {
+			wf, err = parser.ParseWorkflowFile(workflowFile)
+		} else {
+			wf, err = parser.ParseWorkflow([]byte(letter.WorkflowDefinition), fmt.Sprintf("dead letter #%d", letter.ID))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			closeDatabase()
+			os.Exit(1)
+		}
+		if wf.Name != letter.WorkflowName {
+			logger.L().Warnw("Replaying dead letter with a different workflow",
+				"dead_letter_id", letter.ID,
+				"dead_letter_workflow", letter.WorkflowName,
+				"workflow_name", wf.Name)
+		}
+
+		data, err := executor.ReplayData(letter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			closeDatabase()
+			os.Exit(1)
+		}
+
+		logger.L().Infow("Replaying dead letter",
+			"dead_letter_id", letter.ID,
+			"workflow_name", wf.Name,
+			"original_workflow_exec_id", letter.WorkflowExecutionID)
+
+		summary := executor.ExecuteAndSummarize(wf, executor.TriggerTypeReplay, data)
+		printRunSummary(summary)
+		if code := exitCode(summary.Status); code != 0 {
+			closeDatabase()
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dlqCmd)
+	dlqCmd.AddCommand(dlqListCmd, dlqShowCmd, dlqReplayCmd)
+
+	addDBFlags(dlqCmd.PersistentFlags())
+	dlqListCmd.Flags().String("workflow", "", "Only list dead letters of this workflow")
+	dlqListCmd.Flags().Bool("all", false, "Include dead letters resolved by a successful replay")
+	dlqListCmd.Flags().Int("limit", 50, "Maximum number of dead letters to list")
+	dlqReplayCmd.Flags().String("workflow", "", "Replay with this workflow file instead of the stored definition")
+}
+
+func parseDeadLetterID(arg string) (int64, bool) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid dead letter ID '%s'\n", arg)
+		return 0, false
+	}
+	return id, true
+}
+
+// indentJSON pretty-prints stored JSON, or returns it as is if it is invalid
+func indentJSON(s string) string {
+	if s == "" {
+		return "  -"
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(s), "  ", "  "); err != nil {
+		return "  " + s
+	}
+	return "  " + out.String()
+}
+
+// formatReplays describes the replays of a dead letter, e.g. "2 (failed)"
+func formatReplays(letter database.DeadLetter) string {
+	if letter.ReplayStatus == nil {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%s)", letter.ReplayCount, formatStatus(*letter.ReplayStatus))
+}
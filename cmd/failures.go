@@ -8,20 +8,25 @@ import (
 
 	"github.com/codecrafted007/autozap/internal/database"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/timezone"
 	"github.com/spf13/cobra"
 )
 
 var failuresCmd = &cobra.Command{
 	Use:   "failures",
 	Short: "Show recent failed workflow executions",
-	Long:  `Display recently failed workflow executions with error details.`,
+	Long:  `Display recently failed or timed out workflow executions with error details.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		hours, _ := cmd.Flags().GetInt("hours")
 		limit, _ := cmd.Flags().GetInt("limit")
+		format, err := getOutputFormat(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
 
 		// Initialize database
-		dbPath, _ := cmd.Flags().GetString("db")
-		if err := database.InitDB(dbPath); err != nil {
+		if err := openDatabase(cmd); err != nil {
 			logger.L().Errorw("Failed to initialize database", "error", err)
 			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
 			return
@@ -35,6 +40,20 @@ var failuresCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "Error: Failed to get failed executions: %v\n", err)
 			return
 		}
+		failures = displayExecutions(failures)
+
+		switch format {
+		case outputJSON:
+			if err := printJSON(failures); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to write output: %v\n", err)
+			}
+			return
+		case outputCSV:
+			if err := printCSV(executionCSVHeader, executionCSVRows(failures)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to write output: %v\n", err)
+			}
+			return
+		}
 
 		if len(failures) == 0 {
 			fmt.Printf("✓ No failures found in the last %d hours.\n", hours)
@@ -45,8 +64,8 @@ var failuresCmd = &cobra.Command{
 
 		// Print table
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tWORKFLOW\tTRIGGER\tSTARTED\tERROR")
-		fmt.Fprintln(w, "---\t--------\t-------\t-------\t-----")
+		fmt.Fprintln(w, "ID\tWORKFLOW\tSTATUS\tTRIGGER\tSTARTED\tERROR")
+		fmt.Fprintln(w, "---\t--------\t------\t-------\t-------\t-----")
 
 		for _, exec := range failures {
 			errorMsg := "unknown error"
@@ -54,11 +73,12 @@ var failuresCmd = &cobra.Command{
 				errorMsg = truncateFailure(*exec.Error, 80)
 			}
 
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
 				exec.ID,
 				exec.WorkflowName,
+				formatStatus(exec.Status),
 				exec.TriggerType,
-				exec.StartedAt.Format("2006-01-02 15:04:05"),
+				timezone.Format(exec.StartedAt),
 				errorMsg,
 			)
 		}
@@ -72,7 +92,8 @@ func init() {
 
 	failuresCmd.Flags().Int("hours", 24, "Show failures from last N hours")
 	failuresCmd.Flags().Int("limit", 50, "Maximum number of failures to show")
-	failuresCmd.Flags().String("db", "./data/autozap.db", "Database file path")
+	addDBFlags(failuresCmd.Flags())
+	addOutputFlag(failuresCmd)
 }
 
 func truncateFailure(s string, maxLen int) string {
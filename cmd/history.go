@@ -3,10 +3,17 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/i18n"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -17,18 +24,27 @@ var historyCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		workflowName, _ := cmd.Flags().GetString("workflow")
 		limit, _ := cmd.Flags().GetInt("limit")
+		showActions, _ := cmd.Flags().GetBool("actions")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		format, err := getOutputFormat(cmd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error", err))
+			return
+		}
+		if showActions && format == outputCSV {
+			fmt.Fprintln(os.Stderr, i18n.T("history.actions_csv"))
+			return
+		}
 
 		// Initialize database
-		dbPath, _ := cmd.Flags().GetString("db")
-		if err := database.InitDB(dbPath); err != nil {
+		if err := openDatabase(cmd); err != nil {
 			logger.L().Errorw("Failed to initialize database", "error", err)
-			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			fmt.Fprintln(os.Stderr, i18n.T("error.db_init", err))
 			return
 		}
 		defer database.CloseDB()
 
 		var executions []database.WorkflowExecution
-		var err error
 
 		if workflowName != "" {
 			executions, err = database.GetWorkflowHistory(workflowName, limit)
@@ -38,58 +54,335 @@ var historyCmd = &cobra.Command{
 
 		if err != nil {
 			logger.L().Errorw("Failed to get workflow history", "error", err)
-			fmt.Fprintf(os.Stderr, "Error: Failed to get workflow history: %v\n", err)
+			fmt.Fprintln(os.Stderr, i18n.T("history.get_failed", err))
 			return
 		}
+		executions = displayExecutions(executions)
 
-		if len(executions) == 0 {
-			fmt.Println("No execution history found.")
-			return
+		// Load action executions once for all output formats
+		var actionsByExec map[int64][]database.ActionExecution
+		if showActions {
+			actionsByExec = make(map[int64][]database.ActionExecution, len(executions))
+			for _, exec := range executions {
+				actions, err := database.GetActionExecutions(exec.ID)
+				if err != nil {
+					logger.L().Errorw("Failed to get action executions", "error", err, "workflow_exec_id", exec.ID)
+					continue
+				}
+				actionsByExec[exec.ID] = displayActions(actions)
+			}
 		}
 
-		// Print table
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "ID\tWORKFLOW\tSTATUS\tTRIGGER\tSTARTED\tDURATION\tERROR")
-		fmt.Fprintln(w, "---\t--------\t------\t-------\t-------\t--------\t-----")
-
-		for _, exec := range executions {
-			duration := "-"
-			if exec.DurationMs != nil {
-				duration = fmt.Sprintf("%dms", *exec.DurationMs)
+		switch format {
+		case outputJSON:
+			if !showActions {
+				err = printJSON(executions)
+				break
 			}
-
-			errorMsg := "-"
-			if exec.Error != nil {
-				errorMsg = truncate(*exec.Error, 50)
+			detailed := make([]executionDetail, 0, len(executions))
+			for _, exec := range executions {
+				detailed = append(detailed, executionDetail{WorkflowExecution: exec, Actions: actionsByExec[exec.ID]})
 			}
+			err = printJSON(detailed)
+		case outputCSV:
+			err = printCSV(executionCSVHeader, executionCSVRows(executions))
+		default:
+			printHistoryTable(executions, actionsByExec, verbose)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error.write_output", err))
+		}
+	},
+}
 
-			status := exec.Status
-			if status == "success" {
-				status = "✓ " + status
-			} else if status == "failed" {
-				status = "✗ " + status
-			}
+// executionDetail is a workflow execution together with its action executions
+type executionDetail struct {
+	database.WorkflowExecution
+	Actions []database.ActionExecution `json:"actions"`
+}
+
+var executionCSVHeader = []string{"id", "workflow_name", "status", "trigger_type", "started_at", "completed_at", "duration_ms", "error"}
+
+// executionCSVRows converts workflow executions to CSV rows matching executionCSVHeader
+func executionCSVRows(executions []database.WorkflowExecution) [][]string {
+	rows := make([][]string, 0, len(executions))
+	for _, exec := range executions {
+		completedAt := ""
+		if exec.CompletedAt != nil {
+			completedAt = exec.CompletedAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(exec.ID, 10),
+			exec.WorkflowName,
+			exec.Status,
+			exec.TriggerType,
+			exec.StartedAt.Format(time.RFC3339),
+			completedAt,
+			csvOptionalInt(exec.DurationMs),
+			csvOptionalString(exec.Error),
+		})
+	}
+	return rows
+}
 
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				exec.ID,
-				exec.WorkflowName,
-				status,
-				exec.TriggerType,
-				exec.StartedAt.Format("2006-01-02 15:04:05"),
-				duration,
-				errorMsg,
+// printHistoryTable prints workflow executions, and optionally their actions
+// and what triggered them, as a table
+func printHistoryTable(executions []database.WorkflowExecution, actionsByExec map[int64][]database.ActionExecution, verbose bool) {
+	if len(executions) == 0 {
+		fmt.Println(i18n.T("history.empty"))
+		return
+	}
+
+	// Print table
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := i18n.T("history.header")
+	if verbose {
+		header += "\t" + i18n.T("history.header_source")
+	}
+	printTableHeader(w, header)
+
+	for _, exec := range executions {
+		errorMsg := "-"
+		if exec.Error != nil {
+			errorMsg = truncate(*exec.Error, 50)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s",
+			exec.ID,
+			exec.WorkflowName,
+			formatStatus(exec.Status),
+			exec.TriggerType,
+			timezone.Format(exec.StartedAt),
+			formatDurationMs(exec.DurationMs),
+			errorMsg,
+		)
+		if verbose {
+			fmt.Fprintf(w, "\t%s", formatTriggerSource(exec.TriggerSource))
+		}
+		fmt.Fprintln(w)
+
+		for _, act := range actionsByExec[exec.ID] {
+			fmt.Fprintf(w, "\t  └ %s\t%s\t%s\t\t%s\t%s",
+				act.ActionName,
+				formatStatus(act.Status),
+				act.ActionType,
+				formatDurationMs(act.DurationMs),
+				formatOptional(act.Error, 50),
 			)
+			if verbose {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	w.Flush()
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show [execution-id]",
+	Short: "Show the actions of a single workflow execution",
+	Long: `Display a workflow execution together with the status, duration and
+truncated output of each of its actions.
+
+Example:
+  autozap history show 42`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		execID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("history.invalid_id", args[0]))
+			return
+		}
+		outputLen, _ := cmd.Flags().GetInt("output-length")
+		format, err := getOutputFormat(cmd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error", err))
+			return
+		}
+
+		// Initialize database
+		if err := openDatabase(cmd); err != nil {
+			logger.L().Errorw("Failed to initialize database", "error", err)
+			fmt.Fprintln(os.Stderr, i18n.T("error.db_init", err))
+			return
+		}
+		defer database.CloseDB()
+
+		exec, err := database.GetWorkflowExecution(execID)
+		if err != nil {
+			logger.L().Errorw("Failed to get workflow execution", "error", err)
+			fmt.Fprintln(os.Stderr, i18n.T("history.get_execution_failed", err))
+			return
+		}
+		*exec = exec.In(timezone.Location())
+
+		actions, err := database.GetActionExecutions(execID)
+		if err != nil {
+			logger.L().Errorw("Failed to get action executions", "error", err)
+			fmt.Fprintln(os.Stderr, i18n.T("history.get_actions_failed", err))
+			return
+		}
+		actions = displayActions(actions)
+
+		switch format {
+		case outputJSON:
+			err = printJSON(executionDetail{WorkflowExecution: *exec, Actions: actions})
+		case outputCSV:
+			err = printCSV(actionCSVHeader, actionCSVRows(actions))
+		default:
+			printExecutionDetail(exec, actions, outputLen)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error.write_output", err))
 		}
-		w.Flush()
 	},
 }
 
+var actionCSVHeader = []string{"id", "workflow_execution_id", "action_name", "action_type", "status", "started_at", "duration_ms", "error", "output"}
+
+// actionCSVRows converts action executions to CSV rows matching actionCSVHeader
+func actionCSVRows(actions []database.ActionExecution) [][]string {
+	rows := make([][]string, 0, len(actions))
+	for _, act := range actions {
+		rows = append(rows, []string{
+			strconv.FormatInt(act.ID, 10),
+			strconv.FormatInt(act.WorkflowExecutionID, 10),
+			act.ActionName,
+			act.ActionType,
+			act.Status,
+			act.StartedAt.Format(time.RFC3339),
+			csvOptionalInt(act.DurationMs),
+			csvOptionalString(act.Error),
+			csvOptionalString(act.Output),
+		})
+	}
+	return rows
+}
+
+// printExecutionDetail prints a workflow execution header followed by a table of its actions
+func printExecutionDetail(exec *database.WorkflowExecution, actions []database.ActionExecution, outputLen int) {
+	fmt.Printf("\n%s\n\n", i18n.T("history.execution", exec.ID, exec.WorkflowName))
+
+	// Labels differ in length between languages, so align the values
+	details := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.status"), formatStatus(exec.Status))
+	fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.trigger"), exec.TriggerType)
+	if len(exec.TriggerSource) > 0 {
+		fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.source"), formatTriggerSource(exec.TriggerSource))
+	}
+	fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.started"), timezone.Format(exec.StartedAt))
+	fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.duration"), formatDurationMs(exec.DurationMs))
+	if exec.ActionsSucceeded+exec.ActionsFailed > 0 {
+		fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.actions"), i18n.T("history.actions_summary", exec.ActionsSucceeded, exec.ActionsFailed))
+	}
+	if exec.Error != nil {
+		fmt.Fprintf(details, "  %s\t%s\n", i18n.T("history.error"), *exec.Error)
+	}
+	details.Flush()
+	fmt.Println()
+
+	if len(actions) == 0 {
+		fmt.Println(i18n.T("history.no_actions"))
+		return
+	}
+
+	// Print table
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	printTableHeader(w, i18n.T("history.actions_header"))
+
+	for i, act := range actions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i+1,
+			act.ActionName,
+			act.ActionType,
+			formatStatus(act.Status),
+			formatDurationMs(act.DurationMs),
+			formatOptional(act.Output, outputLen),
+			formatOptional(act.Error, 50),
+		)
+	}
+	w.Flush()
+	fmt.Println()
+
+	for i, act := range actions {
+		if act.ScriptHash != nil {
+			fmt.Printf("  %s\n", i18n.T("history.script", i+1, act.ActionName, *act.ScriptHash))
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
 
 	historyCmd.Flags().String("workflow", "", "Filter by workflow name")
 	historyCmd.Flags().Int("limit", 20, "Maximum number of records to show")
-	historyCmd.Flags().String("db", "./data/autozap.db", "Database file path")
+	historyCmd.Flags().Bool("actions", false, "Show the individual actions of each execution")
+	historyCmd.Flags().Bool("verbose", false, "Show what triggered each execution")
+	addDBFlags(historyCmd.PersistentFlags())
+
+	addOutputFlag(historyCmd)
+
+	historyShowCmd.Flags().Int("output-length", 60, "Maximum number of output characters to show per action")
+	addOutputFlag(historyShowCmd)
+}
+
+// formatTriggerSource formats what triggered an execution as sorted key=value pairs
+func formatTriggerSource(source map[string]string) string {
+	if len(source) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(source))
+	for k := range source {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+source[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// formatStatus prefixes a status with a marker for what happened: succeeded,
+// partly succeeded, failed, was cancelled or aborted, didn't start or is still
+// running
+func formatStatus(status string) string {
+	switch {
+	case status == workflow.StatusSuccess:
+		return "✓ " + status
+	case status == workflow.StatusPartialSuccess:
+		return "◐ " + status
+	case workflow.IsFailure(status):
+		return "✗ " + status
+	case status == workflow.StatusCancelled || status == workflow.StatusAborted:
+		return "⊘ " + status
+	case status == workflow.StatusRunning:
+		return "… " + status
+	case !workflow.WasStarted(status):
+		return "– " + status
+	}
+	return status
+}
+
+// formatDurationMs renders an optional millisecond duration
+func formatDurationMs(durationMs *int64) string {
+	if durationMs == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", *durationMs)
+}
+
+// formatOptional renders an optional string on a single line, truncated to maxLen
+func formatOptional(s *string, maxLen int) string {
+	if s == nil {
+		return "-"
+	}
+	line := strings.Join(strings.Fields(*s), " ")
+	if line == "" {
+		return "-"
+	}
+	return truncate(line, maxLen)
 }
 
 func truncate(s string, maxLen int) string {
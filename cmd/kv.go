@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+)
+
+var kvCmd = &cobra.Command{
+	Use:   "kv",
+	Short: "Inspect and modify the workflow key-value store",
+	Long: `The key-value store persists small values between workflow runs, e.g. the
+last processed record ID of an incremental job.
+
+Workflows read values with the {{ kv.get "key" }} template function, count
+with {{ kv.incr "key" }}, deduplicate with {{ seen.add "key" "24h" }} and
+{{ seen.has "key" }}, and write values with 'type: kv' actions. These commands
+let you inspect and seed the store or update it from bash actions.
+
+Examples:
+  autozap kv list
+  autozap kv get lastProcessedId
+  autozap kv set lastProcessedId 1042
+  autozap kv incr alerts.sent
+  autozap kv delete lastProcessedId`,
+}
+
+var kvGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print the value stored under a key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		value, found, err := database.GetKV(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "Key '%s' not found\n", args[0])
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	},
+}
+
+var kvSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Store a value under a key",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		if err := database.SetKV(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	},
+}
+
+var kvIncrCmd = &cobra.Command{
+	Use:   "incr [key] [delta]",
+	Short: "Atomically increment a counter and print the new value",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		delta := int64(1)
+		if len(args) == 2 {
+			var err error
+			if delta, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid delta '%s'\n", args[1])
+				return
+			}
+		}
+
+		if !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		value, err := database.IncrKV(args[0], delta)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		fmt.Println(value)
+	},
+}
+
+var kvDeleteCmd = &cobra.Command{
+	Use:   "delete [key]",
+	Short: "Remove a key from the store",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		if err := database.DeleteKV(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	},
+}
+
+var kvListCmd = &cobra.Command{
+	Use:   "list [prefix]",
+	Short: "List stored keys and values",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+
+		if !initKVDB(cmd) {
+			return
+		}
+		defer database.CloseDB()
+
+		entries, err := database.ListKV(prefix)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No keys found.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tUPDATED")
+		fmt.Fprintln(w, "---\t-----\t-------")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Key, truncate(entry.Value, 60), timezone.Format(entry.UpdatedAt))
+		}
+		w.Flush()
+	},
+}
+
+// initKVDB opens the database selected by the --db flag
+func initKVDB(cmd *cobra.Command) bool {
+	if err := openDatabase(cmd); err != nil {
+		logger.L().Errorw("Failed to initialize database", "error", err)
+		fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+		return false
+	}
+	return true
+}
+
+func init() {
+	rootCmd.AddCommand(kvCmd)
+	kvCmd.AddCommand(kvGetCmd, kvSetCmd, kvIncrCmd, kvDeleteCmd, kvListCmd)
+
+	addDBFlags(kvCmd.PersistentFlags())
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/pidfile"
+)
+
+// workflowDirLockFile is the lock file an agent keeps in its workflow
+// directory. It is hidden, so it isn't loaded or watched as a workflow.
+const workflowDirLockFile = ".autozap.lock"
+
+// lockAgent locks the workflow directory and, with SQLite, the database file,
+// so a second agent started on either doesn't run the workflows twice. It
+// returns a *pidfile.HeldError if another agent holds one of the locks,
+// unless force is set, in which case that lock is skipped with a warning. A
+// read-only workflow directory, e.g. a mounted ConfigMap, isn't locked.
+// release removes the lock files taken.
+func lockAgent(workflowDir, dbDriver, dbPath string, force bool) (release func(), err error) {
+	var locks []*pidfile.Lock
+	release = func() {
+		for _, l := range locks {
+			l.Release()
+		}
+	}
+
+	type lockFile struct {
+		path     string
+		optional bool // not locked if the file can't be created
+	}
+	var files []lockFile
+	if info, err := os.Stat(workflowDir); err == nil && info.IsDir() {
+		files = append(files, lockFile{path: filepath.Join(workflowDir, workflowDirLockFile), optional: true})
+	}
+	if dbDriver == database.DriverSQLite || dbDriver == "sqlite3" || dbDriver == "" {
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+			return release, err
+		}
+		files = append(files, lockFile{path: dbPath + ".lock"})
+	}
+
+	for _, f := range files {
+		lock, err := pidfile.Acquire(f.path)
+		var held *pidfile.HeldError
+		switch {
+		case errors.As(err, &held) && force:
+			logger.L().Warnw("Another agent holds the lock, starting anyway (--force)",
+				"lock_file", f.path,
+				"pid", held.PID,
+			)
+		case errors.As(err, &held):
+			release()
+			return func() {}, err
+		case err != nil && f.optional:
+			logger.L().Warnw("Cannot create lock file, not locking the workflow directory",
+				"lock_file", f.path,
+				"error", err,
+			)
+		case err != nil:
+			release()
+			return func() {}, err
+		default:
+			locks = append(locks, lock)
+		}
+	}
+	return release, nil
+}
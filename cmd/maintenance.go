@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Put the agent in or out of maintenance mode",
+	Long: `Maintenance mode suppresses every trigger of the agent while its HTTP server
+and dashboard keep running, e.g. during a host maintenance window. Manual
+triggers still run.
+
+With the 'drop' policy runs triggered during maintenance are recorded as
+skipped; with 'queue' they are recorded as deferred and run when maintenance
+ends. Maintenance ends automatically after its TTL.
+
+Examples:
+  autozap maintenance on --ttl 2h --reason "kernel upgrade"
+  autozap maintenance on --policy queue
+  autozap maintenance status
+  autozap maintenance off`,
+}
+
+var maintenanceOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Suppress the agent's triggers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		policy, _ := cmd.Flags().GetString("policy")
+		reason, _ := cmd.Flags().GetString("reason")
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"enabled": true,
+			"ttl":     ttl.String(),
+			"policy":  policy,
+			"reason":  reason,
+		})
+		state := callMaintenanceAPI(cmd, http.MethodPost, body)
+		printMaintenanceState(state)
+	},
+}
+
+var maintenanceOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "End maintenance mode and run the queued triggers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		state := callMaintenanceAPI(cmd, http.MethodPost, []byte(`{"enabled": false}`))
+		printMaintenanceState(state)
+	},
+}
+
+var maintenanceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the agent is in maintenance mode",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		state := callMaintenanceAPI(cmd, http.MethodGet, nil)
+		printMaintenanceState(state)
+	},
+}
+
+// callMaintenanceAPI calls /api/agent/maintenance on the agent and returns
+// its maintenance state, exiting on errors
+func callMaintenanceAPI(cmd *cobra.Command, method string, body []byte) server.MaintenanceState {
+	agentURL := agentBaseURL(cmd)
+	endpoint := strings.TrimRight(agentURL, "/") + "/api/agent/maintenance"
+
+	req, err := newAgentRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to reach agent at %s: %v\n", agentURL, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		fmt.Fprintf(os.Stderr, "Error: agent returned %s: %s\n", resp.Status, strings.TrimSpace(string(respBody)))
+		os.Exit(1)
+	}
+
+	var state server.MaintenanceState
+	if err := json.Unmarshal(respBody, &state); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unexpected response from agent: %v\n", err)
+		os.Exit(1)
+	}
+	return state
+}
+
+func printMaintenanceState(state server.MaintenanceState) {
+	if !state.Enabled {
+		fmt.Println("✓ Agent is not in maintenance mode")
+		return
+	}
+
+	fmt.Println("🔧 Agent is in maintenance mode")
+	if state.Reason != "" {
+		fmt.Printf("  Reason:  %s\n", state.Reason)
+	}
+	fmt.Printf("  Policy:  %s\n", state.Policy)
+	if state.Since != nil {
+		fmt.Printf("  Since:   %s\n", timezone.Format(*state.Since))
+	}
+	if state.Until != nil {
+		fmt.Printf("  Until:   %s (in %s)\n", timezone.Format(*state.Until), time.Until(*state.Until).Round(time.Second))
+	}
+	if state.Policy == server.MaintenanceQueue {
+		fmt.Printf("  Queued:  %d runs\n", state.Queued)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(maintenanceCmd)
+	maintenanceCmd.AddCommand(maintenanceOnCmd, maintenanceOffCmd, maintenanceStatusCmd)
+
+	maintenanceCmd.PersistentFlags().String("url", "http://localhost:8080", "Base URL of the agent's HTTP server")
+	maintenanceOnCmd.Flags().Duration("ttl", server.DefaultMaintenanceTTL, "End maintenance mode automatically after this duration")
+	maintenanceOnCmd.Flags().String("policy", server.MaintenanceDrop, "What to do with triggered runs: drop or queue")
+	maintenanceOnCmd.Flags().String("reason", "", "Why the agent is in maintenance, shown in status and logs")
+}
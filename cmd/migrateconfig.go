@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/migrate"
+	"github.com/spf13/cobra"
+)
+
+var migrateConfigCmd = &cobra.Command{
+	Use:   "migrate-config [workflow_files...]",
+	Short: "Rewrite workflow files written for an older schema",
+	Long: `Migrate-config brings workflow files, and files of shared actions, up to date
+with the current workflow schema after a release renames or restructures
+fields. Each file is rewritten in place, with a diff of the changes printed
+first; comments, quoting and blank lines are kept. Files that are up to date
+are left alone, so the command can be run over a whole directory.
+
+Old spellings keep working until the files are migrated; the parser warns
+about them.
+
+Migrations:
+  expect-camel-case  rename expect_status and expect_body_contains to
+                     expectStatus and expectBodyContains
+
+Examples:
+  autozap migrate-config ./workflows/*.yaml --dry-run
+  autozap migrate-config ./workflows/*.yaml ./workflows/shared/*.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		// Expand glob patterns
+		var files []string
+		for _, pattern := range args {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				logger.L().Errorw("Invalid file pattern",
+					"pattern", pattern,
+					"error", err,
+				)
+				continue
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			logger.L().Error("No workflow files found to migrate")
+			os.Exit(1)
+		}
+
+		migrated, failed := 0, 0
+		for _, file := range files {
+			changed, err := migrateFile(file, dryRun)
+			if err != nil {
+				fmt.Printf("✗ %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			if changed {
+				migrated++
+			}
+		}
+
+		fmt.Println("─────────────────────────────────────")
+		if dryRun {
+			fmt.Printf("%d of %d files need migrating (dry run, nothing written)\n", migrated, len(files))
+		} else {
+			fmt.Printf("%d of %d files migrated\n", migrated, len(files))
+		}
+		if failed > 0 {
+			fmt.Printf("✗ %d files failed\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+// migrateFile migrates a workflow file, printing a diff of the changes, and
+// reports whether it needed any. The file is rewritten unless dryRun.
+func migrateFile(path string, dryRun bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	migrated, changes, err := migrate.Source(data)
+	if err != nil {
+		return false, err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("✓ %s: up to date\n", path)
+		return false, nil
+	}
+
+	fmt.Printf("--- %s\n+++ %s (migrated)\n", path, path)
+	for _, change := range changes {
+		fmt.Printf("@@ %s\n", change)
+	}
+	for _, line := range diffLines(string(data), string(migrated)) {
+		fmt.Println(line)
+	}
+	if dryRun {
+		fmt.Println()
+		return true, nil
+	}
+
+	if err := writeFileAtomic(path, migrated); err != nil {
+		return false, fmt.Errorf("failed to write migrated file: %w", err)
+	}
+	fmt.Printf("✓ %s: migrated (%d changes)\n\n", path, len(changes))
+	return true, nil
+}
+
+// writeFileAtomic replaces a file with data, keeping its permissions, via a
+// temporary file in the same directory, so an interrupted write can't leave
+// a truncated workflow behind
+func writeFileAtomic(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func init() {
+	rootCmd.AddCommand(migrateConfigCmd)
+
+	migrateConfigCmd.Flags().Bool("dry-run", false, "Show the changes without writing them")
+}
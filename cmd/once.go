@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/source"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// onceWorkflow is a workflow agent --once runs, and the file or source
+// document it was loaded from
+type onceWorkflow struct {
+	key string
+	wf  *workflow.Workflow
+}
+
+// runWorkflowsOnce runs each workflow of the agent once, ignoring its trigger,
+// one after the other in file order and then the order of the sources. It
+// returns the exit code of agent --once: 0 if every run succeeded, 2 if some
+// only partially succeeded, and 1 if a run failed or a workflow couldn't be
+// loaded. Disabled workflows are not run.
+func runWorkflowsOnce(workflowDir string, localDir bool, sourceSpecs []string) int {
+	loaded, failed := loadWorkflowsOnce(workflowDir, localDir, sourceSpecs)
+
+	// Register every workflow first, so workflow actions can call the others
+	var runnable []onceWorkflow
+	for _, w := range loaded {
+		if owner, ok := claimWorkflowName(w.wf.Name, w.key); !ok {
+			logger.L().Errorw("Not running workflow, its name is already used",
+				"workflow_name", w.wf.Name,
+				"file", w.key,
+				"conflicts_with", owner,
+			)
+			failed++
+			continue
+		}
+		server.GetRegistry().RegisterWorkflow(w.wf)
+		if !w.wf.IsEnabled() {
+			logger.L().Infow("Workflow disabled, not running it",
+				"workflow_name", w.wf.Name,
+				"file", w.key,
+			)
+			continue
+		}
+		runnable = append(runnable, w)
+	}
+
+	succeeded, partial := 0, 0
+	for _, w := range runnable {
+		summary := executor.ExecuteAndSummarize(w.wf, executor.TriggerTypeManual,
+			executor.WithEvent(nil, executor.Event{Source: cliTriggerSource("agent --once")}))
+		printRunSummary(summary)
+		switch exitCode(summary.Status) {
+		case 0:
+			succeeded++
+		case 2:
+			partial++
+		default:
+			failed++
+		}
+	}
+
+	fmt.Printf("\nWorkflows: %d succeeded, %d partially succeeded, %d failed\n", succeeded, partial, failed)
+	switch {
+	case failed > 0:
+		return 1
+	case partial > 0:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// loadWorkflowsOnce parses the workflows in workflowDir, if localDir, and
+// fetches those of the additional sources once. It returns the workflows and
+// the number of files, documents and sources that failed to load.
+func loadWorkflowsOnce(workflowDir string, localDir bool, sourceSpecs []string) ([]onceWorkflow, int) {
+	var loaded []onceWorkflow
+	failed := 0
+
+	if localDir {
+		files, err := workflowFiles(workflowDir)
+		if err != nil {
+			logger.L().Errorw("Failed to read workflow directory",
+				"directory", workflowDir,
+				"error", err,
+			)
+			failed++
+		}
+		for _, file := range files {
+			wf, err := parser.ParseWorkflowFile(file)
+			if err != nil {
+				logger.L().Errorw("Failed to load workflow",
+					"file", file,
+					"error", err,
+				)
+				failed++
+				continue
+			}
+			loaded = append(loaded, onceWorkflow{key: file, wf: wf})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, spec := range sourceSpecs {
+		src, err := source.New(spec)
+		if err != nil {
+			logger.L().Errorw("Invalid workflow source",
+				"source", spec,
+				"error", err,
+			)
+			failed++
+			continue
+		}
+		docs, err := src.Fetch(ctx)
+		if err != nil {
+			logger.L().Errorw("Failed to fetch workflow source",
+				"source", src.Name(),
+				"error", err,
+			)
+			failed++
+			continue
+		}
+		for _, doc := range docs {
+			wf, err := parser.ParseWorkflow(doc.Data, doc.ID)
+			if err != nil {
+				logger.L().Errorw("Failed to load workflow",
+					"file", doc.ID,
+					"error", err,
+				)
+				failed++
+				continue
+			}
+			loaded = append(loaded, onceWorkflow{key: doc.ID, wf: wf})
+		}
+	}
+
+	return loaded, failed
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+)
+
+// Output formats supported by the reporting commands (history, stats, failures)
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// addOutputFlag registers the --output flag on a reporting command
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", outputTable, "Output format: table, json or csv")
+}
+
+// getOutputFormat reads and validates the --output flag
+func getOutputFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("output")
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid output format '%s'. Must be one of: %s, %s, %s", format, outputTable, outputJSON, outputCSV)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printCSV writes a header and rows to stdout as CSV
+func printCSV(header []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// printTableHeader writes a tab-separated table header followed by a rule of
+// dashes under each column, sized to the (translated) column name
+func printTableHeader(w io.Writer, header string) {
+	columns := strings.Split(header, "\t")
+	rules := make([]string, len(columns))
+	for i, column := range columns {
+		rules[i] = strings.Repeat("-", utf8.RuneCountInString(column))
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, strings.Join(rules, "\t"))
+}
+
+// csvOptionalString renders an optional string as a CSV cell
+func csvOptionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// csvOptionalInt renders an optional integer as a CSV cell
+func csvOptionalInt(i *int64) string {
+	if i == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *i)
+}
+
+// displayExecutions converts the times of workflow executions, stored in UTC,
+// to the --timezone for all output formats
+func displayExecutions(executions []database.WorkflowExecution) []database.WorkflowExecution {
+	for i := range executions {
+		executions[i] = executions[i].In(timezone.Location())
+	}
+	return executions
+}
+
+// displayActions converts the times of action executions to the --timezone
+func displayActions(actions []database.ActionExecution) []database.ActionExecution {
+	for i := range actions {
+		actions[i] = actions[i].In(timezone.Location())
+	}
+	return actions
+}
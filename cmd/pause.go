@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause [workflow_name]",
+	Short: "Pause a workflow running in the agent",
+	Long: `Stop a workflow's trigger from firing without unloading it. The workflow
+stays registered with status 'paused' until it is resumed.
+
+Examples:
+  autozap pause nightly-backup
+  autozap pause nightly-backup --url http://autozap.internal:9090`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorkflowControl(cmd, args[0], "pause")
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume [workflow_name]",
+	Short: "Resume a paused workflow",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWorkflowControl(cmd, args[0], "resume")
+	},
+}
+
+// agentBaseURL returns the agent's URL from the --url flag or, without it, from
+// the HTTP port in the agent configuration
+func agentBaseURL(cmd *cobra.Command) string {
+	agentURL, _ := cmd.Flags().GetString("url")
+	if !cmd.Flags().Changed("url") && agentConfig.HTTPPort != 0 {
+		agentURL = fmt.Sprintf("http://localhost:%d", agentConfig.HTTPPort)
+	}
+	return agentURL
+}
+
+// newAgentRequest builds a JSON request to the agent's API, authorized with
+// AUTOZAP_API_TOKEN or the apiToken of the agent configuration if set
+func newAgentRequest(method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	token := os.Getenv(envName("api-token"))
+	if token == "" {
+		token = agentConfig.APIToken
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// runWorkflowControl calls POST /api/workflows/{name}/{operation} on the agent
+func runWorkflowControl(cmd *cobra.Command, name, operation string) {
+	agentURL := agentBaseURL(cmd)
+
+	status, err := postWorkflowAPI(agentURL, name, operation, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Workflow '%s' is now %s\n", name, status)
+}
+
+// postWorkflowAPI posts body to the agent's per-workflow endpoint and returns
+// the "status" field of the JSON response
+func postWorkflowAPI(agentURL, name, operation string, body io.Reader) (string, error) {
+	endpoint := fmt.Sprintf("%s/api/workflows/%s/%s", strings.TrimRight(agentURL, "/"), url.PathEscape(name), operation)
+
+	req, err := newAgentRequest(http.MethodPost, endpoint, body)
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach agent at %s: %w", agentURL, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("agent returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unexpected response from agent: %w", err)
+	}
+	return result.Status, nil
+}
+
+func init() {
+	rootCmd.AddCommand(pauseCmd, resumeCmd)
+
+	for _, c := range []*cobra.Command{pauseCmd, resumeCmd} {
+		c.Flags().String("url", "http://localhost:8080", "Base URL of the agent's HTTP server")
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/notify"
+	"github.com/codecrafted007/autozap/internal/remediation"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/fsnotify/fsnotify"
+)
+
+// configReloadDelay is how long the agent waits after the configuration file
+// changed before reloading it, so an editor's writes are reloaded once
+const configReloadDelay = 500 * time.Millisecond
+
+// reloadableSettings are the agent configuration settings a reload applies
+// without restarting workflows; the others take effect after a restart
+var reloadableSettings = map[string]bool{
+	"log":           true, // level and format, not dir
+	"secrets":       true,
+	"retry":         true,
+	"notifications": true,
+	"remediations":  true,
+	"retention":     true,
+}
+
+// watchAgentConfig calls reload when the agent configuration file at path
+// changes. Its directory is watched, so a file replaced by an editor or a
+// ConfigMap update is noticed too.
+func watchAgentConfig(ctx context.Context, path string, reload func()) (*fsnotify.Watcher, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		var pending <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// Kubernetes swaps the ..data link of a mounted ConfigMap
+				if event.Name != path && filepath.Base(event.Name) != "..data" {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				pending = time.After(configReloadDelay)
+			case <-pending:
+				pending = nil
+				reload()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.L().Errorw("Agent configuration watcher error",
+					"config", path,
+					"error", err)
+			}
+		}
+	}()
+	return watcher, nil
+}
+
+// reloadMu serializes reloads from the file watcher and SIGHUP
+var reloadMu sync.Mutex
+
+// reloadAgentConfig loads the agent configuration file again and applies the
+// settings that can change while workflows run. An invalid file is logged and
+// the current configuration kept. A changed retention is sent to retention
+// unless retentionLocked, because --retention or AUTOZAP_RETENTION set it.
+func reloadAgentConfig(path string, retention chan time.Duration, retentionLocked bool) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		logger.L().Errorw("Failed to reload agent configuration, keeping the current one",
+			"config", path,
+			"error", err)
+		metrics.RecordConfigReload("failure")
+		return
+	}
+
+	previous := agentConfig
+	changed := changedSettings(previous, cfg)
+	if len(changed) == 0 {
+		return
+	}
+
+	if cfg.Log.Level != previous.Log.Level || cfg.Log.Format != previous.Log.Format {
+		if err := logger.Configure(cfg.Log.Level, cfg.Log.Format); err != nil {
+			logger.L().Errorw("Failed to reload agent configuration, keeping the current one",
+				"config", path,
+				"error", err)
+			metrics.RecordConfigReload("failure")
+			return
+		}
+	}
+	templating.SetSecretsDir(cfg.Secrets.Dir)
+	executor.SetDefaultRetry(cfg.Retry)
+	notify.Configure(cfg.Notifications)
+	remediation.Configure(cfg.Remediations)
+
+	var restart []string
+	for _, name := range changed {
+		if !reloadableSettings[name] {
+			restart = append(restart, name)
+		}
+	}
+	if cfg.Log.Dir != previous.Log.Dir {
+		restart = append(restart, "log.dir")
+	}
+
+	if cfg.Retention != previous.Retention {
+		if retentionLocked {
+			logger.L().Warnw("Ignoring changed retention, --retention or AUTOZAP_RETENTION takes precedence",
+				"config", path,
+				"retention", cfg.Retention)
+		} else {
+			d, _ := config.ParseRetention(cfg.Retention) // validated by Load
+			setRetention(retention, d)
+		}
+	}
+
+	agentConfig = cfg
+	logger.L().Infow("Reloaded agent configuration",
+		"config", path,
+		"changed", changed)
+	if len(restart) > 0 {
+		logger.L().Warnw("Changed agent settings take effect after a restart",
+			"config", path,
+			"settings", restart)
+	}
+	metrics.RecordConfigReload("success")
+}
+
+// changedSettings returns the names of the top-level settings that differ
+// between two agent configurations, as written in the file
+func changedSettings(previous, current *config.AgentConfig) []string {
+	var changed []string
+	pv, cv := reflect.ValueOf(previous).Elem(), reflect.ValueOf(current).Elem()
+	for i := 0; i < pv.NumField(); i++ {
+		if reflect.DeepEqual(pv.Field(i).Interface(), cv.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(pv.Type().Field(i).Tag.Get("yaml"), ",")
+		changed = append(changed, name)
+	}
+	return changed
+}
+
+// setRetention sends a changed retention to pruneLoop, replacing a change it
+// hasn't picked up yet. retention must have a buffer of one.
+func setRetention(retention chan time.Duration, d time.Duration) {
+	select {
+	case <-retention:
+	default:
+	}
+	retention <- d
+}
@@ -4,9 +4,27 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/i18n"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/timezone"
 	"github.com/spf13/cobra"
 )
 
+// agentConfig is the agent configuration file loaded before every command, or
+// an empty configuration if there is none
+var agentConfig = &config.AgentConfig{}
+
+// agentConfigPath is the file agentConfig was loaded from, if any
+var agentConfigPath string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "autozap",
@@ -15,6 +33,17 @@ var rootCmd = &cobra.Command{
 that allows users to define workflows in YAML that react to events
 (like cron schedules or file changes) and perform actions (like running Bash commands).
 Think of it as “Zapier for infra and Bash scripts” — without the cloud.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setLanguage(cmd); err != nil {
+			return err
+		}
+		if err := applySettings(cmd); err != nil {
+			return err
+		}
+		noStrict, _ := cmd.Flags().GetBool("no-strict")
+		parser.SetStrict(!noStrict)
+		return setTimezone(cmd)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -23,6 +52,58 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// loadAgentConfig loads the agent configuration file at path, or
+// config.DefaultPath if path is empty and it exists, and applies the settings
+// every command shares: logging, secrets and the default retry policy
+func loadAgentConfig(path string) error {
+	if path == "" {
+		if _, err := os.Stat(config.DefaultPath); err != nil {
+			return nil
+		}
+		path = config.DefaultPath
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := logger.Configure(cfg.Log.Level, cfg.Log.Format); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	templating.SetSecretsDir(cfg.Secrets.Dir)
+	executor.SetDefaultRetry(cfg.Retry)
+
+	agentConfig = cfg
+	agentConfigPath = path
+	return nil
+}
+
+// setTimezone sets the time zone timestamps are shown in from the --timezone flag
+func setTimezone(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("timezone")
+	loc, err := timezone.Load(name)
+	if err != nil {
+		return err
+	}
+	timezone.Set(loc)
+	return nil
+}
+
+// setLanguage sets the language of command output from the --lang flag or,
+// without it, from AUTOZAP_LANG or the locale environment variables
+func setLanguage(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("lang") {
+		lang, _ := cmd.Flags().GetString("lang")
+		return i18n.Set(lang)
+	}
+	i18n.Detect(os.Getenv("AUTOZAP_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG"))
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
+	rootCmd.PersistentFlags().String("config", "", "Agent configuration file with defaults for every command, services and remediation rules (default "+config.DefaultPath+" if it exists)")
+	rootCmd.PersistentFlags().String("timezone", "", "Time zone to show timestamps in: Local, UTC or an IANA name such as Europe/Berlin (default local time)")
+	rootCmd.PersistentFlags().Bool("no-strict", false, "Ignore unknown workflow fields instead of failing, e.g. to load workflows written for a newer release")
+	rootCmd.PersistentFlags().String("lang", "", "Language of command output: "+strings.Join(i18n.Languages(), ", ")+" (default from AUTOZAP_LANG or the locale, else en)")
 }
@@ -5,10 +5,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
-	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/executor"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
 	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/templating"
 	"github.com/codecrafted007/autozap/internal/trigger"
 	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/spf13/cobra"
@@ -22,21 +31,35 @@ var runCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		workflowFile := args[0]
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		dbPath, _ := cmd.Flags().GetString("db")
+		once, _ := cmd.Flags().GetBool("once")
+		pushURL, _ := cmd.Flags().GetString("pushgateway")
+		pushJob, _ := cmd.Flags().GetString("pushgateway-job")
+		summaryFile, _ := cmd.Flags().GetString("summary-file")
+		templatesDir, _ := cmd.Flags().GetString("templates-dir")
+		shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
 
 		if dryRun {
 			logger.L().Info("[DRY RUN MODE] No actions will be executed")
 		}
+		if summaryFile != "" && !once {
+			logger.L().Warn("--summary-file only applies with --once; no summary will be written")
+		}
+
+		if err := templating.LoadTemplates(templatesDir); err != nil {
+			logger.L().Errorw("Failed to load templates",
+				"error", err,
+			)
+			return
+		}
 
 		// Initialize database
-		if err := database.InitDB(dbPath); err != nil {
+		if err := openDatabase(cmd); err != nil {
 			logger.L().Errorw("Failed to initialize database",
 				"error", err,
-				"db_path", dbPath,
 			)
 			return
 		}
-		defer database.CloseDB()
+		defer closeDatabase()
 
 		logger.L().Infof("Attempting to run workflow from file: %s", workflowFile)
 		logger.L().Infow("Workflow processing initiated",
@@ -64,22 +87,100 @@ var runCmd = &cobra.Command{
 			logger.L().Infof("[DRY RUN] Would start workflow: %s", wf.Name)
 			logger.L().Infof("[DRY RUN] Trigger: %s", wf.Trigger.Type)
 
-			switch wf.Trigger.Type {
-			case workflow.TriggerTypeCron:
-				logger.L().Infof("[DRY RUN] Schedule: %s", wf.Trigger.Schedule)
-			case workflow.TriggerTypeFileWatch:
-				logger.L().Infof("[DRY RUN] Watch path: %s", wf.Trigger.Path)
-				logger.L().Infof("[DRY RUN] Events: %v", wf.Trigger.Events)
+			for _, line := range trigger.Describe(wf.Trigger) {
+				logger.L().Infof("[DRY RUN] %s", line)
+			}
+			if wf.Trigger.Type == workflow.TriggerTypeCron {
+				if runs, err := nextRunLines(wf.Trigger); err == nil {
+					logger.L().Infof("[DRY RUN] Next %d runs:", len(runs))
+					for _, run := range runs {
+						logger.L().Infof("[DRY RUN]   %s", run)
+					}
+				}
 			}
 
 			logger.L().Infof("[DRY RUN] Would execute %d actions:", len(wf.Actions))
 			for i, action := range wf.Actions {
-				logger.L().Infof("[DRY RUN]   %d. [%s] %s", i+1, action.Type, action.Name)
+				if action.RunAsync {
+					logger.L().Infof("[DRY RUN]   %d. [%s] %s (async)", i+1, action.Type, action.Name)
+				} else {
+					logger.L().Infof("[DRY RUN]   %d. [%s] %s", i+1, action.Type, action.Name)
+				}
+				if action.When != "" {
+					logger.L().Infof("[DRY RUN]      When: %s", action.When)
+				}
 				switch action.Type {
 				case workflow.ActionTypeBash:
-					logger.L().Infof("[DRY RUN]      Command: %s", action.Command)
+					switch {
+					case len(action.Exec) > 0:
+						logger.L().Infof("[DRY RUN]      Exec: %q", action.Exec)
+					case action.ScriptFile != "":
+						logger.L().Infof("[DRY RUN]      Script: %s", action.ScriptFile)
+					default:
+						logger.L().Infof("[DRY RUN]      Command: %s", action.Command)
+					}
+					if action.Shell != "" {
+						logger.L().Infof("[DRY RUN]      Shell: %s", action.Shell)
+					}
+					if action.WorkingDir != "" {
+						logger.L().Infof("[DRY RUN]      Working dir: %s", action.WorkingDir)
+					}
+					if action.RunAsUser != "" || action.RunAsGroup != "" {
+						logger.L().Infof("[DRY RUN]      Run as: %s", runAsLabel(action.RunAsUser, action.RunAsGroup))
+					}
 				case workflow.ActionTypeHTTP:
 					logger.L().Infof("[DRY RUN]      %s %s", action.Method, action.URL)
+					if action.UnixSocket != "" {
+						logger.L().Infof("[DRY RUN]      Unix socket: %s", action.UnixSocket)
+					}
+					if action.BodyFile != "" {
+						logger.L().Infof("[DRY RUN]      Body file: %s", action.BodyFile)
+					}
+				case workflow.ActionTypeKV:
+					logger.L().Infof("[DRY RUN]      Key: %s", action.Key)
+				case workflow.ActionTypeVerify:
+					logger.L().Infof("[DRY RUN]      Manifest: %s", action.Manifest)
+				case workflow.ActionTypeDownload:
+					logger.L().Infof("[DRY RUN]      Download: %s -> %s", action.URL, action.Path)
+				case workflow.ActionTypeFile:
+					if action.Source != "" {
+						logger.L().Infof("[DRY RUN]      File: %s %s -> %s", action.Operation, action.Source, action.Path)
+					} else {
+						logger.L().Infof("[DRY RUN]      File: %s %s", action.Operation, action.Path)
+					}
+				case workflow.ActionTypeStorage:
+					if action.Operation == "upload" {
+						logger.L().Infof("[DRY RUN]      Upload: %s -> %s", action.Path, action.URL)
+					} else {
+						logger.L().Infof("[DRY RUN]      Download: %s -> %s", action.URL, action.Path)
+					}
+				case workflow.ActionTypeGit:
+					logger.L().Infof("[DRY RUN]      Git: %s %s %s", action.Operation, action.URL, action.Path)
+					if action.Git != nil && action.Git.Ref != "" {
+						logger.L().Infof("[DRY RUN]      Ref: %s", action.Git.Ref)
+					}
+				case workflow.ActionTypeTemplate:
+					logger.L().Infof("[DRY RUN]      Template: %s -> %s", action.Template, action.Path)
+				case workflow.ActionTypeWait:
+					if action.Until != "" {
+						logger.L().Infof("[DRY RUN]      Wait until: %s", action.Until)
+					} else {
+						logger.L().Infof("[DRY RUN]      Wait: %s", action.Duration)
+					}
+				case workflow.ActionTypeApproval:
+					logger.L().Infof("[DRY RUN]      Approval: %s", action.Message)
+				case workflow.ActionTypeWorkflow:
+					target := action.Workflow
+					if action.WorkflowFile != "" {
+						target = action.WorkflowFile
+					}
+					if action.WaitsForWorkflow() {
+						logger.L().Infof("[DRY RUN]      Workflow: %s (waits for it)", target)
+					} else {
+						logger.L().Infof("[DRY RUN]      Workflow: %s (doesn't wait)", target)
+					}
+				case workflow.ActionTypeMQTT:
+					logger.L().Infof("[DRY RUN]      Publish: %s", action.Topic)
 				case workflow.ActionTypeCustom:
 					logger.L().Infof("[DRY RUN]      Function: %s", action.FunctionName)
 				}
@@ -89,6 +190,27 @@ var runCmd = &cobra.Command{
 			return
 		}
 
+		// Run the actions now and exit, e.g. when scheduled by system cron
+		if once {
+			summary := executor.ExecuteAndSummarize(wf, executor.TriggerTypeManual,
+				executor.WithEvent(nil, executor.Event{Source: cliTriggerSource("run --once")}))
+			printRunSummary(summary)
+			if summaryFile != "" {
+				if err := writeRunSummary(summaryFile, summary); err != nil {
+					logger.L().Errorw("Failed to write run summary",
+						"summary_file", summaryFile,
+						"error", err,
+					)
+				}
+			}
+			pushMetrics(pushURL, pushJob, wf.Name)
+			if code := exitCode(summary.Status); code != 0 {
+				closeDatabase()
+				os.Exit(code)
+			}
+			return
+		}
+
 		for i, action := range wf.Actions {
 			logger.L().Infow("Parsed action",
 				"action_index", i,
@@ -96,39 +218,142 @@ var runCmd = &cobra.Command{
 				"action_name", action.Name,
 				"action_command", action.Command)
 		}
-		// Start the cron trigger
-		switch wf.Trigger.Type {
-		case workflow.TriggerTypeCron:
-			if err := trigger.StartCronTrigger(context.Background(), wf); err != nil {
-				logger.L().Errorw("Failed to start cron trigger",
-					"workflow_name", wf.Name,
-					"error", err,
-				)
-				return // Exit the run function on error
-			}
-		case workflow.TriggerTypeFileWatch:
-			if err := trigger.StartFileWatchTrigger(context.Background(), wf); err != nil {
-				logger.L().Errorw("Failed to start file watch trigger",
-					"workflow_name", wf.Name,
-					"error", err,
-				)
-				return // Exit the run function on error
-			}
-		default:
-			logger.L().Errorf("Unsupported trigger type '%s' for workflow '%s'. Only 'cron' is supported at this time.", wf.Trigger.Type, wf.Name)
-			return // Exit the run function on unsupported trigger type
+		// Stop the trigger on Ctrl+C so the database is closed cleanly. The
+		// trigger outlives the signal until shutdown workflows have run.
+		signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		t, err := trigger.New(wf)
+		if err != nil {
+			logger.L().Errorw("Failed to start trigger",
+				"workflow_name", wf.Name,
+				"error", err,
+			)
+			return
+		}
+		if err := t.Start(ctx); err != nil {
+			logger.L().Errorw("Failed to start trigger",
+				"workflow_name", wf.Name,
+				"trigger_type", wf.Trigger.Type,
+				"error", err,
+			)
+			return
 		}
+		defer t.Stop()
 
 		logger.L().Info("Autozap is now running in background. Press Ctrl+C to stop.")
-		select {} // Block forever to keep the cron trigger running
+		<-signalCtx.Done()
 
+		logger.L().Info("Received shutdown signal. Stopping workflow...")
+		trigger.RunShutdownTriggers()
+		cancel()
+		drainRuns(shutdownTimeout)
+		waitForAsyncActions()
+		pushMetrics(pushURL, pushJob, wf.Name)
 	},
 }
 
+// pushMetrics sends the workflow's metrics to the Pushgateway, if one is set,
+// so they outlive the process
+func pushMetrics(url, job, workflowName string) {
+	if url == "" {
+		return
+	}
+	if err := metrics.Push(url, job, workflowName); err != nil {
+		logger.L().Errorw("Failed to push metrics",
+			"workflow_name", workflowName,
+			"error", err,
+		)
+		return
+	}
+	logger.L().Infow("Pushed metrics to Pushgateway",
+		"workflow_name", workflowName,
+		"pushgateway", url,
+	)
+}
+
+// printRunSummary prints the outcome of each step of a one-shot run
+func printRunSummary(summary workflow.ExecutionResult) {
+	fmt.Printf("\nWorkflow: %s\n\n", summary.Workflow)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tSTATUS\tDURATION\tRETRIES")
+	fmt.Fprintln(w, "----\t------\t--------\t-------")
+	for _, step := range summary.Actions {
+		name := step.Name
+		if step.Async {
+			name += " (async)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%dms\t%d\n", name, formatStatus(step.Status), step.Duration.Milliseconds(), step.Retries())
+	}
+	w.Flush()
+
+	fmt.Printf("\nStatus: %s (%dms)\n", formatStatus(summary.Status), summary.Duration.Milliseconds())
+	fmt.Printf("Actions: %d succeeded, %d failed\n", summary.ActionsSucceeded, summary.ActionsFailed)
+	if summary.Error != "" {
+		fmt.Printf("Error: %s\n", formatOptional(&summary.Error, 200))
+	}
+}
+
+// exitCode returns the exit code of a one-shot run that ended with status: 0
+// on success, 2 if only actions with continueOnError failed, 1 otherwise
+func exitCode(status string) int {
+	switch status {
+	case workflow.StatusSuccess:
+		return 0
+	case workflow.StatusPartialSuccess:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// writeRunSummary writes the summary of a one-shot run as JSON for the script
+// that started it
+func writeRunSummary(path string, summary workflow.ExecutionResult) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// drainRuns lets the workflow runs in progress finish and record their results
+// before the database is closed; those still running after timeout are aborted
+func drainRuns(timeout time.Duration) {
+	logger.L().Infow("Waiting for running workflows to finish",
+		"shutdown_timeout", timeout.String())
+	if aborted := executor.Drain(timeout); aborted > 0 {
+		logger.L().Warnw("Aborted workflow runs still in progress at the shutdown timeout",
+			"aborted", aborted,
+			"shutdown_timeout", timeout.String())
+	}
+}
+
+// asyncActionTimeout bounds how long shutdown waits for runAsync actions
+const asyncActionTimeout = 30 * time.Second
+
+// waitForAsyncActions lets runAsync actions finish and record their results
+// before the database is closed
+func waitForAsyncActions() {
+	if !executor.WaitForAsyncActions(asyncActionTimeout) {
+		logger.L().Warnw("Async actions still running at shutdown; their results will not be recorded",
+			"timeout", asyncActionTimeout.String())
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 
 	// Add flags
 	runCmd.Flags().Bool("dry-run", false, "Show what would be executed without running actions")
-	runCmd.Flags().String("db", "./data/autozap.db", "Database file path")
+	runCmd.Flags().Bool("once", false, "Run the workflow's actions once immediately and exit instead of waiting for its trigger")
+	runCmd.Flags().String("summary-file", "", "With --once, write a JSON summary of the run (status, duration and retries of each step) to this file")
+	runCmd.Flags().String("templates-dir", "", "Directory of *.tmpl files whose named templates actions can execute, like the agent config's templatesDir")
+	runCmd.Flags().String("pushgateway", "", "Prometheus Pushgateway URL to push the workflow's metrics to when the run exits")
+	runCmd.Flags().String("pushgateway-job", metrics.DefaultPushJob, "Job name for metrics pushed to the Pushgateway")
+	runCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "How long shutdown waits for a running workflow to finish before aborting it")
+	addDBFlags(runCmd.Flags())
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codecrafted007/autozap/internal/i18n"
+	"github.com/codecrafted007/autozap/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the workflow format",
+	Long: `Schema prints the JSON Schema of workflow files, generated from the workflow
+format of this build with its action and trigger types. Editors use it to
+autocomplete and check workflows as they are written, and other tools to
+validate workflows without autozap. The agent serves the same schema at
+/api/schema.
+
+Examples:
+  autozap schema > workflow.schema.json
+  autozap schema --output-file .vscode/autozap.schema.json
+
+With the YAML extension of VS Code, or any editor using yaml-language-server,
+add this line to the top of a workflow file:
+  # yaml-language-server: $schema=./workflow.schema.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		outputFile, _ := cmd.Flags().GetString("output-file")
+
+		data, err := schema.JSON()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error", err))
+			os.Exit(1)
+		}
+		if outputFile == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(outputFile, data, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error.write_output", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote workflow schema to %s\n", outputFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().String("output-file", "", "Write the schema to this file instead of stdout")
+}
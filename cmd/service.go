@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Install and control the agent as a systemd or launchd service",
+	Long: `Generates a systemd unit (Linux) or launchd property list (macOS) that runs
+'autozap agent' with the given workflow directory, log directory and agent
+configuration file, and starts, stops and shows it through systemctl or
+launchctl.
+
+Run as root to install a system service that starts at boot
+(/etc/systemd/system, /Library/LaunchDaemons); as another user it is that
+user's service (~/.config/systemd/user, ~/Library/LaunchAgents).
+
+Examples:
+  sudo autozap service install /etc/autozap/workflows --run-as autozap --log-dir /var/log/autozap
+  sudo autozap service start
+  autozap service status
+  autozap service install ./workflows --print   # show the unit without installing it`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install [workflow-directory]",
+	Short: "Generate and register the agent's service",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workflowDir := "./workflows"
+		if len(args) > 0 {
+			workflowDir = args[0]
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		printOnly, _ := cmd.Flags().GetBool("print")
+
+		m := serviceManager(cmd)
+		spec, err := serviceSpec(cmd, workflowDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if printOnly {
+			data, err := m.Render(spec)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(data))
+			return
+		}
+
+		if err := m.Install(spec, force); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Installed %s service at %s\n", m.Kind(), m.Path())
+		fmt.Printf("  Start it with: autozap service start%s\n", serviceNameFlag(cmd))
+	},
+}
+
+var serviceStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent's service",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := serviceManager(cmd).Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Service started")
+	},
+}
+
+var serviceStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the agent's service, letting running workflows finish",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := serviceManager(cmd).Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Service stopped")
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the agent's service",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// systemctl and launchctl print the status; they fail if it isn't running
+		if err := serviceManager(cmd).Status(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// serviceManager returns the manager of the service named by --name, exiting
+// if this host has no supported service manager
+func serviceManager(cmd *cobra.Command) *service.Manager {
+	name, _ := cmd.Flags().GetString("name")
+	m, err := service.New(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return m
+}
+
+// serviceNameFlag returns the --name flag to repeat in hints, if not the default
+func serviceNameFlag(cmd *cobra.Command) string {
+	name, _ := cmd.Flags().GetString("name")
+	if name == service.DefaultName {
+		return ""
+	}
+	return " --name " + name
+}
+
+// serviceSpec describes the agent service for the install flags. Paths are
+// made absolute, since the service doesn't run in the current directory. The
+// agent flags given on the command line or in the environment are passed on;
+// those from the configuration file are read from it by the agent.
+func serviceSpec(cmd *cobra.Command, workflowDir string) (service.Spec, error) {
+	runAs, _ := cmd.Flags().GetString("run-as")
+	workingDir, _ := cmd.Flags().GetString("working-dir")
+	logDir, _ := cmd.Flags().GetString("log-dir")
+	httpPort, _ := cmd.Flags().GetInt("http-port")
+	shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+
+	executable, err := os.Executable()
+	if err != nil {
+		return service.Spec{}, err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return service.Spec{}, err
+	}
+
+	workflowDir, err = filepath.Abs(workflowDir)
+	if err != nil {
+		return service.Spec{}, err
+	}
+	if info, err := os.Stat(workflowDir); err != nil || !info.IsDir() {
+		return service.Spec{}, fmt.Errorf("workflow directory %s does not exist", workflowDir)
+	}
+	if workingDir == "" {
+		workingDir = "."
+	}
+	if workingDir, err = filepath.Abs(workingDir); err != nil {
+		return service.Spec{}, err
+	}
+	if logDir != "" {
+		if logDir, err = filepath.Abs(logDir); err != nil {
+			return service.Spec{}, err
+		}
+	}
+
+	args := []string{"agent", workflowDir}
+	passed := func(flag string) bool {
+		return cmd.Flags().Changed(flag) && !flagsFromConfig[flag]
+	}
+	if passed("log-dir") {
+		args = append(args, "--log-dir", logDir)
+	}
+	if passed("http-port") {
+		args = append(args, "--http-port", strconv.Itoa(httpPort))
+	}
+	if passed("shutdown-timeout") {
+		args = append(args, "--shutdown-timeout", shutdownTimeout.String())
+	}
+	configPath := agentConfigPath
+	if configPath != "" {
+		if configPath, err = filepath.Abs(configPath); err != nil {
+			return service.Spec{}, err
+		}
+		args = append(args, "--config", configPath)
+	}
+
+	return service.Spec{
+		Executable:      executable,
+		Args:            args,
+		WorkingDir:      workingDir,
+		User:            runAs,
+		LogDir:          logDir,
+		ShutdownTimeout: shutdownTimeout,
+		Reload:          configPath != "",
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceInstallCmd, serviceStartCmd, serviceStopCmd, serviceStatusCmd)
+
+	serviceCmd.PersistentFlags().String("name", service.DefaultName, "Name of the service, to run several agents on one host")
+	serviceInstallCmd.Flags().String("run-as", "", "User a system service runs the agent as (default root)")
+	serviceInstallCmd.Flags().String("working-dir", "", "Directory the agent runs in, where the default database ./data/autozap.db is kept (default the current directory)")
+	serviceInstallCmd.Flags().String("log-dir", "", "Directory for per-workflow log files; launchd also writes the agent's output to agent.log there")
+	serviceInstallCmd.Flags().Int("http-port", 8080, "HTTP port of the agent")
+	serviceInstallCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "How long the agent waits for running workflows on stop; the service manager waits a minute longer")
+	serviceInstallCmd.Flags().Bool("force", false, "Replace an installed service definition")
+	serviceInstallCmd.Flags().Bool("print", false, "Print the service definition instead of installing it")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// envPrefix prefixes the environment variables flags are read from, e.g.
+// AUTOZAP_HTTP_PORT for --http-port
+const envPrefix = "AUTOZAP"
+
+// notFromEnv are flags whose environment variable means something else:
+// AUTOZAP_WORKFLOW is set for bash actions, and AUTOZAP_LANG may be a locale
+// name, which setLanguage handles
+var notFromEnv = map[string]bool{"help": true, "workflow": true, "lang": true}
+
+// flagsFromConfig are the flags applySettings took from the configuration
+// file, which a reload of the file may change
+var flagsFromConfig = map[string]bool{}
+
+// applySettings loads the agent configuration file and fills in the flags not
+// given on the command line. A flag takes its value from, in order: the
+// command line, its AUTOZAP_* environment variable, the configuration file
+// and its default.
+func applySettings(cmd *cobra.Command) error {
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+
+	var bindErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if notFromEnv[f.Name] || bindErr != nil {
+			return
+		}
+		if err := v.BindPFlag(f.Name, f); err != nil {
+			bindErr = err
+			return
+		}
+		bindErr = v.BindEnv(f.Name)
+	})
+	if bindErr != nil {
+		return bindErr
+	}
+
+	// --config itself may come from AUTOZAP_CONFIG
+	if err := loadAgentConfig(v.GetString("config")); err != nil {
+		return err
+	}
+	if err := v.MergeConfigMap(configFlagValues(agentConfig)); err != nil {
+		return err
+	}
+
+	var setErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || notFromEnv[f.Name] || setErr != nil || !v.IsSet(f.Name) {
+			return
+		}
+		source := "the configuration file"
+		if env := envName(f.Name); os.Getenv(env) != "" {
+			source = env
+		}
+
+		values := []string{v.GetString(f.Name)}
+		if strings.HasSuffix(f.Value.Type(), "Array") || strings.HasSuffix(f.Value.Type(), "Slice") {
+			values = v.GetStringSlice(f.Name) // space-separated in the environment
+		}
+		for _, value := range values {
+			if err := cmd.Flags().Set(f.Name, value); err != nil {
+				setErr = fmt.Errorf("invalid value '%s' for --%s from %s: %w", value, f.Name, source, err)
+				return
+			}
+		}
+		flagsFromConfig[f.Name] = os.Getenv(envName(f.Name)) == ""
+	})
+	return setErr
+}
+
+// envName returns the environment variable a flag is read from
+func envName(flag string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// configFlagValues returns the flags set in the agent configuration file
+func configFlagValues(cfg *config.AgentConfig) map[string]interface{} {
+	values := map[string]interface{}{}
+	for flag, value := range map[string]string{
+		"db":        cfg.Database.Path,
+		"db-driver": cfg.Database.Driver,
+		"log-dir":   cfg.Log.Dir,
+		"timezone":  cfg.Timezone,
+		"retention": cfg.Retention,
+		"api-token": cfg.APIToken,
+	} {
+		if value != "" {
+			values[flag] = value
+		}
+	}
+	if cfg.HTTPPort != 0 {
+		values["http-port"] = cfg.HTTPPort
+	}
+	if cfg.Watch != nil {
+		values["watch"] = *cfg.Watch
+	}
+	return values
+}
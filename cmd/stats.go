@@ -3,11 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"text/tabwriter"
 	"time"
 
 	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/i18n"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -19,12 +22,16 @@ var statsCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		workflowName := args[0]
 		days, _ := cmd.Flags().GetInt("days")
+		format, err := getOutputFormat(cmd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("error", err))
+			return
+		}
 
 		// Initialize database
-		dbPath, _ := cmd.Flags().GetString("db")
-		if err := database.InitDB(dbPath); err != nil {
+		if err := openDatabase(cmd); err != nil {
 			logger.L().Errorw("Failed to initialize database", "error", err)
-			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			fmt.Fprintln(os.Stderr, i18n.T("error.db_init", err))
 			return
 		}
 		defer database.CloseDB()
@@ -33,34 +40,60 @@ var statsCmd = &cobra.Command{
 		stats, err := database.GetWorkflowStats(workflowName, since)
 		if err != nil {
 			logger.L().Errorw("Failed to get workflow stats", "error", err)
-			fmt.Fprintf(os.Stderr, "Error: Failed to get workflow stats: %v\n", err)
+			fmt.Fprintln(os.Stderr, i18n.T("stats.get_failed", err))
+			return
+		}
+
+		switch format {
+		case outputJSON:
+			if err := printJSON(stats); err != nil {
+				fmt.Fprintln(os.Stderr, i18n.T("error.write_output", err))
+			}
+			return
+		case outputCSV:
+			header := []string{"workflow_name", "total_executions", "success_count", "failed_count", "success_rate", "avg_duration_ms"}
+			row := []string{
+				stats.WorkflowName,
+				strconv.Itoa(stats.TotalExecutions),
+				strconv.Itoa(stats.SuccessCount),
+				strconv.Itoa(stats.FailedCount),
+				strconv.FormatFloat(stats.SuccessRate, 'f', 2, 64),
+				strconv.FormatFloat(stats.AvgDurationMs, 'f', 2, 64),
+			}
+			if err := printCSV(header, [][]string{row}); err != nil {
+				fmt.Fprintln(os.Stderr, i18n.T("error.write_output", err))
+			}
 			return
 		}
 
 		if stats.TotalExecutions == 0 {
-			fmt.Printf("No executions found for workflow '%s' in the last %d days.\n", workflowName, days)
+			fmt.Println(i18n.T("stats.empty", workflowName, days))
 			return
 		}
 
 		// Print stats
-		fmt.Printf("\n📊 Statistics for workflow: %s (Last %d days)\n\n", workflowName, days)
+		fmt.Printf("\n📊 %s\n\n", i18n.T("stats.title", workflowName, days))
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "METRIC\tVALUE")
-		fmt.Fprintln(w, "------\t-----")
-		fmt.Fprintf(w, "Total Executions\t%d\n", stats.TotalExecutions)
-		fmt.Fprintf(w, "Successful\t%d (✓)\n", stats.SuccessCount)
-		fmt.Fprintf(w, "Failed\t%d (✗)\n", stats.FailedCount)
-		fmt.Fprintf(w, "Success Rate\t%.2f%%\n", stats.SuccessRate)
+		printTableHeader(w, i18n.T("stats.header"))
+		fmt.Fprintf(w, "%s\t%d\n", i18n.T("stats.total"), stats.TotalExecutions)
+		fmt.Fprintf(w, "%s\t%d (✓)\n", i18n.T("stats.successful"), stats.SuccessCount)
+		fmt.Fprintf(w, "%s\t%d (✗)\n", i18n.T("stats.failed"), stats.FailedCount)
+		for _, status := range statsBreakdown {
+			if count := stats.StatusCounts[status]; count > 0 {
+				fmt.Fprintf(w, "  %s\t%d\n", formatStatus(status), count)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%.2f%%\n", i18n.T("stats.success_rate"), stats.SuccessRate)
 
 		if stats.AvgDurationMs > 0 {
 			if stats.AvgDurationMs < 1000 {
-				fmt.Fprintf(w, "Avg Duration\t%.2fms\n", stats.AvgDurationMs)
+				fmt.Fprintf(w, "%s\t%.2fms\n", i18n.T("stats.avg_duration"), stats.AvgDurationMs)
 			} else {
-				fmt.Fprintf(w, "Avg Duration\t%.2fs\n", stats.AvgDurationMs/1000)
+				fmt.Fprintf(w, "%s\t%.2fs\n", i18n.T("stats.avg_duration"), stats.AvgDurationMs/1000)
 			}
 		} else {
-			fmt.Fprintln(w, "Avg Duration\t-")
+			fmt.Fprintf(w, "%s\t-\n", i18n.T("stats.avg_duration"))
 		}
 
 		w.Flush()
@@ -68,9 +101,25 @@ var statsCmd = &cobra.Command{
 	},
 }
 
+// statsBreakdown are the statuses other than success listed by count, in
+// order: degraded and failed runs first, then runs that didn't start
+var statsBreakdown = []string{
+	workflow.StatusPartialSuccess,
+	workflow.StatusFailed,
+	workflow.StatusTimeout,
+	workflow.StatusCancelled,
+	workflow.StatusAborted,
+	workflow.StatusSkipped,
+	workflow.StatusThrottled,
+	workflow.StatusDeferred,
+	workflow.StatusBlocked,
+	workflow.StatusRunning,
+}
+
 func init() {
 	rootCmd.AddCommand(statsCmd)
 
 	statsCmd.Flags().Int("days", 7, "Number of days to analyze")
-	statsCmd.Flags().String("db", "./data/autozap.db", "Database file path")
+	addDBFlags(statsCmd.Flags())
+	addOutputFlag(statsCmd)
 }
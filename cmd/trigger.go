@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var triggerCmd = &cobra.Command{
+	Use:   "trigger [workflow_file]",
+	Short: "Execute a workflow's actions once, without waiting for its trigger",
+	Long: `Trigger runs all actions of a workflow file immediately and exits, which is
+useful for testing a workflow interactively. The trigger section is validated
+but ignored; the run is recorded in the history with trigger type 'manual'.
+
+A JSON payload can be passed with --payload and is available to action
+templates as {{ .payload }}, like the agent's /api/workflows/{name}/trigger endpoint.
+
+Examples:
+  autozap trigger ./workflows/backup.yaml
+  autozap trigger ./workflows/deploy.yaml --payload '{"version": "1.4.2"}'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		payloadJSON, _ := cmd.Flags().GetString("payload")
+
+		var data templating.Data
+		var payload map[string]interface{}
+		if payloadJSON != "" {
+			if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --payload must be a JSON object: %v\n", err)
+				os.Exit(1)
+			}
+			data = templating.Data{"payload": payload}
+		}
+		data = executor.WithEvent(data, executor.Event{Payload: payload, Source: cliTriggerSource("trigger")})
+
+		wf, err := parser.ParseWorkflowFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := openDatabase(cmd); err != nil {
+			logger.L().Errorw("Failed to initialize database", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("▶ Running workflow '%s' (%d actions)\n", wf.Name, len(wf.Actions))
+
+		start := time.Now()
+		status := executor.ExecuteAndWait(wf, executor.TriggerTypeManual, data)
+		closeDatabase()
+
+		duration := time.Since(start).Round(time.Millisecond)
+		switch status {
+		case workflow.StatusSuccess:
+			fmt.Printf("✓ Workflow '%s' succeeded in %s\n", wf.Name, duration)
+		case workflow.StatusPartialSuccess:
+			fmt.Printf("◐ Workflow '%s' partially succeeded in %s\n", wf.Name, duration)
+		default:
+			fmt.Printf("✗ Workflow '%s' %s after %s\n", wf.Name, status, duration)
+		}
+		if code := exitCode(status); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+// cliTriggerSource describes a run started from the command line for the
+// execution history: the command, and the user and host that ran it
+func cliTriggerSource(command string) map[string]string {
+	source := map[string]string{"command": command}
+	if u, err := user.Current(); err == nil {
+		source["user"] = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		source["host"] = host
+	}
+	return source
+}
+
+func init() {
+	rootCmd.AddCommand(triggerCmd)
+
+	addDBFlags(triggerCmd.Flags())
+	triggerCmd.Flags().String("payload", "", "JSON object available to action templates as {{ .payload }}")
+}
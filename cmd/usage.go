@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show run counts and execution time per workflow per month",
+	Long: `Display how many times each workflow ran and how much execution time it used
+per calendar month, with its share of the month's total. Useful when several
+teams share an agent.
+
+Examples:
+  autozap usage
+  autozap usage --months 6
+  autozap usage --workflow nightly-backup --output csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		months, _ := cmd.Flags().GetInt("months")
+		workflowName, _ := cmd.Flags().GetString("workflow")
+		format, err := getOutputFormat(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if months < 1 {
+			fmt.Fprintln(os.Stderr, "Error: --months must be at least 1")
+			return
+		}
+
+		// Initialize database
+		if err := openDatabase(cmd); err != nil {
+			logger.L().Errorw("Failed to initialize database", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to initialize database: %v\n", err)
+			return
+		}
+		defer database.CloseDB()
+
+		usages, err := database.GetUsage(database.StartOfMonth(timezone.In(time.Now()), months), workflowName)
+		if err != nil {
+			logger.L().Errorw("Failed to get usage", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to get usage: %v\n", err)
+			return
+		}
+
+		switch format {
+		case outputJSON:
+			err = printJSON(usages)
+		case outputCSV:
+			err = printCSV(usageCSVHeader, usageCSVRows(usages))
+		default:
+			printUsageTable(usages)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write output: %v\n", err)
+		}
+	},
+}
+
+var usageCSVHeader = []string{"month", "workflow_name", "runs", "failed_runs", "total_duration_ms", "avg_duration_ms", "share"}
+
+// usageCSVRows converts usage records to CSV rows matching usageCSVHeader
+func usageCSVRows(usages []database.WorkflowUsage) [][]string {
+	rows := make([][]string, 0, len(usages))
+	for _, u := range usages {
+		rows = append(rows, []string{
+			u.Month,
+			u.WorkflowName,
+			strconv.Itoa(u.Runs),
+			strconv.Itoa(u.FailedRuns),
+			strconv.FormatInt(u.TotalDurationMs, 10),
+			strconv.FormatFloat(u.AvgDurationMs, 'f', 2, 64),
+			strconv.FormatFloat(u.Share, 'f', 2, 64),
+		})
+	}
+	return rows
+}
+
+// printUsageTable prints usage grouped by month
+func printUsageTable(usages []database.WorkflowUsage) {
+	if len(usages) == 0 {
+		fmt.Println("No executions found.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MONTH\tWORKFLOW\tRUNS\tFAILED\tTOTAL TIME\tAVG\tSHARE")
+	fmt.Fprintln(w, "-----\t--------\t----\t------\t----------\t---\t-----")
+
+	for _, u := range usages {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%.0fms\t%.1f%%\n",
+			u.Month,
+			u.WorkflowName,
+			u.Runs,
+			u.FailedRuns,
+			(time.Duration(u.TotalDurationMs) * time.Millisecond).String(),
+			u.AvgDurationMs,
+			u.Share,
+		)
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().Int("months", 1, "Number of calendar months to show, including the current one")
+	usageCmd.Flags().String("workflow", "", "Filter by workflow name")
+	addDBFlags(usageCmd.Flags())
+	addOutputFlag(usageCmd)
+}
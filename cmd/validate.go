@@ -4,9 +4,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/codecrafted007/autozap/internal/i18n"
+	"github.com/codecrafted007/autozap/internal/lint"
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/trigger"
+	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -14,15 +20,18 @@ import (
 var validateCmd = &cobra.Command{
 	Use:   "validate [workflow_files...]",
 	Short: "Validate workflow files without executing them",
-	Long: `Validate checks workflow YAML files for syntax errors and configuration issues
+	Long: `Validate checks workflow YAML or JSON files for syntax errors and configuration issues
 without executing them. This is useful for CI/CD pipelines and pre-deployment checks.
 
 The command validates:
-- YAML syntax
+- YAML or JSON syntax
+- Unknown fields, e.g. a misspelled 'commnad' (skip with --no-strict)
 - Required fields (name, trigger, actions)
 - Trigger type and configuration
 - Action types and required fields
 - Cron schedule syntax (if using cron trigger)
+- Bash commands, for unquoted variables, rm -rf on templated paths and
+  multi-line scripts without set -e (skip with --no-lint)
 
 Examples:
   autozap validate ./workflows/backup.yaml
@@ -32,6 +41,7 @@ Examples:
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		strict, _ := cmd.Flags().GetBool("strict")
+		noLint, _ := cmd.Flags().GetBool("no-lint")
 
 		// Expand glob patterns
 		var workflowFiles []string
@@ -56,14 +66,15 @@ Examples:
 		invalidCount := 0
 		warnings := 0
 
-		fmt.Println("🔍 Validating workflow files...\n")
+		fmt.Printf("🔍 %s\n\n", i18n.T("validate.start"))
 
+		names := make(map[string]string) // workflow name to the first file defining it
 		for _, file := range workflowFiles {
-			fmt.Printf("Validating: %s\n", file)
+			fmt.Println(i18n.T("validate.file", file))
 
 			// Check if file exists
 			if _, err := os.Stat(file); os.IsNotExist(err) {
-				fmt.Printf("  ✗ File does not exist\n\n")
+				fmt.Printf("  ✗ %s\n\n", i18n.T("validate.file_missing"))
 				invalidCount++
 				continue
 			}
@@ -71,53 +82,131 @@ Examples:
 			// Parse and validate workflow
 			wf, err := parser.ParseWorkflowFile(file)
 			if err != nil {
-				fmt.Printf("  ✗ Validation failed: %v\n\n", err)
+				fmt.Printf("  ✗ %s\n\n", i18n.T("validate.file_invalid", err))
 				invalidCount++
 				continue
 			}
 
 			// Print validation details
-			fmt.Printf("  ✓ YAML syntax valid\n")
-			fmt.Printf("  ✓ Workflow name: '%s'\n", wf.Name)
-			fmt.Printf("  ✓ Trigger type: '%s'\n", wf.Trigger.Type)
+			format := "YAML"
+			if filepath.Ext(file) == ".json" {
+				format = "JSON"
+			}
+			fmt.Printf("  ✓ %s\n", i18n.T("validate.syntax_valid", format))
+			// The agent doesn't start a second workflow of the same name
+			if owner, taken := names[wf.Name]; taken && owner != file {
+				fmt.Printf("  ✗ %s\n\n", i18n.T("validate.duplicate_name", wf.Name, owner))
+				invalidCount++
+				continue
+			}
+			names[wf.Name] = file
+			fmt.Printf("  ✓ %s\n", i18n.T("validate.workflow_name", wf.Name))
+			fmt.Printf("  ✓ %s\n", i18n.T("validate.trigger_type", wf.Trigger.Type))
+			if !wf.IsEnabled() {
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.disabled"))
+			}
 
 			// Validate trigger configuration
 			switch wf.Trigger.Type.String() {
 			case "cron":
 				if wf.Trigger.Schedule != "" {
-					fmt.Printf("  ✓ Cron schedule: '%s'\n", wf.Trigger.Schedule)
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.cron_schedule", wf.Trigger.Schedule))
+					if runs, err := nextRunLines(wf.Trigger); err == nil {
+						fmt.Printf("  ✓ %s\n", i18n.T("validate.next_runs", len(runs)))
+						for _, run := range runs {
+							fmt.Printf("      %s\n", run)
+						}
+					}
+				}
+				if wf.Trigger.Jitter != "" {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.jitter", wf.Trigger.Jitter))
 				}
 				// Warn if filewatch fields are present
 				if wf.Trigger.Path != "" || len(wf.Trigger.Events) > 0 {
-					fmt.Printf("  ⚠ Warning: filewatch fields present in cron trigger (will be ignored)\n")
+					fmt.Printf("  ⚠ %s\n", i18n.T("validate.cron_filewatch_fields"))
 					warnings++
 					if strict {
 						invalidCount++
-						fmt.Printf("  ✗ Strict mode: warnings treated as errors\n\n")
+						fmt.Printf("  ✗ %s\n\n", i18n.T("validate.strict_warnings"))
 						continue
 					}
 				}
 			case "filewatch":
 				if wf.Trigger.Path != "" {
-					fmt.Printf("  ✓ Watch path: '%s'\n", wf.Trigger.Path)
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.watch_path", wf.Trigger.Path))
 				}
 				if len(wf.Trigger.Events) > 0 {
-					fmt.Printf("  ✓ Events: %v\n", wf.Trigger.Events)
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.events", wf.Trigger.Events))
+				}
+				if len(wf.Trigger.Patterns) > 0 {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.patterns", wf.Trigger.Patterns))
+				}
+				if len(wf.Trigger.Ignore) > 0 {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.ignore", wf.Trigger.Ignore))
+				}
+				if wf.Trigger.Debounce != "" {
+					key := "validate.debounce_per_file"
+					if wf.Trigger.Batch {
+						key = "validate.debounce_batched"
+					}
+					fmt.Printf("  ✓ %s\n", i18n.T(key, wf.Trigger.Debounce))
 				}
 				// Warn if cron schedule is present
 				if wf.Trigger.Schedule != "" {
-					fmt.Printf("  ⚠ Warning: schedule field present in filewatch trigger (will be ignored)\n")
+					fmt.Printf("  ⚠ %s\n", i18n.T("validate.filewatch_schedule"))
 					warnings++
 					if strict {
 						invalidCount++
-						fmt.Printf("  ✗ Strict mode: warnings treated as errors\n\n")
+						fmt.Printf("  ✗ %s\n\n", i18n.T("validate.strict_warnings"))
 						continue
 					}
 				}
+			case "httppoll":
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.poll_url", wf.Trigger.URL, wf.Trigger.Interval))
+				switch {
+				case wf.Trigger.MatchStatus != 0 || wf.Trigger.MatchBody != "":
+					var conditions []string
+					if wf.Trigger.MatchStatus != 0 {
+						conditions = append(conditions, i18n.T("validate.condition_status", wf.Trigger.MatchStatus))
+					}
+					if wf.Trigger.MatchBody != "" {
+						conditions = append(conditions, i18n.T("validate.condition_body", wf.Trigger.MatchBody))
+					}
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.fires_when", strings.Join(conditions, i18n.T("validate.condition_and"))))
+				default:
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.fires_on_change"))
+				}
+			case "redis":
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.redis_channel", wf.Trigger.Channel))
+			case "mqtt":
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.mqtt_topic", wf.Trigger.Topic, wf.Trigger.QoS))
+			case "log":
+				if wf.Trigger.Unit != "" {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.journal_unit", wf.Trigger.Unit))
+				} else {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.log_file", wf.Trigger.Path))
+				}
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.match", wf.Trigger.Match))
+			case "shutdown":
+				timeout, _ := wf.Trigger.ShutdownTimeout()
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.shutdown_timeout", timeout))
+			}
+
+			if wf.ConcurrencyPolicy != "" {
+				fmt.Printf("  ✓ %s\n", i18n.T("validate.concurrency_policy", wf.ConcurrencyPolicy))
+			}
+
+			if wf.Network != nil {
+				if wf.Network.Proxy != "" {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.proxy", wf.Network.Proxy))
+				}
+				for _, host := range sortedKeys(wf.Network.DNSOverride) {
+					fmt.Printf("  ✓ %s\n", i18n.T("validate.dns_override", host, wf.Network.DNSOverride[host]))
+				}
 			}
 
 			// Validate actions
-			fmt.Printf("  ✓ Actions count: %d\n", len(wf.Actions))
+			fmt.Printf("  ✓ %s\n", i18n.T("validate.actions_count", len(wf.Actions)))
 			for i, action := range wf.Actions {
 				actionType := action.Type.String()
 				fmt.Printf("    [%d] %s (%s)\n", i+1, action.Name, actionType)
@@ -125,28 +214,70 @@ Examples:
 				// Validate action-specific fields
 				switch actionType {
 				case "bash":
-					if action.Command == "" {
-						fmt.Printf("      ✗ Missing required field: command\n")
+					if action.Command == "" && action.ScriptFile == "" && len(action.Exec) == 0 {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_command"))
 						invalidCount++
 						fmt.Printf("\n")
 						continue
 					}
 				case "http":
 					if action.URL == "" {
-						fmt.Printf("      ✗ Missing required field: url\n")
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "url"))
 						invalidCount++
 						fmt.Printf("\n")
 						continue
 					}
 					if action.Method == "" {
-						fmt.Printf("      ✗ Missing required field: method\n")
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "method"))
+						invalidCount++
+						fmt.Printf("\n")
+						continue
+					}
+				case "download":
+					if action.URL == "" || action.Path == "" {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_url_path"))
+						invalidCount++
+						fmt.Printf("\n")
+						continue
+					}
+				case "storage":
+					if action.URL == "" || action.Path == "" {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_url_path"))
+						invalidCount++
+						fmt.Printf("\n")
+						continue
+					}
+				case "git", "file":
+					if action.Path == "" {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "path"))
+						invalidCount++
+						fmt.Printf("\n")
+						continue
+					}
+				case "template":
+					if action.Template == "" || action.Path == "" {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "template, path"))
+						invalidCount++
+						fmt.Printf("\n")
+						continue
+					}
+				case "wait":
+					if action.Duration == "" && action.Until == "" {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "duration, until"))
+						invalidCount++
+						fmt.Printf("\n")
+						continue
+					}
+				case "workflow":
+					if action.Workflow == "" && action.WorkflowFile == "" {
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "workflow, workflowFile"))
 						invalidCount++
 						fmt.Printf("\n")
 						continue
 					}
 				case "custom":
 					if action.FunctionName == "" {
-						fmt.Printf("      ✗ Missing required field: function_name\n")
+						fmt.Printf("      ✗ %s\n", i18n.T("validate.missing_field", "function_name"))
 						invalidCount++
 						fmt.Printf("\n")
 						continue
@@ -154,38 +285,74 @@ Examples:
 				}
 			}
 
-			fmt.Printf("  ✓ Ready to deploy\n\n")
+			// Lint bash commands for common mistakes
+			if !noLint {
+				findings := lint.Workflow(wf)
+				for _, f := range findings {
+					fmt.Printf("  ⚠ %s\n", i18n.T("validate.lint", f))
+				}
+				warnings += len(findings)
+				if len(findings) > 0 && strict {
+					invalidCount++
+					fmt.Printf("  ✗ %s\n\n", i18n.T("validate.strict_warnings"))
+					continue
+				}
+			}
+
+			fmt.Printf("  ✓ %s\n\n", i18n.T("validate.ready"))
 			validCount++
 		}
 
 		// Print summary
 		fmt.Println("─────────────────────────────────────")
-		fmt.Printf("Validation Summary:\n")
-		fmt.Printf("  Total files: %d\n", len(workflowFiles))
-		fmt.Printf("  ✓ Valid: %d\n", validCount)
-		fmt.Printf("  ✗ Invalid: %d\n", invalidCount)
+		fmt.Println(i18n.T("validate.summary"))
+		fmt.Printf("  %s\n", i18n.T("validate.total_files", len(workflowFiles)))
+		fmt.Printf("  ✓ %s\n", i18n.T("validate.valid", validCount))
+		fmt.Printf("  ✗ %s\n", i18n.T("validate.invalid", invalidCount))
 		if warnings > 0 {
-			fmt.Printf("  ⚠ Warnings: %d\n", warnings)
+			fmt.Printf("  ⚠ %s\n", i18n.T("validate.warnings", warnings))
 		}
 		fmt.Println("─────────────────────────────────────")
 
 		// Exit with appropriate code
 		if invalidCount > 0 {
-			fmt.Println("\n❌ Validation failed")
+			fmt.Printf("\n❌ %s\n", i18n.T("validate.failed"))
 			os.Exit(1)
 		} else if warnings > 0 && strict {
-			fmt.Println("\n❌ Validation failed (strict mode)")
+			fmt.Printf("\n❌ %s\n", i18n.T("validate.failed_strict"))
 			os.Exit(1)
 		} else {
-			fmt.Println("\n✅ All workflows valid")
+			fmt.Printf("\n✅ %s\n", i18n.T("validate.all_valid"))
 			os.Exit(0)
 		}
 	},
 }
 
+// nextRunPreviewCount is how many upcoming cron fire times validate and dry-run show
+const nextRunPreviewCount = 5
+
+// nextRunLines formats the next fire times of a cron schedule in the local time
+// zone, with the UTC time alongside when the two differ
+func nextRunLines(t workflow.Trigger) ([]string, error) {
+	runs, err := trigger.NextRuns(t, time.Now(), nextRunPreviewCount)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, len(runs))
+	for i, run := range runs {
+		lines[i] = run.Format("Mon 2006-01-02 15:04:05 MST")
+		if _, offset := run.Zone(); offset != 0 {
+			lines[i] += run.UTC().Format(" (15:04 UTC)")
+		}
+	}
+	return lines, nil
+}
+
 func init() {
 	rootCmd.AddCommand(validateCmd)
 
 	// Add flags
 	validateCmd.Flags().Bool("strict", false, "Treat warnings as errors")
+	validateCmd.Flags().Bool("no-lint", false, "Skip linting bash commands")
 }
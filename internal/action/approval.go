@@ -0,0 +1,112 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/approval"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeApproval, approvalExecutor{})
+}
+
+// approvalExecutor waits until someone approves or rejects the run
+type approvalExecutor struct{}
+
+func (approvalExecutor) Validate(act *workflow.Action) error {
+	if act.Timeout != "" && !strings.Contains(act.Timeout, "{{") {
+		d, err := time.ParseDuration(act.Timeout)
+		if err != nil {
+			return fmt.Errorf("has invalid 'timeout' '%s': %w", act.Timeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("must have a positive 'timeout'")
+		}
+	}
+	if act.Retry != nil {
+		return fmt.Errorf("cannot have 'retry'")
+	}
+	return nil
+}
+
+func (approvalExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteApprovalAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// DefaultApprovalTimeout is how long an approval action waits for a decision
+// if it has no timeout
+const DefaultApprovalTimeout = 24 * time.Hour
+
+// ExecuteApprovalAction pauses the run until the action is approved or
+// rejected through the agent's dashboard or API, and returns who approved
+// it. A rejection fails the action; so does the timeout expiring, with
+// status timeout.
+func ExecuteApprovalAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeApproval {
+		return "", fmt.Errorf("invalid action type for ExecuteApprovalAction: expected %s, got %s", workflow.ActionTypeApproval, action.Type)
+	}
+
+	timeout := DefaultApprovalTimeout
+	if action.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(action.Timeout); err != nil {
+			return "", fmt.Errorf("invalid timeout duration: %w", err)
+		}
+	}
+
+	startTime := time.Now()
+	name := ""
+	if len(workflowName) > 0 {
+		name = workflowName[0]
+	}
+
+	req, decision, err := approval.Wait(ctx, approval.Request{Workflow: name, Action: action.Name, Message: action.Message}, timeout)
+	var output string
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		err = &retry.TimeoutError{Err: fmt.Errorf("approval action '%s' was not approved within %s: %w", action.Name, timeout, err)}
+	case errors.Is(err, approval.ErrUnavailable):
+		err = fmt.Errorf("approval action '%s' cannot wait for approval: %w", action.Name, err)
+	case err != nil:
+		err = fmt.Errorf("approval action '%s' cancelled while waiting for approval: %w", action.Name, err)
+	case !decision.Approved:
+		err = fmt.Errorf("approval action '%s' %s", action.Name, describeDecision("rejected", decision))
+	default:
+		output = describeDecision("approved", decision)
+	}
+
+	// Record metrics if workflow name is provided
+	if name != "" {
+		metrics.RecordActionExecution(name, action.Name, string(workflow.ActionTypeApproval), Status(err), time.Since(startTime))
+	}
+
+	if err != nil {
+		logger.L().Errorw("Approval Action failed", "action_name", action.Name, "approval_id", req.ID, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Approval Action completed successfully", "action_name", action.Name, "approval_id", req.ID, "approved_by", decision.By)
+	return output, nil
+}
+
+// describeDecision says who made a decision and why, e.g.
+// "approved by alice: ship it"
+func describeDecision(verb string, d approval.Decision) string {
+	s := verb
+	if d.By != "" {
+		s += " by " + d.By
+	}
+	if d.Comment != "" {
+		s += ": " + d.Comment
+	}
+	return s
+}
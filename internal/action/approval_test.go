@@ -0,0 +1,62 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/approval"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestExecuteApprovalAction(t *testing.T) {
+	approval.Enable()
+	act := &workflow.Action{Type: workflow.ActionTypeApproval, Name: "deploy-gate", Message: "Deploy 1.4.2 to production?", Timeout: "1m"}
+
+	// decide decides the action's request once it is pending
+	decide := func(d approval.Decision) {
+		for {
+			for _, req := range approval.Pending() {
+				if req.Action == act.Name {
+					if _, err := approval.Decide(req.ID, d); err != nil {
+						t.Errorf("Failed to decide: %v", err)
+					}
+					return
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	t.Run("Approved", func(t *testing.T) {
+		go decide(approval.Decision{Approved: true, By: "alice", Comment: "ship it"})
+		output, err := ExecuteApprovalAction(context.Background(), act)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "approved by alice: ship it" {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		go decide(approval.Decision{By: "bob", Comment: "freeze"})
+		_, err := ExecuteApprovalAction(context.Background(), act)
+		if err == nil || err.Error() != "approval action 'deploy-gate' rejected by bob: freeze" {
+			t.Errorf("Expected a rejection, got: %v", err)
+		}
+		if Status(err) != workflow.StatusFailed {
+			t.Errorf("Expected status failed, got %s", Status(err))
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		timeout := *act
+		timeout.Timeout = "20ms"
+		_, err := ExecuteApprovalAction(context.Background(), &timeout)
+		if !errors.Is(err, context.DeadlineExceeded) || Status(err) != workflow.StatusTimeout {
+			t.Errorf("Expected a timeout, got: %v", err)
+		}
+	})
+}
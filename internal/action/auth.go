@@ -0,0 +1,208 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// tokenExpiryMargin is how long before it expires a cached OAuth2 token is
+// replaced, so it doesn't expire while a request is in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// oauthTokens caches OAuth2 access tokens by client, see cachedToken
+var oauthTokens = struct {
+	sync.Mutex
+	entries map[string]*oauthToken
+}{entries: make(map[string]*oauthToken)}
+
+// oauthToken is a cached access token. mu is held while it is fetched, so
+// concurrent requests of the same client wait for one token request.
+type oauthToken struct {
+	mu     sync.Mutex
+	value  string
+	expiry time.Time // zero if the token server didn't say
+}
+
+// setAuth sets the Authorization header of an HTTP action's request from its
+// auth block, fetching an OAuth2 token first if none is cached
+func setAuth(ctx context.Context, req *http.Request, action *workflow.Action) error {
+	auth := action.Auth
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case workflow.AuthTypeBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case workflow.AuthTypeBearer:
+		if auth.Token == "" {
+			return fmt.Errorf("HTTP action '%s' has an empty bearer token", action.Name)
+		}
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case workflow.AuthTypeOAuth2:
+		token, err := cachedToken(ctx, action)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return fmt.Errorf("HTTP action '%s' has unsupported auth type '%s'", action.Name, auth.Type)
+	}
+	return nil
+}
+
+// cachedToken returns the cached OAuth2 token of the action's client, or
+// fetches a new one if there is none or it is about to expire
+func cachedToken(ctx context.Context, action *workflow.Action) (string, error) {
+	entry := tokenEntry(action.Auth)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.value != "" && (entry.expiry.IsZero() || time.Now().Before(entry.expiry)) {
+		return entry.value, nil
+	}
+
+	value, expiresIn, err := fetchToken(ctx, action)
+	if err != nil {
+		return "", err
+	}
+	entry.value = value
+	entry.expiry = time.Time{}
+	if expiresIn > 0 {
+		margin := min(tokenExpiryMargin, expiresIn/2)
+		entry.expiry = time.Now().Add(expiresIn - margin)
+	}
+	return value, nil
+}
+
+// invalidateToken drops the cached OAuth2 token of the action's client, e.g.
+// when it was rejected, so the next request fetches a new one
+func invalidateToken(auth *workflow.AuthConfig) {
+	entry := tokenEntry(auth)
+	entry.mu.Lock()
+	entry.value = ""
+	entry.mu.Unlock()
+}
+
+// tokenEntry returns the cache entry of an OAuth2 client. Clients are told
+// apart by everything that goes into their token request, so a rotated
+// secret gets a new token.
+func tokenEntry(auth *workflow.AuthConfig) *oauthToken {
+	sum := sha256.Sum256([]byte(strings.Join([]string{auth.TokenURL, auth.ClientID, auth.ClientSecret, strings.Join(auth.Scopes, " "), auth.Audience}, "\x00")))
+	key := hex.EncodeToString(sum[:])
+
+	oauthTokens.Lock()
+	defer oauthTokens.Unlock()
+	entry, ok := oauthTokens.entries[key]
+	if !ok {
+		entry = &oauthToken{}
+		oauthTokens.entries[key] = entry
+	}
+	return entry
+}
+
+// fetchToken requests a token with the client credentials grant. The client
+// authenticates with HTTP basic auth, which token servers must support
+// (RFC 6749, section 2.3.1). The request goes through the workflow's network
+// configuration and uses the action's TLS settings, but never its Unix socket.
+func fetchToken(ctx context.Context, action *workflow.Action) (string, time.Duration, error) {
+	auth := action.Auth
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	if auth.Audience != "" {
+		form.Set("audience", auth.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("HTTP action '%s': failed to create OAuth2 token request: %w", action.Name, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	client, err := newHTTPClient(&workflow.Action{Network: action.Network, Proxy: action.Proxy, TLS: action.TLS})
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("HTTP action '%s': OAuth2 token request failed: %w", action.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("HTTP action '%s': failed to read OAuth2 token response: %w", action.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("HTTP action '%s': OAuth2 token request failed with status %d: %s", action.Name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("HTTP action '%s': invalid OAuth2 token response: %w", action.Name, err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("HTTP action '%s': OAuth2 token response has no access_token", action.Name)
+	}
+	expiresIn, _ := token.ExpiresIn.Int64()
+
+	logger.L().Infow("Fetched OAuth2 token",
+		"action_name", action.Name,
+		"token_url", auth.TokenURL,
+		"client_id", auth.ClientID,
+		"expires_in", expiresIn)
+
+	return token.AccessToken, time.Duration(expiresIn) * time.Second, nil
+}
+
+// validateAuth checks that an HTTP action's auth block has the fields of its
+// type, and that the action doesn't also set the Authorization header
+func validateAuth(auth *workflow.AuthConfig, headers map[string]string) error {
+	for name := range headers {
+		if strings.EqualFold(name, "Authorization") {
+			return fmt.Errorf("cannot be combined with an 'Authorization' header")
+		}
+	}
+
+	switch auth.Type {
+	case workflow.AuthTypeBasic:
+		if auth.Username == "" {
+			return fmt.Errorf("basic auth requires a 'username'")
+		}
+	case workflow.AuthTypeBearer:
+		if auth.Token == "" {
+			return fmt.Errorf("bearer auth requires a 'token'")
+		}
+	case workflow.AuthTypeOAuth2:
+		if auth.TokenURL == "" || auth.ClientID == "" || auth.ClientSecret == "" {
+			return fmt.Errorf("oauth2 auth requires a 'tokenUrl', 'clientId' and 'clientSecret'")
+		}
+		if !strings.Contains(auth.TokenURL, "{{") {
+			if u, err := url.Parse(auth.TokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("'tokenUrl' '%s' must be an absolute http or https URL", auth.TokenURL)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type '%s'. Must be one of: %s, %s, %s", auth.Type, workflow.AuthTypeBasic, workflow.AuthTypeBearer, workflow.AuthTypeOAuth2)
+	}
+	return nil
+}
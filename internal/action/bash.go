@@ -2,78 +2,382 @@ package action
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/codecrafted007/autozap/internal/config"
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/metrics"
 	"github.com/codecrafted007/autozap/internal/retry"
 	"github.com/codecrafted007/autozap/internal/workflow"
 )
 
+func init() {
+	Register(workflow.ActionTypeBash, bashExecutor{})
+}
+
+// bashExecutor runs a command, a script file or a program without a shell
+type bashExecutor struct{}
+
+func (bashExecutor) Fields() []string {
+	return []string{"workingDir", "runAsUser", "runAsGroup", "shell", "exec", "streamOutput", "maxOutput"}
+}
+
+func (bashExecutor) Validate(act *workflow.Action) error {
+	if act.Command == "" && act.ScriptFile == "" && len(act.Exec) == 0 {
+		return fmt.Errorf("must have a 'command', 'scriptFile' or 'exec'")
+	}
+	if act.Command != "" && act.ScriptFile != "" {
+		return fmt.Errorf("cannot have both 'command' and 'scriptFile'")
+	}
+	if len(act.Exec) > 0 {
+		if act.Command != "" || act.ScriptFile != "" {
+			return fmt.Errorf("cannot combine 'exec' with 'command' or 'scriptFile'")
+		}
+		if act.Shell != "" {
+			return fmt.Errorf("cannot have a 'shell' with 'exec', which runs without one")
+		}
+		if act.Exec[0] == "" {
+			return fmt.Errorf("must name a program as the first 'exec' element")
+		}
+	}
+	switch act.Shell {
+	case "", "bash", "sh", "zsh", "powershell":
+	default:
+		return fmt.Errorf("has invalid 'shell' '%s'. Must be one of: bash, sh, zsh, powershell", act.Shell)
+	}
+	if act.Stdin != "" && act.StdinFile != "" {
+		return fmt.Errorf("cannot have both 'stdin' and 'stdinFile'")
+	}
+	if act.WorkingDir != "" && !filepath.IsAbs(act.WorkingDir) && !strings.Contains(act.WorkingDir, "{{") {
+		return fmt.Errorf("has invalid 'workingDir' '%s': must be an absolute path", act.WorkingDir)
+	}
+	if err := validateRunAs(act.RunAsUser, act.RunAsGroup); err != nil {
+		return err
+	}
+	if act.MaxOutput != "" {
+		n, err := config.ParseSize(act.MaxOutput)
+		if err != nil {
+			return fmt.Errorf("has invalid 'maxOutput': %w", err)
+		}
+		if n < 1<<10 {
+			return fmt.Errorf("'maxOutput' must be at least 1KB, got '%s'", act.MaxOutput)
+		}
+	}
+	//Warn if HTTP/Custom fields are present
+	if act.URL != "" || act.Method != "" || len(act.Headers) > 0 || act.Body != "" || act.BodyFile != "" || len(act.FormData) > 0 || len(act.Files) > 0 {
+		logger.L().Warnf("Bash action %s has unexpected HTTP fields; they will be ignored.", act.Name)
+	}
+	return nil
+}
+
+func (bashExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteBashActionContext(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
 func ExecuteBashAction(action *workflow.Action, workflowName ...string) error {
+	_, err := ExecuteBashActionWithOutput(action, workflowName...)
+	return err
+}
+
+// ExecuteBashActionWithOutput executes a bash action and returns the combined
+// stdout and stderr of the last attempt alongside the error.
+func ExecuteBashActionWithOutput(action *workflow.Action, workflowName ...string) (string, error) {
+	return ExecuteBashActionContext(context.Background(), action, workflowName...)
+}
+
+// ExecuteBashActionContext is like ExecuteBashActionWithOutput but kills the
+// command and stops retrying when ctx is cancelled.
+func ExecuteBashActionContext(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
 	if action.Type != workflow.ActionTypeBash {
-		return fmt.Errorf("invalid action type for ExecuteBashAction: expected %s, got %s", workflow.ActionTypeBash, action.Type)
+		return "", fmt.Errorf("invalid action type for ExecuteBashAction: expected %s, got %s", workflow.ActionTypeBash, action.Type)
 	}
-	if action.Command == "" {
-		return fmt.Errorf("bash action command cannot be empty")
+	if action.Command == "" && action.ScriptFile == "" && len(action.Exec) == 0 {
+		return "", fmt.Errorf("bash action command cannot be empty")
+	}
+	if action.Command == "" && action.ScriptFile != "" {
+		content, _, err := LoadScript(action.ScriptFile)
+		if err != nil {
+			return "", err
+		}
+		withScript := *action
+		withScript.Command = content
+		action = &withScript
 	}
 
 	// Track total execution time (including retries)
 	totalStartTime := time.Now()
 
 	// Execute with retry logic
-	err := retry.ExecuteWithRetry(action.Name, action.Retry, func() error {
-		return executeBashActionOnce(action, workflowName...)
+	var output string
+	tries := 0
+	err := retry.ExecuteWithRetry(ctx, action.Name, action.Retry, func() error {
+		if err := ctx.Err(); err != nil {
+			// Cancelled while waiting to retry; don't start the command again
+			return fmt.Errorf("bash action %s cancelled: %w", action.Name, err)
+		}
+		countAttempt(action, &tries)
+		var runErr error
+		output, runErr = executeBashActionOnce(ctx, action, workflowName...)
+		return runErr
 	})
 
 	totalDuration := time.Since(totalStartTime)
 
 	// Record metrics if workflow name is provided
 	if len(workflowName) > 0 && workflowName[0] != "" {
-		status := "success"
-		if err != nil {
-			status = "failed"
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeBash), Status(err), totalDuration)
+		metrics.RecordActionAttempts(workflowName[0], action.Name, string(workflow.ActionTypeBash), tries)
+	}
+
+	return output, err
+}
+
+// LoadScript reads a bash action's script file and returns its content and
+// SHA-256 hash, which is recorded with each run for auditability
+func LoadScript(path string) (content string, sha256Hex string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read script file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:]), nil
+}
+
+// bashWaitDelay bounds how long a cancelled command's output is still read
+const bashWaitDelay = 2 * time.Second
+
+// maxPooledOutput is the largest output buffer kept for reuse, so one command
+// with a large output doesn't pin its memory
+const maxPooledOutput = 64 << 10
+
+// outputBuffers holds the buffers command output is captured into, reused
+// across runs of frequently triggered workflows
+var outputBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getOutputBuffer() *bytes.Buffer {
+	return outputBuffers.Get().(*bytes.Buffer)
+}
+
+func putOutputBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledOutput {
+		return
+	}
+	buf.Reset()
+	outputBuffers.Put(buf)
+}
+
+// newCommand returns the command running a bash action: its exec program
+// without a shell, or its command in its shell, bash by default
+func newCommand(ctx context.Context, action *workflow.Action) (*exec.Cmd, error) {
+	if len(action.Exec) > 0 {
+		return exec.CommandContext(ctx, action.Exec[0], action.Exec[1:]...), nil
+	}
+
+	switch action.Shell {
+	case "", "bash", "sh", "zsh":
+		shell := action.Shell
+		if shell == "" {
+			shell = "bash"
 		}
-		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeBash), status, totalDuration)
+		if action.ScriptFile != "" {
+			// Run the loaded script content with $0 set to the script path
+			return exec.CommandContext(ctx, shell, "-c", action.Command, action.ScriptFile), nil
+		}
+		return exec.CommandContext(ctx, shell, "-c", action.Command), nil
+	case "powershell":
+		// PowerShell 7 is pwsh on every platform, Windows PowerShell only powershell
+		program := "pwsh"
+		if _, err := exec.LookPath(program); err != nil {
+			program = "powershell"
+		}
+		return exec.CommandContext(ctx, program, "-NoProfile", "-NonInteractive", "-Command", action.Command), nil
 	}
+	return nil, fmt.Errorf("bash action %s has unsupported shell '%s'", action.Name, action.Shell)
+}
 
-	return err
+// commandLine returns what a bash action runs, for logs: its command, or its
+// exec program and arguments
+func commandLine(action *workflow.Action) string {
+	if len(action.Exec) > 0 {
+		return strings.Join(action.Exec, " ")
+	}
+	return action.Command
+}
+
+// configureProcess sets the directory the command runs in and the user and
+// group it runs as
+func configureProcess(cmd *exec.Cmd, action *workflow.Action) error {
+	if action.WorkingDir != "" {
+		if !filepath.IsAbs(action.WorkingDir) {
+			return fmt.Errorf("bash action %s working directory must be an absolute path, got '%s'", action.Name, action.WorkingDir)
+		}
+		info, err := os.Stat(action.WorkingDir)
+		if err != nil {
+			return fmt.Errorf("bash action %s working directory not found: %s", action.Name, action.WorkingDir)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("bash action %s working directory is not a directory: %s", action.Name, action.WorkingDir)
+		}
+		cmd.Dir = action.WorkingDir
+	}
+	if action.RunAsUser != "" || action.RunAsGroup != "" {
+		if err := runAs(cmd, action.RunAsUser, action.RunAsGroup); err != nil {
+			return fmt.Errorf("bash action %s %w", action.Name, err)
+		}
+	}
+	return nil
 }
 
 // executeBashActionOnce executes a bash action once without retry logic
-func executeBashActionOnce(action *workflow.Action, workflowName ...string) error {
+func executeBashActionOnce(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
 	logger.L().Infow("Executing Bash Action",
 		"action_name", action.Name,
-		"command", action.Command,
+		"command", commandLine(action),
+		"shell", action.Shell,
 	)
 
-	cmd := exec.Command("bash", "-c", action.Command)
+	cmd, err := newCommand(ctx, action)
+	if err != nil {
+		return "", err
+	}
+	if len(action.Env) > 0 {
+		cmd.Env = append(os.Environ(), action.Env...)
+	}
+	// Children of a killed bash may keep the output pipes open; don't wait for them
+	cmd.WaitDelay = bashWaitDelay
+	if err := configureProcess(cmd, action); err != nil {
+		logger.L().Errorw("Bash Action failed", "action_name", action.Name, "error", err)
+		return "", err
+	}
+
+	switch {
+	case action.StdinFile != "":
+		f, err := os.Open(action.StdinFile)
+		if err != nil {
+			return "", fmt.Errorf("bash action %s failed to open stdin file: %w", action.Name, err)
+		}
+		defer f.Close()
+		cmd.Stdin = f
+	case action.Stdin != "":
+		cmd.Stdin = strings.NewReader(action.Stdin)
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	wfName := ""
+	if len(workflowName) > 0 {
+		wfName = workflowName[0]
+	}
+	limit := 0
+	if action.MaxOutput != "" {
+		n, err := config.ParseSize(action.MaxOutput)
+		if err != nil {
+			return "", fmt.Errorf("bash action %s has invalid 'maxOutput': %w", action.Name, err)
+		}
+		limit = int(n)
+	}
+	stdoutCapture := newOutputCapture(wfName, action.Name, "stdout", limit)
+	stderrCapture := newOutputCapture(wfName, action.Name, "stderr", limit)
+	defer stdoutCapture.Close()
+	defer stderrCapture.Close()
+	cmd.Stdout = stdoutCapture
+	cmd.Stderr = stderrCapture
+	var stdoutLines, stderrLines *lineLogger
+	if action.StreamOutput {
+		stdoutLines = newLineLogger(wfName, action.Name, "stdout")
+		stderrLines = newLineLogger(wfName, action.Name, "stderr")
+		cmd.Stdout = io.MultiWriter(stdoutCapture, stdoutLines)
+		cmd.Stderr = io.MultiWriter(stderrCapture, stderrLines)
+	}
 
-	err := cmd.Run()
+	if action.OnOutput != nil {
+		stop := reportProgress(action.OnOutput, stdoutCapture, stderrCapture)
+		err = cmd.Run()
+		stop()
+	} else {
+		err = cmd.Run()
+	}
+	if action.StreamOutput {
+		stdoutLines.Flush()
+		stderrLines.Flush()
+	}
 
-	logFields := []interface{}{
+	stdout, stderr := stdoutCapture.String(), stderrCapture.String()
+	output := stdout
+	if stderr != "" {
+		output += stderr
+	}
+
+	// Room for the exit code and spill files, so the logs don't copy the fields
+	logFields := make([]interface{}, 0, 14)
+	logFields = append(logFields,
 		"action_name", action.Name,
-		"command", action.Command,
-		"stdout", stdout.String(),
-		"stderr", stderr.String(),
+		"command", commandLine(action),
+		"stdout", stdout,
+		"stderr", stderr,
+	)
+	if path := stdoutCapture.SpillPath(); path != "" {
+		logFields = append(logFields, "stdout_file", path)
+	}
+	if path := stderrCapture.SpillPath(); path != "" {
+		logFields = append(logFields, "stderr_file", path)
 	}
 
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// Killed because the run was cancelled
+			logger.L().Errorw("Bash Action stopped", logFields...)
+			stopErr := fmt.Errorf("bash action %s stopped: %w", action.Name, ctxErr)
+			if errors.Is(ctxErr, context.DeadlineExceeded) {
+				return output, &retry.TimeoutError{Err: stopErr}
+			}
+			return output, stopErr
+		}
 		if exitError, ok := err.(*exec.ExitError); ok {
 			logFields = append(logFields, "exit_code", exitError.ExitCode())
 			logger.L().Errorw("Bash Action failed", logFields...)
-			return fmt.Errorf("bash action %s failed with exit code %d: %w", action.Name, exitError.ExitCode(), exitError)
+			return output, &retry.ExitCodeError{
+				ExitCode: exitError.ExitCode(),
+				Err:      fmt.Errorf("bash action %s failed with exit code %d: %w", action.Name, exitError.ExitCode(), exitError),
+			}
 		} else {
 			logger.L().Errorw("Bash Action failed", logFields...)
-			return fmt.Errorf("bash action %s failed to execute:  %v", action.Name, err)
+			return output, fmt.Errorf("bash action %s failed to execute:  %v", action.Name, err)
 		}
 	}
 	logger.L().Infow("Bash Action completed successfully", logFields...)
+	return output, nil
+}
+
+// validateRunAs checks that the user and group a bash action runs as exist on
+// this host. Whether the agent may switch to them is only known when it runs.
+func validateRunAs(runAsUser, runAsGroup string) error {
+	if runAsUser != "" && !strings.Contains(runAsUser, "{{") {
+		if _, err := user.Lookup(runAsUser); err != nil {
+			if _, idErr := user.LookupId(runAsUser); idErr != nil {
+				return fmt.Errorf("has unknown 'runAsUser' '%s'", runAsUser)
+			}
+		}
+	}
+	if runAsGroup != "" && !strings.Contains(runAsGroup, "{{") {
+		if _, err := user.LookupGroup(runAsGroup); err != nil {
+			if _, idErr := user.LookupGroupId(runAsGroup); idErr != nil {
+				return fmt.Errorf("has unknown 'runAsGroup' '%s'", runAsGroup)
+			}
+		}
+	}
 	return nil
 }
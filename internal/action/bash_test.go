@@ -1,9 +1,20 @@
 package action
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/codecrafted007/autozap/internal/config"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/retry"
 	"github.com/codecrafted007/autozap/internal/workflow"
 )
 
@@ -52,6 +63,52 @@ func TestExecuteBashAction(t *testing.T) {
 		}
 	})
 
+	t.Run("Exit Code Error", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "test-exit-3",
+			Command: "exit 3",
+		}
+
+		err := ExecuteBashAction(action)
+		var exitErr *retry.ExitCodeError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode != 3 {
+			t.Fatalf("Expected an ExitCodeError with exit code 3, got: %v", err)
+		}
+	})
+
+	t.Run("Retry On Exit Code", func(t *testing.T) {
+		counter := filepath.Join(t.TempDir(), "attempts")
+		retryConfig := &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "10ms", RetryOn: []string{"exit:75"}}
+
+		// Exit code 75 (temporary failure) is retried until the command succeeds
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "test-retry-exit",
+			Command: "echo x >> " + counter + "; [ $(wc -l < " + counter + ") -ge 2 ] || exit 75",
+			Retry:   retryConfig,
+		}
+		if err := ExecuteBashAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		// Other exit codes are not
+		attempts := 0
+		action = &workflow.Action{
+			Type:     workflow.ActionTypeBash,
+			Name:     "test-no-retry-exit",
+			Command:  "exit 1",
+			Retry:    retryConfig,
+			Attempts: &attempts,
+		}
+		if err := ExecuteBashAction(action); err == nil {
+			t.Fatal("Expected error for command with exit code 1, got nil")
+		}
+		if attempts != 1 {
+			t.Errorf("Expected 1 attempt, got %d", attempts)
+		}
+	})
+
 	t.Run("Command That Produces Output", func(t *testing.T) {
 		action := &workflow.Action{
 			Type:    workflow.ActionTypeBash,
@@ -133,4 +190,380 @@ echo "Line 3"
 			t.Fatalf("Expected no error, got: %v", err)
 		}
 	})
+
+	t.Run("Script File", func(t *testing.T) {
+		script := filepath.Join(t.TempDir(), "greet.sh")
+		if err := os.WriteFile(script, []byte("echo \"running $(basename \"$0\")\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		action := &workflow.Action{
+			Type:       workflow.ActionTypeBash,
+			Name:       "script",
+			ScriptFile: script,
+		}
+
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.TrimSpace(output) != "running greet.sh" {
+			t.Errorf("Expected 'running greet.sh', got '%s'", output)
+		}
+	})
+
+	t.Run("Stdin", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "stdin",
+			Command: "tr a-z A-Z",
+			Stdin:   "hello",
+		}
+
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "HELLO" {
+			t.Errorf("Expected 'HELLO', got '%s'", output)
+		}
+	})
+
+	t.Run("Stdin File", func(t *testing.T) {
+		input := filepath.Join(t.TempDir(), "input.txt")
+		if err := os.WriteFile(input, []byte("a\nb\nc\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		action := &workflow.Action{
+			Type:      workflow.ActionTypeBash,
+			Name:      "stdin-file",
+			Command:   "wc -l",
+			StdinFile: input,
+		}
+
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.TrimSpace(output) != "3" {
+			t.Errorf("Expected '3', got '%s'", output)
+		}
+	})
+
+	t.Run("Missing Stdin File", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:      workflow.ActionTypeBash,
+			Name:      "missing-stdin",
+			Command:   "cat",
+			StdinFile: filepath.Join(t.TempDir(), "missing.txt"),
+		}
+
+		if err := ExecuteBashAction(action); err == nil {
+			t.Fatal("Expected error for missing stdin file, got nil")
+		}
+	})
+
+	t.Run("Shell", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "shell",
+			Command: "echo $0",
+			Shell:   "sh",
+		}
+
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.TrimSpace(output) != "sh" {
+			t.Errorf("Expected the command to run in sh, got '%s'", output)
+		}
+	})
+
+	t.Run("Exec Without Shell", func(t *testing.T) {
+		action := &workflow.Action{
+			Type: workflow.ActionTypeBash,
+			Name: "exec",
+			Exec: []string{"printf", "%s|", "two words", "$HOME", "; rm -rf /"},
+		}
+
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "two words|$HOME|; rm -rf /|" {
+			t.Errorf("Expected the arguments passed as is, got '%s'", output)
+		}
+
+		action.Exec = []string{"autozap-no-such-program"}
+		if err := ExecuteBashAction(action); err == nil {
+			t.Fatal("Expected error for missing program, got nil")
+		}
+	})
+
+	t.Run("Working Directory", func(t *testing.T) {
+		dir, err := filepath.EvalSymlinks(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		action := &workflow.Action{
+			Type:       workflow.ActionTypeBash,
+			Name:       "working-dir",
+			Command:    "pwd",
+			WorkingDir: dir,
+		}
+
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.TrimSpace(output) != dir {
+			t.Errorf("Expected '%s', got '%s'", dir, output)
+		}
+
+		action.WorkingDir = filepath.Join(dir, "missing")
+		if err := ExecuteBashAction(action); err == nil || !strings.Contains(err.Error(), "working directory not found") {
+			t.Errorf("Expected error for missing working directory, got: %v", err)
+		}
+	})
+
+	t.Run("Run As User", func(t *testing.T) {
+		if os.Geteuid() != 0 {
+			action := &workflow.Action{Type: workflow.ActionTypeBash, Name: "run-as", Command: "true", RunAsUser: "0"}
+			if err := ExecuteBashAction(action); err == nil || !strings.Contains(err.Error(), "must run as root") {
+				t.Errorf("Expected error switching users without root, got: %v", err)
+			}
+			return
+		}
+
+		action := &workflow.Action{
+			Type:       workflow.ActionTypeBash,
+			Name:       "run-as",
+			Command:    "echo $(id -u) $(id -g) $USER",
+			RunAsUser:  "nobody",
+			RunAsGroup: "0",
+		}
+		output, err := ExecuteBashActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		fields := strings.Fields(output)
+		if len(fields) != 3 || fields[0] == "0" || fields[1] != "0" || fields[2] != "nobody" {
+			t.Errorf("Expected to run as nobody in group 0, got '%s'", output)
+		}
+
+		action.RunAsUser = "no-such-user-autozap"
+		if err := ExecuteBashAction(action); err == nil || !strings.Contains(err.Error(), "unknown user") {
+			t.Errorf("Expected error for unknown user, got: %v", err)
+		}
+	})
+
+	t.Run("Cancelled Command", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "cancelled",
+			Command: "sleep 5",
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		_, err := ExecuteBashActionContext(ctx, action)
+		if err == nil {
+			t.Fatal("Expected error for cancelled command, got nil")
+		}
+		if status := Status(err); status != workflow.StatusCancelled {
+			t.Errorf("Expected status '%s', got '%s'", workflow.StatusCancelled, status)
+		}
+	})
+}
+
+func TestBashOutputSpill(t *testing.T) {
+	spillDir := t.TempDir()
+	ConfigureOutput(config.OutputConfig{MemoryLimit: "1KB", SpillDir: spillDir})
+	defer ConfigureOutput(config.OutputConfig{})
+
+	t.Run("Large Output Spilled To Disk", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "noisy",
+			Command: "echo START; head -c 100000 /dev/zero | tr '\\0' x; echo; echo END",
+		}
+
+		output, err := ExecuteBashActionWithOutput(action, "test-spill")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(output) > 2048 {
+			t.Errorf("Expected output bounded by the memory limit, got %d bytes", len(output))
+		}
+		if !strings.HasPrefix(output, "START\n") || !strings.HasSuffix(output, "\nEND\n") {
+			t.Errorf("Expected the head and tail of the output, got %q", output)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(spillDir, "test-spill-noisy-stdout-*.log"))
+		if len(files) != 1 {
+			t.Fatalf("Expected one spill file, got %v", files)
+		}
+		if !strings.Contains(output, "full output in "+files[0]) {
+			t.Errorf("Expected the output to name the spill file, got %q", output)
+		}
+		content, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(content) != 100011 || !strings.HasSuffix(string(content), "END\n") {
+			t.Errorf("Expected the whole output in the spill file, got %d bytes", len(content))
+		}
+	})
+
+	t.Run("Small Output Kept In Memory", func(t *testing.T) {
+		action := &workflow.Action{Type: workflow.ActionTypeBash, Name: "quiet", Command: "echo hello; echo oops >&2"}
+
+		output, err := ExecuteBashActionWithOutput(action, "test-spill")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "hello\noops\n" {
+			t.Errorf("Expected 'hello\\noops\\n', got %q", output)
+		}
+		if files, _ := filepath.Glob(filepath.Join(spillDir, "test-spill-quiet-*")); len(files) != 0 {
+			t.Errorf("Expected no spill file, got %v", files)
+		}
+	})
+
+	t.Run("Prune Spill Files", func(t *testing.T) {
+		old := filepath.Join(spillDir, "old-stdout.log")
+		if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		past := time.Now().Add(-48 * time.Hour)
+		if err := os.Chtimes(old, past, past); err != nil {
+			t.Fatal(err)
+		}
+
+		deleted, err := PruneSpillFiles(time.Now().Add(-24 * time.Hour))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("Expected 1 spill file deleted, got %d", deleted)
+		}
+		if files, _ := filepath.Glob(filepath.Join(spillDir, "*.log")); len(files) != 1 {
+			t.Errorf("Expected the recent spill file to be kept, got %v", files)
+		}
+	})
+}
+
+func TestOutputCaptureTail(t *testing.T) {
+	ConfigureOutput(config.OutputConfig{MemoryLimit: "1KB", SpillDir: t.TempDir()})
+	defer ConfigureOutput(config.OutputConfig{})
+
+	c := newOutputCapture("test", "tail", "stdout", 0)
+	defer c.Close()
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(c, "line %04d\n", i)
+	}
+
+	output := c.String()
+	if !strings.HasPrefix(output, "line 0000\n") || !strings.HasSuffix(output, "line 0999\n") {
+		t.Errorf("Expected the first and last lines, got %q", output)
+	}
+	if !strings.Contains(output, "bytes omitted") {
+		t.Errorf("Expected a note about the omitted output, got %q", output)
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "hello.sh")
+	if err := os.WriteFile(script, []byte("echo hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content, hash, err := LoadScript(script)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if content != "echo hello\n" {
+		t.Errorf("Unexpected content '%s'", content)
+	}
+	// sha256 of "echo hello\n"
+	if hash != "5dbad7dd0b9b122dcd9956884390f4aac4738caba8ff53498a7ab6718b176c30" {
+		t.Errorf("Unexpected hash '%s'", hash)
+	}
+
+	if _, _, err := LoadScript(filepath.Join(t.TempDir(), "missing.sh")); err == nil {
+		t.Fatal("Expected error for missing script, got nil")
+	}
+}
+
+func TestOutputCaptureLargeFirstWrite(t *testing.T) {
+	ConfigureOutput(config.OutputConfig{MemoryLimit: "1KB", SpillDir: t.TempDir()})
+	defer ConfigureOutput(config.OutputConfig{})
+
+	c := newOutputCapture("test", "first-write", "stdout", 0)
+	defer c.Close()
+	fmt.Fprintf(c, "START\n%sEND\n", strings.Repeat("x", 4096))
+
+	output := c.String()
+	if !strings.HasPrefix(output, "START\n") || !strings.HasSuffix(output, "xEND\n") {
+		t.Errorf("Expected the head and tail of a single large write, got %q", output)
+	}
+}
+
+func TestLineLogger(t *testing.T) {
+	var lines []string
+	l := &lineLogger{log: func(line string) { lines = append(lines, line) }}
+
+	fmt.Fprint(l, "first\nsec")
+	fmt.Fprint(l, "ond\r\n\nthird")
+	if want := []string{"first", "second"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("Expected %q before the last line ends, got %q", want, lines)
+	}
+	l.Flush()
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("Expected %q after flushing, got %q", want, lines)
+	}
+
+	lines = nil
+	fmt.Fprint(l, strings.Repeat("x", maxLoggedLine+10))
+	l.Flush()
+	if len(lines) != 2 || len(lines[0]) != maxLoggedLine || len(lines[1]) != 10 {
+		t.Errorf("Expected a long line to be split, got %d entries", len(lines))
+	}
+}
+
+func TestBashOutputProgress(t *testing.T) {
+	defer func(interval time.Duration) { outputProgressInterval = interval }(outputProgressInterval)
+	outputProgressInterval = 20 * time.Millisecond
+
+	var mu sync.Mutex
+	var reports []string
+	action := &workflow.Action{
+		Type:    workflow.ActionTypeBash,
+		Name:    "progress",
+		Command: "echo one; sleep 0.2; echo two",
+		OnOutput: func(output string) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, output)
+		},
+		StreamOutput: true,
+		MaxOutput:    "4KB",
+	}
+
+	output, err := ExecuteBashActionWithOutput(action)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if output != "one\ntwo\n" {
+		t.Errorf("Expected 'one\\ntwo\\n', got %q", output)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) == 0 || reports[0] != "one\n" {
+		t.Errorf("Expected the output to be reported while running, got %q", reports)
+	}
 }
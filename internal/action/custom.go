@@ -0,0 +1,35 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeCustom, customExecutor{})
+}
+
+// customExecutor accepts custom actions, which don't run yet
+type customExecutor struct{}
+
+func (customExecutor) Validate(act *workflow.Action) error {
+	if act.FunctionName == "" {
+		return fmt.Errorf("must have a 'functionName'")
+	}
+	if act.Command != "" || act.URL != "" || act.Method != "" || len(act.Headers) > 0 || act.Body != "" {
+		logger.L().Warnf("Custom action %s has unexpected Bash or HTTP fields; they will be ignored.", act.Name)
+	}
+	return nil
+}
+
+func (customExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	logger.L().Infow("Custom action type detected, but execution not yet implemented",
+		"workflow_name", ec.WorkflowName,
+		"action_name", act.Name,
+		"function_name", act.FunctionName)
+	// TODO: Implement Custom action execution
+	return Result{}, nil
+}
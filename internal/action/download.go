@@ -0,0 +1,278 @@
+package action
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeDownload, downloadExecutor{})
+}
+
+// downloadExecutor downloads a file and checks its checksum
+type downloadExecutor struct{}
+
+func (downloadExecutor) Fields() []string {
+	return []string{"proxy", "tls"}
+}
+
+func (downloadExecutor) Validate(act *workflow.Action) error {
+	if act.URL == "" {
+		return fmt.Errorf("must have a 'url'")
+	}
+	if act.Path == "" {
+		return fmt.Errorf("must have a 'path'")
+	}
+	switch act.Algorithm {
+	case "", "sha256", "sha512", "sha1", "md5":
+	default:
+		return fmt.Errorf("has invalid algorithm '%s'. Must be one of: sha256, sha512, sha1, md5", act.Algorithm)
+	}
+	// Templated checksums are only known at run time
+	if act.Checksum != "" && !strings.Contains(act.Checksum, "{{") {
+		if _, err := hex.DecodeString(act.Checksum); err != nil {
+			return fmt.Errorf("has invalid 'checksum': must be a hex digest")
+		}
+	}
+	if err := validateConnection(act); err != nil {
+		return err
+	}
+	return validateTimeout(act)
+}
+
+func (downloadExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteDownloadAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// partialSuffix is appended to the destination while a download is incomplete
+const partialSuffix = ".part"
+
+// downloadProgressInterval is how often a running download logs its progress
+var downloadProgressInterval = 10 * time.Second
+
+// ExecuteDownloadAction downloads the action's URL to its path. Data is written
+// to "<path>.part" first, and a later attempt or run resumes from it with an
+// HTTP range request if the server supports it. The file is moved into place
+// only once it is complete and matches the expected checksum, if one is set.
+func ExecuteDownloadAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeDownload {
+		return "", fmt.Errorf("invalid action type for ExecuteDownloadAction: expected %s, got %s", workflow.ActionTypeDownload, action.Type)
+	}
+	if action.URL == "" {
+		return "", fmt.Errorf("download action '%s' has empty URL", action.Name)
+	}
+	if action.Path == "" {
+		return "", fmt.Errorf("download action '%s' has empty path", action.Name)
+	}
+
+	startTime := time.Now()
+
+	var output string
+	tries := 0
+	err := retry.ExecuteWithRetry(ctx, action.Name, action.Retry, func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("download action %s cancelled: %w", action.Name, err)
+		}
+		countAttempt(action, &tries)
+		var runErr error
+		output, runErr = downloadOnce(ctx, action)
+		return runErr
+	})
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeDownload), Status(err), time.Since(startTime))
+		metrics.RecordActionAttempts(workflowName[0], action.Name, string(workflow.ActionTypeDownload), tries)
+	}
+
+	if err != nil {
+		logger.L().Errorw("Download Action failed", "action_name", action.Name, "url", action.URL, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Download Action completed successfully", "action_name", action.Name, "path", action.Path)
+	return output, nil
+}
+
+// downloadOnce makes a single attempt, resuming a partial file if present
+func downloadOnce(ctx context.Context, action *workflow.Action) (string, error) {
+	newHash, err := newHash(action.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	if action.Timeout != "" {
+		timeout, err := time.ParseDuration(action.Timeout)
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout duration: %w", err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(action.Path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	partial := action.Path + partialSuffix
+
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, action.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	for key, value := range action.Headers {
+		req.Header.Set(key, value)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	logger.L().Infow("Executing Download Action",
+		"action_name", action.Name,
+		"url", action.URL,
+		"path", action.Path,
+		"resume_offset", offset)
+
+	client, err := newHTTPClient(action)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("download request failed for action '%s': %w", action.Name, err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &retry.TimeoutError{Err: err}
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range or there was nothing to resume
+		if offset > 0 {
+			logger.L().Infow("Server does not support resuming, restarting download",
+				"action_name", action.Name,
+				"url", action.URL)
+		}
+		offset = 0
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is larger than the resource; start over next attempt
+		os.Remove(partial)
+		return "", fmt.Errorf("download action '%s': partial file does not match the remote file, removed it", action.Name)
+	default:
+		err := fmt.Errorf("download action '%s' failed: unexpected status code %d", action.Name, resp.StatusCode)
+		return "", retry.WrapHTTPError(err, resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open partial file: %w", err)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := &progressWriter{action: action.Name, written: offset, total: total, lastLog: time.Now()}
+
+	_, copyErr := io.Copy(io.MultiWriter(f, progress), resp.Body)
+	if closeErr := f.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		// Keep what arrived so the next attempt can resume
+		err := fmt.Errorf("download action '%s' interrupted after %s: %w", action.Name, formatBytes(progress.written), copyErr)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &retry.TimeoutError{Err: err}
+		}
+		return "", err
+	}
+	if total >= 0 && progress.written != total {
+		return "", fmt.Errorf("download action '%s' incomplete: got %d of %d bytes", action.Name, progress.written, total)
+	}
+
+	sum, err := fileChecksum(partial, newHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum download: %w", err)
+	}
+	if action.Checksum != "" && !strings.EqualFold(sum, action.Checksum) {
+		// A corrupt file must not be resumed
+		os.Remove(partial)
+		return "", fmt.Errorf("download action '%s': checksum mismatch: expected %s, got %s", action.Name, strings.ToLower(action.Checksum), sum)
+	}
+
+	if err := os.Rename(partial, action.Path); err != nil {
+		return "", fmt.Errorf("failed to move download into place: %w", err)
+	}
+
+	algorithm := action.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	return fmt.Sprintf("%s: %s, %s %s\n", action.Path, formatBytes(progress.written), algorithm, sum), nil
+}
+
+// progressWriter counts downloaded bytes and logs progress periodically
+type progressWriter struct {
+	action  string
+	written int64
+	total   int64 // -1 if unknown
+	lastLog time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if time.Since(p.lastLog) < downloadProgressInterval {
+		return len(b), nil
+	}
+	p.lastLog = time.Now()
+
+	fields := []interface{}{"action_name", p.action, "downloaded", formatBytes(p.written)}
+	if p.total > 0 {
+		fields = append(fields,
+			"total", formatBytes(p.total),
+			"percent", strconv.FormatFloat(float64(p.written)*100/float64(p.total), 'f', 1, 64))
+	}
+	logger.L().Infow("Download progress", fields...)
+	return len(b), nil
+}
+
+// formatBytes formats a byte count for humans, e.g. 12.3 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
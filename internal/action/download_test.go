@@ -0,0 +1,113 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestExecuteDownloadAction(t *testing.T) {
+	content := bytes.Repeat([]byte("autozap artifact\n"), 1000)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	var lastRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "artifact.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	newAction := func(path, checksum string) *workflow.Action {
+		return &workflow.Action{
+			Type:     workflow.ActionTypeDownload,
+			Name:     "fetch",
+			URL:      server.URL,
+			Path:     path,
+			Checksum: checksum,
+		}
+	}
+
+	t.Run("Downloads And Verifies Checksum", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "artifact.bin")
+		output, err := ExecuteDownloadAction(context.Background(), newAction(path, strings.ToUpper(checksum)))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(got, content) {
+			t.Fatalf("Downloaded file does not match, err: %v", err)
+		}
+		if !strings.Contains(output, checksum) {
+			t.Errorf("Expected output to contain the checksum, got %q", output)
+		}
+		if _, err := os.Stat(path + partialSuffix); !os.IsNotExist(err) {
+			t.Error("Expected partial file to be removed")
+		}
+	})
+
+	t.Run("Resumes Partial Download", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "artifact.bin")
+		if err := os.WriteFile(path+partialSuffix, content[:5000], 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ExecuteDownloadAction(context.Background(), newAction(path, checksum)); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if lastRange != "bytes=5000-" {
+			t.Errorf("Expected a range request from byte 5000, got %q", lastRange)
+		}
+		got, _ := os.ReadFile(path)
+		if !bytes.Equal(got, content) {
+			t.Error("Resumed file does not match")
+		}
+	})
+
+	t.Run("Checksum Mismatch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "artifact.bin")
+		if _, err := ExecuteDownloadAction(context.Background(), newAction(path, strings.Repeat("0", 64))); err == nil {
+			t.Fatal("Expected error for checksum mismatch, got nil")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("Expected no file at the destination")
+		}
+		if _, err := os.Stat(path + partialSuffix); !os.IsNotExist(err) {
+			t.Error("Expected corrupt partial file to be removed")
+		}
+	})
+
+	t.Run("Unexpected Status", func(t *testing.T) {
+		missing := httptest.NewServer(http.NotFoundHandler())
+		defer missing.Close()
+
+		act := newAction(filepath.Join(t.TempDir(), "artifact.bin"), "")
+		act.URL = missing.URL
+		if _, err := ExecuteDownloadAction(context.Background(), act); err == nil {
+			t.Fatal("Expected error for 404 response, got nil")
+		}
+	})
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512 B",
+		2048:            "2.0 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
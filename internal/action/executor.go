@@ -0,0 +1,129 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Executor runs the actions of one type. The parser validates actions and the
+// workflow executor runs them through it, whatever their type.
+type Executor interface {
+	// Validate checks the action's fields when a workflow is parsed,
+	// returning an error for an action that can't run and logging fields it
+	// ignores. Templated values are only checked when the action runs.
+	Validate(act *workflow.Action) error
+
+	// Execute runs the action, whose templates are rendered, until it
+	// finishes or ctx is cancelled
+	Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error)
+}
+
+// ExecContext is what an action knows about the run executing it
+type ExecContext struct {
+	WorkflowName string
+
+	// RunWorkflow runs the workflow a workflow action names and returns its
+	// output. Set by the workflow executor, which knows the other workflows.
+	RunWorkflow func(ctx context.Context, act *workflow.Action) (string, error)
+}
+
+// Result is what an action produced
+type Result struct {
+	// Output is the captured output: a command's stdout and stderr, a
+	// response body. It is stored with the action's execution.
+	Output string
+}
+
+// Registry holds the executors by action type, so the parser and the
+// workflow executor need no switch over them
+type Registry struct {
+	mu    sync.RWMutex
+	types map[workflow.ActionType]Executor
+}
+
+// NewRegistry returns an empty action registry
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[workflow.ActionType]Executor)}
+}
+
+var registry = NewRegistry()
+
+// GetRegistry returns the registry the action types of this package register
+// with
+func GetRegistry() *Registry {
+	return registry
+}
+
+// Register adds the executor of an action type, replacing one of the same
+// name
+func (r *Registry) Register(typ workflow.ActionType, e Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[typ] = e
+}
+
+// Lookup returns the executor of an action type
+func (r *Registry) Lookup(typ workflow.ActionType) (Executor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.types[typ]
+	return e, ok
+}
+
+// Types returns the registered action types, sorted
+func (r *Registry) Types() []workflow.ActionType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]workflow.ActionType, 0, len(r.types))
+	for typ := range r.types {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Register adds the executor of an action type to the registry, usually from
+// the init function of the file implementing it
+func Register(typ workflow.ActionType, e Executor) {
+	registry.Register(typ, e)
+}
+
+// Validate checks an action with the executor of its type, after checking
+// that it sets no fields of other types
+func Validate(act *workflow.Action) error {
+	e, ok := registry.Lookup(act.Type)
+	if !ok {
+		return fmt.Errorf("has an unsupported type")
+	}
+	if err := registry.validateFields(act, e); err != nil {
+		return err
+	}
+	return e.Validate(act)
+}
+
+// Execute runs an action with the executor of its type
+func Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	e, ok := registry.Lookup(act.Type)
+	if !ok {
+		return Result{}, fmt.Errorf("unsupported action type: %s", act.Type)
+	}
+	return e.Execute(ctx, act, ec)
+}
+
+// validateTimeout checks the timeout of an action that has one, unless it is
+// templated
+func validateTimeout(act *workflow.Action) error {
+	if act.Timeout != "" && !strings.Contains(act.Timeout, "{{") {
+		if _, err := time.ParseDuration(act.Timeout); err != nil {
+			return fmt.Errorf("has invalid 'timeout' '%s': %w", act.Timeout, err)
+		}
+	}
+	return nil
+}
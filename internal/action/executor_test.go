@@ -0,0 +1,132 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// echoExecutor is an action type registered by the tests
+type echoExecutor struct{}
+
+func (echoExecutor) Validate(act *workflow.Action) error {
+	if act.Message == "" {
+		return errors.New("must have a 'message'")
+	}
+	return nil
+}
+
+func (echoExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	return Result{Output: ec.WorkflowName + ": " + act.Message}, nil
+}
+
+func TestRegister(t *testing.T) {
+	const typ workflow.ActionType = "test-echo"
+	Register(typ, echoExecutor{})
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		delete(registry.types, typ)
+		registry.mu.Unlock()
+	})
+
+	if err := Validate(&workflow.Action{Type: typ, Name: "greet"}); err == nil || !strings.Contains(err.Error(), "'message'") {
+		t.Errorf("Expected the executor's validation error, got: %v", err)
+	}
+	if err := Validate(&workflow.Action{Type: typ, Name: "greet", Message: "hello"}); err != nil {
+		t.Errorf("Expected valid action, got: %v", err)
+	}
+
+	result, err := Execute(context.Background(), &workflow.Action{Type: typ, Name: "greet", Message: "hello"}, ExecContext{WorkflowName: "greeter"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Output != "greeter: hello" {
+		t.Errorf("Expected output 'greeter: hello', got %q", result.Output)
+	}
+
+	found := false
+	for _, registered := range GetRegistry().Types() {
+		if registered == typ {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected %s in registered types, got %v", typ, GetRegistry().Types())
+	}
+}
+
+func TestBuiltinActionTypes(t *testing.T) {
+	for _, typ := range []workflow.ActionType{
+		workflow.ActionTypeBash, workflow.ActionTypeHTTP, workflow.ActionTypeKV, workflow.ActionTypeVerify,
+		workflow.ActionTypeDownload, workflow.ActionTypeFile, workflow.ActionTypeStorage, workflow.ActionTypeGit,
+		workflow.ActionTypeTemplate, workflow.ActionTypeWait, workflow.ActionTypeApproval, workflow.ActionTypeWorkflow,
+		workflow.ActionTypeMQTT, workflow.ActionTypeCustom,
+	} {
+		if _, ok := GetRegistry().Lookup(typ); !ok {
+			t.Errorf("Expected built-in action type %s to be registered", typ)
+		}
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	const typ workflow.ActionType = "test-echo-fields"
+	Register(typ, echoExecutor{})
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		delete(registry.types, typ)
+		registry.mu.Unlock()
+	})
+
+	tests := []struct {
+		name    string
+		act     workflow.Action
+		wantErr string
+	}{
+		{"Own Field", workflow.Action{Type: workflow.ActionTypeHTTP, Name: "a", URL: "http://x", Method: "GET", CaptureAs: "id"}, ""},
+		{"Field Of Another Type", workflow.Action{Type: workflow.ActionTypeBash, Name: "a", Command: "true", CaptureAs: "id"}, "uses 'captureAs', which is only supported by http actions"},
+		{"Field Of Several Types", workflow.Action{Type: workflow.ActionTypeBash, Name: "a", Command: "true", Proxy: "http://proxy:3128"}, "only supported by download, http and storage actions"},
+		{"Registered Type Without Fields", workflow.Action{Type: typ, Name: "a", Message: "hi", Shell: "sh"}, "only supported by bash actions"},
+		{"Common Field", workflow.Action{Type: typ, Name: "a", Message: "hi", When: "{{ true }}", RunAsync: true}, ""},
+		{"Field Value Checked By Its Type", workflow.Action{Type: workflow.ActionTypeDownload, Name: "a", URL: "http://x", Path: "/tmp/x", Proxy: "ftp://proxy"}, "unsupported 'proxy' scheme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.act)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected valid action, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecuteUnknownType(t *testing.T) {
+	if err := Validate(&workflow.Action{Type: "ftp", Name: "upload"}); err == nil {
+		t.Error("Expected validation error for an unregistered action type")
+	}
+	if _, err := Execute(context.Background(), &workflow.Action{Type: "ftp", Name: "upload"}, ExecContext{}); err == nil {
+		t.Error("Expected execution error for an unregistered action type")
+	}
+}
+
+func TestWorkflowExecutorNeedsRunner(t *testing.T) {
+	act := &workflow.Action{Type: workflow.ActionTypeWorkflow, Name: "child", Workflow: "cleanup"}
+	if _, err := Execute(context.Background(), act, ExecContext{WorkflowName: "parent"}); err == nil {
+		t.Error("Expected error running a workflow action without a runner")
+	}
+
+	result, err := Execute(context.Background(), act, ExecContext{
+		WorkflowName: "parent",
+		RunWorkflow: func(ctx context.Context, act *workflow.Action) (string, error) {
+			return "ran " + act.Workflow, nil
+		},
+	})
+	if err != nil || result.Output != "ran cleanup" {
+		t.Errorf("Expected output 'ran cleanup', got %q, %v", result.Output, err)
+	}
+}
@@ -0,0 +1,167 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/jsonpath"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// checkExpectations checks an HTTP response against the action's
+// expectMaxLatency, expectHeaders, expectBodyRegex and expectJson, in that
+// order, and returns the first one it doesn't meet
+func checkExpectations(action *workflow.Action, resp *http.Response, body string, latency time.Duration) error {
+	if action.ExpectMaxLatency != "" {
+		maxLatency, err := time.ParseDuration(action.ExpectMaxLatency)
+		if err != nil {
+			return fmt.Errorf("HTTP action '%s': invalid expectMaxLatency: %w", action.Name, err)
+		}
+		if latency > maxLatency {
+			return fmt.Errorf("HTTP action '%s' failed: response took %s, more than expectMaxLatency %s", action.Name, latency.Round(time.Millisecond), maxLatency)
+		}
+	}
+
+	for _, name := range sortedKeys(action.ExpectHeaders) {
+		want := action.ExpectHeaders[name]
+		values, ok := resp.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return fmt.Errorf("HTTP action '%s' failed: response has no header '%s'", action.Name, name)
+		}
+		if !slices.Contains(values, want) {
+			return fmt.Errorf("HTTP action '%s' failed: header '%s' is '%s', expected '%s'", action.Name, name, resp.Header.Get(name), want)
+		}
+	}
+
+	if action.ExpectBodyRegex != "" {
+		re, err := regexp.Compile(action.ExpectBodyRegex)
+		if err != nil {
+			return fmt.Errorf("HTTP action '%s': invalid expectBodyRegex: %w", action.Name, err)
+		}
+		if !re.MatchString(body) {
+			return fmt.Errorf("HTTP action '%s' failed: response body does not match '%s'", action.Name, action.ExpectBodyRegex)
+		}
+	}
+
+	if len(action.ExpectJSON) > 0 {
+		doc, err := jsonpath.Decode([]byte(body))
+		if err != nil {
+			return fmt.Errorf("HTTP action '%s' failed: response is not valid JSON: %w", action.Name, err)
+		}
+		paths := make([]string, 0, len(action.ExpectJSON))
+		for path := range action.ExpectJSON {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, expr := range paths {
+			if err := expectJSONValue(doc, expr, action.ExpectJSON[expr]); err != nil {
+				return fmt.Errorf("HTTP action '%s' failed: %w", action.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expectJSONValue checks that the value at expr in doc equals want
+func expectJSONValue(doc interface{}, expr string, want interface{}) error {
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		return err
+	}
+	got, err := path.Lookup(doc)
+	if err != nil {
+		return fmt.Errorf("response has no value at '%s': %w", expr, err)
+	}
+
+	// Round trip the expected value, which comes from YAML, so both sides
+	// hold the types jsonpath.Decode produces
+	data, err := json.Marshal(want)
+	if err != nil {
+		return fmt.Errorf("invalid expected value for '%s': %w", expr, err)
+	}
+	if want, err = jsonpath.Decode(data); err != nil {
+		return fmt.Errorf("invalid expected value for '%s': %w", expr, err)
+	}
+	if !jsonEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		return fmt.Errorf("value at '%s' is %s, expected %s", expr, gotJSON, data)
+	}
+	return nil
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing
+// numbers by value so 3 equals 3.0
+func jsonEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okX := new(big.Rat).SetString(a.String())
+		y, okY := new(big.Rat).SetString(b.String())
+		return okX && okY && x.Cmp(y) == 0
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// validateExpectations checks the response assertions of an HTTP action that
+// are known before it runs; templated ones are checked when it does
+func validateExpectations(act *workflow.Action) error {
+	if act.ExpectBodyRegex != "" && !strings.Contains(act.ExpectBodyRegex, "{{") {
+		if _, err := regexp.Compile(act.ExpectBodyRegex); err != nil {
+			return fmt.Errorf("has invalid 'expectBodyRegex': %w", err)
+		}
+	}
+	for path := range act.ExpectJSON {
+		if _, err := jsonpath.Parse(path); err != nil {
+			return fmt.Errorf("has an invalid 'expectJson' path: %w", err)
+		}
+	}
+	for name := range act.ExpectHeaders {
+		if name == "" {
+			return fmt.Errorf("has an empty header name in 'expectHeaders'")
+		}
+	}
+	if act.ExpectMaxLatency != "" && !strings.Contains(act.ExpectMaxLatency, "{{") {
+		latency, err := time.ParseDuration(act.ExpectMaxLatency)
+		if err != nil {
+			return fmt.Errorf("has invalid 'expectMaxLatency' '%s': %w", act.ExpectMaxLatency, err)
+		}
+		if latency <= 0 {
+			return fmt.Errorf("has invalid 'expectMaxLatency' '%s': must be positive", act.ExpectMaxLatency)
+		}
+	}
+	return nil
+}
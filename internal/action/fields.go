@@ -0,0 +1,61 @@
+package action
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// FieldSet is implemented by executors whose actions have fields of their
+// own, such as captureAs for HTTP actions. Validate rejects an action setting
+// a field that other types declare but its own type doesn't, since it would
+// be ignored.
+type FieldSet interface {
+	// Fields returns the yaml names of the fields the type's actions use
+	// beyond those every action has
+	Fields() []string
+}
+
+// fieldsOf returns the fields declared by an executor
+func fieldsOf(e Executor) map[string]bool {
+	declared := make(map[string]bool)
+	if set, ok := e.(FieldSet); ok {
+		for _, name := range set.Fields() {
+			declared[name] = true
+		}
+	}
+	return declared
+}
+
+// validateFields checks that act sets no field declared only by other types
+func (r *Registry) validateFields(act *workflow.Action, e Executor) error {
+	own := fieldsOf(e)
+	supportedBy := make(map[string][]string)
+	for _, typ := range r.Types() {
+		other, _ := r.Lookup(typ)
+		for name := range fieldsOf(other) {
+			supportedBy[name] = append(supportedBy[name], typ.String())
+		}
+	}
+
+	value := reflect.ValueOf(act).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("yaml"), ",")
+		types, declared := supportedBy[name]
+		if !declared || own[name] || value.Field(i).IsZero() {
+			continue
+		}
+		return fmt.Errorf("uses '%s', which is only supported by %s actions", name, joinTypes(types))
+	}
+	return nil
+}
+
+// joinTypes lists action types as "a, b and c"
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return strings.Join(types[:len(types)-1], ", ") + " and " + types[len(types)-1]
+}
@@ -0,0 +1,439 @@
+package action
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeFile, fileExecutor{})
+}
+
+// fileExecutor copies, moves, deletes or archives files and creates
+// directories
+type fileExecutor struct{}
+
+func (fileExecutor) Fields() []string {
+	return []string{"source", "recursive"}
+}
+
+func (fileExecutor) Validate(act *workflow.Action) error {
+	if act.Path == "" {
+		return fmt.Errorf("must have a 'path'")
+	}
+	switch act.Operation {
+	case "copy", "move", "archive":
+		if act.Source == "" {
+			return fmt.Errorf("must have a 'source' to %s", act.Operation)
+		}
+	case "delete", "mkdir":
+		if act.Source != "" {
+			return fmt.Errorf("cannot have a 'source' with operation '%s'", act.Operation)
+		}
+	case "":
+		return fmt.Errorf("must have an 'operation'. Must be one of: copy, move, delete, mkdir, archive")
+	default:
+		return fmt.Errorf("has invalid operation '%s'. Must be one of: copy, move, delete, mkdir, archive", act.Operation)
+	}
+	if act.Operation == "archive" && !strings.Contains(act.Path, "{{") && workflow.ArchiveFormat(act.Path) == "" {
+		return fmt.Errorf("has invalid archive 'path' '%s': must end in .tar, .tar.gz, .tgz or .zip", act.Path)
+	}
+	if act.Recursive && act.Operation != "delete" {
+		return fmt.Errorf("uses 'recursive', which is only supported by operation 'delete'")
+	}
+	return nil
+}
+
+func (fileExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteFileAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// File action operations
+const (
+	FileOperationCopy    = "copy"
+	FileOperationMove    = "move"
+	FileOperationDelete  = "delete"
+	FileOperationMkdir   = "mkdir"
+	FileOperationArchive = "archive"
+)
+
+// ExecuteFileAction copies, moves, deletes or archives files, or creates a
+// directory, and returns a short description of what it did. Copies and
+// archives are written to a temporary file next to their destination and
+// renamed into place, so a filewatch trigger never sees a partial file.
+func ExecuteFileAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeFile {
+		return "", fmt.Errorf("invalid action type for ExecuteFileAction: expected %s, got %s", workflow.ActionTypeFile, action.Type)
+	}
+	if action.Path == "" {
+		return "", fmt.Errorf("file action '%s' has empty path", action.Name)
+	}
+
+	startTime := time.Now()
+	output, err := runFileOperation(ctx, action)
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeFile), Status(err), time.Since(startTime))
+	}
+
+	if err != nil {
+		logger.L().Errorw("File Action failed", "action_name", action.Name, "operation", action.Operation, "path", action.Path, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("File Action completed successfully", "action_name", action.Name, "operation", action.Operation, "path", action.Path)
+	return output, nil
+}
+
+// runFileOperation does the work of ExecuteFileAction
+func runFileOperation(ctx context.Context, action *workflow.Action) (string, error) {
+	switch action.Operation {
+	case FileOperationMkdir:
+		if err := os.MkdirAll(action.Path, 0o755); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("created %s", action.Path), nil
+	case FileOperationDelete:
+		return deletePath(action.Path, action.Recursive)
+	case FileOperationCopy, FileOperationMove, FileOperationArchive:
+	default:
+		return "", fmt.Errorf("file action '%s' has unsupported operation '%s'", action.Name, action.Operation)
+	}
+
+	if action.Source == "" {
+		return "", fmt.Errorf("file action '%s' has empty source", action.Name)
+	}
+	info, err := os.Lstat(action.Source)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() && pathWithin(action.Path, action.Source) {
+		return "", fmt.Errorf("cannot %s %s into itself (%s)", action.Operation, action.Source, action.Path)
+	}
+
+	if action.Operation == FileOperationArchive {
+		files, err := writeArchive(ctx, action.Source, action.Path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("archived %s (%d files) -> %s", action.Source, files, action.Path), nil
+	}
+
+	dest := destinationPath(action.Source, action.Path)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", err
+	}
+	if action.Operation == FileOperationMove {
+		if err := movePath(ctx, action.Source, dest, info); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("moved %s -> %s", action.Source, dest), nil
+	}
+	if err := copyPath(ctx, action.Source, dest, info); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("copied %s -> %s", action.Source, dest), nil
+}
+
+// destinationPath returns where source goes when copied or moved to path:
+// into path if it is a directory or ends with a slash, like cp and mv
+func destinationPath(source, path string) string {
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return filepath.Join(path, filepath.Base(source))
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, filepath.Base(source))
+	}
+	return path
+}
+
+// pathWithin reports whether path is dir or inside it, resolving both
+// against the working directory
+func pathWithin(path, dir string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// deletePath removes a file, or a directory if it is empty or recursive is
+// set. A path that doesn't exist is already deleted.
+func deletePath(path string, recursive bool) (string, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Sprintf("%s does not exist", path), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() && recursive {
+		if err := os.RemoveAll(path); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("deleted %s", path), nil
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return "", err
+		}
+		if len(entries) > 0 {
+			return "", fmt.Errorf("%s is a directory with %d entries; set 'recursive: true' to delete it with its contents", path, len(entries))
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("deleted %s", path), nil
+}
+
+// movePath renames source to dest, copying and then deleting it when they
+// are on different file systems
+func movePath(ctx context.Context, source, dest string, info fs.FileInfo) error {
+	err := os.Rename(source, dest)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyPath(ctx, source, dest, info); err != nil {
+		return err
+	}
+	return os.RemoveAll(source)
+}
+
+// copyPath copies a file, symlink or directory tree to dest
+func copyPath(ctx context.Context, source, dest string, info fs.FileInfo) error {
+	if !info.IsDir() {
+		return copyEntry(source, dest, info)
+	}
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyEntry(path, target, info)
+	})
+}
+
+// copyEntry copies a regular file, keeping its permissions, or recreates a
+// symlink
+func copyEntry(source, dest string, info fs.FileInfo) error {
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(source)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(dest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return os.Symlink(link, dest)
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("cannot copy %s: not a regular file, directory or symlink", source)
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return writeFileReplacing(dest, info.Mode().Perm(), func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
+
+// writeFileReplacing writes a file through a temporary file in the same
+// directory that replaces dest once it is complete
+func writeFileReplacing(dest string, perm fs.FileMode, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// writeArchive archives source, a file or directory tree, into dest, with
+// entry names starting at source's base name. It returns the number of files
+// archived.
+func writeArchive(ctx context.Context, source, dest string) (int, error) {
+	format := workflow.ArchiveFormat(dest)
+	if format == "" {
+		return 0, fmt.Errorf("unsupported archive '%s': must end in .tar, .tar.gz, .tgz or .zip", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, err
+	}
+
+	files := 0
+	err := writeFileReplacing(dest, 0o644, func(w io.Writer) error {
+		var add func(path, name string, info fs.FileInfo) error
+		var finish func() error
+		switch format {
+		case "zip":
+			zw := zip.NewWriter(w)
+			add = func(path, name string, info fs.FileInfo) error { return addZipEntry(zw, path, name, info) }
+			finish = zw.Close
+		case "tar.gz":
+			gw := gzip.NewWriter(w)
+			tw := tar.NewWriter(gw)
+			add = func(path, name string, info fs.FileInfo) error { return addTarEntry(tw, path, name, info) }
+			finish = func() error {
+				if err := tw.Close(); err != nil {
+					return err
+				}
+				return gw.Close()
+			}
+		default:
+			tw := tar.NewWriter(w)
+			add = func(path, name string, info fs.FileInfo) error { return addTarEntry(tw, path, name, info) }
+			finish = tw.Close
+		}
+
+		parent := filepath.Dir(source)
+		err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			name, err := filepath.Rel(parent, path)
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				files++
+			}
+			return add(path, filepath.ToSlash(name), info)
+		})
+		if err != nil {
+			return err
+		}
+		return finish()
+	})
+	return files, err
+}
+
+// addTarEntry adds a file, directory or symlink to a tar archive
+func addTarEntry(tw *tar.Writer, path, name string, info fs.FileInfo) error {
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return copyFileTo(tw, path)
+}
+
+// addZipEntry adds a file, directory or symlink to a zip archive
+func addZipEntry(zw *zip.Writer, path, name string, info fs.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	} else {
+		header.Method = zip.Deflate
+	}
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		// Zip stores a symlink as a file holding its target
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, link)
+		return err
+	case info.Mode().IsRegular():
+		return copyFileTo(w, path)
+	}
+	return nil
+}
+
+// copyFileTo writes the content of a file to w
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,202 @@
+package action
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestExecuteFileAction(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		return string(data)
+	}
+	run := func(operation, source, path string) (string, error) {
+		return ExecuteFileAction(context.Background(), &workflow.Action{
+			Type:      workflow.ActionTypeFile,
+			Name:      operation + "-files",
+			Operation: operation,
+			Source:    source,
+			Path:      path,
+		})
+	}
+
+	t.Run("Copy File", func(t *testing.T) {
+		source := write("incoming/report.csv", "a,b\n")
+		output, err := run(FileOperationCopy, source, filepath.Join(dir, "copies", "report.csv"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		copied := filepath.Join(dir, "copies", "report.csv")
+		if read(copied) != "a,b\n" || read(source) != "a,b\n" {
+			t.Error("Expected the file to be copied and the source kept")
+		}
+		if info, _ := os.Stat(copied); info.Mode().Perm() != 0640 {
+			t.Errorf("Expected permissions 0640, got %v", info.Mode().Perm())
+		}
+		if !strings.HasPrefix(output, "copied ") {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Copy Into Directory", func(t *testing.T) {
+		source := write("incoming/data.json", "{}")
+		if _, err := run(FileOperationCopy, source, filepath.Join(dir, "inbox")+"/"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if read(filepath.Join(dir, "inbox", "data.json")) != "{}" {
+			t.Error("Expected the file to be copied into the directory")
+		}
+	})
+
+	t.Run("Copy Directory", func(t *testing.T) {
+		write("site/index.html", "<html>")
+		write("site/css/main.css", "body{}")
+		if _, err := run(FileOperationCopy, filepath.Join(dir, "site"), filepath.Join(dir, "site-backup")); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if read(filepath.Join(dir, "site-backup", "css", "main.css")) != "body{}" {
+			t.Error("Expected the directory tree to be copied")
+		}
+
+		if _, err := run(FileOperationCopy, filepath.Join(dir, "site"), filepath.Join(dir, "site", "nested")); err == nil {
+			t.Error("Expected an error copying a directory into itself")
+		}
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		source := write("incoming/upload.bin", "payload")
+		if _, err := run(FileOperationMove, source, filepath.Join(dir, "processed")+"/"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := os.Stat(source); !os.IsNotExist(err) {
+			t.Error("Expected the source to be gone")
+		}
+		if read(filepath.Join(dir, "processed", "upload.bin")) != "payload" {
+			t.Error("Expected the file in the processed directory")
+		}
+	})
+
+	t.Run("Mkdir And Delete", func(t *testing.T) {
+		target := filepath.Join(dir, "tmp", "work")
+		if _, err := run(FileOperationMkdir, "", target); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		write("tmp/work/scratch.txt", "x")
+
+		if _, err := run(FileOperationDelete, "", target); err == nil || !strings.Contains(err.Error(), "recursive: true") {
+			t.Errorf("Expected deleting a non-empty directory to need recursive, got: %v", err)
+		}
+		_, err := ExecuteFileAction(context.Background(), &workflow.Action{
+			Type: workflow.ActionTypeFile, Name: "cleanup", Operation: FileOperationDelete, Path: target, Recursive: true,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			t.Error("Expected the directory to be deleted")
+		}
+
+		// Deleting is idempotent
+		if output, err := run(FileOperationDelete, "", target); err != nil || !strings.Contains(output, "does not exist") {
+			t.Errorf("Expected a missing path to count as deleted, got %q (%v)", output, err)
+		}
+	})
+
+	write("logs/app.log", "line 1\n")
+	write("logs/old/app.log.1", "line 0\n")
+	wantEntries := []string{"logs/", "logs/app.log", "logs/old/", "logs/old/app.log.1"}
+
+	t.Run("Archive Tar Gz", func(t *testing.T) {
+		archive := filepath.Join(dir, "archives", "logs.tar.gz")
+		output, err := run(FileOperationArchive, filepath.Join(dir, "logs"), archive)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !strings.Contains(output, "(2 files)") {
+			t.Errorf("Expected 2 files archived, got: %s", output)
+		}
+
+		f, err := os.Open(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("Expected a gzip file, got: %v", err)
+		}
+		tr := tar.NewReader(gz)
+		var names []string
+		contents := map[string]string{}
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, header.Name)
+			data, _ := io.ReadAll(tr)
+			contents[header.Name] = string(data)
+		}
+		sort.Strings(names)
+		if strings.Join(names, " ") != strings.Join(wantEntries, " ") {
+			t.Errorf("Expected entries %v, got %v", wantEntries, names)
+		}
+		if contents["logs/app.log"] != "line 1\n" {
+			t.Errorf("Unexpected content: %q", contents["logs/app.log"])
+		}
+	})
+
+	t.Run("Archive Zip", func(t *testing.T) {
+		archive := filepath.Join(dir, "archives", "logs.zip")
+		if _, err := run(FileOperationArchive, filepath.Join(dir, "logs"), archive); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			t.Fatalf("Expected a zip file, got: %v", err)
+		}
+		defer zr.Close()
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		sort.Strings(names)
+		if strings.Join(names, " ") != strings.Join(wantEntries, " ") {
+			t.Errorf("Expected entries %v, got %v", wantEntries, names)
+		}
+	})
+
+	t.Run("Archive Unknown Format", func(t *testing.T) {
+		if _, err := run(FileOperationArchive, filepath.Join(dir, "logs"), filepath.Join(dir, "logs.rar")); err == nil {
+			t.Error("Expected an error for an unsupported archive format")
+		}
+	})
+}
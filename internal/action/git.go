@@ -0,0 +1,418 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeGit, gitExecutor{})
+}
+
+// gitExecutor clones, pulls or checks out a git repository
+type gitExecutor struct{}
+
+func (gitExecutor) Fields() []string {
+	return []string{"git"}
+}
+
+func (gitExecutor) Validate(act *workflow.Action) error {
+	if act.Path == "" {
+		return fmt.Errorf("must have a 'path'")
+	}
+	switch act.Operation {
+	case "clone", "pull":
+		if act.URL == "" {
+			return fmt.Errorf("must have a 'url' to %s", act.Operation)
+		}
+	case "checkout":
+		if act.Git == nil || act.Git.Ref == "" {
+			return fmt.Errorf("must have a 'git.ref' to check out")
+		}
+	case "":
+		return fmt.Errorf("must have an 'operation'. Must be one of: clone, pull, checkout")
+	default:
+		return fmt.Errorf("has invalid operation '%s'. Must be one of: clone, pull, checkout", act.Operation)
+	}
+	if err := validateGit(act); err != nil {
+		return err
+	}
+	return validateTimeout(act)
+}
+
+func (gitExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteGitAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// Git action operations
+const (
+	GitOperationClone    = "clone"
+	GitOperationPull     = "pull"
+	GitOperationCheckout = "checkout"
+)
+
+// defaultGitUsername is sent with a token when no username is set. GitHub
+// and Gitea expect it; GitLab and Bitbucket accept any username.
+const defaultGitUsername = "x-access-token"
+
+// gitWaitDelay bounds how long a killed git waits for its ssh or credential
+// helpers to close the output pipes
+const gitWaitDelay = 5 * time.Second
+
+// ExecuteGitAction clones the repository at the action's URL into its path,
+// pulls it, or checks out a ref in it, and returns a short description of
+// the commit it ended at. Pull clones the repository if the path doesn't
+// exist yet, so a single action keeps a working copy in sync. Updates only
+// fast-forward: local changes or commits make the action fail rather than
+// being discarded.
+func ExecuteGitAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeGit {
+		return "", fmt.Errorf("invalid action type for ExecuteGitAction: expected %s, got %s", workflow.ActionTypeGit, action.Type)
+	}
+	if action.Path == "" {
+		return "", fmt.Errorf("git action '%s' has empty path", action.Name)
+	}
+
+	startTime := time.Now()
+
+	var output string
+	tries := 0
+	err := retry.ExecuteWithRetry(ctx, action.Name, action.Retry, func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("git action %s cancelled: %w", action.Name, err)
+		}
+		countAttempt(action, &tries)
+		var runErr error
+		output, runErr = gitOnce(ctx, action)
+		return runErr
+	})
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeGit), Status(err), time.Since(startTime))
+		metrics.RecordActionAttempts(workflowName[0], action.Name, string(workflow.ActionTypeGit), tries)
+	}
+
+	if err != nil {
+		logger.L().Errorw("Git Action failed", "action_name", action.Name, "operation", action.Operation, "path", action.Path, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Git Action completed successfully", "action_name", action.Name, "operation", action.Operation, "path", action.Path)
+	return output, nil
+}
+
+// gitOnce makes a single attempt at the action's operation
+func gitOnce(ctx context.Context, action *workflow.Action) (string, error) {
+	cfg := action.Git
+	if cfg == nil {
+		cfg = &workflow.GitConfig{}
+	}
+
+	if action.Timeout != "" {
+		timeout, err := time.ParseDuration(action.Timeout)
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout duration: %w", err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	env, cleanup, err := gitEnv(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	g := &gitRunner{ctx: ctx, env: env}
+
+	logger.L().Infow("Executing Git Action",
+		"action_name", action.Name,
+		"operation", action.Operation,
+		"url", action.URL,
+		"path", action.Path,
+		"ref", cfg.Ref)
+
+	var output string
+	switch action.Operation {
+	case GitOperationClone:
+		output, err = gitClone(g, action.URL, action.Path, cfg)
+	case GitOperationPull:
+		var empty bool
+		if empty, err = isEmptyDir(action.Path); err != nil {
+			break
+		}
+		if empty {
+			output, err = gitClone(g, action.URL, action.Path, cfg)
+		} else {
+			output, err = gitUpdate(g, action.URL, action.Path, cfg)
+		}
+	case GitOperationCheckout:
+		if cfg.Ref == "" {
+			return "", fmt.Errorf("git action '%s' must have a 'git.ref' to check out", action.Name)
+		}
+		output, err = gitUpdate(g, action.URL, action.Path, cfg)
+	default:
+		return "", fmt.Errorf("git action '%s' has unsupported operation '%s'", action.Name, action.Operation)
+	}
+	if err != nil {
+		err = fmt.Errorf("git action '%s' failed to %s %s: %w", action.Name, action.Operation, action.Path, err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &retry.TimeoutError{Err: err}
+		}
+		return "", err
+	}
+	return output, nil
+}
+
+// gitClone clones url into path and checks out the configured ref
+func gitClone(g *gitRunner, url, path string, cfg *workflow.GitConfig) (string, error) {
+	if url == "" {
+		return "", fmt.Errorf("%s does not exist and there is no 'url' to clone", path)
+	}
+	args := []string{"clone", "--quiet"}
+	if cfg.Depth > 0 {
+		// A shallow clone only has the history of the branch or tag it clones
+		args = append(args, "--depth", fmt.Sprint(cfg.Depth))
+		if cfg.Ref != "" {
+			args = append(args, "--branch", cfg.Ref)
+		}
+	}
+	if _, err := g.run("", append(args, "--", url, path)...); err != nil {
+		return "", err
+	}
+	if cfg.Ref != "" && cfg.Depth == 0 {
+		if _, err := g.run(path, "checkout", "--quiet", cfg.Ref); err != nil {
+			return "", err
+		}
+	}
+
+	head, err := g.run(path, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cloned %s into %s at %s %s", url, path, g.describeHead(path, cfg.Ref), shortCommit(head)), nil
+}
+
+// gitUpdate fetches the working copy at path, checks out the configured ref
+// and fast-forwards its branch to the remote one
+func gitUpdate(g *gitRunner, url, path string, cfg *workflow.GitConfig) (string, error) {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return "", fmt.Errorf("%s is not a git working copy", path)
+	}
+	if url != "" {
+		origin, err := g.run(path, "remote", "get-url", "origin")
+		if err != nil {
+			return "", err
+		}
+		if origin != url {
+			return "", fmt.Errorf("%s is a clone of %s, not %s", path, origin, url)
+		}
+	}
+
+	before, err := g.run(path, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"fetch", "--quiet", "--prune", "--tags", "origin"}
+	if cfg.Depth > 0 {
+		args = append(args, "--depth", fmt.Sprint(cfg.Depth))
+	}
+	if _, err := g.run(path, args...); err != nil {
+		return "", err
+	}
+	if cfg.Ref != "" {
+		if _, err := g.run(path, "checkout", "--quiet", cfg.Ref); err != nil {
+			return "", err
+		}
+	}
+	// A detached HEAD, e.g. at a tag, has nothing to fast-forward
+	if _, err := g.run(path, "rev-parse", "--abbrev-ref", "@{upstream}"); err == nil {
+		if _, err := g.run(path, "merge", "--quiet", "--ff-only", "@{upstream}"); err != nil {
+			return "", err
+		}
+	}
+
+	after, err := g.run(path, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	head := g.describeHead(path, cfg.Ref)
+	if before == after {
+		return fmt.Sprintf("%s already up to date at %s %s", path, head, shortCommit(after)), nil
+	}
+	output := fmt.Sprintf("updated %s at %s %s -> %s", path, head, shortCommit(before), shortCommit(after))
+	if stat, err := g.run(path, "diff", "--shortstat", before, after); err == nil && stat != "" {
+		output += " (" + stat + ")"
+	}
+	return output, nil
+}
+
+// gitRunner runs git commands with the action's credentials
+type gitRunner struct {
+	ctx context.Context
+	env []string
+}
+
+// run runs git in dir and returns its trimmed standard output. Errors
+// include what git printed to standard error, which says why it failed.
+func (g *gitRunner) run(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), g.env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = gitWaitDelay
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("git is not installed: %w", err)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// describeHead returns the branch checked out at path, or ref for a
+// detached HEAD
+func (g *gitRunner) describeHead(path, ref string) string {
+	branch, err := g.run(path, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err == nil && branch != "" {
+		return branch
+	}
+	if ref != "" {
+		return ref
+	}
+	return "HEAD"
+}
+
+// gitEnv returns the environment git runs with for cfg's credentials, and a
+// function removing the key file it writes for an SSH key. Git never prompts,
+// and SSH only connects to hosts whose key is known. The token is passed in
+// the environment rather than the URL or arguments, so it doesn't show up in
+// process listings or the repository's config.
+func gitEnv(cfg *workflow.GitConfig) ([]string, func(), error) {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	cleanup := func() {}
+
+	sshCommand := "ssh -o BatchMode=yes -o StrictHostKeyChecking=yes"
+	if cfg.KnownHosts != "" {
+		sshCommand += " -o UserKnownHostsFile=" + shellQuote(cfg.KnownHosts)
+	}
+	if cfg.SSHKey != "" {
+		f, err := os.CreateTemp("", "autozap-git-key-*")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to write SSH key: %w", err)
+		}
+		cleanup = func() { os.Remove(f.Name()) }
+		// ssh rejects a key without its final newline, which secrets lose
+		key := strings.TrimRight(cfg.SSHKey, "\n") + "\n"
+		_, err = f.WriteString(key)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to write SSH key: %w", err)
+		}
+		sshCommand += " -i " + shellQuote(f.Name()) + " -o IdentitiesOnly=yes"
+	}
+	env = append(env, "GIT_SSH_COMMAND="+sshCommand)
+
+	if cfg.Token != "" {
+		username := cfg.Username
+		if username == "" {
+			username = defaultGitUsername
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + cfg.Token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+	return env, cleanup, nil
+}
+
+// isEmptyDir reports whether path doesn't exist or is an empty directory
+func isEmptyDir(path string) (bool, error) {
+	entries, err := os.ReadDir(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(entries) == 0, nil
+}
+
+// shortCommit abbreviates a commit hash for output
+func shortCommit(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// shellQuote quotes s as a single word for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// validateGit checks the URL and credentials of a git action as far as they
+// are known before it runs
+func validateGit(act *workflow.Action) error {
+	cfg := act.Git
+	if cfg == nil {
+		cfg = &workflow.GitConfig{}
+	}
+	if cfg.Depth < 0 {
+		return fmt.Errorf("has invalid 'git.depth' %d: must not be negative", cfg.Depth)
+	}
+	if cfg.Username != "" && cfg.Token == "" {
+		return fmt.Errorf("sets 'git.username' without a 'git.token'")
+	}
+
+	// Only URLs with a scheme say how the repository is reached;
+	// user@host:path is SSH
+	if act.URL == "" || strings.Contains(act.URL, "{{") {
+		return nil
+	}
+	isHTTP := false
+	if strings.Contains(act.URL, "://") {
+		u, err := url.Parse(act.URL)
+		if err != nil {
+			return fmt.Errorf("has invalid 'url' '%s': %w", act.URL, err)
+		}
+		if _, ok := u.User.Password(); ok {
+			return fmt.Errorf("has a password in its 'url'; set 'git.token' instead, which is kept out of the repository's config")
+		}
+		isHTTP = u.Scheme == "http" || u.Scheme == "https"
+	}
+	if cfg.Token != "" && !isHTTP {
+		return fmt.Errorf("sets 'git.token', which is only used for http:// and https:// URLs; use 'git.sshKey' for SSH")
+	}
+	if cfg.SSHKey != "" && isHTTP {
+		return fmt.Errorf("sets 'git.sshKey', which is only used for SSH URLs; use 'git.token' for https://")
+	}
+	return nil
+}
@@ -5,98 +5,211 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/codecrafted007/autozap/internal/httpclient"
+	"github.com/codecrafted007/autozap/internal/jsonpath"
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/metrics"
 	"github.com/codecrafted007/autozap/internal/retry"
 	"github.com/codecrafted007/autozap/internal/workflow"
 )
 
+func init() {
+	Register(workflow.ActionTypeHTTP, httpExecutor{})
+}
+
+// httpExecutor sends an HTTP request and checks the response
+type httpExecutor struct{}
+
+func (httpExecutor) Fields() []string {
+	return []string{"jsonPath", "jq", "captureAs", "expectBodyRegex", "expectJson", "expectHeaders", "expectMaxLatency", "auth", "followRedirects", "proxy", "tls"}
+}
+
+func (httpExecutor) Validate(act *workflow.Action) error {
+	if act.URL == "" {
+		return fmt.Errorf("must have a 'url'")
+	}
+	if act.Method == "" {
+		return fmt.Errorf("must have a 'method'")
+	}
+	if act.Body != "" && act.BodyFile != "" {
+		return fmt.Errorf("cannot have both 'body' and 'bodyFile'")
+	}
+	if (len(act.FormData) > 0 || len(act.Files) > 0) && (act.Body != "" || act.BodyFile != "") {
+		return fmt.Errorf("cannot combine 'formData' or 'files' with 'body' or 'bodyFile'")
+	}
+	for field, path := range act.Files {
+		if field == "" || path == "" {
+			return fmt.Errorf("has a file upload with an empty field name or path")
+		}
+	}
+	if act.UnixSocket != "" {
+		if !filepath.IsAbs(act.UnixSocket) && !strings.Contains(act.UnixSocket, "{{") {
+			return fmt.Errorf("has invalid 'unixSocket' '%s': must be an absolute path", act.UnixSocket)
+		}
+		if act.Proxy != "" {
+			return fmt.Errorf("cannot combine 'unixSocket' with 'proxy'")
+		}
+	}
+
+	if act.Auth != nil {
+		if err := validateAuth(act.Auth, act.Headers); err != nil {
+			return fmt.Errorf("has invalid 'auth': %w", err)
+		}
+	}
+	if err := validateConnection(act); err != nil {
+		return err
+	}
+	if err := validateCapture(act); err != nil {
+		return err
+	}
+	if err := validateExpectations(act); err != nil {
+		return err
+	}
+
+	// ExpectStatus validation is handled at runtime with proper type conversion
+	// We allow int, float64, or []interface{} from YAML unmarshaling
+
+	// Reject Bash/Custom fields
+	if act.Command != "" || act.FunctionName != "" || act.Arguments != nil {
+		return fmt.Errorf("has unexpected Bash or Custom fields; they will be ignored")
+	}
+	return nil
+}
+
+func (httpExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteHttpActionContext(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
 // ExecuteHTTPAction executes an HTTP request defined in a workflow.Action.
 // It handles method, URL, headers, body, timeout, and response validation.
 func ExecuteHttpAction(action *workflow.Action, workflowName ...string) error {
+	_, err := ExecuteHttpActionWithOutput(action, workflowName...)
+	return err
+}
+
+// ExecuteHttpActionWithOutput executes an HTTP action and returns the response
+// body of the last attempt alongside the error.
+func ExecuteHttpActionWithOutput(action *workflow.Action, workflowName ...string) (string, error) {
+	return ExecuteHttpActionContext(context.Background(), action, workflowName...)
+}
+
+// ExecuteHttpActionContext is like ExecuteHttpActionWithOutput but aborts the
+// request and stops retrying when ctx is cancelled.
+func ExecuteHttpActionContext(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
 	if action.Type != workflow.ActionTypeHTTP {
-		return fmt.Errorf("invalid action type expected '%s' got '%s' ", workflow.ActionTypeHTTP.String(), action.Type.String())
+		return "", fmt.Errorf("invalid action type expected '%s' got '%s' ", workflow.ActionTypeHTTP.String(), action.Type.String())
 	}
 	if action.URL == "" {
-		return fmt.Errorf("http action '%s' has empty URL", action.Name)
+		return "", fmt.Errorf("http action '%s' has empty URL", action.Name)
 	}
 	if action.Method == "" {
-		return fmt.Errorf("http action '%s' has empty method", action.Name)
+		return "", fmt.Errorf("http action '%s' has empty method", action.Name)
 	}
 
 	// Track total execution time (including retries)
 	totalStartTime := time.Now()
 
 	// Execute with retry logic
-	err := retry.ExecuteWithRetry(action.Name, action.Retry, func() error {
-		return executeHttpActionOnce(action)
+	var output string
+	tries := 0
+	err := retry.ExecuteWithRetry(ctx, action.Name, action.Retry, func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("http action '%s' cancelled: %w", action.Name, err)
+		}
+		countAttempt(action, &tries)
+		var runErr error
+		output, runErr = executeHttpActionOnce(ctx, action)
+		return runErr
 	})
 
 	totalDuration := time.Since(totalStartTime)
 
 	// Record metrics if workflow name is provided
 	if len(workflowName) > 0 && workflowName[0] != "" {
-		status := "success"
-		if err != nil {
-			status = "failed"
-		}
-		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeHTTP), status, totalDuration)
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeHTTP), Status(err), totalDuration)
+		metrics.RecordActionAttempts(workflowName[0], action.Name, string(workflow.ActionTypeHTTP), tries)
 	}
 
-	return err
+	return output, err
 }
 
 // executeHttpActionOnce executes an HTTP action once without retry logic
-func executeHttpActionOnce(action *workflow.Action) error {
+func executeHttpActionOnce(parent context.Context, action *workflow.Action) (string, error) {
 
 	logger.L().Infow("Executing http action",
 		"action_name", action.Name,
 		"method", action.Method,
 		"url", action.URL)
 
-	var requestBody io.Reader
-	if action.Body != "" {
-		requestBody = bytes.NewBufferString(action.Body)
+	requestBody, contentType, err := newRequestBody(action)
+	if err != nil {
+		logger.L().Errorw("Failed to create HTTP request body", "error", err, "action_name", action.Name)
+		return "", err
+	}
+	if closer, ok := requestBody.(io.Closer); ok {
+		// Stops the multipart writer if the request is never sent
+		defer closer.Close()
 	}
 
 	req, err := http.NewRequest(action.Method, action.URL, requestBody)
 	if err != nil {
 		logger.L().Errorw("Failed to create HTTP request", "error", err, "action_name", action.Name)
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	for key, value := range action.Headers {
 		req.Header.Set(key, value)
 	}
+	if contentType != "" {
+		// The multipart boundary must match the body
+		req.Header.Set("Content-Type", contentType)
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // This ensures context is cancelled when function exits
-
+	// Without a timeout of its own the request is bounded by the shared
+	// client's default
+	timeout := httpclient.Timeout()
 	if action.Timeout != "" {
 		duration, parseError := time.ParseDuration(action.Timeout)
 		if parseError != nil {
 			logger.L().Errorw("Invalid timeout duration", "error", parseError, "timeout", action.Timeout, "action_name", action.Name)
-			return fmt.Errorf("invalid timeout duration: %w", parseError)
+			return "", fmt.Errorf("invalid timeout duration: %w", parseError)
 		}
-
-		ctx, cancel = context.WithTimeout(context.Background(), duration)
-		defer cancel()
+		timeout = duration
 	}
 
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel() // This ensures context is cancelled when function exits
+
 	req = req.WithContext(ctx)
 
-	client := &http.Client{}
+	// Fetching an OAuth2 token counts against the action's timeout
+	if err := setAuth(ctx, req, action); err != nil {
+		logger.L().Errorw("Failed to authenticate HTTP request", "error", err, "action_name", action.Name)
+		return "", err
+	}
 
+	client, err := newHTTPClient(action)
+	if err != nil {
+		return "", err
+	}
+
+	requestStart := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("HTTP action '%s' timed out after %s: %v", action.Name, action.Timeout, err)
+			return "", &retry.TimeoutError{Err: fmt.Errorf("HTTP action '%s' timed out after %s: %w", action.Name, timeout, err)}
 		}
-		return fmt.Errorf("HTTP request failed for action '%s': %v", action.Name, err)
+		return "", fmt.Errorf("HTTP request failed for action '%s': %w", action.Name, err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -104,11 +217,18 @@ func executeHttpActionOnce(action *workflow.Action) error {
 		}
 	}()
 
+	// A rejected OAuth2 token may have been revoked before it expired; the
+	// next attempt or run fetches a new one
+	if resp.StatusCode == http.StatusUnauthorized && action.Auth != nil && action.Auth.Type == workflow.AuthTypeOAuth2 {
+		invalidateToken(action.Auth)
+	}
+
 	respBodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logger.L().Errorw("Failed to read HTTP response body", "error", err, "action_name", action.Name)
-		return fmt.Errorf("failed to read HTTP response body: %w", err)
+		return "", fmt.Errorf("failed to read HTTP response body: %w", err)
 	}
+	latency := time.Since(requestStart)
 	responseBody := string(respBodyBytes)
 
 	bodyOverview := responseBody
@@ -121,6 +241,7 @@ func executeHttpActionOnce(action *workflow.Action) error {
 		"method", action.Method,
 		"url", action.URL,
 		"status_code", resp.StatusCode,
+		"latency_ms", latency.Milliseconds(),
 		"respone_body_overview", bodyOverview, // print only first few charcters
 	}
 	logger.L().Infow("HTTP action response received", logFields...)
@@ -144,15 +265,15 @@ func executeHttpActionOnce(action *workflow.Action) error {
 					expectedStatuses = append(expectedStatuses, int(val))
 				} else {
 					// Status cannot have other data type other than Int/Float64
-					err := fmt.Errorf("HTTP action '%s': invalid type in expect_status list at index %d. Expected integer, got %T", action.Name, i, s)
-					logger.L().Errorw("Invalid type in expect_status list", "error", err, "action_name", action.Name, "index", i, "type", fmt.Sprintf("%T", s))
-					return err
+					err := fmt.Errorf("HTTP action '%s': invalid type in expectStatus list at index %d. Expected integer, got %T", action.Name, i, s)
+					logger.L().Errorw("Invalid type in expectStatus list", "error", err, "action_name", action.Name, "index", i, "type", fmt.Sprintf("%T", s))
+					return responseBody, err
 				}
 			}
 		} else {
-			err := fmt.Errorf("HTTP action '%s': invalid type for expect_status: %T (expected int or list of ints)", action.Name, action.ExpectStatus)
-			logger.L().Errorw("Invalid type for expect_status", "error", err, "action_name", action.Name)
-			return err
+			err := fmt.Errorf("HTTP action '%s': invalid type for expectStatus: %T (expected int or list of ints)", action.Name, action.ExpectStatus)
+			logger.L().Errorw("Invalid type for expectStatus", "error", err, "action_name", action.Name)
+			return responseBody, err
 		}
 		statusMatch := false
 
@@ -165,8 +286,14 @@ func executeHttpActionOnce(action *workflow.Action) error {
 		if !statusMatch {
 			err := fmt.Errorf("HTTP action '%s' failed: unexpected status code %d. Expected one of: %v", action.Name, resp.StatusCode, expectedStatuses)
 			logger.L().Errorw("Unexpected status code", "error", err, "action_name", action.Name, "status_code", resp.StatusCode, "expected_statuses", expectedStatuses)
-			return err
+			return responseBody, retry.WrapHTTPError(err, resp.StatusCode)
 		}
+	} else if action.Retry != nil && retry.IsRetryableHTTPStatus(resp.StatusCode) {
+		// Without expectStatus any status passes, but an action that retries
+		// shouldn't give up on a temporary error such as a 503
+		err := fmt.Errorf("HTTP action '%s' failed: retryable status code %d", action.Name, resp.StatusCode)
+		logger.L().Errorw("Retryable status code", "error", err, "action_name", action.Name, "status_code", resp.StatusCode)
+		return responseBody, retry.WrapHTTPError(err, resp.StatusCode)
 	}
 
 	// Validate response if body has the expected string
@@ -174,11 +301,143 @@ func executeHttpActionOnce(action *workflow.Action) error {
 		if !strings.Contains(responseBody, action.ExpectBodyContains) {
 			err := fmt.Errorf("HTTP action '%s' failed: response body does not contain expected string '%s'", action.Name, action.ExpectBodyContains)
 			logger.L().Errorw("Response body does not contain expected string", "error", err, "action_name", action.Name)
-			return err
+			return responseBody, err
+		}
+	}
+
+	if err := checkExpectations(action, resp, responseBody, latency); err != nil {
+		logger.L().Errorw("Response does not meet expectations", "error", err, "action_name", action.Name)
+		return responseBody, err
+	}
+
+	// A response the value can't be extracted from fails the attempt, so an
+	// action that retries waits for it like for an expected status
+	if action.ResponsePath() != "" || action.CaptureAs != "" {
+		if _, _, err := ExtractJSON(action, responseBody); err != nil {
+			logger.L().Errorw("Failed to extract value from response", "error", err, "action_name", action.Name)
+			return responseBody, err
 		}
 	}
 
 	logger.L().Infow("Http action completed succesfully", "action_name", action.Name, "status_code", resp.Status)
 
+	return responseBody, nil
+}
+
+// newRequestBody returns the request body of an HTTP action: its body, or a
+// multipart/form-data body built from formData and files. contentType is only
+// set for multipart bodies. Files are opened up front so a missing file fails
+// the action before anything is sent, then streamed without buffering.
+func newRequestBody(action *workflow.Action) (body io.Reader, contentType string, err error) {
+	if len(action.FormData) == 0 && len(action.Files) == 0 {
+		if action.Body == "" {
+			return nil, "", nil
+		}
+		return bytes.NewBufferString(action.Body), "", nil
+	}
+
+	fields := sortedKeys(action.Files)
+	files := make([]*os.File, 0, len(fields))
+	for _, field := range fields {
+		f, err := os.Open(action.Files[field])
+		if err != nil {
+			for _, opened := range files {
+				opened.Close()
+			}
+			return nil, "", fmt.Errorf("HTTP action '%s' failed to open file for field '%s': %w", action.Name, field, err)
+		}
+		files = append(files, f)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		defer func() {
+			for _, f := range files {
+				f.Close()
+			}
+		}()
+		pw.CloseWithError(writeMultipart(mw, action.FormData, fields, files))
+	}()
+
+	return pr, mw.FormDataContentType(), nil
+}
+
+// writeMultipart writes the form fields, then each file under its field name
+func writeMultipart(mw *multipart.Writer, formData map[string]string, fields []string, files []*os.File) error {
+	for _, key := range sortedKeys(formData) {
+		if err := mw.WriteField(key, formData[key]); err != nil {
+			return err
+		}
+	}
+	for i, field := range fields {
+		part, err := mw.CreateFormFile(field, filepath.Base(files[i].Name()))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, files[i]); err != nil {
+			return fmt.Errorf("failed to read file '%s': %w", files[i].Name(), err)
+		}
+	}
+	return mw.Close()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ExtractJSON decodes the JSON response body of an HTTP action and returns it
+// with the value at the action's jsonPath (or jq) path, which is the whole
+// document if it has none
+func ExtractJSON(action *workflow.Action, body string) (doc, value interface{}, err error) {
+	doc, err = jsonpath.Decode([]byte(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("HTTP action '%s' failed: response is not valid JSON: %w", action.Name, err)
+	}
+	value = doc
+	if expr := action.ResponsePath(); expr != "" {
+		path, err := jsonpath.Parse(expr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("HTTP action '%s' failed: %w", action.Name, err)
+		}
+		if value, err = path.Lookup(doc); err != nil {
+			return nil, nil, fmt.Errorf("HTTP action '%s' failed: response has no value at '%s': %w", action.Name, expr, err)
+		}
+	}
+	return doc, value, nil
+}
+
+// captureName is the syntax of captureAs, a template variable name
+var captureName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateCapture checks the response path and captureAs of an HTTP action.
+// Actions running per item or in the background have no single response to
+// capture for the actions after them.
+func validateCapture(act *workflow.Action) error {
+	if act.JSONPath != "" && act.JQ != "" {
+		return fmt.Errorf("cannot have both 'jsonPath' and 'jq'")
+	}
+	if path := act.ResponsePath(); path != "" {
+		if _, err := jsonpath.Parse(path); err != nil {
+			return fmt.Errorf("has an %w", err)
+		}
+	}
+	if act.ResponsePath() == "" && act.CaptureAs == "" {
+		return nil
+	}
+	if act.CaptureAs != "" && !captureName.MatchString(act.CaptureAs) {
+		return fmt.Errorf("has invalid 'captureAs' '%s': must start with a letter or underscore and contain only letters, digits and underscores", act.CaptureAs)
+	}
+	if act.ForEach != nil {
+		return fmt.Errorf("cannot capture its response with 'foreach'")
+	}
+	if act.RunAsync {
+		return fmt.Errorf("cannot capture its response with 'runAsync'")
+	}
 	return nil
 }
@@ -1,9 +1,24 @@
 package action
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/codecrafted007/autozap/internal/workflow"
 )
@@ -220,6 +235,30 @@ func TestExecuteHttpAction(t *testing.T) {
 		}
 	})
 
+	t.Run("Timeout Exceeded", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(500 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeHTTP,
+			Name:    "too-slow",
+			URL:     server.URL,
+			Method:  "GET",
+			Timeout: "50ms",
+		}
+
+		err := ExecuteHttpAction(action)
+		if err == nil {
+			t.Fatal("Expected timeout error, got nil")
+		}
+		if status := Status(err); status != workflow.StatusTimeout {
+			t.Errorf("Expected status '%s', got '%s'", workflow.StatusTimeout, status)
+		}
+	})
+
 	t.Run("Invalid Timeout Format", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusOK)
@@ -319,3 +358,581 @@ func TestExecuteHttpAction(t *testing.T) {
 		}
 	})
 }
+
+func TestHttpActionRetry(t *testing.T) {
+	// flaky answers 503 until it has been called failures times
+	flaky := func(failures int) (*httptest.Server, *int) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		return server, &calls
+	}
+	retryConfig := &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "10ms"}
+
+	t.Run("Retries Retryable Status", func(t *testing.T) {
+		server, calls := flaky(2)
+		defer server.Close()
+
+		attempts := 0
+		action := &workflow.Action{
+			Type:         workflow.ActionTypeHTTP,
+			Name:         "test-retry",
+			URL:          server.URL,
+			Method:       "GET",
+			ExpectStatus: 200,
+			Retry:        retryConfig,
+			Attempts:     &attempts,
+		}
+
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if *calls != 3 || attempts != 3 {
+			t.Errorf("Expected 3 attempts, got %d calls and %d attempts", *calls, attempts)
+		}
+	})
+
+	t.Run("Retryable Status Without ExpectStatus", func(t *testing.T) {
+		server, calls := flaky(5)
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:   workflow.ActionTypeHTTP,
+			Name:   "test-retry-any-status",
+			URL:    server.URL,
+			Method: "GET",
+			Retry:  retryConfig,
+		}
+
+		if err := ExecuteHttpAction(action); err == nil {
+			t.Fatal("Expected an error after the last attempt got a 503, got nil")
+		}
+		if *calls != 3 {
+			t.Errorf("Expected 3 calls, got %d", *calls)
+		}
+	})
+
+	t.Run("Client Error Is Not Retried", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:         workflow.ActionTypeHTTP,
+			Name:         "test-no-retry",
+			URL:          server.URL,
+			Method:       "GET",
+			ExpectStatus: 200,
+			Retry:        retryConfig,
+		}
+
+		if err := ExecuteHttpAction(action); err == nil {
+			t.Fatal("Expected an error for a 404, got nil")
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 call, got %d", calls)
+		}
+	})
+}
+
+func TestHttpActionMultipart(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(report, []byte("%PDF-1.4 nightly"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Uploads Fields And Files", func(t *testing.T) {
+		var gotField, gotName, gotContent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			gotField = r.FormValue("title")
+			file, header, err := r.FormFile("report")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			defer file.Close()
+			content, _ := io.ReadAll(file)
+			gotName, gotContent = header.Filename, string(content)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:         workflow.ActionTypeHTTP,
+			Name:         "upload",
+			URL:          server.URL,
+			Method:       "POST",
+			FormData:     map[string]string{"title": "Nightly report"},
+			Files:        map[string]string{"report": report},
+			ExpectStatus: 201,
+		}
+
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if gotField != "Nightly report" {
+			t.Errorf("Expected field 'Nightly report', got '%s'", gotField)
+		}
+		if gotName != "report.pdf" || gotContent != "%PDF-1.4 nightly" {
+			t.Errorf("Unexpected file '%s' with content '%s'", gotName, gotContent)
+		}
+	})
+
+	t.Run("Missing File Fails Before Sending", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:   workflow.ActionTypeHTTP,
+			Name:   "upload-missing",
+			URL:    server.URL,
+			Method: "POST",
+			Files:  map[string]string{"report": filepath.Join(t.TempDir(), "missing.pdf")},
+		}
+
+		if err := ExecuteHttpAction(action); err == nil {
+			t.Fatal("Expected error for missing file, got nil")
+		}
+		if requests != 0 {
+			t.Errorf("Expected no request to be sent, got %d", requests)
+		}
+	})
+}
+
+func TestHttpActionNetwork(t *testing.T) {
+	t.Run("DNS Override", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Host))
+		}))
+		defer server.Close()
+
+		_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeHTTP,
+			Name:    "test-dns-override",
+			URL:     "http://api.internal:" + port + "/health",
+			Method:  "GET",
+			Network: &workflow.NetworkConfig{DNSOverride: map[string]string{"API.internal": "127.0.0.1"}},
+		}
+
+		output, err := ExecuteHttpActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		// The request keeps the original host name
+		if output != "api.internal:"+port {
+			t.Errorf("Expected Host 'api.internal:%s', got '%s'", port, output)
+		}
+	})
+
+	t.Run("Unix Socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "api.sock")
+		listener, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Fatalf("Failed to listen on socket: %v", err)
+		}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Method + " " + r.URL.Path))
+		}))
+		server.Listener = listener
+		server.Start()
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:       workflow.ActionTypeHTTP,
+			Name:       "test-unix-socket",
+			URL:        "http://docker/v1.43/containers/json",
+			Method:     "GET",
+			UnixSocket: socket,
+			// The socket bypasses the workflow's proxy
+			Network: &workflow.NetworkConfig{Proxy: "http://127.0.0.1:1"},
+		}
+
+		output, err := ExecuteHttpActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "GET /v1.43/containers/json" {
+			t.Errorf("Expected 'GET /v1.43/containers/json', got '%s'", output)
+		}
+	})
+
+	t.Run("IPv6 Target", func(t *testing.T) {
+		listener, err := net.Listen("tcp6", "[::1]:0")
+		if err != nil {
+			t.Skipf("IPv6 loopback not available: %v", err)
+		}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}))
+		server.Listener = listener
+		server.Start()
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type:   workflow.ActionTypeHTTP,
+			Name:   "test-ipv6",
+			URL:    server.URL, // http://[::1]:port
+			Method: "GET",
+		}
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A forward proxy receives the absolute URL
+			_, _ = w.Write([]byte(r.URL.String()))
+		}))
+		defer proxy.Close()
+
+		action := &workflow.Action{
+			Type:    workflow.ActionTypeHTTP,
+			Name:    "test-proxy",
+			URL:     "http://updates.example.com/latest",
+			Method:  "GET",
+			Network: &workflow.NetworkConfig{Proxy: proxy.URL},
+		}
+
+		output, err := ExecuteHttpActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "http://updates.example.com/latest" {
+			t.Errorf("Expected the request to go through the proxy, got '%s'", output)
+		}
+	})
+}
+
+func TestHttpActionAuth(t *testing.T) {
+	t.Run("Basic", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "deploy" || pass != "hunter2" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type: workflow.ActionTypeHTTP, Name: "basic", URL: server.URL, Method: "GET", ExpectStatus: 200,
+			Auth: &workflow.AuthConfig{Type: workflow.AuthTypeBasic, Username: "deploy", Password: "hunter2"},
+		}
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Bearer", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer abc123" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer server.Close()
+
+		action := &workflow.Action{
+			Type: workflow.ActionTypeHTTP, Name: "bearer", URL: server.URL, Method: "GET", ExpectStatus: 200,
+			Auth: &workflow.AuthConfig{Type: workflow.AuthTypeBearer, Token: "abc123"},
+		}
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("OAuth2 Token Is Cached", func(t *testing.T) {
+		var tokenRequests int
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenRequests++
+			user, pass, _ := r.BasicAuth()
+			if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" || user != "autozap" || pass != "s3cret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 3600}`, tokenRequests)
+		}))
+		defer tokenServer.Close()
+
+		var revoked bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if revoked && r.Header.Get("Authorization") == "Bearer token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer token-") {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer server.Close()
+
+		newAction := func() *workflow.Action {
+			return &workflow.Action{
+				Type: workflow.ActionTypeHTTP, Name: "oauth2", URL: server.URL, Method: "GET", ExpectStatus: 200,
+				Auth: &workflow.AuthConfig{Type: workflow.AuthTypeOAuth2, TokenURL: tokenServer.URL, ClientID: "autozap", ClientSecret: "s3cret", Scopes: []string{"read", "write"}},
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if err := ExecuteHttpAction(newAction()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+		if tokenRequests != 1 {
+			t.Errorf("Expected 1 token request, got %d", tokenRequests)
+		}
+
+		// A rejected token is replaced on the next attempt
+		revoked = true
+		action := newAction()
+		action.Retry = &workflow.RetryConfig{MaxAttempts: 2, InitialDelay: "1ms", RetryOn: []string{"status:401"}}
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if tokenRequests != 2 {
+			t.Errorf("Expected 2 token requests, got %d", tokenRequests)
+		}
+	})
+
+	t.Run("OAuth2 Token Request Fails", func(t *testing.T) {
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid_client"}`))
+		}))
+		defer tokenServer.Close()
+
+		action := &workflow.Action{
+			Type: workflow.ActionTypeHTTP, Name: "oauth2-invalid", URL: "http://127.0.0.1:1", Method: "GET",
+			Auth: &workflow.AuthConfig{Type: workflow.AuthTypeOAuth2, TokenURL: tokenServer.URL, ClientID: "autozap", ClientSecret: "wrong"},
+		}
+		err := ExecuteHttpAction(action)
+		if err == nil || !strings.Contains(err.Error(), "invalid_client") {
+			t.Errorf("Expected token request error, got: %v", err)
+		}
+	})
+}
+
+func TestHttpActionRedirectsAndProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("moved here"))
+	}))
+	defer server.Close()
+
+	t.Run("Follows Redirects By Default", func(t *testing.T) {
+		action := &workflow.Action{Type: workflow.ActionTypeHTTP, Name: "old", URL: server.URL + "/old", Method: "GET"}
+		output, err := ExecuteHttpActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "moved here" {
+			t.Errorf("Expected 'moved here', got '%s'", output)
+		}
+	})
+
+	t.Run("Follow Redirects False", func(t *testing.T) {
+		follow := false
+		action := &workflow.Action{Type: workflow.ActionTypeHTTP, Name: "old", URL: server.URL + "/old", Method: "GET", FollowRedirects: &follow, ExpectStatus: 302}
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected the 302 response, got: %v", err)
+		}
+	})
+
+	// A forward proxy receives the absolute URL of the request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	t.Run("Action Proxy", func(t *testing.T) {
+		action := &workflow.Action{
+			Type:   workflow.ActionTypeHTTP,
+			Name:   "external",
+			URL:    "http://api.example.invalid/status",
+			Method: "GET",
+			Proxy:  proxy.URL,
+			// The action's proxy takes precedence over the workflow's
+			Network: &workflow.NetworkConfig{Proxy: "http://127.0.0.1:1"},
+		}
+		output, err := ExecuteHttpActionWithOutput(action)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "proxied http://api.example.invalid/status" {
+			t.Errorf("Expected the request to go through the proxy, got '%s'", output)
+		}
+	})
+}
+
+func TestHttpActionExpectations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Version", "v2.1.0")
+		_, _ = w.Write([]byte(`{"status":"ok","build":"2024.10.3","replicas":3,"ready":true,"zones":["a","b"],"db":{"latency":1.5}}`))
+	}))
+	defer server.Close()
+
+	newAction := func(path string) *workflow.Action {
+		return &workflow.Action{Type: workflow.ActionTypeHTTP, Name: "check", URL: server.URL + path, Method: "GET"}
+	}
+
+	t.Run("All Met", func(t *testing.T) {
+		action := newAction("/")
+		action.ExpectBodyRegex = `"build":"2024\.\d+\.\d+"`
+		action.ExpectHeaders = map[string]string{"content-type": "application/json", "X-Version": "v2.1.0"}
+		action.ExpectMaxLatency = "5s"
+		action.ExpectJSON = map[string]interface{}{
+			".status":     "ok",
+			".replicas":   3,
+			".ready":      true,
+			".zones":      []interface{}{"a", "b"},
+			"$.db":        map[string]interface{}{"latency": 1.5},
+			".db.latency": 1.50,
+		}
+		if err := ExecuteHttpAction(action); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		modify  func(*workflow.Action)
+		wantErr string
+	}{
+		{"Body Regex", "/", func(a *workflow.Action) { a.ExpectBodyRegex = `"status":"down"` }, "does not match"},
+		{"Missing Header", "/", func(a *workflow.Action) { a.ExpectHeaders = map[string]string{"X-Cache": "HIT"} }, "no header 'X-Cache'"},
+		{"Header Value", "/", func(a *workflow.Action) { a.ExpectHeaders = map[string]string{"X-Version": "v3"} }, "is 'v2.1.0', expected 'v3'"},
+		{"JSON Value", "/", func(a *workflow.Action) { a.ExpectJSON = map[string]interface{}{".replicas": 2} }, "value at '.replicas' is 3, expected 2"},
+		{"JSON Type", "/", func(a *workflow.Action) { a.ExpectJSON = map[string]interface{}{".replicas": "3"} }, "expected \"3\""},
+		{"JSON Missing Path", "/", func(a *workflow.Action) { a.ExpectJSON = map[string]interface{}{".version": "1"} }, "no value at '.version'"},
+		{"Max Latency", "/slow", func(a *workflow.Action) { a.ExpectMaxLatency = "20ms" }, "more than expectMaxLatency 20ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := newAction(tt.path)
+			tt.modify(action)
+			err := ExecuteHttpAction(action)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing '%s', got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHttpActionTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			_, _ = w.Write([]byte("client " + r.TLS.PeerCertificates[0].Subject.CommonName))
+			return
+		}
+		_, _ = w.Write([]byte("anonymous"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	newAction := func(cfg *workflow.TLSConfig) *workflow.Action {
+		return &workflow.Action{Type: workflow.ActionTypeHTTP, Name: "internal", URL: server.URL, Method: "GET", TLS: cfg}
+	}
+
+	t.Run("Unknown CA Fails", func(t *testing.T) {
+		err := ExecuteHttpAction(newAction(nil))
+		if err == nil || !strings.Contains(err.Error(), "certificate") {
+			t.Errorf("Expected certificate error, got: %v", err)
+		}
+	})
+
+	t.Run("Custom CA", func(t *testing.T) {
+		output, err := ExecuteHttpActionWithOutput(newAction(&workflow.TLSConfig{CAFile: caFile}))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "anonymous" {
+			t.Errorf("Expected 'anonymous', got '%s'", output)
+		}
+	})
+
+	t.Run("Insecure Skip Verify", func(t *testing.T) {
+		if err := ExecuteHttpAction(newAction(&workflow.TLSConfig{InsecureSkipVerify: true})); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Client Certificate", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "autozap-agent"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+		writePEM(t, certFile, "CERTIFICATE", certDER)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+		output, err := ExecuteHttpActionWithOutput(newAction(&workflow.TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "client autozap-agent" {
+			t.Errorf("Expected 'client autozap-agent', got '%s'", output)
+		}
+	})
+
+	t.Run("Invalid CA File", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.pem")
+		if err := os.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		err := ExecuteHttpAction(newAction(&workflow.TLSConfig{CAFile: invalid}))
+		if err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+			t.Errorf("Expected CA file error, got: %v", err)
+		}
+	})
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
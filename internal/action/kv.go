@@ -0,0 +1,96 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeKV, kvExecutor{})
+}
+
+// kvExecutor sets, increments or deletes a key of the key-value store
+type kvExecutor struct{}
+
+func (kvExecutor) Validate(act *workflow.Action) error {
+	if act.Key == "" {
+		return fmt.Errorf("must have a 'key'")
+	}
+	switch act.Operation {
+	case "", "set", "delete", "incr":
+	default:
+		return fmt.Errorf("has invalid operation '%s'. Must be one of: set, delete, incr", act.Operation)
+	}
+	return nil
+}
+
+func (kvExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteKVAction(act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// KV action operations
+const (
+	KVOperationSet    = "set"
+	KVOperationDelete = "delete"
+	KVOperationIncr   = "incr"
+)
+
+// ExecuteKVAction sets or deletes a value in the persistent key-value store and
+// returns a short description of what was stored
+func ExecuteKVAction(action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeKV {
+		return "", fmt.Errorf("invalid action type for ExecuteKVAction: expected %s, got %s", workflow.ActionTypeKV, action.Type)
+	}
+	if action.Key == "" {
+		return "", fmt.Errorf("kv action '%s' has empty key", action.Name)
+	}
+
+	startTime := time.Now()
+
+	var output string
+	var err error
+	switch action.Operation {
+	case "", KVOperationSet:
+		err = database.SetKV(action.Key, action.Value)
+		output = fmt.Sprintf("%s=%s", action.Key, action.Value)
+	case KVOperationDelete:
+		err = database.DeleteKV(action.Key)
+		output = fmt.Sprintf("deleted %s", action.Key)
+	case KVOperationIncr:
+		// value holds an optional increment, defaulting to 1
+		delta := int64(1)
+		if action.Value != "" {
+			delta, err = strconv.ParseInt(action.Value, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("kv action '%s' has non-integer increment '%s'", action.Name, action.Value)
+				break
+			}
+		}
+		var value int64
+		value, err = database.IncrKV(action.Key, delta)
+		output = fmt.Sprintf("%s=%d", action.Key, value)
+	default:
+		err = fmt.Errorf("kv action '%s' has unsupported operation '%s'", action.Name, action.Operation)
+	}
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeKV), Status(err), time.Since(startTime))
+	}
+
+	if err != nil {
+		logger.L().Errorw("KV Action failed", "action_name", action.Name, "key", action.Key, "error", err)
+		return "", err
+	}
+
+	logger.L().Infow("KV Action completed successfully", "action_name", action.Name, "key", action.Key, "operation", action.Operation)
+	return output, nil
+}
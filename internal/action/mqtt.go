@@ -0,0 +1,95 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/mqtt"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeMQTT, mqttExecutor{})
+}
+
+// mqttExecutor publishes a message to the MQTT broker
+type mqttExecutor struct{}
+
+func (mqttExecutor) Validate(act *workflow.Action) error {
+	if act.Topic == "" {
+		return fmt.Errorf("must have a 'topic'")
+	}
+	if strings.ContainsAny(act.Topic, "+#") {
+		return fmt.Errorf("cannot publish to a wildcard 'topic' '%s'", act.Topic)
+	}
+	if act.QoS < 0 || act.QoS > 2 {
+		return fmt.Errorf("has invalid 'qos' %d. Must be 0, 1 or 2", act.QoS)
+	}
+	return validateTimeout(act)
+}
+
+func (mqttExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteMQTTAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// ExecuteMQTTAction publishes the action's message to its topic on the agent's
+// MQTT broker and returns a short description of what was published. Retries
+// stop when ctx is cancelled.
+func ExecuteMQTTAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeMQTT {
+		return "", fmt.Errorf("invalid action type for ExecuteMQTTAction: expected %s, got %s", workflow.ActionTypeMQTT, action.Type)
+	}
+	if action.Topic == "" {
+		return "", fmt.Errorf("mqtt action '%s' has empty topic", action.Name)
+	}
+
+	timeout := mqtt.DefaultPublishTimeout
+	if action.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(action.Timeout); err != nil {
+			return "", fmt.Errorf("invalid timeout duration: %w", err)
+		}
+	}
+
+	startTime := time.Now()
+
+	logger.L().Infow("Executing MQTT Action",
+		"action_name", action.Name,
+		"topic", action.Topic,
+		"qos", action.QoS,
+		"retain", action.Retain)
+
+	tries := 0
+	err := retry.ExecuteWithRetry(ctx, action.Name, action.Retry, func() error {
+		countAttempt(action, &tries)
+		if err := mqtt.Publish(action.Topic, byte(action.QoS), action.Retain, action.Message, timeout); err != nil {
+			err = fmt.Errorf("mqtt action '%s' failed to publish to '%s': %w", action.Name, action.Topic, err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				return &retry.TimeoutError{Err: err}
+			}
+			return err
+		}
+		return nil
+	})
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeMQTT), Status(err), time.Since(startTime))
+		metrics.RecordActionAttempts(workflowName[0], action.Name, string(workflow.ActionTypeMQTT), tries)
+	}
+
+	if err != nil {
+		logger.L().Errorw("MQTT Action failed", "action_name", action.Name, "topic", action.Topic, "error", err)
+		return "", err
+	}
+
+	logger.L().Infow("MQTT Action completed successfully", "action_name", action.Name, "topic", action.Topic)
+	return fmt.Sprintf("published %d bytes to %s", len(action.Message), action.Topic), nil
+}
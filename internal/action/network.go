@@ -0,0 +1,135 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/httpclient"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// validateConnection checks the proxy and TLS block of an HTTP, download or
+// storage action
+func validateConnection(act *workflow.Action) error {
+	// Templated proxies, e.g. with credentials from a secret, are only known at run time
+	if act.Proxy != "" && !strings.Contains(act.Proxy, "{{") {
+		if err := ValidateProxy(act.Proxy); err != nil {
+			return err
+		}
+	}
+	if act.TLS != nil {
+		if err := validateTLS(act.TLS); err != nil {
+			return fmt.Errorf("has invalid 'tls': %w", err)
+		}
+		if act.TLS.InsecureSkipVerify {
+			logger.L().Warnf("Action %s sets 'insecureSkipVerify'; server certificates will not be verified.", act.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateProxy checks that a proxy URL has a host and a supported scheme
+func ValidateProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("has invalid 'proxy' '%s': expected e.g. http://proxy.internal:3128", proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("has unsupported 'proxy' scheme '%s'. Must be one of: http, https, socks5, socks5h", u.Scheme)
+	}
+	return nil
+}
+
+// newHTTPClient returns the client for an action's request. Plain requests go
+// through the shared, pooled transport; otherwise the client gets a transport
+// with the action's TLS settings that connects over its Unix socket or through
+// its proxy and the workflow's DNS overrides. Such a transport serves a single
+// request, so it keeps no idle connections.
+func newHTTPClient(action *workflow.Action) (*http.Client, error) {
+	transport, err := newTransport(action)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+	if !action.FollowsRedirects() {
+		// Return the 3xx response itself, for expectStatus to check
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client, nil
+}
+
+// newTransport returns the transport for an action's request. The action's
+// proxy takes precedence over the workflow's, which takes precedence over the
+// agent's httpClient.proxy and the environment.
+func newTransport(action *workflow.Action) (http.RoundTripper, error) {
+	network := action.Network
+	if network == nil {
+		network = &workflow.NetworkConfig{}
+	}
+	proxy := network.Proxy
+	if action.Proxy != "" {
+		proxy = action.Proxy
+	}
+	if action.UnixSocket == "" && proxy == "" && len(network.DNSOverride) == 0 && action.TLS == nil {
+		return httpclient.Client().Transport, nil
+	}
+
+	transport := httpclient.NewTransport()
+	transport.DisableKeepAlives = true
+	if action.TLS != nil {
+		tlsConfig, err := tlsClientConfig(action.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	// A socket is local, so the URL's host only names the request's Host
+	// header and neither proxies nor DNS apply
+	if action.UnixSocket != "" {
+		socket := action.UnixSocket
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		return transport, nil
+	}
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL '%s': %w", proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if len(network.DNSOverride) > 0 {
+		overrides := make(map[string]string, len(network.DNSOverride))
+		for host, ip := range network.DNSOverride {
+			overrides[strings.ToLower(host)] = ip
+		}
+
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err == nil {
+				if ip, ok := overrides[strings.ToLower(host)]; ok {
+					addr = net.JoinHostPort(ip, port)
+				}
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	return transport, nil
+}
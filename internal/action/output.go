@@ -0,0 +1,317 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+// outputSettings bounds the memory used to capture bash output, see
+// ConfigureOutput
+var outputSettings = struct {
+	sync.RWMutex
+	memoryLimit int
+	spillDir    string
+}{memoryLimit: config.DefaultOutputMemoryLimit}
+
+// ConfigureOutput sets how much of each output stream of a bash action is
+// kept in memory, and where streams beyond that are spilled to
+func ConfigureOutput(cfg config.OutputConfig) {
+	outputSettings.Lock()
+	defer outputSettings.Unlock()
+	outputSettings.memoryLimit = int(cfg.MemoryLimitBytes())
+	outputSettings.spillDir = cfg.SpillDir
+}
+
+// SpillDir returns the directory output beyond the memory limit is spilled to
+func SpillDir() string {
+	outputSettings.RLock()
+	defer outputSettings.RUnlock()
+	if outputSettings.spillDir != "" {
+		return outputSettings.spillDir
+	}
+	return filepath.Join(os.TempDir(), "autozap-output")
+}
+
+// PruneSpillFiles deletes spill files last written before the cutoff and
+// returns how many were deleted
+func PruneSpillFiles(before time.Time) (int, error) {
+	entries, err := os.ReadDir(SpillDir())
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list spill files: %w", err)
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(SpillDir(), entry.Name())); err == nil {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// outputCapture captures an output stream with bounded memory. Up to limit
+// bytes are buffered; beyond that the stream is written to a spill file and
+// only its first and last limit/2 bytes are kept in memory.
+type outputCapture struct {
+	mu    sync.Mutex // output is read while the command still writes it
+	limit int
+	name  []string // workflow, action and stream, naming the spill file
+
+	buf *bytes.Buffer // the whole stream until it is spilled, then its head
+
+	spilled bool
+	file    *os.File // nil if the spill file couldn't be created
+	path    string
+	total   int64
+	tail    []byte // ring buffer of the last bytes once spilled
+	tailPos int    // next write position in tail
+	tailLen int
+}
+
+var spillNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// newOutputCapture returns a capture of a stream keeping up to limit bytes in
+// memory, or the configured memory limit if limit is 0
+func newOutputCapture(workflowName, actionName, stream string, limit int) *outputCapture {
+	if limit <= 0 {
+		outputSettings.RLock()
+		limit = outputSettings.memoryLimit
+		outputSettings.RUnlock()
+	}
+
+	return &outputCapture{limit: limit, name: []string{workflowName, actionName, stream}, buf: getOutputBuffer()}
+}
+
+func (c *outputCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(p)
+	c.total += int64(n)
+	if !c.spilled {
+		// Buffer p before spilling, so a first write beyond the limit still
+		// fills the head
+		c.buf.Write(p)
+		if c.buf.Len() > c.limit {
+			c.spill()
+		}
+		return n, nil
+	}
+
+	if c.file != nil {
+		if _, err := c.file.Write(p); err != nil {
+			logger.L().Errorw("Failed to write spilled output, keeping only its head and tail",
+				"path", c.path,
+				"error", err)
+			c.file.Close()
+			c.file = nil
+		}
+	}
+	c.writeTail(p)
+	return n, nil
+}
+
+// spill moves the buffered stream to a spill file, keeping its head in the
+// buffer and its end in the tail
+func (c *outputCapture) spill() {
+	c.spilled = true
+	buffered := c.buf.Bytes()
+
+	// e.g. nightly-backup-dump-stdout-20260301-020000-123456.log
+	dir := SpillDir()
+	prefix := strings.Trim(spillNameUnsafe.ReplaceAllString(strings.Join(c.name, "-"), "_"), "-")
+	err := os.MkdirAll(dir, 0o750)
+	if err == nil {
+		c.file, err = os.CreateTemp(dir, fmt.Sprintf("%s-%s-*.log", prefix, time.Now().Format("20060102-150405")))
+	}
+	if err != nil {
+		logger.L().Errorw("Failed to create spill file for large output, keeping only its head and tail",
+			"dir", dir,
+			"error", err)
+		c.file = nil
+	} else {
+		c.path = c.file.Name()
+		if _, err := c.file.Write(buffered); err != nil {
+			logger.L().Errorw("Failed to write spilled output, keeping only its head and tail",
+				"path", c.path,
+				"error", err)
+			c.file.Close()
+			c.file = nil
+		}
+	}
+
+	c.tail = make([]byte, c.limit-c.limit/2)
+	head := c.limit / 2
+	if len(buffered) > head {
+		c.writeTail(buffered[head:])
+		c.buf.Truncate(head)
+	}
+}
+
+// writeTail appends p to the tail ring buffer
+func (c *outputCapture) writeTail(p []byte) {
+	size := len(c.tail)
+	if len(p) >= size {
+		copy(c.tail, p[len(p)-size:])
+		c.tailPos, c.tailLen = 0, size
+		return
+	}
+	n := copy(c.tail[c.tailPos:], p)
+	copy(c.tail, p[n:])
+	c.tailPos = (c.tailPos + len(p)) % size
+	c.tailLen = min(c.tailLen+len(p), size)
+}
+
+// String returns the captured stream: all of it, or once spilled its head and
+// tail around a note saying where the rest is
+func (c *outputCapture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.spilled {
+		return c.buf.String()
+	}
+
+	tail := make([]byte, 0, c.tailLen)
+	if c.tailLen < len(c.tail) {
+		tail = append(tail, c.tail[:c.tailLen]...)
+	} else {
+		tail = append(tail, c.tail[c.tailPos:]...)
+		tail = append(tail, c.tail[:c.tailPos]...)
+	}
+
+	omitted := c.total - int64(c.buf.Len()) - int64(len(tail))
+	where := "it was not saved"
+	if c.file != nil {
+		where = "full output in " + c.path
+	}
+	return fmt.Sprintf("%s\n... [%d bytes omitted; %s] ...\n%s", c.buf.String(), omitted, where, tail)
+}
+
+// Total returns how many bytes were written to the stream
+func (c *outputCapture) Total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// SpillPath returns the spill file holding the whole stream, if it was spilled
+func (c *outputCapture) SpillPath() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return ""
+	}
+	return c.path
+}
+
+// Close closes the spill file and returns the buffer to the pool
+func (c *outputCapture) Close() {
+	if c.file != nil {
+		if err := c.file.Close(); err != nil {
+			logger.L().Errorw("Failed to close spill file", "path", c.path, "error", err)
+		}
+	}
+	putOutputBuffer(c.buf)
+	c.buf = nil
+}
+
+// maxLoggedLine is the longest line streamed to the log in one entry; longer
+// lines are split
+const maxLoggedLine = 8 << 10
+
+// lineLogger logs each line of an output stream as it is written, for bash
+// actions with streamOutput
+type lineLogger struct {
+	log     func(line string)
+	partial []byte // the line being written, without its newline
+}
+
+func newLineLogger(workflowName, actionName, stream string) *lineLogger {
+	return &lineLogger{log: func(line string) {
+		logger.L().Infow("Bash Action output",
+			"workflow_name", workflowName,
+			"action_name", actionName,
+			"stream", stream,
+			"line", line)
+	}}
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			l.partial = append(l.partial, p...)
+			for len(l.partial) >= maxLoggedLine {
+				l.log(string(l.partial[:maxLoggedLine]))
+				l.partial = append(l.partial[:0], l.partial[maxLoggedLine:]...)
+			}
+			break
+		}
+		l.partial = append(l.partial, p[:i]...)
+		l.Flush()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Flush logs the line being written, e.g. a last line without a newline
+func (l *lineLogger) Flush() {
+	if len(l.partial) == 0 {
+		return
+	}
+	l.log(strings.TrimSuffix(string(l.partial), "\r"))
+	l.partial = l.partial[:0]
+}
+
+// outputProgressInterval is how often a running bash action reports its
+// captured output to OnOutput
+var outputProgressInterval = 2 * time.Second
+
+// reportProgress calls onOutput with the output captured so far every
+// outputProgressInterval while it grows, until the returned stop is called
+func reportProgress(onOutput func(string), stdout, stderr *outputCapture) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(outputProgressInterval)
+		defer ticker.Stop()
+		var reported int64
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if total := stdout.Total() + stderr.Total(); total != reported {
+					reported = total
+					onOutput(stdout.String() + stderr.String())
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,12 @@
+package action
+
+import "github.com/codecrafted007/autozap/internal/workflow"
+
+// countAttempt records one try of the action in tries, for the attempts
+// metric, and for the executor's run summary
+func countAttempt(action *workflow.Action, tries *int) {
+	*tries++
+	if action.Attempts != nil {
+		*action.Attempts++
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package action
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// runAs fails: switching users is only supported on Unix
+func runAs(cmd *exec.Cmd, runAsUser, runAsGroup string) error {
+	return fmt.Errorf("cannot run as user '%s' group '%s': 'runAsUser' and 'runAsGroup' are only supported on Unix", runAsUser, runAsGroup)
+}
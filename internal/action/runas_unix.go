@@ -0,0 +1,83 @@
+//go:build unix
+
+package action
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// runAs makes cmd run as runAsUser, with the user's groups and home
+// directory, and as runAsGroup instead of the user's primary group. Only
+// root may switch to another user or group.
+func runAs(cmd *exec.Cmd, runAsUser, runAsGroup string) error {
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	var u *user.User
+	var groups []uint32
+	if runAsUser != "" {
+		var err error
+		if u, err = user.Lookup(runAsUser); err != nil {
+			if u, err = user.LookupId(runAsUser); err != nil {
+				return fmt.Errorf("cannot run as unknown user '%s'", runAsUser)
+			}
+		}
+		if uid, err = parseID(u.Uid); err != nil {
+			return fmt.Errorf("cannot run as user '%s': %w", runAsUser, err)
+		}
+		if gid, err = parseID(u.Gid); err != nil {
+			return fmt.Errorf("cannot run as user '%s': %w", runAsUser, err)
+		}
+		gids, _ := u.GroupIds()
+		for _, g := range gids {
+			if id, err := parseID(g); err == nil {
+				groups = append(groups, id)
+			}
+		}
+	}
+	if runAsGroup != "" {
+		g, err := user.LookupGroup(runAsGroup)
+		if err != nil {
+			if g, err = user.LookupGroupId(runAsGroup); err != nil {
+				return fmt.Errorf("cannot run as unknown group '%s'", runAsGroup)
+			}
+		}
+		if gid, err = parseID(g.Gid); err != nil {
+			return fmt.Errorf("cannot run as group '%s': %w", runAsGroup, err)
+		}
+	}
+
+	if u != nil {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+	}
+
+	if os.Geteuid() != 0 {
+		if uid == uint32(os.Geteuid()) && gid == uint32(os.Getegid()) {
+			// Already the requested user and group
+			return nil
+		}
+		return fmt.Errorf("cannot run as user '%s' group '%s': autozap must run as root to switch users", runAsUser, runAsGroup)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{
+		Uid:    uid,
+		Gid:    gid,
+		Groups: groups,
+		// Without a user, only the group changes; keep root's other groups
+		NoSetGroups: u == nil,
+	}}
+	return nil
+}
+
+func parseID(id string) (uint32, error) {
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id '%s'", id)
+	}
+	return uint32(n), nil
+}
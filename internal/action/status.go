@@ -0,0 +1,23 @@
+package action
+
+import (
+	"context"
+	"errors"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Status returns the status recorded for an action that returned err:
+// success, timeout if it ran out of time, cancelled if its run was stopped,
+// or failed
+func Status(err error) string {
+	switch {
+	case err == nil:
+		return workflow.StatusSuccess
+	case errors.Is(err, context.DeadlineExceeded):
+		return workflow.StatusTimeout
+	case errors.Is(err, context.Canceled):
+		return workflow.StatusCancelled
+	}
+	return workflow.StatusFailed
+}
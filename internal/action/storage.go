@@ -0,0 +1,531 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func init() {
+	Register(workflow.ActionTypeStorage, storageExecutor{})
+}
+
+// storageExecutor uploads files to or downloads them from S3, GCS or SFTP
+type storageExecutor struct{}
+
+func (storageExecutor) Fields() []string {
+	return []string{"storage", "proxy", "tls"}
+}
+
+func (storageExecutor) Validate(act *workflow.Action) error {
+	if act.URL == "" {
+		return fmt.Errorf("must have a 'url'")
+	}
+	if act.Path == "" {
+		return fmt.Errorf("must have a 'path'")
+	}
+	switch act.Operation {
+	case "upload", "download":
+	case "":
+		return fmt.Errorf("must have an 'operation'. Must be one of: upload, download")
+	default:
+		return fmt.Errorf("has invalid operation '%s'. Must be one of: upload, download", act.Operation)
+	}
+	if err := validateConnection(act); err != nil {
+		return err
+	}
+	if err := validateStorage(act); err != nil {
+		return err
+	}
+	return validateTimeout(act)
+}
+
+func (storageExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteStorageAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// Storage action operations
+const (
+	StorageOperationUpload   = "upload"
+	StorageOperationDownload = "download"
+)
+
+// Default endpoints of the S3-compatible APIs
+const (
+	defaultS3Endpoint  = "https://s3.amazonaws.com"
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+)
+
+// sftpDialTimeout bounds connecting to an SFTP server, separately from the
+// action's timeout, which also covers the transfer
+const sftpDialTimeout = 30 * time.Second
+
+// ExecuteStorageAction uploads the file at the action's path to its URL, or
+// downloads the URL to the path, and returns a short description of what it
+// did. Downloads are written to a temporary file that replaces the path once
+// complete, and SFTP uploads to "<path>.part" renamed on the server, so
+// neither side sees a partial file. The action's timeout applies per attempt.
+func ExecuteStorageAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeStorage {
+		return "", fmt.Errorf("invalid action type for ExecuteStorageAction: expected %s, got %s", workflow.ActionTypeStorage, action.Type)
+	}
+	if action.URL == "" {
+		return "", fmt.Errorf("storage action '%s' has empty URL", action.Name)
+	}
+	if action.Path == "" {
+		return "", fmt.Errorf("storage action '%s' has empty path", action.Name)
+	}
+
+	startTime := time.Now()
+
+	var output string
+	tries := 0
+	err := retry.ExecuteWithRetry(ctx, action.Name, action.Retry, func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("storage action %s cancelled: %w", action.Name, err)
+		}
+		countAttempt(action, &tries)
+		var runErr error
+		output, runErr = transferOnce(ctx, action)
+		return runErr
+	})
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeStorage), Status(err), time.Since(startTime))
+		metrics.RecordActionAttempts(workflowName[0], action.Name, string(workflow.ActionTypeStorage), tries)
+	}
+
+	if err != nil {
+		logger.L().Errorw("Storage Action failed", "action_name", action.Name, "operation", action.Operation, "url", action.URL, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Storage Action completed successfully", "action_name", action.Name, "operation", action.Operation, "url", action.URL, "path", action.Path)
+	return output, nil
+}
+
+// transferOnce makes a single upload or download attempt
+func transferOnce(ctx context.Context, action *workflow.Action) (string, error) {
+	remote, err := workflow.ParseStorageURL(action.URL)
+	if err != nil {
+		return "", fmt.Errorf("storage action '%s' has invalid URL '%s': %w", action.Name, action.URL, err)
+	}
+
+	var local string
+	switch action.Operation {
+	case StorageOperationUpload:
+		info, err := os.Stat(action.Path)
+		if err != nil {
+			return "", err
+		}
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("cannot upload %s: not a regular file", action.Path)
+		}
+		local = action.Path
+		if strings.HasSuffix(remote.Path, "/") {
+			remote.Path += filepath.Base(local)
+		}
+	case StorageOperationDownload:
+		if strings.HasSuffix(remote.Path, "/") {
+			return "", fmt.Errorf("storage action '%s' cannot download '%s': the URL must name a file", action.Name, action.URL)
+		}
+		local = destinationPath(path.Base(remote.Path), action.Path)
+		if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create download directory: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("storage action '%s' has unsupported operation '%s'", action.Name, action.Operation)
+	}
+
+	if action.Timeout != "" {
+		timeout, err := time.ParseDuration(action.Timeout)
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout duration: %w", err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	logger.L().Infow("Executing Storage Action",
+		"action_name", action.Name,
+		"operation", action.Operation,
+		"url", remote.String(),
+		"path", local)
+
+	var size int64
+	if remote.Scheme == workflow.StorageSchemeSFTP {
+		size, err = transferSFTP(ctx, action, remote, local)
+	} else {
+		size, err = transferObject(ctx, action, remote, local)
+	}
+	if err != nil {
+		err = fmt.Errorf("storage action '%s' failed to %s %s: %w", action.Name, action.Operation, remote, err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &retry.TimeoutError{Err: err}
+		}
+		return "", err
+	}
+
+	if action.Operation == StorageOperationUpload {
+		return fmt.Sprintf("uploaded %s (%s) -> %s", local, formatBytes(size), remote), nil
+	}
+	return fmt.Sprintf("downloaded %s (%s) -> %s", remote, formatBytes(size), local), nil
+}
+
+// transferObject uploads or downloads an object in an S3 or GCS bucket and
+// returns its size
+func transferObject(ctx context.Context, action *workflow.Action, remote *url.URL, local string) (int64, error) {
+	client, err := newObjectClient(action, remote.Scheme)
+	if err != nil {
+		return 0, err
+	}
+	bucket, key := remote.Host, strings.TrimPrefix(remote.Path, "/")
+
+	if action.Operation == StorageOperationUpload {
+		f, err := os.Open(local)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return 0, err
+		}
+		// Large files are uploaded in parts, in parallel
+		_, err = client.PutObject(ctx, bucket, key, f, info.Size(), minio.PutObjectOptions{ContentType: contentType(local)})
+		return info.Size(), objectError(err)
+	}
+
+	object, err := client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return 0, objectError(err)
+	}
+	defer object.Close()
+	var written int64
+	err = writeFileReplacing(local, 0o644, func(w io.Writer) error {
+		var err error
+		written, err = io.Copy(w, object)
+		return err
+	})
+	return written, objectError(err)
+}
+
+// newObjectClient returns a client for the S3-compatible API of an s3:// or
+// gs:// URL, connecting through the action's proxy and TLS settings
+func newObjectClient(action *workflow.Action, scheme string) (*minio.Client, error) {
+	cfg := action.Storage
+	if cfg == nil {
+		cfg = &workflow.StorageConfig{}
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultS3Endpoint
+		if scheme == workflow.StorageSchemeGCS {
+			endpoint = defaultGCSEndpoint
+		}
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+		return nil, fmt.Errorf("invalid storage endpoint '%s': must be an http:// or https:// URL", endpoint)
+	}
+
+	var creds *credentials.Credentials
+	switch {
+	case cfg.AccessKey != "" || cfg.SecretKey != "":
+		creds = credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, cfg.SessionToken)
+	case scheme == workflow.StorageSchemeGCS:
+		return nil, fmt.Errorf("gs:// URLs need storage.accessKey and storage.secretKey (an HMAC key of a service account)")
+	default:
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	transport, err := newTransport(action)
+	if err != nil {
+		return nil, err
+	}
+	return minio.New(endpointURL.Host, &minio.Options{
+		Creds:     creds,
+		Secure:    endpointURL.Scheme == "https",
+		Region:    cfg.Region,
+		Transport: transport,
+		// Retries follow the action's retry configuration
+		MaxRetries: 1,
+	})
+}
+
+// objectError marks an error response of an object store with its status
+// code, so retryOn conditions such as "status:503" match it
+func objectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if code := minio.ToErrorResponse(err).StatusCode; code != 0 {
+		return retry.WrapHTTPError(err, code)
+	}
+	return err
+}
+
+// contentType returns the MIME type an upload is stored with, by extension
+func contentType(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// transferSFTP uploads or downloads a file over SFTP and returns its size
+func transferSFTP(ctx context.Context, action *workflow.Action, remote *url.URL, local string) (int64, error) {
+	client, closeClient, err := dialSFTP(ctx, action.Storage, remote)
+	if err != nil {
+		return 0, err
+	}
+	defer closeClient()
+
+	if action.Operation == StorageOperationUpload {
+		return uploadSFTP(client, local, remote.Path)
+	}
+
+	f, err := client.Open(remote.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var written int64
+	err = writeFileReplacing(local, 0o644, func(w io.Writer) error {
+		var err error
+		written, err = io.Copy(w, f)
+		return err
+	})
+	return written, err
+}
+
+// uploadSFTP writes local to "<dest>.part" on the server and renames it to
+// dest once complete
+func uploadSFTP(client *sftp.Client, local, dest string) (int64, error) {
+	in, err := os.Open(local)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	if err := client.MkdirAll(path.Dir(dest)); err != nil {
+		return 0, fmt.Errorf("failed to create remote directory: %w", err)
+	}
+	partial := dest + partialSuffix
+	out, err := client.OpenFile(partial, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		client.Remove(partial)
+		return written, err
+	}
+
+	// Plain SFTP renames fail if dest exists; OpenSSH's extension replaces it
+	if err := client.PosixRename(partial, dest); err != nil {
+		client.Remove(dest)
+		if err := client.Rename(partial, dest); err != nil {
+			return written, fmt.Errorf("failed to move upload into place: %w", err)
+		}
+	}
+	return written, nil
+}
+
+// dialSFTP connects and logs in to the SFTP server of remote, returning the
+// client and a function closing the connection. The connection is also closed
+// when ctx is done, which aborts a transfer in progress.
+func dialSFTP(ctx context.Context, cfg *workflow.StorageConfig, remote *url.URL) (*sftp.Client, func(), error) {
+	if cfg == nil {
+		cfg = &workflow.StorageConfig{}
+	}
+	username := cfg.Username
+	if username == "" {
+		username = remote.User.Username()
+	}
+	if username == "" {
+		return nil, nil, fmt.Errorf("sftp:// URLs need a user, in the URL or as storage.username")
+	}
+
+	var auth []ssh.AuthMethod
+	if cfg.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if cfg.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(cfg.PrivateKey), []byte(cfg.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(cfg.PrivateKey))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid storage.privateKey: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(auth) == 0 {
+		return nil, nil, fmt.Errorf("sftp:// URLs need storage.password or storage.privateKey")
+	}
+
+	hostKeyCallback, err := sftpHostKeyCallback(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr := remote.Host
+	if remote.Port() == "" {
+		addr = net.JoinHostPort(remote.Hostname(), "22")
+	}
+	dialer := &net.Dialer{Timeout: sftpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sftpDialTimeout,
+	})
+	if err != nil {
+		stop()
+		conn.Close()
+		return nil, nil, err
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		stop()
+		sshClient.Close()
+		return nil, nil, err
+	}
+	return client, func() {
+		stop()
+		client.Close()
+		sshClient.Close()
+	}, nil
+}
+
+// sftpHostKeyCallback verifies the server's host key against the configured
+// fingerprint, or else the known_hosts file
+func sftpHostKeyCallback(cfg *workflow.StorageConfig) (ssh.HostKeyCallback, error) {
+	if cfg.HostKey != "" {
+		want := cfg.HostKey
+		return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != want {
+				return fmt.Errorf("host key of %s is %s, expected %s", hostname, got, want)
+			}
+			return nil
+		}, nil
+	}
+
+	file := cfg.KnownHosts
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot find known_hosts: %w; set storage.hostKey or storage.knownHosts", err)
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w; set storage.hostKey or storage.knownHosts", err)
+	}
+	return callback, nil
+}
+
+// validateStorage checks the URL and storage configuration of a storage
+// action as far as they are known before it runs; templated values are
+// checked when it does
+func validateStorage(act *workflow.Action) error {
+	cfg := act.Storage
+	if cfg == nil {
+		cfg = &workflow.StorageConfig{}
+	}
+	if cfg.AccessKey != "" && cfg.SecretKey == "" || cfg.SecretKey != "" && cfg.AccessKey == "" {
+		return fmt.Errorf("must set both 'storage.accessKey' and 'storage.secretKey'")
+	}
+	if cfg.HostKey != "" && !strings.Contains(cfg.HostKey, "{{") && !strings.HasPrefix(cfg.HostKey, "SHA256:") {
+		return fmt.Errorf("has invalid 'storage.hostKey' '%s': must be a SHA256 fingerprint, e.g. SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8", cfg.HostKey)
+	}
+	if cfg.Endpoint != "" && !strings.Contains(cfg.Endpoint, "{{") {
+		endpoint, err := url.Parse(cfg.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("has invalid 'storage.endpoint' '%s': must be an http:// or https:// URL", cfg.Endpoint)
+		}
+		if strings.Trim(endpoint.Path, "/") != "" {
+			return fmt.Errorf("has invalid 'storage.endpoint' '%s': the bucket goes in the action's url, not the endpoint", cfg.Endpoint)
+		}
+	}
+
+	// The scheme decides which fields apply; a templated URL may have any
+	if strings.Contains(act.URL, "{{") {
+		return nil
+	}
+	remote, err := workflow.ParseStorageURL(act.URL)
+	if err != nil {
+		return fmt.Errorf("has invalid 'url' '%s': %w", act.URL, err)
+	}
+	if act.Operation == "download" && strings.HasSuffix(remote.Path, "/") {
+		return fmt.Errorf("cannot download '%s': the url must name a file, not a directory", act.URL)
+	}
+
+	objectFields := cfg.Endpoint != "" || cfg.Region != "" || cfg.AccessKey != "" || cfg.SessionToken != ""
+	sftpFields := cfg.Username != "" || cfg.Password != "" || cfg.PrivateKey != "" || cfg.Passphrase != "" || cfg.HostKey != "" || cfg.KnownHosts != ""
+	switch remote.Scheme {
+	case workflow.StorageSchemeSFTP:
+		if objectFields {
+			return fmt.Errorf("uses 'endpoint', 'region', 'accessKey', 'secretKey' or 'sessionToken', which are only supported by s3:// and gs:// URLs")
+		}
+		if act.TLS != nil || act.Proxy != "" {
+			return fmt.Errorf("uses 'tls' or 'proxy', which are only supported by s3:// and gs:// URLs")
+		}
+		if cfg.Password == "" && cfg.PrivateKey == "" {
+			return fmt.Errorf("must set 'storage.password' or 'storage.privateKey' for an sftp:// URL")
+		}
+		if cfg.Username == "" && remote.User.Username() == "" {
+			return fmt.Errorf("must name a user in the url (sftp://user@host/path) or 'storage.username'")
+		}
+	default:
+		if sftpFields {
+			return fmt.Errorf("uses 'username', 'password', 'privateKey', 'passphrase', 'hostKey' or 'knownHosts', which are only supported by sftp:// URLs")
+		}
+		if remote.Scheme == workflow.StorageSchemeGCS && cfg.AccessKey == "" {
+			return fmt.Errorf("must set 'storage.accessKey' and 'storage.secretKey' for a gs:// URL, an HMAC key of a service account")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package action
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// fakeS3 is a minimal S3 API storing objects in memory
+type fakeS3 struct {
+	mu            sync.Mutex
+	objects       map[string][]byte
+	contentTypes  map[string]string
+	authorization string
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorization = r.Header.Get("Authorization")
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		s.objects[r.URL.Path] = data
+		s.contentTypes[r.URL.Path] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestExecuteStorageActionS3(t *testing.T) {
+	store := &fakeS3{objects: map[string][]byte{}, contentTypes: map[string]string{}}
+	server := httptest.NewTLSServer(store)
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(dir, "db.sql.gz")
+	if err := os.WriteFile(backup, []byte("backup data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newAction := func(operation, url, path string) *workflow.Action {
+		return &workflow.Action{
+			Type:      workflow.ActionTypeStorage,
+			Name:      operation + "-backup",
+			Operation: operation,
+			URL:       url,
+			Path:      path,
+			Storage: &workflow.StorageConfig{
+				Endpoint:  server.URL,
+				Region:    "us-east-1",
+				AccessKey: "AKIDEXAMPLE",
+				SecretKey: "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+			},
+			TLS: &workflow.TLSConfig{CAFile: caFile},
+		}
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		output, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationUpload, "s3://backups/nightly/", backup))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := string(store.objects["/backups/nightly/db.sql.gz"]); got != "backup data" {
+			t.Errorf("Expected the file stored under the directory key, got objects %v", store.objects)
+		}
+		if got := store.contentTypes["/backups/nightly/db.sql.gz"]; got != "application/gzip" {
+			t.Errorf("Expected content type application/gzip, got %q", got)
+		}
+		if !strings.HasPrefix(store.authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			t.Errorf("Expected a SigV4 signed request, got Authorization %q", store.authorization)
+		}
+		if !strings.Contains(output, "uploaded ") || !strings.Contains(output, "s3://backups/nightly/db.sql.gz") {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		restoreDir := filepath.Join(dir, "restore") + "/"
+		if _, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationDownload, "s3://backups/nightly/db.sql.gz", restoreDir)); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "restore", "db.sql.gz"))
+		if err != nil || string(data) != "backup data" {
+			t.Errorf("Expected the object downloaded into the directory, got %q (%v)", data, err)
+		}
+	})
+
+	t.Run("Missing Object", func(t *testing.T) {
+		path := filepath.Join(dir, "missing.sql.gz")
+		_, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationDownload, "s3://backups/missing.sql.gz", path))
+		if err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Errorf("Expected a missing key error, got: %v", err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("Expected no file for a failed download")
+		}
+	})
+}
+
+func TestExecuteStorageActionSFTP(t *testing.T) {
+	addr, fingerprint := startSFTPServer(t, "backup", "s3cret")
+	dir := t.TempDir()
+	remoteDir := filepath.Join(dir, "remote")
+
+	backup := filepath.Join(dir, "db.sql.gz")
+	if err := os.WriteFile(backup, []byte("backup data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newAction := func(operation, path, remotePath string, cfg workflow.StorageConfig) *workflow.Action {
+		return &workflow.Action{
+			Type:      workflow.ActionTypeStorage,
+			Name:      operation + "-backup",
+			Operation: operation,
+			URL:       "sftp://backup@" + addr + remotePath,
+			Path:      path,
+			Storage:   &cfg,
+		}
+	}
+	valid := workflow.StorageConfig{Password: "s3cret", HostKey: fingerprint}
+
+	t.Run("Upload", func(t *testing.T) {
+		if _, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationUpload, backup, remoteDir+"/incoming/", valid)); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(remoteDir, "incoming", "db.sql.gz"))
+		if err != nil || string(data) != "backup data" {
+			t.Errorf("Expected the file on the server, got %q (%v)", data, err)
+		}
+		if _, err := os.Stat(filepath.Join(remoteDir, "incoming", "db.sql.gz.part")); !os.IsNotExist(err) {
+			t.Error("Expected the partial file to be renamed")
+		}
+
+		// Uploading again replaces the file
+		if err := os.WriteFile(backup, []byte("newer backup"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationUpload, backup, remoteDir+"/incoming/db.sql.gz", valid)); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(remoteDir, "incoming", "db.sql.gz")); string(data) != "newer backup" {
+			t.Errorf("Expected the file to be replaced, got %q", data)
+		}
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		restored := filepath.Join(dir, "restored.sql.gz")
+		output, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationDownload, restored, remoteDir+"/incoming/db.sql.gz", valid))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if data, _ := os.ReadFile(restored); string(data) != "newer backup" {
+			t.Errorf("Expected the downloaded file, got %q", data)
+		}
+		if !strings.HasPrefix(output, "downloaded ") {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Wrong Host Key", func(t *testing.T) {
+		cfg := valid
+		cfg.HostKey = "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"
+		_, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationUpload, backup, remoteDir+"/other/", cfg))
+		if err == nil || !strings.Contains(err.Error(), "host key") {
+			t.Errorf("Expected a host key error, got: %v", err)
+		}
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		cfg := valid
+		cfg.Password = "wrong"
+		if _, err := ExecuteStorageAction(context.Background(), newAction(StorageOperationUpload, backup, remoteDir+"/other/", cfg)); err == nil {
+			t.Error("Expected an authentication error")
+		}
+	})
+}
+
+// startSFTPServer serves the local file system over SFTP to a user logging
+// in with a password, and returns its address and host key fingerprint
+func startSFTPServer(t *testing.T, user, password string) (string, string) {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if conn.User() == user && string(pass) == password {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+	return listener.Addr().String(), ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+// serveSFTP runs the sftp subsystem on the sessions of an SSH connection
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}()
+		go func() {
+			defer channel.Close()
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			server.Serve()
+		}()
+	}
+}
@@ -0,0 +1,115 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeTemplate, templateExecutor{})
+}
+
+// templateExecutor renders a template file to a path
+type templateExecutor struct{}
+
+func (templateExecutor) Fields() []string {
+	return []string{"template", "mode"}
+}
+
+func (templateExecutor) Validate(act *workflow.Action) error {
+	if act.Template == "" {
+		return fmt.Errorf("must have a 'template' file")
+	}
+	if act.Path == "" {
+		return fmt.Errorf("must have a 'path' to write to")
+	}
+	if act.Mode != "" {
+		if _, err := workflow.ParseFileMode(act.Mode); err != nil {
+			return fmt.Errorf("has invalid 'mode' '%s': %w", act.Mode, err)
+		}
+	}
+	return nil
+}
+
+func (templateExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteTemplateAction(act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// ExecuteTemplateAction writes a template action's rendered content to its
+// path and returns a short description of what it did. The file is written
+// through a temporary file renamed into place, so readers never see a
+// partial file, and left alone if it already has the content and mode, so
+// "<path> unchanged" in the output tells later steps there is nothing to
+// reload.
+func ExecuteTemplateAction(action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeTemplate {
+		return "", fmt.Errorf("invalid action type for ExecuteTemplateAction: expected %s, got %s", workflow.ActionTypeTemplate, action.Type)
+	}
+	if action.Path == "" {
+		return "", fmt.Errorf("template action '%s' has empty path", action.Name)
+	}
+
+	startTime := time.Now()
+	output, err := writeTemplate(action)
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeTemplate), Status(err), time.Since(startTime))
+	}
+
+	if err != nil {
+		logger.L().Errorw("Template Action failed", "action_name", action.Name, "template", action.Template, "path", action.Path, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Template Action completed successfully", "action_name", action.Name, "template", action.Template, "path", action.Path)
+	return output, nil
+}
+
+// writeTemplate does the work of ExecuteTemplateAction
+func writeTemplate(action *workflow.Action) (string, error) {
+	perm := fs.FileMode(0o644)
+	existing, err := os.Stat(action.Path)
+	switch {
+	case err == nil && existing.IsDir():
+		return "", fmt.Errorf("cannot write %s: is a directory", action.Path)
+	case err == nil:
+		perm = existing.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", err
+	}
+	if action.Mode != "" {
+		if perm, err = workflow.ParseFileMode(action.Mode); err != nil {
+			return "", fmt.Errorf("template action '%s' has invalid mode '%s': %w", action.Name, action.Mode, err)
+		}
+	}
+
+	if existing != nil && existing.Mode().Perm() == perm && existing.Size() == int64(len(action.Content)) {
+		if current, err := os.ReadFile(action.Path); err == nil && string(current) == action.Content {
+			return fmt.Sprintf("%s unchanged", action.Path), nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(action.Path), 0o755); err != nil {
+		return "", err
+	}
+	err = writeFileReplacing(action.Path, perm, func(w io.Writer) error {
+		_, err := io.WriteString(w, action.Content)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("rendered %s -> %s (%s)", action.Template, action.Path, formatBytes(int64(len(action.Content)))), nil
+}
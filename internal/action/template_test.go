@@ -0,0 +1,87 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestExecuteTemplateAction(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf.d", "app.conf")
+	act := &workflow.Action{
+		Type:     workflow.ActionTypeTemplate,
+		Name:     "config",
+		Template: "app.conf.tmpl",
+		Path:     path,
+		Content:  "listen 8080\n",
+	}
+
+	t.Run("Writes File", func(t *testing.T) {
+		output, err := ExecuteTemplateAction(act)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read rendered file: %v", err)
+		}
+		if string(data) != "listen 8080\n" {
+			t.Errorf("Unexpected content %q", data)
+		}
+		if !strings.HasPrefix(output, "rendered app.conf.tmpl -> ") {
+			t.Errorf("Unexpected output: %s", output)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+			t.Errorf("Expected permissions 0644, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		output, err := ExecuteTemplateAction(act)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != path+" unchanged" {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Keeps Existing Mode", func(t *testing.T) {
+		if err := os.Chmod(path, 0640); err != nil {
+			t.Fatal(err)
+		}
+		act.Content = "listen 9090\n"
+		if _, err := ExecuteTemplateAction(act); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+			t.Errorf("Expected permissions 0640, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("Mode", func(t *testing.T) {
+		act.Mode = "0600"
+		output, err := ExecuteTemplateAction(act)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.HasSuffix(output, "unchanged") {
+			t.Error("Expected a mode change to rewrite the file")
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("Expected permissions 0600, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		dirAction := *act
+		dirAction.Path = dir
+		if _, err := ExecuteTemplateAction(&dirAction); err == nil {
+			t.Error("Expected an error writing over a directory")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package action
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// validateTLS checks that a TLS block sets a client certificate together
+// with its key, and that its CA file isn't ignored
+func validateTLS(cfg *workflow.TLSConfig) error {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return fmt.Errorf("'certFile' and 'keyFile' must be set together")
+	}
+	if cfg.InsecureSkipVerify && (cfg.CAFile != "" || cfg.ServerName != "") {
+		return fmt.Errorf("'insecureSkipVerify' cannot be combined with 'caFile' or 'serverName', which only apply when verifying")
+	}
+	return nil
+}
+
+// tlsClientConfig builds the TLS configuration of an action's connections.
+// The files are read for every client, so renewed certificates are picked
+// up without restarting the agent.
+func tlsClientConfig(cfg *workflow.TLSConfig) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS CA file '%s' has no PEM certificates", cfg.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,190 @@
+package action
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeVerify, verifyExecutor{})
+}
+
+// verifyExecutor checks files against the checksums of a manifest
+type verifyExecutor struct{}
+
+func (verifyExecutor) Validate(act *workflow.Action) error {
+	if act.Manifest == "" {
+		return fmt.Errorf("must have a 'manifest'")
+	}
+	switch act.Algorithm {
+	case "", "sha256", "sha512", "sha1", "md5":
+	default:
+		return fmt.Errorf("has invalid algorithm '%s'. Must be one of: sha256, sha512, sha1, md5", act.Algorithm)
+	}
+	return nil
+}
+
+func (verifyExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteVerifyAction(act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// newHash returns the hash constructor for a verify action algorithm
+func newHash(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "md5":
+		return md5.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm '%s'", algorithm)
+	}
+}
+
+// manifestEntry is one line of a checksum manifest
+type manifestEntry struct {
+	checksum string
+	file     string
+}
+
+// parseManifest reads a checksum file in the format written by sha256sum and
+// friends: "<hex digest>  <file>" or "<hex digest> *<file>" for binary mode.
+// Blank lines and lines starting with # are ignored.
+func parseManifest(r io.Reader) ([]manifestEntry, error) {
+	var entries []manifestEntry
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		checksum, file, ok := strings.Cut(line, " ")
+		file = strings.TrimPrefix(strings.TrimLeft(file, " "), "*")
+		if !ok || file == "" {
+			return nil, fmt.Errorf("invalid manifest line %d: expected '<checksum>  <file>'", lineNum)
+		}
+		if _, err := hex.DecodeString(checksum); err != nil {
+			return nil, fmt.Errorf("invalid checksum on manifest line %d: %w", lineNum, err)
+		}
+
+		entries = append(entries, manifestEntry{checksum: strings.ToLower(checksum), file: file})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("manifest contains no entries")
+	}
+	return entries, nil
+}
+
+// fileChecksum returns the hex digest of a file
+func fileChecksum(path string, newHash func() hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ExecuteVerifyAction checks every file listed in the action's manifest against
+// its checksum. Relative file names are resolved against the manifest's directory.
+// It returns a sha256sum -c style report and fails if any file is missing or differs.
+func ExecuteVerifyAction(action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeVerify {
+		return "", fmt.Errorf("invalid action type for ExecuteVerifyAction: expected %s, got %s", workflow.ActionTypeVerify, action.Type)
+	}
+	if action.Manifest == "" {
+		return "", fmt.Errorf("verify action '%s' has empty manifest", action.Name)
+	}
+
+	startTime := time.Now()
+	output, err := verifyManifest(action.Manifest, action.Algorithm)
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeVerify), Status(err), time.Since(startTime))
+	}
+
+	if err != nil {
+		logger.L().Errorw("Verify Action failed", "action_name", action.Name, "manifest", action.Manifest, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Verify Action completed successfully", "action_name", action.Name, "manifest", action.Manifest)
+	return output, nil
+}
+
+// verifyManifest does the work of ExecuteVerifyAction
+func verifyManifest(manifestPath, algorithm string) (string, error) {
+	newHash, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open manifest: %w", err)
+	}
+	entries, err := parseManifest(f)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse manifest '%s': %w", manifestPath, err)
+	}
+
+	baseDir := filepath.Dir(manifestPath)
+	var report strings.Builder
+	failed := 0
+	for _, entry := range entries {
+		path := entry.file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+
+		sum, err := fileChecksum(path, newHash)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(&report, "%s: FAILED open or read (%v)\n", entry.file, err)
+		case sum != entry.checksum:
+			failed++
+			fmt.Fprintf(&report, "%s: FAILED\n", entry.file)
+		default:
+			fmt.Fprintf(&report, "%s: OK\n", entry.file)
+		}
+	}
+
+	if failed > 0 {
+		return report.String(), fmt.Errorf("checksum verification failed for %d of %d files", failed, len(entries))
+	}
+	return report.String(), nil
+}
@@ -0,0 +1,111 @@
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestExecuteVerifyAction(t *testing.T) {
+	dir := t.TempDir()
+
+	sha := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("backup.tar.gz", "backup data")
+	write("db.sql", "dump")
+
+	t.Run("All Files Match", func(t *testing.T) {
+		write("good.sha256", fmt.Sprintf("%s  backup.tar.gz\n%s *db.sql\n", sha("backup data"), sha("dump")))
+
+		output, err := ExecuteVerifyAction(&workflow.Action{
+			Type:     workflow.ActionTypeVerify,
+			Name:     "verify-backup",
+			Manifest: filepath.Join(dir, "good.sha256"),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "backup.tar.gz: OK\ndb.sql: OK\n" {
+			t.Errorf("Unexpected report: %q", output)
+		}
+	})
+
+	t.Run("Checksum Mismatch", func(t *testing.T) {
+		write("bad.sha256", fmt.Sprintf("%s  backup.tar.gz\n%s  db.sql\n", sha("backup data"), sha("other")))
+
+		output, err := ExecuteVerifyAction(&workflow.Action{
+			Type:     workflow.ActionTypeVerify,
+			Name:     "verify-backup",
+			Manifest: filepath.Join(dir, "bad.sha256"),
+		})
+		if err == nil {
+			t.Fatal("Expected error for checksum mismatch, got nil")
+		}
+		if !strings.Contains(output, "db.sql: FAILED") {
+			t.Errorf("Expected report to list failed file, got: %q", output)
+		}
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		write("missing.sha256", fmt.Sprintf("%s  gone.tar.gz\n", sha("x")))
+
+		_, err := ExecuteVerifyAction(&workflow.Action{
+			Type:     workflow.ActionTypeVerify,
+			Name:     "verify-backup",
+			Manifest: filepath.Join(dir, "missing.sha256"),
+		})
+		if err == nil {
+			t.Fatal("Expected error for missing file, got nil")
+		}
+	})
+
+	t.Run("Invalid Manifest", func(t *testing.T) {
+		write("invalid.sha256", "not-a-checksum\n")
+
+		_, err := ExecuteVerifyAction(&workflow.Action{
+			Type:     workflow.ActionTypeVerify,
+			Name:     "verify-backup",
+			Manifest: filepath.Join(dir, "invalid.sha256"),
+		})
+		if err == nil {
+			t.Fatal("Expected error for invalid manifest, got nil")
+		}
+	})
+
+	t.Run("Unsupported Algorithm", func(t *testing.T) {
+		_, err := ExecuteVerifyAction(&workflow.Action{
+			Type:      workflow.ActionTypeVerify,
+			Name:      "verify-backup",
+			Manifest:  filepath.Join(dir, "good.sha256"),
+			Algorithm: "crc32",
+		})
+		if err == nil {
+			t.Fatal("Expected error for unsupported algorithm, got nil")
+		}
+	})
+
+	t.Run("Invalid Action Type", func(t *testing.T) {
+		_, err := ExecuteVerifyAction(&workflow.Action{
+			Type:     workflow.ActionTypeBash,
+			Name:     "verify-backup",
+			Manifest: filepath.Join(dir, "good.sha256"),
+		})
+		if err == nil {
+			t.Fatal("Expected error for invalid action type, got nil")
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeWait, waitExecutor{})
+}
+
+// waitExecutor pauses the workflow for a duration or until a time
+type waitExecutor struct{}
+
+func (waitExecutor) Fields() []string {
+	return []string{"duration", "until"}
+}
+
+func (waitExecutor) Validate(act *workflow.Action) error {
+	if act.Duration == "" && act.Until == "" {
+		return fmt.Errorf("must have a 'duration' or 'until'")
+	}
+	if act.Duration != "" && act.Until != "" {
+		return fmt.Errorf("cannot have both 'duration' and 'until'")
+	}
+	if act.Duration != "" && !strings.Contains(act.Duration, "{{") {
+		d, err := time.ParseDuration(act.Duration)
+		if err != nil {
+			return fmt.Errorf("has invalid 'duration' '%s': %w", act.Duration, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("has negative 'duration' '%s'", act.Duration)
+		}
+	}
+	if act.Until != "" && !strings.Contains(act.Until, "{{") {
+		if _, err := time.Parse(time.RFC3339, act.Until); err != nil {
+			return fmt.Errorf("has invalid 'until' '%s': must be an RFC 3339 time such as 2026-03-01T02:00:00Z", act.Until)
+		}
+	}
+	if act.Retry != nil {
+		return fmt.Errorf("cannot have 'retry'")
+	}
+	return nil
+}
+
+func (waitExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	output, err := ExecuteWaitAction(ctx, act, ec.WorkflowName)
+	return Result{Output: output}, err
+}
+
+// ExecuteWaitAction sleeps for the action's duration, or until its time, and
+// returns how long it waited. A time in the past doesn't wait at all.
+// Cancelling ctx, e.g. when a newer run replaces this one, ends the wait
+// with an error.
+func ExecuteWaitAction(ctx context.Context, action *workflow.Action, workflowName ...string) (string, error) {
+	if action.Type != workflow.ActionTypeWait {
+		return "", fmt.Errorf("invalid action type for ExecuteWaitAction: expected %s, got %s", workflow.ActionTypeWait, action.Type)
+	}
+
+	startTime := time.Now()
+	output, err := sleep(ctx, action, startTime)
+
+	// Record metrics if workflow name is provided
+	if len(workflowName) > 0 && workflowName[0] != "" {
+		metrics.RecordActionExecution(workflowName[0], action.Name, string(workflow.ActionTypeWait), Status(err), time.Since(startTime))
+	}
+
+	if err != nil {
+		logger.L().Errorw("Wait Action failed", "action_name", action.Name, "error", err)
+		return output, err
+	}
+
+	logger.L().Infow("Wait Action completed successfully", "action_name", action.Name, "waited", time.Since(startTime).String())
+	return output, nil
+}
+
+// sleep does the work of ExecuteWaitAction
+func sleep(ctx context.Context, action *workflow.Action, now time.Time) (string, error) {
+	var d time.Duration
+	switch {
+	case action.Duration != "" && action.Until != "":
+		return "", fmt.Errorf("wait action '%s' cannot have both 'duration' and 'until'", action.Name)
+	case action.Duration != "":
+		var err error
+		if d, err = time.ParseDuration(action.Duration); err != nil {
+			return "", fmt.Errorf("wait action '%s' has invalid duration '%s': %w", action.Name, action.Duration, err)
+		}
+		if d < 0 {
+			return "", fmt.Errorf("wait action '%s' has negative duration '%s'", action.Name, action.Duration)
+		}
+	case action.Until != "":
+		until, err := time.Parse(time.RFC3339, action.Until)
+		if err != nil {
+			return "", fmt.Errorf("wait action '%s' has invalid time '%s': must be RFC 3339, e.g. 2026-03-01T02:00:00Z", action.Name, action.Until)
+		}
+		if !until.After(now) {
+			return fmt.Sprintf("%s already passed", action.Until), nil
+		}
+		d = until.Sub(now)
+	default:
+		return "", fmt.Errorf("wait action '%s' must have a 'duration' or 'until'", action.Name)
+	}
+
+	logger.L().Infow("Executing Wait Action",
+		"action_name", action.Name,
+		"duration", d.String(),
+		"until", now.Add(d).Format(time.RFC3339))
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return "", fmt.Errorf("wait action '%s' cancelled after %s: %w", action.Name, time.Since(now).Round(time.Second), ctx.Err())
+	}
+
+	if action.Until != "" {
+		return fmt.Sprintf("waited until %s", action.Until), nil
+	}
+	return fmt.Sprintf("waited %s", d), nil
+}
@@ -0,0 +1,72 @@
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestExecuteWaitAction(t *testing.T) {
+	t.Run("Duration", func(t *testing.T) {
+		start := time.Now()
+		output, err := ExecuteWaitAction(context.Background(), &workflow.Action{Type: workflow.ActionTypeWait, Name: "settle", Duration: "50ms"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("Expected to wait at least 50ms, waited %s", elapsed)
+		}
+		if output != "waited 50ms" {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Until", func(t *testing.T) {
+		until := time.Now().Add(1100 * time.Millisecond).UTC().Truncate(time.Second).Add(time.Second)
+		output, err := ExecuteWaitAction(context.Background(), &workflow.Action{Type: workflow.ActionTypeWait, Name: "window", Until: until.Format(time.RFC3339)})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if time.Now().Before(until) {
+			t.Errorf("Expected to wait until %s", until)
+		}
+		if !strings.HasPrefix(output, "waited until ") {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Until Passed", func(t *testing.T) {
+		output, err := ExecuteWaitAction(context.Background(), &workflow.Action{Type: workflow.ActionTypeWait, Name: "window", Until: "2020-01-01T00:00:00Z"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if output != "2020-01-01T00:00:00Z already passed" {
+			t.Errorf("Unexpected output: %s", output)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := ExecuteWaitAction(ctx, &workflow.Action{Type: workflow.ActionTypeWait, Name: "settle", Duration: "1h"})
+		if err == nil {
+			t.Fatal("Expected an error when the run is cancelled")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, act := range []workflow.Action{
+			{Type: workflow.ActionTypeWait, Name: "settle"},
+			{Type: workflow.ActionTypeWait, Name: "settle", Duration: "soon"},
+			{Type: workflow.ActionTypeWait, Name: "settle", Duration: "-1s"},
+			{Type: workflow.ActionTypeWait, Name: "settle", Until: "tomorrow"},
+		} {
+			if _, err := ExecuteWaitAction(context.Background(), &act); err == nil {
+				t.Errorf("Expected an error for %+v", act)
+			}
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.ActionTypeWorkflow, workflowExecutor{})
+}
+
+// workflowExecutor runs another workflow, the workflow executor's
+// sub-workflow runner doing the work
+type workflowExecutor struct{}
+
+func (workflowExecutor) Fields() []string {
+	return []string{"workflow", "workflowFile", "payload", "wait"}
+}
+
+func (workflowExecutor) Validate(act *workflow.Action) error {
+	if act.Workflow == "" && act.WorkflowFile == "" {
+		return fmt.Errorf("must have a 'workflow' or 'workflowFile' to run")
+	}
+	if act.Workflow != "" && act.WorkflowFile != "" {
+		return fmt.Errorf("cannot have both 'workflow' and 'workflowFile'")
+	}
+	if err := validateTimeout(act); err != nil {
+		return err
+	}
+	if act.Timeout != "" && !act.WaitsForWorkflow() {
+		return fmt.Errorf("cannot have a 'timeout' without waiting for the workflow")
+	}
+	if act.Retry != nil {
+		return fmt.Errorf("cannot have 'retry'")
+	}
+	return nil
+}
+
+func (workflowExecutor) Execute(ctx context.Context, act *workflow.Action, ec ExecContext) (Result, error) {
+	if ec.RunWorkflow == nil {
+		return Result{}, fmt.Errorf("workflow action %s cannot run outside a workflow", act.Name)
+	}
+	output, err := ec.RunWorkflow(ctx, act)
+	return Result{Output: output}, err
+}
@@ -0,0 +1,140 @@
+// Package approval tracks the approval actions waiting for a decision, so
+// the runs they belong to can be approved or rejected through the agent's
+// dashboard and API.
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+// ErrNotFound is returned when deciding an approval that isn't pending,
+// because it doesn't exist, was already decided or timed out
+var ErrNotFound = errors.New("approval not found")
+
+// ErrUnavailable is returned by Wait when nothing can decide a request, e.g.
+// when a workflow runs with `autozap trigger` rather than in the agent
+var ErrUnavailable = errors.New("approvals can only be decided through the agent's HTTP server")
+
+// Request is an approval action waiting for a decision
+type Request struct {
+	ID          string    `json:"id"`
+	Workflow    string    `json:"workflow"`
+	Action      string    `json:"action"`
+	Message     string    `json:"message,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Decision approves or rejects a pending request
+type Decision struct {
+	Approved bool   `json:"approved"`
+	By       string `json:"by,omitempty"`      // who decided, e.g. a user name
+	Comment  string `json:"comment,omitempty"` // why, shown in the action's output or error
+}
+
+type pendingRequest struct {
+	Request
+	decided chan Decision
+}
+
+var (
+	mu      sync.Mutex
+	pending = make(map[string]*pendingRequest)
+
+	available atomic.Bool
+)
+
+// Enable lets Wait accept requests. The agent's HTTP server calls it when it
+// starts, as it serves the API that decides them.
+func Enable() {
+	available.Store(true)
+}
+
+// Wait registers req as pending and blocks until it is decided, timeout
+// expires or ctx is cancelled. The request gets a new ID and its times are
+// set. On timeout the error wraps context.DeadlineExceeded; without Enable it
+// fails immediately with ErrUnavailable.
+func Wait(ctx context.Context, req Request, timeout time.Duration) (Request, Decision, error) {
+	if !available.Load() {
+		return req, Decision{}, ErrUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req.ID = newID()
+	req.RequestedAt = time.Now()
+	req.ExpiresAt = req.RequestedAt.Add(timeout)
+	p := &pendingRequest{Request: req, decided: make(chan Decision, 1)}
+
+	mu.Lock()
+	pending[req.ID] = p
+	mu.Unlock()
+
+	logger.L().Infow("Waiting for approval",
+		"approval_id", req.ID,
+		"workflow_name", req.Workflow,
+		"action_name", req.Action,
+		"expires_at", req.ExpiresAt.Format(time.RFC3339))
+
+	select {
+	case d := <-p.decided:
+		return req, d, nil
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := pending[req.ID]; !ok {
+		// Decided just as the wait ended; the decision is already sent
+		return req, <-p.decided, nil
+	}
+	delete(pending, req.ID)
+	return req, Decision{}, ctx.Err()
+}
+
+// Decide approves or rejects the pending request with the given ID and
+// returns it
+func Decide(id string, d Decision) (Request, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	p, ok := pending[id]
+	if !ok {
+		return Request{}, ErrNotFound
+	}
+	delete(pending, id)
+	p.decided <- d
+	return p.Request, nil
+}
+
+// Pending returns the requests waiting for a decision, oldest first
+func Pending() []Request {
+	mu.Lock()
+	defer mu.Unlock()
+
+	requests := make([]Request, 0, len(pending))
+	for _, p := range pending {
+		requests = append(requests, p.Request)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].RequestedAt.Before(requests[j].RequestedAt)
+	})
+	return requests
+}
+
+// newID returns a random ID that can't be guessed from other requests
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+// waitFor returns the pending request of the given action once it is registered
+func waitFor(t *testing.T, action string) Request {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, req := range Pending() {
+			if req.Action == action {
+				return req
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Approval for %s was never requested", action)
+	return Request{}
+}
+
+func TestWait(t *testing.T) {
+	if _, _, err := Wait(context.Background(), Request{Workflow: "deploy", Action: "approve"}, time.Minute); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable before Enable, got: %v", err)
+	}
+	Enable()
+
+	type result struct {
+		req      Request
+		decision Decision
+		err      error
+	}
+	wait := func(ctx context.Context, action string, timeout time.Duration) <-chan result {
+		done := make(chan result, 1)
+		go func() {
+			req, d, err := Wait(ctx, Request{Workflow: "deploy", Action: action, Message: "Deploy to production?"}, timeout)
+			done <- result{req, d, err}
+		}()
+		return done
+	}
+
+	t.Run("Approved", func(t *testing.T) {
+		done := wait(context.Background(), "approve", time.Minute)
+		req := waitFor(t, "approve")
+		if req.Message != "Deploy to production?" || req.ExpiresAt.Sub(req.RequestedAt) != time.Minute {
+			t.Errorf("Unexpected pending request %+v", req)
+		}
+
+		decided, err := Decide(req.ID, Decision{Approved: true, By: "alice", Comment: "ship it"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if decided.ID != req.ID {
+			t.Errorf("Expected request %s, got %s", req.ID, decided.ID)
+		}
+
+		r := <-done
+		if r.err != nil || !r.decision.Approved || r.decision.By != "alice" || r.decision.Comment != "ship it" {
+			t.Errorf("Unexpected result %+v", r)
+		}
+		if _, err := Decide(req.ID, Decision{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound deciding twice, got: %v", err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		done := wait(context.Background(), "reject", time.Minute)
+		req := waitFor(t, "reject")
+		if _, err := Decide(req.ID, Decision{By: "bob"}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if r := <-done; r.err != nil || r.decision.Approved {
+			t.Errorf("Expected a rejection, got %+v", r)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		r := <-wait(context.Background(), "timeout", 20*time.Millisecond)
+		if !errors.Is(r.err, context.DeadlineExceeded) {
+			t.Errorf("Expected a deadline error, got: %v", r.err)
+		}
+		if _, err := Decide(r.req.ID, Decision{Approved: true}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound after the timeout, got: %v", err)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := wait(ctx, "cancel", time.Minute)
+		waitFor(t, "cancel")
+		cancel()
+		if r := <-done; !errors.Is(r.err, context.Canceled) {
+			t.Errorf("Expected a cancellation error, got: %v", r.err)
+		}
+		if len(Pending()) != 0 {
+			t.Errorf("Expected no pending requests, got %v", Pending())
+		}
+	})
+}
@@ -0,0 +1,574 @@
+// Package config loads the agent configuration file, which holds settings
+// shared by all workflows of an agent.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// Service health check types
+const (
+	ServiceCheckHTTP    = "http"    // GET a URL and expect a 2xx/3xx or ExpectStatus
+	ServiceCheckTCP     = "tcp"     // connect to Address
+	ServiceCheckCommand = "command" // run a bash command and expect exit code 0
+)
+
+// Defaults for service health checks
+const (
+	DefaultCheckInterval = 30 * time.Second
+	DefaultCheckTimeout  = 5 * time.Second
+)
+
+// DefaultMaxRemediationsPerHour limits how often a remediation rule fires
+const DefaultMaxRemediationsPerHour = 3
+
+// AgentConfig is the agent configuration file
+type AgentConfig struct {
+	// Services are health-checked dependencies that workflows can declare with
+	// dependsOnServices; runs are blocked while a dependency is unhealthy
+	Services []ServiceConfig `yaml:"services,omitempty"`
+
+	// Remediations run a workflow automatically when another one fails
+	Remediations []RemediationConfig `yaml:"remediations,omitempty"`
+
+	// MQTT is the broker used by mqtt triggers and actions
+	MQTT *MQTTConfig `yaml:"mqtt,omitempty"`
+
+	// Output bounds the memory used to capture the output of bash actions
+	Output OutputConfig `yaml:"output,omitempty"`
+
+	// HTTPClient tunes the connection pool shared by HTTP actions, downloads
+	// and service health checks
+	HTTPClient HTTPClientConfig `yaml:"httpClient,omitempty"`
+
+	// TemplatesDir holds *.tmpl files whose named templates every action
+	// can execute, e.g. {{ template "slack-failure" . }} for alert messages
+	TemplatesDir string `yaml:"templatesDir,omitempty"`
+
+	// Timezone is the zone the agent shows timestamps in, e.g. UTC or
+	// Europe/Berlin; the --timezone flag and AUTOZAP_TIMEZONE take
+	// precedence. Default local time.
+	Timezone string `yaml:"timezone,omitempty"`
+
+	// Database, HTTPPort, Log, Watch and Retention are defaults for the --db,
+	// --db-driver, --http-port, --log-dir, --watch and --retention flags; the
+	// flags and their AUTOZAP_* environment variables take precedence
+	Database  DatabaseConfig `yaml:"database,omitempty"`
+	HTTPPort  int            `yaml:"httpPort,omitempty"`
+	Log       LogConfig      `yaml:"log,omitempty"`
+	Watch     *bool          `yaml:"watch,omitempty"`     // hot-reload workflow files, default true
+	Retention string         `yaml:"retention,omitempty"` // e.g. 30d, default keep forever
+
+	// Secrets is where {{ secret "name" }} reads secrets from
+	Secrets SecretsConfig `yaml:"secrets,omitempty"`
+
+	// Retry is the retry policy of actions without a retry of their own
+	Retry *workflow.RetryConfig `yaml:"retry,omitempty"`
+
+	// Notifications are webhooks told about failed runs of any workflow
+	Notifications []NotificationConfig `yaml:"notifications,omitempty"`
+
+	// APIToken is the bearer token required by the API endpoints that change
+	// the agent, e.g. triggering a workflow; the default of --api-token.
+	// Without one they only accept requests from the agent's host.
+	APIToken string `yaml:"apiToken,omitempty"`
+}
+
+// DefaultPath is the agent configuration file loaded when --config isn't given
+const DefaultPath = "/etc/autozap/config.yaml"
+
+// DatabaseConfig selects the database holding execution history
+type DatabaseConfig struct {
+	Driver string `yaml:"driver,omitempty"` // sqlite, postgres or mysql
+	Path   string `yaml:"path,omitempty"`   // file path (sqlite) or DSN
+}
+
+// Log formats
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console"
+)
+
+// LogConfig sets how the agent logs
+type LogConfig struct {
+	Level  string `yaml:"level,omitempty"`  // debug, info, warn or error; default info
+	Format string `yaml:"format,omitempty"` // json or console; default json
+	Dir    string `yaml:"dir,omitempty"`    // per-workflow log files; default stdout
+}
+
+// SecretsConfig is where secrets are read from: a directory with a file per
+// secret, as Docker and Kubernetes mount them. AUTOZAP_SECRETS_DIR takes
+// precedence.
+type SecretsConfig struct {
+	Dir string `yaml:"dir,omitempty"` // default /run/secrets
+}
+
+// DefaultOutputMemoryLimit is how much of each of a bash action's stdout and
+// stderr is kept in memory before the rest is spilled to disk
+const DefaultOutputMemoryLimit = 1 << 20
+
+// OutputConfig bounds the memory used to capture a bash action's stdout and
+// stderr. Beyond MemoryLimit the whole stream is written to a file in
+// SpillDir and only its head and tail are kept in memory and the database.
+type OutputConfig struct {
+	MemoryLimit string `yaml:"memoryLimit,omitempty"` // per stream, e.g. "512KB" or "4MB", default 1MB
+	SpillDir    string `yaml:"spillDir,omitempty"`    // default autozap-output in the temporary directory
+}
+
+// MemoryLimitBytes returns the memory limit of a captured output stream
+func (o OutputConfig) MemoryLimitBytes() int64 {
+	if o.MemoryLimit == "" {
+		return DefaultOutputMemoryLimit
+	}
+	n, err := ParseSize(o.MemoryLimit)
+	if err != nil || n <= 0 {
+		return DefaultOutputMemoryLimit
+	}
+	return n
+}
+
+// Defaults for the shared HTTP client
+const (
+	DefaultHTTPMaxIdleConns        = 100
+	DefaultHTTPMaxIdleConnsPerHost = 10
+	DefaultHTTPIdleConnTimeout     = 90 * time.Second
+	DefaultHTTPTimeout             = 5 * time.Minute
+)
+
+// HTTPClientConfig tunes the HTTP client shared by HTTP actions, downloads
+// and service health checks. Actions with their own TLS settings, proxy,
+// DNS overrides or Unix socket get a client of their own that copies these
+// settings.
+type HTTPClientConfig struct {
+	MaxIdleConns        int    `yaml:"maxIdleConns,omitempty"`        // across all hosts, default 100
+	MaxIdleConnsPerHost int    `yaml:"maxIdleConnsPerHost,omitempty"` // default 10
+	MaxConnsPerHost     int    `yaml:"maxConnsPerHost,omitempty"`     // including active ones, default unlimited
+	IdleConnTimeout     string `yaml:"idleConnTimeout,omitempty"`     // default 90s
+	DisableKeepAlives   bool   `yaml:"disableKeepAlives,omitempty"`   // open a connection per request
+	Proxy               string `yaml:"proxy,omitempty"`               // default from HTTP_PROXY and HTTPS_PROXY
+	Timeout             string `yaml:"timeout,omitempty"`             // for HTTP actions without a timeout, default 5m
+
+	// NoProxy lists the hosts reached without the proxy: host names, domains
+	// with a leading dot that also match their subdomains, IP addresses and
+	// CIDR ranges, optionally with a :port. Default from NO_PROXY.
+	NoProxy []string `yaml:"noProxy,omitempty"`
+}
+
+// MaxIdleConnsOrDefault returns how many idle connections are kept across all hosts
+func (h HTTPClientConfig) MaxIdleConnsOrDefault() int {
+	if h.MaxIdleConns <= 0 {
+		return DefaultHTTPMaxIdleConns
+	}
+	return h.MaxIdleConns
+}
+
+// MaxIdleConnsPerHostOrDefault returns how many idle connections are kept per host
+func (h HTTPClientConfig) MaxIdleConnsPerHostOrDefault() int {
+	if h.MaxIdleConnsPerHost <= 0 {
+		return DefaultHTTPMaxIdleConnsPerHost
+	}
+	return h.MaxIdleConnsPerHost
+}
+
+// IdleConnTimeoutDuration returns how long an idle connection is kept
+func (h HTTPClientConfig) IdleConnTimeoutDuration() time.Duration {
+	return parseDurationOr(h.IdleConnTimeout, DefaultHTTPIdleConnTimeout)
+}
+
+// TimeoutDuration returns how long an HTTP action without a timeout may take
+func (h HTTPClientConfig) TimeoutDuration() time.Duration {
+	return parseDurationOr(h.Timeout, DefaultHTTPTimeout)
+}
+
+// sizeUnits are the suffixes accepted by ParseSize, in multiples of 1024
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes such as "1048576", "512KB" or "4MB".
+// Units are multiples of 1024 and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(number), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s': expected e.g. 512KB or 4MB", s)
+	}
+	return n * multiplier, nil
+}
+
+// ParseRetention parses a retention period. In addition to Go durations
+// ("72h", "90m") it accepts whole days ("30d"). An empty string means no retention.
+func ParseRetention(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid retention '%s': expected a number of days like '30d'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention '%s': %w", s, err)
+	}
+	return d, nil
+}
+
+// MQTTConfig is the connection to an MQTT broker, shared by all workflows
+type MQTTConfig struct {
+	Broker   string `yaml:"broker"`             // tcp://host:1883, ssl://host:8883 or ws://host:80/mqtt
+	ClientID string `yaml:"clientId,omitempty"` // default autozap-<hostname>
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// RemediationConfig binds failures to a remediation workflow. A failed run
+// matches when its workflow is listed in Workflows (any workflow if empty),
+// its status is one of Statuses and its error matches ErrorPattern (any error
+// if empty).
+type RemediationConfig struct {
+	Name         string   `yaml:"name"`
+	Workflows    []string `yaml:"workflows,omitempty"`    // names of the failing workflows
+	ErrorPattern string   `yaml:"errorPattern,omitempty"` // regular expression
+	Run          string   `yaml:"run"`                    // name of the remediation workflow
+	MaxPerHour   int      `yaml:"maxPerHour,omitempty"`   // default 3
+
+	// Statuses are the run statuses the rule fires on: failed and timeout by
+	// default, or partial-success for runs that only degraded
+	Statuses []string `yaml:"statuses,omitempty"`
+}
+
+// MatchStatuses returns the run statuses the rule fires on
+func (r RemediationConfig) MatchStatuses() []string {
+	if len(r.Statuses) == 0 {
+		return workflow.FailureStatuses
+	}
+	return r.Statuses
+}
+
+// MaxRemediationsPerHour returns how often the rule may fire per hour
+func (r RemediationConfig) MaxRemediationsPerHour() int {
+	if r.MaxPerHour <= 0 {
+		return DefaultMaxRemediationsPerHour
+	}
+	return r.MaxPerHour
+}
+
+// NotificationConfig is a webhook that failed runs are POSTed to. A run
+// matches when its workflow is listed in Workflows (any workflow if empty) and
+// its status is one of Statuses.
+type NotificationConfig struct {
+	Name      string            `yaml:"name"`
+	URL       string            `yaml:"url"`
+	Workflows []string          `yaml:"workflows,omitempty"` // names of the failing workflows
+	Headers   map[string]string `yaml:"headers,omitempty"`   // templates, e.g. with a {{ secret }}
+
+	// Body is a template rendered with the run as {{ .workflow.name }},
+	// {{ .status }}, {{ .error }}, {{ .executionId }} and {{ .triggerType }};
+	// default a JSON object with these fields
+	Body string `yaml:"body,omitempty"`
+
+	// Statuses are the run statuses that are notified: failed and timeout by
+	// default, or partial-success for runs that only degraded
+	Statuses []string `yaml:"statuses,omitempty"`
+}
+
+// MatchStatuses returns the run statuses that are notified
+func (n NotificationConfig) MatchStatuses() []string {
+	if len(n.Statuses) == 0 {
+		return workflow.FailureStatuses
+	}
+	return n.Statuses
+}
+
+// ServiceConfig defines the health check of a service
+type ServiceConfig struct {
+	Name         string `yaml:"name"`
+	Type         string `yaml:"type"`
+	URL          string `yaml:"url,omitempty"`          // http
+	ExpectStatus int    `yaml:"expectStatus,omitempty"` // http, default any 2xx or 3xx
+	Address      string `yaml:"address,omitempty"`      // tcp, host:port
+	Command      string `yaml:"command,omitempty"`      // command
+	Interval     string `yaml:"interval,omitempty"`     // default 30s
+	Timeout      string `yaml:"timeout,omitempty"`      // default 5s
+}
+
+// IntervalDuration returns how often the service is checked
+func (s ServiceConfig) IntervalDuration() time.Duration {
+	return parseDurationOr(s.Interval, DefaultCheckInterval)
+}
+
+// TimeoutDuration returns how long a single check may take
+func (s ServiceConfig) TimeoutDuration() time.Duration {
+	return parseDurationOr(s.Timeout, DefaultCheckTimeout)
+}
+
+// parseDurationOr parses s, which has been validated, or returns def if it is empty
+func parseDurationOr(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+// validate checks the shared HTTP client settings
+func (h HTTPClientConfig) validate() error {
+	for field, value := range map[string]int{"maxIdleConns": h.MaxIdleConns, "maxIdleConnsPerHost": h.MaxIdleConnsPerHost, "maxConnsPerHost": h.MaxConnsPerHost} {
+		if value < 0 {
+			return fmt.Errorf("has negative '%s'", field)
+		}
+	}
+	for field, value := range map[string]string{"idleConnTimeout": h.IdleConnTimeout, "timeout": h.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("has invalid '%s' '%s'", field, value)
+		}
+	}
+	if h.Proxy != "" {
+		u, err := url.Parse(h.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("has invalid 'proxy' '%s': expected e.g. http://proxy.internal:3128", h.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("'proxy' has unsupported scheme '%s'. Must be one of: http, https, socks5, socks5h", u.Scheme)
+		}
+	}
+	for _, host := range h.NoProxy {
+		if host == "" || (strings.ContainsAny(host, ", /") && !isCIDR(host)) {
+			return fmt.Errorf("has invalid 'noProxy' entry '%s'", host)
+		}
+	}
+	return nil
+}
+
+// isCIDR reports whether s is a CIDR range such as 10.0.0.0/8
+func isCIDR(s string) bool {
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
+// Load reads and validates an agent configuration file. Unknown fields are
+// rejected so typos don't silently disable a setting.
+func Load(path string) (*AgentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	cfg := &AgentConfig{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration for missing or conflicting settings
+func (c *AgentConfig) Validate() error {
+	names := make(map[string]bool, len(c.Services))
+	for i, s := range c.Services {
+		if s.Name == "" {
+			return fmt.Errorf("service at index %d must have a 'name'", i)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("duplicate service name '%s'", s.Name)
+		}
+		names[s.Name] = true
+
+		switch s.Type {
+		case ServiceCheckHTTP:
+			if s.URL == "" {
+				return fmt.Errorf("http service '%s' requires a 'url'", s.Name)
+			}
+		case ServiceCheckTCP:
+			if s.Address == "" {
+				return fmt.Errorf("tcp service '%s' requires an 'address'", s.Name)
+			}
+		case ServiceCheckCommand:
+			if s.Command == "" {
+				return fmt.Errorf("command service '%s' requires a 'command'", s.Name)
+			}
+		default:
+			return fmt.Errorf("service '%s' has invalid type '%s'. Must be one of: %s, %s, %s",
+				s.Name, s.Type, ServiceCheckHTTP, ServiceCheckTCP, ServiceCheckCommand)
+		}
+
+		for field, value := range map[string]string{"interval": s.Interval, "timeout": s.Timeout} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+				return fmt.Errorf("service '%s' has invalid '%s' '%s'", s.Name, field, value)
+			}
+		}
+	}
+
+	rules := make(map[string]bool, len(c.Remediations))
+	for i, r := range c.Remediations {
+		if r.Name == "" {
+			return fmt.Errorf("remediation at index %d must have a 'name'", i)
+		}
+		if rules[r.Name] {
+			return fmt.Errorf("duplicate remediation name '%s'", r.Name)
+		}
+		rules[r.Name] = true
+
+		if r.Run == "" {
+			return fmt.Errorf("remediation '%s' requires a workflow to 'run'", r.Name)
+		}
+		if len(r.Workflows) == 0 && r.ErrorPattern == "" {
+			return fmt.Errorf("remediation '%s' requires 'workflows' or an 'errorPattern'", r.Name)
+		}
+		for _, name := range r.Workflows {
+			if name == r.Run {
+				return fmt.Errorf("remediation '%s' cannot run the workflow it remediates ('%s')", r.Name, name)
+			}
+		}
+		if r.ErrorPattern != "" {
+			if _, err := regexp.Compile(r.ErrorPattern); err != nil {
+				return fmt.Errorf("remediation '%s' has invalid 'errorPattern': %w", r.Name, err)
+			}
+		}
+		if r.MaxPerHour < 0 {
+			return fmt.Errorf("remediation '%s' has negative 'maxPerHour'", r.Name)
+		}
+		for _, status := range r.Statuses {
+			if !workflow.IsFailure(status) && status != workflow.StatusPartialSuccess {
+				return fmt.Errorf("remediation '%s' has invalid status '%s'. Must be one of: %s, %s, %s",
+					r.Name, status, workflow.StatusFailed, workflow.StatusTimeout, workflow.StatusPartialSuccess)
+			}
+		}
+	}
+
+	if c.Output.MemoryLimit != "" {
+		n, err := ParseSize(c.Output.MemoryLimit)
+		if err != nil {
+			return fmt.Errorf("output has invalid 'memoryLimit': %w", err)
+		}
+		if n < 1<<10 {
+			return fmt.Errorf("output 'memoryLimit' must be at least 1KB, got '%s'", c.Output.MemoryLimit)
+		}
+	}
+
+	if err := c.HTTPClient.validate(); err != nil {
+		return fmt.Errorf("httpClient %w", err)
+	}
+
+	if _, err := timezone.Load(c.Timezone); err != nil {
+		return fmt.Errorf("invalid 'timezone': %w", err)
+	}
+
+	if _, err := ParseRetention(c.Retention); err != nil {
+		return err
+	}
+
+	if c.HTTPPort < 0 || c.HTTPPort > 65535 {
+		return fmt.Errorf("invalid 'httpPort' %d", c.HTTPPort)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("log has invalid 'level' '%s'. Must be one of: debug, info, warn, error", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "", LogFormatJSON, LogFormatConsole:
+	default:
+		return fmt.Errorf("log has invalid 'format' '%s'. Must be one of: %s, %s", c.Log.Format, LogFormatJSON, LogFormatConsole)
+	}
+
+	if c.Retry != nil {
+		if c.Retry.MaxAttempts < 0 {
+			return fmt.Errorf("retry has negative 'maxAttempts'")
+		}
+		for field, value := range map[string]string{"initialDelay": c.Retry.InitialDelay, "maxDelay": c.Retry.MaxDelay} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return fmt.Errorf("retry has invalid '%s' '%s'", field, value)
+			}
+		}
+	}
+
+	notifications := make(map[string]bool, len(c.Notifications))
+	for i, n := range c.Notifications {
+		if n.Name == "" {
+			return fmt.Errorf("notification at index %d must have a 'name'", i)
+		}
+		if notifications[n.Name] {
+			return fmt.Errorf("duplicate notification name '%s'", n.Name)
+		}
+		notifications[n.Name] = true
+
+		if u, err := url.Parse(n.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notification '%s' requires an http or https 'url'", n.Name)
+		}
+		for _, status := range n.Statuses {
+			if !workflow.IsFailure(status) && status != workflow.StatusPartialSuccess {
+				return fmt.Errorf("notification '%s' has invalid status '%s'. Must be one of: %s, %s, %s",
+					n.Name, status, workflow.StatusFailed, workflow.StatusTimeout, workflow.StatusPartialSuccess)
+			}
+		}
+	}
+
+	if c.MQTT != nil {
+		if c.MQTT.Broker == "" {
+			return fmt.Errorf("mqtt requires a 'broker'")
+		}
+		u, err := url.Parse(c.MQTT.Broker)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("mqtt has invalid 'broker' '%s': expected e.g. tcp://host:1883", c.MQTT.Broker)
+		}
+		switch u.Scheme {
+		case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+		default:
+			return fmt.Errorf("mqtt 'broker' has unsupported scheme '%s'. Must be one of: tcp, mqtt, ssl, tls, mqtts, ws, wss", u.Scheme)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Valid Services", func(t *testing.T) {
+		path := writeConfig(t, `
+services:
+  - name: api
+    type: http
+    url: http://localhost:8080/health
+    interval: 10s
+  - name: postgres
+    type: tcp
+    address: localhost:5432
+  - name: queue
+    type: command
+    command: test -S /var/run/queue.sock
+    timeout: 2s
+`)
+
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(cfg.Services) != 3 {
+			t.Fatalf("Expected 3 services, got %d", len(cfg.Services))
+		}
+		if got := cfg.Services[0].IntervalDuration(); got != 10*time.Second {
+			t.Errorf("Expected interval 10s, got %v", got)
+		}
+		if got := cfg.Services[1].IntervalDuration(); got != DefaultCheckInterval {
+			t.Errorf("Expected default interval, got %v", got)
+		}
+		if got := cfg.Services[2].TimeoutDuration(); got != 2*time.Second {
+			t.Errorf("Expected timeout 2s, got %v", got)
+		}
+	})
+
+	t.Run("Empty File", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, ""))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(cfg.Services) != 0 {
+			t.Errorf("Expected no services, got %d", len(cfg.Services))
+		}
+	})
+
+	t.Run("Unknown Field", func(t *testing.T) {
+		_, err := Load(writeConfig(t, "services:\n  - name: api\n    type: http\n    uri: http://localhost\n"))
+		if err == nil {
+			t.Fatal("Expected error for unknown field, got nil")
+		}
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		if err == nil {
+			t.Fatal("Expected error for missing file, got nil")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		service ServiceConfig
+	}{
+		{"Missing Name", ServiceConfig{Type: ServiceCheckTCP, Address: "localhost:5432"}},
+		{"Invalid Type", ServiceConfig{Name: "db", Type: "ping"}},
+		{"HTTP Without URL", ServiceConfig{Name: "api", Type: ServiceCheckHTTP}},
+		{"TCP Without Address", ServiceConfig{Name: "db", Type: ServiceCheckTCP}},
+		{"Command Without Command", ServiceConfig{Name: "queue", Type: ServiceCheckCommand}},
+		{"Invalid Interval", ServiceConfig{Name: "db", Type: ServiceCheckTCP, Address: "localhost:5432", Interval: "often"}},
+		{"Zero Timeout", ServiceConfig{Name: "db", Type: ServiceCheckTCP, Address: "localhost:5432", Timeout: "0s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AgentConfig{Services: []ServiceConfig{tt.service}}
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+		})
+	}
+
+	remediations := []struct {
+		name string
+		rule RemediationConfig
+	}{
+		{"Remediation Missing Name", RemediationConfig{Workflows: []string{"api"}, Run: "restart"}},
+		{"Remediation Missing Run", RemediationConfig{Name: "r", Workflows: []string{"api"}}},
+		{"Remediation Without Match", RemediationConfig{Name: "r", Run: "restart"}},
+		{"Remediation Of Itself", RemediationConfig{Name: "r", Workflows: []string{"restart"}, Run: "restart"}},
+		{"Remediation Invalid Pattern", RemediationConfig{Name: "r", ErrorPattern: "(", Run: "restart"}},
+		{"Remediation Invalid Status", RemediationConfig{Name: "r", Workflows: []string{"api"}, Run: "restart", Statuses: []string{"success"}}},
+	}
+
+	for _, tt := range remediations {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AgentConfig{Remediations: []RemediationConfig{tt.rule}}
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+		})
+	}
+
+	brokers := []struct {
+		name string
+		mqtt MQTTConfig
+	}{
+		{"MQTT Missing Broker", MQTTConfig{ClientID: "autozap"}},
+		{"MQTT Broker Without Scheme", MQTTConfig{Broker: "localhost:1883"}},
+		{"MQTT Unsupported Scheme", MQTTConfig{Broker: "http://localhost:1883"}},
+	}
+
+	for _, tt := range brokers {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AgentConfig{MQTT: &tt.mqtt}
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+		})
+	}
+
+	t.Run("Valid MQTT Broker", func(t *testing.T) {
+		cfg := &AgentConfig{MQTT: &MQTTConfig{Broker: "tcp://mosquitto.local:1883"}}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Output Memory Limit", func(t *testing.T) {
+		for _, limit := range []string{"lots", "-1MB", "512"} {
+			cfg := &AgentConfig{Output: OutputConfig{MemoryLimit: limit}}
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("%s: expected validation error, got nil", limit)
+			}
+		}
+		cfg := &AgentConfig{Output: OutputConfig{MemoryLimit: "4MB"}}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := cfg.Output.MemoryLimitBytes(); got != 4<<20 {
+			t.Errorf("Expected 4MB, got %d bytes", got)
+		}
+		if got := (OutputConfig{}).MemoryLimitBytes(); got != DefaultOutputMemoryLimit {
+			t.Errorf("Expected the default limit, got %d bytes", got)
+		}
+	})
+
+	t.Run("HTTP Client", func(t *testing.T) {
+		invalid := []HTTPClientConfig{
+			{MaxIdleConns: -1},
+			{IdleConnTimeout: "soon"},
+			{Timeout: "0s"},
+			{Proxy: "proxy.internal:3128"},
+			{Proxy: "ftp://proxy.internal"},
+			{NoProxy: []string{"a.internal,b.internal"}},
+			{NoProxy: []string{""}},
+		}
+		for _, h := range invalid {
+			cfg := &AgentConfig{HTTPClient: h}
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("%+v: expected validation error, got nil", h)
+			}
+		}
+		cfg := &AgentConfig{HTTPClient: HTTPClientConfig{
+			MaxIdleConnsPerHost: 32,
+			Timeout:             "30s",
+			Proxy:               "socks5h://proxy.internal:1080",
+			NoProxy:             []string{"localhost", ".corp.example.com", "10.0.0.0/8", "registry.internal:5000"},
+		}}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := cfg.HTTPClient.TimeoutDuration(); got != 30*time.Second {
+			t.Errorf("Expected 30s timeout, got %s", got)
+		}
+		if got := cfg.HTTPClient.MaxIdleConnsPerHostOrDefault(); got != 32 {
+			t.Errorf("Expected 32 idle connections per host, got %d", got)
+		}
+		if got := (HTTPClientConfig{}).MaxIdleConnsOrDefault(); got != DefaultHTTPMaxIdleConns {
+			t.Errorf("Expected the default idle connections, got %d", got)
+		}
+	})
+
+	t.Run("Timezone", func(t *testing.T) {
+		for _, tz := range []string{"", "Local", "utc", "Europe/Berlin"} {
+			cfg := &AgentConfig{Timezone: tz}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("%s: expected no error, got: %v", tz, err)
+			}
+		}
+		cfg := &AgentConfig{Timezone: "Mars/Olympus_Mons"}
+		if err := cfg.Validate(); err == nil {
+			t.Fatal("Expected error for unknown time zone, got nil")
+		}
+	})
+
+	t.Run("Agent Defaults", func(t *testing.T) {
+		invalid := []AgentConfig{
+			{HTTPPort: 70000},
+			{Log: LogConfig{Level: "loud"}},
+			{Log: LogConfig{Format: "xml"}},
+			{Retry: &workflow.RetryConfig{MaxAttempts: -1}},
+			{Retry: &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "soon"}},
+		}
+		for _, cfg := range invalid {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("%+v: expected validation error, got nil", cfg)
+			}
+		}
+		cfg := &AgentConfig{
+			Database: DatabaseConfig{Driver: "postgres", Path: "postgres://autozap@db/autozap"},
+			HTTPPort: 9090,
+			Log:      LogConfig{Level: "WARN", Format: LogFormatConsole, Dir: "/var/log/autozap"},
+			Secrets:  SecretsConfig{Dir: "/etc/autozap/secrets"},
+			Retry:    &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "2s"},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	notifications := []struct {
+		name         string
+		notification NotificationConfig
+	}{
+		{"Notification Missing Name", NotificationConfig{URL: "https://hooks.example.com"}},
+		{"Notification Missing URL", NotificationConfig{Name: "ops"}},
+		{"Notification Invalid URL", NotificationConfig{Name: "ops", URL: "hooks.example.com"}},
+		{"Notification Invalid Status", NotificationConfig{Name: "ops", URL: "https://hooks.example.com", Statuses: []string{"success"}}},
+	}
+
+	for _, tt := range notifications {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AgentConfig{Notifications: []NotificationConfig{tt.notification}}
+			if err := cfg.Validate(); err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+		})
+	}
+
+	t.Run("Duplicate Names", func(t *testing.T) {
+		service := ServiceConfig{Name: "db", Type: ServiceCheckTCP, Address: "localhost:5432"}
+		cfg := &AgentConfig{Services: []ServiceConfig{service, service}}
+		if err := cfg.Validate(); err == nil {
+			t.Fatal("Expected error for duplicate service names, got nil")
+		}
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1048576": 1048576,
+		"100B":    100,
+		"512KB":   512 << 10,
+		"512kb":   512 << 10,
+		"4MB":     4 << 20,
+		"4 MiB":   4 << 20,
+		"2G":      2 << 30,
+	}
+	for in, want := range tests {
+		got, err := ParseSize(in)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %d, got %d", in, want, got)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "1.5MB", "4TB", "-1"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("%q: expected error, got nil", in)
+		}
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    0,
+		"0":   0,
+		"30d": 30 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"72h": 72 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for in, want := range tests {
+		got, err := ParseRetention(in)
+		if err != nil {
+			t.Fatalf("%q: expected no error, got: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("%q: expected %s, got %s", in, want, got)
+		}
+	}
+
+	for _, in := range []string{"d", "-1d", "1.5d", "forever", "garbage", "30 days"} {
+		if _, err := ParseRetention(in); err == nil {
+			t.Errorf("%q: expected error, got nil", in)
+		}
+	}
+}
@@ -2,154 +2,270 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
 )
 
 var db *sql.DB
 
+// Timestamps are stored in UTC, whatever the time zone of the agent or the
+// database server, so they compare and sort correctly; the CLI converts them
+// to the display time zone with the records' In methods. Times passed to
+// queries are converted to UTC, and so are times read back, since drivers
+// return them in the zone they were written in or the session's zone.
+
+// utcNow returns the current time as it is stored
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// timeIn converts an optional time to loc
+func timeIn(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.In(loc)
+	return &u
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// currentDialect adapts queries to the driver db was opened with
+var currentDialect dialect = sqliteDialect{}
+
 // WorkflowExecution represents a workflow execution record
 type WorkflowExecution struct {
-	ID           int64
-	WorkflowName string
-	StartedAt    time.Time
-	CompletedAt  *time.Time
-	Status       string // running, success, failed
-	Error        *string
-	DurationMs   *int64
-	TriggerType  string
+	ID           int64      `json:"id"`
+	WorkflowName string     `json:"workflow_name"`
+	StartedAt    time.Time  `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	Status       string     `json:"status"` // one of the workflow.Status constants
+	Error        *string    `json:"error"`
+	DurationMs   *int64     `json:"duration_ms"`
+	TriggerType  string     `json:"trigger_type"`
+
+	// TriggerSource describes what fired the run, e.g. the cron tick time, the
+	// file path and event, or the remote address of a manual trigger
+	TriggerSource map[string]string `json:"trigger_source,omitempty"`
+
+	// ActionsSucceeded and ActionsFailed count the run's finished actions
+	ActionsSucceeded int `json:"actions_succeeded"`
+	ActionsFailed    int `json:"actions_failed"`
 }
 
 // ActionExecution represents an action execution record
 type ActionExecution struct {
-	ID                  int64
-	WorkflowExecutionID int64
-	ActionName          string
-	ActionType          string
-	StartedAt           time.Time
-	CompletedAt         *time.Time
-	Status              string // running, success, failed
-	Error               *string
-	DurationMs          *int64
-	Output              *string
+	ID                  int64      `json:"id"`
+	WorkflowExecutionID int64      `json:"workflow_execution_id"`
+	ActionName          string     `json:"action_name"`
+	ActionType          string     `json:"action_type"`
+	StartedAt           time.Time  `json:"started_at"`
+	CompletedAt         *time.Time `json:"completed_at"`
+	Status              string     `json:"status"` // running, success, failed
+	Error               *string    `json:"error"`
+	DurationMs          *int64     `json:"duration_ms"`
+	Output              *string    `json:"output"`
+	ScriptHash          *string    `json:"script_hash"` // SHA-256 of the script file that ran, for scriptFile actions
+}
+
+// In returns the execution with its times converted to loc
+func (e WorkflowExecution) In(loc *time.Location) WorkflowExecution {
+	e.StartedAt = e.StartedAt.In(loc)
+	e.CompletedAt = timeIn(e.CompletedAt, loc)
+	return e
+}
+
+// In returns the action execution with its times converted to loc
+func (a ActionExecution) In(loc *time.Location) ActionExecution {
+	a.StartedAt = a.StartedAt.In(loc)
+	a.CompletedAt = timeIn(a.CompletedAt, loc)
+	return a
 }
 
 // InitDB initializes the SQLite database
 func InitDB(dbPath string) error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create database directory: %w", err)
+	return Open(DriverSQLite, dbPath)
+}
+
+// Open connects to the database and creates the schema. For SQLite dsn is a
+// file path; for postgres and mysql it is the driver's connection string.
+func Open(driver, dsn string) error {
+	d, err := newDialect(driver)
+	if err != nil {
+		return err
 	}
 
-	var err error
-	db, err = sql.Open("sqlite3", dbPath)
+	_, isSQLite := d.(sqliteDialect)
+	if isSQLite {
+		// Create directory if it doesn't exist
+		dir := filepath.Dir(dsn)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	dsn, err = d.prepareDSN(dsn)
+	if err != nil {
+		return err
+	}
+
+	conn, err := sql.Open(d.driverName(), dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Test connection
-	if err := db.Ping(); err != nil {
+	if err := conn.Ping(); err != nil {
+		conn.Close()
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	db, currentDialect = conn, d
+
 	// Create tables
 	if err := createTables(); err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
-	logger.L().Infow("Database initialized successfully", "path", dbPath)
+	if isSQLite {
+		logger.L().Infow("Database initialized successfully", "path", dsn)
+	} else {
+		// The DSN may contain credentials, so only the driver is logged
+		logger.L().Infow("Database initialized successfully", "driver", driver)
+	}
 	return nil
 }
 
 // createTables creates the necessary database tables
 func createTables() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS workflow_executions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		workflow_name TEXT NOT NULL,
-		started_at TIMESTAMP NOT NULL,
-		completed_at TIMESTAMP,
-		status TEXT NOT NULL,
-		error TEXT,
-		duration_ms INTEGER,
-		trigger_type TEXT
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_workflow_started
-	ON workflow_executions(workflow_name, started_at);
-
-	CREATE INDEX IF NOT EXISTS idx_workflow_status
-	ON workflow_executions(status);
-
-	CREATE TABLE IF NOT EXISTS action_executions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		workflow_execution_id INTEGER NOT NULL,
-		action_name TEXT NOT NULL,
-		action_type TEXT NOT NULL,
-		started_at TIMESTAMP NOT NULL,
-		completed_at TIMESTAMP,
-		status TEXT NOT NULL,
-		error TEXT,
-		duration_ms INTEGER,
-		output TEXT,
-		FOREIGN KEY (workflow_execution_id) REFERENCES workflow_executions(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_action_workflow
-	ON action_executions(workflow_execution_id);
-	`
+	for _, stmt := range currentDialect.schema() {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to execute schema: %w", err)
+		}
+	}
 
-	_, err := db.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+	// Columns added after the initial schema, for databases created by older versions
+	if err := ensureColumn("action_executions", "script_hash", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn("workflow_executions", "trigger_source", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn("workflow_executions", "actions_succeeded", "INTEGER"); err != nil {
+		return err
+	}
+	if err := ensureColumn("workflow_executions", "actions_failed", "INTEGER"); err != nil {
+		return err
 	}
 
+	return currentDialect.normalizeTimestamps(db)
+}
+
+// ensureColumn adds a column to an existing table if it is missing
+func ensureColumn(table, column, columnType string) error {
+	if _, err := db.Exec(fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0", column, table)); err == nil {
+		return nil
+	}
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, columnType)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
 	return nil
 }
 
-// StartWorkflowExecution creates a new workflow execution record
-func StartWorkflowExecution(workflowName, triggerType string) (int64, error) {
+// StartWorkflowExecution creates a new workflow execution record. source
+// describes what fired the run and may be nil.
+func StartWorkflowExecution(workflowName, triggerType string, source map[string]string) (int64, error) {
 	if db == nil {
 		return 0, fmt.Errorf("database not initialized")
 	}
 
-	result, err := db.Exec(`
-		INSERT INTO workflow_executions (workflow_name, started_at, status, trigger_type)
-		VALUES (?, ?, ?, ?)
-	`, workflowName, time.Now(), "running", triggerType)
-
-	if err != nil {
-		return 0, fmt.Errorf("failed to insert workflow execution: %w", err)
+	var encodedSource *string
+	if len(source) > 0 {
+		encoded, err := json.Marshal(source)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode trigger source: %w", err)
+		}
+		str := string(encoded)
+		encodedSource = &str
 	}
 
-	id, err := result.LastInsertId()
+	id, err := currentDialect.insertID(db, `
+		INSERT INTO workflow_executions (workflow_name, started_at, status, trigger_type, trigger_source)
+		VALUES (?, ?, ?, ?, ?)
+	`, workflowName, utcNow(), workflow.StatusRunning, triggerType, encodedSource)
+
 	if err != nil {
-		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+		return 0, fmt.Errorf("failed to insert workflow execution: %w", err)
 	}
 
 	return id, nil
 }
 
-// CompleteWorkflowExecution updates a workflow execution as completed
-func CompleteWorkflowExecution(id int64, status string, errorMsg *string, duration time.Duration) error {
+// CompleteWorkflowExecution updates a workflow execution as completed with
+// its result: status, error, duration and the numbers of its actions that
+// succeeded and failed
+func CompleteWorkflowExecution(id int64, result *workflow.ExecutionResult) error {
 	if db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
-	durationMs := duration.Milliseconds()
-	completedAt := time.Now()
+	durationMs := result.Duration.Milliseconds()
+	completedAt := utcNow()
 
-	_, err := db.Exec(`
+	_, err := db.Exec(rebind(`
 		UPDATE workflow_executions
-		SET completed_at = ?, status = ?, error = ?, duration_ms = ?
+		SET completed_at = ?, status = ?, error = ?, duration_ms = ?, actions_succeeded = ?, actions_failed = ?
 		WHERE id = ?
-	`, completedAt, status, errorMsg, durationMs, id)
+	`), completedAt, result.Status, nullString(result.Error), durationMs, result.ActionsSucceeded, result.ActionsFailed, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to update workflow execution: %w", err)
+	}
+
+	return nil
+}
+
+// RecordLateAction counts an async action that finished after its workflow
+// execution was completed. If it failed, the execution's status and error are
+// set to status and errorMsg, unless errorMsg is nil.
+func RecordLateAction(id int64, failed bool, status string, errorMsg *string) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	var err error
+	switch {
+	case !failed:
+		_, err = db.Exec(rebind(`
+			UPDATE workflow_executions
+			SET actions_succeeded = COALESCE(actions_succeeded, 0) + 1
+			WHERE id = ?
+		`), id)
+	case errorMsg == nil:
+		_, err = db.Exec(rebind(`
+			UPDATE workflow_executions
+			SET actions_failed = COALESCE(actions_failed, 0) + 1
+			WHERE id = ?
+		`), id)
+	default:
+		_, err = db.Exec(rebind(`
+			UPDATE workflow_executions
+			SET actions_failed = COALESCE(actions_failed, 0) + 1, status = ?, error = ?
+			WHERE id = ?
+		`), status, *errorMsg, id)
+	}
 
 	if err != nil {
 		return fmt.Errorf("failed to update workflow execution: %w", err)
@@ -164,37 +280,59 @@ func StartActionExecution(workflowExecID int64, actionName, actionType string) (
 		return 0, fmt.Errorf("database not initialized")
 	}
 
-	result, err := db.Exec(`
+	id, err := currentDialect.insertID(db, `
 		INSERT INTO action_executions (workflow_execution_id, action_name, action_type, started_at, status)
 		VALUES (?, ?, ?, ?, ?)
-	`, workflowExecID, actionName, actionType, time.Now(), "running")
+	`, workflowExecID, actionName, actionType, utcNow(), workflow.StatusRunning)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert action execution: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	return id, nil
+}
+
+// SetActionScriptHash records the hash of the script a scriptFile action ran
+func SetActionScriptHash(id int64, hash string) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
 	}
 
-	return id, nil
+	if _, err := db.Exec(rebind(`UPDATE action_executions SET script_hash = ? WHERE id = ?`), hash, id); err != nil {
+		return fmt.Errorf("failed to update action execution: %w", err)
+	}
+
+	return nil
 }
 
-// CompleteActionExecution updates an action execution as completed
-func CompleteActionExecution(id int64, status string, errorMsg *string, output *string, duration time.Duration) error {
+// SetActionOutput records the output of a running action so far
+func SetActionOutput(id int64, output string) error {
 	if db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
-	durationMs := duration.Milliseconds()
-	completedAt := time.Now()
+	if _, err := db.Exec(rebind(`UPDATE action_executions SET output = ? WHERE id = ?`), output, id); err != nil {
+		return fmt.Errorf("failed to update action execution: %w", err)
+	}
 
-	_, err := db.Exec(`
+	return nil
+}
+
+// CompleteActionExecution updates an action execution as completed with its
+// result
+func CompleteActionExecution(id int64, result *workflow.ActionResult) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	durationMs := result.Duration.Milliseconds()
+	completedAt := utcNow()
+
+	_, err := db.Exec(rebind(`
 		UPDATE action_executions
 		SET completed_at = ?, status = ?, error = ?, output = ?, duration_ms = ?
 		WHERE id = ?
-	`, completedAt, status, errorMsg, output, durationMs, id)
+	`), completedAt, result.Status, nullString(result.Error), nullString(result.Output), durationMs, id)
 
 	if err != nil {
 		return fmt.Errorf("failed to update action execution: %w", err)
@@ -210,14 +348,14 @@ func GetWorkflowHistory(workflowName string, limit int) ([]WorkflowExecution, er
 	}
 
 	query := `
-		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type
+		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type, trigger_source, actions_succeeded, actions_failed
 		FROM workflow_executions
 		WHERE workflow_name = ?
 		ORDER BY started_at DESC
 		LIMIT ?
 	`
 
-	rows, err := db.Query(query, workflowName, limit)
+	rows, err := db.Query(rebind(query), workflowName, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query workflow history: %w", err)
 	}
@@ -225,17 +363,7 @@ func GetWorkflowHistory(workflowName string, limit int) ([]WorkflowExecution, er
 
 	executions := make([]WorkflowExecution, 0)
 	for rows.Next() {
-		var exec WorkflowExecution
-		err := rows.Scan(
-			&exec.ID,
-			&exec.WorkflowName,
-			&exec.StartedAt,
-			&exec.CompletedAt,
-			&exec.Status,
-			&exec.Error,
-			&exec.DurationMs,
-			&exec.TriggerType,
-		)
+		exec, err := scanWorkflowExecution(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
@@ -252,13 +380,13 @@ func GetAllWorkflowHistory(limit int) ([]WorkflowExecution, error) {
 	}
 
 	query := `
-		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type
+		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type, trigger_source, actions_succeeded, actions_failed
 		FROM workflow_executions
 		ORDER BY started_at DESC
 		LIMIT ?
 	`
 
-	rows, err := db.Query(query, limit)
+	rows, err := db.Query(rebind(query), limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query workflow history: %w", err)
 	}
@@ -266,17 +394,7 @@ func GetAllWorkflowHistory(limit int) ([]WorkflowExecution, error) {
 
 	executions := make([]WorkflowExecution, 0)
 	for rows.Next() {
-		var exec WorkflowExecution
-		err := rows.Scan(
-			&exec.ID,
-			&exec.WorkflowName,
-			&exec.StartedAt,
-			&exec.CompletedAt,
-			&exec.Status,
-			&exec.Error,
-			&exec.DurationMs,
-			&exec.TriggerType,
-		)
+		exec, err := scanWorkflowExecution(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
@@ -286,21 +404,26 @@ func GetAllWorkflowHistory(limit int) ([]WorkflowExecution, error) {
 	return executions, nil
 }
 
-// GetFailedExecutions returns recent failed workflow executions
+// GetFailedExecutions returns recent workflow executions with one of the
+// workflow.FailureStatuses
 func GetFailedExecutions(since time.Time, limit int) ([]WorkflowExecution, error) {
 	if db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
 
 	query := `
-		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type
+		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type, trigger_source, actions_succeeded, actions_failed
 		FROM workflow_executions
-		WHERE status = 'failed' AND started_at >= ?
+		WHERE status IN (` + placeholders(len(workflow.FailureStatuses)) + `) AND started_at >= ?
 		ORDER BY started_at DESC
 		LIMIT ?
 	`
 
-	rows, err := db.Query(query, since, limit)
+	args := make([]interface{}, 0, len(workflow.FailureStatuses)+2)
+	for _, status := range workflow.FailureStatuses {
+		args = append(args, status)
+	}
+	rows, err := db.Query(rebind(query), append(args, since.UTC(), limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query failed executions: %w", err)
 	}
@@ -308,17 +431,7 @@ func GetFailedExecutions(since time.Time, limit int) ([]WorkflowExecution, error
 
 	executions := make([]WorkflowExecution, 0)
 	for rows.Next() {
-		var exec WorkflowExecution
-		err := rows.Scan(
-			&exec.ID,
-			&exec.WorkflowName,
-			&exec.StartedAt,
-			&exec.CompletedAt,
-			&exec.Status,
-			&exec.Error,
-			&exec.DurationMs,
-			&exec.TriggerType,
-		)
+		exec, err := scanWorkflowExecution(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
@@ -328,14 +441,120 @@ func GetFailedExecutions(since time.Time, limit int) ([]WorkflowExecution, error
 	return executions, nil
 }
 
+// GetWorkflowExecution returns a single workflow execution by ID
+func GetWorkflowExecution(id int64) (*WorkflowExecution, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `
+		SELECT id, workflow_name, started_at, completed_at, status, error, duration_ms, trigger_type, trigger_source, actions_succeeded, actions_failed
+		FROM workflow_executions
+		WHERE id = ?
+	`
+
+	exec, err := scanWorkflowExecution(db.QueryRow(rebind(query), id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("workflow execution %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workflow execution: %w", err)
+	}
+
+	return &exec, nil
+}
+
+// scanWorkflowExecution scans a row selected with the columns of
+// GetWorkflowExecution
+func scanWorkflowExecution(row interface{ Scan(...interface{}) error }) (WorkflowExecution, error) {
+	var exec WorkflowExecution
+	var source sql.NullString
+	var succeeded, failed sql.NullInt64
+	err := row.Scan(
+		&exec.ID,
+		&exec.WorkflowName,
+		&exec.StartedAt,
+		&exec.CompletedAt,
+		&exec.Status,
+		&exec.Error,
+		&exec.DurationMs,
+		&exec.TriggerType,
+		&source,
+		&succeeded,
+		&failed,
+	)
+	if err != nil {
+		return exec, err
+	}
+	exec.StartedAt = exec.StartedAt.UTC()
+	exec.CompletedAt = timeIn(exec.CompletedAt, time.UTC)
+	exec.ActionsSucceeded = int(succeeded.Int64)
+	exec.ActionsFailed = int(failed.Int64)
+	if source.Valid && source.String != "" {
+		if err := json.Unmarshal([]byte(source.String), &exec.TriggerSource); err != nil {
+			logger.L().Warnw("Ignoring invalid trigger source",
+				"workflow_exec_id", exec.ID,
+				"error", err)
+		}
+	}
+	return exec, nil
+}
+
+// GetActionExecutions returns the action executions of a workflow execution in run order
+func GetActionExecutions(workflowExecID int64) ([]ActionExecution, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `
+		SELECT id, workflow_execution_id, action_name, action_type, started_at, completed_at, status, error, duration_ms, output, script_hash
+		FROM action_executions
+		WHERE workflow_execution_id = ?
+		ORDER BY started_at ASC, id ASC
+	`
+
+	rows, err := db.Query(rebind(query), workflowExecID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query action executions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := make([]ActionExecution, 0)
+	for rows.Next() {
+		var act ActionExecution
+		err := rows.Scan(
+			&act.ID,
+			&act.WorkflowExecutionID,
+			&act.ActionName,
+			&act.ActionType,
+			&act.StartedAt,
+			&act.CompletedAt,
+			&act.Status,
+			&act.Error,
+			&act.DurationMs,
+			&act.Output,
+			&act.ScriptHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		act.StartedAt = act.StartedAt.UTC()
+		act.CompletedAt = timeIn(act.CompletedAt, time.UTC)
+		actions = append(actions, act)
+	}
+
+	return actions, nil
+}
+
 // GetWorkflowStats returns statistics for a workflow
 type WorkflowStats struct {
-	WorkflowName    string
-	TotalExecutions int
-	SuccessCount    int
-	FailedCount     int
-	SuccessRate     float64
-	AvgDurationMs   float64
+	WorkflowName    string         `json:"workflow_name"`
+	TotalExecutions int            `json:"total_executions"`
+	SuccessCount    int            `json:"success_count"`
+	FailedCount     int            `json:"failed_count"` // executions with one of the workflow.FailureStatuses
+	SuccessRate     float64        `json:"success_rate"`
+	AvgDurationMs   float64        `json:"avg_duration_ms"`
+	StatusCounts    map[string]int `json:"status_counts"` // executions by status
 }
 
 func GetWorkflowStats(workflowName string, since time.Time) (*WorkflowStats, error) {
@@ -344,46 +563,50 @@ func GetWorkflowStats(workflowName string, since time.Time) (*WorkflowStats, err
 	}
 
 	query := `
-		SELECT
-			workflow_name,
-			COUNT(*) as total,
-			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success_count,
-			SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failed_count,
-			AVG(duration_ms) as avg_duration
+		SELECT status, COUNT(*), COUNT(duration_ms), SUM(duration_ms)
 		FROM workflow_executions
 		WHERE workflow_name = ? AND started_at >= ?
-		GROUP BY workflow_name
+		GROUP BY status
 	`
 
-	var stats WorkflowStats
-	var avgDuration sql.NullFloat64
-
-	err := db.QueryRow(query, workflowName, since).Scan(
-		&stats.WorkflowName,
-		&stats.TotalExecutions,
-		&stats.SuccessCount,
-		&stats.FailedCount,
-		&avgDuration,
-	)
+	rows, err := db.Query(rebind(query), workflowName, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workflow stats: %w", err)
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		// No executions found
-		return &WorkflowStats{
-			WorkflowName:    workflowName,
-			TotalExecutions: 0,
-			SuccessCount:    0,
-			FailedCount:     0,
-			SuccessRate:     0,
-			AvgDurationMs:   0,
-		}, nil
+	stats := WorkflowStats{
+		WorkflowName: workflowName,
+		StatusCounts: make(map[string]int),
 	}
+	var timedRuns int
+	var totalDurationMs float64
+	for rows.Next() {
+		var status string
+		var count, timed int
+		var durationMs sql.NullFloat64
+		if err := rows.Scan(&status, &count, &timed, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to query workflow stats: %w", err)
+		stats.StatusCounts[status] = count
+		stats.TotalExecutions += count
+		if status == workflow.StatusSuccess {
+			stats.SuccessCount += count
+		} else if workflow.IsFailure(status) {
+			stats.FailedCount += count
+		}
+		if workflow.WasStarted(status) {
+			timedRuns += timed
+			totalDurationMs += durationMs.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read workflow stats: %w", err)
 	}
 
-	if avgDuration.Valid {
-		stats.AvgDurationMs = avgDuration.Float64
+	if timedRuns > 0 {
+		stats.AvgDurationMs = totalDurationMs / float64(timedRuns)
 	}
 
 	if stats.TotalExecutions > 0 {
@@ -405,3 +628,57 @@ func CloseDB() error {
 func GetDB() *sql.DB {
 	return db
 }
+
+// PruneExecutions deletes workflow executions (and their action executions)
+// that started before the cutoff. It returns the number of deleted workflow
+// and action execution rows.
+func PruneExecutions(before time.Time) (workflowsDeleted, actionsDeleted int64, err error) {
+	if db == nil {
+		return 0, 0, fmt.Errorf("database not initialized")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(rebind(`
+		DELETE FROM action_executions
+		WHERE workflow_execution_id IN (
+			SELECT id FROM workflow_executions WHERE started_at < ?
+		)
+	`), before.UTC())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete action executions: %w", err)
+	}
+	actionsDeleted, _ = result.RowsAffected()
+
+	result, err = tx.Exec(rebind(`DELETE FROM workflow_executions WHERE started_at < ?`), before.UTC())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete workflow executions: %w", err)
+	}
+	workflowsDeleted, _ = result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit prune: %w", err)
+	}
+
+	return workflowsDeleted, actionsDeleted, nil
+}
+
+// Vacuum reclaims space freed by deletions (VACUUM on SQLite and Postgres,
+// OPTIMIZE TABLE on MySQL)
+func Vacuum() error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	for _, stmt := range currentDialect.vacuum() {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+func init() {
+	logger.InitLogger()
+}
+
+func TestPruneExecutions(t *testing.T) {
+	if err := InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer CloseDB()
+
+	// start records an execution with two actions, started age ago
+	start := func(name string, age time.Duration) int64 {
+		id, err := StartWorkflowExecution(name, "manual", nil)
+		if err != nil {
+			t.Fatalf("Failed to start workflow execution: %v", err)
+		}
+		for _, action := range []string{"first", "second"} {
+			if _, err := StartActionExecution(id, action, "bash"); err != nil {
+				t.Fatalf("Failed to start action execution: %v", err)
+			}
+		}
+		startedAt := time.Now().UTC().Add(-age)
+		if _, err := db.Exec(rebind(`UPDATE workflow_executions SET started_at = ? WHERE id = ?`), startedAt, id); err != nil {
+			t.Fatalf("Failed to backdate execution: %v", err)
+		}
+		return id
+	}
+	old := start("old", 40*24*time.Hour)
+	recent := start("recent", time.Hour)
+
+	workflowsDeleted, actionsDeleted, err := PruneExecutions(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if workflowsDeleted != 1 || actionsDeleted != 2 {
+		t.Errorf("Expected 1 workflow and 2 action executions deleted, got %d and %d", workflowsDeleted, actionsDeleted)
+	}
+
+	if exec, err := GetWorkflowExecution(old); err == nil && exec != nil {
+		t.Errorf("Expected the old execution to be deleted, got %+v", exec)
+	}
+	if actions, err := GetActionExecutions(old); err != nil || len(actions) != 0 {
+		t.Errorf("Expected the old execution's actions to be deleted, got %d (err: %v)", len(actions), err)
+	}
+	if exec, err := GetWorkflowExecution(recent); err != nil || exec == nil {
+		t.Errorf("Expected the recent execution to be kept, got err: %v", err)
+	}
+	if actions, err := GetActionExecutions(recent); err != nil || len(actions) != 2 {
+		t.Errorf("Expected the recent execution's 2 actions to be kept, got %d (err: %v)", len(actions), err)
+	}
+}
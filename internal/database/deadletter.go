@@ -0,0 +1,209 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// DeadLetter is a failed run kept with everything needed to replay it: the
+// workflow definition it ran with, its trigger payload and the inputs and
+// results of its actions
+type DeadLetter struct {
+	ID                  int64
+	WorkflowName        string
+	WorkflowExecutionID int64
+	TriggerType         string
+	FailedActions       []string
+	Error               *string
+	CreatedAt           time.Time
+
+	// WorkflowDefinition is the YAML of the workflow as it ran
+	WorkflowDefinition string
+	// TriggerData is the JSON encoded template data the run was triggered with
+	TriggerData string
+	// Steps is the JSON encoded .steps data of the run, with the inputs and
+	// results of its actions
+	Steps string
+
+	// ReplayCount counts the replays; the other replay fields describe the latest one
+	ReplayCount       int
+	ReplayedAt        *time.Time
+	ReplayExecutionID *int64
+	ReplayStatus      *string
+}
+
+// Resolved reports whether the dead letter's latest replay succeeded
+func (d DeadLetter) Resolved() bool {
+	return d.ReplayStatus != nil && !workflow.IsFailure(*d.ReplayStatus)
+}
+
+// AddDeadLetter stores a failed run in the dead-letter queue and returns its ID
+func AddDeadLetter(d DeadLetter) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	failedActions, err := json.Marshal(d.FailedActions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode failed actions: %w", err)
+	}
+
+	var workflowExecID *int64
+	if d.WorkflowExecutionID > 0 {
+		workflowExecID = &d.WorkflowExecutionID
+	}
+
+	id, err := currentDialect.insertID(db, `
+		INSERT INTO dead_letters (workflow_name, workflow_execution_id, trigger_type, failed_actions, error, workflow_definition, trigger_data, steps, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.WorkflowName, workflowExecID, d.TriggerType, string(failedActions), d.Error, d.WorkflowDefinition, d.TriggerData, d.Steps, utcNow())
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+
+	return id, nil
+}
+
+const deadLetterColumns = `id, workflow_name, workflow_execution_id, trigger_type, failed_actions, error, workflow_definition, trigger_data, steps, created_at, replay_count, replayed_at, replay_execution_id, replay_status`
+
+// ListDeadLetters returns dead letters newest first. An empty workflowName
+// lists every workflow's; resolved dead letters are left out unless
+// includeResolved is set. A limit of 0 or less returns all of them.
+func ListDeadLetters(workflowName string, includeResolved bool, limit int) ([]DeadLetter, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE 1 = 1`
+	var args []interface{}
+	if workflowName != "" {
+		query += ` AND workflow_name = ?`
+		args = append(args, workflowName)
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+
+	rows, err := db.Query(rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := make([]DeadLetter, 0)
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if letter.Resolved() && !includeResolved {
+			continue
+		}
+		letters = append(letters, letter)
+		if limit > 0 && len(letters) >= limit {
+			break
+		}
+	}
+
+	return letters, rows.Err()
+}
+
+// GetDeadLetter returns a dead letter by ID
+func GetDeadLetter(id int64) (*DeadLetter, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	row := db.QueryRow(rebind(`SELECT `+deadLetterColumns+` FROM dead_letters WHERE id = ?`), id)
+	letter, err := scanDeadLetter(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("dead letter %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	return &letter, nil
+}
+
+// RecordDeadLetterReplay records the workflow execution and final status of
+// a replay of the dead letter
+func RecordDeadLetterReplay(id, workflowExecID int64, status string) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	var replayExecID *int64
+	if workflowExecID > 0 {
+		replayExecID = &workflowExecID
+	}
+
+	result, err := db.Exec(rebind(`
+		UPDATE dead_letters
+		SET replay_count = replay_count + 1, replayed_at = ?, replay_execution_id = ?, replay_status = ?
+		WHERE id = ?
+	`), utcNow(), replayExecID, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to record dead letter replay: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("dead letter %d not found", id)
+	}
+
+	return nil
+}
+
+// PruneDeadLetters deletes dead letters created before the cutoff and
+// returns how many were deleted
+func PruneDeadLetters(before time.Time) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	result, err := db.Exec(rebind(`DELETE FROM dead_letters WHERE created_at < ?`), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letters: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
+
+// scanDeadLetter scans a row selected with deadLetterColumns
+func scanDeadLetter(row interface{ Scan(...interface{}) error }) (DeadLetter, error) {
+	var letter DeadLetter
+	var workflowExecID sql.NullInt64
+	var triggerType, failedActions, triggerData, steps sql.NullString
+	err := row.Scan(
+		&letter.ID,
+		&letter.WorkflowName,
+		&workflowExecID,
+		&triggerType,
+		&failedActions,
+		&letter.Error,
+		&letter.WorkflowDefinition,
+		&triggerData,
+		&steps,
+		&letter.CreatedAt,
+		&letter.ReplayCount,
+		&letter.ReplayedAt,
+		&letter.ReplayExecutionID,
+		&letter.ReplayStatus,
+	)
+	if err != nil {
+		return letter, err
+	}
+	letter.CreatedAt = letter.CreatedAt.UTC()
+	letter.ReplayedAt = timeIn(letter.ReplayedAt, time.UTC)
+	letter.WorkflowExecutionID = workflowExecID.Int64
+	letter.TriggerType = triggerType.String
+	letter.TriggerData = triggerData.String
+	letter.Steps = steps.String
+	if failedActions.Valid && failedActions.String != "" {
+		if err := json.Unmarshal([]byte(failedActions.String), &letter.FailedActions); err != nil {
+			return letter, fmt.Errorf("invalid failed actions of dead letter %d: %w", letter.ID, err)
+		}
+	}
+	return letter, nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  dialect
+		query    string
+		expected string
+	}{
+		{
+			name:     "SQLite Unchanged",
+			dialect:  sqliteDialect{},
+			query:    "SELECT value FROM kv_store WHERE key = ? AND value != ?",
+			expected: "SELECT value FROM kv_store WHERE key = ? AND value != ?",
+		},
+		{
+			name:     "Postgres Numbers Placeholders",
+			dialect:  postgresDialect{},
+			query:    "UPDATE kv_store SET value = ?, updated_at = ? WHERE key = ?",
+			expected: "UPDATE kv_store SET value = $1, updated_at = $2 WHERE key = $3",
+		},
+		{
+			name:     "Postgres Skips Literals",
+			dialect:  postgresDialect{},
+			query:    "SELECT '?' || value, \"odd?col\" FROM kv_store WHERE value ~ '^a?$' AND key = ?",
+			expected: "SELECT '?' || value, \"odd?col\" FROM kv_store WHERE value ~ '^a?$' AND key = $1",
+		},
+		{
+			name:     "Postgres Doubled Quote",
+			dialect:  postgresDialect{},
+			query:    "SELECT 'it''s ?' FROM kv_store WHERE key = ?",
+			expected: "SELECT 'it''s ?' FROM kv_store WHERE key = $1",
+		},
+		{
+			name:     "Postgres Leaves Key Unquoted",
+			dialect:  postgresDialect{},
+			query:    "SELECT key FROM seen_set WHERE key = ?",
+			expected: "SELECT key FROM seen_set WHERE key = $1",
+		},
+		{
+			name:     "MySQL Quotes Key Column",
+			dialect:  mysqlDialect{},
+			query:    "SELECT kv_store.key, value FROM kv_store WHERE key = ?",
+			expected: "SELECT kv_store.`key`, value FROM kv_store WHERE `key` = ?",
+		},
+		{
+			name:     "MySQL Skips Key In Literals",
+			dialect:  mysqlDialect{},
+			query:    "SELECT 'key' AS kind, \"key\" AS label FROM kv_store WHERE key = ? AND value LIKE '%key%'",
+			expected: "SELECT 'key' AS kind, \"key\" AS label FROM kv_store WHERE `key` = ? AND value LIKE '%key%'",
+		},
+		{
+			name:     "MySQL Leaves Quoted And Longer Names",
+			dialect:  mysqlDialect{},
+			query:    "SELECT `key`, value AS key_value, monkey FROM kv_store ON DUPLICATE KEY UPDATE value = ?",
+			expected: "SELECT `key`, value AS key_value, monkey FROM kv_store ON DUPLICATE KEY UPDATE value = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.rebind(tt.query); got != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// KVEntry represents a value in the workflow key-value store
+type KVEntry struct {
+	Key       string
+	Value     string
+	UpdatedAt time.Time
+}
+
+// GetKV returns the value stored under key. found is false if the key does not exist.
+func GetKV(key string) (value string, found bool, err error) {
+	if db == nil {
+		return "", false, fmt.Errorf("database not initialized")
+	}
+
+	err = db.QueryRow(rebind(`SELECT value FROM kv_store WHERE key = ?`), key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get key '%s': %w", key, err)
+	}
+
+	return value, true, nil
+}
+
+// SetKV stores value under key, replacing any previous value
+func SetKV(key, value string) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	_, err := db.Exec(rebind(currentDialect.upsertKV()), key, value, utcNow())
+	if err != nil {
+		return fmt.Errorf("failed to set key '%s': %w", key, err)
+	}
+
+	return nil
+}
+
+// IncrKV atomically adds delta to the integer stored under key and returns the
+// new value. Missing keys and non-numeric values start at 0.
+func IncrKV(key string, delta int64) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	value, err := currentDialect.incrKV(db, key, delta, utcNow())
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment key '%s': %w", key, err)
+	}
+
+	return value, nil
+}
+
+// SeenAdd records key in the seen-set for ttl (0 means forever). It returns
+// true if the key was not already present, which makes it suitable for
+// "only once per unique event" checks.
+func SeenAdd(key string, ttl time.Duration) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+
+	now := utcNow()
+	if _, err := db.Exec(rebind(`DELETE FROM seen_set WHERE expires_at IS NOT NULL AND expires_at <= ?`), now.Unix()); err != nil {
+		return false, fmt.Errorf("failed to expire seen keys: %w", err)
+	}
+
+	var expiresAt *int64
+	if ttl > 0 {
+		expiry := now.Add(ttl).Unix()
+		expiresAt = &expiry
+	}
+
+	result, err := db.Exec(rebind(currentDialect.insertSeen()), key, now, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to add seen key '%s': %w", key, err)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to add seen key '%s': %w", key, err)
+	}
+
+	return added > 0, nil
+}
+
+// SeenHas reports whether key is in the seen-set and has not expired
+func SeenHas(key string) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+
+	var exists int
+	err := db.QueryRow(rebind(`
+		SELECT COUNT(*) FROM seen_set
+		WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)
+	`), key, time.Now().Unix()).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check seen key '%s': %w", key, err)
+	}
+
+	return exists > 0, nil
+}
+
+// DeleteKV removes key from the store. Deleting a missing key is not an error.
+func DeleteKV(key string) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	if _, err := db.Exec(rebind(`DELETE FROM kv_store WHERE key = ?`), key); err != nil {
+		return fmt.Errorf("failed to delete key '%s': %w", key, err)
+	}
+
+	return nil
+}
+
+// ListKV returns all entries whose key starts with prefix, ordered by key
+func ListKV(prefix string) ([]KVEntry, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := db.Query(rebind(`
+		SELECT key, value, updated_at
+		FROM kv_store
+		WHERE substr(key, 1, ?) = ?
+		ORDER BY key
+	`), utf8.RuneCountInString(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]KVEntry, 0)
+	for rows.Next() {
+		var entry KVEntry
+		if err := rows.Scan(&entry.Key, &entry.Value, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		entry.UpdatedAt = entry.UpdatedAt.UTC()
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
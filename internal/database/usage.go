@@ -0,0 +1,108 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// WorkflowUsage is the run count and cumulative execution time of a workflow
+// in one calendar month
+type WorkflowUsage struct {
+	Month           string // YYYY-MM, in the time zone of GetUsage's since
+	WorkflowName    string
+	Runs            int
+	FailedRuns      int // runs with one of the workflow.FailureStatuses
+	TotalDurationMs int64
+	AvgDurationMs   float64
+	Share           float64 // percentage of the month's total execution time
+}
+
+// GetUsage returns per-workflow usage for each month since the given time,
+// with months in since's time zone, newest month first and the heaviest workflows first within a month. If
+// workflowName is not empty only that workflow is included, but its share is
+// still relative to all workflows.
+func GetUsage(since time.Time, workflowName string) ([]WorkflowUsage, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `
+		SELECT workflow_name, started_at, status, duration_ms
+		FROM workflow_executions
+		WHERE started_at >= ?
+	`
+
+	rows, err := db.Query(rebind(query), since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	type key struct{ month, workflow string }
+	byKey := make(map[key]*WorkflowUsage)
+	monthTotals := make(map[string]int64)
+
+	for rows.Next() {
+		var name, status string
+		var startedAt time.Time
+		var durationMs *int64
+		if err := rows.Scan(&name, &startedAt, &status, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		month := startedAt.In(since.Location()).Format("2006-01")
+		var duration int64
+		if durationMs != nil {
+			duration = *durationMs // still running otherwise
+		}
+		monthTotals[month] += duration
+
+		k := key{month, name}
+		usage, exists := byKey[k]
+		if !exists {
+			usage = &WorkflowUsage{Month: month, WorkflowName: name}
+			byKey[k] = usage
+		}
+		usage.Runs++
+		if workflow.IsFailure(status) {
+			usage.FailedRuns++
+		}
+		usage.TotalDurationMs += duration
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	usages := make([]WorkflowUsage, 0, len(byKey))
+	for k, usage := range byKey {
+		if workflowName != "" && k.workflow != workflowName {
+			continue
+		}
+		usage.AvgDurationMs = float64(usage.TotalDurationMs) / float64(usage.Runs)
+		if total := monthTotals[k.month]; total > 0 {
+			usage.Share = float64(usage.TotalDurationMs) / float64(total) * 100
+		}
+		usages = append(usages, *usage)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Month != usages[j].Month {
+			return usages[i].Month > usages[j].Month
+		}
+		if usages[i].TotalDurationMs != usages[j].TotalDurationMs {
+			return usages[i].TotalDurationMs > usages[j].TotalDurationMs
+		}
+		return usages[i].WorkflowName < usages[j].WorkflowName
+	})
+
+	return usages, nil
+}
+
+// StartOfMonth returns midnight on the first day of the month, months-1
+// months before t's month, so StartOfMonth(t, 1) is the start of t's month
+func StartOfMonth(t time.Time, months int) time.Time {
+	return time.Date(t.Year(), t.Month()-time.Month(months-1), 1, 0, 0, 0, 0, t.Location())
+}
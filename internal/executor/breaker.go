@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"sync"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// workflowBreaker is the circuit breaker of a workflow with the configuration
+// it was created from
+type workflowBreaker struct {
+	config workflow.CircuitBreakerConfig
+	*retry.CircuitBreaker
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*workflowBreaker) // by workflow name
+)
+
+// circuitBreaker returns the circuit breaker of wf, or nil if it has none. The
+// breaker keeps its state when the workflow is reloaded unchanged.
+func circuitBreaker(wf *workflow.Workflow) *retry.CircuitBreaker {
+	if wf.CircuitBreaker == nil {
+		return nil
+	}
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b := breakers[wf.Name]
+	if b == nil || b.config != *wf.CircuitBreaker {
+		cooldown, err := wf.CircuitBreaker.CooldownDuration()
+		if err != nil {
+			cooldown = workflow.DefaultCircuitCooldown // rejected by the parser
+		}
+		b = &workflowBreaker{
+			config:         *wf.CircuitBreaker,
+			CircuitBreaker: retry.NewCircuitBreaker(wf.CircuitBreaker.FailureThreshold, cooldown),
+		}
+		breakers[wf.Name] = b
+	}
+	return b.CircuitBreaker
+}
+
+// recordCircuit records the status of a finished run in the workflow's
+// circuit breaker. Failures count against it, successful and partially
+// successful runs close it, and other runs leave it as it was.
+func recordCircuit(wf *workflow.Workflow, breaker *retry.CircuitBreaker, status string) {
+	wasOpen := breaker.State() != retry.CircuitClosed
+
+	switch {
+	case workflow.IsFailure(status):
+		breaker.Record(false)
+	case status == workflow.StatusSuccess || status == workflow.StatusPartialSuccess:
+		breaker.Record(true)
+	default:
+		breaker.Release()
+	}
+
+	open := breaker.State() != retry.CircuitClosed
+	switch {
+	case open && !wasOpen:
+		logger.L().Warnw("Circuit breaker opened, blocking runs",
+			"workflow_name", wf.Name,
+			"consecutive_failures", breaker.Failures(),
+			"cooldown", wf.CircuitBreaker.Cooldown)
+	case !open && wasOpen:
+		logger.L().Infow("Circuit breaker closed",
+			"workflow_name", wf.Name)
+	}
+	metrics.SetCircuitOpen(wf.Name, open)
+	server.GetRegistry().SetCircuitOpen(wf.Name, open)
+}
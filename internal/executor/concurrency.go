@@ -0,0 +1,209 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// inFlightRun is a workflow run that has started and not yet been recorded
+type inFlightRun struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{} // closed when the run has been recorded
+}
+
+var (
+	inFlightMu sync.Mutex
+	inFlight   = make(map[string][]*inFlightRun) // by workflow name
+	draining   bool                              // set by Drain, no new runs start
+
+	// drainingWorkflows counts the DrainWorkflows calls in progress by
+	// workflow name; those workflows start no new runs meanwhile
+	drainingWorkflows = make(map[string]int)
+)
+
+// Reasons startRun doesn't start a run
+var (
+	errPreviousRun  = errors.New("previous run still in progress")
+	errShuttingDown = errors.New("agent shutting down")
+)
+
+// errAborted is the cause of the runs Drain cancels
+var errAborted = errors.New("run aborted: agent shut down before it finished")
+
+// abortGrace bounds how long Drain waits for aborted runs to be recorded
+const abortGrace = 5 * time.Second
+
+// startRun registers a new run of wf according to its concurrency policy.
+// With "forbid" it returns errPreviousRun while another run is in progress.
+// With "replace" it cancels the runs in progress and waits for them to be
+// recorded before starting. Once Drain was called, or while DrainWorkflows
+// drains wf, it returns errShuttingDown, unless a workflow action of a run in
+// progress starts the run. The returned
+// context is cancelled when a newer run replaces this one or Drain aborts it,
+// and release must be called once the run is recorded.
+func startRun(wf *workflow.Workflow, triggerType string) (ctx context.Context, release func(), err error) {
+	for {
+		inFlightMu.Lock()
+		if (draining || drainingWorkflows[wf.Name] > 0) && triggerType != TriggerTypeWorkflow {
+			inFlightMu.Unlock()
+			return nil, nil, errShuttingDown
+		}
+		running := inFlight[wf.Name]
+
+		if len(running) > 0 {
+			switch wf.ConcurrencyPolicy {
+			case workflow.ConcurrencyForbid:
+				inFlightMu.Unlock()
+				return nil, nil, errPreviousRun
+			case workflow.ConcurrencyReplace:
+				for _, r := range running {
+					r.cancel(nil)
+				}
+				done := running[0].done
+				inFlightMu.Unlock()
+
+				// Another run may have started meanwhile, so check again
+				<-done
+				continue
+			}
+		}
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		run := &inFlightRun{cancel: cancel, done: make(chan struct{})}
+		inFlight[wf.Name] = append(running, run)
+		inFlightMu.Unlock()
+
+		return ctx, func() { finishRun(wf.Name, run) }, nil
+	}
+}
+
+func finishRun(workflowName string, run *inFlightRun) {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+
+	running := inFlight[workflowName]
+	for i, r := range running {
+		if r == run {
+			running = append(running[:i], running[i+1:]...)
+			break
+		}
+	}
+	if len(running) == 0 {
+		delete(inFlight, workflowName)
+	} else {
+		inFlight[workflowName] = running
+	}
+	close(run.done)
+}
+
+// CancelRuns cancels the runs of a workflow in progress, e.g. a shutdown
+// workflow that overran its timeout. They are recorded as cancelled.
+func CancelRuns(workflowName string) {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+
+	for _, r := range inFlight[workflowName] {
+		r.cancel(nil)
+	}
+}
+
+// Drain stops new runs from starting and waits up to timeout for the runs in
+// progress to finish, e.g. on agent shutdown. Runs still going then are
+// cancelled and recorded as aborted. It returns the number of aborted runs.
+func Drain(timeout time.Duration) int {
+	inFlightMu.Lock()
+	draining = true
+	inFlightMu.Unlock()
+
+	if waitForRuns(nil, timeout) {
+		return 0
+	}
+
+	inFlightMu.Lock()
+	aborted := 0
+	for _, running := range inFlight {
+		for _, r := range running {
+			r.cancel(errAborted)
+			aborted++
+		}
+	}
+	inFlightMu.Unlock()
+
+	waitForRuns(nil, abortGrace)
+	return aborted
+}
+
+// DrainWorkflows is Drain for the runs of the named workflows only, e.g. when
+// an embedded engine stops while the process keeps running. Their runs can
+// start again once it returns.
+func DrainWorkflows(names []string, timeout time.Duration) int {
+	inFlightMu.Lock()
+	for _, name := range names {
+		drainingWorkflows[name]++
+	}
+	inFlightMu.Unlock()
+
+	defer func() {
+		inFlightMu.Lock()
+		for _, name := range names {
+			if drainingWorkflows[name]--; drainingWorkflows[name] <= 0 {
+				delete(drainingWorkflows, name)
+			}
+		}
+		inFlightMu.Unlock()
+	}()
+
+	if waitForRuns(names, timeout) {
+		return 0
+	}
+
+	inFlightMu.Lock()
+	aborted := 0
+	for _, name := range names {
+		for _, r := range inFlight[name] {
+			r.cancel(errAborted)
+			aborted++
+		}
+	}
+	inFlightMu.Unlock()
+
+	waitForRuns(names, abortGrace)
+	return aborted
+}
+
+// waitForRuns waits up to timeout for the runs in progress of the named
+// workflows, or of all workflows if names is nil, to be recorded. It returns
+// false if some were still running when the timeout expired.
+func waitForRuns(names []string, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		var done chan struct{}
+		inFlightMu.Lock()
+		if names == nil {
+			for _, running := range inFlight {
+				done = running[0].done
+				break
+			}
+		}
+		for _, name := range names {
+			if running := inFlight[name]; len(running) > 0 {
+				done = running[0].done
+				break
+			}
+		}
+		inFlightMu.Unlock()
+
+		if done == nil {
+			return true
+		}
+		select {
+		case <-done:
+		case <-deadline:
+			return false
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// deadLetter stores a failed run in the dead-letter queue: the workflow as it
+// ran, the data it was triggered with and the results of its actions, so it
+// can be inspected and replayed with `autozap dlq replay`. Replays aren't
+// dead-lettered again; their outcome is recorded on the original entry.
+func deadLetter(wf *workflow.Workflow, triggerType string, workflowExecID int64, triggerData templating.Data, steps map[string]interface{}, state *runState) {
+	definition, err := yaml.Marshal(wf)
+	if err != nil {
+		logger.L().Errorw("Failed to encode workflow for the dead-letter queue",
+			"workflow_name", wf.Name,
+			"error", err)
+		return
+	}
+	encodedData, err := EncodeRunData(triggerData)
+	if err != nil {
+		logger.L().Errorw("Failed to encode trigger data for the dead-letter queue",
+			"workflow_name", wf.Name,
+			"error", err)
+		return
+	}
+	encodedSteps, err := json.Marshal(steps)
+	if err != nil {
+		logger.L().Errorw("Failed to encode action results for the dead-letter queue",
+			"workflow_name", wf.Name,
+			"error", err)
+		return
+	}
+
+	state.mu.Lock()
+	errMsg := state.result.Error
+	var failedActions []string
+	for _, act := range state.actions {
+		if act != nil && workflow.IsFailure(act.Status) {
+			failedActions = append(failedActions, act.Name)
+		}
+	}
+	state.mu.Unlock()
+
+	id, err := database.AddDeadLetter(database.DeadLetter{
+		WorkflowName:        wf.Name,
+		WorkflowExecutionID: workflowExecID,
+		TriggerType:         triggerType,
+		FailedActions:       failedActions,
+		Error:               &errMsg,
+		WorkflowDefinition:  string(definition),
+		TriggerData:         encodedData,
+		Steps:               string(encodedSteps),
+	})
+	if err != nil {
+		logger.L().Errorw("Failed to add run to the dead-letter queue",
+			"workflow_name", wf.Name,
+			"workflow_exec_id", workflowExecID,
+			"error", err)
+		return
+	}
+
+	logger.L().Infow("Failed run added to the dead-letter queue",
+		"workflow_name", wf.Name,
+		"workflow_exec_id", workflowExecID,
+		"dead_letter_id", id,
+		"failed_actions", failedActions)
+}
+
+// recordReplay records the outcome of a replay on its dead letter
+func recordReplay(wf *workflow.Workflow, data templating.Data, workflowExecID int64, status string) {
+	replay, _ := data["replay"].(map[string]interface{})
+	id, _ := replay["deadLetterId"].(int64)
+	if id == 0 {
+		return
+	}
+
+	if err := database.RecordDeadLetterReplay(id, workflowExecID, status); err != nil {
+		logger.L().Errorw("Failed to record dead letter replay",
+			"workflow_name", wf.Name,
+			"dead_letter_id", id,
+			"error", err)
+	}
+}
+
+// ReplayData returns the data to replay a dead letter with: its trigger data
+// and {{ .replay }} describing the dead letter
+func ReplayData(letter *database.DeadLetter) (templating.Data, error) {
+	data, err := DecodeRunData(letter.TriggerData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trigger data of dead letter %d: %w", letter.ID, err)
+	}
+	return withData(data, "replay", map[string]interface{}{
+		"deadLetterId":        letter.ID,
+		"workflowExecutionId": letter.WorkflowExecutionID,
+		"triggerType":         letter.TriggerType,
+		"failedActions":       letter.FailedActions,
+	}), nil
+}
+
+// EncodeRunData encodes the data a run was triggered with as JSON
+func EncodeRunData(data templating.Data) (string, error) {
+	if data == nil {
+		return "", nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// DecodeRunData decodes data encoded with EncodeRunData, restoring the types
+// of the event fields the executor reads
+func DecodeRunData(encoded string) (templating.Data, error) {
+	if encoded == "" {
+		return templating.Data{}, nil
+	}
+
+	var data templating.Data
+	if err := json.Unmarshal([]byte(encoded), &data); err != nil {
+		return nil, err
+	}
+
+	if files, ok := data["files"].([]interface{}); ok {
+		data["files"] = stringSlice(files)
+	}
+	if event, ok := data["event"].(map[string]interface{}); ok {
+		if files, ok := event["files"].([]interface{}); ok {
+			event["files"] = stringSlice(files)
+		}
+		if source, ok := event["source"].(map[string]interface{}); ok {
+			details := make(map[string]string, len(source))
+			for k, v := range source {
+				details[k] = fmt.Sprint(v)
+			}
+			event["source"] = details
+		}
+	}
+	return data, nil
+}
+
+func stringSlice(values []interface{}) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
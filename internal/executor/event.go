@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/templating"
+)
+
+// Event describes the trigger event that started a run. It is available to
+// action templates as {{ .event }} and to bash actions as AUTOZAP_* environment
+// variables.
+type Event struct {
+	Type    string                 // file event of a filewatch trigger: create, write, remove, rename or chmod
+	Path    string                 // file that changed (filewatch) or was tailed (log)
+	Files   []string               // files of the run; several when batched (filewatch)
+	Time    time.Time              // when the trigger fired, defaults to the start of the run
+	Payload map[string]interface{} // request payload (manual), or a message that is a JSON object
+	Topic   string                 // channel or topic a message arrived on (redis, mqtt)
+	Message string                 // raw message (redis, mqtt) or matched line (log)
+
+	// Source holds details of what fired the run for the execution history,
+	// e.g. the remote address of a manual trigger or the polled URL
+	Source map[string]string
+}
+
+// WithEvent returns a copy of data with e stored under "event"
+func WithEvent(data templating.Data, e Event) templating.Data {
+	event := map[string]interface{}{
+		"type":    e.Type,
+		"path":    e.Path,
+		"files":   e.Files,
+		"payload": e.Payload,
+		"topic":   e.Topic,
+		"message": e.Message,
+		"source":  e.Source,
+	}
+	if !e.Time.IsZero() {
+		event["time"] = e.Time.Format(time.RFC3339)
+	}
+	return withData(data, "event", event)
+}
+
+// withTrigger completes the run's event with the trigger type and, if the
+// trigger didn't set it, the fire time
+func withTrigger(data templating.Data, triggerType string, now time.Time) templating.Data {
+	event := make(map[string]interface{})
+	if existing, ok := data["event"].(map[string]interface{}); ok {
+		for k, v := range existing {
+			event[k] = v
+		}
+	}
+	event["trigger"] = triggerType
+	if t, _ := event["time"].(string); t == "" {
+		event["time"] = now.Format(time.RFC3339)
+	}
+	return withData(data, "event", event)
+}
+
+// triggerSource returns what fired the run for the execution history: the
+// event's time, file path and type, topic and number of files, and the
+// trigger's Source details
+func triggerSource(data templating.Data) map[string]string {
+	event, _ := data["event"].(map[string]interface{})
+	if event == nil {
+		return nil
+	}
+
+	source := make(map[string]string)
+	for _, key := range []string{"time", "path", "topic"} {
+		if s, _ := event[key].(string); s != "" {
+			source[key] = s
+		}
+	}
+	if s, _ := event["type"].(string); s != "" {
+		source["event"] = s
+	}
+	if files, _ := event["files"].([]string); len(files) > 1 {
+		source["files"] = strconv.Itoa(len(files))
+	}
+	if details, _ := event["source"].(map[string]string); details != nil {
+		for k, v := range details {
+			source[k] = v
+		}
+	}
+	return source
+}
+
+// eventEnv returns the environment variables describing the run's event for
+// bash actions
+func eventEnv(workflowName string, data templating.Data) []string {
+	env := []string{"AUTOZAP_WORKFLOW=" + workflowName}
+
+	event, _ := data["event"].(map[string]interface{})
+	if event == nil {
+		return env
+	}
+
+	str := func(key string) string {
+		s, _ := event[key].(string)
+		return s
+	}
+	env = append(env,
+		"AUTOZAP_TRIGGER_TYPE="+str("trigger"),
+		"AUTOZAP_EVENT_TYPE="+str("type"),
+		"AUTOZAP_EVENT_PATH="+str("path"),
+		"AUTOZAP_EVENT_TIME="+str("time"),
+		"AUTOZAP_EVENT_TOPIC="+str("topic"),
+		"AUTOZAP_EVENT_MESSAGE="+str("message"),
+	)
+
+	// One file per line, like the output of ls
+	files, _ := event["files"].([]string)
+	env = append(env, "AUTOZAP_EVENT_FILES="+strings.Join(files, "\n"))
+
+	payload := ""
+	if p, _ := event["payload"].(map[string]interface{}); p != nil {
+		if encoded, err := json.Marshal(p); err == nil {
+			payload = string(encoded)
+		} else {
+			payload = fmt.Sprintf("%v", p)
+		}
+	}
+	return append(env, "AUTOZAP_EVENT_PAYLOAD="+payload)
+}
@@ -0,0 +1,782 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/health"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// TriggerTypeManual is recorded for runs started through the API rather than
+// by the workflow's own trigger
+const TriggerTypeManual = "manual"
+
+// TriggerTypeRemediation is recorded for runs started by a remediation rule
+// after another workflow failed
+const TriggerTypeRemediation = "remediation"
+
+// TriggerTypeReplay is recorded for runs replayed from the dead-letter queue
+const TriggerTypeReplay = "replay"
+
+// FailureHandler is called after a run has failed or partially succeeded and
+// been recorded, with the run's status. It must not block.
+type FailureHandler func(wf *workflow.Workflow, triggerType string, workflowExecID int64, status, errMsg string)
+
+var failureHandler FailureHandler
+
+// SetFailureHandler sets the function called after each failed or partially
+// successful run, e.g. to start remediation workflows
+func SetFailureHandler(fn FailureHandler) {
+	failureHandler = fn
+}
+
+// defaultRetry is the retry policy of actions without a retry of their own
+var defaultRetry *workflow.RetryConfig
+
+// SetDefaultRetry sets the retry policy of actions that don't set 'retry',
+// e.g. from the agent configuration; nil means they run once
+func SetDefaultRetry(cfg *workflow.RetryConfig) {
+	defaultRetry = cfg
+}
+
+// asyncActions tracks actions started with runAsync so shutdown can wait for them
+var asyncActions sync.WaitGroup
+
+// runState is shared between a workflow run and its async actions, which may
+// finish after the run has been completed in the database
+type runState struct {
+	async    sync.WaitGroup // async actions of this run
+	mu       sync.Mutex
+	asyncErr string // first error of an async action
+
+	// The first error of an async action with continueOnError
+	asyncTolerated string
+
+	// The run's result. Its status is empty until the run has been
+	// recorded; actions holds the result of each action by index, nil for
+	// actions that didn't run.
+	result  workflow.ExecutionResult
+	actions []*workflow.ActionResult
+}
+
+// Execute runs every action of a workflow once, in order, and records the
+// outcome in the database, Prometheus metrics and the workflow registry.
+// It returns the final workflow status, one of the workflow.Status constants:
+// success, failed, or timeout if the failed action ran out of time;
+// partial-success if only actions with continueOnError failed; skipped and
+// cancelled when the workflow's concurrency policy stopped the run, or blocked
+// when a service it depends on is unhealthy or its circuit breaker is open.
+// While the agent is in maintenance mode runs are skipped, or deferred until
+// it ends. Runs are skipped once Drain was called, and aborted if the agent
+// shuts down before they finish.
+func Execute(wf *workflow.Workflow, triggerType string) string {
+	return ExecuteWithData(wf, triggerType, nil)
+}
+
+// ExecuteWithData is like Execute but makes data available to action
+// templates, e.g. {{ .payload.version }} for a manual trigger payload. The
+// trigger event is completed with the trigger type and exposed as {{ .event }}.
+func ExecuteWithData(wf *workflow.Workflow, triggerType string, data templating.Data) string {
+	status, _ := execute(wf, triggerType, data)
+	return status
+}
+
+// ExecuteAndWait is like ExecuteWithData but also waits for the run's async
+// actions and returns the final status, including their failures
+func ExecuteAndWait(wf *workflow.Workflow, triggerType string, data templating.Data) string {
+	return ExecuteAndSummarize(wf, triggerType, data).Status
+}
+
+func execute(wf *workflow.Workflow, triggerType string, data templating.Data) (string, *runState) {
+	// Maintenance mode suppresses every trigger except manual runs, which an
+	// operator may still need during the maintenance window, and runs started
+	// by a workflow action, which belong to a run that already got past it
+	if policy, ok := server.InMaintenance(); ok && triggerType != TriggerTypeManual && triggerType != TriggerTypeWorkflow {
+		status, reason := workflow.StatusSkipped, "skipped: agent in maintenance mode"
+		if policy == server.MaintenanceQueue && server.QueueMaintenanceRun(func() { ExecuteWithData(wf, triggerType, data) }) {
+			status, reason = workflow.StatusDeferred, "deferred: agent in maintenance mode, queued until it ends"
+		}
+		logger.L().Infow("Not running workflow, agent is in maintenance mode",
+			"workflow_name", wf.Name,
+			"trigger_type", triggerType,
+			"status", status)
+		return status, &runState{result: RecordNotStarted(wf, triggerType, data, status, reason)}
+	}
+
+	if unhealthy := health.Unhealthy(wf.DependsOnServices); len(unhealthy) > 0 {
+		logger.L().Warnw("Skipping workflow run, dependencies are unhealthy",
+			"workflow_name", wf.Name,
+			"trigger_type", triggerType,
+			"unhealthy_services", unhealthy)
+		reason := fmt.Sprintf("blocked: unhealthy dependencies: %s", strings.Join(unhealthy, ", "))
+		return workflow.StatusBlocked, &runState{result: RecordNotStarted(wf, triggerType, data, workflow.StatusBlocked, reason)}
+	}
+
+	// Runs are blocked while the circuit is open, except manual ones, which
+	// probe it like the run let through after the cooldown
+	breaker := circuitBreaker(wf)
+	if breaker != nil && triggerType != TriggerTypeManual && !breaker.Allow() {
+		logger.L().Warnw("Skipping workflow run, circuit breaker is open",
+			"workflow_name", wf.Name,
+			"trigger_type", triggerType,
+			"consecutive_failures", breaker.Failures())
+		reason := fmt.Sprintf("blocked: circuit breaker open after %d consecutive failures", breaker.Failures())
+		return workflow.StatusBlocked, &runState{result: RecordNotStarted(wf, triggerType, data, workflow.StatusBlocked, reason)}
+	}
+
+	ctx, release, err := startRun(wf, triggerType)
+	if err != nil {
+		if breaker != nil {
+			breaker.Release()
+		}
+		if errors.Is(err, errShuttingDown) {
+			logger.L().Warnw("Skipping workflow run, agent is shutting down",
+				"workflow_name", wf.Name,
+				"trigger_type", triggerType)
+		} else {
+			logger.L().Warnw("Skipping workflow run, previous run still in progress",
+				"workflow_name", wf.Name,
+				"trigger_type", triggerType,
+				"concurrency_policy", wf.ConcurrencyPolicy)
+		}
+		reason := "skipped: " + err.Error()
+		return workflow.StatusSkipped, &runState{result: RecordNotStarted(wf, triggerType, data, workflow.StatusSkipped, reason)}
+	}
+	defer release()
+
+	// Track workflow execution time
+	workflowStartTime := time.Now()
+	workflowStatus := workflow.StatusSuccess
+	var workflowError *string
+	var toleratedError *string // last failure of an action with continueOnError
+
+	triggerData := data // kept for the dead-letter queue if the run fails
+	// withTrigger returns a copy of data, so the run's keys are set on it
+	// without copying it again for each
+	data = withTrigger(data, triggerType, workflowStartTime)
+	data["workflow"] = workflowData(wf)
+
+	// Start workflow execution in database
+	workflowExecID, err := database.StartWorkflowExecution(wf.Name, triggerType, triggerSource(data))
+	if err != nil {
+		logger.L().Errorw("Failed to start workflow execution in database",
+			"workflow_name", wf.Name,
+			"error", err)
+	}
+
+	state := &runState{
+		result:  workflow.ExecutionResult{Workflow: wf.Name, TriggerType: triggerType, StartedAt: workflowStartTime},
+		actions: make([]*workflow.ActionResult, len(wf.Actions)),
+	}
+
+	// Results of the actions that ran, available to later actions and custom
+	// metrics as {{ .steps.<action>.stdout }}
+	steps := make(map[string]interface{}, len(wf.Actions))
+
+	// Values of HTTP responses captured with captureAs, available to later
+	// actions as {{ .captured.<name> }}
+	captured := make(map[string]interface{})
+
+	// No action runs if the vars they share can't be rendered
+	vars, varsErr := templating.RenderVars(wf.Vars, data)
+	if varsErr != nil {
+		logger.L().Errorw("Failed to render workflow vars",
+			"workflow_name", wf.Name,
+			"error", varsErr)
+		workflowStatus = workflow.StatusFailed
+		errMsg := varsErr.Error()
+		workflowError = &errMsg
+	}
+	data["vars"] = vars
+	// Action results are available as {{ .steps.<action> }} and {{ .actions.<action> }}
+	data["steps"] = steps
+	data["actions"] = steps
+	data["captured"] = captured
+
+	for i := range wf.Actions {
+		if ctx.Err() != nil || varsErr != nil {
+			break // replaced by a newer run or no vars; remaining actions don't run
+		}
+
+		act := &wf.Actions[i]
+		actionStartTime := time.Now()
+
+		if act.When != "" {
+			run, err := conditionMet(act, data)
+			if err != nil {
+				// A broken condition fails the action without running it
+				logger.L().Errorw("Failed to render action condition",
+					"workflow_name", wf.Name,
+					"action_name", act.Name,
+					"error", err)
+				errMsg := err.Error()
+				if act.ContinueOnError {
+					toleratedError = &errMsg
+				} else {
+					workflowStatus = workflow.StatusFailed
+					workflowError = &errMsg
+				}
+				state.mu.Lock()
+				state.actions[i] = newActionResult(act, "", err, 0, 0)
+				state.count(err)
+				state.mu.Unlock()
+				steps[act.Name] = StepResult("", err)
+				continue
+			}
+			if !run {
+				skipAction(wf, act, i, workflowExecID, steps, state)
+				continue
+			}
+		}
+
+		// Start action execution in database
+		var actionExecID int64
+		if workflowExecID > 0 {
+			actionExecID, err = database.StartActionExecution(workflowExecID, act.Name, act.Type.String())
+			if err != nil {
+				logger.L().Errorw("Failed to start action execution in database",
+					"workflow_name", wf.Name,
+					"action_name", act.Name,
+					"error", err)
+			}
+		}
+
+		if act.RunAsync {
+			asyncActions.Add(1)
+			state.async.Add(1)
+			// Async actions see the steps finished and values captured so
+			// far; the maps keep changing
+			finished := copySteps(steps)
+			asyncData := withData(withData(withData(data, "steps", finished), "actions", finished), "captured", copySteps(captured))
+			go runAsyncAction(ctx, wf, act, i, asyncData, workflowExecID, actionExecID, state)
+			continue
+		}
+
+		attempts := 0
+		output, actionError := runAction(ctx, wf, act, i, data, actionExecID, &attempts)
+		result := newActionResult(act, output, actionError, time.Since(actionStartTime), max(attempts, 1))
+		state.mu.Lock()
+		state.actions[i] = result
+		state.count(actionError)
+		state.mu.Unlock()
+		if actionError != nil {
+			errMsg := actionError.Error()
+			if act.ContinueOnError {
+				toleratedError = &errMsg
+			} else {
+				workflowStatus = action.Status(actionError)
+				workflowError = &errMsg
+			}
+		}
+		step := StepResult(output, actionError)
+		if actionError == nil {
+			CaptureResponse(act, output, step, captured)
+		}
+		steps[act.Name] = step
+
+		// Complete action execution in database
+		if actionExecID > 0 {
+			recordActionExecution(wf.Name, actionExecID, result)
+		}
+	}
+
+	if errors.Is(context.Cause(ctx), errAborted) {
+		logger.L().Warnw("Workflow run aborted, agent shut down before it finished",
+			"workflow_name", wf.Name)
+		workflowStatus = workflow.StatusAborted
+		errMsg := errAborted.Error()
+		workflowError = &errMsg
+	} else if ctx.Err() != nil {
+		logger.L().Warnw("Workflow run cancelled by a newer run",
+			"workflow_name", wf.Name,
+			"concurrency_policy", wf.ConcurrencyPolicy)
+		workflowStatus = workflow.StatusCancelled
+		errMsg := "run cancelled: replaced by a newer run"
+		workflowError = &errMsg
+	}
+
+	// Async actions that already failed count against the run. The lock is
+	// held until the run is recorded so later failures update the final record.
+	state.mu.Lock()
+	if state.asyncErr != "" && workflowStatus == workflow.StatusSuccess {
+		workflowStatus = workflow.StatusFailed
+		errMsg := state.asyncErr
+		workflowError = &errMsg
+	}
+	if toleratedError == nil && state.asyncTolerated != "" {
+		toleratedError = &state.asyncTolerated
+	}
+	if toleratedError != nil && workflowStatus == workflow.StatusSuccess {
+		workflowStatus = workflow.StatusPartialSuccess
+		workflowError = toleratedError
+	}
+
+	errorMsg := ""
+	if workflowError != nil {
+		errorMsg = *workflowError
+	}
+	state.result.Status = workflowStatus
+	state.result.Error = errorMsg
+	state.result.Duration = time.Since(workflowStartTime)
+	result := state.snapshot()
+
+	// Record the result in the metrics, the database and the registry
+	metrics.RecordWorkflowExecution(&result)
+	if workflowExecID > 0 {
+		if err := database.CompleteWorkflowExecution(workflowExecID, &result); err != nil {
+			logger.L().Errorw("Failed to complete workflow execution in database",
+				"workflow_name", wf.Name,
+				"workflow_exec_id", workflowExecID,
+				"error", err)
+		}
+	}
+	server.GetRegistry().UpdateExecutionStats(&result)
+	state.mu.Unlock()
+
+	// Failed runs go to the dead-letter queue; a replay records its outcome
+	// on the dead letter it replays instead
+	if triggerType == TriggerTypeReplay {
+		recordReplay(wf, data, workflowExecID, workflowStatus)
+	} else if workflow.IsFailure(workflowStatus) && workflowExecID > 0 {
+		deadLetter(wf, triggerType, workflowExecID, triggerData, steps, state)
+	}
+
+	if breaker != nil {
+		recordCircuit(wf, breaker, workflowStatus)
+	}
+
+	recordCustomMetrics(wf, withData(data, "status", workflowStatus))
+
+	degraded := workflowStatus == workflow.StatusPartialSuccess
+	if (workflow.IsFailure(workflowStatus) || degraded) && failureHandler != nil {
+		failureHandler(wf, triggerType, workflowExecID, workflowStatus, errorMsg)
+	}
+
+	return workflowStatus, state
+}
+
+// RecordNotStarted records a run that was triggered but didn't start, e.g.
+// because it was throttled, with its status and the reason it didn't start.
+// The run shows up in the history, metrics and registry like any other.
+func RecordNotStarted(wf *workflow.Workflow, triggerType string, data templating.Data, status, reason string) workflow.ExecutionResult {
+	result := workflow.ExecutionResult{
+		Workflow:    wf.Name,
+		TriggerType: triggerType,
+		Status:      status,
+		Error:       reason,
+		StartedAt:   time.Now(),
+	}
+	metrics.RecordWorkflowNotStarted(wf.Name, status)
+	server.GetRegistry().UpdateExecutionStats(&result)
+
+	source := triggerSource(withTrigger(data, triggerType, result.StartedAt))
+	workflowExecID, err := database.StartWorkflowExecution(wf.Name, triggerType, source)
+	if err != nil {
+		logger.L().Errorw("Failed to start workflow execution in database",
+			"workflow_name", wf.Name,
+			"error", err)
+		return result
+	}
+	if err := database.CompleteWorkflowExecution(workflowExecID, &result); err != nil {
+		logger.L().Errorw("Failed to complete workflow execution in database",
+			"workflow_name", wf.Name,
+			"workflow_exec_id", workflowExecID,
+			"error", err)
+	}
+	return result
+}
+
+// ExecuteAction renders and runs a single action of wf without recording it,
+// for tools that drive a workflow step by step such as the debugger
+func ExecuteAction(wf *workflow.Workflow, act *workflow.Action, index int, data templating.Data) (string, error) {
+	return runAction(context.Background(), wf, act, index, data, 0, nil)
+}
+
+// StepResult is the value stored under {{ .steps.<action> }} for a finished
+// action. Its exitCode is that of a bash command, or 0 if the action succeeded
+// and -1 if it failed otherwise.
+func StepResult(output string, err error) map[string]interface{} {
+	step := map[string]interface{}{"stdout": strings.TrimSpace(output), "status": action.Status(err), "error": "", "exitCode": 0}
+	if err != nil {
+		step["error"] = err.Error()
+		step["exitCode"] = -1
+		var exitErr *retry.ExitCodeError
+		if errors.As(err, &exitErr) {
+			step["exitCode"] = exitErr.ExitCode
+		}
+	}
+	return step
+}
+
+// conditionMet renders an action's when condition and reports whether the
+// action runs: unless it renders empty, false, 0 or no
+func conditionMet(act *workflow.Action, data templating.Data) (bool, error) {
+	rendered, err := templating.Render(act.Name+".when", act.When, data)
+	if err != nil {
+		return false, fmt.Errorf("action %s has invalid 'when': %w", act.Name, err)
+	}
+	switch strings.ToLower(strings.TrimSpace(rendered)) {
+	case "", "false", "0", "no", "<no value>":
+		return false, nil
+	}
+	return true, nil
+}
+
+// skipAction records an action whose when condition was false as skipped. It
+// counts as neither succeeded nor failed.
+func skipAction(wf *workflow.Workflow, act *workflow.Action, index int, workflowExecID int64, steps map[string]interface{}, state *runState) {
+	logger.L().Infow("Skipping action, its condition is false",
+		"workflow_name", wf.Name,
+		"action_name", act.Name,
+		"action_index", index,
+		"when", act.When)
+
+	result := &workflow.ActionResult{Name: act.Name, Type: act.Type, Status: workflow.StatusSkipped, Async: act.RunAsync}
+	state.mu.Lock()
+	state.actions[index] = result
+	state.mu.Unlock()
+	steps[act.Name] = map[string]interface{}{"stdout": "", "status": workflow.StatusSkipped, "error": "", "exitCode": 0}
+
+	if workflowExecID > 0 {
+		actionExecID, err := database.StartActionExecution(workflowExecID, act.Name, act.Type.String())
+		if err == nil {
+			err = database.CompleteActionExecution(actionExecID, result)
+		}
+		if err != nil {
+			logger.L().Errorw("Failed to record skipped action in database",
+				"workflow_name", wf.Name,
+				"action_name", act.Name,
+				"error", err)
+		}
+	}
+}
+
+// CaptureResponse adds the JSON response of an HTTP action with jsonPath, jq
+// or captureAs to its step result as {{ .steps.<action>.json }} and the
+// extracted value as {{ .steps.<action>.value }}, and stores the value in
+// captured under the action's captureAs name
+func CaptureResponse(act *workflow.Action, output string, step, captured map[string]interface{}) {
+	if act.Type != workflow.ActionTypeHTTP || (act.ResponsePath() == "" && act.CaptureAs == "") {
+		return
+	}
+	doc, value, err := action.ExtractJSON(act, output)
+	if err != nil {
+		return // the action has already failed on it
+	}
+	step["json"] = doc
+	step["value"] = value
+	if act.CaptureAs != "" {
+		captured[act.CaptureAs] = value
+	}
+}
+
+// workflowData returns the workflow's metadata available to templates as
+// {{ .workflow.<field> }}, e.g. to link a failure alert to the runbook
+func workflowData(wf *workflow.Workflow) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        wf.Name,
+		"description": wf.Description,
+		"owner":       wf.Owner,
+		"docsUrl":     wf.DocsURL,
+		"runbookUrl":  wf.RunbookURL,
+	}
+}
+
+// withData returns a copy of data with key set to value
+func withData(data templating.Data, key string, value interface{}) templating.Data {
+	out := make(templating.Data, len(data)+1)
+	for k, v := range data {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+func copySteps(steps map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(steps))
+	for k, v := range steps {
+		out[k] = v
+	}
+	return out
+}
+
+// recordCustomMetrics emits the workflow's custom metrics. Values and labels
+// are rendered with the run data, including {{ .steps }} and {{ .status }}.
+// Errors are logged and never fail the run.
+func recordCustomMetrics(wf *workflow.Workflow, data templating.Data) {
+	if len(wf.Metrics) == 0 {
+		return
+	}
+
+	for _, m := range wf.Metrics {
+		if err := recordCustomMetric(wf.Name, m, data); err != nil {
+			logger.L().Errorw("Failed to record custom metric",
+				"workflow_name", wf.Name,
+				"metric", m.Name,
+				"error", err)
+		}
+	}
+}
+
+func recordCustomMetric(workflowName string, m workflow.MetricConfig, data templating.Data) error {
+	value := 1.0
+	if m.ValueFrom != "" {
+		rendered, err := templating.Render("metrics."+m.Name, m.ValueFrom, data)
+		if err != nil {
+			return err
+		}
+		value, err = strconv.ParseFloat(strings.TrimSpace(rendered), 64)
+		if err != nil {
+			return fmt.Errorf("value '%s' is not a number", strings.TrimSpace(rendered))
+		}
+	}
+
+	labels := make(map[string]string, len(m.Labels))
+	for name, tmpl := range m.Labels {
+		rendered, err := templating.Render("metrics."+m.Name+".labels."+name, tmpl, data)
+		if err != nil {
+			return err
+		}
+		labels[name] = rendered
+	}
+
+	return metrics.RecordCustomMetric(workflowName, m.Name, m.Help, m.Type, value, labels)
+}
+
+// runAsyncAction executes an action marked runAsync in the background. Its
+// outcome is recorded against the execution: if the run has already completed
+// a failure marks it as failed afterwards, or as partial-success for an action
+// with continueOnError.
+func runAsyncAction(ctx context.Context, wf *workflow.Workflow, act *workflow.Action, index int, data templating.Data, workflowExecID, actionExecID int64, state *runState) {
+	defer asyncActions.Done()
+	defer state.async.Done()
+
+	startTime := time.Now()
+	attempts := 0
+	output, actionError := runAction(ctx, wf, act, index, data, actionExecID, &attempts)
+	result := newActionResult(act, output, actionError, time.Since(startTime), max(attempts, 1))
+
+	if actionExecID > 0 {
+		recordActionExecution(wf.Name, actionExecID, result)
+	}
+
+	errMsg := ""
+	if actionError != nil {
+		errMsg = actionError.Error()
+		logger.L().Errorw("Async action failed",
+			"workflow_name", wf.Name,
+			"action_name", act.Name,
+			"action_index", index,
+			"error", errMsg)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.actions[index] = result
+	state.count(actionError)
+	if actionError != nil {
+		if act.ContinueOnError && state.asyncTolerated == "" {
+			state.asyncTolerated = errMsg
+		} else if !act.ContinueOnError && state.asyncErr == "" {
+			state.asyncErr = errMsg
+		}
+	}
+
+	// Before completion the run picks the outcome up itself
+	if state.result.Status == "" {
+		return
+	}
+
+	// A late failure downgrades the run: a failed run stays failed with its
+	// original error
+	previous := state.result.Status
+	if actionError != nil {
+		switch {
+		case act.ContinueOnError && previous == workflow.StatusSuccess:
+			state.result.Status = workflow.StatusPartialSuccess
+		case !act.ContinueOnError && (previous == workflow.StatusSuccess || previous == workflow.StatusPartialSuccess):
+			state.result.Status = workflow.StatusFailed
+		}
+	}
+
+	var newError *string
+	if state.result.Status != previous {
+		newError = &errMsg
+		state.result.Error = errMsg
+		server.GetRegistry().RecordLateStatus(wf.Name, previous, state.result.Status, errMsg)
+	}
+	if workflowExecID > 0 {
+		if err := database.RecordLateAction(workflowExecID, actionError != nil, state.result.Status, newError); err != nil {
+			logger.L().Errorw("Failed to record late async action",
+				"workflow_name", wf.Name,
+				"workflow_exec_id", workflowExecID,
+				"error", err)
+		}
+	}
+}
+
+// count counts a finished action; the caller holds s.mu
+func (s *runState) count(err error) {
+	if err != nil {
+		s.result.ActionsFailed++
+	} else {
+		s.result.ActionsSucceeded++
+	}
+}
+
+// snapshot returns the run's result with the actions that ran so far; the
+// caller holds s.mu
+func (s *runState) snapshot() workflow.ExecutionResult {
+	result := s.result
+	result.Actions = make([]workflow.ActionResult, 0, len(s.actions))
+	for _, act := range s.actions {
+		if act != nil {
+			result.Actions = append(result.Actions, *act)
+		}
+	}
+	return result
+}
+
+// WaitForAsyncActions waits up to timeout for running async actions to finish.
+// It returns false if some were still running when the timeout expired.
+func WaitForAsyncActions(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		asyncActions.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// executeAction renders the action's templates, dispatches it to its
+// implementation and returns the captured output together with any execution
+// error. Cancelling ctx stops a running bash command. attempts, if not nil,
+// counts the tries of actions that retry.
+func executeAction(ctx context.Context, wf *workflow.Workflow, act *workflow.Action, index int, data templating.Data, actionExecID int64, attempts *int) (string, error) {
+	rendered, err := templating.RenderAction(act, data)
+	if err != nil {
+		logger.L().Errorw("Failed to render action templates",
+			"workflow_name", wf.Name,
+			"action_name", act.Name,
+			"action_index", index,
+			"error", err)
+		return "", err
+	}
+	act = rendered
+	act.Env = eventEnv(wf.Name, data)
+	if item, ok := data["item"].(string); ok {
+		act.Env = append(act.Env, "AUTOZAP_ITEM="+item)
+	}
+	act.Network = wf.Network
+	act.Attempts = attempts
+	if act.Retry == nil {
+		act.Retry = defaultRetry
+	}
+
+	if act.Type == workflow.ActionTypeBash {
+		if act.ScriptFile != "" {
+			if err := loadScript(wf, act, index, actionExecID); err != nil {
+				return "", err
+			}
+		}
+		// The items of a forEach share one execution record, so only single
+		// runs save their output while running
+		if _, isItem := data["item"]; !isItem && actionExecID > 0 {
+			act.OnOutput = func(output string) {
+				if err := database.SetActionOutput(actionExecID, output); err != nil {
+					logger.L().Warnw("Failed to save output of running action",
+						"workflow_name", wf.Name,
+						"action_name", act.Name,
+						"action_exec_id", actionExecID,
+						"error", err)
+				}
+			}
+		}
+	}
+
+	logger.L().Infow("Attempting to execute action",
+		"workflow_name", wf.Name,
+		"action_name", act.Name,
+		"action_index", index,
+		"action_type", act.Type.String())
+	result, err := action.Execute(ctx, act, action.ExecContext{
+		WorkflowName: wf.Name,
+		RunWorkflow: func(ctx context.Context, act *workflow.Action) (string, error) {
+			return runWorkflowAction(ctx, wf, act, data)
+		},
+	})
+	if err != nil {
+		logger.L().Errorw("Failed to execute action",
+			"workflow_name", wf.Name,
+			"action_name", act.Name,
+			"action_index", index,
+			"action_type", act.Type.String(),
+			"error", err)
+	}
+	return result.Output, err
+}
+
+// loadScript reads the script file of a bash action into its command, so the
+// current version of the script runs, and records the script's hash
+func loadScript(wf *workflow.Workflow, act *workflow.Action, index int, actionExecID int64) error {
+	content, hash, err := action.LoadScript(act.ScriptFile)
+	if err != nil {
+		logger.L().Errorw("Failed to load script file",
+			"workflow_name", wf.Name,
+			"action_name", act.Name,
+			"action_index", index,
+			"script_file", act.ScriptFile,
+			"error", err)
+		return err
+	}
+	act.Command = content
+
+	logger.L().Infow("Loaded script file",
+		"workflow_name", wf.Name,
+		"action_name", act.Name,
+		"action_index", index,
+		"script_file", act.ScriptFile,
+		"script_sha256", hash)
+
+	if actionExecID > 0 {
+		if err := database.SetActionScriptHash(actionExecID, hash); err != nil {
+			logger.L().Errorw("Failed to record script hash in database",
+				"workflow_name", wf.Name,
+				"action_name", act.Name,
+				"action_exec_id", actionExecID,
+				"error", err)
+		}
+	}
+	return nil
+}
+
+// recordActionExecution stores the result of a single action in the database
+func recordActionExecution(workflowName string, actionExecID int64, result *workflow.ActionResult) {
+	if err := database.CompleteActionExecution(actionExecID, result); err != nil {
+		logger.L().Errorw("Failed to complete action execution in database",
+			"workflow_name", workflowName,
+			"action_name", result.Name,
+			"action_exec_id", actionExecID,
+			"error", err)
+	}
+}
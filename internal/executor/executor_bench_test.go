@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Benchmarks of the per-run hot path, which matters for workflows triggered
+// every second or faster. Every run logs to stderr, which go test mixes into
+// the results, so run the test binary directly:
+//
+//	go test -c -o executor.test ./internal/executor
+//	./executor.test -test.run '^$' -test.bench . -test.benchmem 2>/dev/null
+
+func BenchmarkExecute(b *testing.B) {
+	if err := database.InitDB(filepath.Join(b.TempDir(), "bench.db")); err != nil {
+		b.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDB()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	benchmarks := []struct {
+		name    string
+		actions []workflow.Action
+	}{
+		{"Bash", []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "check", Command: "echo ok"},
+		}},
+		{"HTTP", []workflow.Action{
+			{Type: workflow.ActionTypeHTTP, Name: "ping", URL: server.URL, Method: "GET", ExpectStatus: 200},
+		}},
+		{"Templated Steps", []workflow.Action{
+			{Type: workflow.ActionTypeHTTP, Name: "ping", URL: server.URL + "/{{ .workflow.name }}", Method: "GET", ExpectStatus: 200},
+			{Type: workflow.ActionTypeHTTP, Name: "report", URL: server.URL + "/report", Method: "POST",
+				Headers: map[string]string{"X-Run": "{{ .event.trigger }}"},
+				Body:    `{"ping": "{{ .steps.ping.status }}", "body": {{ printf "%q" .steps.ping.stdout }}}`},
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		wf := &workflow.Workflow{Name: "bench-" + strings.ToLower(strings.ReplaceAll(bm.name, " ", "-")), Actions: bm.actions}
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if status := ExecuteWithData(wf, "cron", WithEvent(nil, Event{})); status != workflow.StatusSuccess {
+					b.Fatalf("Expected success, got %s", status)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRenderAction(b *testing.B) {
+	act := &workflow.Action{
+		Type:    workflow.ActionTypeHTTP,
+		Name:    "report",
+		URL:     "https://api.example.com/{{ .vars.env }}/report",
+		Method:  "POST",
+		Headers: map[string]string{"Authorization": "Bearer {{ .vars.token }}", "Content-Type": "application/json"},
+		Body:    `{"workflow": "{{ .workflow.name }}", "status": "{{ .steps.check.status }}"}`,
+	}
+	data := templating.Data{
+		"vars":     map[string]string{"env": "prod", "token": "secret"},
+		"workflow": map[string]interface{}{"name": "bench"},
+		"steps":    map[string]interface{}{"check": StepResult("ok", nil)},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := templating.RenderAction(act, data); err != nil {
+			b.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+}
@@ -0,0 +1,1288 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/health"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+func TestExecute(t *testing.T) {
+	t.Run("All Actions Succeed", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-success",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "first", Command: "echo first"},
+				{Type: workflow.ActionTypeBash, Name: "second", Command: "echo second"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Failing Action Marks Workflow Failed", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-failure",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "ok", Command: "true"},
+				{Type: workflow.ActionTypeBash, Name: "broken", Command: "exit 1"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "failed" {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+	})
+
+	t.Run("Unsupported Action Type", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-unsupported",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionType("unknown"), Name: "mystery"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "failed" {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+	})
+
+	t.Run("Timed Out Action Marks Workflow Timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(500 * time.Millisecond)
+		}))
+		defer slow.Close()
+
+		wf := &workflow.Workflow{
+			Name: "test-timeout",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeHTTP, Name: "slow", URL: slow.URL, Method: "GET", Timeout: "50ms"},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusTimeout {
+			t.Errorf("Expected status '%s', got '%s'", workflow.StatusTimeout, summary.Status)
+		}
+		if len(summary.Actions) != 1 || summary.Actions[0].Status != workflow.StatusTimeout {
+			t.Errorf("Expected a timed out step, got %+v", summary.Actions)
+		}
+	})
+}
+
+func TestExecuteWithData(t *testing.T) {
+	wf := &workflow.Workflow{
+		Name: "test-payload",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "check-version", Command: `test "{{ .payload.version }}" = "1.4.2"`},
+		},
+	}
+
+	t.Run("Payload Rendered Into Actions", func(t *testing.T) {
+		data := map[string]interface{}{"payload": map[string]interface{}{"version": "1.4.2"}}
+		if status := ExecuteWithData(wf, TriggerTypeManual, data); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Different Payload", func(t *testing.T) {
+		data := map[string]interface{}{"payload": map[string]interface{}{"version": "0.0.1"}}
+		if status := ExecuteWithData(wf, TriggerTypeManual, data); status != "failed" {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+	})
+}
+
+func TestRawAction(t *testing.T) {
+	wf := &workflow.Workflow{
+		Name: "test-raw",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "format", Command: `echo '{{.Names}}'`, Raw: true},
+			{Type: workflow.ActionTypeBash, Name: "templated", Command: `echo '{{.Names}}'`},
+		},
+	}
+
+	result := ExecuteAndSummarize(wf, TriggerTypeManual, nil)
+	if len(result.Actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(result.Actions))
+	}
+	if out := strings.TrimSpace(result.Actions[0].Output); out != "{{.Names}}" {
+		t.Errorf("Expected the raw command to run unchanged, got %q", out)
+	}
+	if templated := result.Actions[1]; templated.Status != workflow.StatusFailed {
+		t.Errorf("Expected the templated command to fail on the missing key, got %+v", templated)
+	}
+}
+
+// TestCounterIncrementedOncePerRun checks that kv.incr in a command counts
+// runs, not renders or retries
+func TestCounterIncrementedOncePerRun(t *testing.T) {
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDB()
+
+	marker := filepath.Join(t.TempDir(), "failed-once")
+	wf := &workflow.Workflow{
+		Name: "test-counter",
+		Actions: []workflow.Action{
+			{
+				Type:    workflow.ActionTypeBash,
+				Name:    "count",
+				When:    "true",
+				Command: "echo {{ kv.incr \"runs\" }}; test -f " + marker + " || { touch " + marker + "; exit 1; }",
+				Retry:   &workflow.RetryConfig{MaxAttempts: 2, InitialDelay: "10ms"},
+			},
+		},
+	}
+
+	result := ExecuteAndSummarize(wf, TriggerTypeManual, nil)
+	if step := result.Actions[0]; step.Status != workflow.StatusSuccess || step.Retries() != 1 {
+		t.Fatalf("Expected count to succeed after a retry, got %+v", step)
+	}
+	if value, _, err := database.GetKV("runs"); err != nil || value != "1" {
+		t.Errorf("Expected the counter to be incremented once, got '%s' (err: %v)", value, err)
+	}
+}
+
+func TestWorkflowVars(t *testing.T) {
+	t.Run("Shared By Actions", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-vars",
+			Vars: map[string]string{"version": "{{ .payload.version }}", "dir": "/opt/app"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "check-version", Command: `test "{{ .vars.version }}" = "1.4.2"`},
+				{Type: workflow.ActionTypeBash, Name: "check-dir", Command: `test "{{ .vars.dir }}" = "/opt/app"`},
+			},
+		}
+		data := map[string]interface{}{"payload": map[string]interface{}{"version": "1.4.2"}}
+		if status := ExecuteWithData(wf, TriggerTypeManual, data); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Workflow Metadata", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:       "test-vars-metadata",
+			Owner:      "team-platform",
+			RunbookURL: "https://wiki.example.com/runbooks/backups",
+			Vars:       map[string]string{"alert": "{{ .workflow.name }} failed, owner {{ .workflow.owner }}"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "check-alert", Command: `test "{{ .vars.alert }}" = "test-vars-metadata failed, owner team-platform"`},
+				{Type: workflow.ActionTypeBash, Name: "check-runbook", Command: `test "{{ .workflow.runbookUrl }}" = "https://wiki.example.com/runbooks/backups"`},
+			},
+		}
+		if status := ExecuteWithData(wf, TriggerTypeManual, nil); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Invalid Var Fails Run", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "ran")
+		wf := &workflow.Workflow{
+			Name: "test-vars-invalid",
+			Vars: map[string]string{"broken": "{{ .missing.field }"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "touch " + out},
+			},
+		}
+		summary := ExecuteAndSummarize(wf, TriggerTypeManual, nil)
+		if summary.Status != "failed" || len(summary.Actions) != 0 {
+			t.Errorf("Expected a failed run without steps, got %+v", summary)
+		}
+		if _, err := os.Stat(out); err == nil {
+			t.Error("Expected no action to run")
+		}
+	})
+}
+
+func TestEventData(t *testing.T) {
+	fired := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := WithEvent(nil, Event{
+		Type:    "create",
+		Path:    "/data/in/report.csv",
+		Files:   []string{"/data/in/report.csv"},
+		Time:    fired,
+		Payload: map[string]interface{}{"version": "1.4.2"},
+	})
+
+	t.Run("Event Exposed To Templates", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-event-template",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "check", Command: `test "{{ .event.trigger }}:{{ .event.type }}:{{ .event.path }}:{{ .event.time }}" = "filewatch:create:/data/in/report.csv:2026-03-01T12:00:00Z"`},
+			},
+		}
+		if status := ExecuteWithData(wf, "filewatch", data); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Event Exposed As Environment", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-event-env",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "path", Command: `test "$AUTOZAP_EVENT_PATH" = /data/in/report.csv`},
+				{Type: workflow.ActionTypeBash, Name: "type", Command: `test "$AUTOZAP_EVENT_TYPE:$AUTOZAP_TRIGGER_TYPE" = create:filewatch`},
+				{Type: workflow.ActionTypeBash, Name: "payload", Command: `test "$AUTOZAP_EVENT_PAYLOAD" = '{"version":"1.4.2"}'`},
+				{Type: workflow.ActionTypeBash, Name: "workflow", Command: `test "$AUTOZAP_WORKFLOW" = test-event-env`},
+			},
+		}
+		if status := ExecuteWithData(wf, "filewatch", data); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Fire Time Defaults To Run Start", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-event-default",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "time", Command: `test -n "$AUTOZAP_EVENT_TIME" && test -z "$AUTOZAP_EVENT_PATH" && test "$AUTOZAP_TRIGGER_TYPE" = cron`},
+			},
+		}
+		if status := Execute(wf, "cron"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Trigger Source", func(t *testing.T) {
+		data := WithEvent(nil, Event{
+			Type:   "create",
+			Path:   "/data/in/report.csv",
+			Files:  []string{"/data/in/a.csv", "/data/in/b.csv"},
+			Time:   fired,
+			Source: map[string]string{"remote_addr": "10.0.0.5:5123"},
+		})
+		source := triggerSource(withTrigger(data, "filewatch", time.Now()))
+
+		expected := map[string]string{
+			"time":        "2026-03-01T12:00:00Z",
+			"path":        "/data/in/report.csv",
+			"event":       "create",
+			"files":       "2",
+			"remote_addr": "10.0.0.5:5123",
+		}
+		if len(source) != len(expected) {
+			t.Fatalf("Expected source %v, got %v", expected, source)
+		}
+		for k, v := range expected {
+			if source[k] != v {
+				t.Errorf("Expected source %s '%s', got '%s'", k, v, source[k])
+			}
+		}
+	})
+}
+
+func TestStepOutputPipedToStdin(t *testing.T) {
+	wf := &workflow.Workflow{
+		Name: "test-stdin",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "fetch", Command: "echo 'id,name'; echo '1,alice'"},
+			{Type: workflow.ActionTypeBash, Name: "check", Command: `grep -q alice`, Stdin: "{{ .steps.fetch.stdout }}"},
+		},
+	}
+
+	if status := Execute(wf, "manual"); status != "success" {
+		t.Errorf("Expected status 'success', got '%s'", status)
+	}
+}
+
+func TestActionConditions(t *testing.T) {
+	t.Run("Exit Code And Stdout Of Earlier Actions", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		wf := &workflow.Workflow{
+			Name: "test-action-results",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "check", Command: "echo ' degraded '; exit 3", ContinueOnError: true},
+				{Type: workflow.ActionTypeBash, Name: "report", Command: "echo '{{ .actions.check.stdout }}:{{ .actions.check.exitCode }}' > " + out},
+			},
+		}
+
+		ExecuteAndSummarize(wf, "manual", nil)
+		got, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected report to run: %v", err)
+		}
+		if strings.TrimSpace(string(got)) != "degraded:3" {
+			t.Errorf("Expected 'degraded:3', got %q", got)
+		}
+	})
+
+	t.Run("When Skips Or Runs Actions", func(t *testing.T) {
+		dir := t.TempDir()
+		wf := &workflow.Workflow{
+			Name: "test-action-when",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "check", Command: "echo ok"},
+				{Type: workflow.ActionTypeBash, Name: "alert", Command: "touch " + filepath.Join(dir, "alert"), When: "{{ ne .actions.check.exitCode 0 }}"},
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "touch " + filepath.Join(dir, "record"), When: `{{ eq .actions.check.stdout "ok" }}`},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusSuccess || summary.ActionsSucceeded != 2 || summary.ActionsFailed != 0 {
+			t.Errorf("Expected success with 2 succeeded actions, got %+v", summary)
+		}
+		if len(summary.Actions) != 3 || summary.Actions[1].Status != workflow.StatusSkipped {
+			t.Errorf("Expected alert to be skipped, got %+v", summary.Actions)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "alert")); err == nil {
+			t.Error("Expected alert not to run")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "record")); err != nil {
+			t.Errorf("Expected record to run: %v", err)
+		}
+	})
+
+	t.Run("Invalid When Fails Action", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-action-when-invalid",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "broken", Command: "true", When: "{{ .actions.missing.exitCode | nosuchfunc }}"},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusFailed || summary.ActionsFailed != 1 {
+			t.Errorf("Expected a failed run, got %+v", summary)
+		}
+	})
+}
+
+func TestResponseCapture(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/orders"):
+			w.Write([]byte(`{"data": {"id": 9007199254740993, "items": [{"sku": "abc"}]}}`))
+		case r.URL.Path == "/pending":
+			w.Write([]byte(`{"data": {}}`))
+		case r.URL.Path == "/text":
+			w.Write([]byte("ok"))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("Captured Value Used By Later Actions", func(t *testing.T) {
+		received = nil
+		out := filepath.Join(t.TempDir(), "out")
+		wf := &workflow.Workflow{
+			Name: "test-capture",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeHTTP, Name: "create", URL: server.URL + "/orders", Method: "POST", JSONPath: ".data.id", CaptureAs: "orderId"},
+				{Type: workflow.ActionTypeHTTP, Name: "order", URL: server.URL + "/orders/{{ .captured.orderId }}", Method: "GET", CaptureAs: "order"},
+				{Type: workflow.ActionTypeBash, Name: "report", Command: "echo '{{ .steps.create.value }} {{ (index .captured.order.data.items 0).sku }}' > " + out},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != workflow.StatusSuccess {
+			t.Fatalf("Expected status 'success', got '%s'", status)
+		}
+		if len(received) != 2 || received[1] != "/orders/9007199254740993" {
+			t.Errorf("Expected the captured ID in the second request, got %v", received)
+		}
+		content, _ := os.ReadFile(out)
+		if got := strings.TrimSpace(string(content)); got != "9007199254740993 abc" {
+			t.Errorf("Expected '9007199254740993 abc', got '%s'", got)
+		}
+	})
+
+	t.Run("Missing Value Fails Action", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-capture-missing",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeHTTP, Name: "create", URL: server.URL + "/pending", Method: "POST", JSONPath: ".data.id"},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusFailed {
+			t.Fatalf("Expected status 'failed', got '%s'", summary.Status)
+		}
+		if !strings.Contains(summary.Error, "no value at '.data.id'") {
+			t.Errorf("Expected missing value error, got '%s'", summary.Error)
+		}
+	})
+
+	t.Run("Non-JSON Response Fails Action", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-capture-text",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeHTTP, Name: "ping", URL: server.URL + "/text", Method: "GET", CaptureAs: "ping"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != workflow.StatusFailed {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+	})
+}
+
+func TestForEach(t *testing.T) {
+	t.Run("Static Items", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		wf := &workflow.Workflow{
+			Name: "test-foreach-items",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "each", Command: "echo {{ .itemIndex }}={{ .item }} >> " + out,
+					ForEach: &workflow.ForEachConfig{Items: []string{"a", "b", "c"}}},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Fatalf("Expected status 'success', got '%s'", status)
+		}
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if string(content) != "0=a\n1=b\n2=c\n" {
+			t.Errorf("Expected items in order, got %q", content)
+		}
+	})
+
+	t.Run("Glob And Environment", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"one.csv", "two.csv", "skip.txt"} {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+		wf := &workflow.Workflow{
+			Name: "test-foreach-glob",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "each", Command: `basename "$AUTOZAP_ITEM"`,
+					ForEach: &workflow.ForEachConfig{Glob: dir + "/*.csv"}},
+				{Type: workflow.ActionTypeBash, Name: "check", Command: `[ "$(cat)" = "$(printf 'one.csv\ntwo.csv')" ]`,
+					Stdin: "{{ .steps.each.stdout }}"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Items From Earlier Output In Parallel", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-foreach-from",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "hosts", Command: "printf 'web1\n\nweb2\nweb3\n'"},
+				{Type: workflow.ActionTypeBash, Name: "ping", Command: "sleep 0.2; echo {{ .item }}",
+					ForEach: &workflow.ForEachConfig{From: "{{ .steps.hosts.stdout }}", MaxParallel: 3}},
+			},
+		}
+
+		start := time.Now()
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != "success" {
+			t.Fatalf("Expected status 'success', got %+v", summary)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected items to run in parallel, took %s", elapsed)
+		}
+	})
+
+	t.Run("Failed Items Fail Action", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-foreach-failure",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "each", Command: "[ {{ .item }} != bad ]",
+					ForEach: &workflow.ForEachConfig{Items: []string{"good", "bad", "fine"}}},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != "failed" || !strings.Contains(summary.Error, "item 'bad'") || strings.Contains(summary.Error, "item 'good'") {
+			t.Errorf("Expected only item 'bad' to fail, got %+v", summary)
+		}
+	})
+}
+
+func TestExecuteAsyncActions(t *testing.T) {
+	t.Run("Run Does Not Wait For Async Action", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-async",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "slow-notify", Command: "sleep 1", RunAsync: true},
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "true"},
+			},
+		}
+
+		start := time.Now()
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("Expected run to finish before the async action, took %v", elapsed)
+		}
+		if !WaitForAsyncActions(5 * time.Second) {
+			t.Error("Timed out waiting for async action")
+		}
+	})
+
+	t.Run("Async Failure During Run Fails Workflow", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-async-failure",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "broken", Command: "exit 1", RunAsync: true},
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "sleep 0.5"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "failed" {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+		WaitForAsyncActions(5 * time.Second)
+	})
+
+	t.Run("Execute And Wait Reports Late Async Failure", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-async-late-failure",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "slow-broken", Command: "sleep 0.3; exit 1", RunAsync: true},
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "true"},
+			},
+		}
+
+		if status := ExecuteAndWait(wf, "manual", nil); status != "failed" {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+	})
+}
+
+func TestContinueOnError(t *testing.T) {
+	t.Run("Tolerated Failure Marks Partial Success", func(t *testing.T) {
+		var handled string
+		SetFailureHandler(func(wf *workflow.Workflow, triggerType string, workflowExecID int64, status, errMsg string) {
+			handled = status
+		})
+		t.Cleanup(func() { SetFailureHandler(nil) })
+
+		wf := &workflow.Workflow{
+			Name: "test-partial",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "true"},
+				{Type: workflow.ActionTypeBash, Name: "optional", Command: "exit 1", ContinueOnError: true},
+				{Type: workflow.ActionTypeBash, Name: "after", Command: "true"},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusPartialSuccess || summary.Error == "" {
+			t.Errorf("Expected status 'partial-success' with an error, got %+v", summary)
+		}
+		if summary.ActionsSucceeded != 2 || summary.ActionsFailed != 1 {
+			t.Errorf("Expected 2 succeeded and 1 failed action, got %d and %d", summary.ActionsSucceeded, summary.ActionsFailed)
+		}
+		if handled != workflow.StatusPartialSuccess {
+			t.Errorf("Expected the failure handler to see 'partial-success', got '%s'", handled)
+		}
+	})
+
+	t.Run("Other Failure Still Fails Run", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-partial-failed",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "optional", Command: "exit 1", ContinueOnError: true},
+				{Type: workflow.ActionTypeBash, Name: "required", Command: "exit 2"},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusFailed || summary.ActionsFailed != 2 {
+			t.Errorf("Expected status 'failed' with 2 failed actions, got %+v", summary)
+		}
+	})
+
+	t.Run("Late Async Failure Marks Partial Success", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-partial-async",
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "notify", Command: "sleep 0.3; exit 1", RunAsync: true, ContinueOnError: true},
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "true"},
+			},
+		}
+
+		summary := ExecuteAndSummarize(wf, "manual", nil)
+		if summary.Status != workflow.StatusPartialSuccess || summary.ActionsSucceeded != 1 || summary.ActionsFailed != 1 {
+			t.Errorf("Expected status 'partial-success' with 1 failed action, got %+v", summary)
+		}
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "healthy")
+	wf := &workflow.Workflow{
+		Name:           "test-circuit",
+		CircuitBreaker: &workflow.CircuitBreakerConfig{FailureThreshold: 2, Cooldown: "200ms"},
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "check", Command: "test -f " + marker},
+		},
+	}
+
+	t.Run("Opens After Consecutive Failures", func(t *testing.T) {
+		for i, want := range []string{workflow.StatusFailed, workflow.StatusFailed, workflow.StatusBlocked} {
+			if status := Execute(wf, "cron"); status != want {
+				t.Fatalf("Run %d: expected status '%s', got '%s'", i+1, want, status)
+			}
+		}
+	})
+
+	t.Run("Manual Run Bypasses Open Circuit", func(t *testing.T) {
+		if status := Execute(wf, TriggerTypeManual); status != workflow.StatusFailed {
+			t.Errorf("Expected status 'failed', got '%s'", status)
+		}
+		if status := Execute(wf, "cron"); status != workflow.StatusBlocked {
+			t.Errorf("Expected the failed manual run to keep the circuit open, got '%s'", status)
+		}
+	})
+
+	t.Run("Successful Probe Closes Circuit", func(t *testing.T) {
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		time.Sleep(250 * time.Millisecond)
+
+		for i := 0; i < 2; i++ {
+			if status := Execute(wf, "cron"); status != workflow.StatusSuccess {
+				t.Errorf("Run %d: expected status 'success', got '%s'", i+1, status)
+			}
+		}
+	})
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "ran")
+	wf := &workflow.Workflow{
+		Name: "test-maintenance",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "record", Command: "echo {{ .event.trigger }} >> " + out},
+		},
+	}
+	enter := func(t *testing.T, policy string, ttl time.Duration) {
+		t.Helper()
+		if _, err := server.EnterMaintenance("test", policy, ttl); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		t.Cleanup(func() { server.ExitMaintenance() })
+	}
+
+	t.Run("Drop Policy Skips Runs", func(t *testing.T) {
+		os.Remove(out)
+		enter(t, server.MaintenanceDrop, time.Minute)
+
+		if status := Execute(wf, "cron"); status != workflow.StatusSkipped {
+			t.Errorf("Expected status 'skipped', got '%s'", status)
+		}
+		if _, err := os.Stat(out); err == nil {
+			t.Error("Expected the workflow not to run")
+		}
+		if status := Execute(wf, TriggerTypeManual); status != workflow.StatusSuccess {
+			t.Errorf("Expected manual runs to bypass maintenance, got '%s'", status)
+		}
+	})
+
+	t.Run("Queue Policy Runs After Maintenance", func(t *testing.T) {
+		os.Remove(out)
+		enter(t, server.MaintenanceQueue, time.Minute)
+
+		if status := Execute(wf, "cron"); status != workflow.StatusDeferred {
+			t.Errorf("Expected status 'deferred', got '%s'", status)
+		}
+		if queued := server.Maintenance().Queued; queued != 1 {
+			t.Errorf("Expected 1 queued run, got %d", queued)
+		}
+
+		server.ExitMaintenance()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if content, _ := os.ReadFile(out); strings.TrimSpace(string(content)) == "cron" {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Error("Expected the queued cron run to run after maintenance")
+	})
+
+	t.Run("Ends After TTL", func(t *testing.T) {
+		enter(t, server.MaintenanceDrop, 100*time.Millisecond)
+		time.Sleep(200 * time.Millisecond)
+
+		if _, ok := server.InMaintenance(); ok {
+			t.Fatal("Expected maintenance mode to end after its TTL")
+		}
+		if status := Execute(wf, "cron"); status != workflow.StatusSuccess {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+}
+
+func TestConcurrencyPolicy(t *testing.T) {
+	slowWorkflow := func(name string, policy workflow.ConcurrencyPolicy) *workflow.Workflow {
+		return &workflow.Workflow{
+			Name:              name,
+			ConcurrencyPolicy: policy,
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "slow", Command: "sleep 1"},
+				{Type: workflow.ActionTypeBash, Name: "after", Command: "true"},
+			},
+		}
+	}
+
+	// startFirst starts a run in the background and waits until it is in flight
+	startFirst := func(wf *workflow.Workflow) chan string {
+		first := make(chan string, 1)
+		go func() { first <- Execute(wf, "manual") }()
+		time.Sleep(200 * time.Millisecond)
+		return first
+	}
+
+	t.Run("Forbid Skips Overlapping Run", func(t *testing.T) {
+		wf := slowWorkflow("test-concurrency-forbid", workflow.ConcurrencyForbid)
+		first := startFirst(wf)
+
+		if status := Execute(wf, "manual"); status != workflow.StatusSkipped {
+			t.Errorf("Expected status '%s', got '%s'", workflow.StatusSkipped, status)
+		}
+		if status := <-first; status != "success" {
+			t.Errorf("Expected first run status 'success', got '%s'", status)
+		}
+
+		// Once the first run is done the workflow runs again
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Replace Cancels Running Run", func(t *testing.T) {
+		wf := slowWorkflow("test-concurrency-replace", workflow.ConcurrencyReplace)
+		first := startFirst(wf)
+
+		start := time.Now()
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+		if status := <-first; status != workflow.StatusCancelled {
+			t.Errorf("Expected first run status '%s', got '%s'", workflow.StatusCancelled, status)
+		}
+		// The first run's sleep was killed, so both runs fit in well under 2s
+		if elapsed := time.Since(start); elapsed >= 1800*time.Millisecond {
+			t.Errorf("Expected the first run to be cancelled, took %v", elapsed)
+		}
+	})
+
+	t.Run("Allow Runs Concurrently", func(t *testing.T) {
+		wf := slowWorkflow("test-concurrency-allow", "")
+		first := startFirst(wf)
+
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+		if status := <-first; status != "success" {
+			t.Errorf("Expected first run status 'success', got '%s'", status)
+		}
+	})
+}
+
+func TestDrain(t *testing.T) {
+	// Drain stops new runs for the rest of the process, so allow them again
+	t.Cleanup(func() {
+		inFlightMu.Lock()
+		draining = false
+		inFlightMu.Unlock()
+	})
+
+	sleeping := func(name, seconds string) *workflow.Workflow {
+		return &workflow.Workflow{
+			Name: name,
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "sleep", Command: "sleep " + seconds},
+			},
+		}
+	}
+	finishing, overrunning := sleeping("test-drain-finish", "0.5"), sleeping("test-drain-abort", "10")
+	finished, aborted := make(chan string, 1), make(chan string, 1)
+	go func() { finished <- Execute(finishing, "manual") }()
+	go func() { aborted <- Execute(overrunning, "manual") }()
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	if n := Drain(time.Second); n != 1 {
+		t.Errorf("Expected 1 aborted run, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed >= 3*time.Second {
+		t.Errorf("Expected the overrunning run to be aborted after the timeout, took %v", elapsed)
+	}
+	if status := <-finished; status != workflow.StatusSuccess {
+		t.Errorf("Expected status '%s', got '%s'", workflow.StatusSuccess, status)
+	}
+	if status := <-aborted; status != workflow.StatusAborted {
+		t.Errorf("Expected status '%s', got '%s'", workflow.StatusAborted, status)
+	}
+
+	// No new runs start once draining
+	if status := Execute(finishing, "manual"); status != workflow.StatusSkipped {
+		t.Errorf("Expected status '%s', got '%s'", workflow.StatusSkipped, status)
+	}
+}
+
+func TestDrainWorkflows(t *testing.T) {
+	sleeping := func(name, seconds string) *workflow.Workflow {
+		return &workflow.Workflow{
+			Name: name,
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "sleep", Command: "sleep " + seconds},
+			},
+		}
+	}
+	drained, other := sleeping("test-drain-workflows", "10"), sleeping("test-drain-workflows-other", "0.5")
+	aborted, finished := make(chan string, 1), make(chan string, 1)
+	go func() { aborted <- Execute(drained, "manual") }()
+	time.Sleep(200 * time.Millisecond)
+
+	go func() {
+		// Other workflows keep running while drained ones are drained
+		time.Sleep(100 * time.Millisecond)
+		finished <- Execute(other, "manual")
+	}()
+	if n := DrainWorkflows([]string{drained.Name}, time.Second); n != 1 {
+		t.Errorf("Expected 1 aborted run, got %d", n)
+	}
+	if status := <-aborted; status != workflow.StatusAborted {
+		t.Errorf("Expected status '%s', got '%s'", workflow.StatusAborted, status)
+	}
+	if status := <-finished; status != workflow.StatusSuccess {
+		t.Errorf("Expected the other workflow's status '%s', got '%s'", workflow.StatusSuccess, status)
+	}
+
+	// Once drained, the workflow runs again
+	quick := sleeping(drained.Name, "0")
+	if status := Execute(quick, "manual"); status != workflow.StatusSuccess {
+		t.Errorf("Expected status '%s' after draining, got '%s'", workflow.StatusSuccess, status)
+	}
+}
+
+func TestDependsOnServices(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	health.Start(ctx, []config.ServiceConfig{
+		{Name: "up", Type: config.ServiceCheckCommand, Command: "true"},
+		{Name: "down", Type: config.ServiceCheckCommand, Command: "false"},
+	})
+
+	t.Run("Healthy Dependencies Run", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:              "test-depends-healthy",
+			DependsOnServices: []string{"up"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "true"},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Errorf("Expected status 'success', got '%s'", status)
+		}
+	})
+
+	t.Run("Unhealthy Dependency Blocks Run", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "ran")
+		wf := &workflow.Workflow{
+			Name:              "test-depends-blocked",
+			DependsOnServices: []string{"up", "down"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "main", Command: "touch " + marker},
+			},
+		}
+
+		if status := Execute(wf, "manual"); status != workflow.StatusBlocked {
+			t.Errorf("Expected status '%s', got '%s'", workflow.StatusBlocked, status)
+		}
+		if _, err := os.Stat(marker); err == nil {
+			t.Error("Expected blocked run not to execute its actions")
+		}
+	})
+}
+
+// gatherValue returns the value of the sample of a gauge or counter with the given labels
+func gatherValue(t *testing.T, name string, labels map[string]string) (float64, bool) {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+	samples:
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if labels[lp.GetName()] != lp.GetValue() {
+					continue samples
+				}
+			}
+			if m.GetGauge() != nil {
+				return m.GetGauge().GetValue(), true
+			}
+			return m.GetCounter().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+func TestCustomMetrics(t *testing.T) {
+	wf := &workflow.Workflow{
+		Name: "test-metrics",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "count", Command: "echo 42"},
+		},
+		Metrics: []workflow.MetricConfig{
+			{Name: "test_files_processed", ValueFrom: "{{ .steps.count.stdout }}", Labels: map[string]string{"source": "inbox"}},
+			{Name: "test_runs_total", Type: "counter", Labels: map[string]string{"status": "{{ .status }}"}},
+		},
+	}
+
+	t.Run("Gauge From Step Output", func(t *testing.T) {
+		if status := Execute(wf, "manual"); status != "success" {
+			t.Fatalf("Expected status 'success', got '%s'", status)
+		}
+		value, ok := gatherValue(t, "test_files_processed", map[string]string{"workflow": "test-metrics", "source": "inbox"})
+		if !ok || value != 42 {
+			t.Errorf("Expected gauge value 42, got %v (found: %v)", value, ok)
+		}
+	})
+
+	t.Run("Counter Adds One Per Run", func(t *testing.T) {
+		Execute(wf, "manual")
+		value, ok := gatherValue(t, "test_runs_total", map[string]string{"workflow": "test-metrics", "status": "success"})
+		if !ok || value != 2 {
+			t.Errorf("Expected counter value 2, got %v (found: %v)", value, ok)
+		}
+	})
+}
+
+func TestExecuteAndSummarize(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "attempts")
+	wf := &workflow.Workflow{
+		Name: "test-summary",
+		Actions: []workflow.Action{
+			{
+				Type:    workflow.ActionTypeBash,
+				Name:    "flaky",
+				Command: "echo x >> " + counter + "; [ $(wc -l < " + counter + ") -ge 3 ]",
+				Retry:   &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "10ms"},
+			},
+			{Type: workflow.ActionTypeBash, Name: "notify", Command: "true", RunAsync: true},
+			{Type: workflow.ActionTypeBash, Name: "broken", Command: "exit 1"},
+		},
+	}
+
+	summary := ExecuteAndSummarize(wf, "manual", nil)
+	if summary.Status != "failed" || summary.Workflow != "test-summary" || summary.Error == "" {
+		t.Errorf("Unexpected run summary: %+v", summary)
+	}
+	if len(summary.Actions) != 3 {
+		t.Fatalf("Expected 3 actions, got %d", len(summary.Actions))
+	}
+
+	flaky, notify, broken := summary.Actions[0], summary.Actions[1], summary.Actions[2]
+	if flaky.Name != "flaky" || flaky.Status != "success" || flaky.Retries() != 2 {
+		t.Errorf("Expected flaky to succeed after 2 retries, got %+v", flaky)
+	}
+	if notify.Name != "notify" || notify.Status != "success" || !notify.Async || notify.Retries() != 0 {
+		t.Errorf("Unexpected async step: %+v", notify)
+	}
+	if broken.Name != "broken" || broken.Status != "failed" || broken.Error == "" {
+		t.Errorf("Expected broken to fail, got %+v", broken)
+	}
+}
+
+func TestExecutionResultActions(t *testing.T) {
+	wf := &workflow.Workflow{
+		Name: "test-result",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "greet", Command: "echo hello"},
+			{Type: workflow.ActionTypeBash, Name: "never", Command: "echo never", When: "{{ eq 1 2 }}"},
+		},
+	}
+
+	result := ExecuteAndSummarize(wf, TriggerTypeManual, nil)
+	if result.Status != workflow.StatusSuccess || result.TriggerType != TriggerTypeManual || result.Duration <= 0 {
+		t.Errorf("Unexpected run result: %+v", result)
+	}
+	if len(result.Actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(result.Actions))
+	}
+	if greet := result.Actions[0]; greet.Type != workflow.ActionTypeBash || strings.TrimSpace(greet.Output) != "hello" || greet.Attempts != 1 {
+		t.Errorf("Expected greet to output hello in one attempt, got %+v", greet)
+	}
+	if never := result.Actions[1]; never.Status != workflow.StatusSkipped || never.Attempts != 0 {
+		t.Errorf("Expected never to be skipped without an attempt, got %+v", never)
+	}
+}
+
+func TestDefaultRetry(t *testing.T) {
+	SetDefaultRetry(&workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "10ms"})
+	t.Cleanup(func() { SetDefaultRetry(nil) })
+
+	counter := filepath.Join(t.TempDir(), "attempts")
+	flaky := "echo x >> " + counter + "; [ $(wc -l < " + counter + ") -ge 3 ]"
+	wf := &workflow.Workflow{
+		Name: "test-default-retry",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "flaky", Command: flaky},
+			{Type: workflow.ActionTypeBash, Name: "once", Command: "exit 1", Retry: &workflow.RetryConfig{MaxAttempts: 0}},
+		},
+	}
+
+	summary := ExecuteAndSummarize(wf, "manual", nil)
+	if len(summary.Actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(summary.Actions))
+	}
+	if step := summary.Actions[0]; step.Status != "success" || step.Retries() != 2 {
+		t.Errorf("Expected flaky to succeed after 2 retries of the default policy, got %+v", step)
+	}
+	if step := summary.Actions[1]; step.Status != "failed" || step.Retries() != 0 {
+		t.Errorf("Expected an action with its own retry to run once, got %+v", step)
+	}
+}
+
+func TestDeadLetterQueue(t *testing.T) {
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDB()
+
+	dir := t.TempDir()
+	fixed := filepath.Join(dir, "fixed")
+	wf := &workflow.Workflow{
+		Name:    "test-dead-letter",
+		Trigger: workflow.Trigger{Type: workflow.TriggerTypeFileWatch, Path: dir, Events: []string{"create"}},
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "import", Command: "test -f " + fixed + " && echo {{ index .event.files 0 }}"},
+		},
+	}
+	event := WithEvent(nil, Event{Type: "create", Path: "/data/in/a.csv", Files: []string{"/data/in/a.csv"}, Source: map[string]string{"host": "edge-1"}})
+
+	if summary := ExecuteAndSummarize(wf, string(workflow.TriggerTypeFileWatch), event); summary.Status != workflow.StatusFailed {
+		t.Fatalf("Expected the run to fail, got %s", summary.Status)
+	}
+
+	letters, err := database.ListDeadLetters(wf.Name, false, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(letters))
+	}
+	letter := letters[0]
+
+	t.Run("Failed Run Is Dead-Lettered", func(t *testing.T) {
+		if letter.TriggerType != "filewatch" || letter.WorkflowExecutionID == 0 {
+			t.Errorf("Unexpected dead letter: %+v", letter)
+		}
+		if len(letter.FailedActions) != 1 || letter.FailedActions[0] != "import" {
+			t.Errorf("Expected failed actions [import], got %v", letter.FailedActions)
+		}
+		if !strings.Contains(letter.Steps, `"status":"failed"`) {
+			t.Errorf("Expected the action results to be stored, got %s", letter.Steps)
+		}
+		if _, err := parser.ParseWorkflow([]byte(letter.WorkflowDefinition), "dead letter"); err != nil {
+			t.Errorf("Expected the stored definition to parse, got: %v", err)
+		}
+	})
+
+	t.Run("Replay Restores Trigger Data", func(t *testing.T) {
+		data, err := ReplayData(&letter)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		ev := data["event"].(map[string]interface{})
+		if files, _ := ev["files"].([]string); len(files) != 1 || files[0] != "/data/in/a.csv" {
+			t.Errorf("Expected event files to be restored, got %#v", ev["files"])
+		}
+		if source, _ := ev["source"].(map[string]string); source["host"] != "edge-1" {
+			t.Errorf("Expected event source to be restored, got %#v", ev["source"])
+		}
+	})
+
+	t.Run("Successful Replay Resolves", func(t *testing.T) {
+		if err := os.WriteFile(fixed, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ReplayData(&letter)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		summary := ExecuteAndSummarize(wf, TriggerTypeReplay, data)
+		if summary.Status != workflow.StatusSuccess {
+			t.Fatalf("Expected the replay to succeed, got %s: %s", summary.Status, summary.Error)
+		}
+
+		replayed, err := database.GetDeadLetter(letter.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if replayed.ReplayCount != 1 || !replayed.Resolved() || replayed.ReplayExecutionID == nil {
+			t.Errorf("Expected the dead letter to be resolved by the replay, got %+v", replayed)
+		}
+		if open, _ := database.ListDeadLetters(wf.Name, false, 0); len(open) != 0 {
+			t.Errorf("Expected no unresolved dead letters, got %d", len(open))
+		}
+	})
+
+	t.Run("Failed Replay Is Not Dead-Lettered Again", func(t *testing.T) {
+		os.Remove(fixed)
+		data, _ := ReplayData(&letter)
+		ExecuteAndSummarize(wf, TriggerTypeReplay, data)
+
+		all, _ := database.ListDeadLetters(wf.Name, true, 0)
+		if len(all) != 1 || all[0].ReplayCount != 2 || all[0].Resolved() {
+			t.Errorf("Expected one unresolved dead letter replayed twice, got %+v", all)
+		}
+	})
+}
+
+func TestWorkflowAction(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	writeWorkflow := func(name, content string) string {
+		path := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write workflow: %v", err)
+		}
+		return path
+	}
+	child := writeWorkflow("child", `name: "test-child"
+trigger:
+  type: "cron"
+  schedule: "0 0 * * *"
+actions:
+  - type: "bash"
+    name: "record"
+    command: "echo {{ .payload.env }} {{ .parent.workflow }} >> `+out+`"
+`)
+	failing := writeWorkflow("failing", `name: "test-failing-child"
+trigger:
+  type: "cron"
+  schedule: "0 0 * * *"
+actions:
+  - type: "bash"
+    name: "broken"
+    command: "exit 3"
+`)
+
+	run := func(act workflow.Action) workflow.ExecutionResult {
+		act.Type = workflow.ActionTypeWorkflow
+		act.Name = "call"
+		return ExecuteAndSummarize(&workflow.Workflow{Name: "test-parent", Actions: []workflow.Action{act}}, TriggerTypeManual, nil)
+	}
+
+	t.Run("Waits For Workflow File", func(t *testing.T) {
+		summary := run(workflow.Action{WorkflowFile: child, Payload: map[string]string{"env": "staging"}})
+		if summary.Status != workflow.StatusSuccess {
+			t.Fatalf("Expected success, got %+v", summary)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected the child workflow to run: %v", err)
+		}
+		if strings.TrimSpace(string(data)) != "staging test-parent" {
+			t.Errorf("Expected payload and parent in the child run, got %q", data)
+		}
+	})
+
+	t.Run("Propagates Failure", func(t *testing.T) {
+		summary := run(workflow.Action{WorkflowFile: failing})
+		if summary.Status != workflow.StatusFailed || !strings.Contains(summary.Error, "workflow 'test-failing-child' failed") {
+			t.Errorf("Expected the child's failure to fail the parent, got %+v", summary)
+		}
+	})
+
+	t.Run("Does Not Wait", func(t *testing.T) {
+		wait := false
+		summary := run(workflow.Action{WorkflowFile: failing, Wait: &wait})
+		if summary.Status != workflow.StatusSuccess {
+			t.Errorf("Expected the action to succeed without waiting, got %+v", summary)
+		}
+	})
+
+	t.Run("Registered Workflow", func(t *testing.T) {
+		summary := run(workflow.Action{Workflow: "test-unregistered"})
+		if summary.Status != workflow.StatusFailed || !strings.Contains(summary.Error, "not loaded in the agent") {
+			t.Errorf("Expected an unknown workflow to fail, got %+v", summary)
+		}
+
+		registered, err := parser.ParseWorkflowFile(child)
+		if err != nil {
+			t.Fatalf("Failed to parse child workflow: %v", err)
+		}
+		server.GetRegistry().RegisterWorkflow(registered)
+		defer server.GetRegistry().UnregisterWorkflow(registered.Name)
+		if summary := run(workflow.Action{Workflow: "test-child", Payload: map[string]string{"env": "prod"}}); summary.Status != workflow.StatusSuccess {
+			t.Errorf("Expected success, got %+v", summary)
+		}
+
+		if err := server.GetRegistry().PauseWorkflow(registered.Name); err != nil {
+			t.Fatalf("Failed to pause workflow: %v", err)
+		}
+		if summary := run(workflow.Action{Workflow: "test-child"}); summary.Status != workflow.StatusFailed || !strings.Contains(summary.Error, "paused") {
+			t.Errorf("Expected a paused workflow to fail, got %+v", summary)
+		}
+	})
+
+	t.Run("Detects Cycles", func(t *testing.T) {
+		parent := filepath.Join(dir, "parent.yaml")
+		writeWorkflow("parent", `name: "test-parent"
+trigger:
+  type: "cron"
+  schedule: "0 0 * * *"
+actions:
+  - type: "workflow"
+    name: "call-loop"
+    workflowFile: "`+filepath.Join(dir, "loop.yaml")+`"
+`)
+		writeWorkflow("loop", `name: "test-loop"
+trigger:
+  type: "cron"
+  schedule: "0 0 * * *"
+actions:
+  - type: "workflow"
+    name: "call-parent"
+    workflowFile: "`+parent+`"
+`)
+		summary := run(workflow.Action{WorkflowFile: filepath.Join(dir, "loop.yaml")})
+		if summary.Status != workflow.StatusFailed || !strings.Contains(summary.Error, "failed") {
+			t.Fatalf("Expected the cycle to fail the run, got %+v", summary)
+		}
+		if !strings.Contains(summary.Error, "test-parent -> test-loop -> test-parent") {
+			t.Errorf("Expected the cycle in the error, got %q", summary.Error)
+		}
+	})
+}
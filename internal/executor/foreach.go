@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// runAction executes act once, or once per item if it has a foreach
+func runAction(ctx context.Context, wf *workflow.Workflow, act *workflow.Action, index int, data templating.Data, actionExecID int64, attempts *int) (string, error) {
+	if act.ForEach == nil {
+		return executeAction(ctx, wf, act, index, data, actionExecID, attempts)
+	}
+	return runForEach(ctx, wf, act, index, data, actionExecID, attempts)
+}
+
+// runForEach executes act for each of its items, up to maxParallel at once.
+// Every item runs even if others fail; the output is the items' outputs in
+// order and the error lists the items that failed. Items not yet started
+// when ctx is cancelled don't run.
+func runForEach(ctx context.Context, wf *workflow.Workflow, act *workflow.Action, index int, data templating.Data, actionExecID int64, attempts *int) (string, error) {
+	items, err := forEachItems(act, data)
+	if err != nil {
+		logger.L().Errorw("Failed to list foreach items",
+			"workflow_name", wf.Name,
+			"action_name", act.Name,
+			"action_index", index,
+			"error", err)
+		return "", err
+	}
+
+	parallel := max(act.ForEach.MaxParallel, 1)
+	logger.L().Infow("Running action for each item",
+		"workflow_name", wf.Name,
+		"action_name", act.Name,
+		"action_index", index,
+		"items", len(items),
+		"max_parallel", parallel)
+
+	outputs := make([]string, len(items))
+	errs := make([]error, len(items))
+	tries := make([]int, len(items))
+
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		slots <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			itemData := withData(withData(data, "item", item), "itemIndex", i)
+			outputs[i], errs[i] = executeAction(ctx, wf, act, index, itemData, actionExecID, &tries[i])
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("item '%s': %w", item, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The action's retries are those of all its items
+	if attempts != nil {
+		*attempts = 1
+		for _, n := range tries {
+			*attempts += max(n-1, 0)
+		}
+	}
+
+	var output []string
+	for _, out := range outputs {
+		if out = strings.TrimSpace(out); out != "" {
+			output = append(output, out)
+		}
+	}
+	return strings.Join(output, "\n"), errors.Join(errs...)
+}
+
+// forEachItems renders the items of a foreach action: its static list, the
+// files matching its glob in lexical order, or the non-empty lines of from
+func forEachItems(act *workflow.Action, data templating.Data) ([]string, error) {
+	foreach := act.ForEach
+
+	switch {
+	case foreach.Glob != "":
+		pattern, err := templating.Render(act.Name+".foreach.glob", foreach.Glob, data)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid foreach glob '%s': %w", pattern, err)
+		}
+		return matches, nil
+	case foreach.From != "":
+		rendered, err := templating.Render(act.Name+".foreach.from", foreach.From, data)
+		if err != nil {
+			return nil, err
+		}
+		var items []string
+		for _, line := range strings.Split(rendered, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, line)
+			}
+		}
+		return items, nil
+	default:
+		items := make([]string, len(foreach.Items))
+		for i, item := range foreach.Items {
+			var err error
+			if items[i], err = templating.Render(fmt.Sprintf("%s.foreach.items.%d", act.Name, i), item, data); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+}
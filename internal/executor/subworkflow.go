@@ -0,0 +1,148 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/retry"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// TriggerTypeWorkflow is recorded for runs started by a workflow action of
+// another workflow
+const TriggerTypeWorkflow = "workflow"
+
+// maxWorkflowDepth limits how deeply workflow actions nest, so workflows that
+// keep starting each other fail instead of piling up runs
+const maxWorkflowDepth = 10
+
+// runWorkflowAction runs the workflow of a workflow action with the action's
+// payload, available to it as {{ .payload }}, and the calling workflow as
+// {{ .parent }}. Unless the action doesn't wait, it waits for the run, up to
+// the action's timeout, and fails if the run failed or didn't start.
+func runWorkflowAction(ctx context.Context, wf *workflow.Workflow, act *workflow.Action, data templating.Data) (string, error) {
+	startTime := time.Now()
+	output, err := runSubWorkflow(ctx, wf, act, data)
+	metrics.RecordActionExecution(wf.Name, act.Name, string(workflow.ActionTypeWorkflow), action.Status(err), time.Since(startTime))
+	return output, err
+}
+
+func runSubWorkflow(ctx context.Context, wf *workflow.Workflow, act *workflow.Action, data templating.Data) (string, error) {
+	child, err := subWorkflow(act)
+	if err != nil {
+		return "", fmt.Errorf("workflow action '%s' %w", act.Name, err)
+	}
+
+	// The workflows whose actions led to this run, outermost first
+	var chain []string
+	if parent, ok := data["parent"].(map[string]interface{}); ok {
+		chain, _ = parent["chain"].([]string)
+	}
+	chain = append(slices.Clip(chain), wf.Name)
+	if slices.Contains(chain, child.Name) {
+		return "", fmt.Errorf("workflow action '%s' cannot run workflow '%s', which is already running it: %s -> %s", act.Name, child.Name, strings.Join(chain, " -> "), child.Name)
+	}
+	if len(chain) >= maxWorkflowDepth {
+		return "", fmt.Errorf("workflow action '%s' cannot run workflow '%s': workflows are nested more than %d deep", act.Name, child.Name, maxWorkflowDepth)
+	}
+
+	payload := make(map[string]interface{}, len(act.Payload))
+	for key, value := range act.Payload {
+		payload[key] = value
+	}
+	childData := templating.Data{
+		"payload": payload,
+		"parent": map[string]interface{}{
+			"workflow": wf.Name,
+			"action":   act.Name,
+			"chain":    chain,
+		},
+	}
+	childData = WithEvent(childData, Event{
+		Payload: payload,
+		Time:    time.Now(),
+		Source:  map[string]string{"workflow": wf.Name, "action": act.Name},
+	})
+	metrics.RecordTriggerFire(child.Name, TriggerTypeWorkflow)
+
+	if !act.WaitsForWorkflow() {
+		go ExecuteWithData(child, TriggerTypeWorkflow, childData)
+		return fmt.Sprintf("started workflow '%s'", child.Name), nil
+	}
+
+	if act.Timeout != "" {
+		timeout, err := time.ParseDuration(act.Timeout)
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout duration: %w", err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan workflow.ExecutionResult, 1)
+	go func() {
+		done <- ExecuteAndSummarize(child, TriggerTypeWorkflow, childData)
+	}()
+
+	select {
+	case summary := <-done:
+		return describeSubWorkflowRun(act, summary)
+	case <-ctx.Done():
+		// The run goes on; only this action stops waiting for it
+		err := fmt.Errorf("workflow action '%s' stopped waiting for workflow '%s': %w", act.Name, child.Name, ctx.Err())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &retry.TimeoutError{Err: err}
+		}
+		return "", err
+	}
+}
+
+// subWorkflow returns the workflow a workflow action runs: its workflow file,
+// parsed again so edits take effect, or the workflow of that name loaded in
+// the agent, unless it is paused
+func subWorkflow(act *workflow.Action) (*workflow.Workflow, error) {
+	if act.WorkflowFile != "" {
+		wf, err := parser.ParseWorkflowFile(act.WorkflowFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workflow file: %w", err)
+		}
+		return wf, nil
+	}
+
+	wf, ok := server.GetRegistry().GetWorkflowDefinition(act.Workflow)
+	if !ok {
+		return nil, fmt.Errorf("cannot run workflow '%s': it is not loaded in the agent", act.Workflow)
+	}
+	if server.GetRegistry().IsPaused(act.Workflow) {
+		return nil, fmt.Errorf("cannot run workflow '%s': it is paused", act.Workflow)
+	}
+	if !wf.IsEnabled() {
+		return nil, fmt.Errorf("cannot run workflow '%s': it is disabled", act.Workflow)
+	}
+	return wf, nil
+}
+
+// describeSubWorkflowRun returns the output of a workflow action for the run it
+// waited for, or an error if the run failed or didn't start
+func describeSubWorkflowRun(act *workflow.Action, summary workflow.ExecutionResult) (string, error) {
+	duration := summary.Duration.Round(time.Millisecond)
+	switch {
+	case summary.Status == workflow.StatusSuccess:
+		return fmt.Sprintf("workflow '%s' succeeded in %s (%d actions)", summary.Workflow, duration, summary.ActionsSucceeded), nil
+	case summary.Status == workflow.StatusPartialSuccess:
+		return fmt.Sprintf("workflow '%s' partially succeeded in %s: %s", summary.Workflow, duration, summary.Error), nil
+	case !workflow.WasStarted(summary.Status):
+		return "", fmt.Errorf("workflow action '%s': workflow '%s' did not run: %s", act.Name, summary.Workflow, summary.Error)
+	}
+	return "", fmt.Errorf("workflow action '%s': workflow '%s' %s after %s: %s", act.Name, summary.Workflow, summary.Status, duration, summary.Error)
+}
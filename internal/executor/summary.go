@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// ExecuteAndSummarize is like ExecuteAndWait but returns the result of the
+// run rather than only its status, e.g. for a one-shot run to report to the
+// script that started it. Actions that didn't run, e.g. after the run was
+// cancelled, are left out.
+func ExecuteAndSummarize(wf *workflow.Workflow, triggerType string, data templating.Data) workflow.ExecutionResult {
+	_, state := execute(wf, triggerType, data)
+	state.async.Wait()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.snapshot()
+}
+
+// newActionResult records the outcome of an action, given the number of times
+// it was tried
+func newActionResult(act *workflow.Action, output string, err error, duration time.Duration, attempts int) *workflow.ActionResult {
+	result := &workflow.ActionResult{
+		Name:     act.Name,
+		Type:     act.Type,
+		Status:   action.Status(err),
+		Output:   output,
+		Duration: duration,
+		Attempts: attempts,
+		Async:    act.RunAsync,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,202 @@
+// Package health periodically checks the services declared in the agent
+// configuration so workflows that depend on them can be blocked while they
+// are down.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/httpclient"
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+// ServiceStatus is the result of the latest health check of a service
+type ServiceStatus struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Healthy     bool      `json:"healthy"`
+	LastChecked time.Time `json:"last_checked"`
+	Error       string    `json:"error,omitempty"`
+}
+
+var (
+	mu       sync.RWMutex
+	started  bool
+	order    []string
+	statuses = make(map[string]*ServiceStatus)
+)
+
+// Start checks every service once, then keeps checking each at its interval
+// until ctx is cancelled. Dependencies are only enforced once Start has been
+// called.
+func Start(ctx context.Context, services []config.ServiceConfig) {
+	mu.Lock()
+	started = true
+	order = order[:0]
+	statuses = make(map[string]*ServiceStatus, len(services))
+	for _, s := range services {
+		order = append(order, s.Name)
+		statuses[s.Name] = &ServiceStatus{Name: s.Name, Type: s.Type}
+	}
+	mu.Unlock()
+
+	// The first round runs before workflows start so their first runs see it
+	var wg sync.WaitGroup
+	for _, s := range services {
+		wg.Add(1)
+		go func(s config.ServiceConfig) {
+			defer wg.Done()
+			update(s, check(ctx, s))
+		}(s)
+	}
+	wg.Wait()
+
+	for _, s := range services {
+		go monitor(ctx, s)
+	}
+}
+
+func monitor(ctx context.Context, s config.ServiceConfig) {
+	ticker := time.NewTicker(s.IntervalDuration())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			update(s, check(ctx, s))
+		}
+	}
+}
+
+// update records a check result and logs health transitions
+func update(s config.ServiceConfig, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	status, exists := statuses[s.Name]
+	if !exists {
+		return // replaced by a later Start
+	}
+	wasHealthy, firstCheck := status.Healthy, status.LastChecked.IsZero()
+
+	status.Healthy = err == nil
+	status.LastChecked = time.Now()
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	switch {
+	case err != nil && (wasHealthy || firstCheck):
+		logger.L().Warnw("Service is unhealthy",
+			"service", s.Name,
+			"check_type", s.Type,
+			"error", err)
+	case err == nil && !wasHealthy:
+		logger.L().Infow("Service is healthy",
+			"service", s.Name,
+			"check_type", s.Type)
+	}
+}
+
+// check runs a single health check of s
+func check(ctx context.Context, s config.ServiceConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeoutDuration())
+	defer cancel()
+
+	switch s.Type {
+	case config.ServiceCheckHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpclient.Client().Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if s.ExpectStatus != 0 {
+			if resp.StatusCode != s.ExpectStatus {
+				return fmt.Errorf("status code %d, expected %d", resp.StatusCode, s.ExpectStatus)
+			}
+			return nil
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("status code %d", resp.StatusCode)
+		}
+		return nil
+	case config.ServiceCheckTCP:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", s.Address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case config.ServiceCheckCommand:
+		output, err := exec.CommandContext(ctx, "bash", "-c", s.Command).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, truncateOutput(string(output)))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported check type '%s'", s.Type)
+	}
+}
+
+func truncateOutput(s string) string {
+	const maxLen = 200
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
+
+// Unhealthy returns the services in names that are unhealthy or not defined.
+// It returns nil when health checking has not been started, e.g. outside the
+// agent.
+func Unhealthy(names []string) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if !started {
+		return nil
+	}
+
+	var unhealthy []string
+	for _, name := range names {
+		if status, exists := statuses[name]; !exists || !status.Healthy {
+			unhealthy = append(unhealthy, name)
+		}
+	}
+	return unhealthy
+}
+
+// Defined reports whether a service is defined in the agent configuration
+func Defined(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	_, exists := statuses[name]
+	return exists
+}
+
+// Statuses returns the latest status of every service in configuration order
+func Statuses() []ServiceStatus {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make([]ServiceStatus, 0, len(order))
+	for _, name := range order {
+		result = append(result, *statuses[name])
+	}
+	return result
+}
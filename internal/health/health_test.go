@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("HTTP", func(t *testing.T) {
+		ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer ok.Close()
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer down.Close()
+
+		if err := check(ctx, config.ServiceConfig{Name: "ok", Type: config.ServiceCheckHTTP, URL: ok.URL}); err != nil {
+			t.Errorf("Expected healthy service, got: %v", err)
+		}
+		if err := check(ctx, config.ServiceConfig{Name: "down", Type: config.ServiceCheckHTTP, URL: down.URL}); err == nil {
+			t.Error("Expected error for 503 response, got nil")
+		}
+		if err := check(ctx, config.ServiceConfig{Name: "down", Type: config.ServiceCheckHTTP, URL: down.URL, ExpectStatus: 503}); err != nil {
+			t.Errorf("Expected expectStatus 503 to be healthy, got: %v", err)
+		}
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		address := listener.Addr().String()
+
+		if err := check(ctx, config.ServiceConfig{Name: "db", Type: config.ServiceCheckTCP, Address: address}); err != nil {
+			t.Errorf("Expected healthy service, got: %v", err)
+		}
+
+		listener.Close()
+		if err := check(ctx, config.ServiceConfig{Name: "db", Type: config.ServiceCheckTCP, Address: address}); err == nil {
+			t.Error("Expected error for closed port, got nil")
+		}
+	})
+
+	t.Run("Command", func(t *testing.T) {
+		if err := check(ctx, config.ServiceConfig{Name: "ok", Type: config.ServiceCheckCommand, Command: "true"}); err != nil {
+			t.Errorf("Expected healthy service, got: %v", err)
+		}
+		if err := check(ctx, config.ServiceConfig{Name: "broken", Type: config.ServiceCheckCommand, Command: "echo down; exit 1"}); err == nil {
+			t.Error("Expected error for failing command, got nil")
+		}
+	})
+}
+
+func TestUnhealthy(t *testing.T) {
+	if got := Unhealthy([]string{"anything"}); got != nil {
+		t.Fatalf("Expected no unhealthy services before Start, got %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Start(ctx, []config.ServiceConfig{
+		{Name: "up", Type: config.ServiceCheckCommand, Command: "true"},
+		{Name: "down", Type: config.ServiceCheckCommand, Command: "false"},
+	})
+
+	got := Unhealthy([]string{"up", "down", "undefined"})
+	if len(got) != 2 || got[0] != "down" || got[1] != "undefined" {
+		t.Errorf("Expected [down undefined], got %v", got)
+	}
+
+	statuses := Statuses()
+	if len(statuses) != 2 || !statuses[0].Healthy || statuses[1].Healthy {
+		t.Errorf("Expected 'up' healthy and 'down' unhealthy, got %+v", statuses)
+	}
+}
@@ -0,0 +1,104 @@
+// Package httpclient holds the HTTP client shared by HTTP actions, downloads,
+// OAuth2 token requests and service health checks, so their connections are
+// pooled and kept alive across runs instead of being opened per request.
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"golang.org/x/net/http/httpproxy"
+)
+
+var shared = struct {
+	sync.RWMutex
+	client  *http.Client
+	timeout time.Duration
+}{}
+
+func init() {
+	// The defaults need no parsing, so they can't fail
+	_ = Configure(config.HTTPClientConfig{})
+}
+
+// Configure replaces the shared client with one built from cfg. Requests in
+// flight finish on the previous client, whose idle connections are closed.
+func Configure(cfg config.HTTPClientConfig) error {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	shared.Lock()
+	previous := shared.client
+	shared.client = &http.Client{Transport: transport}
+	shared.timeout = cfg.TimeoutDuration()
+	shared.Unlock()
+
+	if previous != nil {
+		previous.CloseIdleConnections()
+	}
+	return nil
+}
+
+// newTransport builds the pooled transport described by cfg
+func newTransport(cfg config.HTTPClientConfig) (*http.Transport, error) {
+	// The agent config overrides the proxy environment variables
+	proxyConfig := httpproxy.FromEnvironment()
+	if cfg.Proxy != "" {
+		if _, err := url.Parse(cfg.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL '%s': %w", cfg.Proxy, err)
+		}
+		proxyConfig.HTTPProxy = cfg.Proxy
+		proxyConfig.HTTPSProxy = cfg.Proxy
+	}
+	if len(cfg.NoProxy) > 0 {
+		proxyConfig.NoProxy = strings.Join(cfg.NoProxy, ",")
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+	proxy := func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConnsOrDefault(),
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHostOrDefault(),
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeoutDuration(),
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
+
+// Client returns the shared client. It has no timeout of its own, since
+// actions set theirs: callers bound requests with their context, by default
+// with Timeout.
+func Client() *http.Client {
+	shared.RLock()
+	defer shared.RUnlock()
+	return shared.client
+}
+
+// Timeout returns how long an HTTP action without a timeout may take
+func Timeout() time.Duration {
+	shared.RLock()
+	defer shared.RUnlock()
+	return shared.timeout
+}
+
+// NewTransport returns a copy of the shared client's transport, with its pool
+// and proxy settings, for a client that needs its own TLS settings or dialer
+func NewTransport() *http.Transport {
+	return Client().Transport.(*http.Transport).Clone()
+}
@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { Configure(config.HTTPClientConfig{}) })
+
+	t.Run("Defaults", func(t *testing.T) {
+		if err := Configure(config.HTTPClientConfig{}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		transport := Client().Transport.(*http.Transport)
+		if transport.MaxIdleConnsPerHost != config.DefaultHTTPMaxIdleConnsPerHost {
+			t.Errorf("Expected %d idle connections per host, got %d", config.DefaultHTTPMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+		}
+		if transport.DisableKeepAlives {
+			t.Error("Expected keep-alives to be enabled")
+		}
+		if Timeout() != config.DefaultHTTPTimeout {
+			t.Errorf("Expected default timeout %s, got %s", config.DefaultHTTPTimeout, Timeout())
+		}
+		if Client() != Client() {
+			t.Error("Expected the same shared client on every call")
+		}
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		err := Configure(config.HTTPClientConfig{
+			MaxIdleConns:      20,
+			MaxConnsPerHost:   4,
+			IdleConnTimeout:   "15s",
+			DisableKeepAlives: true,
+			Proxy:             "http://proxy.internal:3128",
+			NoProxy:           []string{".corp.example.com"},
+			Timeout:           "30s",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		transport := Client().Transport.(*http.Transport)
+		if transport.MaxIdleConns != 20 || transport.MaxConnsPerHost != 4 || transport.IdleConnTimeout != 15*time.Second || !transport.DisableKeepAlives {
+			t.Errorf("Expected configured pool settings, got %+v", transport)
+		}
+		if Timeout() != 30*time.Second {
+			t.Errorf("Expected 30s timeout, got %s", Timeout())
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+		proxyURL, err := transport.Proxy(req)
+		if err != nil || proxyURL == nil || proxyURL.Host != "proxy.internal:3128" {
+			t.Errorf("Expected proxy.internal:3128, got %v (%v)", proxyURL, err)
+		}
+		req = httptest.NewRequest(http.MethodGet, "http://wiki.corp.example.com/", nil)
+		if proxyURL, err := transport.Proxy(req); err != nil || proxyURL != nil {
+			t.Errorf("Expected no proxy for a noProxy domain, got %v (%v)", proxyURL, err)
+		}
+
+		// Clients with their own settings start from the shared ones
+		clone := NewTransport()
+		if clone == transport || clone.MaxConnsPerHost != 4 {
+			t.Errorf("Expected a copy of the shared transport, got %+v", clone)
+		}
+	})
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	t.Cleanup(func() { Configure(config.HTTPClientConfig{}) })
+	if err := Configure(config.HTTPClientConfig{}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for i := 0; i < 5; i++ {
+		resp, err := Client().Get(server.URL)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if got := connections.Load(); got != 1 {
+		t.Errorf("Expected 5 requests to share 1 connection, got %d connections", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Package i18n translates the messages of the CLI. Messages are looked up by
+// key in the catalog of the selected language, embedded from locales/, and
+// fall back to English when a catalog lacks a key.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLanguage is the language messages fall back to
+const DefaultLanguage = "en"
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// catalogs maps a language code to its messages by key
+var catalogs = mustLoadCatalogs()
+
+var current = struct {
+	sync.RWMutex
+	lang string
+}{lang: DefaultLanguage}
+
+// mustLoadCatalogs parses the embedded catalogs; they are checked by the
+// tests, so a broken one is a build mistake
+func mustLoadCatalogs() map[string]map[string]string {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := make(map[string]string)
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("invalid message catalog %s: %v", file.Name(), err))
+		}
+		out[strings.TrimSuffix(file.Name(), ".yaml")] = messages
+	}
+	return out
+}
+
+// Languages returns the codes of the available languages, sorted
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize returns the language code of a language name or locale such as
+// "de", "de_DE.UTF-8" or "en-US". The C and POSIX locales are English.
+func Normalize(name string) string {
+	if name == "C" || name == "POSIX" {
+		return DefaultLanguage
+	}
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	name = strings.ReplaceAll(name, "-", "_")
+	lang, _, _ := strings.Cut(name, "_")
+	return strings.ToLower(lang)
+}
+
+// Set selects the language messages are shown in
+func Set(name string) error {
+	lang := Normalize(name)
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("unsupported language '%s'. Must be one of: %s", name, strings.Join(Languages(), ", "))
+	}
+	current.Lock()
+	defer current.Unlock()
+	current.lang = lang
+	return nil
+}
+
+// Detect selects the language from the first of the given locale names, e.g.
+// from the environment, that has a catalog. It keeps the current language if
+// none does, so an unsupported system locale shows English.
+func Detect(names ...string) {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if Set(name) == nil {
+			return
+		}
+	}
+}
+
+// Language returns the code of the selected language
+func Language() string {
+	current.RLock()
+	defer current.RUnlock()
+	return current.lang
+}
+
+// T returns the message with the given key in the selected language,
+// formatted with args like fmt.Sprintf. Unknown keys are returned as is.
+func T(key string, args ...interface{}) string {
+	message, ok := catalogs[Language()][key]
+	if !ok {
+		message, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// verbPattern matches fmt verbs, skipping escaped percent signs
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func verbs(message string) []string {
+	var out []string
+	for _, verb := range verbPattern.FindAllString(message, -1) {
+		if verb != "%%" {
+			out = append(out, verb)
+		}
+	}
+	return out
+}
+
+func TestCatalogs(t *testing.T) {
+	english := catalogs[DefaultLanguage]
+	if len(english) == 0 {
+		t.Fatal("Expected an English catalog")
+	}
+
+	for _, lang := range Languages() {
+		if lang == DefaultLanguage {
+			continue
+		}
+		messages := catalogs[lang]
+		t.Run(lang, func(t *testing.T) {
+			for key, message := range english {
+				translated, ok := messages[key]
+				if !ok {
+					t.Errorf("Missing translation for '%s'", key)
+					continue
+				}
+				if want, got := strings.Join(verbs(message), " "), strings.Join(verbs(translated), " "); want != got {
+					t.Errorf("Expected '%s' to use verbs '%s', got '%s'", key, want, got)
+				}
+			}
+			for key := range messages {
+				if _, ok := english[key]; !ok {
+					t.Errorf("Unknown key '%s' not in the English catalog", key)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"de":          "de",
+		"de_DE.UTF-8": "de",
+		"en-US":       "en",
+		"DE_at@euro":  "de",
+		"C":           "en",
+		"POSIX":       "en",
+	}
+	for name, want := range tests {
+		if got := Normalize(name); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSetAndTranslate(t *testing.T) {
+	t.Cleanup(func() { Set(DefaultLanguage) })
+
+	t.Run("Unsupported Language", func(t *testing.T) {
+		if err := Set("xx"); err == nil || !strings.Contains(err.Error(), "unsupported language 'xx'") {
+			t.Errorf("Expected an unsupported language error, got: %v", err)
+		}
+		if Language() != DefaultLanguage {
+			t.Errorf("Expected the language to stay %s, got %s", DefaultLanguage, Language())
+		}
+	})
+
+	t.Run("Translate", func(t *testing.T) {
+		if err := Set("de_DE.UTF-8"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := T("validate.valid", 3); got != "Gültig: 3" {
+			t.Errorf("Expected German message, got '%s'", got)
+		}
+	})
+
+	t.Run("Fallbacks", func(t *testing.T) {
+		catalogs[DefaultLanguage]["test.english_only"] = "English only"
+		defer delete(catalogs[DefaultLanguage], "test.english_only")
+
+		if err := Set("de"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := T("test.english_only"); got != "English only" {
+			t.Errorf("Expected the English message, got '%s'", got)
+		}
+		if got := T("test.unknown"); got != "test.unknown" {
+			t.Errorf("Expected the key of an unknown message, got '%s'", got)
+		}
+	})
+
+	t.Run("Detect", func(t *testing.T) {
+		Set(DefaultLanguage)
+		Detect("", "fr_FR.UTF-8", "de_DE.UTF-8", "en_US.UTF-8")
+		if Language() != "de" {
+			t.Errorf("Expected the first supported locale, got %s", Language())
+		}
+		Detect("fr_FR.UTF-8")
+		if Language() != "de" {
+			t.Errorf("Expected an unsupported locale to keep the language, got %s", Language())
+		}
+	})
+}
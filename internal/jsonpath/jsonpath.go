@@ -0,0 +1,185 @@
+// Package jsonpath extracts values from JSON documents with simple paths such
+// as .data.items[0].id. It supports the subset of jq and JSONPath that
+// selects a single value: field names, quoted field names (.["a b"]) and
+// array indexes, negative ones counting from the end. A path may start with
+// "$" like JSONPath or "." like jq; "." or "$" alone selects the document.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Path is a parsed path
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// step is a field name or, if field is false, an array index
+type step struct {
+	field bool
+	name  string
+	index int
+}
+
+// Parse parses a path
+func Parse(expr string) (Path, error) {
+	p := Path{expr: expr}
+	rest := strings.TrimSpace(expr)
+	switch {
+	case strings.HasPrefix(rest, "$"):
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "."):
+	default:
+		return p, fmt.Errorf("invalid path '%s': must start with '.' or '$'", expr)
+	}
+	if rest == "." {
+		return p, nil
+	}
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".["):
+			rest = rest[1:] // jq's .["key"] and .[0]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			n := strings.IndexAny(rest, ".[")
+			if n < 0 {
+				n = len(rest)
+			}
+			if n == 0 {
+				return p, fmt.Errorf("invalid path '%s': empty field name", expr)
+			}
+			if !isFieldName(rest[:n]) {
+				return p, fmt.Errorf("invalid path '%s': field name '%s' must be quoted, e.g. .[\"%s\"]; filters aren't supported", expr, rest[:n], rest[:n])
+			}
+			p.steps = append(p.steps, step{field: true, name: rest[:n]})
+			rest = rest[n:]
+			continue
+		case !strings.HasPrefix(rest, "["):
+			return p, fmt.Errorf("invalid path '%s': unexpected '%s'", expr, rest)
+		}
+
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return p, fmt.Errorf("invalid path '%s': missing ']'", expr)
+		}
+		inner := strings.TrimSpace(rest[1:end])
+		if name, err := strconv.Unquote(inner); err == nil && strings.HasPrefix(inner, `"`) {
+			p.steps = append(p.steps, step{field: true, name: name})
+		} else if inner != "" && inner[0] == '\'' && inner[len(inner)-1] == '\'' && len(inner) > 1 {
+			p.steps = append(p.steps, step{field: true, name: inner[1 : len(inner)-1]})
+		} else if index, err := strconv.Atoi(inner); err == nil {
+			p.steps = append(p.steps, step{index: index})
+		} else {
+			return p, fmt.Errorf("invalid path '%s': '[%s]' must be an array index or a quoted field name", expr, inner)
+		}
+		rest = rest[end+1:]
+	}
+	return p, nil
+}
+
+// String returns the path as it was parsed
+func (p Path) String() string {
+	return p.expr
+}
+
+// Lookup returns the value at the path in a document decoded with Decode
+func (p Path) Lookup(doc interface{}) (interface{}, error) {
+	value := doc
+	for i, s := range p.steps {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if !s.field {
+				return nil, fmt.Errorf("%s: cannot index an object with %d", p.prefix(i), s.index)
+			}
+			var ok bool
+			if value, ok = v[s.name]; !ok {
+				return nil, fmt.Errorf("%s: no field '%s'", p.prefix(i), s.name)
+			}
+		case []interface{}:
+			if s.field {
+				return nil, fmt.Errorf("%s: cannot read field '%s' of an array", p.prefix(i), s.name)
+			}
+			index := s.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("%s: index %d out of range for an array of length %d", p.prefix(i), s.index, len(v))
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("%s: cannot look up '%s' in a %s", p.prefix(i), s, typeName(value))
+		}
+	}
+	return value, nil
+}
+
+// prefix describes the value the first n steps of the path lead to
+func (p Path) prefix(n int) string {
+	if n == 0 {
+		return "document"
+	}
+	var b strings.Builder
+	for _, s := range p.steps[:n] {
+		if s.field {
+			b.WriteString(".")
+		}
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+func (s step) String() string {
+	if s.field {
+		return s.name
+	}
+	return "[" + strconv.Itoa(s.index) + "]"
+}
+
+// Decode decodes a JSON document. Numbers are kept as json.Number so large
+// IDs render exactly in templates instead of in exponent form.
+func Decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return doc, nil
+}
+
+// isFieldName reports whether name can be used unquoted: letters, digits,
+// underscores and dashes
+func isFieldName(name string) bool {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,85 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	doc, err := Decode([]byte(`{"data": {"id": 12345678901234567890, "name": "order", "items": [{"sku": "a"}, {"sku": "b"}], "x-trace id": "t1"}, "ok": true}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{".data.name", "order"},
+		{"$.data.name", "order"},
+		{".data.id", json.Number("12345678901234567890")},
+		{".data.items[1].sku", "b"},
+		{".data.items[-1].sku", "b"},
+		{"$.data.items[0]['sku']", "a"},
+		{`.data["x-trace id"]`, "t1"},
+		{`.data.["x-trace id"]`, "t1"},
+		{".ok", true},
+	}
+	for _, tt := range tests {
+		path, err := Parse(tt.path)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", tt.path, err)
+		}
+		got, err := path.Lookup(doc)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.want, got)
+		}
+	}
+
+	t.Run("Whole Document", func(t *testing.T) {
+		for _, expr := range []string{".", "$"} {
+			path, err := Parse(expr)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got: %v", expr, err)
+			}
+			got, err := path.Lookup(doc)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got: %v", expr, err)
+			}
+			if _, ok := got.(map[string]interface{}); !ok {
+				t.Errorf("%s: expected the document, got %v", expr, got)
+			}
+		}
+	})
+
+	t.Run("Missing Values", func(t *testing.T) {
+		for _, expr := range []string{".data.missing", ".data.items[2]", ".data.items.sku", ".data.name.first", ".data[0]"} {
+			path, err := Parse(expr)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got: %v", expr, err)
+			}
+			if _, err := path.Lookup(doc); err == nil {
+				t.Errorf("%s: expected error, got nil", expr)
+			}
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{"", "data.id", ".data.", ".data[", ".data[x]", ".data..id", "$data", ".data | keys", ".items[].id"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: expected error, got nil", expr)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, body := range []string{"", "not json", `{"a": 1} {"b": 2}`} {
+		if _, err := Decode([]byte(body)); err == nil {
+			t.Errorf("%q: expected error, got nil", body)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// RuleLiteralCredential is reported for credentials written into an HTTP
+// action's auth block instead of read from a secret or the environment
+const RuleLiteralCredential = "literal-credential"
+
+// Auth lints the auth block of an HTTP action. Passwords, tokens and client
+// secrets written as plain text end up in version control and in the
+// dead-letter queue's copy of the workflow.
+func Auth(actionName string, auth *workflow.AuthConfig) []Finding {
+	if auth == nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, field := range []struct{ name, value string }{
+		{"password", auth.Password},
+		{"token", auth.Token},
+		{"clientSecret", auth.ClientSecret},
+	} {
+		if field.value != "" && !strings.Contains(field.value, "{{") {
+			findings = append(findings, Finding{
+				Action:  actionName,
+				Rule:    RuleLiteralCredential,
+				Message: fmt.Sprintf("auth '%s' is written in the workflow; read it with {{ secret \"name\" }} or {{ env \"NAME\" }}", field.name),
+			})
+		}
+	}
+	return findings
+}
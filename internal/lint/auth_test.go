@@ -0,0 +1,30 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		auth *workflow.AuthConfig
+		want int
+	}{
+		{"no auth", nil, 0},
+		{"secret token", &workflow.AuthConfig{Type: "bearer", Token: `{{ secret "api_token" }}`}, 0},
+		{"literal token", &workflow.AuthConfig{Type: "bearer", Token: "abc123"}, 1},
+		{"literal password", &workflow.AuthConfig{Type: "basic", Username: "deploy", Password: "hunter2"}, 1},
+		{"env client secret", &workflow.AuthConfig{Type: "oauth2", TokenURL: "https://auth.example.com/token", ClientID: "autozap", ClientSecret: `{{ env "CLIENT_SECRET" }}`}, 0},
+		{"literal client secret", &workflow.AuthConfig{Type: "oauth2", TokenURL: "https://auth.example.com/token", ClientID: "autozap", ClientSecret: "s3cret"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(Auth("call", tt.auth))[RuleLiteralCredential]; got != tt.want {
+				t.Errorf("Expected %d %s findings, got %d", tt.want, RuleLiteralCredential, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package lint flags common mistakes in workflow definitions that are valid
+// but likely to misbehave at runtime.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Rules reported by the bash linter
+const (
+	RuleUnquotedVariable = "unquoted-variable"
+	RuleRmTemplatedPath  = "rm-templated-path"
+	RuleMissingSetE      = "missing-set-e"
+)
+
+// Finding is a single lint warning
+type Finding struct {
+	Action  string // action name
+	Line    int    // 1-based line within the command, 0 if it applies to the whole command
+	Rule    string
+	Message string
+}
+
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %s [%s]", f.Action, f.Line, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s: %s [%s]", f.Action, f.Message, f.Rule)
+}
+
+// Workflow lints every bash action of a workflow and the auth blocks of its
+// HTTP actions
+func Workflow(wf *workflow.Workflow) []Finding {
+	var findings []Finding
+	for _, act := range wf.Actions {
+		switch act.Type {
+		case workflow.ActionTypeBash:
+			// The rules are about bash; powershell commands would only give false positives
+			if act.Shell != "powershell" {
+				findings = append(findings, Bash(act.Name, act.Command)...)
+			}
+		case workflow.ActionTypeHTTP:
+			findings = append(findings, Auth(act.Name, act.Auth)...)
+		}
+	}
+	return findings
+}
+
+// Bash lints a bash command. It is a heuristic scanner for a few common
+// footguns, not a shell parser:
+//   - variables expanded outside double quotes, which word-split and glob
+//   - rm -rf with a path built from a template or variable, which can
+//     delete the wrong directory when the value is empty
+//   - multi-line scripts without set -e, which keep going after a failure
+func Bash(actionName, command string) []Finding {
+	var findings []Finding
+
+	seen := make(map[string]bool)
+	for _, v := range unquotedVariables(command) {
+		if seen[v.name] {
+			continue
+		}
+		seen[v.name] = true
+		findings = append(findings, Finding{
+			Action:  actionName,
+			Line:    v.line,
+			Rule:    RuleUnquotedVariable,
+			Message: fmt.Sprintf("$%s is not quoted; use \"$%s\" to avoid word splitting and globbing", v.name, v.name),
+		})
+	}
+
+	for i, line := range strings.Split(command, "\n") {
+		if path := rmTemplatedPath(line); path != "" {
+			findings = append(findings, Finding{
+				Action:  actionName,
+				Line:    i + 1,
+				Rule:    RuleRmTemplatedPath,
+				Message: fmt.Sprintf("'%s' removes a templated path; guard against empty values (e.g. ${VAR:?})", path),
+			})
+		}
+	}
+
+	if scriptLines(command) > 1 && !setErrexit.MatchString(command) {
+		findings = append(findings, Finding{
+			Action:  actionName,
+			Rule:    RuleMissingSetE,
+			Message: "multi-line script without 'set -e'; later commands run even if an earlier one fails",
+		})
+	}
+
+	return findings
+}
+
+// setErrexit matches set -e, set -euo pipefail, set -o errexit, ...
+var setErrexit = regexp.MustCompile(`(^|[\s;])set\s+(-[a-zA-Z]*e[a-zA-Z]*\b|-o\s+errexit\b)`)
+
+// scriptLines counts the lines of a script that contain a command
+func scriptLines(command string) int {
+	count := 0
+	for _, line := range strings.Split(command, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			count++
+		}
+	}
+	return count
+}
+
+type variableRef struct {
+	name string
+	line int
+}
+
+// unquotedVariables scans command for $NAME and ${NAME} expansions outside
+// double quotes. Single-quoted text, comments and {{ }} templates are skipped,
+// as are the contexts where bash does not word-split: assignments, [[ ]],
+// (( )) and $(( )).
+func unquotedVariables(command string) []variableRef {
+	var refs []variableRef
+
+	line := 1
+	inSingle, inDouble := false, false
+	safeDepth := 0 // inside [[ ]] or (( ))
+	wordStart := true
+	assignment := false // current word is NAME=value
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+
+		if c == '\n' {
+			line++
+		}
+
+		switch {
+		case inSingle:
+			if c == '\'' {
+				inSingle = false
+			}
+			continue
+		case c == '\\':
+			i++ // skip the escaped character
+			if i < len(command) && command[i] == '\n' {
+				line++
+			}
+			continue
+		case strings.HasPrefix(command[i:], "{{"):
+			end := strings.Index(command[i:], "}}")
+			if end < 0 {
+				return refs
+			}
+			line += strings.Count(command[i:i+end], "\n")
+			i += end + 1
+			wordStart = false
+			continue
+		case inDouble:
+			if c == '"' {
+				inDouble = false
+			}
+			continue
+		}
+
+		switch c {
+		case '\'':
+			inSingle = true
+		case '"':
+			inDouble = true
+		case '#':
+			if wordStart {
+				end := strings.IndexByte(command[i:], '\n')
+				if end < 0 {
+					return refs
+				}
+				i += end - 1 // the newline is handled by the next iteration
+				continue
+			}
+		case '[', '(':
+			if wordStart && i+1 < len(command) && command[i+1] == c {
+				safeDepth++
+				i++
+			}
+		case ']', ')':
+			if safeDepth > 0 && i+1 < len(command) && command[i+1] == c {
+				safeDepth--
+				i++
+			}
+		case '=':
+			if !assignment && isName(currentWord(command, i)) {
+				assignment = true
+			}
+		case '$':
+			if strings.HasPrefix(command[i+1:], "((") {
+				safeDepth++ // $(( )) arithmetic
+				i += 2
+				break
+			}
+			if name, length := variableName(command[i+1:]); name != "" {
+				if safeDepth == 0 && !assignment {
+					refs = append(refs, variableRef{name: name, line: line})
+				}
+				i += length
+			}
+		}
+
+		wordStart = c == ' ' || c == '\t' || c == '\n' || c == ';' || c == '|' || c == '&' || c == '('
+		if wordStart {
+			assignment = false
+		}
+	}
+
+	return refs
+}
+
+// currentWord returns the text between the start of the word containing
+// position i and i
+func currentWord(s string, i int) string {
+	start := strings.LastIndexAny(s[:i], " \t\n;|&(") + 1
+	return s[start:i]
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func isName(s string) bool {
+	return namePattern.MatchString(s)
+}
+
+// variableName returns the name of a $NAME or ${NAME} expansion at the start of
+// s and its length. Special parameters ($?, $#, $1, ...) and ${NAME...}
+// expansions with operators are ignored since they are usually deliberate.
+func variableName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isName(s[1:end]) {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+
+	end := 0
+	for end < len(s) && (s[end] == '_' || s[end] >= 'a' && s[end] <= 'z' || s[end] >= 'A' && s[end] <= 'Z' || end > 0 && s[end] >= '0' && s[end] <= '9') {
+		end++
+	}
+	if end == 0 {
+		return "", 0
+	}
+	return s[:end], end
+}
+
+// rmCommand matches an rm invocation and its arguments up to the next command separator
+var rmCommand = regexp.MustCompile(`(?:^|[;&|]\s*|\bsudo\s+)rm\s+([^;&|]*)`)
+
+// rmTemplatedPath returns the first recursive, forced rm on line whose path
+// comes from a template or variable, or "" if there is none
+func rmTemplatedPath(line string) string {
+	for _, m := range rmCommand.FindAllStringSubmatch(strings.TrimSpace(line), -1) {
+		args := strings.Fields(m[1])
+
+		recursive, force := false, false
+		var paths []string
+		for _, arg := range args {
+			switch {
+			case arg == "--recursive":
+				recursive = true
+			case arg == "--force":
+				force = true
+			case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+				recursive = recursive || strings.ContainsAny(arg, "rR")
+				force = force || strings.Contains(arg, "f")
+			default:
+				paths = append(paths, arg)
+			}
+		}
+		if !recursive || !force {
+			continue
+		}
+
+		for _, path := range paths {
+			if strings.Contains(path, ":?") {
+				continue // ${VAR:?} aborts on empty values
+			}
+			if strings.Contains(path, "{{") || strings.Contains(path, "$") {
+				return strings.TrimSpace("rm " + m[1])
+			}
+		}
+	}
+	return ""
+}
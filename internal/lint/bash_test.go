@@ -0,0 +1,81 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func rules(findings []Finding) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Rule]++
+	}
+	return counts
+}
+
+func TestBash(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    map[string]int
+	}{
+		{"Clean Command", `echo "hello $USER"`, map[string]int{}},
+		{"Unquoted Variable", `cp $SRC /backup`, map[string]int{RuleUnquotedVariable: 1}},
+		{"Unquoted Braced Variable", `ls ${DIR}/logs`, map[string]int{RuleUnquotedVariable: 1}},
+		{"Single Quotes Are Literal", `echo '$HOME'`, map[string]int{}},
+		{"Escaped Dollar", `echo \$HOME`, map[string]int{}},
+		{"Special Parameters Ignored", `exit $?`, map[string]int{}},
+		{"Assignment Not Flagged", `OUT=$HOME/out`, map[string]int{}},
+		{"Double Brackets Not Flagged", `[[ -z $NAME ]] && exit 1`, map[string]int{}},
+		{"Arithmetic Not Flagged", `echo $(( ($END - $START) / 1000 ))`, map[string]int{}},
+		{"Comment Ignored", "# uses $HOME\necho ok", map[string]int{RuleMissingSetE: 0}},
+		{"Template Ignored", `echo {{ $x := 1 }}done`, map[string]int{}},
+		{"Same Variable Reported Once", `cp $F /a && cp $F /b`, map[string]int{RuleUnquotedVariable: 1}},
+		{"Rm With Template Path", `rm -rf {{ .payload.dir }}/cache`, map[string]int{RuleRmTemplatedPath: 1}},
+		{"Rm With Quoted Variable", `rm -rf "$BUILD_DIR/out"`, map[string]int{RuleRmTemplatedPath: 1}},
+		{"Rm With Separate Flags", `cd /tmp && rm -r -f "$TARGET"`, map[string]int{RuleRmTemplatedPath: 1}},
+		{"Rm With Guard", `rm -rf "${BUILD_DIR:?}/out"`, map[string]int{}},
+		{"Rm Without Force", `rm -r "$DIR"`, map[string]int{}},
+		{"Rm Literal Path", `rm -rf /tmp/cache`, map[string]int{}},
+		{"Multi Line Without Set E", "cd /srv\n./deploy.sh", map[string]int{RuleMissingSetE: 1}},
+		{"Multi Line With Set E", "set -euo pipefail\ncd /srv\n./deploy.sh", map[string]int{}},
+		{"Multi Line With Errexit", "set -o errexit\ncd /srv\n./deploy.sh", map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules(Bash("test", tt.command))
+			for _, rule := range []string{RuleUnquotedVariable, RuleRmTemplatedPath, RuleMissingSetE} {
+				if got[rule] != tt.want[rule] {
+					t.Errorf("Expected %d %s findings, got %d (%v)", tt.want[rule], rule, got[rule], Bash("test", tt.command))
+				}
+			}
+		})
+	}
+}
+
+func TestBashFindingLine(t *testing.T) {
+	findings := Bash("deploy", "set -e\necho \"ok\"\ntar czf out.tgz $DIR")
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d: %v", len(findings), findings)
+	}
+	if findings[0].Line != 3 || findings[0].Action != "deploy" {
+		t.Errorf("Expected finding on line 3 of 'deploy', got %+v", findings[0])
+	}
+}
+
+func TestWorkflow(t *testing.T) {
+	wf := &workflow.Workflow{
+		Name: "test-workflow",
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "copy", Command: "cp $SRC /backup"},
+			{Type: workflow.ActionTypeHTTP, Name: "notify", URL: "http://example.com/$X", Method: "GET"},
+		},
+	}
+
+	findings := Workflow(wf)
+	if len(findings) != 1 || findings[0].Action != "copy" {
+		t.Errorf("Expected one finding for the bash action, got %v", findings)
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -10,18 +11,51 @@ import (
 
 var globalSugaredLogger *zap.SugaredLogger
 
+// level is the minimum level of the global logger and workflow log files
+var level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
 func InitLogger() {
+	if err := Configure("", ""); err != nil {
+		panic(err)
+	}
+}
+
+// Configure replaces the global logger with one logging at level (debug,
+// info, warn or error) in format (json or console). Empty values keep info
+// and json.
+func Configure(levelName, format string) error {
+	if levelName == "" {
+		levelName = "info"
+	}
+	if err := level.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("invalid log level '%s': %w", levelName, err)
+	}
+
 	config := zap.NewProductionConfig()
+	config.Level = level
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	config.EncoderConfig.CallerKey = "caller"
+	switch format {
+	case "", "json":
+	case "console":
+		config.Encoding = "console"
+	default:
+		return fmt.Errorf("invalid log format '%s': must be json or console", format)
+	}
 
 	logger, err := config.Build(zap.AddCaller())
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	globalSugaredLogger = logger.Sugar()
+	return nil
+}
 
+// Initialized reports whether the global logger was set up, e.g. for code
+// embedding autozap that may have configured it already
+func Initialized() bool {
+	return globalSugaredLogger != nil
 }
 
 func L() *zap.SugaredLogger {
@@ -63,7 +97,7 @@ func NewWorkflowLogger(workflowName, logDir string) (*zap.SugaredLogger, error)
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderConfig),
 		zapcore.AddSync(file),
-		zapcore.InfoLevel,
+		level,
 	)
 
 	// Create logger with workflow name field
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"testing"
+
+	"go.uber.org/zap/zapcore"
 )
 
 func TestInitLogger(t *testing.T) {
@@ -54,3 +56,28 @@ func TestLoggerUsage(t *testing.T) {
 		logger.Infow("Test info message with fields", "key", "value")
 	})
 }
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(InitLogger)
+
+	t.Run("Level And Format", func(t *testing.T) {
+		if err := Configure("warn", "console"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if L().Desugar().Core().Enabled(zapcore.InfoLevel) {
+			t.Error("Expected info to be disabled at level warn")
+		}
+		if !L().Desugar().Core().Enabled(zapcore.WarnLevel) {
+			t.Error("Expected warn to be enabled at level warn")
+		}
+	})
+
+	t.Run("Invalid Settings", func(t *testing.T) {
+		if err := Configure("loud", ""); err == nil {
+			t.Error("Expected error for invalid level, got nil")
+		}
+		if err := Configure("", "xml"); err == nil {
+			t.Error("Expected error for invalid format, got nil")
+		}
+	})
+}
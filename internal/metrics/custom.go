@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Custom metric types
+const (
+	CustomMetricGauge   = "gauge"
+	CustomMetricCounter = "counter"
+)
+
+// metricNamePattern is the Prometheus metric and label name syntax
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// customMetric is a metric registered on demand by a workflow
+type customMetric struct {
+	kind      string
+	labels    []string // sorted label names, "workflow" first
+	gauge     *prometheus.GaugeVec
+	counter   *prometheus.CounterVec
+	collector prometheus.Collector
+}
+
+var (
+	customMetrics   = make(map[string]*customMetric)
+	customMetricsMu sync.Mutex
+)
+
+// ValidateCustomMetric checks the name, type and label names of a custom metric
+func ValidateCustomMetric(name, kind string, labels []string) error {
+	if !metricNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid metric name '%s'", name)
+	}
+	if strings.HasPrefix(name, "autozap_") {
+		return fmt.Errorf("metric name '%s' uses the reserved 'autozap_' prefix", name)
+	}
+	switch kind {
+	case "", CustomMetricGauge, CustomMetricCounter:
+	default:
+		return fmt.Errorf("invalid metric type '%s'. Must be one of: %s, %s", kind, CustomMetricGauge, CustomMetricCounter)
+	}
+	for _, label := range labels {
+		if !metricNamePattern.MatchString(label) || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("invalid label name '%s' for metric '%s'", label, name)
+		}
+		if label == "workflow" {
+			return fmt.Errorf("label 'workflow' of metric '%s' is reserved", name)
+		}
+	}
+	return nil
+}
+
+// RecordCustomMetric sets a gauge or adds to a counter defined by a workflow.
+// The metric is registered on first use; later uses must have the same type
+// and label names. Every sample carries a "workflow" label.
+func RecordCustomMetric(workflowName, name, help, kind string, value float64, labels map[string]string) error {
+	if kind == "" {
+		kind = CustomMetricGauge
+	}
+
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	if err := ValidateCustomMetric(name, kind, names); err != nil {
+		return err
+	}
+	sort.Strings(names)
+	names = append([]string{"workflow"}, names...)
+
+	m, err := getOrRegisterCustomMetric(name, help, kind, names)
+	if err != nil {
+		return err
+	}
+
+	values := make([]string, len(m.labels))
+	values[0] = workflowName
+	for i, label := range m.labels[1:] {
+		values[i+1] = labels[label]
+	}
+
+	switch kind {
+	case CustomMetricCounter:
+		if value < 0 {
+			return fmt.Errorf("counter '%s' cannot be decreased (value %v)", name, value)
+		}
+		m.counter.WithLabelValues(values...).Add(value)
+	default:
+		m.gauge.WithLabelValues(values...).Set(value)
+	}
+	return nil
+}
+
+func getOrRegisterCustomMetric(name, help, kind string, labels []string) (*customMetric, error) {
+	customMetricsMu.Lock()
+	defer customMetricsMu.Unlock()
+
+	if m, exists := customMetrics[name]; exists {
+		if m.kind != kind || strings.Join(m.labels, ",") != strings.Join(labels, ",") {
+			return nil, fmt.Errorf("metric '%s' is already registered as a %s with labels %v", name, m.kind, m.labels)
+		}
+		return m, nil
+	}
+
+	if help == "" {
+		help = "Custom workflow metric " + name
+	}
+
+	m := &customMetric{kind: kind, labels: labels}
+	if kind == CustomMetricCounter {
+		m.counter = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+		m.collector = m.counter
+	} else {
+		m.gauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+		m.collector = m.gauge
+	}
+
+	if err := prometheus.Register(m.collector); err != nil {
+		return nil, fmt.Errorf("failed to register metric '%s': %w", name, err)
+	}
+	customMetrics[name] = m
+	return m, nil
+}
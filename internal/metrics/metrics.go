@@ -3,6 +3,7 @@ package metrics
 import (
 	"time"
 
+	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -46,6 +47,15 @@ var (
 		[]string{"workflow", "action", "action_type"},
 	)
 
+	// ActionAttempts counts the tries of actions, including retries
+	ActionAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "autozap_action_attempts_total",
+			Help: "Total number of action attempts, including retries, by workflow, action name and action type",
+		},
+		[]string{"workflow", "action", "action_type"},
+	)
+
 	// TriggerFires tracks trigger fire counts
 	TriggerFires = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -55,6 +65,24 @@ var (
 		[]string{"workflow", "trigger_type"},
 	)
 
+	// ClockJumps counts system suspends and jumps of the wall clock
+	ClockJumps = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "autozap_clock_jumps_total",
+			Help: "Total number of detected system suspends and wall clock jumps by kind (suspend, forward, backward)",
+		},
+		[]string{"kind"},
+	)
+
+	// MissedCronRuns counts cron runs missed during a suspend or clock jump
+	MissedCronRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "autozap_cron_missed_runs_total",
+			Help: "Total number of cron runs missed during a system suspend or clock jump by workflow and outcome (run, skipped)",
+		},
+		[]string{"workflow", "outcome"},
+	)
+
 	// AgentActiveWorkflows tracks the number of active workflows
 	AgentActiveWorkflows = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -80,6 +108,32 @@ var (
 		[]string{"workflow"},
 	)
 
+	// WorkflowCircuitOpen tracks whether a workflow's circuit breaker is open
+	WorkflowCircuitOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "autozap_workflow_circuit_open",
+			Help: "Whether the workflow's circuit breaker is open (1) or closed (0)",
+		},
+		[]string{"workflow"},
+	)
+
+	// MaintenanceMode tracks whether the agent is in maintenance mode
+	MaintenanceMode = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "autozap_maintenance_mode",
+			Help: "Whether the agent is in maintenance mode (1) or not (0)",
+		},
+	)
+
+	// ConfigReloads counts reloads of the agent configuration file
+	ConfigReloads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "autozap_config_reloads_total",
+			Help: "Total number of agent configuration reloads by result (success, failure)",
+		},
+		[]string{"result"},
+	)
+
 	// WorkflowInfo provides metadata about workflows
 	WorkflowInfo = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -90,11 +144,17 @@ var (
 	)
 )
 
-// RecordWorkflowExecution records a workflow execution with duration
-func RecordWorkflowExecution(workflowName string, status string, duration time.Duration) {
+// RecordWorkflowExecution records the status and duration of a workflow run
+func RecordWorkflowExecution(result *workflow.ExecutionResult) {
+	WorkflowExecutions.WithLabelValues(result.Workflow, result.Status).Inc()
+	WorkflowDuration.WithLabelValues(result.Workflow).Observe(result.Duration.Seconds())
+	WorkflowLastExecution.WithLabelValues(result.Workflow).SetToCurrentTime()
+}
+
+// RecordWorkflowNotStarted counts a triggered run that didn't start, e.g. a
+// skipped or throttled one, without observing a duration
+func RecordWorkflowNotStarted(workflowName string, status string) {
 	WorkflowExecutions.WithLabelValues(workflowName, status).Inc()
-	WorkflowDuration.WithLabelValues(workflowName).Observe(duration.Seconds())
-	WorkflowLastExecution.WithLabelValues(workflowName).SetToCurrentTime()
 }
 
 // RecordActionExecution records an action execution with duration
@@ -103,11 +163,53 @@ func RecordActionExecution(workflowName, actionName, actionType, status string,
 	ActionDuration.WithLabelValues(workflowName, actionName, actionType).Observe(duration.Seconds())
 }
 
+// RecordActionAttempts records how often an action was tried in one execution
+func RecordActionAttempts(workflowName, actionName, actionType string, attempts int) {
+	ActionAttempts.WithLabelValues(workflowName, actionName, actionType).Add(float64(attempts))
+}
+
+// SetCircuitOpen records the state of a workflow's circuit breaker
+func SetCircuitOpen(workflowName string, open bool) {
+	value := 0.0
+	if open {
+		value = 1
+	}
+	WorkflowCircuitOpen.WithLabelValues(workflowName).Set(value)
+}
+
+// SetMaintenanceMode records whether the agent is in maintenance mode
+func SetMaintenanceMode(enabled bool) {
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	MaintenanceMode.Set(value)
+}
+
+// RecordConfigReload records a reload of the agent configuration file that
+// succeeded ("success") or kept the previous configuration ("failure")
+func RecordConfigReload(result string) {
+	ConfigReloads.WithLabelValues(result).Inc()
+}
+
 // RecordTriggerFire records a trigger fire event
 func RecordTriggerFire(workflowName, triggerType string) {
 	TriggerFires.WithLabelValues(workflowName, triggerType).Inc()
 }
 
+// RecordClockJump records a detected system suspend or wall clock jump
+func RecordClockJump(kind string) {
+	ClockJumps.WithLabelValues(kind).Inc()
+}
+
+// RecordMissedCronRuns records cron runs missed during a suspend or clock
+// jump that were made up for ("run") or dropped ("skipped")
+func RecordMissedCronRuns(workflowName, outcome string, count int) {
+	if count > 0 {
+		MissedCronRuns.WithLabelValues(workflowName, outcome).Add(float64(count))
+	}
+}
+
 // RegisterWorkflow registers a workflow in the info metric
 func RegisterWorkflow(workflowName, triggerType, schedule string) {
 	WorkflowInfo.WithLabelValues(workflowName, triggerType, schedule).Set(1)
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultPushJob is the Pushgateway job name used when none is given
+const DefaultPushJob = "autozap"
+
+// pushTimeout bounds a push to the Pushgateway
+const pushTimeout = 10 * time.Second
+
+// runtimeMetricPrefixes are the Go runtime and process metrics of the default
+// registry, which describe the autozap process rather than the workflow and
+// are not pushed
+var runtimeMetricPrefixes = []string{"go_", "process_", "promhttp_"}
+
+// Push sends the workflow metrics to a Prometheus Pushgateway at url, grouped
+// by job and workflow. Each push replaces the previous metrics of the group,
+// so the Pushgateway holds the metrics of the workflow's latest run.
+func Push(url, job, workflowName string) error {
+	if job == "" {
+		job = DefaultPushJob
+	}
+
+	err := push.New(url, job).
+		Client(&http.Client{Timeout: pushTimeout}).
+		Gatherer(workflowGatherer(prometheus.DefaultGatherer, workflowName)).
+		Grouping("workflow", workflowName).
+		Push()
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
+
+// workflowGatherer returns the metrics of g for a workflow, without runtime
+// metrics and series of other workflows. The workflow label is removed since
+// the Pushgateway adds it from the grouping key.
+func workflowGatherer(g prometheus.Gatherer, workflowName string) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		filtered := families[:0]
+		for _, family := range families {
+			if isRuntimeMetric(family.GetName()) {
+				continue
+			}
+
+			metrics := family.Metric[:0]
+			for _, m := range family.Metric {
+				if keepWorkflowSeries(m, workflowName) {
+					metrics = append(metrics, m)
+				}
+			}
+			if len(metrics) > 0 {
+				family.Metric = metrics
+				filtered = append(filtered, family)
+			}
+		}
+		return filtered, err
+	})
+}
+
+// keepWorkflowSeries reports whether m belongs to the workflow, removing its
+// workflow label if so. Series without the label, such as agent metrics, are
+// kept.
+func keepWorkflowSeries(m *dto.Metric, workflowName string) bool {
+	for i, label := range m.Label {
+		if label.GetName() != "workflow" {
+			continue
+		}
+		if label.GetValue() != workflowName {
+			return false
+		}
+		m.Label = append(m.Label[:i], m.Label[i+1:]...)
+		return true
+	}
+	return true
+}
+
+func isRuntimeMetric(name string) bool {
+	for _, prefix := range runtimeMetricPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPush(t *testing.T) {
+	t.Run("Groups By Workflow", func(t *testing.T) {
+		var method, path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path = r.Method, r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		RecordWorkflowExecution(&workflow.ExecutionResult{Workflow: "nightly-backup", Status: "success"})
+		if err := Push(server.URL, "", "nightly-backup"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if method != http.MethodPut {
+			t.Errorf("Expected PUT, got %s", method)
+		}
+		if path != "/metrics/job/autozap/workflow/nightly-backup" {
+			t.Errorf("Unexpected push path: %s", path)
+		}
+	})
+
+	t.Run("Pushgateway Error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "pushgateway unavailable", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		if err := Push(server.URL, "batch", "nightly-backup"); err == nil {
+			t.Fatal("Expected error for failed push, got nil")
+		}
+	})
+}
+
+func TestWorkflowGatherer(t *testing.T) {
+	RecordWorkflowExecution(&workflow.ExecutionResult{Workflow: "nightly-backup", Status: "success"})
+	RecordWorkflowExecution(&workflow.ExecutionResult{Workflow: "hourly-sync", Status: "failed"})
+
+	families, err := workflowGatherer(prometheus.DefaultGatherer, "nightly-backup").Gather()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	found := false
+	for _, family := range families {
+		name := family.GetName()
+		if strings.HasPrefix(name, "go_") || strings.HasPrefix(name, "process_") {
+			t.Errorf("Expected runtime metric %s to be filtered out", name)
+		}
+		for _, m := range family.Metric {
+			for _, label := range m.Label {
+				if label.GetName() == "workflow" {
+					t.Errorf("Expected workflow label to be removed from %s", name)
+				}
+				if label.GetValue() == "failed" && name == "autozap_workflow_executions_total" {
+					t.Error("Expected series of other workflows to be filtered out")
+				}
+			}
+		}
+		if name == "autozap_workflow_executions_total" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected autozap_workflow_executions_total to be pushed")
+	}
+}
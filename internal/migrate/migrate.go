@@ -0,0 +1,189 @@
+// Package migrate rewrites workflow files written for an older version of the
+// workflow schema to the current one. Migrations edit the file's text at the
+// positions the YAML parser reports, rather than re-encoding the document, so
+// comments, quoting and blank lines are kept as they are.
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migration is one change to the workflow schema
+type migration struct {
+	name string
+
+	// edits returns the edits that bring the actions of a workflow, or of an
+	// included actions file, up to date
+	edits func(action *yaml.Node) []edit
+}
+
+// Change is an edit made by a migration
+type Change struct {
+	Line      int
+	Migration string
+	Message   string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("line %d: %s (%s)", c.Line, c.Message, c.Migration)
+}
+
+// edit replaces the text old at a line and column, both starting at 1 as
+// reported by yaml.Node, with new
+type edit struct {
+	line, column int
+	old, new     string
+	message      string
+}
+
+// migrations are the schema changes Source applies, oldest first
+var migrations = []migration{
+	// The HTTP expectations were the only snake_case action fields
+	{
+		name: "expect-camel-case",
+		edits: func(action *yaml.Node) []edit {
+			return renameKeys(action, map[string]string{
+				"expect_status":        "expectStatus",
+				"expect_body_contains": "expectBodyContains",
+			})
+		},
+	},
+}
+
+// Source returns a workflow file, or a file of shared actions, migrated to
+// the current schema, with the changes made. A file that is up to date is
+// returned unchanged, with no changes.
+func Source(data []byte) ([]byte, []Change, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	actions := actionNodes(&doc)
+
+	var edits []edit
+	var changes []Change
+	for _, migration := range migrations {
+		for _, action := range actions {
+			for _, e := range migration.edits(action) {
+				edits = append(edits, e)
+				changes = append(changes, Change{Line: e.line, Migration: migration.name, Message: e.message})
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return data, nil, nil
+	}
+
+	migrated, err := applyEdits(data, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Line < changes[j].Line })
+	return migrated, changes, nil
+}
+
+// actionNodes returns the action mappings of a workflow's 'actions' or of a
+// shared actions file's 'actions'
+func actionNodes(doc *yaml.Node) []*yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var actions []*yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "actions" || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, action := range root.Content[i+1].Content {
+			if action.Kind == yaml.MappingNode {
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
+
+// renameKeys returns the edits renaming the keys of a mapping found in names
+// to their new names. A key whose new name is already set is left for the
+// parser to report.
+func renameKeys(mapping *yaml.Node, names map[string]string) []edit {
+	present := make(map[string]bool, len(mapping.Content)/2)
+	for i := 0; i < len(mapping.Content); i += 2 {
+		present[mapping.Content[i].Value] = true
+	}
+
+	var edits []edit
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		newName, ok := names[key.Value]
+		if !ok || present[newName] {
+			continue
+		}
+		edits = append(edits, edit{
+			line:    key.Line,
+			column:  key.Column,
+			old:     key.Value,
+			new:     newName,
+			message: fmt.Sprintf("renamed '%s' to '%s'", key.Value, newName),
+		})
+	}
+	return edits
+}
+
+// applyEdits applies edits to data, checking that each finds the text it
+// replaces, possibly quoted, at its position
+func applyEdits(data []byte, edits []edit) ([]byte, error) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	// Later edits on a line first, so earlier columns stay valid
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line < edits[j].line
+		}
+		return edits[i].column > edits[j].column
+	})
+
+	for _, e := range edits {
+		if e.line < 1 || e.line > len(lines) {
+			return nil, fmt.Errorf("line %d: out of range", e.line)
+		}
+		line := lines[e.line-1]
+		offset := byteOffset(line, e.column)
+		rest := line[offset:]
+		if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+			// Keep the quotes around a quoted key
+			offset++
+			rest = rest[1:]
+		}
+		if !bytes.HasPrefix(rest, []byte(e.old)) {
+			return nil, fmt.Errorf("line %d: expected '%s' at column %d", e.line, e.old, e.column)
+		}
+
+		edited := make([]byte, 0, len(line)+len(e.new)-len(e.old))
+		edited = append(edited, line[:offset]...)
+		edited = append(edited, e.new...)
+		edited = append(edited, line[offset+len(e.old):]...)
+		lines[e.line-1] = edited
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// byteOffset converts a column, counted in characters from 1, to an offset
+// into line
+func byteOffset(line []byte, column int) int {
+	offset := 0
+	for i := 1; i < column && offset < len(line); i++ {
+		_, size := utf8.DecodeRune(line[offset:])
+		offset += size
+	}
+	return offset
+}
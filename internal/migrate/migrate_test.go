@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	t.Run("Renames Keys In Place", func(t *testing.T) {
+		old := `# Health checks
+name: api-health
+trigger:
+  type: cron
+  schedule: "*/5 * * * *"
+
+actions:
+  - type: http
+    name: check
+    url: https://example.com/health
+    method: GET
+    expect_status: [200, 204]   # both are fine
+    "expect_body_contains": 'héalthy'
+
+  - type: http
+    name: ping
+    url: https://example.com/ping
+    method: GET
+    headers: {expect_status: keep}
+    expect_status: 200
+`
+		want := strings.NewReplacer(
+			"    expect_status: [200, 204]", "    expectStatus: [200, 204]",
+			`"expect_body_contains"`, `"expectBodyContains"`,
+			"    expect_status: 200", "    expectStatus: 200",
+		).Replace(old)
+
+		migrated, changes, err := Source([]byte(old))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if string(migrated) != want {
+			t.Errorf("Expected:\n%s\ngot:\n%s", want, migrated)
+		}
+		if len(changes) != 3 {
+			t.Fatalf("Expected 3 changes, got %v", changes)
+		}
+		if got := changes[0].String(); got != "line 12: renamed 'expect_status' to 'expectStatus' (expect-camel-case)" {
+			t.Errorf("Unexpected first change: %s", got)
+		}
+		if changes[2].Line != 20 {
+			t.Errorf("Expected the last change on line 20, got %d", changes[2].Line)
+		}
+	})
+
+	t.Run("Up To Date", func(t *testing.T) {
+		current := "actions:\n  - type: http\n    name: check\n    expectStatus: 200\n"
+		migrated, changes, err := Source([]byte(current))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(changes) != 0 || string(migrated) != current {
+			t.Errorf("Expected no changes, got %v", changes)
+		}
+	})
+
+	t.Run("Keeps Conflicting Keys", func(t *testing.T) {
+		// Both spellings is an error for the parser to report, not a rename
+		both := "actions:\n  - type: http\n    expect_status: 200\n    expectStatus: 201\n"
+		_, changes, err := Source([]byte(both))
+		if err != nil || len(changes) != 0 {
+			t.Errorf("Expected the file to be left alone, got %v (%v)", changes, err)
+		}
+	})
+
+	t.Run("Invalid YAML", func(t *testing.T) {
+		if _, _, err := Source([]byte("actions: [")); err == nil {
+			t.Error("Expected an error for invalid YAML")
+		}
+	})
+}
@@ -0,0 +1,197 @@
+// Package mqtt holds the agent's connection to its MQTT broker, which is
+// shared by all mqtt triggers and actions.
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/logger"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultPublishTimeout bounds how long a publish waits for the broker
+const DefaultPublishTimeout = 10 * time.Second
+
+// connectWait is how long Connect waits for the first connection before
+// returning; the client keeps retrying in the background afterwards
+var connectWait = 10 * time.Second
+
+// Handler receives the messages of a subscription
+type Handler func(topic string, payload []byte)
+
+var (
+	mu         sync.Mutex
+	client     paho.Client
+	handlers   = make(map[string]map[int]Handler) // by topic filter, then subscription id
+	qosByTopic = make(map[string]byte)
+	nextID     int
+)
+
+// Connect connects to the broker and keeps the connection up, reconnecting
+// and resubscribing after it drops. It only fails for invalid settings; an
+// unreachable broker is logged and retried.
+func Connect(cfg config.MQTTConfig) error {
+	clientID := cfg.ClientID
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "autozap-" + hostname
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(resubscribe).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			logger.L().Warnw("MQTT connection lost, reconnecting",
+				"broker", cfg.Broker,
+				"error", err)
+		})
+
+	c := paho.NewClient(opts)
+	mu.Lock()
+	client = c
+	mu.Unlock()
+
+	token := c.Connect()
+	if !token.WaitTimeout(connectWait) {
+		logger.L().Warnw("MQTT broker not reachable yet, retrying in the background",
+			"broker", cfg.Broker)
+		return nil
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker %s: %w", cfg.Broker, err)
+	}
+
+	logger.L().Infow("Connected to MQTT broker",
+		"broker", cfg.Broker,
+		"client_id", clientID)
+	return nil
+}
+
+// Disconnect closes the connection to the broker
+func Disconnect() {
+	mu.Lock()
+	c := client
+	client = nil
+	handlers = make(map[string]map[int]Handler)
+	qosByTopic = make(map[string]byte)
+	mu.Unlock()
+
+	if c != nil {
+		c.Disconnect(250)
+	}
+}
+
+// Configured reports whether Connect has been called
+func Configured() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return client != nil
+}
+
+// Subscribe calls handler for every message matching the topic filter, which
+// may contain the + and # wildcards. Several subscriptions may share a topic.
+// The returned function removes the subscription.
+func Subscribe(topic string, qos byte, handler Handler) (unsubscribe func(), err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("MQTT broker is not configured; set 'mqtt.broker' in the agent config")
+	}
+
+	if handlers[topic] == nil {
+		handlers[topic] = make(map[int]Handler)
+	}
+	// The broker subscription uses the highest QoS any subscriber asked for
+	if qos > qosByTopic[topic] || len(handlers[topic]) == 0 {
+		qosByTopic[topic] = qos
+	}
+	nextID++
+	id := nextID
+	handlers[topic][id] = handler
+
+	// While disconnected the subscription is made by resubscribe on connect
+	if client.IsConnectionOpen() {
+		if err := waitToken(client.Subscribe(topic, qosByTopic[topic], dispatch(topic)), connectWait); err != nil {
+			delete(handlers[topic], id)
+			return nil, fmt.Errorf("failed to subscribe to MQTT topic '%s': %w", topic, err)
+		}
+	}
+
+	return func() { unsubscribeHandler(topic, id) }, nil
+}
+
+func unsubscribeHandler(topic string, id int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(handlers[topic], id)
+	if len(handlers[topic]) > 0 {
+		return
+	}
+	delete(handlers, topic)
+	delete(qosByTopic, topic)
+	if client != nil && client.IsConnectionOpen() {
+		client.Unsubscribe(topic)
+	}
+}
+
+// resubscribe restores all subscriptions after a (re)connect
+func resubscribe(c paho.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for topic := range handlers {
+		c.Subscribe(topic, qosByTopic[topic], dispatch(topic))
+	}
+}
+
+// dispatch returns the broker callback for a topic filter, which passes each
+// message to the filter's current handlers
+func dispatch(topic string) paho.MessageHandler {
+	return func(_ paho.Client, msg paho.Message) {
+		mu.Lock()
+		current := make([]Handler, 0, len(handlers[topic]))
+		for _, h := range handlers[topic] {
+			current = append(current, h)
+		}
+		mu.Unlock()
+
+		for _, h := range current {
+			h(msg.Topic(), msg.Payload())
+		}
+	}
+}
+
+// Publish sends a message and waits for the broker to accept it according to
+// qos, up to timeout
+func Publish(topic string, qos byte, retain bool, payload string, timeout time.Duration) error {
+	mu.Lock()
+	c := client
+	mu.Unlock()
+
+	if c == nil {
+		return fmt.Errorf("MQTT broker is not configured; set 'mqtt.broker' in the agent config")
+	}
+	if !c.IsConnectionOpen() {
+		return fmt.Errorf("not connected to MQTT broker")
+	}
+	return waitToken(c.Publish(topic, qos, retain, payload), timeout)
+}
+
+func waitToken(token paho.Token, timeout time.Duration) error {
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("timed out after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	return token.Error()
+}
@@ -0,0 +1,131 @@
+package mqtt
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/logger"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+func init() {
+	logger.InitLogger()
+}
+
+// startBroker runs an in-process broker and returns its tcp:// address
+func startBroker(t *testing.T) (*mochi.Server, string) {
+	t.Helper()
+
+	broker := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := broker.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("Failed to add auth hook: %v", err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{Type: "tcp", ID: "test", Address: "127.0.0.1:0"})
+	if err := broker.AddListener(tcp); err != nil {
+		t.Fatalf("Failed to add listener: %v", err)
+	}
+	go broker.Serve()
+	t.Cleanup(func() { broker.Close() })
+
+	return broker, "tcp://" + tcp.Address()
+}
+
+func receive(t *testing.T, messages <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for message")
+		return ""
+	}
+}
+
+func TestNotConfigured(t *testing.T) {
+	if Configured() {
+		t.Fatal("Expected no broker to be configured")
+	}
+	if _, err := Subscribe("sensors/#", 0, func(string, []byte) {}); err == nil {
+		t.Error("Expected error subscribing without a broker, got nil")
+	}
+	if err := Publish("sensors/door", 0, false, "open", time.Second); err == nil {
+		t.Error("Expected error publishing without a broker, got nil")
+	}
+}
+
+func TestSubscribeAndPublish(t *testing.T) {
+	broker, addr := startBroker(t)
+
+	if err := Connect(config.MQTTConfig{Broker: addr, ClientID: "autozap-test"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	t.Cleanup(Disconnect)
+
+	t.Run("Shared Topic", func(t *testing.T) {
+		first := make(chan string, 1)
+		second := make(chan string, 1)
+		unsubscribeFirst, err := Subscribe("sensors/+/state", 1, func(topic string, payload []byte) {
+			first <- topic + " " + string(payload)
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		unsubscribeSecond, err := Subscribe("sensors/+/state", 0, func(topic string, payload []byte) {
+			second <- topic + " " + string(payload)
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		defer unsubscribeSecond()
+
+		if err := broker.Publish("sensors/door/state", []byte("open"), false, 0); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		if got := receive(t, first); got != "sensors/door/state open" {
+			t.Errorf("Expected 'sensors/door/state open', got '%s'", got)
+		}
+		if got := receive(t, second); got != "sensors/door/state open" {
+			t.Errorf("Expected 'sensors/door/state open', got '%s'", got)
+		}
+
+		// The remaining subscriber keeps receiving messages
+		unsubscribeFirst()
+		if err := broker.Publish("sensors/window/state", []byte("closed"), false, 0); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		if got := receive(t, second); got != "sensors/window/state closed" {
+			t.Errorf("Expected 'sensors/window/state closed', got '%s'", got)
+		}
+		select {
+		case msg := <-first:
+			t.Errorf("Expected no message after unsubscribing, got '%s'", msg)
+		default:
+		}
+	})
+
+	t.Run("Publish", func(t *testing.T) {
+		published := make(chan string, 1)
+		err := broker.Subscribe("lights/+", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+			published <- pk.TopicName + " " + string(pk.Payload)
+		})
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+
+		if err := Publish("lights/hall", 1, false, "on", time.Second); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := receive(t, published); got != "lights/hall on" {
+			t.Errorf("Expected 'lights/hall on', got '%s'", got)
+		}
+	})
+}
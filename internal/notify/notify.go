@@ -0,0 +1,140 @@
+// Package notify posts failed runs to the webhooks in the agent configuration,
+// so teams hear about failures without adding an alert action to every
+// workflow.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/httpclient"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+var (
+	mu            sync.RWMutex
+	notifications []config.NotificationConfig
+
+	// deliver posts a notification in the background; replaced in tests
+	deliver = func(n config.NotificationConfig, body []byte, headers map[string]string) {
+		go post(n, body, headers)
+	}
+)
+
+// Configure replaces the notification webhooks. They must have been validated
+// with config.AgentConfig.Validate.
+func Configure(configs []config.NotificationConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	notifications = configs
+}
+
+// HandleFailure posts a failed or partially successful run to the webhooks it
+// matches. It is meant to be registered with executor.SetFailureHandler.
+func HandleFailure(wf *workflow.Workflow, triggerType string, workflowExecID int64, status, errMsg string) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	data := templating.Data{
+		"workflow": map[string]interface{}{
+			"name":       wf.Name,
+			"owner":      wf.Owner,
+			"docsUrl":    wf.DocsURL,
+			"runbookUrl": wf.RunbookURL,
+		},
+		"status":      status,
+		"error":       errMsg,
+		"executionId": workflowExecID,
+		"triggerType": triggerType,
+	}
+	for _, n := range notifications {
+		if !matches(n, wf.Name, status) {
+			continue
+		}
+		body, headers, err := render(n, data)
+		if err != nil {
+			logger.L().Errorw("Failed to render notification",
+				"notification", n.Name,
+				"workflow_name", wf.Name,
+				"error", err)
+			continue
+		}
+		deliver(n, body, headers)
+	}
+}
+
+// matches reports whether a run of workflowName that ended with status is
+// notified to n
+func matches(n config.NotificationConfig, workflowName, status string) bool {
+	if !slices.Contains(n.MatchStatuses(), status) {
+		return false
+	}
+	return len(n.Workflows) == 0 || slices.Contains(n.Workflows, workflowName)
+}
+
+// render returns the body of a notification, its body template or the run as
+// JSON, and its headers, which are templates too, e.g. for a {{ secret }}
+func render(n config.NotificationConfig, data templating.Data) ([]byte, map[string]string, error) {
+	headers := make(map[string]string, len(n.Headers))
+	for key, value := range n.Headers {
+		rendered, err := templating.Render(n.Name+".headers."+key, value, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		headers[key] = rendered
+	}
+
+	if n.Body == "" {
+		body, err := json.Marshal(data)
+		return body, headers, err
+	}
+	body, err := templating.Render(n.Name+".body", n.Body, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []byte(body), headers, nil
+}
+
+// post sends a notification, logging instead of returning errors
+func post(n config.NotificationConfig, body []byte, headers map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpclient.Timeout())
+	defer cancel()
+
+	err := send(ctx, n.URL, body, headers)
+	if err != nil {
+		logger.L().Errorw("Failed to send notification",
+			"notification", n.Name,
+			"error", err)
+		return
+	}
+	logger.L().Infow("Sent notification", "notification", n.Name)
+}
+
+func send(ctx context.Context, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+type delivery struct {
+	name    string
+	body    string
+	headers map[string]string
+}
+
+// recordDeliveries replaces deliver and returns the notifications sent
+func recordDeliveries(t *testing.T) *[]delivery {
+	t.Helper()
+	var sent []delivery
+	original := deliver
+	deliver = func(n config.NotificationConfig, body []byte, headers map[string]string) {
+		sent = append(sent, delivery{n.Name, string(body), headers})
+	}
+	t.Cleanup(func() { deliver = original })
+	return &sent
+}
+
+func TestHandleFailure(t *testing.T) {
+	sent := recordDeliveries(t)
+	Configure([]config.NotificationConfig{
+		{Name: "all", URL: "https://hooks.example.com/all"},
+		{
+			Name:      "api",
+			URL:       "https://hooks.example.com/api",
+			Workflows: []string{"api-check"},
+			Statuses:  []string{workflow.StatusPartialSuccess},
+			Headers:   map[string]string{"X-Workflow": "{{ .workflow.name }}"},
+			Body:      `{"text": "{{ .workflow.name }} {{ .status }}: {{ .error }}"}`,
+		},
+	})
+	t.Cleanup(func() { Configure(nil) })
+
+	api := &workflow.Workflow{Name: "api-check"}
+
+	t.Run("Failed Run Uses Default Body", func(t *testing.T) {
+		*sent = nil
+		HandleFailure(api, "cron", 7, workflow.StatusFailed, "exit status 1")
+		if len(*sent) != 1 || (*sent)[0].name != "all" {
+			t.Fatalf("Expected only 'all' to be notified, got %+v", *sent)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte((*sent)[0].body), &body); err != nil {
+			t.Fatalf("Expected a JSON body, got %q: %v", (*sent)[0].body, err)
+		}
+		if body["status"] != workflow.StatusFailed || body["error"] != "exit status 1" || body["executionId"] != float64(7) {
+			t.Errorf("Unexpected body: %v", body)
+		}
+	})
+
+	t.Run("Statuses, Workflows And Templates", func(t *testing.T) {
+		*sent = nil
+		HandleFailure(api, "cron", 8, workflow.StatusPartialSuccess, "optional step failed")
+		HandleFailure(&workflow.Workflow{Name: "report"}, "cron", 9, workflow.StatusPartialSuccess, "optional step failed")
+		if len(*sent) != 1 || (*sent)[0].name != "api" {
+			t.Fatalf("Expected only 'api' to be notified once, got %+v", *sent)
+		}
+		if want := `{"text": "api-check partial-success: optional step failed"}`; (*sent)[0].body != want {
+			t.Errorf("Expected body %s, got %s", want, (*sent)[0].body)
+		}
+		if (*sent)[0].headers["X-Workflow"] != "api-check" {
+			t.Errorf("Expected rendered header, got %v", (*sent)[0].headers)
+		}
+	})
+}
+
+func TestSend(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	if err := send(t.Context(), srv.URL, []byte(`{}`), map[string]string{"Authorization": "Bearer token"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	select {
+	case r := <-received:
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s with %s", r.Method, r.Header.Get("Content-Type"))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the webhook to be called")
+	}
+
+	if err := send(t.Context(), srv.URL, []byte(`{}`), nil); err == nil {
+		t.Error("Expected error for status 401, got nil")
+	}
+}
@@ -1,18 +1,31 @@
 package parser
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
 
+	"github.com/codecrafted007/autozap/internal/action"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
 	"github.com/codecrafted007/autozap/internal/workflow"
 	"gopkg.in/yaml.v3"
 )
 
 func ParseWorkflowFile(filePath string) (*workflow.Workflow, error) {
-	// This function will read the YAML file at filePath,
+	// This function will read the YAML or JSON file at filePath,
 	// parse it into a workflow.Workflow struct, and return it.
-	// For now, we will just return nil and nil to avoid compilation errors.
 
 	if _, err := os.Stat(filePath); err != nil {
 		return nil, fmt.Errorf("workflow file not found: %s", filePath)
@@ -23,19 +36,125 @@ func ParseWorkflowFile(filePath string) (*workflow.Workflow, error) {
 		return nil, fmt.Errorf("failed to read workflow file: %s %w", filePath, err)
 	}
 
+	wf, err := parseWorkflow(yamFile, filePath, filepath.Dir(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveActionFiles(wf, filepath.Dir(filePath)); err != nil {
+		return nil, fmt.Errorf("workflow validation failed for file %s: %w", filePath, err)
+	}
+	logger.L().Infof("Successfully parsed workflow file: %s", filePath)
+	return wf, nil
+}
+
+// ParseWorkflow parses and validates a workflow definition that has already
+// been loaded into memory. source identifies where the data came from (a file
+// path, URL, ...) and is only used in error messages.
+//
+// Action includes need a directory to be resolved against, so they are only
+// supported by ParseWorkflowFile.
+func ParseWorkflow(data []byte, source string) (*workflow.Workflow, error) {
+	return parseWorkflow(data, source, "")
+}
+
+// parseWorkflow parses a workflow, expanding action includes relative to
+// baseDir if it is set
+func parseWorkflow(data []byte, source, baseDir string) (*workflow.Workflow, error) {
 	var wf workflow.Workflow
 
-	if err := yaml.Unmarshal(yamFile, &wf); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal workflow YAML file: %s %w", filePath, err)
+	format := "YAML"
+	if isJSON(data, source) {
+		format = "JSON"
+		if err := checkJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal workflow JSON file: %s %w", source, err)
+		}
+	}
+	if err := decodeStrict(data, &wf); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow %s file: %s %w", format, source, err)
+	}
+
+	actions, err := expandIncludes(wf.Actions, baseDir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("workflow validation failed for file %s: %w", source, err)
+	}
+	wf.Actions = actions
+
+	if err := upgradeDeprecatedFields(wf.Actions); err != nil {
+		return nil, fmt.Errorf("workflow validation failed for file %s: %w", source, err)
 	}
 
 	if err := validateWorkflow(&wf); err != nil {
-		return nil, fmt.Errorf("workflow validation failed for file %s: %w", filePath, err)
+		return nil, fmt.Errorf("workflow validation failed for file %s: %w", source, err)
 	}
-	logger.L().Infof("Successfully parsed workflow file: %s", filePath)
 	return &wf, nil
 }
 
+// lenient turns off strict parsing, see SetStrict
+var lenient atomic.Bool
+
+// SetStrict sets whether workflows with unknown fields, e.g. a misspelled
+// 'commnad', fail to parse, which is the default. Turning it off lets a
+// workflow written for a newer release load, ignoring the fields this one
+// doesn't know.
+func SetStrict(strict bool) {
+	lenient.Store(!strict)
+}
+
+// decodeStrict decodes a workflow or a file of shared actions into v,
+// rejecting fields v has no place for unless strict parsing is off
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(!lenient.Load())
+	err := decoder.Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil // empty file, reported by validation
+	}
+	if err != nil && strings.Contains(err.Error(), "not found in type") {
+		return fmt.Errorf("%w (check the spelling, or pass --no-strict to ignore unknown fields)", err)
+	}
+	return err
+}
+
+// isJSON reports whether a workflow definition is a JSON object rather than
+// YAML, e.g. one generated by a program. JSON is valid YAML, so both are
+// decoded into the workflow and validated the same way; JSON is only checked
+// first to report syntax errors by line.
+//
+// The extension of source decides, so a .yaml file written as a flow mapping
+// ({name: backup, ...}) stays YAML. Data from a source without one, such as
+// a dead letter, is JSON if it starts with '{'.
+func isJSON(data []byte, source string) bool {
+	if i := strings.IndexAny(source, "?#"); i >= 0 {
+		source = source[:i] // query or fragment of a URL
+	}
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".json":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// checkJSON reports JSON syntax errors with their line, which the YAML
+// decoder would report in YAML terms
+func checkJSON(data []byte) error {
+	var doc json.RawMessage
+	err := json.Unmarshal(data, &doc)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return err
+}
+
+// varName matches var names usable as {{ .vars.<name> }}, and captureAs
+// names usable as {{ .captured.<name> }}
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 func validateWorkflow(wf *workflow.Workflow) error {
 	if wf.Name == "" {
 		return fmt.Errorf("workflow name cannot be empty")
@@ -45,97 +164,290 @@ func validateWorkflow(wf *workflow.Workflow) error {
 		return fmt.Errorf("workflow must define at least one action")
 	}
 
-	switch wf.Trigger.Type {
-	case workflow.TriggerTypeCron:
-		if wf.Trigger.Schedule == "" {
-			return fmt.Errorf("cron trigger requires a 'schedule'")
+	validate, ok := lookupTriggerType(wf.Trigger.Type)
+	if !ok {
+		return fmt.Errorf("unsupported trigger type: %s", wf.Trigger.Type)
+	}
+	if validate != nil {
+		if err := validate(&wf.Trigger); err != nil {
+			return err
 		}
+	}
 
-		if wf.Trigger.Path != "" || len(wf.Trigger.Events) > 0 {
-			logger.L().Warnf("cron trigger has unexpected 'path' or 'event' these will be ignored.")
+	switch wf.ConcurrencyPolicy {
+	case "", workflow.ConcurrencyAllow, workflow.ConcurrencyForbid, workflow.ConcurrencyReplace:
+	default:
+		return fmt.Errorf("invalid concurrencyPolicy '%s'. Must be one of: %s, %s, %s",
+			wf.ConcurrencyPolicy, workflow.ConcurrencyAllow, workflow.ConcurrencyForbid, workflow.ConcurrencyReplace)
+	}
+
+	for _, link := range []struct{ field, url string }{{"docsUrl", wf.DocsURL}, {"runbookUrl", wf.RunbookURL}} {
+		if link.url == "" {
+			continue
 		}
-	case workflow.TriggerTypeFileWatch:
-		if wf.Trigger.Path == "" {
-			return fmt.Errorf("filewatch trigger requires a 'path'")
+		if u, err := url.Parse(link.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid '%s' '%s': must be an absolute http or https URL", link.field, link.url)
 		}
+	}
 
-		if len(wf.Trigger.Events) == 0 {
-			return fmt.Errorf("filewatch trigger requires at least one 'event'")
+	services := make(map[string]bool, len(wf.DependsOnServices))
+	for _, name := range wf.DependsOnServices {
+		if name == "" {
+			return fmt.Errorf("dependsOnServices cannot contain an empty service name")
+		}
+		if services[name] {
+			return fmt.Errorf("dependsOnServices lists service '%s' more than once", name)
 		}
+		services[name] = true
+	}
 
-		// Validate event names at parse time
-		if err := validateFileWatchEvents(wf.Trigger.Events); err != nil {
-			return fmt.Errorf("filewatch trigger validation failed: %w", err)
+	if wf.Network != nil {
+		if err := validateNetwork(wf.Network); err != nil {
+			return err
 		}
+	}
 
-		if wf.Trigger.Schedule != "" {
-			logger.L().Warnf("Filewatch trigger has unexpected 'schedule' field; it will be ignored.")
+	if cb := wf.CircuitBreaker; cb != nil {
+		if cb.FailureThreshold < 1 {
+			return fmt.Errorf("circuitBreaker requires a 'failureThreshold' of at least 1")
 		}
-	default:
-		return fmt.Errorf("unsupported trigger type: %s", wf.Trigger.Type)
+		if _, err := cb.CooldownDuration(); err != nil {
+			return fmt.Errorf("circuitBreaker has invalid 'cooldown' '%s': %w", cb.Cooldown, err)
+		}
+	}
 
+	for name := range wf.Vars {
+		if !varName.MatchString(name) {
+			return fmt.Errorf("invalid var name '%s': must start with a letter or underscore and contain only letters, digits and underscores", name)
+		}
 	}
 
 	// Validate Actions
-	for i, action := range wf.Actions {
-		if action.Name == "" {
+	for i, act := range wf.Actions {
+		if act.Name == "" {
 			return fmt.Errorf("action at index %d must have a 'name' ", i)
 		}
 
-		switch action.Type {
-		case workflow.ActionTypeBash:
-			if action.Command == "" {
-				return fmt.Errorf("bash action %s at index %d must have a 'command'", action.Name, i)
-			}
-			//Warn if HTTP/Custom fields are present
-			if action.URL != "" || action.Method != "" || len(action.Headers) > 0 || action.Body != "" {
-				logger.L().Warnf("Bash action %s at index %d has unexpected HTTP fields; they will be ignored.", action.Name, i)
-			}
-		case workflow.ActionTypeHTTP:
-			if action.URL == "" {
-				return fmt.Errorf("HTTP action %s at index %d must have a 'url'", action.Name, i)
-			}
-			if action.Method == "" {
-				return fmt.Errorf("HTTP action %s at index %d must have a 'method'", action.Name, i)
+		if err := action.Validate(&act); err != nil {
+			return fmt.Errorf("%s action %s at index %d %w", act.Type, act.Name, i, err)
+		}
+		if act.Type == workflow.ActionTypeWorkflow && act.Workflow == wf.Name {
+			return fmt.Errorf("workflow action %s at index %d cannot run its own workflow", act.Name, i)
+		}
+		if act.Type == workflow.ActionTypeHTTP && act.UnixSocket != "" && wf.Network != nil && wf.Network.Proxy != "" {
+			logger.L().Warnf("HTTP action %s at index %d uses 'unixSocket'; the workflow's proxy will be ignored for it.", act.Name, i)
+		}
+
+		if act.ForEach != nil {
+			if err := validateForEach(act.ForEach); err != nil {
+				return fmt.Errorf("action %s at index %d has invalid 'foreach': %w", act.Name, i, err)
 			}
+		}
+	}
+
+	// Validate custom metrics
+	for i, m := range wf.Metrics {
+		labels := make([]string, 0, len(m.Labels))
+		for label := range m.Labels {
+			labels = append(labels, label)
+		}
+		if err := metrics.ValidateCustomMetric(m.Name, m.Type, labels); err != nil {
+			return fmt.Errorf("metric at index %d: %w", i, err)
+		}
+		if m.ValueFrom == "" && m.Type != metrics.CustomMetricCounter {
+			return fmt.Errorf("gauge metric %s at index %d must have a 'valueFrom'", m.Name, i)
+		}
+	}
+
+	return nil
+}
+
+// resolveActionFiles makes relative scriptFile, bodyFile, template and
+// workflowFile paths relative to baseDir (the workflow file's directory) and checks that the files exist. The
+// files are read again on every run, so edits take effect without reloading
+// the workflow.
+func resolveActionFiles(wf *workflow.Workflow, baseDir string) error {
+	for i := range wf.Actions {
+		action := &wf.Actions[i]
+		if err := resolveActionFile(&action.ScriptFile, baseDir); err != nil {
+			return fmt.Errorf("bash action %s at index %d: script file %w", action.Name, i, err)
+		}
+		if err := resolveActionFile(&action.BodyFile, baseDir); err != nil {
+			return fmt.Errorf("HTTP action %s at index %d: body file %w", action.Name, i, err)
+		}
+		if err := resolveActionFile(&action.Template, baseDir); err != nil {
+			return fmt.Errorf("template action %s at index %d: template file %w", action.Name, i, err)
+		}
+		if err := resolveActionFile(&action.WorkflowFile, baseDir); err != nil {
+			return fmt.Errorf("workflow action %s at index %d: workflow file %w", action.Name, i, err)
+		}
+	}
+	return nil
+}
 
-			// ExpectStatus validation is handled at runtime with proper type conversion
-			// We allow int, float64, or []interface{} from YAML unmarshaling
+// resolveActionFile resolves *path against baseDir and checks that it is a file
+func resolveActionFile(path *string, baseDir string) error {
+	if *path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(*path) {
+		*path = filepath.Join(baseDir, *path)
+	}
+	info, err := os.Stat(*path)
+	if err != nil {
+		return fmt.Errorf("not found: %s", *path)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("is a directory: %s", *path)
+	}
+	return nil
+}
 
-			// Warn if Bash/Custom fields are present
-			if action.Command != "" || action.FunctionName != "" || action.Arguments != nil {
-				return fmt.Errorf("HTTP action %s at index %d has unexpected Bash or Custom fields; they will be ignored", action.Name, i)
+// sharedActions is the format of a file included from an action list
+type sharedActions struct {
+	Actions []workflow.Action `yaml:"actions"`
+}
+
+// IsSharedActions reports whether data is a file of shared actions for
+// include rather than a workflow: it has actions but no name or trigger. The
+// agent doesn't load such files from its workflow directory.
+func IsSharedActions(data []byte) bool {
+	var doc struct {
+		Name    string      `yaml:"name"`
+		Trigger *yaml.Node  `yaml:"trigger"`
+		Actions []yaml.Node `yaml:"actions"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return len(doc.Actions) > 0 && doc.Name == "" && doc.Trigger == nil
+}
+
+// expandIncludes replaces the include entries of actions with the actions of
+// the included files, resolved against baseDir. Included files may include
+// others relative to their own directory; including lists the files being
+// expanded, to detect cycles. Script, body, template and workflow files of
+// included actions are relative to the file that defines them.
+func expandIncludes(actions []workflow.Action, baseDir string, including []string) ([]workflow.Action, error) {
+	expanded := make([]workflow.Action, 0, len(actions))
+	for i, action := range actions {
+		if action.Include == "" {
+			if len(including) > 0 {
+				joinBaseDir(&action.ScriptFile, baseDir)
+				joinBaseDir(&action.BodyFile, baseDir)
+				joinBaseDir(&action.Template, baseDir)
+				joinBaseDir(&action.WorkflowFile, baseDir)
 			}
-		case workflow.ActionTypeCustom:
-			if action.FunctionName == "" {
-				return fmt.Errorf("custom action %s at index %d must have a 'functionName'", action.Name, i)
+			expanded = append(expanded, action)
+			continue
+		}
+
+		if !reflect.DeepEqual(action, workflow.Action{Include: action.Include}) {
+			return nil, fmt.Errorf("include entry at index %d cannot set other action fields", i)
+		}
+		if baseDir == "" {
+			return nil, fmt.Errorf("include entry at index %d: includes are only supported in workflow files", i)
+		}
+
+		path := action.Include
+		joinBaseDir(&path, baseDir)
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("include entry at index %d: %w", i, err)
+		}
+		if slices.Contains(including, path) {
+			return nil, fmt.Errorf("include entry at index %d: include cycle through %s", i, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("include entry at index %d: failed to read included file: %w", i, err)
+		}
+		var shared sharedActions
+		if err := decodeStrict(data, &shared); err != nil {
+			return nil, fmt.Errorf("include entry at index %d: failed to unmarshal included file %s: %w", i, path, err)
+		}
+		if len(shared.Actions) == 0 {
+			return nil, fmt.Errorf("include entry at index %d: included file %s has no actions", i, path)
+		}
+
+		included, err := expandIncludes(shared.Actions, filepath.Dir(path), append(slices.Clip(including), path))
+		if err != nil {
+			return nil, fmt.Errorf("included file %s: %w", path, err)
+		}
+		expanded = append(expanded, included...)
+	}
+	return expanded, nil
+}
+
+// upgradeDeprecatedFields moves fields set under a deprecated name to the
+// current one, so old workflows keep working until they are migrated
+func upgradeDeprecatedFields(actions []workflow.Action) error {
+	for i := range actions {
+		action := &actions[i]
+		if action.DeprecatedExpectStatus != nil {
+			if action.ExpectStatus != nil {
+				return fmt.Errorf("action %s at index %d cannot have both 'expectStatus' and 'expect_status'", action.Name, i)
 			}
-			if action.Command != "" || action.URL != "" || action.Method != "" || len(action.Headers) > 0 || action.Body != "" {
-				logger.L().Warnf("Custom action %s at index %d has unexpected Bash or HTTP fields; they will be ignored.", action.Name, i)
+			logger.L().Warnf("Action %s at index %d uses the deprecated 'expect_status'; run 'autozap migrate-config' to rename it to 'expectStatus'.", action.Name, i)
+			action.ExpectStatus, action.DeprecatedExpectStatus = action.DeprecatedExpectStatus, nil
+		}
+		if action.DeprecatedExpectBodyContains != "" {
+			if action.ExpectBodyContains != "" {
+				return fmt.Errorf("action %s at index %d cannot have both 'expectBodyContains' and 'expect_body_contains'", action.Name, i)
 			}
-		default:
-			return fmt.Errorf("action %s at index %d has unsupported type: %s", action.Name, i, action.Type)
+			logger.L().Warnf("Action %s at index %d uses the deprecated 'expect_body_contains'; run 'autozap migrate-config' to rename it to 'expectBodyContains'.", action.Name, i)
+			action.ExpectBodyContains, action.DeprecatedExpectBodyContains = action.DeprecatedExpectBodyContains, ""
 		}
 	}
-
 	return nil
 }
 
-// validateFileWatchEvents checks if all event names are valid
-func validateFileWatchEvents(events []string) error {
-	validEvents := map[string]bool{
-		"create": true,
-		"write":  true,
-		"remove": true,
-		"rename": true,
-		"chmod":  true,
+// joinBaseDir makes a relative *path relative to baseDir
+func joinBaseDir(path *string, baseDir string) {
+	if *path != "" && !filepath.IsAbs(*path) {
+		*path = filepath.Join(baseDir, *path)
 	}
+}
 
-	for _, event := range events {
-		if !validEvents[event] {
-			return fmt.Errorf("invalid filewatch event: '%s'. Valid events are: create, write, remove, rename, chmod", event)
+// validateNetwork checks the proxy URL and that DNS overrides map host names
+// to IP addresses
+func validateNetwork(network *workflow.NetworkConfig) error {
+	if network.Proxy != "" {
+		if err := action.ValidateProxy(network.Proxy); err != nil {
+			return fmt.Errorf("network %w", err)
 		}
 	}
 
+	for host, ip := range network.DNSOverride {
+		if host == "" || strings.ContainsAny(host, ":/") {
+			return fmt.Errorf("network 'dnsOverride' has invalid host name '%s'", host)
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("network 'dnsOverride' for '%s' must be an IP address, got '%s'", host, ip)
+		}
+	}
+	return nil
+}
+
+// validateForEach checks that exactly one source of items is set
+func validateForEach(foreach *workflow.ForEachConfig) error {
+	sources := 0
+	for _, set := range []bool{len(foreach.Items) > 0, foreach.Glob != "", foreach.From != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("must have exactly one of 'items', 'glob' or 'from'")
+	}
+	if foreach.Glob != "" && !strings.Contains(foreach.Glob, "{{") {
+		if _, err := filepath.Match(foreach.Glob, ""); err != nil {
+			return fmt.Errorf("invalid 'glob' '%s': %w", foreach.Glob, err)
+		}
+	}
+	if foreach.MaxParallel < 0 {
+		return fmt.Errorf("'maxParallel' cannot be negative")
+	}
 	return nil
 }
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// TriggerValidator checks the fields of a trigger of one type, returning an
+// error for a trigger that can't start and logging fields it ignores
+type TriggerValidator func(t *workflow.Trigger) error
+
+var (
+	triggerTypesMu sync.RWMutex
+	triggerTypes   = make(map[workflow.TriggerType]TriggerValidator)
+)
+
+// RegisterTriggerType makes workflows with a trigger of type typ valid, and
+// checks their trigger with validate if it isn't nil. trigger.Register calls
+// it for every trigger type, the built-in ones included.
+func RegisterTriggerType(typ workflow.TriggerType, validate TriggerValidator) {
+	triggerTypesMu.Lock()
+	defer triggerTypesMu.Unlock()
+	triggerTypes[typ] = validate
+}
+
+// lookupTriggerType returns the validator of a trigger type, and false if the
+// type is unknown
+func lookupTriggerType(typ workflow.TriggerType) (TriggerValidator, bool) {
+	triggerTypesMu.RLock()
+	defer triggerTypesMu.RUnlock()
+
+	validate, ok := triggerTypes[typ]
+	return validate, ok
+}
+
+// TriggerTypes returns the trigger types workflows may use, sorted
+func TriggerTypes() []workflow.TriggerType {
+	triggerTypesMu.RLock()
+	defer triggerTypesMu.RUnlock()
+
+	types := make([]workflow.TriggerType, 0, len(triggerTypes))
+	for typ := range triggerTypes {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package pidfile
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile creates the file at path, which must not exist. The file of an
+// agent that crashed stays behind and has to be removed by hand.
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, errLocked
+	}
+	return file, err
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package pidfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquireStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".autozap.lock")
+
+	// Left behind by an agent that crashed: not locked any more
+	if err := os.WriteFile(path, []byte("99999999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Expected stale lock file to be taken over, got %v", err)
+	}
+	defer lock.Release()
+}
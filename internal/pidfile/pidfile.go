@@ -0,0 +1,78 @@
+// Package pidfile keeps two agents from running against the same workflow
+// directory or database. A lock file holds the process ID of the agent that
+// owns it, and is locked while that agent runs, so a crashed agent's file
+// doesn't block the next start.
+package pidfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// HeldError is returned by Acquire when another process holds the lock
+type HeldError struct {
+	Path string
+	PID  int // 0 if the file doesn't say
+}
+
+func (e *HeldError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%s is locked by another process", e.Path)
+	}
+	return fmt.Sprintf("%s is locked by process %d", e.Path, e.PID)
+}
+
+// Lock is a lock file held by this process
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire locks the file at path, creating it, and writes the process ID to
+// it. If another process holds it, it returns a *HeldError.
+func Acquire(path string) (*Lock, error) {
+	file, err := lockFile(path)
+	if errors.Is(err, errLocked) {
+		return nil, &HeldError{Path: path, PID: readPID(path)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Lock{path: path, file: file}, nil
+}
+
+// Path returns the lock file's path
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// Release removes the lock file and unlocks it
+func (l *Lock) Release() {
+	os.Remove(l.path)
+	l.file.Close()
+}
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("locked")
+
+// readPID returns the process ID in the lock file at path, or 0
+func readPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(string(bytes.TrimSpace(data)))
+	return pid
+}
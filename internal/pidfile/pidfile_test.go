@@ -0,0 +1,47 @@
+package pidfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".autozap.lock")
+
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read lock file: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Expected lock file to contain pid %d, got %q", os.Getpid(), data)
+	}
+
+	// A second agent is refused and told who holds the lock
+	_, err = Acquire(path)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Expected HeldError, got %v", err)
+	}
+	if held.PID != os.Getpid() {
+		t.Errorf("Expected holder pid %d, got %d", os.Getpid(), held.PID)
+	}
+
+	lock.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected lock file to be removed, got %v", err)
+	}
+
+	lock, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	lock.Release()
+}
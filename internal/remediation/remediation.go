@@ -0,0 +1,177 @@
+// Package remediation starts remediation workflows automatically when other
+// workflows fail, according to the rules in the agent configuration.
+package remediation
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// rule is a compiled remediation rule with the times it fired in the last hour
+type rule struct {
+	config.RemediationConfig
+	pattern   *regexp.Regexp
+	workflows map[string]bool
+	fired     []time.Time
+}
+
+var (
+	mu    sync.Mutex
+	rules []*rule
+
+	// run starts a remediation workflow and throttle records one that was
+	// rate limited; replaced in tests
+	run = func(wf *workflow.Workflow, data templating.Data) {
+		go executor.ExecuteWithData(wf, executor.TriggerTypeRemediation, data)
+	}
+	throttle = func(wf *workflow.Workflow, data templating.Data, reason string) {
+		executor.RecordNotStarted(wf, executor.TriggerTypeRemediation, data, workflow.StatusThrottled, reason)
+	}
+)
+
+// Configure replaces the remediation rules. The rules must have been
+// validated with config.AgentConfig.Validate.
+func Configure(configs []config.RemediationConfig) {
+	compiled := make([]*rule, 0, len(configs))
+	for _, c := range configs {
+		r := &rule{RemediationConfig: c, workflows: make(map[string]bool, len(c.Workflows))}
+		if c.ErrorPattern != "" {
+			r.pattern = regexp.MustCompile(c.ErrorPattern)
+		}
+		for _, name := range c.Workflows {
+			r.workflows[name] = true
+		}
+		compiled = append(compiled, r)
+	}
+
+	mu.Lock()
+	rules = compiled
+	mu.Unlock()
+}
+
+// HandleFailure starts the remediation workflows whose rules match a failed
+// or partially successful run. It is meant to be registered with
+// executor.SetFailureHandler.
+//
+// To prevent loops, failed remediation runs never trigger remediation, a rule
+// never runs a remediation for its own workflow, and each rule fires at most
+// MaxPerHour times per hour; further remediations are recorded as throttled.
+func HandleFailure(wf *workflow.Workflow, triggerType string, workflowExecID int64, status, errMsg string) {
+	if triggerType == executor.TriggerTypeRemediation {
+		logger.L().Infow("Not remediating failed remediation run",
+			"workflow_name", wf.Name,
+			"workflow_exec_id", workflowExecID)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	for _, r := range rules {
+		if !r.matches(wf.Name, status, errMsg) {
+			continue
+		}
+
+		remedy, ok := server.GetRegistry().GetWorkflowDefinition(r.Run)
+		if !ok {
+			logger.L().Errorw("Remediation workflow is not loaded",
+				"rule", r.Name,
+				"workflow_name", wf.Name,
+				"remediation_workflow", r.Run)
+			continue
+		}
+		if server.GetRegistry().IsPaused(r.Run) {
+			logger.L().Warnw("Remediation workflow is paused, not running remediation",
+				"rule", r.Name,
+				"workflow_name", wf.Name,
+				"remediation_workflow", r.Run)
+			continue
+		}
+
+		data := templating.Data{"remediation": map[string]interface{}{
+			"rule":        r.Name,
+			"workflow":    wf.Name,
+			"executionId": workflowExecID,
+			"triggerType": triggerType,
+			"status":      status,
+			"error":       errMsg,
+			"owner":       wf.Owner,
+			"docsUrl":     wf.DocsURL,
+			"runbookUrl":  wf.RunbookURL,
+		}}
+		data = executor.WithEvent(data, executor.Event{
+			Time: now,
+			Source: map[string]string{
+				"rule":         r.Name,
+				"workflow":     wf.Name,
+				"execution_id": strconv.FormatInt(workflowExecID, 10),
+			},
+		})
+
+		if !r.allow(now) {
+			logger.L().Warnw("Remediation rate limit reached, not running remediation",
+				"rule", r.Name,
+				"workflow_name", wf.Name,
+				"remediation_workflow", r.Run,
+				"max_per_hour", r.MaxRemediationsPerHour())
+			throttle(remedy, data, fmt.Sprintf("throttled: remediation rule '%s' ran %d times in the last hour", r.Name, r.MaxRemediationsPerHour()))
+			continue
+		}
+
+		r.fired = append(r.fired, now)
+
+		logger.L().Infow("Running remediation workflow",
+			"rule", r.Name,
+			"workflow_name", wf.Name,
+			"workflow_exec_id", workflowExecID,
+			"remediation_workflow", r.Run)
+		metrics.RecordTriggerFire(remedy.Name, executor.TriggerTypeRemediation)
+
+		run(remedy, data)
+	}
+}
+
+// matches reports whether a run of workflowName that ended with status and
+// errMsg matches the rule
+func (r *rule) matches(workflowName, status, errMsg string) bool {
+	if workflowName == r.Run {
+		return false
+	}
+	if !slices.Contains(r.MatchStatuses(), status) {
+		return false
+	}
+	if len(r.workflows) > 0 && !r.workflows[workflowName] {
+		return false
+	}
+	if r.pattern != nil && !r.pattern.MatchString(errMsg) {
+		return false
+	}
+	return true
+}
+
+// allow drops firings older than an hour and reports whether the rule may
+// fire again
+func (r *rule) allow(now time.Time) bool {
+	cutoff := now.Add(-time.Hour)
+	recent := r.fired[:0]
+	for _, t := range r.fired {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	r.fired = recent
+	return len(r.fired) < r.MaxRemediationsPerHour()
+}
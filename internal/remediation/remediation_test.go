@@ -0,0 +1,174 @@
+package remediation
+
+import (
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+// recordRuns replaces run and returns the data of the started remediation
+// workflows, with the workflow name under "started"
+func recordRuns(t *testing.T) *[]templating.Data {
+	t.Helper()
+	var started []templating.Data
+	original := run
+	run = func(wf *workflow.Workflow, data templating.Data) {
+		data["started"] = wf.Name
+		started = append(started, data)
+	}
+	t.Cleanup(func() { run = original })
+	return &started
+}
+
+// recordThrottled replaces throttle and returns the reasons of the throttled
+// remediation runs
+func recordThrottled(t *testing.T) *[]string {
+	t.Helper()
+	var reasons []string
+	original := throttle
+	throttle = func(wf *workflow.Workflow, data templating.Data, reason string) {
+		reasons = append(reasons, reason)
+	}
+	t.Cleanup(func() { throttle = original })
+	return &reasons
+}
+
+func registerWorkflow(name string) *workflow.Workflow {
+	wf := &workflow.Workflow{
+		Name:    name,
+		Trigger: workflow.Trigger{Type: workflow.TriggerTypeCron, Schedule: "@daily"},
+		Actions: []workflow.Action{{Type: workflow.ActionTypeBash, Name: "run", Command: "true"}},
+	}
+	server.GetRegistry().RegisterWorkflow(wf)
+	return wf
+}
+
+func TestHandleFailure(t *testing.T) {
+	api := registerWorkflow("api-check")
+	api.RunbookURL = "https://wiki.example.com/runbooks/api"
+	other := registerWorkflow("report")
+	registerWorkflow("restart-api")
+
+	t.Run("Matching Workflow And Error", func(t *testing.T) {
+		started := recordRuns(t)
+		Configure([]config.RemediationConfig{
+			{Name: "restart", Workflows: []string{"api-check"}, ErrorPattern: "connection refused", Run: "restart-api"},
+		})
+
+		HandleFailure(api, "cron", 7, workflow.StatusFailed, "dial tcp: connection refused")
+		if len(*started) != 1 {
+			t.Fatalf("Expected 1 remediation run, got %d", len(*started))
+		}
+		data := (*started)[0]
+		if data["started"] != "restart-api" {
+			t.Errorf("Expected 'restart-api' to run, got %v", data["started"])
+		}
+		info := data["remediation"].(map[string]interface{})
+		if info["workflow"] != "api-check" || info["executionId"] != int64(7) {
+			t.Errorf("Expected remediation data for api-check #7, got %v", info)
+		}
+		if info["runbookUrl"] != "https://wiki.example.com/runbooks/api" {
+			t.Errorf("Expected remediation data with the runbook URL, got %v", info)
+		}
+	})
+
+	t.Run("Non-Matching Failures", func(t *testing.T) {
+		started := recordRuns(t)
+		Configure([]config.RemediationConfig{
+			{Name: "restart", Workflows: []string{"api-check"}, ErrorPattern: "connection refused", Run: "restart-api"},
+		})
+
+		HandleFailure(api, "cron", 1, workflow.StatusFailed, "exit status 2")
+		HandleFailure(other, "cron", 2, workflow.StatusFailed, "connection refused")
+		if len(*started) != 0 {
+			t.Errorf("Expected no remediation runs, got %d", len(*started))
+		}
+	})
+
+	t.Run("Matching Status", func(t *testing.T) {
+		started := recordRuns(t)
+		Configure([]config.RemediationConfig{
+			{Name: "restart", Workflows: []string{"api-check"}, Run: "restart-api"},
+			{Name: "degraded", Workflows: []string{"api-check"}, Run: "restart-api", Statuses: []string{workflow.StatusPartialSuccess}},
+		})
+
+		HandleFailure(api, "cron", 8, workflow.StatusPartialSuccess, "exit status 1")
+		if len(*started) != 1 {
+			t.Fatalf("Expected 1 remediation run, got %d", len(*started))
+		}
+		info := (*started)[0]["remediation"].(map[string]interface{})
+		if info["rule"] != "degraded" || info["status"] != workflow.StatusPartialSuccess {
+			t.Errorf("Expected the degraded rule to fire for a partial success, got %v", info)
+		}
+
+		HandleFailure(api, "cron", 9, workflow.StatusTimeout, "timed out")
+		if len(*started) != 2 || (*started)[1]["remediation"].(map[string]interface{})["rule"] != "restart" {
+			t.Errorf("Expected only the default rule to fire for a timeout, got %d runs", len(*started))
+		}
+	})
+
+	t.Run("Failed Remediation Run Is Not Remediated", func(t *testing.T) {
+		started := recordRuns(t)
+		Configure([]config.RemediationConfig{
+			{Name: "any-error", ErrorPattern: ".", Run: "restart-api"},
+		})
+
+		HandleFailure(api, executor.TriggerTypeRemediation, 3, workflow.StatusFailed, "connection refused")
+		if len(*started) != 0 {
+			t.Errorf("Expected no remediation runs, got %d", len(*started))
+		}
+	})
+
+	t.Run("Remediation Workflow Does Not Remediate Itself", func(t *testing.T) {
+		started := recordRuns(t)
+		Configure([]config.RemediationConfig{
+			{Name: "any-error", ErrorPattern: ".", Run: "restart-api"},
+		})
+
+		remedy, _ := server.GetRegistry().GetWorkflowDefinition("restart-api")
+		HandleFailure(remedy, "cron", 4, workflow.StatusFailed, "boom")
+		if len(*started) != 0 {
+			t.Errorf("Expected no remediation runs, got %d", len(*started))
+		}
+	})
+
+	t.Run("Max Per Hour", func(t *testing.T) {
+		started := recordRuns(t)
+		throttled := recordThrottled(t)
+		Configure([]config.RemediationConfig{
+			{Name: "restart", Workflows: []string{"api-check"}, Run: "restart-api", MaxPerHour: 2},
+		})
+
+		for i := 0; i < 5; i++ {
+			HandleFailure(api, "cron", int64(i), workflow.StatusFailed, "boom")
+		}
+		if len(*started) != 2 {
+			t.Errorf("Expected 2 remediation runs, got %d", len(*started))
+		}
+		if len(*throttled) != 3 {
+			t.Errorf("Expected 3 throttled remediation runs, got %d", len(*throttled))
+		}
+	})
+
+	t.Run("Remediation Workflow Not Loaded", func(t *testing.T) {
+		started := recordRuns(t)
+		Configure([]config.RemediationConfig{
+			{Name: "restart", Workflows: []string{"api-check"}, Run: "missing"},
+		})
+
+		HandleFailure(api, "cron", 5, workflow.StatusFailed, "boom")
+		if len(*started) != 0 {
+			t.Errorf("Expected no remediation runs, got %d", len(*started))
+		}
+	})
+}
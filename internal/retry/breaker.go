@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // calls pass
+	CircuitOpen     CircuitState = "open"      // calls are blocked until the cooldown ends
+	CircuitHalfOpen CircuitState = "half-open" // one probe call may pass
+)
+
+// CircuitBreaker blocks calls after a number of consecutive failures. Once
+// the cooldown has passed it lets a single probe call through: a successful
+// probe closes the circuit, a failed one opens it for another cooldown.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int // consecutive failures
+	open     bool
+	openedAt time.Time
+	probing  bool // a probe call is in progress
+
+	now func() time.Time // replaced in tests
+}
+
+// NewCircuitBreaker returns a closed breaker that opens after threshold
+// consecutive failures and stays open for cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may proceed. While half-open only one probe
+// is allowed until its result is recorded or released.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state() {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Record records the result of a call. Calls made without Allow, e.g. forced
+// by an operator, count like a probe.
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if b.open || b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = b.now()
+	}
+}
+
+// Release ends a call allowed by Allow without a result, e.g. one that was
+// cancelled, so another probe may run
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+// Failures returns the number of consecutive failures
+func (b *CircuitBreaker) Failures() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures
+}
+
+func (b *CircuitBreaker) state() CircuitState {
+	switch {
+	case !b.open:
+		return CircuitClosed
+	case b.now().Sub(b.openedAt) < b.cooldown:
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
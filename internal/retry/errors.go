@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// HTTPStatusError is returned by actions whose HTTP response had an
+// unexpected status code
+type HTTPStatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *HTTPStatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the status code is worth retrying, e.g. a 503
+// but not a 404
+func (e *HTTPStatusError) Retryable() bool {
+	return IsRetryableHTTPStatus(e.StatusCode)
+}
+
+// TimeoutError is returned by actions that ran out of time
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCodeError is returned by commands that exited with a non-zero code
+type ExitCodeError struct {
+	ExitCode int
+	Err      error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// IsTimeout reports whether err is a timeout: a TimeoutError, an exceeded
+// context deadline or a network timeout
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isNetworkError reports whether err comes from the network, e.g. a refused
+// connection or a failed DNS lookup
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isTyped reports whether err carries one of the structured error types, so
+// retry decisions don't need to match its message
+func isTyped(err error) bool {
+	var statusErr *HTTPStatusError
+	var exitErr *ExitCodeError
+	return errors.As(err, &statusErr) || errors.As(err, &exitErr) || IsTimeout(err) || isNetworkError(err)
+}
@@ -1,8 +1,12 @@
 package retry
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,8 +24,16 @@ func (e *RetryableError) Error() string {
 	return e.Err.Error()
 }
 
-// ExecuteWithRetry executes a function with retry logic based on the retry configuration
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteWithRetry executes a function with retry logic based on the retry
+// configuration. Waiting for the next attempt stops when ctx is cancelled, so
+// a long backoff doesn't hold up a cancelled run or agent shutdown; the last
+// error is then returned together with ctx's error.
 func ExecuteWithRetry(
+	ctx context.Context,
 	actionName string,
 	retryConfig *workflow.RetryConfig,
 	fn func() error,
@@ -93,7 +105,18 @@ func ExecuteWithRetry(
 		)
 
 		// Wait before retrying
-		time.Sleep(delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.L().Warnw("Action retry aborted, context cancelled",
+				"action_name", actionName,
+				"attempt", attempt,
+				"error", ctx.Err(),
+			)
+			return fmt.Errorf("%w (retry aborted: %w)", err, ctx.Err())
+		case <-timer.C:
+		}
 	}
 
 	return lastErr
@@ -121,59 +144,107 @@ func calculateDelay(attempt int, initialDelay, maxDelay time.Duration, multiplie
 	return finalDelay
 }
 
-// shouldRetry determines if an error should trigger a retry
+// shouldRetry determines if an error should trigger a retry. Decisions are
+// made on the structured error types returned by actions; the error message
+// is only matched for errors that don't carry one.
 func shouldRetry(err error, retryOn []string) bool {
 	if err == nil {
 		return false
 	}
 
-	// If no retry conditions specified, retry on all errors
+	// If no retry conditions specified, retry on all errors except those
+	// marked as not retryable, e.g. an HTTP 404
 	if len(retryOn) == 0 {
-		return true
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			return statusErr.Retryable()
+		}
+		var retryable *RetryableError
+		return !errors.As(err, &retryable) || retryable.Retryable
 	}
 
-	errMsg := err.Error()
-	errMsgLower := strings.ToLower(errMsg)
-
 	for _, condition := range retryOn {
-		conditionLower := strings.ToLower(condition)
-
-		// Check for specific conditions
-		switch {
-		case conditionLower == "timeout":
-			if strings.Contains(errMsgLower, "timeout") ||
-				strings.Contains(errMsgLower, "deadline exceeded") {
-				return true
-			}
-
-		case conditionLower == "error":
+		condition = strings.ToLower(condition)
+		if condition == "error" {
 			// Retry on any error
 			return true
+		}
 
-		case strings.HasPrefix(conditionLower, "status:"):
-			// HTTP status code check (e.g., "status:500")
-			statusCode := strings.TrimPrefix(conditionLower, "status:")
-			if strings.Contains(errMsgLower, "status code "+statusCode) ||
-				strings.Contains(errMsgLower, "status "+statusCode) {
-				return true
-			}
+		matched, typed := matchTyped(err, condition)
+		if !typed {
+			matched = matchMessage(err.Error(), condition)
+		}
+		if matched {
+			return true
+		}
+	}
 
-		case conditionLower == "network":
-			if strings.Contains(errMsgLower, "network") ||
-				strings.Contains(errMsgLower, "connection") ||
-				strings.Contains(errMsgLower, "dns") {
-				return true
-			}
+	return false
+}
 
-		default:
-			// Check if error message contains the condition
-			if strings.Contains(errMsgLower, conditionLower) {
-				return true
-			}
+// matchTyped matches a retryOn condition against the structured type of err.
+// typed is false if err doesn't carry a type the condition can be decided on.
+func matchTyped(err error, condition string) (matched, typed bool) {
+	var statusErr *HTTPStatusError
+	var exitErr *ExitCodeError
+
+	switch {
+	case condition == "timeout":
+		if IsTimeout(err) {
+			return true, true
+		}
+
+	case condition == "network":
+		if isNetworkError(err) && !IsTimeout(err) {
+			return true, true
 		}
+
+	case strings.HasPrefix(condition, "status:"):
+		// HTTP status code check (e.g., "status:500")
+		if errors.As(err, &statusErr) {
+			return strconv.Itoa(statusErr.StatusCode) == strings.TrimPrefix(condition, "status:"), true
+		}
+
+	case strings.HasPrefix(condition, "exit:"):
+		// Command exit code check (e.g., "exit:75")
+		if errors.As(err, &exitErr) {
+			return strconv.Itoa(exitErr.ExitCode) == strings.TrimPrefix(condition, "exit:"), true
+		}
+
+	default:
+		return false, false
 	}
 
-	return false
+	return false, isTyped(err)
+}
+
+// matchMessage matches a retryOn condition against an error message, for
+// errors without a structured type
+func matchMessage(errMsg, condition string) bool {
+	errMsgLower := strings.ToLower(errMsg)
+
+	switch {
+	case condition == "timeout":
+		return strings.Contains(errMsgLower, "timeout") ||
+			strings.Contains(errMsgLower, "deadline exceeded")
+
+	case strings.HasPrefix(condition, "status:"):
+		statusCode := strings.TrimPrefix(condition, "status:")
+		return strings.Contains(errMsgLower, "status code "+statusCode) ||
+			strings.Contains(errMsgLower, "status "+statusCode)
+
+	case strings.HasPrefix(condition, "exit:"):
+		return strings.Contains(errMsgLower, "exit code "+strings.TrimPrefix(condition, "exit:"))
+
+	case condition == "network":
+		return strings.Contains(errMsgLower, "network") ||
+			strings.Contains(errMsgLower, "connection") ||
+			strings.Contains(errMsgLower, "dns")
+
+	default:
+		// Check if error message contains the condition
+		return strings.Contains(errMsgLower, condition)
+	}
 }
 
 // parseDuration parses a duration string, returning defaultValue if parsing fails
@@ -219,11 +290,11 @@ func CreateRetryableError(err error, retryable bool) error {
 	}
 }
 
-// WrapHTTPError wraps an HTTP error with retry information based on status code
+// WrapHTTPError wraps an HTTP error in an HTTPStatusError for its status code
 func WrapHTTPError(err error, statusCode int) error {
-	return &RetryableError{
-		Err:       err,
-		Retryable: IsRetryableHTTPStatus(statusCode),
+	return &HTTPStatusError{
+		StatusCode: statusCode,
+		Err:        err,
 	}
 }
 
@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	t.Run("Retries Until Success", func(t *testing.T) {
+		attempts := 0
+		err := ExecuteWithRetry(context.Background(), "flaky", &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "1ms"}, func() error {
+			if attempts++; attempts < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("Expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("Cancelled During Backoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		failure := errors.New("unavailable")
+		attempts := 0
+		start := time.Now()
+		err := ExecuteWithRetry(ctx, "slow-backoff", &workflow.RetryConfig{MaxAttempts: 3, InitialDelay: "10s"}, func() error {
+			attempts++
+			return failure
+		})
+
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Expected the backoff to stop when cancelled, waited %s", elapsed)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected 1 attempt, got %d", attempts)
+		}
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, failure) {
+			t.Errorf("Expected the last error and context.Canceled, got: %v", err)
+		}
+	})
+}
+
+func TestShouldRetry(t *testing.T) {
+	notFound := WrapHTTPError(errors.New("unexpected status code 404"), 404)
+	unavailable := WrapHTTPError(errors.New("unexpected status code 503"), 503)
+	exit75 := &ExitCodeError{ExitCode: 75, Err: errors.New("bash action failed with exit code 75")}
+	timeout := &TimeoutError{Err: fmt.Errorf("timed out: %w", context.DeadlineExceeded)}
+	refused := fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("refused")})
+
+	tests := []struct {
+		name    string
+		err     error
+		retryOn []string
+		want    bool
+	}{
+		{"no conditions retries untyped errors", errors.New("boom"), nil, true},
+		{"no conditions retries 503", unavailable, nil, true},
+		{"no conditions doesn't retry 404", notFound, nil, false},
+		{"status matches code", unavailable, []string{"status:503"}, true},
+		{"status doesn't match other code", notFound, []string{"status:503"}, false},
+		{"status ignores message of other types", &ExitCodeError{ExitCode: 1, Err: errors.New("status code 503")}, []string{"status:503"}, false},
+		{"exit code matches", exit75, []string{"exit:75"}, true},
+		{"exit code doesn't match", exit75, []string{"exit:1"}, false},
+		{"timeout type", timeout, []string{"timeout"}, true},
+		{"deadline exceeded", fmt.Errorf("publish: %w", context.DeadlineExceeded), []string{"timeout"}, true},
+		{"timeout ignores other types", &ExitCodeError{ExitCode: 124, Err: errors.New("timeout")}, []string{"timeout"}, false},
+		{"network error", refused, []string{"network"}, true},
+		{"network ignores status errors", WrapHTTPError(errors.New("connection reset by upstream"), 502), []string{"network"}, false},
+		{"message fallback", errors.New("dial tcp: connection refused"), []string{"network"}, true},
+		{"message fallback status", errors.New("unexpected status code 500"), []string{"status:500"}, true},
+		{"custom substring", errors.New("database is locked"), []string{"locked"}, true},
+		{"any error", notFound, []string{"error"}, true},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.err, tt.retryOn); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package schema generates the JSON Schema of the workflow format from the Go
+// structs the parser decodes workflows into, for editor autocompletion and
+// validation outside autozap
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Draft is the JSON Schema version of the generated schema, the newest one
+// widely supported by editors
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// ID identifies the schema, e.g. in a yaml-language-server modeline
+const ID = "https://github.com/codecrafted007/autozap/workflow.schema.json"
+
+// Schema is a JSON Schema, or one of its subschemas
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	Deprecated  bool               `json:"deprecated,omitempty"`
+
+	// AdditionalProperties is false for the structs, whose unknown fields
+	// are typos, or the schema of the values of a map
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// required lists the fields a workflow can't do without. The other fields
+// are optional, whether or not their tag has omitempty.
+var required = map[reflect.Type][]string{
+	reflect.TypeOf(workflow.Workflow{}):     {"name", "trigger", "actions"},
+	reflect.TypeOf(workflow.Trigger{}):      {"type"},
+	reflect.TypeOf(workflow.MetricConfig{}): {"name"},
+	reflect.TypeOf(workflow.AuthConfig{}):   {"type"},
+}
+
+// enums lists the values of the string types other than the action and
+// trigger types, which come from their registries
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(workflow.MissedRunPolicy("")): {
+		string(workflow.MissedRunsOnce), string(workflow.MissedRunsAll), string(workflow.MissedRunsSkip),
+	},
+	reflect.TypeOf(workflow.ConcurrencyPolicy("")): {
+		string(workflow.ConcurrencyAllow), string(workflow.ConcurrencyForbid), string(workflow.ConcurrencyReplace),
+	},
+}
+
+// Generate returns the schema of a workflow. The action and trigger types are
+// those registered, so types added by Register calls are included.
+func Generate() *Schema {
+	g := &generator{definitions: make(map[string]*Schema)}
+
+	var actionTypes []string
+	for _, typ := range action.GetRegistry().Types() {
+		actionTypes = append(actionTypes, typ.String())
+	}
+	var triggerTypes []string
+	for _, typ := range parser.TriggerTypes() {
+		triggerTypes = append(triggerTypes, typ.String())
+	}
+	g.enums = map[reflect.Type][]string{
+		reflect.TypeOf(workflow.ActionType("")):  actionTypes,
+		reflect.TypeOf(workflow.TriggerType("")): triggerTypes,
+	}
+	for typ, values := range enums {
+		g.enums[typ] = values
+	}
+
+	root := g.structSchema(reflect.TypeOf(workflow.Workflow{}))
+	root.Schema = Draft
+	root.ID = ID
+	root.Title = "AutoZap workflow"
+	root.Definitions = g.definitions
+
+	// An action is either an include of shared actions or a typed action
+	if def := g.definitions["Action"]; def != nil {
+		def.AnyOf = []*Schema{
+			{Required: []string{"include"}},
+			{Required: []string{"type", "name"}},
+		}
+	}
+	return root
+}
+
+// JSON returns the schema of a workflow as indented JSON
+func JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Generate(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// generator builds the schemas of Go types, collecting named structs as
+// definitions
+type generator struct {
+	enums       map[reflect.Type][]string
+	definitions map[string]*Schema
+}
+
+// schemaOf returns the schema of a field of type t
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	if values, ok := g.enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.ref(t)
+	default:
+		// interface{}: any value, e.g. expectStatus, a code or a list of them
+		return &Schema{}
+	}
+}
+
+// ref returns a reference to the definition of a named struct, adding it
+// the first time
+func (g *generator) ref(t reflect.Type) *Schema {
+	name := t.Name()
+	if _, ok := g.definitions[name]; !ok {
+		g.definitions[name] = nil // reserved, for recursive types
+		g.definitions[name] = g.structSchema(t)
+	}
+	ref := &Schema{Ref: "#/definitions/" + name}
+
+	// A foreach is also a plain list of items, see ForEachConfig.UnmarshalYAML
+	if t == reflect.TypeOf(workflow.ForEachConfig{}) {
+		return &Schema{OneOf: []*Schema{
+			{Type: "array", Items: &Schema{Type: "string"}},
+			ref,
+		}}
+	}
+	return ref
+}
+
+// structSchema returns the schema of a struct from the yaml tags of its fields
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		Required:             required[t],
+		AdditionalProperties: false,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		prop := g.schemaOf(field.Type)
+		if strings.HasPrefix(field.Name, "Deprecated") {
+			prop.Deprecated = true
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
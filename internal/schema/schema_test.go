@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/action"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	logger.InitLogger()
+}
+
+// pingExecutor is an action type registered by the tests
+type pingExecutor struct{}
+
+func (pingExecutor) Validate(act *workflow.Action) error { return nil }
+
+func (pingExecutor) Execute(ctx context.Context, act *workflow.Action, ec action.ExecContext) (action.Result, error) {
+	return action.Result{}, nil
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate()
+	if s.Schema != Draft || s.ID != ID {
+		t.Errorf("Expected $schema %s and $id %s, got %s and %s", Draft, ID, s.Schema, s.ID)
+	}
+	if !slices.Equal(s.Required, []string{"name", "trigger", "actions"}) {
+		t.Errorf("Expected name, trigger and actions to be required, got %v", s.Required)
+	}
+
+	act := s.Definitions["Action"]
+	if act == nil {
+		t.Fatal("Expected an Action definition")
+	}
+	for _, typ := range []workflow.ActionType{workflow.ActionTypeBash, workflow.ActionTypeHTTP, workflow.ActionTypeWorkflow} {
+		if !slices.Contains(act.Properties["type"].Enum, typ.String()) {
+			t.Errorf("Expected action type %s in %v", typ, act.Properties["type"].Enum)
+		}
+	}
+	for _, typ := range []workflow.TriggerType{workflow.TriggerTypeCron, workflow.TriggerTypeFileWatch, workflow.TriggerTypeShutdown} {
+		if !slices.Contains(s.Definitions["Trigger"].Properties["type"].Enum, typ.String()) {
+			t.Errorf("Expected trigger type %s in %v", typ, s.Definitions["Trigger"].Properties["type"].Enum)
+		}
+	}
+	if _, ok := act.Properties["onOutput"]; ok {
+		t.Error("Expected fields without a yaml name to be left out")
+	}
+	if !act.Properties["expect_status"].Deprecated {
+		t.Error("Expected expect_status to be deprecated")
+	}
+	if len(act.Properties["foreach"].OneOf) != 2 {
+		t.Errorf("Expected foreach to be a list or a mapping, got %+v", act.Properties["foreach"])
+	}
+
+	data, err := JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	if !json.Valid(data) || !strings.Contains(string(data), `"additionalProperties": false`) {
+		t.Errorf("Expected a JSON schema rejecting unknown fields, got %s", data)
+	}
+}
+
+func TestGenerateRegisteredActionType(t *testing.T) {
+	const typ workflow.ActionType = "test-ping"
+	action.Register(typ, pingExecutor{})
+
+	enum := Generate().Definitions["Action"].Properties["type"].Enum
+	if !slices.Contains(enum, typ.String()) {
+		t.Errorf("Expected registered action type %s in %v", typ, enum)
+	}
+}
+
+// TestExampleWorkflows checks the example workflows against the schema: their
+// fields, enum values and required fields
+func TestExampleWorkflows(t *testing.T) {
+	s := Generate()
+	files, err := filepath.Glob(filepath.Join("..", "..", "workflows", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Expected example workflows, got %v, %v", files, err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if err := check(s, s, doc, ""); err != nil {
+			t.Errorf("%s: %v", file, err)
+		}
+	}
+
+	for _, doc := range []string{
+		"name: typo\ntrigger:\n  type: cron\n  schedule: '@hourly'\nactions:\n  - type: bash\n    name: a\n    comand: echo\n",
+		"name: bad-type\ntrigger:\n  type: webhook\nactions:\n  - type: bash\n    name: a\n",
+		"name: no-trigger\nactions:\n  - type: bash\n    name: a\n",
+	} {
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		if err := check(s, s, v, ""); err == nil {
+			t.Errorf("Expected the schema to reject %q", doc)
+		}
+	}
+}
+
+// check validates v against the subset of JSON Schema Generate uses
+func check(root, s *Schema, v interface{}, path string) error {
+	if s.Ref != "" {
+		return check(root, root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")], v, path)
+	}
+	if len(s.OneOf) > 0 {
+		for _, option := range s.OneOf {
+			if check(root, option, v, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: matches none of the alternatives", path)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, option := range s.AnyOf {
+			matched = matched || check(root, option, v, path) == nil
+		}
+		if !matched {
+			return fmt.Errorf("%s: matches none of the alternatives", path)
+		}
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, fmt.Sprint(v)) {
+		return fmt.Errorf("%s: %v is not one of %v", path, v, s.Enum)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if s.Type != "" && s.Type != "object" {
+			return fmt.Errorf("%s: expected %s, got an object", path, s.Type)
+		}
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required field %s", path, name)
+			}
+		}
+		for name, value := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				switch extra := s.AdditionalProperties.(type) {
+				case bool:
+					if !extra {
+						return fmt.Errorf("%s: unknown field %s", path, name)
+					}
+					continue
+				case *Schema:
+					prop = extra
+				default:
+					continue // constraints only, e.g. the required fields of an action
+				}
+			}
+			if err := check(root, prop, value, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Type != "" && s.Type != "array" {
+			return fmt.Errorf("%s: expected %s, got a list", path, s.Type)
+		}
+		if s.Items == nil {
+			return nil // any value, e.g. expectStatus
+		}
+		for i, item := range v {
+			if err := check(root, s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		if s.Type == "object" || s.Type == "array" {
+			return fmt.Errorf("%s: expected %s, got %v", path, s.Type, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package schema_test
+
+// The trigger package registers the built-in trigger types, and how workflows
+// using them are validated, for the tests of this package
+import _ "github.com/codecrafted007/autozap/internal/trigger"
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"crypto/subtle"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// apiToken is the bearer token required by the endpoints that change the
+// agent, set with SetAPIToken
+var apiToken struct {
+	sync.RWMutex
+	token string
+}
+
+// SetAPIToken sets the bearer token the endpoints that change the agent
+// require. Without one they only accept requests from the agent's host.
+func SetAPIToken(token string) {
+	apiToken.Lock()
+	defer apiToken.Unlock()
+	apiToken.token = token
+}
+
+// control guards an endpoint that changes the agent. The caller must send
+// the API token as "Authorization: Bearer <token>", or connect from the
+// agent's host if there is none, and a JSON body. Browsers can only send a
+// JSON Content-Type to another origin after a CORS preflight, which the agent
+// doesn't answer, so a web page can't call these endpoints for its visitor.
+func control(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiToken.RLock()
+		token := apiToken.token
+		apiToken.RUnlock()
+
+		if token != "" {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="autozap"`)
+				http.Error(w, "Missing or invalid API token", http.StatusUnauthorized)
+				return
+			}
+		} else if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "Remote requests require an API token; set apiToken in the agent config or --api-token", http.StatusForbidden)
+			return
+		}
+
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// isLoopback reports whether a request's remote address is on this host
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
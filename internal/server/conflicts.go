@@ -0,0 +1,48 @@
+package server
+
+import (
+	"sort"
+	"sync"
+)
+
+// WorkflowConflict is a workflow the agent didn't start because another file
+// or source document already defines a workflow of the same name
+type WorkflowConflict struct {
+	Name          string `json:"name"`
+	File          string `json:"file"`           // the definition that wasn't started
+	ConflictsWith string `json:"conflicts_with"` // the definition that is running
+}
+
+var (
+	conflictsMu sync.Mutex
+	conflicts   = make(map[string]WorkflowConflict) // by file
+)
+
+// AddConflict records that the workflow defined by c.File wasn't started
+func AddConflict(c WorkflowConflict) {
+	conflictsMu.Lock()
+	defer conflictsMu.Unlock()
+	conflicts[c.File] = c
+}
+
+// RemoveConflict forgets the conflict of file, e.g. once it was changed or
+// removed
+func RemoveConflict(file string) {
+	conflictsMu.Lock()
+	defer conflictsMu.Unlock()
+	delete(conflicts, file)
+}
+
+// Conflicts returns the workflows not started because of a name conflict,
+// sorted by file
+func Conflicts() []WorkflowConflict {
+	conflictsMu.Lock()
+	defer conflictsMu.Unlock()
+
+	list := make([]WorkflowConflict, 0, len(conflicts))
+	for _, c := range conflicts {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].File < list[j].File })
+	return list
+}
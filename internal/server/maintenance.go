@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+)
+
+// Maintenance policies decide what happens to runs triggered while the agent
+// is in maintenance mode
+const (
+	MaintenanceDrop  = "drop"  // runs are recorded as skipped
+	MaintenanceQueue = "queue" // runs are recorded as deferred and run when maintenance ends
+)
+
+// DefaultMaintenanceTTL is how long maintenance mode lasts if no TTL is given
+const DefaultMaintenanceTTL = time.Hour
+
+// MaxQueuedMaintenanceRuns limits the runs queued during maintenance; later
+// ones are dropped
+const MaxQueuedMaintenanceRuns = 1000
+
+// MaintenanceState describes the agent's maintenance mode
+type MaintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	Policy  string     `json:"policy,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+	Until   *time.Time `json:"until,omitempty"` // maintenance ends automatically at this time
+	Queued  int        `json:"queued_runs"`
+}
+
+var maintenance struct {
+	mu     sync.Mutex
+	state  MaintenanceState
+	queue  []func()
+	timer  *time.Timer
+	active uint64 // incremented on every change, so a stale TTL timer does nothing
+}
+
+// EnterMaintenance suppresses the agent's triggers for ttl. Entering it again
+// while already in maintenance updates the reason, policy and TTL and keeps
+// the queued runs.
+func EnterMaintenance(reason, policy string, ttl time.Duration) (MaintenanceState, error) {
+	if policy == "" {
+		policy = MaintenanceDrop
+	}
+	if policy != MaintenanceDrop && policy != MaintenanceQueue {
+		return MaintenanceState{}, fmt.Errorf("invalid maintenance policy '%s'. Must be one of: %s, %s", policy, MaintenanceDrop, MaintenanceQueue)
+	}
+	if ttl == 0 {
+		ttl = DefaultMaintenanceTTL
+	}
+	if ttl < 0 {
+		return MaintenanceState{}, fmt.Errorf("maintenance TTL must be positive, got %s", ttl)
+	}
+
+	maintenance.mu.Lock()
+	defer maintenance.mu.Unlock()
+
+	now := time.Now()
+	until := now.Add(ttl)
+	if !maintenance.state.Enabled {
+		maintenance.state.Since = &now
+	}
+	maintenance.state.Enabled = true
+	maintenance.state.Reason = reason
+	maintenance.state.Policy = policy
+	maintenance.state.Until = &until
+
+	maintenance.active++
+	active := maintenance.active
+	if maintenance.timer != nil {
+		maintenance.timer.Stop()
+	}
+	maintenance.timer = time.AfterFunc(ttl, func() {
+		exitMaintenance(active)
+	})
+
+	metrics.SetMaintenanceMode(true)
+	logger.L().Infow("Agent entered maintenance mode",
+		"reason", reason,
+		"policy", policy,
+		"until", until.Format(time.RFC3339))
+
+	state := maintenance.state
+	state.Queued = len(maintenance.queue)
+	return state, nil
+}
+
+// ExitMaintenance ends maintenance mode and starts the runs queued during it
+func ExitMaintenance() MaintenanceState {
+	return exitMaintenance(0)
+}
+
+// exitMaintenance ends maintenance mode. A TTL timer passes the change it was
+// started for, and does nothing if maintenance was changed since.
+func exitMaintenance(active uint64) MaintenanceState {
+	maintenance.mu.Lock()
+	if !maintenance.state.Enabled || (active != 0 && active != maintenance.active) {
+		state := maintenance.state
+		state.Queued = len(maintenance.queue)
+		maintenance.mu.Unlock()
+		return state
+	}
+
+	queued := maintenance.queue
+	maintenance.queue = nil
+	maintenance.state = MaintenanceState{}
+	maintenance.active++
+	if maintenance.timer != nil {
+		maintenance.timer.Stop()
+		maintenance.timer = nil
+	}
+	state := maintenance.state
+	maintenance.mu.Unlock()
+
+	metrics.SetMaintenanceMode(false)
+	logger.L().Infow("Agent left maintenance mode",
+		"ttl_expired", active != 0,
+		"queued_runs", len(queued))
+
+	for _, run := range queued {
+		go run()
+	}
+	return state
+}
+
+// Maintenance returns the agent's maintenance mode
+func Maintenance() MaintenanceState {
+	maintenance.mu.Lock()
+	defer maintenance.mu.Unlock()
+
+	state := maintenance.state
+	state.Queued = len(maintenance.queue)
+	return state
+}
+
+// InMaintenance reports whether the agent is in maintenance mode and its
+// policy. The executor checks it before starting a run.
+func InMaintenance() (policy string, ok bool) {
+	maintenance.mu.Lock()
+	defer maintenance.mu.Unlock()
+	return maintenance.state.Policy, maintenance.state.Enabled
+}
+
+// QueueMaintenanceRun queues run to be started when maintenance ends. It
+// returns false if the agent isn't in maintenance with the queue policy or
+// the queue is full.
+func QueueMaintenanceRun(run func()) bool {
+	maintenance.mu.Lock()
+	defer maintenance.mu.Unlock()
+
+	if !maintenance.state.Enabled || maintenance.state.Policy != MaintenanceQueue || len(maintenance.queue) >= MaxQueuedMaintenanceRuns {
+		return false
+	}
+	maintenance.queue = append(maintenance.queue, run)
+	return true
+}
@@ -1,12 +1,21 @@
 package server
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/codecrafted007/autozap/internal/workflow"
 )
 
+// Workflow statuses tracked by the registry
+const (
+	StatusActive   = "active"
+	StatusPaused   = "paused" // loaded, but the trigger does not fire
+	StatusStopped  = "stopped"
+	StatusDisabled = "disabled" // enabled: false, loaded but not started
+)
+
 // WorkflowRegistry tracks active workflows and their status
 type WorkflowRegistry struct {
 	workflows map[string]*WorkflowInfo
@@ -15,19 +24,28 @@ type WorkflowRegistry struct {
 
 // WorkflowInfo contains runtime information about a workflow
 type WorkflowInfo struct {
-	Name          string                 `json:"name"`
-	Description   string                 `json:"description"`
-	TriggerType   string                 `json:"trigger_type"`
-	Schedule      string                 `json:"schedule,omitempty"`
-	Status        string                 `json:"status"` // active, stopped, error
-	RegisteredAt  time.Time              `json:"registered_at"`
-	LastExecution *time.Time             `json:"last_execution,omitempty"`
-	NextExecution *time.Time             `json:"next_execution,omitempty"`
-	TotalRuns     int                    `json:"total_runs"`
-	SuccessCount  int                    `json:"success_count"`
-	FailureCount  int                    `json:"failure_count"`
-	LastError     string                 `json:"last_error,omitempty"`
-	Actions       []WorkflowActionInfo   `json:"actions"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	Owner         string               `json:"owner,omitempty"`
+	DocsURL       string               `json:"docs_url,omitempty"`
+	RunbookURL    string               `json:"runbook_url,omitempty"`
+	TriggerType   string               `json:"trigger_type"`
+	Schedule      string               `json:"schedule,omitempty"`
+	Status        string               `json:"status"` // active, paused, stopped, disabled, error
+	RegisteredAt  time.Time            `json:"registered_at"`
+	LastExecution *time.Time           `json:"last_execution,omitempty"`
+	NextExecution *time.Time           `json:"next_execution,omitempty"`
+	TotalRuns     int                  `json:"total_runs"`
+	SuccessCount  int                  `json:"success_count"`
+	FailureCount  int                  `json:"failure_count"`
+	LastStatus    string               `json:"last_status,omitempty"`
+	StatusCounts  map[string]int       `json:"status_counts,omitempty"` // runs by status, including runs that didn't start
+	LastError     string               `json:"last_error,omitempty"`
+	Actions       []WorkflowActionInfo `json:"actions"`
+	DependsOn     []string             `json:"depends_on_services,omitempty"`
+	CircuitOpen   bool                 `json:"circuit_open,omitempty"` // runs are blocked by the circuit breaker
+
+	definition *workflow.Workflow // parsed workflow, used to run it on demand
 }
 
 // WorkflowActionInfo contains information about an action
@@ -62,14 +80,27 @@ func (r *WorkflowRegistry) RegisterWorkflow(wf *workflow.Workflow) {
 		}
 	}
 
+	// A reloaded workflow stays paused until it is resumed
+	status := StatusActive
+	if !wf.IsEnabled() {
+		status = StatusDisabled
+	} else if existing, exists := r.workflows[wf.Name]; exists && existing.Status == StatusPaused {
+		status = StatusPaused
+	}
+
 	info := &WorkflowInfo{
 		Name:         wf.Name,
 		Description:  wf.Description,
+		Owner:        wf.Owner,
+		DocsURL:      wf.DocsURL,
+		RunbookURL:   wf.RunbookURL,
 		TriggerType:  string(wf.Trigger.Type),
 		Schedule:     wf.Trigger.Schedule,
-		Status:       "active",
+		Status:       status,
 		RegisteredAt: time.Now(),
 		Actions:      actions,
+		DependsOn:    wf.DependsOnServices,
+		definition:   wf,
 	}
 
 	r.workflows[wf.Name] = info
@@ -81,17 +112,69 @@ func (r *WorkflowRegistry) UnregisterWorkflow(name string) {
 	defer r.mu.Unlock()
 
 	if info, exists := r.workflows[name]; exists {
-		info.Status = "stopped"
+		info.Status = StatusStopped
 	}
 }
 
-// UpdateExecutionStats updates execution statistics for a workflow
-func (r *WorkflowRegistry) UpdateExecutionStats(name string, success bool, errorMsg string) {
+// PauseWorkflow stops a workflow's trigger from firing without unloading it
+func (r *WorkflowRegistry) PauseWorkflow(name string) error {
+	return r.setPaused(name, true)
+}
+
+// ResumeWorkflow lets a paused workflow's trigger fire again
+func (r *WorkflowRegistry) ResumeWorkflow(name string) error {
+	return r.setPaused(name, false)
+}
+
+func (r *WorkflowRegistry) setPaused(name string, paused bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	info, exists := r.workflows[name]
 	if !exists {
+		return fmt.Errorf("workflow '%s' not found", name)
+	}
+	if info.Status != StatusActive && info.Status != StatusPaused {
+		return fmt.Errorf("workflow '%s' is %s", name, info.Status)
+	}
+
+	if paused {
+		info.Status = StatusPaused
+	} else {
+		info.Status = StatusActive
+	}
+	return nil
+}
+
+// IsPaused reports whether a workflow is paused. Triggers check it before firing.
+func (r *WorkflowRegistry) IsPaused(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info, exists := r.workflows[name]
+	return exists && info.Status == StatusPaused
+}
+
+// UpdateExecutionStats updates execution statistics for a workflow with the
+// result of a run. Runs that didn't start, e.g. skipped ones, are only counted
+// by status.
+func (r *WorkflowRegistry) UpdateExecutionStats(result *workflow.ExecutionResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status, errorMsg := result.Status, result.Error
+	info, exists := r.workflows[result.Workflow]
+	if !exists {
+		return
+	}
+
+	if info.StatusCounts == nil {
+		info.StatusCounts = make(map[string]int)
+	}
+	info.StatusCounts[status]++
+	info.LastStatus = status
+
+	if !workflow.WasStarted(status) {
 		return
 	}
 
@@ -99,14 +182,51 @@ func (r *WorkflowRegistry) UpdateExecutionStats(name string, success bool, error
 	info.LastExecution = &now
 	info.TotalRuns++
 
-	if success {
+	if status == workflow.StatusSuccess {
 		info.SuccessCount++
 		info.LastError = ""
-	} else {
+		return
+	}
+	if workflow.IsFailure(status) {
 		info.FailureCount++
-		if errorMsg != "" {
-			info.LastError = errorMsg
+	}
+	if errorMsg != "" {
+		info.LastError = errorMsg
+	}
+}
+
+// RecordLateStatus changes the last recorded run of a workflow from status
+// from to status to, for async actions that fail after the run was counted
+func (r *WorkflowRegistry) RecordLateStatus(name, from, to, errorMsg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, exists := r.workflows[name]
+	if !exists {
+		return
+	}
+
+	if info.StatusCounts[from] > 0 {
+		info.StatusCounts[from]--
+		info.StatusCounts[to]++
+		info.LastStatus = to
+		if from == workflow.StatusSuccess {
+			info.SuccessCount--
 		}
+		if workflow.IsFailure(to) {
+			info.FailureCount++
+		}
+	}
+	info.LastError = errorMsg
+}
+
+// SetCircuitOpen records whether a workflow's circuit breaker is open
+func (r *WorkflowRegistry) SetCircuitOpen(name string, open bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if info, exists := r.workflows[name]; exists {
+		info.CircuitOpen = open
 	}
 }
 
@@ -132,6 +252,18 @@ func (r *WorkflowRegistry) GetWorkflow(name string) (*WorkflowInfo, bool) {
 	return info, exists
 }
 
+// GetWorkflowDefinition returns the parsed workflow registered under name
+func (r *WorkflowRegistry) GetWorkflowDefinition(name string) (*workflow.Workflow, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info, exists := r.workflows[name]
+	if !exists || info.definition == nil {
+		return nil, false
+	}
+	return info.definition, true
+}
+
 // GetAllWorkflows returns all registered workflows
 func (r *WorkflowRegistry) GetAllWorkflows() []*WorkflowInfo {
 	r.mu.RLock()
@@ -152,7 +284,7 @@ func (r *WorkflowRegistry) GetActiveWorkflows() []*WorkflowInfo {
 
 	workflows := make([]*WorkflowInfo, 0)
 	for _, info := range r.workflows {
-		if info.Status == "active" {
+		if info.Status == StatusActive {
 			workflows = append(workflows, info)
 		}
 	}
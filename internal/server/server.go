@@ -1,15 +1,23 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/codecrafted007/autozap/internal/approval"
 	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/health"
 	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/schema"
+	"github.com/codecrafted007/autozap/internal/timezone"
+	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
@@ -41,10 +49,13 @@ type HealthResponse struct {
 
 // StatusResponse represents the response for /status endpoint
 type StatusResponse struct {
-	Status    string           `json:"status"`
-	Uptime    string           `json:"uptime"`
-	Workflows WorkflowsSummary `json:"workflows"`
-	Timestamp time.Time        `json:"timestamp"`
+	Status      string                 `json:"status"`
+	Uptime      string                 `json:"uptime"`
+	Workflows   WorkflowsSummary       `json:"workflows"`
+	Services    []health.ServiceStatus `json:"services,omitempty"` // dependencies from the agent config
+	Maintenance *MaintenanceState      `json:"maintenance,omitempty"`
+	Conflicts   []WorkflowConflict     `json:"conflicts,omitempty"` // workflows not started, their name is taken
+	Timestamp   time.Time              `json:"timestamp"`
 }
 
 // WorkflowsSummary provides a summary of workflow states
@@ -59,8 +70,12 @@ var (
 	serverStartTime    = time.Now()
 	workflowStatuses   = make(map[string]*WorkflowStatus)
 	workflowStatusFunc func() []WorkflowStatus
+	manualTriggerFunc  func(wf *workflow.Workflow, payload map[string]interface{}, source map[string]string)
 )
 
+// maxTriggerPayloadBytes limits the JSON body accepted by the trigger endpoint
+const maxTriggerPayloadBytes = 1 << 20
+
 // NewServer creates a new HTTP server for metrics and health endpoints
 func NewServer(port int) *Server {
 	mux := http.NewServeMux()
@@ -82,6 +97,17 @@ func NewServer(port int) *Server {
 	mux.HandleFunc("/api/workflows/history", historyAPIHandler)
 	mux.HandleFunc("/api/workflows/stats", statsAPIHandler)
 	mux.HandleFunc("/api/workflows/failures", failuresAPIHandler)
+	mux.HandleFunc("/api/workflows/usage", usageAPIHandler)
+	mux.HandleFunc("GET /api/executions/{id}", executionAPIHandler)
+	mux.HandleFunc("POST /api/workflows/{name}/trigger", control(triggerWorkflowAPIHandler))
+	mux.HandleFunc("POST /api/workflows/{name}/pause", control(pauseWorkflowAPIHandler))
+	mux.HandleFunc("POST /api/workflows/{name}/resume", control(resumeWorkflowAPIHandler))
+	mux.HandleFunc("GET /api/approvals", approvalsAPIHandler)
+	mux.HandleFunc("POST /api/approvals/{id}/approve", control(approveAPIHandler))
+	mux.HandleFunc("POST /api/approvals/{id}/reject", control(rejectAPIHandler))
+	mux.HandleFunc("GET /api/agent/maintenance", maintenanceAPIHandler)
+	mux.HandleFunc("POST /api/agent/maintenance", control(maintenanceAPIHandler))
+	mux.HandleFunc("GET /api/schema", schemaAPIHandler)
 
 	// Metrics endpoint
 	mux.Handle("/metrics", promhttp.Handler())
@@ -116,6 +142,8 @@ func (s *Server) Start() error {
 	s.logger.Infof("❤️  Health check at: http://localhost:%d/health", s.port)
 	s.logger.Infof("📈 Status at: http://localhost:%d/status", s.port)
 
+	approval.Enable()
+
 	go func() {
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			s.logger.Errorf("HTTP server error: %v", err)
@@ -192,8 +220,13 @@ func statusHandler(w http.ResponseWriter, r *http.Request) {
 			Failed:  failed,
 			Details: details,
 		},
+		Services:  health.Statuses(),
+		Conflicts: Conflicts(),
 		Timestamp: time.Now(),
 	}
+	if state := Maintenance(); state.Enabled {
+		response.Maintenance = &state
+	}
 
 	json.NewEncoder(w).Encode(response)
 }
@@ -203,6 +236,13 @@ func SetWorkflowStatusFunc(fn func() []WorkflowStatus) {
 	workflowStatusFunc = fn
 }
 
+// SetManualTriggerFunc sets the function that runs a workflow triggered through
+// the API. It is called with the workflow, the request's JSON payload (nil if
+// the body was empty) and where the request came from, and must not block.
+func SetManualTriggerFunc(fn func(wf *workflow.Workflow, payload map[string]interface{}, source map[string]string)) {
+	manualTriggerFunc = fn
+}
+
 // formatDuration formats a duration into a human-readable string
 func formatDuration(d time.Duration) string {
 	days := int(d.Hours() / 24)
@@ -269,15 +309,17 @@ func statsAPIHandler(w http.ResponseWriter, r *http.Request) {
 		if _, exists := workflowStats[exec.WorkflowName]; !exists {
 			workflowStats[exec.WorkflowName] = &database.WorkflowStats{
 				WorkflowName: exec.WorkflowName,
+				StatusCounts: make(map[string]int),
 			}
 		}
 
 		stats := workflowStats[exec.WorkflowName]
 		stats.TotalExecutions++
+		stats.StatusCounts[exec.Status]++
 
-		if exec.Status == "success" {
+		if exec.Status == workflow.StatusSuccess {
 			stats.SuccessCount++
-		} else if exec.Status == "failed" {
+		} else if workflow.IsFailure(exec.Status) {
 			stats.FailedCount++
 		}
 
@@ -310,3 +352,298 @@ func failuresAPIHandler(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(failures)
 }
+
+// usageAPIHandler handles /api/workflows/usage?months=N&workflow=name
+func usageAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	months := 1
+	if v := r.URL.Query().Get("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, fmt.Sprintf("Invalid months '%s'", v), http.StatusBadRequest)
+			return
+		}
+		months = n
+	}
+
+	usage, err := database.GetUsage(database.StartOfMonth(timezone.In(time.Now()), months), r.URL.Query().Get("workflow"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(usage)
+}
+
+// executionAPIHandler handles GET /api/executions/{id}, returning an execution,
+// including what triggered it, together with its actions
+func executionAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid execution ID '%s'", r.PathValue("id")), http.StatusBadRequest)
+		return
+	}
+
+	exec, err := database.GetWorkflowExecution(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Execution %d not found", id), http.StatusNotFound)
+		return
+	}
+	actions, err := database.GetActionExecutions(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get actions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		database.WorkflowExecution
+		Actions []database.ActionExecution `json:"actions"`
+	}{*exec, actions})
+}
+
+// triggerWorkflowAPIHandler handles POST /api/workflows/{name}/trigger. The
+// workflow runs in the background; an optional JSON object body is passed to
+// the workflow as its payload.
+func triggerWorkflowAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	name := r.PathValue("name")
+
+	if manualTriggerFunc == nil {
+		http.Error(w, "Manual triggers are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	info, exists := GetRegistry().GetWorkflow(name)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Workflow '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	if info.Status != StatusActive {
+		http.Error(w, fmt.Sprintf("Workflow '%s' is %s", name, info.Status), http.StatusConflict)
+		return
+	}
+	wf, ok := GetRegistry().GetWorkflowDefinition(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Workflow '%s' not found", name), http.StatusNotFound)
+		return
+	}
+
+	var payload map[string]interface{}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxTriggerPayloadBytes+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxTriggerPayloadBytes {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, fmt.Sprintf("Payload must be a JSON object: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	logger.L().Infow("Manual trigger requested for workflow",
+		"workflow_name", name,
+		"remote_addr", r.RemoteAddr,
+		"has_payload", payload != nil)
+
+	source := map[string]string{"remote_addr": r.RemoteAddr}
+	if id := r.Header.Get("X-Request-Id"); id != "" {
+		source["request_id"] = id
+	}
+	manualTriggerFunc(wf, payload, source)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"workflow": name,
+		"status":   "triggered",
+	})
+}
+
+// pauseWorkflowAPIHandler handles POST /api/workflows/{name}/pause
+func pauseWorkflowAPIHandler(w http.ResponseWriter, r *http.Request) {
+	setWorkflowPaused(w, r, true)
+}
+
+// resumeWorkflowAPIHandler handles POST /api/workflows/{name}/resume
+func resumeWorkflowAPIHandler(w http.ResponseWriter, r *http.Request) {
+	setWorkflowPaused(w, r, false)
+}
+
+// setWorkflowPaused pauses or resumes the workflow named in the request path
+func setWorkflowPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	name := r.PathValue("name")
+	if _, exists := GetRegistry().GetWorkflow(name); !exists {
+		http.Error(w, fmt.Sprintf("Workflow '%s' not found", name), http.StatusNotFound)
+		return
+	}
+
+	var err error
+	status := StatusPaused
+	if paused {
+		err = GetRegistry().PauseWorkflow(name)
+	} else {
+		err = GetRegistry().ResumeWorkflow(name)
+		status = StatusActive
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	logger.L().Infow("Workflow status changed through API",
+		"workflow_name", name,
+		"status", status,
+		"remote_addr", r.RemoteAddr)
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"workflow": name,
+		"status":   status,
+	})
+}
+
+// approvalsAPIHandler handles GET /api/approvals, listing the approval
+// actions waiting for a decision
+func approvalsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	json.NewEncoder(w).Encode(approval.Pending())
+}
+
+// schemaAPIHandler handles GET /api/schema, the JSON Schema of the workflow
+// format with the action and trigger types of this agent
+func schemaAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	json.NewEncoder(w).Encode(schema.Generate())
+}
+
+// approveAPIHandler handles POST /api/approvals/{id}/approve
+func approveAPIHandler(w http.ResponseWriter, r *http.Request) {
+	decideApproval(w, r, true)
+}
+
+// rejectAPIHandler handles POST /api/approvals/{id}/reject
+func rejectAPIHandler(w http.ResponseWriter, r *http.Request) {
+	decideApproval(w, r, false)
+}
+
+// decisionRequest is the optional body of the approve and reject endpoints
+type decisionRequest struct {
+	By      string `json:"by"` // defaults to the caller's address
+	Comment string `json:"comment"`
+}
+
+// decideApproval approves or rejects the approval named in the request path
+func decideApproval(w http.ResponseWriter, r *http.Request, approved bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req decisionRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxTriggerPayloadBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.By == "" {
+		req.By = r.RemoteAddr
+	}
+
+	id := r.PathValue("id")
+	pending, err := approval.Decide(id, approval.Decision{Approved: approved, By: req.By, Comment: req.Comment})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Approval '%s' not found or no longer pending", id), http.StatusNotFound)
+		return
+	}
+
+	status := "rejected"
+	if approved {
+		status = "approved"
+	}
+	logger.L().Infow("Approval decided through API",
+		"approval_id", id,
+		"workflow_name", pending.Workflow,
+		"action_name", pending.Action,
+		"status", status,
+		"by", req.By,
+		"remote_addr", r.RemoteAddr)
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"id":       id,
+		"workflow": pending.Workflow,
+		"action":   pending.Action,
+		"status":   status,
+	})
+}
+
+// maintenanceRequest is the body of POST /api/agent/maintenance
+type maintenanceRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason"`
+	Policy  string `json:"policy"` // drop (default) or queue
+	TTL     string `json:"ttl"`    // e.g. "2h", defaults to DefaultMaintenanceTTL
+}
+
+// maintenanceAPIHandler handles GET and POST /api/agent/maintenance
+func maintenanceAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(Maintenance())
+		return
+	}
+
+	var req maintenanceRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxTriggerPayloadBytes)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Enabled == nil {
+		http.Error(w, "Request body requires 'enabled'", http.StatusBadRequest)
+		return
+	}
+
+	if !*req.Enabled {
+		state := ExitMaintenance()
+		logger.L().Infow("Maintenance mode ended through API", "remote_addr", r.RemoteAddr)
+		json.NewEncoder(w).Encode(state)
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid 'ttl' '%s': expected a positive duration such as 2h", req.TTL), http.StatusBadRequest)
+			return
+		}
+	}
+	state, err := EnterMaintenance(req.Reason, req.Policy, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logger.L().Infow("Maintenance mode started through API",
+		"remote_addr", r.RemoteAddr,
+		"reason", req.Reason)
+	json.NewEncoder(w).Encode(state)
+}
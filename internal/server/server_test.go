@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+func init() {
+	logger.InitLogger()
+}
+
+// controlRequest sends a POST to the server from remoteAddr
+func controlRequest(t *testing.T, path, contentType, authorization, remoteAddr string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+	req.RemoteAddr = remoteAddr
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	NewServer(0).httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestControlEndpoints(t *testing.T) {
+	const local, remote = "127.0.0.1:51234", "203.0.113.7:51234"
+	paths := []string{
+		"/api/workflows/missing/trigger",
+		"/api/workflows/missing/pause",
+		"/api/workflows/missing/resume",
+		"/api/approvals/missing/approve",
+		"/api/approvals/missing/reject",
+		"/api/agent/maintenance",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			t.Run("Remote Request Without Token", func(t *testing.T) {
+				SetAPIToken("")
+				if rec := controlRequest(t, path, "application/json", "", remote); rec.Code != http.StatusForbidden {
+					t.Errorf("Expected 403, got %d: %s", rec.Code, rec.Body)
+				}
+			})
+
+			t.Run("Local Request Without Token", func(t *testing.T) {
+				SetAPIToken("")
+				if rec := controlRequest(t, path, "application/json", "", "[::1]:51234"); rec.Code == http.StatusForbidden {
+					t.Errorf("Expected a request from this host to reach the endpoint, got %d: %s", rec.Code, rec.Body)
+				}
+			})
+
+			t.Run("Cross-Origin Simple Request", func(t *testing.T) {
+				// A web page can POST text/plain to the agent without a preflight
+				SetAPIToken("")
+				rec := controlRequest(t, path, "text/plain", "", local)
+				if rec.Code != http.StatusUnsupportedMediaType {
+					t.Errorf("Expected 415, got %d: %s", rec.Code, rec.Body)
+				}
+				if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+					t.Errorf("Expected no CORS header, got %q", origin)
+				}
+			})
+
+			t.Run("Missing Or Wrong Token", func(t *testing.T) {
+				SetAPIToken("s3cret")
+				defer SetAPIToken("")
+				for _, authorization := range []string{"", "Bearer wrong", "s3cret"} {
+					if rec := controlRequest(t, path, "application/json", authorization, local); rec.Code != http.StatusUnauthorized {
+						t.Errorf("Authorization %q: expected 401, got %d: %s", authorization, rec.Code, rec.Body)
+					}
+				}
+			})
+
+			t.Run("Accepted", func(t *testing.T) {
+				SetAPIToken("s3cret")
+				defer SetAPIToken("")
+				for _, addr := range []string{local, remote} {
+					rec := controlRequest(t, path, "application/json; charset=utf-8", "Bearer s3cret", addr)
+					if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden || rec.Code == http.StatusUnsupportedMediaType {
+						t.Errorf("From %s: expected the request to reach the endpoint, got %d: %s", addr, rec.Code, rec.Body)
+					}
+					if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+						t.Errorf("Expected no CORS header, got %q", origin)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// Package service installs the agent as a service of the host's service
+// manager, systemd on Linux and launchd on macOS, and starts, stops and
+// reports on it.
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultName is the name of the agent's service unless --name changes it
+const DefaultName = "autozap"
+
+// stopGrace is how much longer than the agent's shutdown timeout the service
+// manager waits before killing it, for shutdown workflows and async actions
+const stopGrace = time.Minute
+
+// Spec describes the agent service to install
+type Spec struct {
+	Executable      string   // absolute path of the autozap binary
+	Args            []string // arguments after the executable, e.g. agent ./workflows
+	WorkingDir      string   // where relative paths, such as the default database, resolve
+	User            string   // account a system service runs as; empty for root
+	LogDir          string   // launchd writes the agent's output here
+	ShutdownTimeout time.Duration
+	Reload          bool // SIGHUP reloads the agent configuration
+}
+
+// Manager installs and controls a service with systemd or launchd. Root
+// installs a system service, other users a service of their own.
+type Manager struct {
+	kind    string // "systemd" or "launchd"
+	name    string
+	perUser bool
+	home    string
+}
+
+// New returns the manager of the service called name on this host
+func New(name string) (*Manager, error) {
+	if name == "" || strings.ContainsAny(name, `/\ `) {
+		return nil, fmt.Errorf("invalid service name '%s'", name)
+	}
+	m := &Manager{name: name, perUser: os.Geteuid() != 0}
+	switch runtime.GOOS {
+	case "linux":
+		m.kind = "systemd"
+	case "darwin":
+		m.kind = "launchd"
+	default:
+		return nil, fmt.Errorf("service management is not supported on %s", runtime.GOOS)
+	}
+	if m.perUser {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		m.home = home
+	}
+	return m, nil
+}
+
+// Kind returns the service manager, systemd or launchd
+func (m *Manager) Kind() string {
+	return m.kind
+}
+
+// PerUser reports whether the service is the current user's rather than a
+// system service
+func (m *Manager) PerUser() bool {
+	return m.perUser
+}
+
+// Path returns the file the service definition is installed to
+func (m *Manager) Path() string {
+	switch {
+	case m.kind == "systemd" && m.perUser:
+		return filepath.Join(m.home, ".config", "systemd", "user", m.name+".service")
+	case m.kind == "systemd":
+		return filepath.Join("/etc/systemd/system", m.name+".service")
+	case m.perUser:
+		return filepath.Join(m.home, "Library", "LaunchAgents", m.label()+".plist")
+	default:
+		return filepath.Join("/Library/LaunchDaemons", m.label()+".plist")
+	}
+}
+
+// label is the launchd label of the service
+func (m *Manager) label() string {
+	return "io.autozap." + m.name
+}
+
+// Render returns the service definition of spec: a systemd unit or a launchd
+// property list
+func (m *Manager) Render(spec Spec) ([]byte, error) {
+	if m.kind == "launchd" {
+		return render(launchdPlist, spec, m.label(), m.perUser)
+	}
+	return render(systemdUnit, spec, m.name, m.perUser)
+}
+
+// Install writes the service definition and registers it with the service
+// manager, so it starts at boot (or login, for a user's service). An existing
+// definition is only replaced if force is set. The service isn't started.
+func (m *Manager) Install(spec Spec, force bool) error {
+	data, err := m.Render(spec)
+	if err != nil {
+		return err
+	}
+	path := m.Path()
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to replace it", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if spec.LogDir != "" {
+		if err := os.MkdirAll(spec.LogDir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	if m.kind == "launchd" {
+		return nil // loaded by Start; RunAtLoad starts it at boot or login
+	}
+	if err := m.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return m.systemctl("enable", m.name)
+}
+
+// Start starts the installed service
+func (m *Manager) Start() error {
+	if err := m.checkInstalled(); err != nil {
+		return err
+	}
+	if m.kind == "launchd" {
+		return run("launchctl", "load", "-w", m.Path())
+	}
+	return m.systemctl("start", m.name)
+}
+
+// Stop stops the service, which lets the agent drain its runs
+func (m *Manager) Stop() error {
+	if err := m.checkInstalled(); err != nil {
+		return err
+	}
+	if m.kind == "launchd" {
+		return run("launchctl", "unload", "-w", m.Path())
+	}
+	return m.systemctl("stop", m.name)
+}
+
+// Status prints the service manager's status of the service. It returns an
+// error if the service isn't running.
+func (m *Manager) Status() error {
+	if err := m.checkInstalled(); err != nil {
+		return err
+	}
+	if m.kind == "launchd" {
+		return run("launchctl", "list", m.label())
+	}
+	return m.systemctl("status", "--no-pager", m.name)
+}
+
+func (m *Manager) checkInstalled() error {
+	if _, err := os.Stat(m.Path()); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("service '%s' is not installed (%s not found), run 'autozap service install' first", m.name, m.Path())
+	}
+	return nil
+}
+
+func (m *Manager) systemctl(args ...string) error {
+	if m.perUser {
+		args = append([]string{"--user"}, args...)
+	}
+	return run("systemctl", args...)
+}
+
+// run runs a service manager command, showing its output
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+var systemdUnit = template.Must(template.New("systemd").Funcs(template.FuncMap{
+	"quote":      systemdQuote,
+	"specifiers": escapeSpecifiers,
+}).Parse(`[Unit]
+Description=AutoZap workflow agent ({{ .Name }})
+Documentation=https://github.com/codecrafted007/autozap
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart={{ quote .Spec.Executable }}{{ range .Spec.Args }} {{ quote . }}{{ end }}
+{{- if .Spec.Reload }}
+ExecReload=/bin/kill -HUP $MAINPID
+{{- end }}
+WorkingDirectory={{ specifiers .Spec.WorkingDir }}
+{{- if and .Spec.User (not .PerUser) }}
+User={{ .Spec.User }}
+{{- end }}
+Restart=on-failure
+RestartSec=10s
+KillSignal=SIGTERM
+TimeoutStopSec={{ .StopTimeout }}
+LimitNOFILE=65536
+
+[Install]
+WantedBy={{ if .PerUser }}default.target{{ else }}multi-user.target{{ end }}
+`))
+
+var launchdPlist = template.Must(template.New("launchd").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{ xml .Name }}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{ xml .Spec.Executable }}</string>
+{{- range .Spec.Args }}
+		<string>{{ xml . }}</string>
+{{- end }}
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{ xml .Spec.WorkingDir }}</string>
+{{- if and .Spec.User (not .PerUser) }}
+	<key>UserName</key>
+	<string>{{ xml .Spec.User }}</string>
+{{- end }}
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ExitTimeOut</key>
+	<integer>{{ .StopTimeout }}</integer>
+{{- if .Spec.LogDir }}
+	<key>StandardOutPath</key>
+	<string>{{ xml .LogFile }}</string>
+	<key>StandardErrorPath</key>
+	<string>{{ xml .LogFile }}</string>
+{{- end }}
+</dict>
+</plist>
+`))
+
+func render(tmpl *template.Template, spec Spec, name string, perUser bool) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Name        string
+		Spec        Spec
+		PerUser     bool
+		StopTimeout int
+		LogFile     string
+	}{
+		Name:        name,
+		Spec:        spec,
+		PerUser:     perUser,
+		StopTimeout: int((spec.ShutdownTimeout + stopGrace).Seconds()),
+		LogFile:     filepath.Join(spec.LogDir, "agent.log"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// systemdQuote quotes a word of a systemd command line if it needs it
+func systemdQuote(s string) string {
+	s = escapeSpecifiers(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$;") {
+		return s
+	}
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`).Replace(s)
+	return `"` + s + `"`
+}
+
+// escapeSpecifiers doubles each %, which systemd would expand as a specifier
+func escapeSpecifiers(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s)) // writing to a buffer can't fail
+	return buf.String()
+}
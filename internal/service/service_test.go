@@ -0,0 +1,122 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	spec := Spec{
+		Executable:      "/usr/local/bin/autozap",
+		Args:            []string{"agent", "/srv/my workflows", "--config", "/etc/autozap/config.yaml"},
+		WorkingDir:      "/var/lib/autozap",
+		User:            "autozap",
+		LogDir:          "/var/log/autozap",
+		ShutdownTimeout: 30 * time.Second,
+		Reload:          true,
+	}
+
+	t.Run("Systemd", func(t *testing.T) {
+		m := &Manager{kind: "systemd", name: "autozap"}
+		data, err := m.Render(spec)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		unit := string(data)
+		for _, want := range []string{
+			`ExecStart=/usr/local/bin/autozap agent "/srv/my workflows" --config /etc/autozap/config.yaml` + "\n",
+			"ExecReload=/bin/kill -HUP $MAINPID\n",
+			"WorkingDirectory=/var/lib/autozap\n",
+			"User=autozap\n",
+			"TimeoutStopSec=90\n",
+			"WantedBy=multi-user.target\n",
+		} {
+			if !strings.Contains(unit, want) {
+				t.Errorf("Expected unit to contain %q, got:\n%s", want, unit)
+			}
+		}
+		if m.Path() != "/etc/systemd/system/autozap.service" {
+			t.Errorf("Expected system unit path, got %s", m.Path())
+		}
+	})
+
+	t.Run("Systemd Per User", func(t *testing.T) {
+		m := &Manager{kind: "systemd", name: "autozap", perUser: true, home: "/home/ops"}
+		data, err := m.Render(spec)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		unit := string(data)
+		if strings.Contains(unit, "User=") {
+			t.Errorf("Expected no User= in a user's unit, got:\n%s", unit)
+		}
+		if !strings.Contains(unit, "WantedBy=default.target\n") {
+			t.Errorf("Expected WantedBy=default.target, got:\n%s", unit)
+		}
+		if m.Path() != "/home/ops/.config/systemd/user/autozap.service" {
+			t.Errorf("Expected user unit path, got %s", m.Path())
+		}
+	})
+
+	t.Run("Launchd", func(t *testing.T) {
+		m := &Manager{kind: "launchd", name: "autozap"}
+		data, err := m.Render(spec)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		plist := string(data)
+		for _, want := range []string{
+			"<string>io.autozap.autozap</string>",
+			"<string>/srv/my workflows</string>",
+			"<key>UserName</key>\n\t<string>autozap</string>",
+			"<integer>90</integer>",
+			"<string>/var/log/autozap/agent.log</string>",
+		} {
+			if !strings.Contains(plist, want) {
+				t.Errorf("Expected plist to contain %q, got:\n%s", want, plist)
+			}
+		}
+		if m.Path() != "/Library/LaunchDaemons/io.autozap.autozap.plist" {
+			t.Errorf("Expected daemon plist path, got %s", m.Path())
+		}
+	})
+
+	t.Run("Launchd Escapes XML", func(t *testing.T) {
+		m := &Manager{kind: "launchd", name: "autozap"}
+		data, err := m.Render(Spec{Executable: "/opt/a&b/autozap", WorkingDir: "/"})
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(string(data), "<string>/opt/a&amp;b/autozap</string>") {
+			t.Errorf("Expected escaped executable, got:\n%s", data)
+		}
+	})
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/usr/bin/autozap", "/usr/bin/autozap"},
+		{"/srv/my workflows", `"/srv/my workflows"`},
+		{"100%", "100%%"},
+		{`say "hi"`, `"say \"hi\""`},
+		{"$HOME", `"$$HOME"`},
+		{"", `""`},
+	}
+
+	for _, tt := range tests {
+		if got := systemdQuote(tt.in); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, expected %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewInvalidName(t *testing.T) {
+	for _, name := range []string{"", "a/b", "my agent"} {
+		if _, err := New(name); err == nil {
+			t.Errorf("Expected error for service name %q", name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirSource reads workflow files from a directory by polling instead of
+// fsnotify. This is what mounted Kubernetes ConfigMaps need: the kubelet
+// updates them by swapping a hidden "..data" symlink, which file watchers
+// on the visible files never see.
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource creates a polled directory source
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+// Name returns the source description
+func (s *DirSource) Name() string {
+	return "configmap:" + s.dir
+}
+
+// Fetch reads every visible .yaml/.yml file in the directory
+func (s *DirSource) Fetch(ctx context.Context) ([]Document, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", s.dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// ConfigMap mounts contain hidden "..data" and "..<timestamp>" entries
+		if strings.HasPrefix(entry.Name(), ".") || !isWorkflowFile(entry.Name()) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	docs := make([]Document, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(s.dir, name)
+		// os.Stat follows the ConfigMap symlinks to the real file
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		docs = append(docs, Document{ID: path, Data: data})
+	}
+
+	return docs, nil
+}
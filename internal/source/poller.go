@@ -0,0 +1,78 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"sort"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+// Change describes a workflow document that appeared, changed or disappeared
+type Change struct {
+	ID      string
+	Data    []byte
+	Removed bool
+}
+
+// Poll fetches the source every interval until ctx is cancelled and calls
+// onChange for every document that was added, modified or removed since the
+// previous fetch. The first fetch reports every document as added. A failed
+// fetch is logged and leaves the known documents untouched, so a temporarily
+// unreachable source does not stop its workflows.
+func Poll(ctx context.Context, src WorkflowSource, interval time.Duration, onChange func(Change)) {
+	known := make(map[string][32]byte)
+
+	poll := func() {
+		docs, err := src.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.L().Errorw("Failed to fetch workflow source",
+					"source", src.Name(),
+					"error", err,
+				)
+			}
+			return
+		}
+
+		// Report changes in a stable order, so workflows start reproducibly
+		sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+
+		seen := make(map[string]bool, len(docs))
+		for _, doc := range docs {
+			seen[doc.ID] = true
+			hash := sha256.Sum256(doc.Data)
+			if prev, ok := known[doc.ID]; ok && prev == hash {
+				continue
+			}
+			known[doc.ID] = hash
+			onChange(Change{ID: doc.ID, Data: doc.Data})
+		}
+
+		var removed []string
+		for id := range known {
+			if !seen[id] {
+				removed = append(removed, id)
+			}
+		}
+		sort.Strings(removed)
+		for _, id := range removed {
+			delete(known, id)
+			onChange(Change{ID: id, Removed: true})
+		}
+	}
+
+	poll()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
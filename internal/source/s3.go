@@ -0,0 +1,156 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// defaultS3Endpoint is the endpoint of AWS S3, used unless AWS_ENDPOINT_URL is set
+const defaultS3Endpoint = "https://s3.amazonaws.com"
+
+// S3Source lists and downloads workflow definitions stored under an S3 (or
+// S3-compatible, e.g. MinIO) bucket prefix.
+//
+// Configuration is read from the standard AWS environment variables:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION and
+// AWS_ENDPOINT_URL. Requests are sent unsigned when no credentials are set,
+// which works for public buckets.
+type S3Source struct {
+	bucket string
+	prefix string
+	client *minio.Client
+}
+
+// NewS3Source creates a source from an s3://bucket/prefix URL
+func NewS3Source(spec string) (*S3Source, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 source '%s': %w", spec, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 source '%s': missing bucket name", spec)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	// A custom endpoint uses path-style addressing, which S3-compatible
+	// servers support without wildcard DNS
+	lookup := minio.BucketLookupAuto
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/")
+	if endpoint != "" {
+		lookup = minio.BucketLookupPath
+	} else {
+		endpoint = defaultS3Endpoint
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+		return nil, fmt.Errorf("invalid AWS_ENDPOINT_URL '%s': must be an http:// or https:// URL", endpoint)
+	}
+
+	client, err := minio.New(endpointURL.Host, &minio.Options{
+		Creds:        credentials.NewEnvAWS(),
+		Secure:       endpointURL.Scheme == "https",
+		Region:       region,
+		BucketLookup: lookup,
+		Transport:    &timeoutTransport{timeout: 30 * time.Second},
+		MaxRetries:   1, // the poller retries on its next tick
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 source '%s': %w", spec, err)
+	}
+
+	return &S3Source{
+		bucket: u.Host,
+		prefix: strings.TrimPrefix(u.Path, "/"),
+		client: client,
+	}, nil
+}
+
+// Name returns the source description
+func (s *S3Source) Name() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+// Fetch downloads every workflow object under the prefix
+func (s *S3Source) Fetch(ctx context.Context) ([]Document, error) {
+	keys, err := s.listKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]Document, 0, len(keys))
+	for _, key := range keys {
+		object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get s3://%s/%s: %w", s.bucket, key, err)
+		}
+		data, err := io.ReadAll(object)
+		object.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read s3://%s/%s: %w", s.bucket, key, err)
+		}
+		docs = append(docs, Document{ID: fmt.Sprintf("s3://%s/%s", s.bucket, key), Data: data})
+	}
+
+	return docs, nil
+}
+
+// listKeys returns the sorted workflow object keys under the prefix
+func (s *S3Source) listKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: s.prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", s.Name(), obj.Err)
+		}
+		if isWorkflowFile(obj.Key) {
+			keys = append(keys, obj.Key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// timeoutTransport bounds each request, as http.Client.Timeout would
+type timeoutTransport struct {
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := http.DefaultTransport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
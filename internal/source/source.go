@@ -0,0 +1,50 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Document is a single workflow definition fetched from a source
+type Document struct {
+	ID   string // Stable identifier of the document, e.g. a URL or s3://bucket/key
+	Data []byte // Raw workflow definition
+}
+
+// WorkflowSource provides workflow definitions from a location other than the
+// agent's local workflow directory (remote URLs, object storage, mounted ConfigMaps)
+type WorkflowSource interface {
+	// Name returns a human readable description of the source
+	Name() string
+	// Fetch returns all workflow documents currently available from the source
+	Fetch(ctx context.Context) ([]Document, error)
+}
+
+// New creates a WorkflowSource from a source specification:
+//
+//	https://example.com/workflows/backup.yaml  single workflow served over HTTP(S)
+//	s3://bucket/prefix/                       all .yaml/.yml/.json objects under an S3 prefix
+//	configmap:/etc/autozap/workflows          polled directory (e.g. a mounted Kubernetes ConfigMap)
+//	dir:/srv/workflows                        alias of configmap:
+func New(spec string) (WorkflowSource, error) {
+	switch {
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return NewURLSource(spec), nil
+	case strings.HasPrefix(spec, "s3://"):
+		return NewS3Source(spec)
+	case strings.HasPrefix(spec, "configmap:"):
+		return NewDirSource(strings.TrimPrefix(spec, "configmap:")), nil
+	case strings.HasPrefix(spec, "dir:"):
+		return NewDirSource(strings.TrimPrefix(spec, "dir:")), nil
+	default:
+		return nil, fmt.Errorf("unsupported workflow source '%s'. Must start with http://, https://, s3://, configmap: or dir:", spec)
+	}
+}
+
+// isWorkflowFile reports whether a file name or key looks like a workflow definition
+func isWorkflowFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
@@ -0,0 +1,421 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+func TestNew(t *testing.T) {
+	valid := []string{
+		"https://example.com/workflow.yaml",
+		"http://example.com/workflow.yaml",
+		"s3://bucket/prefix/",
+		"configmap:/etc/autozap/workflows",
+		"dir:/srv/workflows",
+	}
+	for _, spec := range valid {
+		if _, err := New(spec); err != nil {
+			t.Errorf("Expected no error for source '%s', got: %v", spec, err)
+		}
+	}
+
+	invalid := []string{"ftp://example.com/wf.yaml", "/plain/path", "s3://"}
+	for _, spec := range invalid {
+		if _, err := New(spec); err == nil {
+			t.Errorf("Expected error for source '%s', got nil", spec)
+		}
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Simulate a ConfigMap mount: real files live in a hidden directory and
+	// the visible names are symlinks through "..data"
+	dataDir := filepath.Join(tmpDir, "..2025_01_01")
+	if err := os.Mkdir(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "backup.yaml"), []byte("name: backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "notes.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..2025_01_01", filepath.Join(tmpDir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "backup.yaml"), filepath.Join(tmpDir, "backup.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "notes.txt"), filepath.Join(tmpDir, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := NewDirSource(tmpDir).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Expected 1 document, got %d", len(docs))
+	}
+	if string(docs[0].Data) != "name: backup" {
+		t.Errorf("Unexpected document data: %q", docs[0].Data)
+	}
+}
+
+func TestURLSource(t *testing.T) {
+	t.Run("Successful Fetch", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("name: remote"))
+		}))
+		defer srv.Close()
+
+		docs, err := NewURLSource(srv.URL + "/wf.yaml").Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(docs) != 1 || string(docs[0].Data) != "name: remote" {
+			t.Errorf("Unexpected documents: %+v", docs)
+		}
+	})
+
+	t.Run("Non-200 Status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		if _, err := NewURLSource(srv.URL).Fetch(context.Background()); err == nil {
+			t.Fatal("Expected error for 404 response, got nil")
+		}
+	})
+}
+
+func TestS3Source(t *testing.T) {
+	var authHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/ops/" && r.URL.Query().Get("list-type") == "2":
+			w.Write([]byte(`<ListBucketResult>
+				<Contents><Key>workflows/a.yaml</Key></Contents>
+				<Contents><Key>workflows/readme.md</Key></Contents>
+				<IsTruncated>false</IsTruncated>
+			</ListBucketResult>`))
+		case r.URL.Path == "/ops/workflows/a.yaml":
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Write([]byte("name: from-s3"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	src, err := NewS3Source("s3://ops/workflows/")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	docs, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Expected 1 document, got %d", len(docs))
+	}
+	if docs[0].ID != "s3://ops/workflows/a.yaml" || string(docs[0].Data) != "name: from-s3" {
+		t.Errorf("Unexpected document: %+v", docs[0])
+	}
+	if !strings.HasPrefix(authHeader, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		t.Errorf("Expected signed request, got Authorization header %q", authHeader)
+	}
+}
+
+// fakeSource returns whatever documents are currently set
+type fakeSource struct {
+	mu   sync.Mutex
+	docs []Document
+}
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) Fetch(ctx context.Context) ([]Document, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Document(nil), f.docs...), nil
+}
+
+func (f *fakeSource) set(docs ...Document) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.docs = docs
+}
+
+func TestPoll(t *testing.T) {
+	src := &fakeSource{}
+	src.set(Document{ID: "a", Data: []byte("v1")}, Document{ID: "b", Data: []byte("v1")})
+
+	changes := make(chan Change, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Poll(ctx, src, 20*time.Millisecond, func(c Change) { changes <- c })
+
+	next := func() Change {
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for change")
+			return Change{}
+		}
+	}
+
+	// Initial fetch reports both documents
+	first, second := next(), next()
+	if first.Removed || second.Removed {
+		t.Fatal("Expected initial documents to be reported as added")
+	}
+
+	// Modify a, remove b
+	src.set(Document{ID: "a", Data: []byte("v2")})
+	got := map[string]Change{}
+	for i := 0; i < 2; i++ {
+		c := next()
+		got[c.ID] = c
+	}
+	if string(got["a"].Data) != "v2" || got["a"].Removed {
+		t.Errorf("Expected 'a' to be reported as modified, got %+v", got["a"])
+	}
+	if !got["b"].Removed {
+		t.Errorf("Expected 'b' to be reported as removed, got %+v", got["b"])
+	}
+
+	// No further changes while content is stable
+	select {
+	case c := <-changes:
+		t.Errorf("Unexpected change: %+v", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(path(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path(name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rename := func(from, to string) {
+		if err := os.Rename(path(from), path(to)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.yaml", "v1")
+	write("team-a/backup.yaml", "v1")
+
+	changes := make(chan Change, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := Watch(ctx, dir, 100*time.Millisecond, func(c Change) { changes <- c })
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer watcher.Close()
+
+	next := func() Change {
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for change")
+			return Change{}
+		}
+	}
+	expectNone := func() {
+		select {
+		case c := <-changes:
+			t.Errorf("Unexpected change: %+v", c)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+
+	t.Run("Existing Files Are Known", func(t *testing.T) {
+		if err := os.Chmod(path("a.yaml"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		expectNone()
+	})
+
+	t.Run("Burst Of Writes Is One Change", func(t *testing.T) {
+		for _, v := range []string{"v", "v2-partial", "v2"} {
+			write("a.yaml", v)
+		}
+		if c := next(); c.ID != path("a.yaml") || string(c.Data) != "v2" || c.Removed {
+			t.Errorf("Expected a.yaml changed to v2, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Atomic Save", func(t *testing.T) {
+		// Temporary file renamed over the original, as kubectl cp and most editors do
+		write(".a.yaml.tmp", "v3")
+		rename(".a.yaml.tmp", "a.yaml")
+		if c := next(); c.ID != path("a.yaml") || string(c.Data) != "v3" || c.Removed {
+			t.Errorf("Expected a.yaml changed to v3, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Backup Then Write", func(t *testing.T) {
+		// vim moves the original away, writes a new file and deletes the backup
+		rename("a.yaml", "a.yaml~")
+		write("a.yaml", "v4")
+		if err := os.Remove(path("a.yaml~")); err != nil {
+			t.Fatal(err)
+		}
+		if c := next(); c.ID != path("a.yaml") || string(c.Data) != "v4" || c.Removed {
+			t.Errorf("Expected a.yaml changed to v4, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Unchanged Content Is Not Reported", func(t *testing.T) {
+		write("a.yaml", "v4")
+		expectNone()
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		rename("a.yaml", "b.yaml")
+		got := map[string]Change{}
+		for i := 0; i < 2; i++ {
+			c := next()
+			got[c.ID] = c
+		}
+		if !got[path("a.yaml")].Removed {
+			t.Errorf("Expected a.yaml to be reported as removed, got %+v", got[path("a.yaml")])
+		}
+		if b := got[path("b.yaml")]; b.Removed || string(b.Data) != "v4" {
+			t.Errorf("Expected b.yaml to be reported as added, got %+v", b)
+		}
+		expectNone()
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		if err := os.Remove(path("b.yaml")); err != nil {
+			t.Fatal(err)
+		}
+		if c := next(); c.ID != path("b.yaml") || !c.Removed {
+			t.Errorf("Expected b.yaml to be reported as removed, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Existing Subdirectory", func(t *testing.T) {
+		write("team-a/backup.yaml", "v2")
+		if c := next(); c.ID != path("team-a/backup.yaml") || string(c.Data) != "v2" {
+			t.Errorf("Expected team-a/backup.yaml changed to v2, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("New Subdirectory", func(t *testing.T) {
+		if err := os.MkdirAll(path("team-b/nightly"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		write("team-b/nightly/report.yaml", "v1")
+		if c := next(); c.ID != path("team-b/nightly/report.yaml") || c.Removed {
+			t.Errorf("Expected team-b/nightly/report.yaml to be reported as added, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Subdirectory Moved In", func(t *testing.T) {
+		outside := t.TempDir()
+		if err := os.WriteFile(filepath.Join(outside, "sync.yaml"), []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(outside, path("team-c")); err != nil {
+			t.Fatal(err)
+		}
+		if c := next(); c.ID != path("team-c/sync.yaml") || c.Removed {
+			t.Errorf("Expected team-c/sync.yaml to be reported as added, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Subdirectory Removed", func(t *testing.T) {
+		if err := os.RemoveAll(path("team-b")); err != nil {
+			t.Fatal(err)
+		}
+		if c := next(); c.ID != path("team-b/nightly/report.yaml") || !c.Removed {
+			t.Errorf("Expected team-b/nightly/report.yaml to be reported as removed, got %+v", c)
+		}
+		expectNone()
+
+		// A new directory of the same name is watched again
+		write("team-b/report.yaml", "v1")
+		if c := next(); c.ID != path("team-b/report.yaml") || c.Removed {
+			t.Errorf("Expected team-b/report.yaml to be reported as added, got %+v", c)
+		}
+		expectNone()
+	})
+
+	t.Run("Hidden Subdirectory", func(t *testing.T) {
+		write(".git/config.yaml", "v1")
+		expectNone()
+	})
+}
+
+func TestWorkflowFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yml", "c.json", "notes.txt", ".swap.yaml", "team-a/backup.yaml", "team-a/nightly/report.yaml", ".git/hooks.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("name: x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := WorkflowFiles(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f)
+		got = append(got, rel)
+	}
+	want := "a.yml b.yaml c.json team-a/backup.yaml team-a/nightly/report.yaml"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, " "))
+	}
+
+	if _, err := WorkflowFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error for missing directory, got nil")
+	}
+}
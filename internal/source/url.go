@@ -0,0 +1,53 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// URLSource fetches a single workflow definition over HTTP(S)
+type URLSource struct {
+	url    string
+	client *http.Client
+}
+
+// NewURLSource creates a source for a workflow served at url
+func NewURLSource(url string) *URLSource {
+	return &URLSource{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the source description
+func (s *URLSource) Name() string {
+	return s.url
+}
+
+// Fetch downloads the workflow definition
+func (s *URLSource) Fetch(ctx context.Context) ([]Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", s.url, err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status code %d", s.url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", s.url, err)
+	}
+
+	return []Document{{ID: s.url, Data: data}}, nil
+}
@@ -0,0 +1,293 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDelay is how long a workflow file must be quiet before Watch
+// reports its change
+const DefaultWatchDelay = 500 * time.Millisecond
+
+// WorkflowFiles returns the workflow files in dir and its subdirectories,
+// e.g. workflows/team-a/backup.yaml, sorted so they load reproducibly.
+// Hidden files and directories, such as .git or editor swap files, are
+// skipped.
+func WorkflowFiles(dir string) ([]string, error) {
+	var files []string
+	err := walkWorkflowDir(dir, func(string) error { return nil }, func(path string) {
+		files = append(files, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// walkWorkflowDir calls visitDir for dir and each visible subdirectory, and
+// visitFile for each workflow file in them. A subdirectory visitDir fails for
+// is skipped; an error for dir itself is returned.
+func walkWorkflowDir(dir string, visitDir func(path string) error, visitFile func(path string)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // removed while walking
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if err := visitDir(path); err != nil {
+				if path == dir {
+					return err
+				}
+				logger.L().Warnw("Skipping workflow subdirectory",
+					"directory", path,
+					"error", err,
+				)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isWorkflowFile(d.Name()) {
+			visitFile(path)
+		}
+		return nil
+	})
+}
+
+// watchedFile is what Watch knows about a workflow file. An event starts or
+// restarts its timer; when the timer fires the file is read again and its
+// change, if any, reported.
+type watchedFile struct {
+	exists  bool
+	hash    [32]byte
+	pending *pendingTimer // nil while no event is waiting
+}
+
+// pendingTimer identifies a timer, so a fired timer can tell whether a later
+// event replaced it
+type pendingTimer struct {
+	timer *time.Timer
+}
+
+// firedTimer is sent to the watch loop when a file's timer expires
+type firedTimer struct {
+	path    string
+	pending *pendingTimer
+}
+
+// dirWatcher is the state of Watch, owned by its loop
+type dirWatcher struct {
+	ctx      context.Context
+	watcher  *fsnotify.Watcher
+	delay    time.Duration
+	onChange func(Change)
+
+	dirs  map[string]bool // watched directories
+	files map[string]*watchedFile
+	fired chan firedTimer
+}
+
+// Watch reports changes to the workflow files in dir and its subdirectories
+// until ctx is cancelled, like Poll but notified by fsnotify. Editors and
+// tools such as kubectl cp save by writing a temporary file and renaming it
+// over the original, which arrives as a burst of Create, Write, Rename,
+// Remove and Chmod events. Watch therefore doesn't act on single events: each
+// event restarts the file's timer, and once the file has been quiet for delay
+// it is read again and compared with its last known content. A file that now
+// exists with new content is reported as changed, a file that is gone as
+// removed, and a file whose content didn't change (e.g. only chmod) not at
+// all. Subdirectories created or moved in later are watched too, and the
+// files of a directory removed or moved away are reported as removed. Hidden
+// files and directories are ignored, like in WorkflowFiles. The files in dir
+// when Watch starts are known and not reported.
+func Watch(ctx context.Context, dir string, delay time.Duration, onChange func(Change)) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &dirWatcher{
+		ctx:      ctx,
+		watcher:  watcher,
+		delay:    delay,
+		onChange: onChange,
+		dirs:     make(map[string]bool),
+		files:    make(map[string]*watchedFile),
+		fired:    make(chan firedTimer),
+	}
+	if err := w.addDir(dir, true); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return watcher, nil
+}
+
+func (w *dirWatcher) run() {
+	defer func() {
+		for _, f := range w.files {
+			if f.pending != nil {
+				f.pending.timer.Stop()
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case t := <-w.fired:
+			w.settle(t)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.L().Errorw("Workflow watcher error",
+				"error", err,
+			)
+		}
+	}
+}
+
+// handle restarts the timer of the file an event is about, or starts or
+// stops watching the directory it is about
+func (w *dirWatcher) handle(event fsnotify.Event) {
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && w.dirs[event.Name] {
+		w.removeDir(event.Name)
+		return
+	}
+	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addDir(event.Name, false); err != nil {
+				logger.L().Warnw("Failed to watch workflow subdirectory",
+					"directory", event.Name,
+					"error", err,
+				)
+			}
+			return
+		}
+	}
+	if isWorkflowFile(event.Name) {
+		w.schedule(event.Name)
+	}
+}
+
+// addDir watches dir and its subdirectories. The workflow files in them are
+// known if initial, else their changes are reported, since they may have
+// been written before the watch was added.
+func (w *dirWatcher) addDir(dir string, initial bool) error {
+	return walkWorkflowDir(dir, func(path string) error {
+		if err := w.watcher.Add(path); err != nil {
+			return err
+		}
+		w.dirs[path] = true
+		return nil
+	}, func(path string) {
+		if !initial {
+			w.schedule(path)
+			return
+		}
+		if data, ok := readWatchedFile(path); ok {
+			w.files[path] = &watchedFile{exists: true, hash: sha256.Sum256(data)}
+		}
+	})
+}
+
+// removeDir stops watching a removed or renamed directory and its
+// subdirectories, and checks the files that were in them again
+func (w *dirWatcher) removeDir(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for path := range w.dirs {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			delete(w.dirs, path)
+			_ = w.watcher.Remove(path) // already gone if the directory was removed
+		}
+	}
+	for path := range w.files {
+		if strings.HasPrefix(path, prefix) {
+			w.schedule(path)
+		}
+	}
+}
+
+// schedule starts or restarts the timer of the file at path
+func (w *dirWatcher) schedule(path string) {
+	f := w.files[path]
+	if f == nil {
+		f = &watchedFile{}
+		w.files[path] = f
+	}
+	if f.pending != nil {
+		f.pending.timer.Stop()
+	}
+	p := &pendingTimer{}
+	p.timer = time.AfterFunc(w.delay, func() {
+		select {
+		case w.fired <- firedTimer{path: path, pending: p}:
+		case <-w.ctx.Done():
+		}
+	})
+	f.pending = p
+}
+
+// settle reads a file whose timer expired and reports its change, if any
+func (w *dirWatcher) settle(t firedTimer) {
+	f := w.files[t.path]
+	if f == nil || f.pending != t.pending {
+		return // restarted by a later event
+	}
+	f.pending = nil
+
+	data, exists := readWatchedFile(t.path)
+	switch {
+	case exists && (!f.exists || sha256.Sum256(data) != f.hash):
+		f.exists, f.hash = true, sha256.Sum256(data)
+		w.onChange(Change{ID: t.path, Data: data})
+	case !exists && f.exists:
+		f.exists = false
+		w.onChange(Change{ID: t.path, Removed: true})
+	}
+	if !f.exists {
+		delete(w.files, t.path)
+	}
+}
+
+// readWatchedFile reads the file at path, following symlinks. ok is false if
+// it doesn't exist or isn't a regular file.
+func readWatchedFile(path string) (data []byte, ok bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,515 @@
+package templating
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Data is the data made available to action templates as "."
+type Data map[string]interface{}
+
+// funcs are the helper functions available in every template. Helpers are
+// written with a dotted namespace in workflows (e.g. kv.get) and rewritten to
+// these names before parsing, since text/template identifiers cannot contain dots.
+var funcs = template.FuncMap{
+	"kv_get":     kvGet,
+	"kv_incr":    kvIncr,
+	"seen_add":   seenAdd,
+	"seen_has":   database.SeenHas,
+	"env":        os.Getenv,
+	"secret":     readSecret,
+	"shellquote": shellQuote,
+}
+
+// previewFuncs replace the helpers that change the key-value store when an
+// action is previewed, so showing it doesn't count or record anything
+var previewFuncs = template.FuncMap{
+	"kv_incr":  previewIncr,
+	"seen_add": previewSeenAdd,
+}
+
+// DefaultSecretsDir is where {{ secret "name" }} reads secrets from unless
+// AUTOZAP_SECRETS_DIR or SetSecretsDir says otherwise; Docker and Kubernetes
+// mount secrets there
+const DefaultSecretsDir = "/run/secrets"
+
+// secretsDir is the directory set with SetSecretsDir
+var secretsDir struct {
+	sync.RWMutex
+	dir string
+}
+
+// SetSecretsDir sets the directory {{ secret "name" }} reads secrets from when
+// AUTOZAP_SECRETS_DIR isn't set; empty means DefaultSecretsDir
+func SetSecretsDir(dir string) {
+	secretsDir.Lock()
+	defer secretsDir.Unlock()
+	secretsDir.dir = dir
+}
+
+// namespacedFunc matches dotted helper calls such as kv.get or seen.add, but
+// not field accesses such as .kv.get or $x.kv.get
+var namespacedFunc = regexp.MustCompile(`(^|[^\w.$])(kv|seen)\.([a-z]+)\b`)
+
+// actionBlock matches a single {{ ... }} template action
+var actionBlock = regexp.MustCompile(`{{.*?}}`)
+
+// maxCachedTemplates bounds the parsed template cache; it is emptied when full
+const maxCachedTemplates = 4096
+
+// parsed caches parsed templates by name and text, since the same action
+// templates are rendered on every run
+var parsed = struct {
+	sync.RWMutex
+	templates map[templateKey]*template.Template
+}{templates: make(map[templateKey]*template.Template)}
+
+type templateKey struct {
+	name, text, missingKey string
+}
+
+// shared holds the named templates loaded from the templates directory,
+// which every template can execute with {{ template "name" . }}
+var shared = struct {
+	sync.RWMutex
+	templates *template.Template
+}{}
+
+// LoadTemplates loads the *.tmpl files in dir as named templates, available
+// to every action template as {{ template "<file name without .tmpl>" . }},
+// so a team can define its alert messages once and change them without
+// editing workflows. Files may define more templates with {{ define }}. An
+// empty dir removes the loaded templates.
+func LoadTemplates(dir string) error {
+	var set *template.Template
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("templates directory: %w", err)
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return fmt.Errorf("failed to list templates in %s: %w", dir, err)
+		}
+		set = template.New("").Funcs(funcs)
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read template file %s: %w", path, err)
+			}
+			name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+			if _, err := set.New(name).Parse(rewriteHelpers(string(content))); err != nil {
+				return fmt.Errorf("failed to parse template file %s: %w", path, err)
+			}
+		}
+	}
+
+	shared.Lock()
+	shared.templates = set
+	shared.Unlock()
+
+	// Templates parsed before were bound to the previous set
+	parsed.Lock()
+	parsed.templates = make(map[templateKey]*template.Template)
+	parsed.Unlock()
+	return nil
+}
+
+// maxPooledBuffer is the largest buffer kept for reuse, so one large render
+// doesn't pin its memory
+const maxPooledBuffer = 64 << 10
+
+// buffers holds the buffers templates are executed into
+var buffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// Render renders text as a Go template with data. Strings without template
+// delimiters are returned unchanged. A missing map key renders as "<no value>",
+// so conditions such as {{ .steps.check.failed }} can test for it.
+func Render(name, text string, data Data) (string, error) {
+	return render(name, text, data, "default", nil)
+}
+
+// render renders text like Render, with the missingkey option of text/template
+// and, if not nil, helpers replacing the default ones
+func render(name, text string, data Data, missingKey string, helpers template.FuncMap) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := parse(name, text, missingKey)
+	if err != nil {
+		return "", err
+	}
+	if helpers != nil {
+		// The cached template is shared, so the helpers go on a copy
+		if tmpl, err = tmpl.Clone(); err != nil {
+			return "", fmt.Errorf("failed to copy template '%s': %w", name, err)
+		}
+		tmpl.Funcs(helpers)
+	}
+
+	buf := buffers.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			buf.Reset()
+			buffers.Put(buf)
+		}
+	}()
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template '%s': %w", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// parse returns the parsed template for text, from the cache if it was
+// parsed before. Parsed templates are safe to execute concurrently.
+func parse(name, text, missingKey string) (*template.Template, error) {
+	key := templateKey{name, text, missingKey}
+	parsed.RLock()
+	tmpl, ok := parsed.templates[key]
+	parsed.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := newTemplate(name).Option("missingkey=" + missingKey).Parse(rewriteHelpers(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %w", name, err)
+	}
+
+	parsed.Lock()
+	if len(parsed.templates) >= maxCachedTemplates {
+		parsed.templates = make(map[templateKey]*template.Template)
+	}
+	parsed.templates[key] = tmpl
+	parsed.Unlock()
+	return tmpl, nil
+}
+
+// rewriteHelpers rewrites kv.get to kv_get, only inside {{ }} so plain text
+// is untouched
+func rewriteHelpers(text string) string {
+	return actionBlock.ReplaceAllStringFunc(text, func(block string) string {
+		return namespacedFunc.ReplaceAllString(block, "${1}${2}_${3}")
+	})
+}
+
+// newTemplate returns an empty template named name that can execute the
+// shared templates
+func newTemplate(name string) *template.Template {
+	shared.RLock()
+	set := shared.templates
+	shared.RUnlock()
+	if set == nil {
+		return template.New(name).Funcs(funcs)
+	}
+	// Cloning keeps templates defined by one action from leaking into others
+	clone, err := set.Clone()
+	if err != nil {
+		return template.New(name).Funcs(funcs)
+	}
+	return clone.New(name)
+}
+
+// RenderAction returns a copy of act with all templated string fields rendered.
+// An HTTP action's bodyFile is read on every call and rendered as its body, and
+// a template action's template is read and rendered as its Content. A missing
+// map key is an error rather than "<no value>" in a command or request. Raw
+// actions are returned with their fields as written, so commands such as
+// docker ps --format '{{.Names}}' run unchanged.
+func RenderAction(act *workflow.Action, data Data) (*workflow.Action, error) {
+	return renderAction(act, data, nil)
+}
+
+// PreviewAction renders act like RenderAction without changing the key-value
+// store: {{ kv.incr }} shows the value it would return and {{ seen.add }}
+// whether the key would be new, for tools that show an action before it runs
+func PreviewAction(act *workflow.Action, data Data) (*workflow.Action, error) {
+	return renderAction(act, data, previewFuncs)
+}
+
+// renderAction renders act with helpers replacing the default ones, if not nil
+func renderAction(act *workflow.Action, data Data, helpers template.FuncMap) (*workflow.Action, error) {
+	rendered := *act
+	var err error
+
+	if act.BodyFile != "" {
+		content, err := os.ReadFile(act.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body file for action '%s': %w", act.Name, err)
+		}
+		rendered.Body = string(content)
+	}
+	if act.Template != "" {
+		content, err := os.ReadFile(act.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file for action '%s': %w", act.Name, err)
+		}
+		rendered.Content = string(content)
+	}
+	if act.Raw {
+		return &rendered, nil
+	}
+	if act.Template != "" {
+		if rendered.Content, err = render(act.Name+".template", rendered.Content, data, "error", helpers); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"command", &rendered.Command},
+		{"stdin", &rendered.Stdin},
+		{"stdinFile", &rendered.StdinFile},
+		{"workingDir", &rendered.WorkingDir},
+		{"runAsUser", &rendered.RunAsUser},
+		{"runAsGroup", &rendered.RunAsGroup},
+		{"url", &rendered.URL},
+		{"method", &rendered.Method},
+		{"timeout", &rendered.Timeout},
+		{"expectBodyContains", &rendered.ExpectBodyContains},
+		{"expectBodyRegex", &rendered.ExpectBodyRegex},
+		{"expectMaxLatency", &rendered.ExpectMaxLatency},
+		{"unixSocket", &rendered.UnixSocket},
+		{"proxy", &rendered.Proxy},
+		{"body", &rendered.Body},
+		{"key", &rendered.Key},
+		{"value", &rendered.Value},
+		{"manifest", &rendered.Manifest},
+		{"path", &rendered.Path},
+		{"source", &rendered.Source},
+		{"checksum", &rendered.Checksum},
+		{"duration", &rendered.Duration},
+		{"until", &rendered.Until},
+		{"topic", &rendered.Topic},
+		{"message", &rendered.Message},
+	}
+	if act.Auth != nil {
+		auth := *act.Auth
+		rendered.Auth = &auth
+		fields = append(fields, []struct {
+			name  string
+			value *string
+		}{
+			{"auth.username", &auth.Username},
+			{"auth.password", &auth.Password},
+			{"auth.token", &auth.Token},
+			{"auth.tokenUrl", &auth.TokenURL},
+			{"auth.clientId", &auth.ClientID},
+			{"auth.clientSecret", &auth.ClientSecret},
+			{"auth.audience", &auth.Audience},
+		}...)
+	}
+	if act.TLS != nil {
+		tlsConfig := *act.TLS
+		rendered.TLS = &tlsConfig
+		fields = append(fields, []struct {
+			name  string
+			value *string
+		}{
+			{"tls.caFile", &tlsConfig.CAFile},
+			{"tls.certFile", &tlsConfig.CertFile},
+			{"tls.keyFile", &tlsConfig.KeyFile},
+			{"tls.serverName", &tlsConfig.ServerName},
+		}...)
+	}
+	if act.Storage != nil {
+		storage := *act.Storage
+		rendered.Storage = &storage
+		fields = append(fields, []struct {
+			name  string
+			value *string
+		}{
+			{"storage.endpoint", &storage.Endpoint},
+			{"storage.region", &storage.Region},
+			{"storage.accessKey", &storage.AccessKey},
+			{"storage.secretKey", &storage.SecretKey},
+			{"storage.sessionToken", &storage.SessionToken},
+			{"storage.username", &storage.Username},
+			{"storage.password", &storage.Password},
+			{"storage.privateKey", &storage.PrivateKey},
+			{"storage.passphrase", &storage.Passphrase},
+			{"storage.hostKey", &storage.HostKey},
+			{"storage.knownHosts", &storage.KnownHosts},
+		}...)
+	}
+	if act.Git != nil {
+		git := *act.Git
+		rendered.Git = &git
+		fields = append(fields, []struct {
+			name  string
+			value *string
+		}{
+			{"git.ref", &git.Ref},
+			{"git.sshKey", &git.SSHKey},
+			{"git.knownHosts", &git.KnownHosts},
+			{"git.token", &git.Token},
+			{"git.username", &git.Username},
+		}...)
+	}
+	for _, field := range fields {
+		if *field.value, err = render(act.Name+"."+field.name, *field.value, data, "error", helpers); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(act.Exec) > 0 {
+		rendered.Exec = make([]string, len(act.Exec))
+		for i, arg := range act.Exec {
+			if rendered.Exec[i], err = render(fmt.Sprintf("%s.exec.%d", act.Name, i), arg, data, "error", helpers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	maps := []struct {
+		name  string
+		value *map[string]string
+	}{
+		{"headers", &rendered.Headers},
+		{"formData", &rendered.FormData},
+		{"files", &rendered.Files},
+		{"expectHeaders", &rendered.ExpectHeaders},
+		{"payload", &rendered.Payload},
+	}
+	for _, field := range maps {
+		original := *field.value
+		if len(original) == 0 {
+			continue
+		}
+		*field.value = make(map[string]string, len(original))
+		for key, value := range original {
+			if (*field.value)[key], err = render(act.Name+"."+field.name+"."+key, value, data, "error", helpers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &rendered, nil
+}
+
+// RenderVars renders a workflow's vars with data, e.g. to build a base URL
+// from {{ env "REGION" }}. Vars cannot refer to each other.
+func RenderVars(vars map[string]string, data Data) (map[string]string, error) {
+	rendered := make(map[string]string, len(vars))
+	for name, value := range vars {
+		var err error
+		if rendered[name], err = Render("vars."+name, value, data); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+// shellQuote quotes a value as a single shell word, so text from a trigger
+// payload can't run commands: {{ shellquote .payload.version }}
+func shellQuote(value interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(value), "'", `'\''`) + "'"
+}
+
+// readSecret returns the content of a secret file, without a trailing newline:
+// {{ secret "api_token" }} reads /run/secrets/api_token
+func readSecret(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == ".." {
+		return "", fmt.Errorf("secret: invalid name '%s'", name)
+	}
+	dir := os.Getenv("AUTOZAP_SECRETS_DIR")
+	if dir == "" {
+		secretsDir.RLock()
+		dir = secretsDir.dir
+		secretsDir.RUnlock()
+	}
+	if dir == "" {
+		dir = DefaultSecretsDir
+	}
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", fmt.Errorf("secret: %w", err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// kvGet returns the value stored under key, or an empty string if it is not set
+func kvGet(key string) (string, error) {
+	value, _, err := database.GetKV(key)
+	return value, err
+}
+
+// kvIncr increments the counter stored under key by 1, or by the optional
+// delta, and returns the new value: {{ kv.incr "errors" }}, {{ kv.incr "bytes" 512 }}
+//
+// It writes to the database while the template renders, so every render
+// increments again: the executor renders each field once per run, and
+// anything else rendering actions, such as a debug preview or a dry run,
+// must use PreviewAction. A counter used in both 'when' and the command is
+// incremented by each.
+func kvIncr(key string, delta ...int) (int64, error) {
+	if len(delta) > 1 {
+		return 0, fmt.Errorf("kv.incr accepts at most one delta, got %d", len(delta))
+	}
+	step := int64(1)
+	if len(delta) == 1 {
+		step = int64(delta[0])
+	}
+	return database.IncrKV(key, step)
+}
+
+// previewIncr returns the value kvIncr would return, without storing it
+func previewIncr(key string, delta ...int) (int64, error) {
+	if len(delta) > 1 {
+		return 0, fmt.Errorf("kv.incr accepts at most one delta, got %d", len(delta))
+	}
+	step := int64(1)
+	if len(delta) == 1 {
+		step = int64(delta[0])
+	}
+	value, _, err := database.GetKV(key)
+	if err != nil {
+		return 0, err
+	}
+	current, _ := strconv.ParseInt(value, 10, 64) // missing or non-numeric values start at 0
+	return current + step, nil
+}
+
+// seenAdd adds key to the seen-set with an optional TTL such as "24h" and
+// returns true if it was not seen before: {{ if seen.add .error "1h" }}...{{ end }}
+//
+// Like kvIncr it writes during render, so a second render of the same
+// template finds the key already seen; previews use previewSeenAdd.
+func seenAdd(key string, ttl ...string) (bool, error) {
+	if len(ttl) > 1 {
+		return false, fmt.Errorf("seen.add accepts at most one ttl, got %d", len(ttl))
+	}
+	var duration time.Duration
+	if len(ttl) == 1 && ttl[0] != "" {
+		var err error
+		if duration, err = time.ParseDuration(ttl[0]); err != nil {
+			return false, fmt.Errorf("seen.add: invalid ttl '%s': %w", ttl[0], err)
+		}
+	}
+	return database.SeenAdd(key, duration)
+}
+
+// previewSeenAdd returns what seenAdd would return, without adding key
+func previewSeenAdd(key string, ttl ...string) (bool, error) {
+	if len(ttl) > 1 {
+		return false, fmt.Errorf("seen.add accepts at most one ttl, got %d", len(ttl))
+	}
+	seen, err := database.SeenHas(key)
+	return !seen, err
+}
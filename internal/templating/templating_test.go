@@ -0,0 +1,529 @@
+package templating
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	// Initialize logger for tests
+	logger.InitLogger()
+}
+
+func TestRender(t *testing.T) {
+	t.Run("Plain Text Unchanged", func(t *testing.T) {
+		got, err := Render("test", "echo kv.get ${HOME}", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "echo kv.get ${HOME}" {
+			t.Errorf("Expected text unchanged, got '%s'", got)
+		}
+	})
+
+	t.Run("Data Fields", func(t *testing.T) {
+		got, err := Render("test", "hello {{ .name }}", Data{"name": "world"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "hello world" {
+			t.Errorf("Expected 'hello world', got '%s'", got)
+		}
+	})
+
+	t.Run("Shell Quoting", func(t *testing.T) {
+		got, err := Render("test", "deploy {{ shellquote .version }}", Data{"version": "1.0'; rm -rf / #"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if want := `deploy '1.0'\''; rm -rf / #'`; got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("Invalid Template", func(t *testing.T) {
+		if _, err := Render("test", "{{ .name ", nil); err == nil {
+			t.Fatal("Expected error for invalid template, got nil")
+		}
+	})
+
+	t.Run("Cached Template Renders New Data", func(t *testing.T) {
+		for _, name := range []string{"first", "second", "first"} {
+			got, err := Render("test", "hello {{ .name }}", Data{"name": name})
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got != "hello "+name {
+				t.Errorf("Expected 'hello %s', got '%s'", name, got)
+			}
+		}
+	})
+
+	t.Run("Cached Template Keeps Its Name", func(t *testing.T) {
+		for _, name := range []string{"a.command", "b.command"} {
+			_, err := Render(name, "{{ .missing.field }}", Data{"missing": 1})
+			if err == nil || !strings.Contains(err.Error(), "'"+name+"'") {
+				t.Errorf("Expected an error naming template '%s', got: %v", name, err)
+			}
+		}
+	})
+}
+
+func TestKVFunctions(t *testing.T) {
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDB()
+
+	if err := database.SetKV("lastProcessedId", "41"); err != nil {
+		t.Fatalf("Failed to set key: %v", err)
+	}
+
+	t.Run("Get Existing Key", func(t *testing.T) {
+		got, err := Render("test", `SELECT * FROM t WHERE id > {{ kv.get "lastProcessedId" }}`, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "SELECT * FROM t WHERE id > 41" {
+			t.Errorf("Unexpected render result '%s'", got)
+		}
+	})
+
+	t.Run("Get Missing Key", func(t *testing.T) {
+		got, err := Render("test", `[{{ kv.get "missing" }}]`, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "[]" {
+			t.Errorf("Expected empty value for missing key, got '%s'", got)
+		}
+	})
+
+	t.Run("Render Action", func(t *testing.T) {
+		act := &workflow.Action{
+			Type:    workflow.ActionTypeHTTP,
+			Name:    "notify",
+			URL:     `https://example.com/items?after={{ kv.get "lastProcessedId" }}`,
+			Headers: map[string]string{"X-Cursor": `{{ kv.get "lastProcessedId" }}`},
+		}
+
+		rendered, err := RenderAction(act, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if rendered.URL != "https://example.com/items?after=41" {
+			t.Errorf("Unexpected URL '%s'", rendered.URL)
+		}
+		if rendered.Headers["X-Cursor"] != "41" {
+			t.Errorf("Unexpected header '%s'", rendered.Headers["X-Cursor"])
+		}
+		if act.Headers["X-Cursor"] != `{{ kv.get "lastProcessedId" }}` {
+			t.Error("RenderAction must not modify the original action")
+		}
+	})
+}
+
+func TestRenderActionBodyFile(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(bodyFile, []byte(`{"version": "{{ .payload.version }}"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	act := &workflow.Action{Type: workflow.ActionTypeHTTP, Name: "deploy", BodyFile: bodyFile}
+	data := Data{"payload": map[string]interface{}{"version": "1.4.2"}}
+
+	t.Run("Body Rendered From File", func(t *testing.T) {
+		rendered, err := RenderAction(act, data)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if rendered.Body != `{"version": "1.4.2"}` {
+			t.Errorf("Unexpected body '%s'", rendered.Body)
+		}
+	})
+
+	t.Run("File Read On Every Render", func(t *testing.T) {
+		if err := os.WriteFile(bodyFile, []byte(`{"release": "{{ .payload.version }}"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rendered, err := RenderAction(act, data)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if rendered.Body != `{"release": "1.4.2"}` {
+			t.Errorf("Unexpected body '%s'", rendered.Body)
+		}
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		missing := &workflow.Action{Type: workflow.ActionTypeHTTP, Name: "deploy", BodyFile: filepath.Join(t.TempDir(), "missing.json")}
+		if _, err := RenderAction(missing, data); err == nil {
+			t.Fatal("Expected error for missing body file, got nil")
+		}
+	})
+}
+
+func TestRenderActionTemplate(t *testing.T) {
+	tmpl := filepath.Join(t.TempDir(), "app.conf.tmpl")
+	if err := os.WriteFile(tmpl, []byte("listen {{ .vars.port }}\nversion {{ .payload.version }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	act := &workflow.Action{Type: workflow.ActionTypeTemplate, Name: "config", Template: tmpl, Path: "/etc/app/{{ .payload.version }}.conf"}
+	data := Data{"vars": map[string]interface{}{"port": 8080}, "payload": map[string]interface{}{"version": "1.4.2"}}
+
+	rendered, err := RenderAction(act, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if rendered.Content != "listen 8080\nversion 1.4.2\n" {
+		t.Errorf("Unexpected content '%s'", rendered.Content)
+	}
+	if rendered.Path != "/etc/app/1.4.2.conf" {
+		t.Errorf("Unexpected path '%s'", rendered.Path)
+	}
+	if act.Content != "" {
+		t.Error("Expected the original action to be left unchanged")
+	}
+}
+
+func TestRenderActionExec(t *testing.T) {
+	act := &workflow.Action{Type: workflow.ActionTypeBash, Name: "dump", Exec: []string{"pg_dump", "--file", "/backups/{{ .payload.db }}.sql", "{{ .payload.db }}"}}
+	data := Data{"payload": map[string]interface{}{"db": "app db"}}
+
+	rendered, err := RenderAction(act, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	want := []string{"pg_dump", "--file", "/backups/app db.sql", "app db"}
+	if !reflect.DeepEqual(rendered.Exec, want) {
+		t.Errorf("Expected %q, got %q", want, rendered.Exec)
+	}
+	if act.Exec[3] != "{{ .payload.db }}" {
+		t.Error("Expected the original action to be left unchanged")
+	}
+}
+
+func TestRenderActionMissingKey(t *testing.T) {
+	act := &workflow.Action{Type: workflow.ActionTypeBash, Name: "ps", Command: "docker ps --format '{{.Names}}'"}
+
+	t.Run("Missing Key Is An Error", func(t *testing.T) {
+		if _, err := RenderAction(act, Data{}); err == nil || !strings.Contains(err.Error(), "Names") {
+			t.Errorf("Expected an error for the missing key, got: %v", err)
+		}
+		// Conditions still render a missing key as <no value>
+		if got, err := Render("ps.when", "{{ .Names }}", Data{}); err != nil || got != "<no value>" {
+			t.Errorf("Expected '<no value>', got '%s' (%v)", got, err)
+		}
+	})
+
+	t.Run("Raw Action Unchanged", func(t *testing.T) {
+		raw := *act
+		raw.Raw = true
+		raw.Headers = map[string]string{"X-Format": "{{json .}}"}
+		rendered, err := RenderAction(&raw, Data{})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if rendered.Command != act.Command || rendered.Headers["X-Format"] != "{{json .}}" {
+			t.Errorf("Expected the fields as written, got %q and %q", rendered.Command, rendered.Headers["X-Format"])
+		}
+	})
+
+	t.Run("Field Named Like A Helper", func(t *testing.T) {
+		data := Data{"payload": map[string]interface{}{"kv": map[string]interface{}{"get": "value"}}}
+		got, err := render("test", "{{ .payload.kv.get }}", data, "error", nil)
+		if err != nil || got != "value" {
+			t.Errorf("Expected 'value', got '%s' (%v)", got, err)
+		}
+	})
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate := func(name, text string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate("failure.tmpl", `{"text": "{{ .workflow.name }} {{ .status }}{{ template "owner" . }}"}`)
+	writeTemplate("common.tmpl", `{{ define "owner" }}{{ with .workflow.owner }} (owner: {{ . }}){{ end }}{{ end }}`)
+	writeTemplate("README.md", `{{ not a template`)
+	t.Cleanup(func() { LoadTemplates("") })
+
+	data := Data{"status": "failed", "workflow": map[string]interface{}{"name": "nightly-backup", "owner": "team-platform"}}
+
+	t.Run("Named Templates", func(t *testing.T) {
+		if err := LoadTemplates(dir); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		out, err := Render("alert.body", `{{ template "failure" . }}`, data)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if out != `{"text": "nightly-backup failed (owner: team-platform)"}` {
+			t.Errorf("Unexpected output '%s'", out)
+		}
+	})
+
+	t.Run("Changed Templates Apply After Reload", func(t *testing.T) {
+		writeTemplate("failure.tmpl", `{{ .workflow.name }} is {{ .status }}`)
+		if err := LoadTemplates(dir); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		out, err := Render("alert.body", `{{ template "failure" . }}`, data)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if out != "nightly-backup is failed" {
+			t.Errorf("Unexpected output '%s'", out)
+		}
+	})
+
+	t.Run("Invalid Template File", func(t *testing.T) {
+		writeTemplate("broken.tmpl", `{{ if .status }}`)
+		defer os.Remove(filepath.Join(dir, "broken.tmpl"))
+		if err := LoadTemplates(dir); err == nil || !strings.Contains(err.Error(), "broken.tmpl") {
+			t.Errorf("Expected error naming broken.tmpl, got: %v", err)
+		}
+	})
+
+	t.Run("Missing Directory", func(t *testing.T) {
+		if err := LoadTemplates(filepath.Join(dir, "missing")); err == nil {
+			t.Fatal("Expected error for missing directory, got nil")
+		}
+	})
+
+	t.Run("Undefined Template", func(t *testing.T) {
+		if err := LoadTemplates(""); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := Render("alert.body", `{{ template "failure" . }}`, data); err == nil {
+			t.Fatal("Expected error for undefined template, got nil")
+		}
+	})
+}
+
+func TestRenderActionAuth(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client_secret"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AUTOZAP_SECRETS_DIR", dir)
+
+	act := &workflow.Action{
+		Type: workflow.ActionTypeHTTP,
+		Name: "call",
+		Auth: &workflow.AuthConfig{
+			Type:         workflow.AuthTypeOAuth2,
+			TokenURL:     "https://auth.{{ .vars.domain }}/token",
+			ClientID:     "autozap",
+			ClientSecret: `{{ secret "client_secret" }}`,
+		},
+	}
+	rendered, err := RenderAction(act, Data{"vars": map[string]string{"domain": "example.com"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if rendered.Auth.TokenURL != "https://auth.example.com/token" || rendered.Auth.ClientSecret != "s3cret" {
+		t.Errorf("Unexpected auth %+v", *rendered.Auth)
+	}
+	if act.Auth.ClientSecret != `{{ secret "client_secret" }}` {
+		t.Errorf("Expected the action's auth to be left as is, got '%s'", act.Auth.ClientSecret)
+	}
+}
+
+func TestCounterAndSeenFunctions(t *testing.T) {
+	if err := database.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.CloseDB()
+
+	t.Run("Increment Counter", func(t *testing.T) {
+		steps := []struct {
+			text string
+			want string
+		}{
+			{`{{ kv.incr "errors" }}`, "1"},
+			{`{{ kv.incr "errors" }}`, "2"},
+			{`{{ kv.incr "errors" 10 }}`, "12"},
+		}
+		for _, step := range steps {
+			got, err := Render("test", step.text, nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got != step.want {
+				t.Errorf("Expected '%s', got '%s'", step.want, got)
+			}
+		}
+
+		value, _, err := database.GetKV("errors")
+		if err != nil || value != "12" {
+			t.Errorf("Expected stored counter '12', got '%s' (err: %v)", value, err)
+		}
+	})
+
+	t.Run("Seen Add Only Once", func(t *testing.T) {
+		text := `{{ if seen.add "disk full on db1" }}notify{{ end }}`
+		first, err := Render("test", text, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		second, err := Render("test", text, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if first != "notify" || second != "" {
+			t.Errorf("Expected notification only on first render, got '%s' then '%s'", first, second)
+		}
+
+		got, err := Render("test", `{{ seen.has "disk full on db1" }} {{ seen.has "other" }}`, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "true false" {
+			t.Errorf("Expected 'true false', got '%s'", got)
+		}
+	})
+
+	t.Run("Seen Entry Expires After TTL", func(t *testing.T) {
+		text := `{{ seen.add "flaky" "50ms" }}`
+		first, err := Render("test", text, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if first != "true" {
+			t.Fatalf("Expected first add to report new key, got '%s'", first)
+		}
+
+		time.Sleep(1100 * time.Millisecond)
+
+		again, err := Render("test", text, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if again != "true" {
+			t.Errorf("Expected key to be new again after TTL, got '%s'", again)
+		}
+	})
+
+	t.Run("Invalid TTL", func(t *testing.T) {
+		if _, err := Render("test", `{{ seen.add "x" "soon" }}`, nil); err == nil {
+			t.Fatal("Expected error for invalid ttl, got nil")
+		}
+	})
+
+	t.Run("Preview Changes Nothing", func(t *testing.T) {
+		act := &workflow.Action{
+			Type:    workflow.ActionTypeBash,
+			Name:    "count",
+			Command: `echo {{ kv.incr "previewed" 5 }} {{ seen.add "preview" }}`,
+		}
+		for i := 0; i < 2; i++ {
+			rendered, err := PreviewAction(act, nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if rendered.Command != "echo 5 true" {
+				t.Errorf("Expected 'echo 5 true', got '%s'", rendered.Command)
+			}
+		}
+		if _, found, _ := database.GetKV("previewed"); found {
+			t.Error("Expected the preview not to store the counter")
+		}
+		if seen, _ := database.SeenHas("preview"); seen {
+			t.Error("Expected the preview not to add the key to the seen-set")
+		}
+
+		// The cached template still has the real helpers
+		rendered, err := RenderAction(act, nil)
+		if err != nil || rendered.Command != "echo 5 true" {
+			t.Fatalf("Expected 'echo 5 true', got '%v' (%v)", rendered, err)
+		}
+		if value, _, _ := database.GetKV("previewed"); value != "5" {
+			t.Errorf("Expected the run to store 5, got '%s'", value)
+		}
+	})
+}
+
+func TestVarsEnvAndSecrets(t *testing.T) {
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("AUTOZAP_TEST_REGION", "eu-west-1")
+		got, err := Render("test", `{{ env "AUTOZAP_TEST_REGION" }}`, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "eu-west-1" {
+			t.Errorf("Expected 'eu-west-1', got '%s'", got)
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("AUTOZAP_SECRETS_DIR", dir)
+		if err := os.WriteFile(filepath.Join(dir, "api_token"), []byte("s3cr3t\n"), 0600); err != nil {
+			t.Fatalf("Failed to write secret: %v", err)
+		}
+
+		got, err := Render("test", `Bearer {{ secret "api_token" }}`, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got != "Bearer s3cr3t" {
+			t.Errorf("Expected 'Bearer s3cr3t', got '%s'", got)
+		}
+
+		if _, err := Render("test", `{{ secret "missing" }}`, nil); err == nil {
+			t.Error("Expected error for a missing secret")
+		}
+		if _, err := Render("test", `{{ secret "../api_token" }}`, nil); err == nil {
+			t.Error("Expected error for a secret name with a path")
+		}
+	})
+
+	t.Run("Secrets Dir", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("AUTOZAP_SECRETS_DIR", "")
+		SetSecretsDir(dir)
+		t.Cleanup(func() { SetSecretsDir("") })
+		if err := os.WriteFile(filepath.Join(dir, "api_token"), []byte("s3cr3t"), 0600); err != nil {
+			t.Fatalf("Failed to write secret: %v", err)
+		}
+
+		got, err := Render("test", `{{ secret "api_token" }}`, nil)
+		if err != nil || got != "s3cr3t" {
+			t.Errorf("Expected 's3cr3t', got '%s' (%v)", got, err)
+		}
+	})
+
+	t.Run("Render Vars", func(t *testing.T) {
+		t.Setenv("AUTOZAP_TEST_REGION", "eu-west-1")
+		vars, err := RenderVars(map[string]string{
+			"base_url": `https://api.{{ env "AUTOZAP_TEST_REGION" }}.example.com`,
+			"dir":      "/backups/{{ .event.trigger }}",
+		}, Data{"event": map[string]interface{}{"trigger": "cron"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if vars["base_url"] != "https://api.eu-west-1.example.com" || vars["dir"] != "/backups/cron" {
+			t.Errorf("Unexpected vars: %v", vars)
+		}
+
+		act := &workflow.Action{Name: "call", URL: "{{ .vars.base_url }}/health", Method: "{{ .vars.method }}"}
+		rendered, err := RenderAction(act, Data{"vars": map[string]string{"base_url": vars["base_url"], "method": "GET"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if rendered.URL != "https://api.eu-west-1.example.com/health" || rendered.Method != "GET" {
+			t.Errorf("Unexpected rendered action: %s %s", rendered.Method, rendered.URL)
+		}
+	})
+}
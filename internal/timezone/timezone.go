@@ -0,0 +1,61 @@
+// Package timezone holds the time zone the CLI and the API show timestamps
+// in. Timestamps are stored in UTC and only converted for display, so
+// reports read the same whatever zone the agent or the database runs in.
+package timezone
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Layout is how timestamps are shown in tables: the offset is included so
+// reports from machines in different zones can be compared
+const Layout = "2006-01-02 15:04:05 -07:00"
+
+var display = struct {
+	sync.RWMutex
+	loc *time.Location
+}{loc: time.Local}
+
+// Load returns the time zone with the given name: "" or "Local" for the
+// system's local time zone, "UTC", or an IANA name such as "Europe/Berlin"
+func Load(name string) (*time.Location, error) {
+	switch {
+	case name == "" || strings.EqualFold(name, "local"):
+		return time.Local, nil
+	case strings.EqualFold(name, "utc"):
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone '%s': use Local, UTC or an IANA name such as Europe/Berlin", name)
+	}
+	return loc, nil
+}
+
+// Set sets the time zone timestamps are shown in
+func Set(loc *time.Location) {
+	display.Lock()
+	defer display.Unlock()
+	display.loc = loc
+}
+
+// Location returns the time zone timestamps are shown in, local time unless
+// set otherwise
+func Location() *time.Location {
+	display.RLock()
+	defer display.RUnlock()
+	return display.loc
+}
+
+// In converts t to the display time zone
+func In(t time.Time) time.Time {
+	return t.In(Location())
+}
+
+// Format formats t in the display time zone with Layout
+func Format(t time.Time) string {
+	return In(t).Format(Layout)
+}
@@ -0,0 +1,52 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "Local", false},
+		{"local", "Local", false},
+		{"UTC", "UTC", false},
+		{"utc", "UTC", false},
+		{"Asia/Tokyo", "Asia/Tokyo", false},
+		{"Mars/Olympus", "", true},
+	}
+
+	for _, tt := range tests {
+		loc, err := Load(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Load(%q): expected error %v, got: %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if err == nil && loc.String() != tt.want {
+			t.Errorf("Load(%q): expected %s, got %s", tt.name, tt.want, loc)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	defer Set(time.Local)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+	stored := time.Date(2026, 3, 1, 22, 30, 0, 0, time.UTC)
+
+	Set(tokyo)
+	if got := Format(stored); got != "2026-03-02 07:30:00 +09:00" {
+		t.Errorf("Expected '2026-03-02 07:30:00 +09:00', got '%s'", got)
+	}
+
+	Set(time.UTC)
+	if got := Format(stored.In(tokyo)); got != "2026-03-01 22:30:00 +00:00" {
+		t.Errorf("Expected '2026-03-01 22:30:00 +00:00', got '%s'", got)
+	}
+}
@@ -0,0 +1,136 @@
+package trigger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+)
+
+// clockCheckInterval is how often the clock watcher compares the wall clock
+// with the monotonic clock
+const clockCheckInterval = 10 * time.Second
+
+// clockJumpThreshold is how far the wall clock may move away from the
+// monotonic clock between two checks before it counts as a jump. NTP slews
+// the clock far slower than this.
+const clockJumpThreshold = time.Minute
+
+// Kinds of clock jumps
+const (
+	clockSuspend  = "suspend"  // the system was suspended
+	clockForward  = "forward"  // the wall clock was set forward, or the system suspended where that can't be told apart
+	clockBackward = "backward" // the wall clock was set back
+)
+
+// clockJump is a discontinuity of the wall clock between two checks of the
+// clock watcher
+type clockJump struct {
+	Kind      string
+	From      time.Time     // wall clock at the last check before the jump
+	To        time.Time     // wall clock at the check that detected it
+	Suspended time.Duration // how long the system was suspended, if known
+}
+
+// clockReading holds the clocks compared by the clock watcher
+type clockReading struct {
+	wall  time.Time     // wall clock
+	awake time.Duration // monotonic clock, which stops while the system is suspended
+	boot  time.Duration // time since boot including suspends, zero if unknown
+}
+
+// monotonicBase anchors the monotonic readings of readClock
+var monotonicBase = time.Now()
+
+func readClock() clockReading {
+	now := time.Now()
+	return clockReading{wall: now.Round(0), awake: now.Sub(monotonicBase), boot: bootTime()}
+}
+
+// detectClockJump compares two readings of the clocks. Where the boot clock
+// is known, a suspend shows as the boot clock running ahead of the monotonic
+// one; elsewhere it can't be told apart from setting the clock forward.
+func detectClockJump(prev, cur clockReading) (clockJump, bool) {
+	awake := cur.awake - prev.awake
+	wall := cur.wall.Sub(prev.wall)
+	jump := clockJump{From: prev.wall, To: cur.wall}
+
+	if prev.boot > 0 && cur.boot > 0 {
+		if asleep := cur.boot - prev.boot - awake; asleep > clockJumpThreshold {
+			jump.Kind = clockSuspend
+			jump.Suspended = asleep
+			return jump, true
+		}
+	}
+
+	switch drift := wall - awake; {
+	case drift > clockJumpThreshold:
+		jump.Kind = clockForward
+	case drift < -clockJumpThreshold:
+		jump.Kind = clockBackward
+	default:
+		return jump, false
+	}
+	return jump, true
+}
+
+// clockWatcher checks the clocks every clockCheckInterval once the first
+// cron trigger subscribes, and tells the subscribed triggers about jumps
+var clockWatcher struct {
+	once        sync.Once
+	mu          sync.Mutex
+	subscribers map[chan clockJump]struct{}
+}
+
+// subscribeClockJumps returns a channel receiving the clock jumps detected
+// from now on, and a function that unsubscribes it. A jump detected while
+// the previous one hasn't been received is dropped for that subscriber.
+func subscribeClockJumps() (<-chan clockJump, func()) {
+	clockWatcher.once.Do(func() {
+		clockWatcher.subscribers = make(map[chan clockJump]struct{})
+		go watchClock()
+	})
+
+	ch := make(chan clockJump, 1)
+	clockWatcher.mu.Lock()
+	clockWatcher.subscribers[ch] = struct{}{}
+	clockWatcher.mu.Unlock()
+
+	return ch, func() {
+		clockWatcher.mu.Lock()
+		delete(clockWatcher.subscribers, ch)
+		clockWatcher.mu.Unlock()
+	}
+}
+
+func watchClock() {
+	ticker := time.NewTicker(clockCheckInterval)
+	defer ticker.Stop()
+
+	prev := readClock()
+	for range ticker.C {
+		cur := readClock()
+		jump, ok := detectClockJump(prev, cur)
+		prev = cur
+		if !ok {
+			continue
+		}
+
+		logger.L().Warnw("Clock jump detected, reconciling cron schedules",
+			"kind", jump.Kind,
+			"from", jump.From.Format(time.RFC3339),
+			"to", jump.To.Format(time.RFC3339),
+			"suspended", jump.Suspended)
+		metrics.RecordClockJump(jump.Kind)
+
+		clockWatcher.mu.Lock()
+		for ch := range clockWatcher.subscribers {
+			select {
+			case ch <- jump:
+			default:
+			}
+		}
+		clockWatcher.mu.Unlock()
+	}
+}
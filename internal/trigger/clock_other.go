@@ -0,0 +1,11 @@
+//go:build !linux
+
+package trigger
+
+import "time"
+
+// bootTime returns zero: outside Linux suspends are detected as the wall
+// clock jumping forward
+func bootTime() time.Duration {
+	return 0
+}
@@ -0,0 +1,118 @@
+package trigger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestDetectClockJump(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	boot := time.Hour
+
+	tests := []struct {
+		name     string
+		awake    time.Duration // monotonic clock
+		wall     time.Duration
+		asleep   time.Duration // boot clock minus monotonic clock, -1 if unknown
+		wantKind string
+	}{
+		{"normal tick", 10 * time.Second, 10 * time.Second, 0, ""},
+		{"ntp slew", 10 * time.Second, 10*time.Second + 50*time.Millisecond, 0, ""},
+		{"suspend", 10 * time.Second, 3 * time.Hour, 3 * time.Hour, clockSuspend},
+		{"clock set forward", 10 * time.Second, 2 * time.Hour, 0, clockForward},
+		{"clock set back", 10 * time.Second, -time.Hour, 0, clockBackward},
+		{"suspend without boot clock", 10 * time.Second, 3 * time.Hour, -1, clockForward},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := clockReading{wall: start, awake: time.Minute, boot: boot}
+			cur := clockReading{wall: start.Add(tt.wall), awake: time.Minute + tt.awake, boot: boot + tt.awake + tt.asleep}
+			if tt.asleep < 0 {
+				prev.boot, cur.boot = 0, 0
+			}
+
+			jump, ok := detectClockJump(prev, cur)
+			if tt.wantKind == "" {
+				if ok {
+					t.Errorf("Expected no jump, got %+v", jump)
+				}
+				return
+			}
+			if !ok || jump.Kind != tt.wantKind {
+				t.Fatalf("Expected a %s jump, got %+v (detected %v)", tt.wantKind, jump, ok)
+			}
+			if !jump.From.Equal(start) {
+				t.Errorf("Expected the jump to start at %v, got %v", start, jump.From)
+			}
+		})
+	}
+}
+
+func TestRunMissed(t *testing.T) {
+	from := time.Date(2026, 3, 1, 10, 30, 0, 0, time.Local)
+	jump := clockJump{Kind: clockSuspend, From: from, To: from.Add(3*time.Hour + 5*time.Minute)}
+
+	// startMissed handles the missed runs of an hourly workflow that records
+	// the time each run was scheduled for, and returns a function reading them
+	startMissed := func(t *testing.T, name string, policy workflow.MissedRunPolicy) func() []string {
+		runs := filepath.Join(t.TempDir(), "runs")
+		wf := &workflow.Workflow{
+			Name:    name,
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeCron, Schedule: "0 * * * *", MissedRuns: policy},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "echo {{ .event.time }} >> " + runs},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		runMissed(ctx, wf, jump, from)
+
+		return func() []string {
+			content, err := os.ReadFile(runs)
+			if err != nil {
+				return nil
+			}
+			return strings.Fields(string(content))
+		}
+	}
+	waitForRuns := func(t *testing.T, runs func() []string, n int) []string {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) && len(runs()) < n {
+			time.Sleep(20 * time.Millisecond)
+		}
+		time.Sleep(100 * time.Millisecond) // no more runs than expected
+		return runs()
+	}
+	hour := func(h int) string {
+		return time.Date(2026, 3, 1, h, 0, 0, 0, time.Local).Format(time.RFC3339)
+	}
+
+	t.Run("Run Once By Default", func(t *testing.T) {
+		got := waitForRuns(t, startMissed(t, "missed-once", ""), 1)
+		if len(got) != 1 || got[0] != hour(13) {
+			t.Errorf("Expected one run for %s, got %v", hour(13), got)
+		}
+	})
+
+	t.Run("Run All", func(t *testing.T) {
+		got := waitForRuns(t, startMissed(t, "missed-all", workflow.MissedRunsAll), 3)
+		want := []string{hour(11), hour(12), hour(13)}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Expected runs %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		if got := waitForRuns(t, startMissed(t, "missed-skip", workflow.MissedRunsSkip), 0); len(got) != 0 {
+			t.Errorf("Expected no runs, got %v", got)
+		}
+	})
+}
@@ -3,10 +3,12 @@ package trigger
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/codecrafted007/autozap/internal/action"
-	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/executor"
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/metrics"
 	"github.com/codecrafted007/autozap/internal/server"
@@ -14,123 +16,136 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
-// Helper functions for database operations
-func startWorkflowExecutionInDB(workflowName, triggerType string) (int64, error) {
-	return database.StartWorkflowExecution(workflowName, triggerType)
+func init() {
+	Register(workflow.TriggerTypeCron, Definition{
+		Start:    StartCronTrigger,
+		Validate: validateCronTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			return []string{"Schedule: " + t.Schedule}
+		},
+	})
 }
 
-func completeWorkflowExecutionInDB(id int64, status string, errorMsg *string, duration time.Duration) error {
-	return database.CompleteWorkflowExecution(id, status, errorMsg, duration)
+// validateCronTrigger checks the schedule, jitter and missedRuns of a cron trigger
+func validateCronTrigger(t *workflow.Trigger) error {
+	if t.Schedule == "" {
+		return fmt.Errorf("cron trigger requires a 'schedule'")
+	}
+
+	// Parse the schedule the same way the cron trigger does, so bad
+	// expressions fail validation instead of failing at trigger start
+	if _, err := t.ParseSchedule(); err != nil {
+		return fmt.Errorf("cron trigger has invalid 'schedule' '%s': %w", t.Schedule, err)
+	}
+
+	if _, err := t.JitterDuration(); err != nil {
+		return fmt.Errorf("cron trigger has invalid 'jitter' '%s': %w", t.Jitter, err)
+	}
+
+	switch t.MissedRuns {
+	case "", workflow.MissedRunsOnce, workflow.MissedRunsAll, workflow.MissedRunsSkip:
+	default:
+		return fmt.Errorf("cron trigger has invalid 'missedRuns' '%s'. Must be one of: %s, %s, %s",
+			t.MissedRuns, workflow.MissedRunsOnce, workflow.MissedRunsAll, workflow.MissedRunsSkip)
+	}
+
+	if t.Path != "" || len(t.Events) > 0 {
+		logger.L().Warnf("cron trigger has unexpected 'path' or 'event' these will be ignored.")
+	}
+
+	if t.ProcessedDir != "" || t.FailedDir != "" {
+		logger.L().Warnf("cron trigger has unexpected 'processedDir' or 'failedDir'; these will be ignored.")
+	}
+
+	if t.Extract || t.ExtractDir != "" {
+		logger.L().Warnf("cron trigger has unexpected 'extract' or 'extractDir'; these will be ignored.")
+	}
+
+	if len(t.Patterns) > 0 || len(t.Ignore) > 0 {
+		logger.L().Warnf("cron trigger has unexpected 'patterns' or 'ignore'; these will be ignored.")
+	}
+
+	if t.Debounce != "" || t.Batch {
+		logger.L().Warnf("cron trigger has unexpected 'debounce' or 'batch'; these will be ignored.")
+	}
+
+	if t.URL != "" || t.Interval != "" {
+		logger.L().Warnf("cron trigger has unexpected 'url' or 'interval'; these will be ignored.")
+	}
+	return nil
 }
 
 func StartCronTrigger(ctx context.Context, wf *workflow.Workflow) error {
 	// Register workflow in the registry
 	server.GetRegistry().RegisterWorkflow(wf)
 
-	c := cron.New()
+	jitter, err := wf.Trigger.JitterDuration()
+	if err != nil {
+		return fmt.Errorf("invalid jitter '%s' for workflow '%s': %w", wf.Trigger.Jitter, wf.Name, err)
+	}
+
+	// The first tick of a workflow loaded at agent startup may be delayed to
+	// stagger the workflows' first runs
+	firstDelay := startupDelay(wf.Name)
+	var firstTick sync.Once
+
+	c := cron.New(cron.WithParser(wf.Trigger.CronParser()))
+
+	// lastFire is when the cron scheduler last ran the job, so missed runs it
+	// made up for itself after a clock jump aren't made up again
+	var lastFire atomic.Int64
+
+	job := func() {
+		lastFire.Store(time.Now().UnixNano())
+
+		if server.GetRegistry().IsPaused(wf.Name) {
+			logger.L().Infow("Skipping cron trigger for paused workflow",
+				"workflow_name", wf.Name,
+				"trigger_schedule", wf.Trigger.Schedule)
+			return
+		}
 
-	entryId, err := c.AddFunc(wf.Trigger.Schedule, func() {
 		// Record trigger fire
 		metrics.RecordTriggerFire(wf.Name, string(workflow.TriggerTypeCron))
+		firedAt := time.Now()
 
 		logger.L().Infow("Cron Trigger fired for workflow",
 			"workflow_name", wf.Name,
 			"trigger_schedule", wf.Trigger.Schedule,
-			"timestamp", time.Now().Format(time.RFC3339))
-
-		// Track workflow execution time
-		workflowStartTime := time.Now()
-		workflowStatus := "success"
-		var workflowError *string
+			"timestamp", firedAt.Format(time.RFC3339))
 
-		// Start workflow execution in database
-		workflowExecID, err := startWorkflowExecutionInDB(wf.Name, string(workflow.TriggerTypeCron))
-		if err != nil {
-			logger.L().Errorw("Failed to start workflow execution in database",
-				"workflow_name", wf.Name,
-				"error", err)
-		}
-
-		for i, act := range wf.Actions {
-			var actionError error
-			switch act.Type {
-			case workflow.ActionTypeBash:
-				logger.L().Infow("Attempting to execute Bash Action",
-					"workflow_name", wf.Name,
-					"action_name", act.Name,
-					"action_index", i,
-					"command", act.Command)
-				actionError = action.ExecuteBashAction(&act, wf.Name)
-				if actionError != nil {
-					logger.L().Errorw("Failed to execute Bash Action",
-						"workflow_name", wf.Name,
-						"action_name", act.Name,
-						"action_index", i,
-						"error", actionError)
-					workflowStatus = "failed"
-					errMsg := actionError.Error()
-					workflowError = &errMsg
-				}
-			case workflow.ActionTypeHTTP:
-				logger.L().Infow("Attempting to execute HTTP Action",
-					"workflow_name", wf.Name,
-					"action_name", act.Name,
-					"action_index", i,
-					"url", act.URL,
-					"method", act.Method)
-				actionError = action.ExecuteHttpAction(&act, wf.Name)
-				if actionError != nil {
-					logger.L().Errorw("Failed to execute Http Action",
-						"workflow_name", wf.Name,
-						"action_name", act.Name,
-						"action_index", i,
-						"error", actionError)
-					workflowStatus = "failed"
-					errMsg := actionError.Error()
-					workflowError = &errMsg
-				}
-			case workflow.ActionTypeCustom:
-				logger.L().Infow("Attempting to execute Custom Action",
+		var delay time.Duration
+		firstTick.Do(func() {
+			if firstDelay > 0 {
+				delay = firstDelay
+				logger.L().Infow("Delaying first cron run to stagger agent startup",
 					"workflow_name", wf.Name,
-					"action_name", act.Name,
-					"action_index", i,
-					"action_type", act.Type.String())
-				// TODO: Implement Custom action execution
-			default:
-				logger.L().Errorw("Unknown Action Type",
-					"workflow_name", wf.Name,
-					"action_name", act.Name,
-					"action_index", i,
-					"action_type", act.Type.String(),
-					"error", "unsupported action type")
-				workflowStatus = "failed"
-				errMsg := "unsupported action type: " + act.Type.String()
-				workflowError = &errMsg
+					"delay", delay)
 			}
+		})
+		if jitter > 0 {
+			jitterDelay := time.Duration(rand.Int63n(int64(jitter)))
+			logger.L().Infow("Delaying cron run by jitter",
+				"workflow_name", wf.Name,
+				"delay", jitterDelay)
+			delay += jitterDelay
 		}
 
-		// Record workflow execution metrics
-		workflowDuration := time.Since(workflowStartTime)
-		metrics.RecordWorkflowExecution(wf.Name, workflowStatus, workflowDuration)
-
-		// Complete workflow execution in database
-		if workflowExecID > 0 {
-			if err := completeWorkflowExecutionInDB(workflowExecID, workflowStatus, workflowError, workflowDuration); err != nil {
-				logger.L().Errorw("Failed to complete workflow execution in database",
-					"workflow_name", wf.Name,
-					"workflow_exec_id", workflowExecID,
-					"error", err)
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
 			}
 		}
 
-		// Update registry with execution stats
-		errorMsg := ""
-		if workflowError != nil {
-			errorMsg = *workflowError
-		}
-		server.GetRegistry().UpdateExecutionStats(wf.Name, workflowStatus == "success", errorMsg)
-	})
+		// The fire time is the scheduled time, before any jitter
+		executor.ExecuteWithData(wf, string(workflow.TriggerTypeCron), executor.WithEvent(nil, executor.Event{Time: firedAt}))
+	}
 
+	// The entry is replaced after a clock jump, see reconcile below
+	var entryMu sync.Mutex
+	entryId, err := c.AddFunc(wf.Trigger.Schedule, job)
 	if err != nil {
 		return fmt.Errorf("failed to add cron job for workflow '%s': %w", wf.Name, err)
 	}
@@ -147,13 +162,48 @@ func StartCronTrigger(ctx context.Context, wf *workflow.Workflow) error {
 		"workflow_name", wf.Name,
 		"trigger_schedule", wf.Trigger.Schedule,
 		"entry_id", entryId,
-		"next_run", nextRun)
+		"next_run", nextRun,
+		"first_run_delay", firstDelay)
 
 	// Register workflow info metric
 	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeCron), wf.Trigger.Schedule)
 
+	// After a suspend or a forward clock jump the scheduler would make up
+	// for the missed runs late, whenever the timer it set before the jump
+	// fires. Instead the missed runs are handled by the workflow's policy
+	// right away, and the entry replaced so its next run is computed from
+	// the new time. A backward jump needs nothing: the scheduler waits for
+	// the next run it computed before the jump, so no run is repeated.
+	reconcile := func(jump clockJump) {
+		if jump.Kind == clockBackward {
+			return
+		}
+
+		entryMu.Lock()
+		c.Remove(entryId)
+		id, err := c.AddFunc(wf.Trigger.Schedule, job)
+		if err == nil {
+			entryId = id
+		}
+		entryMu.Unlock()
+		if err != nil {
+			logger.L().Errorw("Failed to reschedule cron job after clock jump",
+				"workflow_name", wf.Name,
+				"error", err)
+			return
+		}
+
+		from := jump.From
+		if last := time.Unix(0, lastFire.Load()); last.After(from) {
+			from = last
+		}
+		runMissed(ctx, wf, jump, from)
+	}
+
 	// Update next execution time after each run
+	jumps, unsubscribe := subscribeClockJumps()
 	go func() {
+		defer unsubscribe()
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 		for {
@@ -163,11 +213,16 @@ func StartCronTrigger(ctx context.Context, wf *workflow.Workflow) error {
 					"workflow_name", wf.Name,
 					"reason", "context cancelled")
 				return
+			case jump := <-jumps:
+				reconcile(jump)
 			case <-ticker.C:
-				entry := c.Entry(entryId)
-				if !entry.Next.IsZero() {
-					server.GetRegistry().UpdateNextExecution(wf.Name, entry.Next)
-				}
+			}
+
+			entryMu.Lock()
+			entry := c.Entry(entryId)
+			entryMu.Unlock()
+			if !entry.Next.IsZero() {
+				server.GetRegistry().UpdateNextExecution(wf.Name, entry.Next)
 			}
 		}
 	}()
@@ -193,3 +248,97 @@ func StartCronTrigger(ctx context.Context, wf *workflow.Workflow) error {
 
 	return nil
 }
+
+// maxMissedRuns bounds the runs made up for with the "all" policy, so a
+// minutely workflow doesn't run thousands of times after a long suspend
+const maxMissedRuns = 100
+
+// runMissed handles the runs of a cron workflow scheduled between from and
+// the end of a clock jump by its missedRuns policy. The runs are made in the
+// background, oldest first, with the time they were scheduled for.
+func runMissed(ctx context.Context, wf *workflow.Workflow, jump clockJump, from time.Time) {
+	sched, err := wf.Trigger.ParseSchedule()
+	if err != nil {
+		return
+	}
+
+	// The first maxMissedRuns runs are kept for the "all" policy, the last
+	// one for "once"
+	var missed []time.Time
+	var last time.Time
+	count := 0
+	for next := sched.Next(from); !next.IsZero() && !next.After(jump.To); next = sched.Next(next) {
+		if count < maxMissedRuns {
+			missed = append(missed, next)
+		}
+		last = next
+		count++
+	}
+	if count == 0 {
+		return
+	}
+
+	policy := wf.Trigger.MissedRuns
+	if policy == "" {
+		policy = workflow.MissedRunsOnce
+	}
+	if server.GetRegistry().IsPaused(wf.Name) {
+		policy = workflow.MissedRunsSkip
+	}
+
+	var runs []time.Time
+	switch policy {
+	case workflow.MissedRunsOnce:
+		runs = []time.Time{last}
+	case workflow.MissedRunsAll:
+		runs = missed
+	}
+	skipped := count - len(runs)
+
+	logger.L().Warnw("Cron runs missed during clock jump",
+		"workflow_name", wf.Name,
+		"clock_jump", jump.Kind,
+		"missed_policy", policy,
+		"missed_runs", count,
+		"runs", len(runs),
+		"first_missed", missed[0].Format(time.RFC3339),
+		"last_missed", last.Format(time.RFC3339))
+	metrics.RecordMissedCronRuns(wf.Name, "skipped", skipped)
+	metrics.RecordMissedCronRuns(wf.Name, "run", len(runs))
+
+	if len(runs) == 0 {
+		return
+	}
+	go func() {
+		for _, scheduled := range runs {
+			if ctx.Err() != nil {
+				return
+			}
+			metrics.RecordTriggerFire(wf.Name, string(workflow.TriggerTypeCron))
+			executor.ExecuteWithData(wf, string(workflow.TriggerTypeCron), executor.WithEvent(nil, executor.Event{
+				Time:   scheduled,
+				Source: map[string]string{"missed_run": "true", "clock_jump": jump.Kind},
+			}))
+		}
+	}()
+}
+
+// NextRuns returns the next n times a cron trigger fires after from, in
+// from's time zone (the agent's local time zone when called with time.Now())
+func NextRuns(t workflow.Trigger, from time.Time, n int) ([]time.Time, error) {
+	sched, err := t.ParseSchedule()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron schedule '%s': %w", t.Schedule, err)
+	}
+
+	runs := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = sched.Next(next)
+		if next.IsZero() {
+			break // schedule never fires again (e.g. Feb 30)
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
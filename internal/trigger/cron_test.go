@@ -1,7 +1,9 @@
 package trigger
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/codecrafted007/autozap/internal/workflow"
 )
@@ -19,7 +21,7 @@ func TestStartCronTrigger(t *testing.T) {
 			},
 		}
 
-		err := StartCronTrigger(wf)
+		err := StartCronTrigger(context.Background(), wf)
 		if err == nil {
 			t.Fatal("Expected error for invalid cron schedule, got nil")
 		}
@@ -37,12 +39,31 @@ func TestStartCronTrigger(t *testing.T) {
 			},
 		}
 
-		err := StartCronTrigger(wf)
+		err := StartCronTrigger(context.Background(), wf)
 		if err != nil {
 			t.Fatalf("Expected no error for valid cron schedule, got: %v", err)
 		}
 	})
 
+	t.Run("Invalid Jitter", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name: "test-workflow-jitter",
+			Trigger: workflow.Trigger{
+				Type:     workflow.TriggerTypeCron,
+				Schedule: "* * * * *",
+				Jitter:   "soon",
+			},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "test", Command: "echo test"},
+			},
+		}
+
+		err := StartCronTrigger(context.Background(), wf)
+		if err == nil {
+			t.Fatal("Expected error for invalid jitter, got nil")
+		}
+	})
+
 	t.Run("Standard Cron Expressions", func(t *testing.T) {
 		schedules := []string{
 			"*/5 * * * *", // Every 5 minutes
@@ -67,10 +88,99 @@ func TestStartCronTrigger(t *testing.T) {
 				},
 			}
 
-			err := StartCronTrigger(wf)
+			err := StartCronTrigger(context.Background(), wf)
 			if err != nil {
 				t.Errorf("Expected no error for schedule '%s', got: %v", schedule, err)
 			}
 		}
 	})
 }
+
+func TestNextRuns(t *testing.T) {
+	from := time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC)
+
+	t.Run("Daily At Midnight", func(t *testing.T) {
+		runs, err := NextRuns(workflow.Trigger{Schedule: "0 0 * * *"}, from, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(runs) != 3 {
+			t.Fatalf("Expected 3 runs, got %d", len(runs))
+		}
+		for i, want := range []time.Time{
+			time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC),
+		} {
+			if !runs[i].Equal(want) {
+				t.Errorf("Run %d: expected %v, got %v", i, want, runs[i])
+			}
+		}
+	})
+
+	t.Run("Descriptor", func(t *testing.T) {
+		runs, err := NextRuns(workflow.Trigger{Schedule: "@every 15m"}, from, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(runs) != 2 || !runs[1].Equal(from.Add(30*time.Minute)) {
+			t.Errorf("Expected runs every 15 minutes, got %v", runs)
+		}
+	})
+
+	t.Run("With Seconds", func(t *testing.T) {
+		runs, err := NextRuns(workflow.Trigger{Schedule: "*/15 * * * * *", WithSeconds: true}, from, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(runs) != 3 || !runs[2].Equal(from.Add(45*time.Second)) {
+			t.Errorf("Expected runs every 15 seconds, got %v", runs)
+		}
+	})
+
+	t.Run("Invalid Schedule", func(t *testing.T) {
+		if _, err := NextRuns(workflow.Trigger{Schedule: "61 * * * *"}, from, 5); err == nil {
+			t.Fatal("Expected error for invalid schedule, got nil")
+		}
+	})
+}
+
+func TestStaggerStartup(t *testing.T) {
+	t.Run("Spreads First Runs In Order", func(t *testing.T) {
+		StaggerStartup([]string{"a", "b", "c", "d"}, time.Minute)
+
+		for name, want := range map[string]time.Duration{"a": 0, "b": 15 * time.Second, "c": 30 * time.Second, "d": 45 * time.Second} {
+			if got := startupDelay(name); got != want {
+				t.Errorf("Workflow %s: expected delay %s, got %s", name, want, got)
+			}
+		}
+	})
+
+	t.Run("Delay Is Only Used Once", func(t *testing.T) {
+		StaggerStartup([]string{"a", "b"}, time.Minute)
+
+		if got := startupDelay("b"); got != 30*time.Second {
+			t.Fatalf("Expected delay 30s, got %s", got)
+		}
+		if got := startupDelay("b"); got != 0 {
+			t.Errorf("Expected a reloaded workflow not to be delayed, got %s", got)
+		}
+	})
+
+	t.Run("No Spread", func(t *testing.T) {
+		StaggerStartup([]string{"a", "b"}, 0)
+
+		if got := startupDelay("b"); got != 0 {
+			t.Errorf("Expected no delay, got %s", got)
+		}
+	})
+
+	t.Run("Expires After Spread", func(t *testing.T) {
+		StaggerStartup([]string{"a", "b"}, 20*time.Millisecond)
+		time.Sleep(30 * time.Millisecond)
+
+		if got := startupDelay("b"); got != 0 {
+			t.Errorf("Expected no delay after the startup window, got %s", got)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package trigger
+
+import (
+	"sync"
+	"time"
+)
+
+// debouncer coalesces rapid file events. Each path gets its own timer that
+// restarts on every event, and the path is delivered on ready once it has
+// been quiet for the delay. In batch mode a single timer covers all paths and
+// every path changed during the burst is delivered together.
+type debouncer struct {
+	delay time.Duration
+	batch bool
+	ready chan []string
+	done  chan struct{}
+
+	mu     sync.Mutex
+	timers map[string]*pendingTimer // by path, or "" in batch mode
+	files  []string                 // paths of the pending batch, in order
+	seen   map[string]bool
+}
+
+// pendingTimer identifies a timer, so an expired timer can tell whether it
+// has been replaced without reading the *time.Timer set after it started
+type pendingTimer struct {
+	timer *time.Timer
+}
+
+func newDebouncer(delay time.Duration, batch bool) *debouncer {
+	return &debouncer{
+		delay:  delay,
+		batch:  batch,
+		ready:  make(chan []string),
+		done:   make(chan struct{}),
+		timers: make(map[string]*pendingTimer),
+		seen:   make(map[string]bool),
+	}
+}
+
+// add records an event for path and restarts its timer
+func (d *debouncer) add(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := path
+	if d.batch {
+		key = ""
+		if !d.seen[path] {
+			d.seen[path] = true
+			d.files = append(d.files, path)
+		}
+	}
+
+	if p, exists := d.timers[key]; exists {
+		p.timer.Stop()
+	}
+	p := &pendingTimer{}
+	p.timer = time.AfterFunc(d.delay, func() { d.fire(key, p) })
+	d.timers[key] = p
+}
+
+// fire delivers the paths of an expired timer unless it has been replaced
+func (d *debouncer) fire(key string, p *pendingTimer) {
+	d.mu.Lock()
+	if d.timers[key] != p {
+		d.mu.Unlock()
+		return // restarted by a later event
+	}
+	delete(d.timers, key)
+
+	paths := []string{key}
+	if d.batch {
+		paths = d.files
+		d.files = nil
+		d.seen = make(map[string]bool)
+	}
+	d.mu.Unlock()
+
+	select {
+	case d.ready <- paths:
+	case <-d.done:
+	}
+}
+
+// stop cancels pending timers; events still waiting are dropped
+func (d *debouncer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, p := range d.timers {
+		p.timer.Stop()
+		delete(d.timers, key)
+	}
+	close(d.done)
+}
@@ -0,0 +1,201 @@
+package trigger
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// archiveKind returns the archive format of a file from its name, or "" if it
+// is not a supported archive
+func archiveKind(path string) string {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	default:
+		return ""
+	}
+}
+
+// archiveStem strips the archive extension from a file name: "logs.tar.gz" -> "logs"
+func archiveStem(path string) string {
+	base := filepath.Base(path)
+	lower := strings.ToLower(base)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return base[:len(base)-len(ext)]
+		}
+	}
+	return base
+}
+
+// extractArchive extracts a zip, tar or tar.gz archive into destDir. Entries
+// that would escape destDir are rejected; links and special files are skipped.
+func extractArchive(path, destDir string) error {
+	switch archiveKind(path) {
+	case "zip":
+		return extractZip(path, destDir)
+	case "tar":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return extractTar(f, destDir)
+	case "tar.gz":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip stream: %w", err)
+		}
+		defer gz.Close()
+		return extractTar(gz, destDir)
+	default:
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(path))
+	}
+}
+
+// safeJoin joins an archive entry name to destDir, rejecting names that
+// resolve outside of it ("zip slip")
+func safeJoin(destDir, name string) (string, error) {
+	target := filepath.Join(destDir, name)
+	if target != destDir && !strings.HasPrefix(target, destDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry '%s' escapes the extraction directory", name)
+	}
+	return target, nil
+}
+
+func extractZip(path, destDir string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		target, err := safeJoin(destDir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("failed to read '%s' from archive: %w", f.Name, err)
+			}
+			err = writeExtractedFile(target, rc, mode.Perm())
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func extractTar(r io.Reader, destDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		target, err := safeJoin(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeExtractedFile(target, tr, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeExtractedFile writes an archive entry to target, creating parent directories
+func writeExtractedFile(target string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if perm == 0 {
+		perm = 0644
+	}
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to extract '%s': %w", target, err)
+	}
+	return out.Close()
+}
+
+// prepareExtraction extracts the archive that triggered a run and returns the
+// extraction directory. Without a configured extractDir the archive goes to a
+// temporary directory that cleanup removes after the run; with one it goes to
+// <extractDir>/<archive name> and is kept.
+func prepareExtraction(t workflow.Trigger, archivePath string) (dir string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	if t.ExtractDir == "" {
+		dir, err = os.MkdirTemp("", "autozap-extract-")
+		if err != nil {
+			return "", cleanup, fmt.Errorf("failed to create extraction directory: %w", err)
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+	} else {
+		baseDir := t.ExtractDir
+		if !filepath.IsAbs(baseDir) {
+			baseDir = filepath.Join(watchBaseDir(t.Path), baseDir)
+		}
+		dir = filepath.Join(baseDir, archiveStem(archivePath))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", cleanup, fmt.Errorf("failed to create extraction directory: %w", err)
+		}
+	}
+
+	if dir, err = filepath.Abs(dir); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+
+	if err := extractArchive(archivePath, dir); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("failed to extract '%s': %w", archivePath, err)
+	}
+	return dir, cleanup, nil
+}
@@ -0,0 +1,156 @@
+package trigger
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	files := map[string]string{
+		"data.csv":        "a,b,c",
+		"nested/info.txt": "hello",
+	}
+
+	t.Run("Zip Archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "upload.zip")
+		writeZip(t, archive, files)
+
+		dest := t.TempDir()
+		if err := extractArchive(archive, dest); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for name, content := range files {
+			data, err := os.ReadFile(filepath.Join(dest, name))
+			if err != nil || string(data) != content {
+				t.Errorf("Expected %s to contain '%s', got '%s' (err: %v)", name, content, data, err)
+			}
+		}
+	})
+
+	t.Run("Tar Gz Archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "upload.tgz")
+		writeTarGz(t, archive, files)
+
+		dest := t.TempDir()
+		if err := extractArchive(archive, dest); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for name, content := range files {
+			data, err := os.ReadFile(filepath.Join(dest, name))
+			if err != nil || string(data) != content {
+				t.Errorf("Expected %s to contain '%s', got '%s' (err: %v)", name, content, data, err)
+			}
+		}
+	})
+
+	t.Run("Path Traversal Rejected", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "evil.zip")
+		writeZip(t, archive, map[string]string{"../escape.txt": "nope"})
+
+		dest := t.TempDir()
+		if err := extractArchive(archive, dest); err == nil {
+			t.Fatal("Expected error for entry escaping the extraction directory, got nil")
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "escape.txt")); !os.IsNotExist(err) {
+			t.Error("Expected no file to be written outside the extraction directory")
+		}
+	})
+
+	t.Run("Unsupported Format", func(t *testing.T) {
+		if err := extractArchive("notes.txt", t.TempDir()); err == nil {
+			t.Fatal("Expected error for unsupported format, got nil")
+		}
+	})
+}
+
+func TestPrepareExtraction(t *testing.T) {
+	inbox := t.TempDir()
+	archive := filepath.Join(inbox, "batch-42.tar.gz")
+	writeTarGz(t, archive, map[string]string{"a.txt": "a"})
+
+	t.Run("Temporary Directory Removed After Run", func(t *testing.T) {
+		trig := workflow.Trigger{Type: workflow.TriggerTypeFileWatch, Path: inbox, Extract: true}
+		dir, cleanup, err := prepareExtraction(trig, archive)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+			t.Fatalf("Expected extracted file, got: %v", err)
+		}
+		cleanup()
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Error("Expected temporary extraction directory to be removed")
+		}
+	})
+
+	t.Run("Configured Directory Kept", func(t *testing.T) {
+		trig := workflow.Trigger{Type: workflow.TriggerTypeFileWatch, Path: inbox, Extract: true, ExtractDir: "extracted"}
+		dir, cleanup, err := prepareExtraction(trig, archive)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		cleanup()
+		if dir != filepath.Join(inbox, "extracted", "batch-42") {
+			t.Errorf("Unexpected extraction directory '%s'", dir)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+			t.Errorf("Expected extracted file to be kept, got: %v", err)
+		}
+	})
+}
@@ -2,18 +2,122 @@ package trigger
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/codecrafted007/autozap/internal/action"
-	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/executor"
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/metrics"
 	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
 	"github.com/codecrafted007/autozap/internal/workflow"
 	"github.com/fsnotify/fsnotify"
 )
 
+func init() {
+	Register(workflow.TriggerTypeFileWatch, Definition{
+		Start:    StartFileWatchTrigger,
+		Validate: validateFileWatchTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			return []string{"Watch path: " + t.Path, fmt.Sprintf("Events: %v", t.Events)}
+		},
+	})
+}
+
+// validateFileWatchTrigger checks the path, events, patterns and debounce of a
+// filewatch trigger
+func validateFileWatchTrigger(t *workflow.Trigger) error {
+	if t.Path == "" {
+		return fmt.Errorf("filewatch trigger requires a 'path'")
+	}
+
+	if len(t.Events) == 0 {
+		return fmt.Errorf("filewatch trigger requires at least one 'event'")
+	}
+
+	// Validate event names at parse time
+	if err := validateFileWatchEvents(t.Events); err != nil {
+		return fmt.Errorf("filewatch trigger validation failed: %w", err)
+	}
+
+	if t.Schedule != "" || t.WithSeconds {
+		logger.L().Warnf("Filewatch trigger has unexpected 'schedule' or 'withSeconds' field; it will be ignored.")
+	}
+
+	if t.ProcessedDir != "" && t.ProcessedDir == t.FailedDir {
+		return fmt.Errorf("filewatch trigger 'processedDir' and 'failedDir' must be different directories")
+	}
+
+	if t.ExtractDir != "" && !t.Extract {
+		logger.L().Warnf("Filewatch trigger has 'extractDir' without 'extract: true'; archives will not be extracted.")
+	}
+
+	if t.Jitter != "" {
+		logger.L().Warnf("Filewatch trigger has unexpected 'jitter' field; it will be ignored.")
+	}
+
+	if err := validateFilePatterns("patterns", t.Patterns); err != nil {
+		return err
+	}
+	if err := validateFilePatterns("ignore", t.Ignore); err != nil {
+		return err
+	}
+
+	debounce, err := t.DebounceDuration()
+	if err != nil {
+		return fmt.Errorf("filewatch trigger has invalid 'debounce' '%s': %w", t.Debounce, err)
+	}
+	if t.Batch && debounce == 0 {
+		return fmt.Errorf("filewatch trigger 'batch' requires a 'debounce'")
+	}
+	if t.Batch && t.Extract {
+		return fmt.Errorf("filewatch trigger 'batch' cannot be combined with 'extract'")
+	}
+
+	if t.URL != "" || t.Interval != "" {
+		logger.L().Warnf("Filewatch trigger has unexpected 'url' or 'interval'; these will be ignored.")
+	}
+	return nil
+}
+
+// validateFilePatterns checks the syntax of filewatch file patterns
+func validateFilePatterns(field string, patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("filewatch trigger '%s' cannot contain an empty pattern", field)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("filewatch trigger has invalid '%s' pattern '%s': %w", field, pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateFileWatchEvents checks if all event names are valid
+func validateFileWatchEvents(events []string) error {
+	validEvents := map[string]bool{
+		"create": true,
+		"write":  true,
+		"remove": true,
+		"rename": true,
+		"chmod":  true,
+	}
+
+	for _, event := range events {
+		if !validEvents[event] {
+			return fmt.Errorf("invalid filewatch event: '%s'. Valid events are: create, write, remove, rename, chmod", event)
+		}
+	}
+
+	return nil
+}
+
 func StartFileWatchTrigger(ctx context.Context, wf *workflow.Workflow) error {
 
 	if wf.Trigger.Type != workflow.TriggerTypeFileWatch {
@@ -60,6 +164,39 @@ func StartFileWatchTrigger(ctx context.Context, wf *workflow.Workflow) error {
 		return err
 	}
 
+	// Resolve the hot-folder output directories
+	processedDir, failedDir, err := resolveOutputDirs(wf.Trigger)
+	if err != nil {
+		if closeErr := watcher.Close(); closeErr != nil {
+			logger.L().Errorw("Failed to close watcher after error", "error", closeErr, "workflow_name", wf.Name)
+		}
+		logger.L().Errorw("File watch trigger setup error",
+			"workflow_name", wf.Name,
+			"error", err,
+		)
+		return err
+	}
+
+	// Extracted files must not retrigger the workflow
+	extractDir := ""
+	if wf.Trigger.Extract && wf.Trigger.ExtractDir != "" {
+		extractDir = wf.Trigger.ExtractDir
+		if !filepath.IsAbs(extractDir) {
+			extractDir = filepath.Join(watchBaseDir(wf.Trigger.Path), extractDir)
+		}
+		extractDir, _ = filepath.Abs(extractDir)
+	}
+
+	baseDir := watchBaseDir(wf.Trigger.Path)
+
+	debounce, err := wf.Trigger.DebounceDuration()
+	if err != nil {
+		if closeErr := watcher.Close(); closeErr != nil {
+			logger.L().Errorw("Failed to close watcher after error", "error", closeErr, "workflow_name", wf.Name)
+		}
+		return fmt.Errorf("invalid debounce '%s' for workflow '%s': %w", wf.Trigger.Debounce, wf.Name, err)
+	}
+
 	logger.L().Infow("File watch trigger started",
 		"workflow_name", wf.Name,
 		"watching_path", wf.Trigger.Path,
@@ -72,9 +209,42 @@ func StartFileWatchTrigger(ctx context.Context, wf *workflow.Workflow) error {
 	// Register workflow info metric
 	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeFileWatch), wf.Trigger.Path)
 
+	// Runs the workflow for files whose events fired it and moves them to the
+	// hot-folder output directory for the outcome. eventType is the event of a
+	// single file, empty for a batch.
+	runFiles := func(paths []string, eventType string) {
+		metrics.RecordTriggerFire(wf.Name, string(workflow.TriggerTypeFileWatch))
+
+		var status string
+		if wf.Trigger.Batch {
+			data := executor.WithEvent(templating.Data{"files": paths}, executor.Event{Files: paths, Time: time.Now()})
+			status = executor.ExecuteWithData(wf, string(workflow.TriggerTypeFileWatch), data)
+		} else {
+			status = runFileWatchWorkflow(wf, paths[0], eventType)
+		}
+		if !workflow.WasStarted(status) {
+			return // the workflow didn't run; leave the files for the next event
+		}
+
+		outputDir := processedDir
+		if status != workflow.StatusSuccess {
+			outputDir = failedDir
+		}
+		if outputDir != "" {
+			for _, path := range paths {
+				moveToOutputDir(wf.Name, path, outputDir)
+			}
+		}
+	}
+
+	pending := newDebouncer(debounce, wf.Trigger.Batch)
+	// Latest event of each debounced file; only used by the event loop
+	pendingEvents := make(map[string]string)
+
 	// Start go routine to handle file events
 	go func() {
 		defer func() {
+			pending.stop()
 			if closeErr := watcher.Close(); closeErr != nil {
 				logger.L().Errorw("Failed to close watcher", "error", closeErr, "workflow_name", wf.Name)
 			}
@@ -104,6 +274,7 @@ func StartFileWatchTrigger(ctx context.Context, wf *workflow.Workflow) error {
 					"event_op", event.Op.String(),
 				)
 				shouldTrigger := false
+				eventType := ""
 				for _, ev := range wf.Trigger.Events {
 					switch ev {
 					case "create":
@@ -134,115 +305,62 @@ func StartFileWatchTrigger(ctx context.Context, wf *workflow.Workflow) error {
 					}
 
 					if shouldTrigger {
+						eventType = ev
 						break // Found a matching event, no need to check further
 					}
 				}
 
-				if shouldTrigger {
-					// Record trigger fire
-					metrics.RecordTriggerFire(wf.Name, string(workflow.TriggerTypeFileWatch))
+				// Ignore our own output directories being created or written to
+				if isWithin(event.Name, processedDir) || isWithin(event.Name, failedDir) || isWithin(event.Name, extractDir) {
+					shouldTrigger = false
+				}
 
-					logger.L().Infow("File watch trigger fired for worflow",
+				if shouldTrigger && !matchesFilePatterns(wf.Trigger, baseDir, event.Name) {
+					logger.L().Debugw("Ignoring file event not matching trigger patterns",
 						"workflow_name", wf.Name,
-						"event_type", event.Op.String(),
-						"file_path", event.Name,
-						"timestamp", time.Now().Format(time.RFC3339),
-					)
-
-					// Track workflow execution time
-					workflowStartTime := time.Now()
-					workflowStatus := "success"
-					var workflowError *string
-
-					// Start workflow execution in database
-					workflowExecID, err := database.StartWorkflowExecution(wf.Name, string(workflow.TriggerTypeFileWatch))
-					if err != nil {
-						logger.L().Errorw("Failed to start workflow execution in database",
-							"workflow_name", wf.Name,
-							"error", err)
-					}
+						"file_path", event.Name)
+					shouldTrigger = false
+				}
 
-					// Exceute actions
-					for i, act := range wf.Actions {
-						var actionError error
-						switch act.Type {
-						case workflow.ActionTypeBash:
-							logger.L().Infow("Attempting to execute Bash Action",
-								"workflow_name", wf.Name,
-								"action_name", act.Name,
-								"action_index", i,
-								"command", act.Command)
-							actionError = action.ExecuteBashAction(&act, wf.Name)
-							if actionError != nil{
-								logger.L().Errorw("Failed to execute Bash Action",
-									"workflow_name", wf.Name,
-									"action_name", act.Name,
-									"action_index", i,
-									"error", actionError)
-								workflowStatus = "failed"
-								errMsg := actionError.Error()
-								workflowError = &errMsg
-							}
-						case workflow.ActionTypeHTTP:
-							logger.L().Infow("Attempting to execute HTTP Action",
-								"workflow_name", wf.Name,
-								"action_name", act.Name,
-								"action_index", i,
-								"url", act.URL,
-								"method", act.Method)
-							actionError = action.ExecuteHttpAction(&act, wf.Name)
-							if actionError != nil {
-								logger.L().Errorw("Failed to execute HTTP Action",
-									"workflow_name", wf.Name,
-									"action_name", act.Name,
-									"action_index", i,
-									"error", actionError)
-								workflowStatus = "failed"
-								errMsg := actionError.Error()
-								workflowError = &errMsg
-							}
-						case workflow.ActionTypeCustom:
-							logger.L().Infow("Custom action type detected, but execution not yet implemented (triggered by filewatch).",
-								"workflow_name", wf.Name,
-								"action_index", i,
-								"action_name", act.Name,
-								"action_type", act.Type.String(),
-							)
-							// TODO: Implement HTTP action execution
-						default:
-							logger.L().Warnw("Unsupported action type encountered for execution (triggered by filewatch)",
-								"workflow_name", wf.Name,
-								"action_index", i,
-								"action_name", act.Name,
-								"action_type", act.Type.String(),
-							)
-							workflowStatus = "failed"
-							errMsg := "unsupported action type: " + act.Type.String()
-							workflowError = &errMsg
-						}
-					} // End of execte actions
-
-					// Record workflow execution metrics
-					workflowDuration := time.Since(workflowStartTime)
-					metrics.RecordWorkflowExecution(wf.Name, workflowStatus, workflowDuration)
-
-					// Complete workflow execution in database
-					if workflowExecID > 0 {
-						if err := database.CompleteWorkflowExecution(workflowExecID, workflowStatus, workflowError, workflowDuration); err != nil {
-							logger.L().Errorw("Failed to complete workflow execution in database",
-								"workflow_name", wf.Name,
-								"workflow_exec_id", workflowExecID,
-								"error", err)
-						}
-					}
+				if shouldTrigger && server.GetRegistry().IsPaused(wf.Name) {
+					logger.L().Infow("Skipping file watch trigger for paused workflow",
+						"workflow_name", wf.Name,
+						"file_path", event.Name)
+					shouldTrigger = false
+				}
 
-					// Update registry with execution stats
-					errorMsg := ""
-					if workflowError != nil {
-						errorMsg = *workflowError
-					}
-					server.GetRegistry().UpdateExecutionStats(wf.Name, workflowStatus == "success", errorMsg)
+				if !shouldTrigger {
+					continue
 				}
+
+				if debounce > 0 {
+					pendingEvents[event.Name] = eventType
+					pending.add(event.Name)
+					continue
+				}
+
+				logger.L().Infow("File watch trigger fired for worflow",
+					"workflow_name", wf.Name,
+					"event_type", event.Op.String(),
+					"file_path", event.Name,
+					"timestamp", time.Now().Format(time.RFC3339),
+				)
+				runFiles([]string{event.Name}, eventType)
+			case paths := <-pending.ready:
+				logger.L().Infow("File watch trigger fired for worflow after debounce",
+					"workflow_name", wf.Name,
+					"file_paths", paths,
+					"debounce", debounce,
+					"timestamp", time.Now().Format(time.RFC3339),
+				)
+				eventType := ""
+				if len(paths) == 1 {
+					eventType = pendingEvents[paths[0]]
+				}
+				for _, path := range paths {
+					delete(pendingEvents, path)
+				}
+				runFiles(paths, eventType)
 			case err, ok := <-watcher.Errors:
 				if !ok {
 					logger.L().Errorw("File watcher errors channel closed", "workflow_name", wf.Name)
@@ -259,3 +377,231 @@ func StartFileWatchTrigger(ctx context.Context, wf *workflow.Workflow) error {
 
 	return nil
 }
+
+// runFileWatchWorkflow executes the workflow for a file event. The file is
+// available to templates as {{ .files }} and {{ .event.path }}. With extract
+// enabled, an archive that triggered the run is extracted first and the
+// extraction directory is exposed to templates as {{ .extractDir }}.
+func runFileWatchWorkflow(wf *workflow.Workflow, path, eventType string) string {
+	data := executor.WithEvent(templating.Data{"files": []string{path}}, executor.Event{
+		Type:  eventType,
+		Path:  path,
+		Files: []string{path},
+		Time:  time.Now(),
+	})
+
+	if !wf.Trigger.Extract || archiveKind(path) == "" {
+		return executor.ExecuteWithData(wf, string(workflow.TriggerTypeFileWatch), data)
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		logger.L().Debugw("Skipping extraction of triggering file",
+			"workflow_name", wf.Name,
+			"file_path", path,
+			"reason", "not a regular file")
+		return executor.ExecuteWithData(wf, string(workflow.TriggerTypeFileWatch), data)
+	}
+
+	dir, cleanup, err := prepareExtraction(wf.Trigger, path)
+	if err != nil {
+		logger.L().Errorw("Failed to extract archive, workflow not run",
+			"workflow_name", wf.Name,
+			"file_path", path,
+			"error", err)
+		return workflow.StatusFailed
+	}
+	defer cleanup()
+
+	logger.L().Infow("Extracted archive",
+		"workflow_name", wf.Name,
+		"file_path", path,
+		"extract_dir", dir)
+
+	data["extractDir"] = dir
+	return executor.ExecuteWithData(wf, string(workflow.TriggerTypeFileWatch), data)
+}
+
+// resolveOutputDirs returns the absolute processed/failed directories of a
+// filewatch trigger, creating them if needed. Relative directories are
+// resolved against the watched directory (or the parent of a watched file).
+func resolveOutputDirs(t workflow.Trigger) (processedDir, failedDir string, err error) {
+	baseDir := watchBaseDir(t.Path)
+
+	resolve := func(dir string) (string, error) {
+		if dir == "" {
+			return "", nil
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create output directory '%s': %w", dir, err)
+		}
+		return dir, nil
+	}
+
+	if processedDir, err = resolve(t.ProcessedDir); err != nil {
+		return "", "", err
+	}
+	if failedDir, err = resolve(t.FailedDir); err != nil {
+		return "", "", err
+	}
+	return processedDir, failedDir, nil
+}
+
+// watchBaseDir returns the watched directory, or the parent of a watched file
+func watchBaseDir(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+// matchesFilePatterns reports whether a file passes the trigger's patterns
+// and ignore filters. Patterns without a slash match the file name, others
+// the path relative to baseDir.
+func matchesFilePatterns(t workflow.Trigger, baseDir, path string) bool {
+	name := filepath.Base(path)
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		rel = name
+	}
+	rel = filepath.ToSlash(rel)
+
+	matches := func(pattern string) bool {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		ok, _ := filepath.Match(pattern, target) // validated by the parser
+		return ok
+	}
+
+	for _, pattern := range t.Ignore {
+		if matches(pattern) {
+			return false
+		}
+	}
+	if len(t.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range t.Patterns {
+		if matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator))
+}
+
+// moveToOutputDir moves the file that triggered a run into dir. Events for
+// files that no longer exist (e.g. remove events) or for directories are skipped.
+func moveToOutputDir(workflowName, path, dir string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		logger.L().Debugw("Skipping move of triggering file",
+			"workflow_name", workflowName,
+			"file_path", path,
+			"reason", "not a regular file")
+		return
+	}
+
+	dest, err := moveFileUnique(path, dir)
+	if err != nil {
+		logger.L().Errorw("Failed to move triggering file",
+			"workflow_name", workflowName,
+			"file_path", path,
+			"output_dir", dir,
+			"error", err)
+		return
+	}
+
+	logger.L().Infow("Moved triggering file",
+		"workflow_name", workflowName,
+		"file_path", path,
+		"destination", dest)
+}
+
+// maxRenameAttempts bounds the search for a free file name in the output directory
+const maxRenameAttempts = 1000
+
+// moveFileUnique moves src into dir without overwriting existing files. If the
+// name is taken a numeric suffix is added: report.csv, report-1.csv, report-2.csv...
+// It returns the destination path.
+func moveFileUnique(src, dir string) (string, error) {
+	base := filepath.Base(src)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for i := 0; i < maxRenameAttempts; i++ {
+		name := base
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		dest := filepath.Join(dir, name)
+
+		err := moveNoClobber(src, dest)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return dest, err
+	}
+
+	return "", fmt.Errorf("no free file name for '%s' in '%s' after %d attempts", base, dir, maxRenameAttempts)
+}
+
+// moveNoClobber moves src to dest, failing with fs.ErrExist if dest exists.
+// A hard link is used so the check and the move are atomic; when that is not
+// possible (e.g. across filesystems) the file is copied with O_EXCL instead.
+func moveNoClobber(src, dest string) error {
+	err := os.Link(src, dest)
+	if err == nil {
+		return os.Remove(src)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return err
+	}
+
+	return os.Remove(src)
+}
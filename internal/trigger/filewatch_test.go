@@ -1,7 +1,12 @@
 package trigger
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/codecrafted007/autozap/internal/logger"
 	"github.com/codecrafted007/autozap/internal/workflow"
@@ -25,7 +30,7 @@ func TestStartFileWatchTrigger(t *testing.T) {
 			},
 		}
 
-		err := StartFileWatchTrigger(wf)
+		err := StartFileWatchTrigger(context.Background(), wf)
 		if err == nil {
 			t.Fatal("Expected error for invalid trigger type, got nil")
 		}
@@ -44,7 +49,7 @@ func TestStartFileWatchTrigger(t *testing.T) {
 			},
 		}
 
-		err := StartFileWatchTrigger(wf)
+		err := StartFileWatchTrigger(context.Background(), wf)
 		if err == nil {
 			t.Fatal("Expected error for empty path, got nil")
 		}
@@ -63,7 +68,7 @@ func TestStartFileWatchTrigger(t *testing.T) {
 			},
 		}
 
-		err := StartFileWatchTrigger(wf)
+		err := StartFileWatchTrigger(context.Background(), wf)
 		if err == nil {
 			t.Fatal("Expected error for empty events, got nil")
 		}
@@ -82,9 +87,294 @@ func TestStartFileWatchTrigger(t *testing.T) {
 			},
 		}
 
-		err := StartFileWatchTrigger(wf)
+		err := StartFileWatchTrigger(context.Background(), wf)
 		if err == nil {
 			t.Fatal("Expected error for invalid path, got nil")
 		}
 	})
 }
+
+func TestMoveFileUnique(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+
+	write := func(dir, name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	write(destDir, "report.csv", "existing")
+	write(destDir, "report-1.csv", "existing")
+
+	dest, err := moveFileUnique(write(srcDir, "report.csv", "new"), destDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if dest != filepath.Join(destDir, "report-2.csv") {
+		t.Errorf("Expected report-2.csv, got '%s'", dest)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "new" {
+		t.Errorf("Expected moved content 'new', got '%s' (err: %v)", data, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "report.csv")); string(data) != "existing" {
+		t.Error("Existing file was overwritten")
+	}
+	if _, err := os.Stat(filepath.Join(srcDir, "report.csv")); !os.IsNotExist(err) {
+		t.Error("Expected source file to be removed")
+	}
+}
+
+func TestFileWatchOutputDirs(t *testing.T) {
+	inbox := t.TempDir()
+
+	tests := []struct {
+		name    string
+		command string
+		wantDir string
+	}{
+		{"Success Moves To Processed", "true", "processed"},
+		{"Failure Moves To Failed", "false", "failed"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &workflow.Workflow{
+				Name: fmt.Sprintf("hot-folder-%d", i),
+				Trigger: workflow.Trigger{
+					Type:         workflow.TriggerTypeFileWatch,
+					Path:         inbox,
+					Events:       []string{"create"},
+					ProcessedDir: "processed",
+					FailedDir:    "failed",
+				},
+				Actions: []workflow.Action{
+					{Type: workflow.ActionTypeBash, Name: "process", Command: tt.command},
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := StartFileWatchTrigger(ctx, wf); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			name := fmt.Sprintf("input-%d.txt", i)
+			if err := os.WriteFile(filepath.Join(inbox, name), []byte("data"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			want := filepath.Join(inbox, tt.wantDir, name)
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				if _, err := os.Stat(want); err == nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("Timed out waiting for file to be moved to %s", want)
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestMatchesFilePatterns(t *testing.T) {
+	base := "/data/inbox"
+
+	tests := []struct {
+		name     string
+		patterns []string
+		ignore   []string
+		path     string
+		want     bool
+	}{
+		{"No Filters", nil, nil, "/data/inbox/report.csv", true},
+		{"Matching Pattern", []string{"*.csv"}, nil, "/data/inbox/report.csv", true},
+		{"Non-Matching Pattern", []string{"*.csv"}, nil, "/data/inbox/report.json", false},
+		{"Any Of Several Patterns", []string{"*.csv", "*.json"}, nil, "/data/inbox/report.json", true},
+		{"Ignored File", nil, []string{"*.tmp"}, "/data/inbox/report.csv.tmp", false},
+		{"Ignore Wins Over Pattern", []string{"*.csv"}, []string{"~*"}, "/data/inbox/~report.csv", false},
+		{"Relative Path Pattern", []string{"incoming/*.json"}, nil, "/data/inbox/incoming/order.json", true},
+		{"Relative Path Pattern Other Dir", []string{"incoming/*.json"}, nil, "/data/inbox/order.json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trig := workflow.Trigger{Patterns: tt.patterns, Ignore: tt.ignore}
+			if got := matchesFilePatterns(trig, base, tt.path); got != tt.want {
+				t.Errorf("Expected %v for %s, got %v", tt.want, tt.path, got)
+			}
+		})
+	}
+}
+
+func TestFileWatchPatterns(t *testing.T) {
+	inbox := t.TempDir()
+	wf := &workflow.Workflow{
+		Name: "pattern-filter",
+		Trigger: workflow.Trigger{
+			Type:         workflow.TriggerTypeFileWatch,
+			Path:         inbox,
+			Events:       []string{"create"},
+			Patterns:     []string{"*.csv"},
+			Ignore:       []string{"skip-*"},
+			ProcessedDir: "processed",
+		},
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "process", Command: "true"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := StartFileWatchTrigger(ctx, wf); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, name := range []string{"notes.txt", "skip-me.csv", "data.csv"} {
+		if err := os.WriteFile(filepath.Join(inbox, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := filepath.Join(inbox, "processed", "data.csv")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(want); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for file to be moved to %s", want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Filtered files never ran the workflow, so they stay in the inbox
+	for _, name := range []string{"notes.txt", "skip-me.csv"} {
+		if _, err := os.Stat(filepath.Join(inbox, name)); err != nil {
+			t.Errorf("Expected %s to stay in the inbox, got: %v", name, err)
+		}
+	}
+}
+
+func TestFileWatchEventEnv(t *testing.T) {
+	inbox := t.TempDir()
+	out := filepath.Join(t.TempDir(), "event")
+	wf := &workflow.Workflow{
+		Name: "event-env",
+		Trigger: workflow.Trigger{
+			Type:   workflow.TriggerTypeFileWatch,
+			Path:   inbox,
+			Events: []string{"create"},
+		},
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "record", Command: `echo "$AUTOZAP_EVENT_TYPE $AUTOZAP_EVENT_PATH" > ` + out},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := StartFileWatchTrigger(ctx, wf); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	path := filepath.Join(inbox, "report.csv")
+	if err := os.WriteFile(path, []byte("data\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the workflow to run, got: %v", err)
+	}
+	if got, want := string(content), "create "+path+"\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFileWatchDebounce(t *testing.T) {
+	t.Run("Rapid Writes Run Once", func(t *testing.T) {
+		inbox := t.TempDir()
+		runs := filepath.Join(t.TempDir(), "runs")
+		wf := &workflow.Workflow{
+			Name: "debounce-single",
+			Trigger: workflow.Trigger{
+				Type:     workflow.TriggerTypeFileWatch,
+				Path:     inbox,
+				Events:   []string{"create", "write"},
+				Debounce: "300ms",
+			},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "count", Command: "echo {{ index .files 0 }} >> " + runs},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := StartFileWatchTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		path := filepath.Join(inbox, "data.csv")
+		for i := 0; i < 5; i++ {
+			if err := os.WriteFile(path, []byte(fmt.Sprintf("line %d\n", i)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		time.Sleep(time.Second)
+		content, err := os.ReadFile(runs)
+		if err != nil {
+			t.Fatalf("Expected the workflow to run, got: %v", err)
+		}
+		if got := string(content); got != path+"\n" {
+			t.Errorf("Expected a single run for %s, got %q", path, got)
+		}
+	})
+
+	t.Run("Batch Passes All Files", func(t *testing.T) {
+		inbox := t.TempDir()
+		runs := filepath.Join(t.TempDir(), "runs")
+		wf := &workflow.Workflow{
+			Name: "debounce-batch",
+			Trigger: workflow.Trigger{
+				Type:     workflow.TriggerTypeFileWatch,
+				Path:     inbox,
+				Events:   []string{"create"},
+				Debounce: "300ms",
+				Batch:    true,
+			},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "count", Command: "echo {{ len .files }} >> " + runs},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := StartFileWatchTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		for _, name := range []string{"a.csv", "b.csv", "c.csv"} {
+			if err := os.WriteFile(filepath.Join(inbox, name), []byte("data"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		time.Sleep(time.Second)
+		content, err := os.ReadFile(runs)
+		if err != nil {
+			t.Fatalf("Expected the workflow to run, got: %v", err)
+		}
+		if got := string(content); got != "3\n" {
+			t.Errorf("Expected one run with 3 files, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package trigger
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.TriggerTypeHTTPPoll, Definition{
+		Start:    StartHTTPPollTrigger,
+		Validate: validateHTTPPollTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			return []string{fmt.Sprintf("Poll: %s every %s", t.URL, t.Interval)}
+		},
+	})
+}
+
+// validateHTTPPollTrigger checks the URL, interval and match conditions of an
+// httppoll trigger
+func validateHTTPPollTrigger(t *workflow.Trigger) error {
+	if t.URL == "" {
+		return fmt.Errorf("httppoll trigger requires a 'url'")
+	}
+	if u, err := url.Parse(t.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("httppoll trigger has invalid 'url' '%s': must be an absolute http or https URL", t.URL)
+	}
+
+	if t.Interval == "" {
+		return fmt.Errorf("httppoll trigger requires an 'interval'")
+	}
+	if _, err := t.PollInterval(); err != nil {
+		return fmt.Errorf("httppoll trigger has invalid 'interval' '%s': %w", t.Interval, err)
+	}
+	if _, err := t.PollTimeout(); err != nil {
+		return fmt.Errorf("httppoll trigger has invalid 'timeout' '%s': %w", t.Timeout, err)
+	}
+
+	if t.MatchStatus != 0 && (t.MatchStatus < 100 || t.MatchStatus > 599) {
+		return fmt.Errorf("httppoll trigger has invalid 'matchStatus' %d", t.MatchStatus)
+	}
+	if t.MatchBody != "" {
+		if _, err := regexp.Compile(t.MatchBody); err != nil {
+			return fmt.Errorf("httppoll trigger has invalid 'matchBody': %w", err)
+		}
+	}
+
+	if t.Schedule != "" || t.Path != "" || len(t.Events) > 0 {
+		logger.L().Warnf("httppoll trigger has unexpected 'schedule', 'path' or 'events'; these will be ignored.")
+	}
+	return nil
+}
+
+// maxPollBodySize bounds how much of a polled response is read and compared
+const maxPollBodySize = 1 << 20
+
+// pollResponse is a polled response; bodies are compared by hash
+type pollResponse struct {
+	status   int
+	body     string
+	bodyHash [sha256.Size]byte
+}
+
+// poller holds the state of an httppoll trigger between polls. It is only
+// used by the trigger's goroutine.
+type poller struct {
+	wf          *workflow.Workflow
+	client      *http.Client
+	bodyPattern *regexp.Regexp
+
+	last    *pollResponse // nil until the first successful poll
+	matched bool          // whether the last response matched the conditions
+}
+
+func StartHTTPPollTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	if wf.Trigger.Type != workflow.TriggerTypeHTTPPoll {
+		return fmt.Errorf("invalid trigger type for StartHTTPPollTrigger: expected '%s', got '%s'", workflow.TriggerTypeHTTPPoll, wf.Trigger.Type)
+	}
+	if wf.Trigger.URL == "" {
+		return fmt.Errorf("url cannot be empty for httppoll trigger")
+	}
+
+	interval, err := wf.Trigger.PollInterval()
+	if err != nil {
+		return fmt.Errorf("invalid interval '%s' for workflow '%s': %w", wf.Trigger.Interval, wf.Name, err)
+	}
+	timeout, err := wf.Trigger.PollTimeout()
+	if err != nil {
+		return fmt.Errorf("invalid timeout '%s' for workflow '%s': %w", wf.Trigger.Timeout, wf.Name, err)
+	}
+
+	p := &poller{wf: wf, client: &http.Client{Timeout: timeout}}
+	if wf.Trigger.MatchBody != "" {
+		if p.bodyPattern, err = regexp.Compile(wf.Trigger.MatchBody); err != nil {
+			return fmt.Errorf("invalid matchBody for workflow '%s': %w", wf.Name, err)
+		}
+	}
+
+	// Register workflow in the registry
+	server.GetRegistry().RegisterWorkflow(wf)
+
+	// Register workflow info metric
+	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeHTTPPoll), wf.Trigger.URL)
+
+	logger.L().Infow("HTTP poll trigger started",
+		"workflow_name", wf.Name,
+		"url", wf.Trigger.URL,
+		"interval", interval)
+
+	go func() {
+		defer func() {
+			// Unregister workflow from registry
+			server.GetRegistry().UnregisterWorkflow(wf.Name)
+			logger.L().Infow("HTTP poll trigger stopped successfully",
+				"workflow_name", wf.Name)
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		p.poll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				logger.L().Infow("Stopping HTTP poll trigger for workflow",
+					"workflow_name", wf.Name,
+					"url", wf.Trigger.URL,
+					"reason", "context cancelled")
+				return
+			case <-ticker.C:
+				p.poll(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// poll requests the URL once and runs the workflow if the response fires the
+// trigger. Failed requests are logged and leave the state unchanged.
+func (p *poller) poll(ctx context.Context) {
+	resp, err := p.fetch(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.L().Warnw("HTTP poll request failed",
+				"workflow_name", p.wf.Name,
+				"url", p.wf.Trigger.URL,
+				"error", err)
+		}
+		return
+	}
+
+	previous := p.last
+	p.last = resp
+	if !p.fires(previous, resp) {
+		return
+	}
+
+	if server.GetRegistry().IsPaused(p.wf.Name) {
+		logger.L().Infow("Skipping HTTP poll trigger for paused workflow",
+			"workflow_name", p.wf.Name,
+			"url", p.wf.Trigger.URL)
+		return
+	}
+
+	metrics.RecordTriggerFire(p.wf.Name, string(workflow.TriggerTypeHTTPPoll))
+
+	response := map[string]interface{}{
+		"url":            p.wf.Trigger.URL,
+		"status":         resp.status,
+		"body":           resp.body,
+		"previousStatus": 0,
+	}
+	if previous != nil {
+		response["previousStatus"] = previous.status
+	}
+
+	logger.L().Infow("HTTP poll trigger fired for workflow",
+		"workflow_name", p.wf.Name,
+		"url", p.wf.Trigger.URL,
+		"status_code", resp.status,
+		"previous_status_code", response["previousStatus"],
+		"timestamp", time.Now().Format(time.RFC3339))
+
+	data := executor.WithEvent(templating.Data{"response": response}, executor.Event{
+		Time:   time.Now(),
+		Source: map[string]string{"url": p.wf.Trigger.URL, "status": strconv.Itoa(resp.status)},
+	})
+	executor.ExecuteWithData(p.wf, string(workflow.TriggerTypeHTTPPoll), data)
+}
+
+// fires reports whether resp fires the trigger. Without conditions any change
+// of status code or body does, except on the first poll which only records
+// the baseline. With conditions a response fires when it matches and the
+// previous one did not.
+func (p *poller) fires(previous, resp *pollResponse) bool {
+	if p.wf.Trigger.MatchStatus != 0 || p.bodyPattern != nil {
+		wasMatched := p.matched
+		p.matched = p.matches(resp)
+		return p.matched && !wasMatched
+	}
+
+	if previous == nil {
+		return false
+	}
+	return previous.status != resp.status || previous.bodyHash != resp.bodyHash
+}
+
+// matches reports whether resp meets the trigger's matchStatus and matchBody
+func (p *poller) matches(resp *pollResponse) bool {
+	if p.wf.Trigger.MatchStatus != 0 && resp.status != p.wf.Trigger.MatchStatus {
+		return false
+	}
+	if p.bodyPattern != nil && !p.bodyPattern.MatchString(resp.body) {
+		return false
+	}
+	return true
+}
+
+func (p *poller) fetch(ctx context.Context) (*pollResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.wf.Trigger.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range p.wf.Trigger.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPollBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return &pollResponse{
+		status:   resp.StatusCode,
+		body:     string(body),
+		bodyHash: sha256.Sum256(body),
+	}, nil
+}
@@ -0,0 +1,126 @@
+package trigger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// pollServer serves a response that tests can change between polls
+type pollServer struct {
+	mu     sync.Mutex
+	status int
+	body   string
+}
+
+func (s *pollServer) set(status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.body = status, body
+}
+
+func (s *pollServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.WriteHeader(s.status)
+	w.Write([]byte(s.body))
+}
+
+func TestStartHTTPPollTrigger(t *testing.T) {
+	t.Run("Invalid Trigger Type", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:    "test-workflow",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeCron, URL: "http://localhost", Interval: "1s"},
+		}
+		if err := StartHTTPPollTrigger(context.Background(), wf); err == nil {
+			t.Fatal("Expected error for invalid trigger type, got nil")
+		}
+	})
+
+	t.Run("Missing Interval", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:    "test-workflow",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeHTTPPoll, URL: "http://localhost"},
+		}
+		if err := StartHTTPPollTrigger(context.Background(), wf); err == nil {
+			t.Fatal("Expected error for missing interval, got nil")
+		}
+	})
+}
+
+func TestHTTPPollTrigger(t *testing.T) {
+	// startPoll starts an httppoll workflow that appends the status code it
+	// fired for to a file, and returns a function reading the recorded runs
+	startPoll := func(t *testing.T, name string, trig workflow.Trigger) func() []string {
+		runs := filepath.Join(t.TempDir(), "runs")
+		trig.Type = workflow.TriggerTypeHTTPPoll
+		trig.Interval = "50ms"
+		wf := &workflow.Workflow{
+			Name:    name,
+			Trigger: trig,
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "echo {{ .response.status }} >> " + runs},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		if err := StartHTTPPollTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		return func() []string {
+			content, err := os.ReadFile(runs)
+			if err != nil {
+				return nil
+			}
+			return strings.Fields(string(content))
+		}
+	}
+
+	t.Run("Fires On Change", func(t *testing.T) {
+		backend := &pollServer{status: 200, body: "v1"}
+		srv := httptest.NewServer(backend)
+		defer srv.Close()
+
+		runs := startPoll(t, "poll-change", workflow.Trigger{URL: srv.URL})
+
+		time.Sleep(200 * time.Millisecond)
+		if got := runs(); len(got) != 0 {
+			t.Fatalf("Expected no run before the response changes, got %v", got)
+		}
+
+		backend.set(200, "v2")
+		time.Sleep(200 * time.Millisecond)
+		backend.set(503, "v2")
+		time.Sleep(200 * time.Millisecond)
+
+		if got := strings.Join(runs(), ","); got != "200,503" {
+			t.Errorf("Expected runs for the body and status changes, got %q", got)
+		}
+	})
+
+	t.Run("Fires Once When Condition Starts Matching", func(t *testing.T) {
+		backend := &pollServer{status: 200, body: `{"state": "building"}`}
+		srv := httptest.NewServer(backend)
+		defer srv.Close()
+
+		runs := startPoll(t, "poll-match", workflow.Trigger{URL: srv.URL, MatchBody: `"state": "ready"`})
+
+		time.Sleep(200 * time.Millisecond)
+		backend.set(200, `{"state": "ready"}`)
+		time.Sleep(300 * time.Millisecond)
+
+		if got := runs(); len(got) != 1 {
+			t.Errorf("Expected a single run, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.TriggerTypeStartup, Definition{Start: StartStartupTrigger, Validate: validateLifecycleTrigger})
+	Register(workflow.TriggerTypeShutdown, Definition{
+		Start:    StartShutdownTrigger,
+		Validate: validateLifecycleTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			timeout, _ := t.ShutdownTimeout()
+			return []string{"Timeout: " + timeout.String()}
+		},
+	})
+}
+
+// validateLifecycleTrigger checks a startup or shutdown trigger
+func validateLifecycleTrigger(t *workflow.Trigger) error {
+	if t.Type == workflow.TriggerTypeShutdown {
+		if _, err := t.ShutdownTimeout(); err != nil {
+			return fmt.Errorf("shutdown trigger has invalid 'timeout' '%s': %w", t.Timeout, err)
+		}
+	} else if t.Timeout != "" {
+		logger.L().Warnf("startup trigger has unexpected 'timeout'; it will be ignored.")
+	}
+
+	if t.Schedule != "" || t.Path != "" || len(t.Events) > 0 || t.URL != "" || t.Interval != "" {
+		logger.L().Warnf("%s trigger has unexpected 'schedule', 'path', 'events', 'url' or 'interval'; these will be ignored.", t.Type)
+	}
+	return nil
+}
+
+// shutdownWorkflows are the loaded workflows with a shutdown trigger, by name
+var (
+	shutdownMu        sync.Mutex
+	shutdownWorkflows = make(map[string]*workflow.Workflow)
+)
+
+// StartStartupTrigger runs the workflow once in the background as soon as it
+// is loaded, which for the agent includes reloads of a changed workflow file.
+// The workflow stays registered until ctx is done.
+func StartStartupTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	if wf.Trigger.Type != workflow.TriggerTypeStartup {
+		return fmt.Errorf("invalid trigger type for StartStartupTrigger: expected '%s', got '%s'", workflow.TriggerTypeStartup, wf.Trigger.Type)
+	}
+
+	// Register workflow in the registry
+	server.GetRegistry().RegisterWorkflow(wf)
+
+	// Register workflow info metric
+	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeStartup), "")
+
+	logger.L().Infow("Startup trigger started",
+		"workflow_name", wf.Name)
+
+	go func() {
+		defer func() {
+			// Unregister workflow from registry
+			server.GetRegistry().UnregisterWorkflow(wf.Name)
+			logger.L().Infow("Startup trigger stopped successfully",
+				"workflow_name", wf.Name)
+		}()
+
+		runLifecycleWorkflow(wf, workflow.TriggerTypeStartup)
+		<-ctx.Done()
+	}()
+
+	return nil
+}
+
+// StartShutdownTrigger registers the workflow to run during graceful shutdown,
+// when RunShutdownTriggers is called. Cancelling ctx, e.g. because the
+// workflow was removed, unregisters it without running it.
+func StartShutdownTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	if wf.Trigger.Type != workflow.TriggerTypeShutdown {
+		return fmt.Errorf("invalid trigger type for StartShutdownTrigger: expected '%s', got '%s'", workflow.TriggerTypeShutdown, wf.Trigger.Type)
+	}
+	timeout, err := wf.Trigger.ShutdownTimeout()
+	if err != nil {
+		return fmt.Errorf("invalid timeout '%s' for workflow '%s': %w", wf.Trigger.Timeout, wf.Name, err)
+	}
+
+	// Register workflow in the registry
+	server.GetRegistry().RegisterWorkflow(wf)
+
+	// Register workflow info metric
+	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeShutdown), timeout.String())
+
+	shutdownMu.Lock()
+	shutdownWorkflows[wf.Name] = wf
+	shutdownMu.Unlock()
+
+	logger.L().Infow("Shutdown trigger started",
+		"workflow_name", wf.Name,
+		"timeout", timeout)
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownMu.Lock()
+		if shutdownWorkflows[wf.Name] == wf {
+			delete(shutdownWorkflows, wf.Name)
+		}
+		shutdownMu.Unlock()
+
+		// Unregister workflow from registry
+		server.GetRegistry().UnregisterWorkflow(wf.Name)
+		logger.L().Infow("Shutdown trigger stopped successfully",
+			"workflow_name", wf.Name)
+	}()
+
+	return nil
+}
+
+// RunShutdownTriggers runs all workflows with a shutdown trigger concurrently
+// and waits for them, each for at most its timeout; a run that takes longer
+// is cancelled. Call it on graceful shutdown before stopping the triggers.
+func RunShutdownTriggers() {
+	shutdownMu.Lock()
+	workflows := make([]*workflow.Workflow, 0, len(shutdownWorkflows))
+	for _, wf := range shutdownWorkflows {
+		workflows = append(workflows, wf)
+	}
+	shutdownWorkflows = make(map[string]*workflow.Workflow)
+	shutdownMu.Unlock()
+
+	runShutdownWorkflows(workflows)
+}
+
+// RunShutdownWorkflows is RunShutdownTriggers for the named workflows only,
+// e.g. those of an embedded engine that stops while the process keeps running
+func RunShutdownWorkflows(names []string) {
+	shutdownMu.Lock()
+	var workflows []*workflow.Workflow
+	for _, name := range names {
+		if wf, ok := shutdownWorkflows[name]; ok {
+			workflows = append(workflows, wf)
+			delete(shutdownWorkflows, name)
+		}
+	}
+	shutdownMu.Unlock()
+
+	runShutdownWorkflows(workflows)
+}
+
+// runShutdownWorkflows runs workflows concurrently and waits for them, each
+// for at most its shutdown timeout
+func runShutdownWorkflows(workflows []*workflow.Workflow) {
+	var wg sync.WaitGroup
+	for _, wf := range workflows {
+		wg.Add(1)
+		go func(wf *workflow.Workflow) {
+			defer wg.Done()
+
+			// Validated when the trigger started
+			timeout, _ := wf.Trigger.ShutdownTimeout()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				runLifecycleWorkflow(wf, workflow.TriggerTypeShutdown)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(timeout):
+				logger.L().Warnw("Shutdown workflow timed out, cancelling it",
+					"workflow_name", wf.Name,
+					"timeout", timeout)
+				executor.CancelRuns(wf.Name)
+			}
+		}(wf)
+	}
+	wg.Wait()
+}
+
+// runLifecycleWorkflow runs wf for a startup or shutdown trigger and waits
+// for its async actions
+func runLifecycleWorkflow(wf *workflow.Workflow, triggerType workflow.TriggerType) {
+	if server.GetRegistry().IsPaused(wf.Name) {
+		logger.L().Infow("Skipping paused workflow",
+			"workflow_name", wf.Name,
+			"trigger_type", triggerType)
+		return
+	}
+
+	metrics.RecordTriggerFire(wf.Name, string(triggerType))
+
+	logger.L().Infow("Lifecycle trigger fired for workflow",
+		"workflow_name", wf.Name,
+		"trigger_type", triggerType,
+		"timestamp", time.Now().Format(time.RFC3339))
+
+	executor.ExecuteAndWait(wf, string(triggerType), nil)
+}
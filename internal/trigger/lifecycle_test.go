@@ -0,0 +1,167 @@
+package trigger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestStartStartupTrigger(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "runs")
+	wf := &workflow.Workflow{
+		Name:    "on-startup",
+		Trigger: workflow.Trigger{Type: workflow.TriggerTypeStartup},
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "record", Command: "echo started >> " + out},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := StartStartupTrigger(ctx, wf); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the workflow to run, got: %v", err)
+	}
+	if strings.TrimSpace(string(content)) != "started" {
+		t.Errorf("Expected a single run, got %q", content)
+	}
+}
+
+func TestShutdownTrigger(t *testing.T) {
+	t.Run("Runs On Shutdown", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "runs")
+		wf := &workflow.Workflow{
+			Name:    "on-shutdown",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeShutdown},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "echo stopped >> " + out},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := StartShutdownTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		if _, err := os.Stat(out); err == nil {
+			t.Fatalf("Expected no run before shutdown")
+		}
+
+		RunShutdownTriggers()
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected the workflow to run, got: %v", err)
+		}
+		if strings.TrimSpace(string(content)) != "stopped" {
+			t.Errorf("Expected a single run, got %q", content)
+		}
+
+		// Runs only once
+		RunShutdownTriggers()
+		content, _ = os.ReadFile(out)
+		if strings.TrimSpace(string(content)) != "stopped" {
+			t.Errorf("Expected a single run, got %q", content)
+		}
+	})
+
+	t.Run("Timeout Cancels Run", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:    "slow-shutdown",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeShutdown, Timeout: "200ms"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "hang", Command: "sleep 10"},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := StartShutdownTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		start := time.Now()
+		RunShutdownTriggers()
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Expected shutdown to be bounded by the timeout, took %s", elapsed)
+		}
+	})
+
+	t.Run("Named Workflows Only", func(t *testing.T) {
+		dir := t.TempDir()
+		record := func(name string) *workflow.Workflow {
+			return &workflow.Workflow{
+				Name:    name,
+				Trigger: workflow.Trigger{Type: workflow.TriggerTypeShutdown},
+				Actions: []workflow.Action{
+					{Type: workflow.ActionTypeBash, Name: "record", Command: "echo stopped > " + filepath.Join(dir, name)},
+				},
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for _, wf := range []*workflow.Workflow{record("named-shutdown"), record("other-shutdown")} {
+			if err := StartShutdownTrigger(ctx, wf); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+		}
+
+		RunShutdownWorkflows([]string{"named-shutdown"})
+		if _, err := os.Stat(filepath.Join(dir, "named-shutdown")); err != nil {
+			t.Errorf("Expected the named workflow to run, got: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "other-shutdown")); err == nil {
+			t.Errorf("Expected the other workflow not to run")
+		}
+
+		RunShutdownTriggers()
+		if _, err := os.Stat(filepath.Join(dir, "other-shutdown")); err != nil {
+			t.Errorf("Expected the other workflow to run on shutdown, got: %v", err)
+		}
+	})
+
+	t.Run("Removed Workflow Does Not Run", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "runs")
+		wf := &workflow.Workflow{
+			Name:    "removed-shutdown",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeShutdown},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "echo stopped >> " + out},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := StartShutdownTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		cancel()
+		time.Sleep(100 * time.Millisecond)
+
+		RunShutdownTriggers()
+		if _, err := os.Stat(out); err == nil {
+			t.Errorf("Expected no run for a removed workflow")
+		}
+	})
+
+	t.Run("Invalid Timeout", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:    "bad-timeout",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeShutdown, Timeout: "soon"},
+		}
+		if err := StartShutdownTrigger(context.Background(), wf); err == nil {
+			t.Errorf("Expected an error for an invalid timeout")
+		}
+	})
+}
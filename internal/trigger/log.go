@@ -0,0 +1,311 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.TriggerTypeLog, Definition{
+		Start:    StartLogTrigger,
+		Validate: validateLogTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			if t.Unit != "" {
+				return []string{fmt.Sprintf("Tail: journal of %s matching '%s'", t.Unit, t.Match)}
+			}
+			return []string{fmt.Sprintf("Tail: %s matching '%s'", t.Path, t.Match)}
+		},
+	})
+}
+
+// validateLogTrigger checks the file or unit and the match pattern of a log
+// trigger
+func validateLogTrigger(t *workflow.Trigger) error {
+	if t.Path == "" && t.Unit == "" {
+		return fmt.Errorf("log trigger requires a 'path' or a systemd 'unit'")
+	}
+	if t.Path != "" && t.Unit != "" {
+		return fmt.Errorf("log trigger cannot have both 'path' and 'unit'")
+	}
+	if t.Match == "" {
+		return fmt.Errorf("log trigger requires a 'match' pattern")
+	}
+	if _, err := regexp.Compile(t.Match); err != nil {
+		return fmt.Errorf("log trigger has invalid 'match': %w", err)
+	}
+
+	if t.Schedule != "" || len(t.Events) > 0 || t.URL != "" || t.Interval != "" {
+		logger.L().Warnf("log trigger has unexpected 'schedule', 'events', 'url' or 'interval'; these will be ignored.")
+	}
+	return nil
+}
+
+// logPollInterval is how often a tailed log file is checked for new lines
+var logPollInterval = 500 * time.Millisecond
+
+// StartLogTrigger tails the trigger's log file, or the systemd journal of its
+// unit, and runs the workflow for every new line matching its pattern, one
+// line at a time. Lines written before the trigger started are ignored.
+func StartLogTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	if wf.Trigger.Type != workflow.TriggerTypeLog {
+		return fmt.Errorf("invalid trigger type for StartLogTrigger: expected '%s', got '%s'", workflow.TriggerTypeLog, wf.Trigger.Type)
+	}
+	if (wf.Trigger.Path == "") == (wf.Trigger.Unit == "") {
+		return fmt.Errorf("log trigger requires exactly one of path or unit")
+	}
+
+	pattern, err := regexp.Compile(wf.Trigger.Match)
+	if err != nil {
+		return fmt.Errorf("invalid match pattern for workflow '%s': %w", wf.Name, err)
+	}
+
+	// Both sources call emit from the trigger's goroutine until ctx is done
+	var follow func(emit func(line string))
+	source := wf.Trigger.Path
+	if wf.Trigger.Path != "" {
+		tailer := newFileTailer(wf.Trigger.Path)
+		follow = func(emit func(string)) { tailer.follow(ctx, emit) }
+	} else {
+		source = wf.Trigger.Unit
+		journal, err := startJournal(ctx, wf.Trigger.Unit)
+		if err != nil {
+			return fmt.Errorf("failed to follow journal of unit '%s' for workflow '%s': %w", wf.Trigger.Unit, wf.Name, err)
+		}
+		follow = journal.follow
+	}
+
+	// Register workflow in the registry
+	server.GetRegistry().RegisterWorkflow(wf)
+
+	// Register workflow info metric
+	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeLog), source)
+
+	logger.L().Infow("Log trigger started",
+		"workflow_name", wf.Name,
+		"source", source,
+		"match", wf.Trigger.Match)
+
+	go func() {
+		defer func() {
+			// Unregister workflow from registry
+			server.GetRegistry().UnregisterWorkflow(wf.Name)
+			logger.L().Infow("Log trigger stopped successfully",
+				"workflow_name", wf.Name)
+		}()
+
+		follow(func(line string) {
+			if groups := pattern.FindStringSubmatch(line); groups != nil {
+				runLogLine(wf, pattern, line, groups)
+			}
+		})
+
+		if ctx.Err() == nil {
+			logger.L().Errorw("Log source closed", "workflow_name", wf.Name, "source", source)
+			return
+		}
+		logger.L().Infow("Stopping log trigger for workflow",
+			"workflow_name", wf.Name,
+			"source", source,
+			"reason", "context cancelled")
+	}()
+
+	return nil
+}
+
+// runLogLine runs the workflow for a matching line. The line is available as
+// {{ .line }}, the submatches as {{ index .groups 1 }} and named groups as
+// {{ .match.<name> }}.
+func runLogLine(wf *workflow.Workflow, pattern *regexp.Regexp, line string, groups []string) {
+	if server.GetRegistry().IsPaused(wf.Name) {
+		logger.L().Infow("Skipping log line for paused workflow",
+			"workflow_name", wf.Name)
+		return
+	}
+
+	metrics.RecordTriggerFire(wf.Name, string(workflow.TriggerTypeLog))
+
+	logger.L().Infow("Log trigger fired for workflow",
+		"workflow_name", wf.Name,
+		"line", line,
+		"timestamp", time.Now().Format(time.RFC3339))
+
+	named := make(map[string]string)
+	for i, name := range pattern.SubexpNames() {
+		if name != "" {
+			named[name] = groups[i]
+		}
+	}
+
+	data := executor.WithEvent(templating.Data{
+		"line":   line,
+		"groups": groups,
+		"match":  named,
+	}, executor.Event{
+		Path:    wf.Trigger.Path,
+		Message: line,
+		Time:    time.Now(),
+		Source:  logSource(wf),
+	})
+	executor.ExecuteWithData(wf, string(workflow.TriggerTypeLog), data)
+}
+
+// logSource records the journal unit of a run; a log file is its event path
+func logSource(wf *workflow.Workflow) map[string]string {
+	if wf.Trigger.Unit == "" {
+		return nil
+	}
+	return map[string]string{"unit": wf.Trigger.Unit}
+}
+
+// fileTailer follows a log file across rotation and truncation
+type fileTailer struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	partial string // start of a line whose newline hasn't been written yet
+}
+
+func newFileTailer(path string) *fileTailer {
+	t := &fileTailer{path: path}
+	// Start at the end of an existing file; a file created later is read from
+	// its beginning
+	if err := t.open(); err == nil {
+		if _, err := t.file.Seek(0, io.SeekEnd); err != nil {
+			t.close()
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		logger.L().Warnw("Failed to open log file, retrying",
+			"path", path,
+			"error", err)
+	}
+	return t
+}
+
+func (t *fileTailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	t.file = f
+	t.reader = bufio.NewReader(f)
+	t.partial = ""
+	return nil
+}
+
+func (t *fileTailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// follow emits new lines until ctx is done
+func (t *fileTailer) follow(ctx context.Context, emit func(string)) {
+	defer t.close()
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		t.poll(emit)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll emits the lines written since the last poll. A file replaced at the
+// path (rotation) is finished first and the new one read from the start; a
+// file that shrank (copytruncate) is read again from the start.
+func (t *fileTailer) poll(emit func(string)) {
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			return
+		}
+	}
+	t.readLines(emit)
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// Rotated away and not recreated yet
+		return
+	}
+	current, err := t.file.Stat()
+	if err != nil || !os.SameFile(info, current) {
+		t.close()
+		if err := t.open(); err == nil {
+			t.readLines(emit)
+		}
+		return
+	}
+
+	offset, err := t.file.Seek(0, io.SeekCurrent)
+	if err == nil && info.Size() < offset-int64(t.reader.Buffered()) {
+		logger.L().Infow("Log file was truncated, reading from the start", "path", t.path)
+		if _, err := t.file.Seek(0, io.SeekStart); err == nil {
+			t.reader.Reset(t.file)
+			t.partial = ""
+			t.readLines(emit)
+		}
+	}
+}
+
+// readLines emits the complete lines available in the file
+func (t *fileTailer) readLines(emit func(string)) {
+	for {
+		chunk, err := t.reader.ReadString('\n')
+		if err != nil {
+			t.partial += chunk
+			return
+		}
+		emit(strings.TrimRight(t.partial+chunk, "\r\n"))
+		t.partial = ""
+	}
+}
+
+// journal follows the systemd journal of a unit through journalctl
+type journal struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+}
+
+func startJournal(ctx context.Context, unit string) (*journal, error) {
+	cmd := exec.CommandContext(ctx, "journalctl", "--follow", "--lines=0", "--output=cat", "--unit", unit)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &journal{cmd: cmd, stdout: stdout}, nil
+}
+
+// follow emits journal lines until journalctl exits, which it does when the
+// context is cancelled
+func (j *journal) follow(emit func(string)) {
+	scanner := bufio.NewScanner(j.stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		emit(scanner.Text())
+	}
+	if err := j.cmd.Wait(); err != nil {
+		logger.L().Debugw("journalctl exited", "error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package trigger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestFileTailer(t *testing.T) {
+	collect := func(tailer *fileTailer) []string {
+		var lines []string
+		tailer.poll(func(line string) { lines = append(lines, line) })
+		return lines
+	}
+	appendTo := func(t *testing.T, path, content string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open log: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatalf("Failed to write log: %v", err)
+		}
+	}
+
+	t.Run("Skips Existing Lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		appendTo(t, path, "old line\n")
+
+		tailer := newFileTailer(path)
+		defer tailer.close()
+		if lines := collect(tailer); len(lines) != 0 {
+			t.Errorf("Expected no lines, got %q", lines)
+		}
+
+		appendTo(t, path, "first\nsecond\r\npart")
+		if lines := collect(tailer); strings.Join(lines, "|") != "first|second" {
+			t.Errorf("Expected 'first|second', got %q", lines)
+		}
+		appendTo(t, path, "ial\n")
+		if lines := collect(tailer); strings.Join(lines, "|") != "partial" {
+			t.Errorf("Expected 'partial', got %q", lines)
+		}
+	})
+
+	t.Run("File Created Later", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		tailer := newFileTailer(path)
+		defer tailer.close()
+		if lines := collect(tailer); len(lines) != 0 {
+			t.Errorf("Expected no lines, got %q", lines)
+		}
+
+		appendTo(t, path, "hello\n")
+		if lines := collect(tailer); strings.Join(lines, "|") != "hello" {
+			t.Errorf("Expected 'hello', got %q", lines)
+		}
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		appendTo(t, path, "")
+		tailer := newFileTailer(path)
+		defer tailer.close()
+
+		appendTo(t, path, "before\n")
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatalf("Failed to rotate: %v", err)
+		}
+		appendTo(t, path+".1", "late\n")
+		appendTo(t, path, "after\n")
+
+		if lines := collect(tailer); strings.Join(lines, "|") != "before|late|after" {
+			t.Errorf("Expected 'before|late|after', got %q", lines)
+		}
+	})
+
+	t.Run("Truncation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		appendTo(t, path, "")
+		tailer := newFileTailer(path)
+		defer tailer.close()
+
+		appendTo(t, path, "a long line before truncation\n")
+		collect(tailer)
+
+		if err := os.Truncate(path, 0); err != nil {
+			t.Fatalf("Failed to truncate: %v", err)
+		}
+		appendTo(t, path, "new\n")
+		if lines := collect(tailer); strings.Join(lines, "|") != "new" {
+			t.Errorf("Expected 'new', got %q", lines)
+		}
+	})
+}
+
+func TestStartLogTrigger(t *testing.T) {
+	logPollInterval = 20 * time.Millisecond
+	defer func() { logPollInterval = 500 * time.Millisecond }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	out := filepath.Join(dir, "matches")
+	if err := os.WriteFile(path, []byte("ERROR before start\n"), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	wf := &workflow.Workflow{
+		Name: "log-errors",
+		Trigger: workflow.Trigger{
+			Type:  workflow.TriggerTypeLog,
+			Path:  path,
+			Match: `ERROR (?P<code>E\d+)`,
+		},
+		Actions: []workflow.Action{
+			{Type: workflow.ActionTypeBash, Name: "record", Command: `echo "{{ .match.code }} $AUTOZAP_EVENT_MESSAGE" >> ` + out},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := StartLogTrigger(ctx, wf); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	f.WriteString("INFO started\nERROR E42 disk full\nWARN slow\nERROR E7 timeout\n")
+	f.Close()
+
+	time.Sleep(500 * time.Millisecond)
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the workflow to run, got: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || lines[0] != "E42 ERROR E42 disk full" || lines[1] != "E7 ERROR E7 timeout" {
+		t.Errorf("Unexpected runs: %q", lines)
+	}
+}
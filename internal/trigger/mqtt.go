@@ -0,0 +1,124 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/mqtt"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func init() {
+	Register(workflow.TriggerTypeMQTT, Definition{
+		Start:    StartMQTTTrigger,
+		Validate: validateMQTTTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			return []string{"Subscribe: " + t.Topic}
+		},
+	})
+}
+
+// validateMQTTTrigger checks the topic filter and QoS of an mqtt trigger
+func validateMQTTTrigger(t *workflow.Trigger) error {
+	if t.Topic == "" {
+		return fmt.Errorf("mqtt trigger requires a 'topic'")
+	}
+	if err := validateMQTTTopicFilter(t.Topic); err != nil {
+		return fmt.Errorf("mqtt trigger has invalid 'topic' '%s': %w", t.Topic, err)
+	}
+	if t.QoS < 0 || t.QoS > 2 {
+		return fmt.Errorf("mqtt trigger has invalid 'qos' %d. Must be 0, 1 or 2", t.QoS)
+	}
+
+	if t.Schedule != "" || t.Path != "" || len(t.Events) > 0 || t.URL != "" || t.Channel != "" {
+		logger.L().Warnf("mqtt trigger has unexpected 'schedule', 'path', 'events', 'url' or 'channel'; these will be ignored.")
+	}
+	return nil
+}
+
+// validateMQTTTopicFilter checks the wildcards of an MQTT subscription: "+"
+// must be a whole topic level and "#" the whole last level
+func validateMQTTTopicFilter(topic string) error {
+	levels := strings.Split(topic, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return fmt.Errorf("'#' must be the last topic level on its own")
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("'+' must be a topic level on its own")
+		}
+	}
+	return nil
+}
+
+// mqttQueueSize is how many received messages may wait for a running workflow
+// before the broker connection is held up
+const mqttQueueSize = 100
+
+// StartMQTTTrigger subscribes to the trigger's topic on the agent's MQTT
+// broker and runs the workflow once per message, one message at a time in
+// arrival order.
+func StartMQTTTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	if wf.Trigger.Type != workflow.TriggerTypeMQTT {
+		return fmt.Errorf("invalid trigger type for StartMQTTTrigger: expected '%s', got '%s'", workflow.TriggerTypeMQTT, wf.Trigger.Type)
+	}
+	if wf.Trigger.Topic == "" {
+		return fmt.Errorf("topic cannot be empty for mqtt trigger")
+	}
+
+	type message struct {
+		topic   string
+		payload string
+	}
+	messages := make(chan message, mqttQueueSize)
+
+	unsubscribe, err := mqtt.Subscribe(wf.Trigger.Topic, byte(wf.Trigger.QoS), func(topic string, payload []byte) {
+		select {
+		case messages <- message{topic: topic, payload: string(payload)}:
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start mqtt trigger for workflow '%s': %w", wf.Name, err)
+	}
+
+	// Register workflow in the registry
+	server.GetRegistry().RegisterWorkflow(wf)
+
+	// Register workflow info metric
+	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeMQTT), wf.Trigger.Topic)
+
+	logger.L().Infow("MQTT trigger started",
+		"workflow_name", wf.Name,
+		"topic", wf.Trigger.Topic,
+		"qos", wf.Trigger.QoS)
+
+	go func() {
+		defer func() {
+			unsubscribe()
+			// Unregister workflow from registry
+			server.GetRegistry().UnregisterWorkflow(wf.Name)
+			logger.L().Infow("MQTT trigger stopped successfully",
+				"workflow_name", wf.Name)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.L().Infow("Stopping mqtt trigger for workflow",
+					"workflow_name", wf.Name,
+					"topic", wf.Trigger.Topic,
+					"reason", "context cancelled")
+				return
+			case msg := <-messages:
+				runMessage(wf, string(workflow.TriggerTypeMQTT), msg.topic, msg.payload)
+			}
+		}
+	}()
+
+	return nil
+}
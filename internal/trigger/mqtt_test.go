@@ -0,0 +1,79 @@
+package trigger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/config"
+	"github.com/codecrafted007/autozap/internal/mqtt"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+func TestStartMQTTTrigger(t *testing.T) {
+	t.Run("Broker Not Configured", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:    "mqtt-unconfigured",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeMQTT, Topic: "sensors/#"},
+		}
+		if err := StartMQTTTrigger(context.Background(), wf); err == nil {
+			t.Fatal("Expected error without a broker, got nil")
+		}
+	})
+
+	t.Run("Runs Once Per Message", func(t *testing.T) {
+		broker := mochi.New(&mochi.Options{
+			InlineClient: true,
+			Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		broker.AddHook(new(auth.AllowHook), nil)
+		tcp := listeners.NewTCP(listeners.Config{Type: "tcp", ID: "test", Address: "127.0.0.1:0"})
+		if err := broker.AddListener(tcp); err != nil {
+			t.Fatalf("Failed to add listener: %v", err)
+		}
+		go broker.Serve()
+		defer broker.Close()
+
+		if err := mqtt.Connect(config.MQTTConfig{Broker: "tcp://" + tcp.Address()}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		defer mqtt.Disconnect()
+
+		out := filepath.Join(t.TempDir(), "messages")
+		wf := &workflow.Workflow{
+			Name:    "mqtt-sensors",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeMQTT, Topic: "sensors/+/temperature"},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: `echo "$AUTOZAP_EVENT_TOPIC {{ .payload.celsius }}" >> ` + out},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := StartMQTTTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		broker.Publish("sensors/attic/temperature", []byte(`{"celsius": 31}`), false, 0)
+		broker.Publish("sensors/attic/humidity", []byte(`{"percent": 60}`), false, 0)
+		broker.Publish("sensors/cellar/temperature", []byte(`{"celsius": 12}`), false, 0)
+
+		time.Sleep(500 * time.Millisecond)
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected the workflow to run, got: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != 2 || lines[0] != "sensors/attic/temperature 31" || lines[1] != "sensors/cellar/temperature 12" {
+			t.Errorf("Unexpected runs: %q", lines)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/metrics"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/workflow"
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	Register(workflow.TriggerTypeRedis, Definition{
+		Start:    StartRedisTrigger,
+		Validate: validateRedisTrigger,
+		Describe: func(t workflow.Trigger) []string {
+			return []string{"Subscribe: " + t.Channel}
+		},
+	})
+}
+
+// validateRedisTrigger checks the URL and channel of a redis trigger
+func validateRedisTrigger(t *workflow.Trigger) error {
+	if t.URL == "" {
+		return fmt.Errorf("redis trigger requires a 'url'")
+	}
+	if _, err := redis.ParseURL(t.URL); err != nil {
+		return fmt.Errorf("redis trigger has invalid 'url': %w", err)
+	}
+	if t.Channel == "" {
+		return fmt.Errorf("redis trigger requires a 'channel'")
+	}
+
+	if t.Schedule != "" || t.Path != "" || len(t.Events) > 0 || t.Interval != "" {
+		logger.L().Warnf("redis trigger has unexpected 'schedule', 'path', 'events' or 'interval'; these will be ignored.")
+	}
+	return nil
+}
+
+// StartRedisTrigger subscribes to the trigger's Redis pub/sub channel and runs
+// the workflow once per message, one message at a time in arrival order. The
+// client reconnects and resubscribes by itself when the connection drops;
+// messages published while it is disconnected are lost, as usual for pub/sub.
+func StartRedisTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	if wf.Trigger.Type != workflow.TriggerTypeRedis {
+		return fmt.Errorf("invalid trigger type for StartRedisTrigger: expected '%s', got '%s'", workflow.TriggerTypeRedis, wf.Trigger.Type)
+	}
+	if wf.Trigger.Channel == "" {
+		return fmt.Errorf("channel cannot be empty for redis trigger")
+	}
+
+	opts, err := redis.ParseURL(wf.Trigger.URL)
+	if err != nil {
+		return fmt.Errorf("invalid redis url for workflow '%s': %w", wf.Name, err)
+	}
+	client := redis.NewClient(opts)
+
+	// Subscribing waits for the server's confirmation so a bad address or
+	// password fails the trigger at startup
+	var pubsub *redis.PubSub
+	if isChannelPattern(wf.Trigger.Channel) {
+		pubsub = client.PSubscribe(ctx, wf.Trigger.Channel)
+	} else {
+		pubsub = client.Subscribe(ctx, wf.Trigger.Channel)
+	}
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		client.Close()
+		return fmt.Errorf("failed to subscribe to redis channel '%s' for workflow '%s': %w", wf.Trigger.Channel, wf.Name, err)
+	}
+
+	// Register workflow in the registry
+	server.GetRegistry().RegisterWorkflow(wf)
+
+	// Register workflow info metric
+	metrics.RegisterWorkflow(wf.Name, string(workflow.TriggerTypeRedis), wf.Trigger.Channel)
+
+	logger.L().Infow("Redis trigger started",
+		"workflow_name", wf.Name,
+		"address", opts.Addr,
+		"channel", wf.Trigger.Channel)
+
+	messages := pubsub.Channel()
+	go func() {
+		defer func() {
+			pubsub.Close()
+			client.Close()
+			// Unregister workflow from registry
+			server.GetRegistry().UnregisterWorkflow(wf.Name)
+			logger.L().Infow("Redis trigger stopped successfully",
+				"workflow_name", wf.Name)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.L().Infow("Stopping redis trigger for workflow",
+					"workflow_name", wf.Name,
+					"channel", wf.Trigger.Channel,
+					"reason", "context cancelled")
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					logger.L().Errorw("Redis subscription closed", "workflow_name", wf.Name)
+					return
+				}
+				runMessage(wf, string(workflow.TriggerTypeRedis), msg.Channel, msg.Payload)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// runMessage runs the workflow for a message received on topic. The message
+// is available as {{ .event.message }}, and as {{ .payload }} when it is a
+// JSON object.
+func runMessage(wf *workflow.Workflow, triggerType, topic, message string) {
+	if server.GetRegistry().IsPaused(wf.Name) {
+		logger.L().Infow("Skipping message for paused workflow",
+			"workflow_name", wf.Name,
+			"topic", topic)
+		return
+	}
+
+	metrics.RecordTriggerFire(wf.Name, triggerType)
+
+	logger.L().Infow("Message trigger fired for workflow",
+		"workflow_name", wf.Name,
+		"trigger_type", triggerType,
+		"topic", topic,
+		"message_size", len(message),
+		"timestamp", time.Now().Format(time.RFC3339))
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(message), &payload); err != nil {
+		payload = nil
+	}
+
+	data := executor.WithEvent(templating.Data{"payload": payload}, executor.Event{
+		Payload: payload,
+		Topic:   topic,
+		Message: message,
+		Time:    time.Now(),
+	})
+	executor.ExecuteWithData(wf, triggerType, data)
+}
+
+// isChannelPattern reports whether a channel uses Redis glob syntax and must
+// be subscribed with PSUBSCRIBE
+func isChannelPattern(channel string) bool {
+	return strings.ContainsAny(channel, "*?[")
+}
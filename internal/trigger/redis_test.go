@@ -0,0 +1,62 @@
+package trigger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestStartRedisTrigger(t *testing.T) {
+	t.Run("Unreachable Server", func(t *testing.T) {
+		wf := &workflow.Workflow{
+			Name:    "redis-unreachable",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeRedis, URL: "redis://127.0.0.1:1/0", Channel: "deploys"},
+		}
+		if err := StartRedisTrigger(context.Background(), wf); err == nil {
+			t.Fatal("Expected error for unreachable server, got nil")
+		}
+	})
+
+	t.Run("Runs Once Per Message", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		out := filepath.Join(t.TempDir(), "messages")
+
+		wf := &workflow.Workflow{
+			Name: "redis-deploys",
+			Trigger: workflow.Trigger{
+				Type:    workflow.TriggerTypeRedis,
+				URL:     "redis://" + mr.Addr(),
+				Channel: "deploys.*",
+			},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: `echo "$AUTOZAP_EVENT_TOPIC {{ .payload.version }}" >> ` + out},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if err := StartRedisTrigger(ctx, wf); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		mr.Publish("deploys.api", `{"version": "1.4.2"}`)
+		mr.Publish("deploys.web", `{"version": "2.0.0"}`)
+		mr.Publish("builds.api", `{"version": "9.9.9"}`)
+
+		time.Sleep(500 * time.Millisecond)
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Expected the workflow to run, got: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != 2 || lines[0] != "deploys.api 1.4.2" || lines[1] != "deploys.web 2.0.0" {
+			t.Errorf("Unexpected runs: %q", lines)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package trigger
+
+import (
+	"sync"
+	"time"
+)
+
+// startupStagger holds the delays of the first cron ticks of the workflows
+// loaded at agent startup, see StaggerStartup
+var startupStagger struct {
+	mu     sync.Mutex
+	delays map[string]time.Duration
+	until  time.Time // delays are only handed out to triggers started before this
+}
+
+// StaggerStartup spreads the first cron ticks of the named workflows evenly
+// over spread, in the given order: the first workflow's first tick runs on
+// schedule and the last one's is delayed by almost spread. Later ticks aren't
+// delayed. It keeps an agent that loads hundreds of workflows on the same
+// schedule from running them all at once when it starts.
+//
+// It must be called before the workflows' triggers are started, and only
+// applies to triggers started within spread, so workflows reloaded later run
+// on schedule.
+func StaggerStartup(names []string, spread time.Duration) {
+	startupStagger.mu.Lock()
+	defer startupStagger.mu.Unlock()
+
+	startupStagger.delays = make(map[string]time.Duration, len(names))
+	startupStagger.until = time.Now().Add(spread)
+	if spread <= 0 {
+		return
+	}
+	for i, name := range names {
+		startupStagger.delays[name] = spread * time.Duration(i) / time.Duration(len(names))
+	}
+}
+
+// startupDelay returns the delay of the workflow's first cron tick set by
+// StaggerStartup, and forgets it
+func startupDelay(workflowName string) time.Duration {
+	startupStagger.mu.Lock()
+	defer startupStagger.mu.Unlock()
+
+	delay, ok := startupStagger.delays[workflowName]
+	if !ok || time.Now().After(startupStagger.until) {
+		return 0
+	}
+	delete(startupStagger.delays, workflowName)
+	return delay
+}
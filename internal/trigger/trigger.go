@@ -0,0 +1,151 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Trigger starts the runs of a workflow when its event happens: a schedule,
+// a file change, a message. The run and agent commands start every workflow
+// through it, whatever its trigger type.
+type Trigger interface {
+	// Start registers the workflow and watches for its event in the
+	// background until Stop is called or ctx is cancelled
+	Start(ctx context.Context) error
+
+	// Stop stops watching and unregisters the workflow. Runs in progress
+	// finish. Stop may be called more than once.
+	Stop()
+}
+
+// startFunc starts a trigger of one type, which stops when ctx is done
+type startFunc func(ctx context.Context, wf *workflow.Workflow) error
+
+// Definition is a trigger type, which its file registers with Register
+type Definition struct {
+	// Start registers wf and watches for its event in the background until
+	// ctx is done
+	Start startFunc
+
+	// Describe returns what the trigger watches, e.g. "Schedule: @hourly",
+	// for dry runs. Optional.
+	Describe func(t workflow.Trigger) []string
+
+	// Validate checks the trigger's fields when a workflow is parsed.
+	// Optional.
+	Validate parser.TriggerValidator
+}
+
+// Registry holds the trigger types by name, so the run and agent commands and
+// the parser need no switch over them
+type Registry struct {
+	mu    sync.RWMutex
+	types map[workflow.TriggerType]Definition
+}
+
+// NewRegistry returns an empty trigger registry
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[workflow.TriggerType]Definition)}
+}
+
+var registry = NewRegistry()
+
+// GetRegistry returns the registry the trigger types of this package register
+// with
+func GetRegistry() *Registry {
+	return registry
+}
+
+// Register adds a trigger type, replacing one of the same name
+func (r *Registry) Register(typ workflow.TriggerType, def Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[typ] = def
+}
+
+// Lookup returns the definition of a trigger type
+func (r *Registry) Lookup(typ workflow.TriggerType) (Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.types[typ]
+	return def, ok
+}
+
+// Types returns the registered trigger types, sorted
+func (r *Registry) Types() []workflow.TriggerType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]workflow.TriggerType, 0, len(r.types))
+	for typ := range r.types {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Register adds a trigger type to the registry, usually from the init
+// function of the file implementing it, and lets the parser accept workflows
+// using it
+func Register(typ workflow.TriggerType, def Definition) {
+	registry.Register(typ, def)
+	parser.RegisterTriggerType(typ, def.Validate)
+}
+
+// New returns the trigger of wf for its trigger type
+func New(wf *workflow.Workflow) (Trigger, error) {
+	def, ok := registry.Lookup(wf.Trigger.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported trigger type '%s' for workflow '%s'", wf.Trigger.Type, wf.Name)
+	}
+	return &contextTrigger{wf: wf, start: def.Start}, nil
+}
+
+// Describe returns what a trigger watches, for dry runs
+func Describe(t workflow.Trigger) []string {
+	def, ok := registry.Lookup(t.Type)
+	if !ok || def.Describe == nil {
+		return nil
+	}
+	return def.Describe(t)
+}
+
+// contextTrigger adapts a startFunc to Trigger: Stop cancels the context the
+// trigger was started with
+type contextTrigger struct {
+	wf    *workflow.Workflow
+	start startFunc
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (t *contextTrigger) Start(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancel != nil {
+		return fmt.Errorf("%s trigger of workflow '%s' already started", t.wf.Trigger.Type, t.wf.Name)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	if err := t.start(ctx, t.wf); err != nil {
+		cancel()
+		return err
+	}
+	t.cancel = cancel
+	return nil
+}
+
+func (t *contextTrigger) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
@@ -0,0 +1,159 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/server"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("Unsupported Type", func(t *testing.T) {
+		wf := &workflow.Workflow{Name: "unknown", Trigger: workflow.Trigger{Type: "webhook"}}
+		if _, err := New(wf); err == nil {
+			t.Error("Expected error for unsupported trigger type")
+		}
+	})
+
+	t.Run("Start And Stop", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "runs")
+		wf := &workflow.Workflow{
+			Name:    "trigger-lifecycle",
+			Trigger: workflow.Trigger{Type: workflow.TriggerTypeShutdown},
+			Actions: []workflow.Action{
+				{Type: workflow.ActionTypeBash, Name: "record", Command: "echo stopped >> " + out},
+			},
+		}
+
+		trig, err := New(wf)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if err := trig.Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		if err := trig.Start(context.Background()); err == nil {
+			t.Error("Expected error starting a trigger twice")
+		}
+		if _, exists := server.GetRegistry().GetWorkflow(wf.Name); !exists {
+			t.Fatal("Expected workflow to be registered once started")
+		}
+
+		// A stopped shutdown trigger doesn't run on shutdown
+		trig.Stop()
+		trig.Stop()
+		time.Sleep(100 * time.Millisecond)
+		RunShutdownTriggers()
+		if _, err := os.Stat(out); err == nil {
+			t.Error("Expected no run after Stop")
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	const typ workflow.TriggerType = "test-webhook"
+	started := make(chan string, 1)
+	Register(typ, Definition{
+		Start: func(ctx context.Context, wf *workflow.Workflow) error {
+			started <- wf.Name
+			return nil
+		},
+		Describe: func(t workflow.Trigger) []string {
+			return []string{"Listen: " + t.Path}
+		},
+		Validate: func(t *workflow.Trigger) error {
+			if t.Path == "" {
+				return errors.New("test-webhook trigger requires a 'path'")
+			}
+			return nil
+		},
+	})
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		delete(registry.types, typ)
+		registry.mu.Unlock()
+	})
+
+	// The parser accepts the registered type and runs its validation
+	wf, err := parser.ParseWorkflow([]byte(`
+name: hook
+trigger:
+  type: test-webhook
+  path: /hooks/deploy
+actions:
+  - type: bash
+    name: deploy
+    command: echo deploy
+`), "hook.yaml")
+	if err != nil {
+		t.Fatalf("Expected registered trigger type to parse, got: %v", err)
+	}
+	if _, err := parser.ParseWorkflow([]byte(`
+name: hook
+trigger:
+  type: test-webhook
+actions:
+  - type: bash
+    name: deploy
+    command: echo deploy
+`), "hook.yaml"); err == nil || !strings.Contains(err.Error(), "requires a 'path'") {
+		t.Errorf("Expected the trigger's validation error, got: %v", err)
+	}
+
+	if got := Describe(wf.Trigger); len(got) != 1 || got[0] != "Listen: /hooks/deploy" {
+		t.Errorf("Expected description from the definition, got %v", got)
+	}
+
+	trig, err := New(wf)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := trig.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer trig.Stop()
+	if name := <-started; name != "hook" {
+		t.Errorf("Expected the definition to start workflow 'hook', got %q", name)
+	}
+
+	found := false
+	for _, registered := range GetRegistry().Types() {
+		found = found || registered == typ
+	}
+	if !found {
+		t.Errorf("Expected %s in registered types %v", typ, GetRegistry().Types())
+	}
+}
+
+func TestBuiltinTriggersValidate(t *testing.T) {
+	for _, typ := range []workflow.TriggerType{
+		workflow.TriggerTypeCron, workflow.TriggerTypeFileWatch, workflow.TriggerTypeHTTPPoll, workflow.TriggerTypeRedis,
+		workflow.TriggerTypeMQTT, workflow.TriggerTypeLog, workflow.TriggerTypeStartup, workflow.TriggerTypeShutdown,
+	} {
+		def, ok := GetRegistry().Lookup(typ)
+		if !ok || def.Validate == nil {
+			t.Errorf("Expected built-in trigger type %s to be registered with its validation", typ)
+		}
+	}
+
+	// Validated through the definition the cron file registers
+	if _, err := parser.ParseWorkflow([]byte(`
+name: broken-cron
+trigger:
+  type: cron
+  schedule: "not a schedule"
+actions:
+  - type: bash
+    name: a
+    command: "true"
+`), "broken.yaml"); err == nil || !strings.Contains(err.Error(), "invalid 'schedule'") {
+		t.Errorf("Expected the cron trigger's validation error, got: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExecutionResult is the outcome of a workflow run: its status and error and
+// the result of each action that ran. The executor returns it and records it
+// in the execution history, the metrics and the workflow registry.
+type ExecutionResult struct {
+	Workflow    string
+	TriggerType string
+	Status      string // one of the Status constants
+	Error       string // why the run failed or only partially succeeded
+	StartedAt   time.Time
+	Duration    time.Duration
+
+	// Actions that ran, in order, including skipped and async ones. Actions
+	// that didn't run, e.g. after the run was cancelled, are left out.
+	Actions []ActionResult
+
+	// Actions that succeeded and failed, including async ones
+	ActionsSucceeded int
+	ActionsFailed    int
+}
+
+// ActionResult is the outcome of one action of a run
+type ActionResult struct {
+	Name     string
+	Type     ActionType
+	Status   string // one of the Status constants
+	Error    string
+	Output   string // captured output, e.g. a command's stdout and stderr
+	Duration time.Duration
+	Attempts int // times the action was tried; 0 if it didn't run, e.g. was skipped
+	Async    bool
+}
+
+// Retries returns how often the action was tried again after its first attempt
+func (r ActionResult) Retries() int {
+	if r.Attempts > 1 {
+		return r.Attempts - 1
+	}
+	return 0
+}
+
+// MarshalJSON encodes the result for scripts, e.g. the --summary-file of a
+// one-shot run, with durations in milliseconds. Action outputs are left out.
+func (r ExecutionResult) MarshalJSON() ([]byte, error) {
+	type actionJSON struct {
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+		Status     string `json:"status"`
+		DurationMs int64  `json:"duration_ms"`
+		Attempts   int    `json:"attempts"`
+		Retries    int    `json:"retries"`
+		Async      bool   `json:"async,omitempty"`
+		Error      string `json:"error,omitempty"`
+	}
+	actions := make([]actionJSON, 0, len(r.Actions))
+	for _, a := range r.Actions {
+		actions = append(actions, actionJSON{
+			Name:       a.Name,
+			Type:       a.Type.String(),
+			Status:     a.Status,
+			DurationMs: a.Duration.Milliseconds(),
+			Attempts:   a.Attempts,
+			Retries:    a.Retries(),
+			Async:      a.Async,
+			Error:      a.Error,
+		})
+	}
+
+	return json.Marshal(struct {
+		Workflow         string       `json:"workflow"`
+		TriggerType      string       `json:"trigger_type"`
+		Status           string       `json:"status"`
+		StartedAt        time.Time    `json:"started_at"`
+		DurationMs       int64        `json:"duration_ms"`
+		Error            string       `json:"error,omitempty"`
+		Steps            []actionJSON `json:"steps"`
+		ActionsSucceeded int          `json:"actions_succeeded"`
+		ActionsFailed    int          `json:"actions_failed"`
+	}{
+		Workflow:         r.Workflow,
+		TriggerType:      r.TriggerType,
+		Status:           r.Status,
+		StartedAt:        r.StartedAt,
+		DurationMs:       r.Duration.Milliseconds(),
+		Error:            r.Error,
+		Steps:            actions,
+		ActionsSucceeded: r.ActionsSucceeded,
+		ActionsFailed:    r.ActionsFailed,
+	})
+}
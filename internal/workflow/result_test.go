@@ -0,0 +1,43 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecutionResultJSON(t *testing.T) {
+	result := ExecutionResult{
+		Workflow:    "nightly-backup",
+		TriggerType: "cron",
+		Status:      StatusFailed,
+		Error:       "exit status 1",
+		Duration:    1500 * time.Millisecond,
+		Actions: []ActionResult{
+			{Name: "dump", Type: ActionTypeBash, Status: StatusSuccess, Output: "secret output", Duration: 1200 * time.Millisecond, Attempts: 3},
+			{Name: "upload", Type: ActionTypeStorage, Status: StatusFailed, Error: "exit status 1", Attempts: 1},
+		},
+		ActionsSucceeded: 1,
+		ActionsFailed:    1,
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		`"workflow":"nightly-backup"`,
+		`"duration_ms":1500`,
+		`"name":"dump","type":"bash","status":"success","duration_ms":1200,"attempts":3,"retries":2`,
+		`"actions_failed":1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected JSON to contain %s, got %s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret output") {
+		t.Errorf("Expected action output to be left out, got %s", out)
+	}
+}
@@ -0,0 +1,100 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// secondsParser parses 6-field cron expressions with a leading seconds field
+var secondsParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// CronParser returns the parser for the trigger's schedule: standard 5-field
+// expressions, or 6 fields starting with seconds when WithSeconds is set.
+// Descriptors such as @daily and @every 10s are accepted by both.
+func (t Trigger) CronParser() cron.ScheduleParser {
+	if t.WithSeconds {
+		return secondsParser
+	}
+	return cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+}
+
+// ParseSchedule parses the trigger's cron schedule
+func (t Trigger) ParseSchedule() (cron.Schedule, error) {
+	return t.CronParser().Parse(t.Schedule)
+}
+
+// JitterDuration parses the trigger's jitter; it is zero when unset
+func (t Trigger) JitterDuration() (time.Duration, error) {
+	return optionalDuration("jitter", t.Jitter)
+}
+
+// DebounceDuration parses the trigger's debounce; it is zero when unset
+func (t Trigger) DebounceDuration() (time.Duration, error) {
+	return optionalDuration("debounce", t.Debounce)
+}
+
+// DefaultPollTimeout bounds a single request of an httppoll trigger
+const DefaultPollTimeout = 10 * time.Second
+
+// PollInterval parses the httppoll interval, which is required and positive
+func (t Trigger) PollInterval() (time.Duration, error) {
+	d, err := optionalDuration("interval", t.Interval)
+	if err != nil {
+		return 0, err
+	}
+	if d == 0 {
+		return 0, fmt.Errorf("interval must be positive")
+	}
+	return d, nil
+}
+
+// PollTimeout parses the httppoll request timeout, DefaultPollTimeout if unset
+func (t Trigger) PollTimeout() (time.Duration, error) {
+	d, err := optionalDuration("timeout", t.Timeout)
+	if err != nil || d > 0 {
+		return d, err
+	}
+	return DefaultPollTimeout, nil
+}
+
+// DefaultShutdownTimeout bounds the run of a shutdown trigger
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ShutdownTimeout parses how long a shutdown trigger's run may delay the
+// agent's shutdown, DefaultShutdownTimeout if unset
+func (t Trigger) ShutdownTimeout() (time.Duration, error) {
+	d, err := optionalDuration("timeout", t.Timeout)
+	if err != nil || d > 0 {
+		return d, err
+	}
+	return DefaultShutdownTimeout, nil
+}
+
+// DefaultCircuitCooldown is how long an open circuit blocks runs by default
+const DefaultCircuitCooldown = 5 * time.Minute
+
+// CooldownDuration parses how long an open circuit blocks runs before a
+// probe run, DefaultCircuitCooldown if unset
+func (c CircuitBreakerConfig) CooldownDuration() (time.Duration, error) {
+	d, err := optionalDuration("cooldown", c.Cooldown)
+	if err != nil || d > 0 {
+		return d, err
+	}
+	return DefaultCircuitCooldown, nil
+}
+
+func optionalDuration(field, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s cannot be negative", field)
+	}
+	return d, nil
+}
@@ -0,0 +1,35 @@
+package workflow
+
+// Statuses of workflow runs and their actions, as recorded in the execution
+// history, the status label of the execution metrics and the registry
+const (
+	StatusRunning        = "running"
+	StatusSuccess        = "success"
+	StatusPartialSuccess = "partial-success" // finished, but some actions failed
+	StatusFailed         = "failed"
+	StatusTimeout        = "timeout"   // an action ran out of time
+	StatusCancelled      = "cancelled" // stopped by a newer run (replace) or a shutdown timeout
+	StatusAborted        = "aborted"   // stopped because the agent shut down before it finished
+	StatusSkipped        = "skipped"   // not started because a run was in progress (forbid), or an action's when was false
+	StatusThrottled      = "throttled" // not started because a rate limit was reached
+	StatusDeferred       = "deferred"  // not started now, postponed to a later time
+	StatusBlocked        = "blocked"   // not started because a service it depends on is unhealthy or its circuit is open
+)
+
+// FailureStatuses are the statuses of runs and actions that failed
+var FailureStatuses = []string{StatusFailed, StatusTimeout}
+
+// IsFailure reports whether status is one of FailureStatuses
+func IsFailure(status string) bool {
+	return status == StatusFailed || status == StatusTimeout
+}
+
+// WasStarted reports whether a run with status started running its actions;
+// skipped, throttled, deferred and blocked runs didn't
+func WasStarted(status string) bool {
+	switch status {
+	case StatusSkipped, StatusThrottled, StatusDeferred, StatusBlocked:
+		return false
+	}
+	return true
+}
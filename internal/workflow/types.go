@@ -2,6 +2,9 @@ package workflow
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -12,6 +15,98 @@ type Workflow struct {
 	Description string   `yaml:"description,omitempty"`
 	Trigger     Trigger  `yaml:"trigger"`
 	Actions     []Action `yaml:"actions"`
+
+	// Enabled false keeps the agent from starting the workflow, so it can
+	// stay in the workflow directory without running. Default true.
+	Enabled *bool `yaml:"enabled,omitempty"`
+
+	// Owner, DocsURL and RunbookURL tell whoever sees the workflow fail who
+	// to contact and where to look. They are shown on the dashboard and are
+	// available to templates as {{ .workflow.owner }}, {{ .workflow.docsUrl }}
+	// and {{ .workflow.runbookUrl }}.
+	Owner      string `yaml:"owner,omitempty"`
+	DocsURL    string `yaml:"docsUrl,omitempty"`
+	RunbookURL string `yaml:"runbookUrl,omitempty"`
+
+	// Vars are values shared by the actions' templates as {{ .vars.<name> }}.
+	// They are rendered at the start of each run and may use env, secrets and
+	// trigger data, but not other vars.
+	Vars map[string]string `yaml:"vars,omitempty"`
+
+	// Metrics are custom samples recorded after every run and exposed on /metrics
+	Metrics []MetricConfig `yaml:"metrics,omitempty"`
+
+	// ConcurrencyPolicy decides what happens when the workflow is triggered
+	// while a previous run is still in progress
+	ConcurrencyPolicy ConcurrencyPolicy `yaml:"concurrencyPolicy,omitempty"`
+
+	// DependsOnServices names services from the agent configuration that must
+	// be healthy for the workflow to run; otherwise the run is blocked
+	DependsOnServices []string `yaml:"dependsOnServices,omitempty"`
+
+	// Network overrides how the workflow's HTTP and download actions connect
+	Network *NetworkConfig `yaml:"network,omitempty"`
+
+	// CircuitBreaker stops running the workflow after consecutive failed
+	// runs, so a broken workflow doesn't hit downstream systems every tick
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker,omitempty"`
+}
+
+// IsEnabled reports whether the agent starts the workflow, which it does
+// unless enabled is false
+func (wf *Workflow) IsEnabled() bool {
+	return wf.Enabled == nil || *wf.Enabled
+}
+
+// CircuitBreakerConfig opens a workflow's circuit after FailureThreshold
+// consecutive failed runs. While open, runs are blocked; after Cooldown one
+// probe run is let through, which closes the circuit if it succeeds.
+type CircuitBreakerConfig struct {
+	FailureThreshold int    `yaml:"failureThreshold"`
+	Cooldown         string `yaml:"cooldown,omitempty"` // e.g. "10m", default 5m
+}
+
+// NetworkConfig routes a workflow's HTTP traffic, e.g. in split-horizon DNS
+// or air-gapped environments
+type NetworkConfig struct {
+	// Proxy is the URL of an http, https or socks5 proxy used instead of the
+	// HTTP_PROXY/HTTPS_PROXY environment variables
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// DNSOverride maps host names to the IP addresses to connect to instead
+	// of resolving them, like curl --resolve. TLS certificates are still
+	// verified against the host name.
+	DNSOverride map[string]string `yaml:"dnsOverride,omitempty"`
+}
+
+// MissedRunPolicy controls the cron runs missed while the system was
+// suspended or its clock jumped forward
+type MissedRunPolicy string
+
+const (
+	MissedRunsOnce MissedRunPolicy = "once" // Run once for all missed runs on resume (default)
+	MissedRunsAll  MissedRunPolicy = "all"  // Run once per missed run, oldest first
+	MissedRunsSkip MissedRunPolicy = "skip" // Wait for the next scheduled run
+)
+
+// ConcurrencyPolicy controls overlapping runs of the same workflow
+type ConcurrencyPolicy string
+
+const (
+	ConcurrencyAllow   ConcurrencyPolicy = "allow"   // Runs may overlap (default)
+	ConcurrencyForbid  ConcurrencyPolicy = "forbid"  // Skip the new run while one is in progress
+	ConcurrencyReplace ConcurrencyPolicy = "replace" // Cancel the running run and start the new one
+)
+
+// MetricConfig defines a custom Prometheus metric emitted by a workflow.
+// ValueFrom and label values are templates rendered after the run with the
+// action results available as {{ .steps.<action>.stdout }}.
+type MetricConfig struct {
+	Name      string            `yaml:"name"`
+	Help      string            `yaml:"help,omitempty"`
+	Type      string            `yaml:"type,omitempty"`      // "gauge" (default) or "counter"
+	ValueFrom string            `yaml:"valueFrom,omitempty"` // Required for gauges; counters add 1 if empty
+	Labels    map[string]string `yaml:"labels,omitempty"`
 }
 
 type TriggerType string
@@ -19,6 +114,12 @@ type TriggerType string
 const (
 	TriggerTypeCron      TriggerType = "cron"
 	TriggerTypeFileWatch TriggerType = "filewatch"
+	TriggerTypeHTTPPoll  TriggerType = "httppoll"
+	TriggerTypeRedis     TriggerType = "redis"
+	TriggerTypeMQTT      TriggerType = "mqtt"
+	TriggerTypeLog       TriggerType = "log"
+	TriggerTypeStartup   TriggerType = "startup"  // Once when the workflow is loaded
+	TriggerTypeShutdown  TriggerType = "shutdown" // Once during graceful shutdown
 )
 
 func (tt *TriggerType) UnmarshalYaml(value *yaml.Node) error {
@@ -34,8 +135,20 @@ func (tt *TriggerType) UnmarshalYaml(value *yaml.Node) error {
 		*tt = TriggerTypeCron
 	case string(TriggerTypeFileWatch):
 		*tt = TriggerTypeFileWatch
+	case string(TriggerTypeHTTPPoll):
+		*tt = TriggerTypeHTTPPoll
+	case string(TriggerTypeRedis):
+		*tt = TriggerTypeRedis
+	case string(TriggerTypeMQTT):
+		*tt = TriggerTypeMQTT
+	case string(TriggerTypeLog):
+		*tt = TriggerTypeLog
+	case string(TriggerTypeStartup):
+		*tt = TriggerTypeStartup
+	case string(TriggerTypeShutdown):
+		*tt = TriggerTypeShutdown
 	default:
-		return fmt.Errorf("invalid trigger type '%s'. Must be one of: %s, %s", s, TriggerTypeCron, TriggerTypeFileWatch)
+		return fmt.Errorf("invalid trigger type '%s'. Must be one of: %s, %s, %s, %s, %s, %s, %s, %s", s, TriggerTypeCron, TriggerTypeFileWatch, TriggerTypeHTTPPoll, TriggerTypeRedis, TriggerTypeMQTT, TriggerTypeLog, TriggerTypeStartup, TriggerTypeShutdown)
 	}
 	return nil
 }
@@ -43,8 +156,70 @@ func (tt *TriggerType) UnmarshalYaml(value *yaml.Node) error {
 type Trigger struct {
 	Type     TriggerType `yaml:"type"`               //custom TriggerType enum
 	Schedule string      `yaml:"schedule,omitempty"` // Mandatory for cron, omitted otherwise
-	Path     string      `yaml:"path,omitempty"`     // Will be used for filewatch trigger later
-	Events   []string    `yaml:"events,omitempty"`   // for filewatch, omitted otherwise
+	// WithSeconds makes the cron schedule a 6-field expression starting with
+	// seconds, e.g. "*/15 * * * * *" for every 15 seconds
+	WithSeconds bool `yaml:"withSeconds,omitempty"`
+	// Jitter delays each cron run by a random duration up to this value,
+	// e.g. "30s", so many workflows on the same schedule don't fire at once
+	Jitter string `yaml:"jitter,omitempty"`
+	// MissedRuns decides what happens to the cron runs that fell into a
+	// system suspend or a forward jump of the clock
+	MissedRuns MissedRunPolicy `yaml:"missedRuns,omitempty"`
+
+	Path   string   `yaml:"path,omitempty"`   // Will be used for filewatch trigger later
+	Events []string `yaml:"events,omitempty"` // for filewatch, omitted otherwise
+
+	// File filters for filewatch: only files matching one of Patterns (all
+	// files if empty) and none of Ignore fire the workflow. Patterns use
+	// filepath.Match syntax; those without a slash match the file name, others
+	// the path relative to the watched directory.
+	Patterns []string `yaml:"patterns,omitempty"`
+	Ignore   []string `yaml:"ignore,omitempty"`
+
+	// Debounce for filewatch coalesces rapid events on a file into one run once
+	// the file has been quiet for this long, e.g. "2s". With Batch, all files
+	// changed during a burst run the workflow once, listed in {{ .files }}.
+	Debounce string `yaml:"debounce,omitempty"`
+	Batch    bool   `yaml:"batch,omitempty"`
+
+	// Hot-folder output for filewatch: after a run the triggering file is moved
+	// to ProcessedDir on success or FailedDir on failure. Relative paths are
+	// resolved against the watched directory.
+	ProcessedDir string `yaml:"processedDir,omitempty"`
+	FailedDir    string `yaml:"failedDir,omitempty"`
+
+	// Archive extraction for filewatch: zip, tar and tar.gz files are extracted
+	// before the actions run and the directory is available as {{ .extractDir }}.
+	// Without ExtractDir a temporary directory is used and removed after the run.
+	Extract    bool   `yaml:"extract,omitempty"`
+	ExtractDir string `yaml:"extractDir,omitempty"`
+
+	// HTTP polling for httppoll: URL is requested every Interval and the
+	// workflow fires when the status code or body changes. With MatchStatus or
+	// MatchBody (a regular expression) it fires instead each time the response
+	// starts matching them.
+	URL         string            `yaml:"url,omitempty"`
+	Interval    string            `yaml:"interval,omitempty"` // e.g. "1m"
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Timeout     string            `yaml:"timeout,omitempty"` // per request, default 10s; for shutdown, the run's limit (default 30s)
+	MatchStatus int               `yaml:"matchStatus,omitempty"`
+	MatchBody   string            `yaml:"matchBody,omitempty"`
+
+	// Message queue for redis: the workflow runs once per message published
+	// on Channel, a Redis pub/sub channel or glob pattern such as "deploys.*",
+	// on the server at URL (redis://[:password@]host:port/db).
+	Channel string `yaml:"channel,omitempty"`
+
+	// MQTT subscription for mqtt: the workflow runs once per message on Topic,
+	// which may use the + and # wildcards, on the broker from the agent config
+	Topic string `yaml:"topic,omitempty"`
+	QoS   int    `yaml:"qos,omitempty"` // 0 (default), 1 or 2
+
+	// Log tailing for log: the workflow runs for every new line matching the
+	// regular expression Match, read from the file at Path or, with Unit, from
+	// the systemd journal of that unit
+	Match string `yaml:"match,omitempty"`
+	Unit  string `yaml:"unit,omitempty"` // e.g. "nginx.service"
 }
 
 // ActionType defines the type of action to be performed (e.g., "bash", "http", etc.)
@@ -53,9 +228,20 @@ type Trigger struct {
 type ActionType string
 
 const (
-	ActionTypeBash   ActionType = "bash"
-	ActionTypeHTTP   ActionType = "http"
-	ActionTypeCustom ActionType = "custom" // For user-defined actions
+	ActionTypeBash     ActionType = "bash"
+	ActionTypeHTTP     ActionType = "http"
+	ActionTypeCustom   ActionType = "custom"   // For user-defined actions
+	ActionTypeKV       ActionType = "kv"       // Persist values in the key-value store
+	ActionTypeVerify   ActionType = "verify"   // Check files against a checksum manifest
+	ActionTypeDownload ActionType = "download" // Download a URL to a file
+	ActionTypeFile     ActionType = "file"     // Copy, move, delete or archive files
+	ActionTypeMQTT     ActionType = "mqtt"     // Publish a message to the MQTT broker
+	ActionTypeStorage  ActionType = "storage"  // Upload or download a file to S3, GCS or SFTP
+	ActionTypeGit      ActionType = "git"      // Clone, pull or check out a git repository
+	ActionTypeTemplate ActionType = "template" // Render a template file to a file
+	ActionTypeWait     ActionType = "wait"     // Sleep for a duration or until a time
+	ActionTypeApproval ActionType = "approval" // Pause the run until it is approved
+	ActionTypeWorkflow ActionType = "workflow" // Run another workflow
 )
 
 // This allows yaml parser to convert string from yaml file directly to ActionType
@@ -72,8 +258,30 @@ func (at *ActionType) UnmarshalYaml(value *yaml.Node) error {
 		*at = ActionTypeHTTP
 	case string(ActionTypeCustom):
 		*at = ActionTypeCustom
+	case string(ActionTypeKV):
+		*at = ActionTypeKV
+	case string(ActionTypeVerify):
+		*at = ActionTypeVerify
+	case string(ActionTypeDownload):
+		*at = ActionTypeDownload
+	case string(ActionTypeFile):
+		*at = ActionTypeFile
+	case string(ActionTypeMQTT):
+		*at = ActionTypeMQTT
+	case string(ActionTypeStorage):
+		*at = ActionTypeStorage
+	case string(ActionTypeGit):
+		*at = ActionTypeGit
+	case string(ActionTypeTemplate):
+		*at = ActionTypeTemplate
+	case string(ActionTypeWait):
+		*at = ActionTypeWait
+	case string(ActionTypeApproval):
+		*at = ActionTypeApproval
+	case string(ActionTypeWorkflow):
+		*at = ActionTypeWorkflow
 	default:
-		return fmt.Errorf("invalid action type '%s'. Must be one of: %s, %s, %s", s, ActionTypeBash, ActionTypeHTTP, ActionTypeCustom)
+		return fmt.Errorf("invalid action type '%s'. Must be one of: %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s", s, ActionTypeBash, ActionTypeHTTP, ActionTypeCustom, ActionTypeKV, ActionTypeVerify, ActionTypeDownload, ActionTypeFile, ActionTypeMQTT, ActionTypeStorage, ActionTypeGit, ActionTypeTemplate, ActionTypeWait, ActionTypeApproval, ActionTypeWorkflow)
 	}
 	return nil
 }
@@ -89,26 +297,388 @@ func (tt TriggerType) String() string {
 type Action struct {
 	Type ActionType `yaml:"type"`
 	Name string     `yaml:"name"`
+
+	// Include is the path of a file of shared actions, relative to the file
+	// it appears in, whose actions replace this entry. It is resolved by the
+	// parser and can't be combined with other fields.
+	Include string `yaml:"include,omitempty"`
+
 	// Field for ActionType bash
-	Command string `yaml:"command,omitempty"` // For bash actions
+	Command    string `yaml:"command,omitempty"`    // For bash actions
+	ScriptFile string `yaml:"scriptFile,omitempty"` // Script run instead of command, relative to the workflow file
+	Shell      string `yaml:"shell,omitempty"`      // Shell running command or scriptFile: bash (default), sh, zsh or powershell
+	Stdin      string `yaml:"stdin,omitempty"`      // Data piped to the command's standard input (templated)
+	StdinFile  string `yaml:"stdinFile,omitempty"`  // File piped to standard input instead of stdin (templated)
+	WorkingDir string `yaml:"workingDir,omitempty"` // Absolute directory the command runs in (templated)
+	RunAsUser  string `yaml:"runAsUser,omitempty"`  // User name or uid to run the command as; needs root
+	RunAsGroup string `yaml:"runAsGroup,omitempty"` // Group name or gid, instead of the user's primary group; needs root
+
+	// StreamOutput logs each line of a bash action's stdout and stderr as it
+	// is written, instead of only the captured output once it exits.
+	// MaxOutput is how much of each stream is captured, e.g. "64KB": its
+	// first and last half once the stream is longer. Default output.memoryLimit
+	// of the agent config.
+	StreamOutput bool   `yaml:"streamOutput,omitempty"`
+	MaxOutput    string `yaml:"maxOutput,omitempty"`
+
+	// Exec is a program and its arguments, run without a shell instead of
+	// command, e.g. ["pg_dump", "--file", "{{ .vars.out }}", "app"]. Each
+	// argument is templated and passed as is, with no quoting or expansion.
+	Exec []string `yaml:"exec,omitempty"`
 
 	//Field for ActionType Http
 
 	URL                string            `yaml:"url,omitempty" json:"url,omitempty"`
 	Method             string            `yaml:"method,omitempty" json:"method,omitempty"`
-	Headers            map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`                        // e.g., {"Content-Type": "application/json"}
-	Body               string            `yaml:"body,omitempty" json:"body,omitempty"`                              // For HTTP actions
-	Timeout            string            `yaml:"timeout,omitempty" json:"timeout,omitempty"`                        // e.g., "10s", will be parsed to time.Duration
-	ExpectStatus       interface{}       `yaml:"expect_status,omitempty" json:"expectStatus,omitempty"`             // Can be int or []int for multiple valid codes
-	ExpectBodyContains string            `yaml:"expect_body_contains,omitempty" json:"expectBodyContains,omitempty"` // For HTTP actions
+	Headers            map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`                       // e.g., {"Content-Type": "application/json"}
+	Body               string            `yaml:"body,omitempty" json:"body,omitempty"`                             // For HTTP actions
+	BodyFile           string            `yaml:"bodyFile,omitempty" json:"bodyFile,omitempty"`                     // Body template file instead of body, relative to the workflow file
+	FormData           map[string]string `yaml:"formData,omitempty" json:"formData,omitempty"`                     // multipart/form-data fields (templated)
+	Files              map[string]string `yaml:"files,omitempty" json:"files,omitempty"`                           // multipart file uploads, field name to file path (templated)
+	Timeout            string            `yaml:"timeout,omitempty" json:"timeout,omitempty"`                       // e.g., "10s"; HTTP actions default to the agent's httpClient.timeout (5m)
+	ExpectStatus       interface{}       `yaml:"expectStatus,omitempty" json:"expectStatus,omitempty"`             // Can be int or []int for multiple valid codes
+	ExpectBodyContains string            `yaml:"expectBodyContains,omitempty" json:"expectBodyContains,omitempty"` // For HTTP actions
+	ExpectBodyRegex    string            `yaml:"expectBodyRegex,omitempty" json:"expectBodyRegex,omitempty"`       // Regular expression the response body must match (templated)
+	ExpectHeaders      map[string]string `yaml:"expectHeaders,omitempty" json:"expectHeaders,omitempty"`           // Response headers and the value they must have (templated)
+	ExpectMaxLatency   string            `yaml:"expectMaxLatency,omitempty" json:"expectMaxLatency,omitempty"`     // e.g. "500ms"; fails slower responses, measured until the body is read
+	UnixSocket         string            `yaml:"unixSocket,omitempty" json:"unixSocket,omitempty"`                 // Send the request over this Unix socket, e.g. /var/run/docker.sock
+	FollowRedirects    *bool             `yaml:"followRedirects,omitempty" json:"followRedirects,omitempty"`       // Default true; false returns a 3xx response instead of following it
+	Proxy              string            `yaml:"proxy,omitempty" json:"proxy,omitempty"`                           // Proxy URL for HTTP, download and S3/GCS storage actions, overriding the workflow's network.proxy (templated)
+
+	// DeprecatedExpectStatus and DeprecatedExpectBodyContains hold the old
+	// spellings of expectStatus and expectBodyContains. The parser moves them
+	// to the new fields; `autozap migrate-config` rewrites them in the file.
+	DeprecatedExpectStatus       interface{} `yaml:"expect_status,omitempty" json:"-"`
+	DeprecatedExpectBodyContains string      `yaml:"expect_body_contains,omitempty" json:"-"`
+
+	// JSONPath (or JQ, its alias) extracts a value from the JSON response,
+	// e.g. ".data.id", available to later actions as {{ .steps.<action>.value }}.
+	// The action fails if the response isn't JSON or has no such value.
+	// CaptureAs also makes the value, or the whole response without a path,
+	// available as {{ .captured.<captureAs> }}.
+	JSONPath  string `yaml:"jsonPath,omitempty" json:"jsonPath,omitempty"`
+	JQ        string `yaml:"jq,omitempty" json:"jq,omitempty"`
+	CaptureAs string `yaml:"captureAs,omitempty" json:"captureAs,omitempty"`
+
+	// ExpectJSON maps paths into the JSON response, as in jsonPath, to the
+	// value each must have, e.g. {".status": "ok", ".replicas": 3}. Numbers
+	// are compared by value, lists and objects as a whole.
+	ExpectJSON map[string]interface{} `yaml:"expectJson,omitempty" json:"expectJson,omitempty"`
+
+	// Auth sets the request's Authorization header
+	Auth *AuthConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
+
+	// TLS configures how HTTP, download and S3/GCS storage actions verify
+	// the server and authenticate to it
+	TLS *TLSConfig `yaml:"tls,omitempty" json:"tls,omitempty"`
 
 	// Fields for ActionTypeCustom
 
 	FunctionName string                 `yaml:"functionName,omitempty"`
 	Arguments    map[string]interface{} `yaml:"arguments,omitempty"` // using interface for flexibility
 
+	// Fields for ActionTypeKV
+
+	Key       string `yaml:"key,omitempty"`       // Key in the key-value store
+	Value     string `yaml:"value,omitempty"`     // Value to store, or the increment for "incr" (templated)
+	Operation string `yaml:"operation,omitempty"` // "set" (default), "delete" or "incr"; for file actions see below
+
+	// Fields for ActionTypeVerify
+	Manifest  string `yaml:"manifest,omitempty"`  // Checksum file in sha256sum format ("<hex>  <file>")
+	Algorithm string `yaml:"algorithm,omitempty"` // sha256 (default), sha512, sha1 or md5
+
+	// Fields for ActionTypeDownload, which also uses URL, Headers, Timeout
+	// (per attempt) and Algorithm
+	Path     string `yaml:"path,omitempty"`     // Destination file (templated)
+	Checksum string `yaml:"checksum,omitempty"` // Expected hex digest of the file (templated)
+
+	// Fields for ActionTypeFile, which also uses Operation and Path: copy or
+	// move Source to Path, archive Source into Path (.tar, .tar.gz, .tgz or
+	// .zip), delete Path or create it as a directory with mkdir
+	Source    string `yaml:"source,omitempty"`    // File or directory to copy, move or archive (templated)
+	Recursive bool   `yaml:"recursive,omitempty"` // Let delete remove a directory with its contents
+
+	// Fields for ActionTypeTemplate, which also uses Path: Template is
+	// rendered with the run's template data (vars, trigger event, earlier
+	// steps) and written to Path
+	Template string `yaml:"template,omitempty"` // Go template file, relative to the workflow file; re-read on every run
+	Mode     string `yaml:"mode,omitempty"`     // Permissions of the written file, e.g. "0600"; by default those of the file it replaces, or 0644
+
+	// Content is the rendered Template of a template action, set by
+	// templating.RenderAction
+	Content string `yaml:"-"`
+
+	// Fields for ActionTypeWait, which sleeps for Duration or until Until
+	Duration string `yaml:"duration,omitempty"` // e.g. "10m" (templated)
+	Until    string `yaml:"until,omitempty"`    // RFC 3339 time, e.g. "2026-03-01T02:00:00Z" (templated)
+
+	// Fields for ActionTypeWorkflow, which runs another workflow with
+	// Payload as its {{ .payload }}, and by default waits for the run and
+	// fails if it fails. The workflow is one loaded in the agent, by name, or
+	// a workflow file parsed on every run.
+	Workflow     string            `yaml:"workflow,omitempty"`     // Name of a workflow loaded in the agent
+	WorkflowFile string            `yaml:"workflowFile,omitempty"` // Workflow file instead of workflow, relative to the workflow file
+	Payload      map[string]string `yaml:"payload,omitempty"`      // Values passed to the workflow (templated)
+	Wait         *bool             `yaml:"wait,omitempty"`         // Default true; false starts the run without waiting for it
+
+	// Fields for ActionTypeMQTT, published to the broker from the agent
+	// config. An ActionTypeApproval shows Message to approvers and waits up
+	// to Timeout for a decision.
+	Topic   string `yaml:"topic,omitempty"`   // Topic to publish to (templated)
+	Message string `yaml:"message,omitempty"` // Message payload, or what an approval asks (templated)
+	QoS     int    `yaml:"qos,omitempty"`     // 0 (default), 1 or 2
+	Retain  bool   `yaml:"retain,omitempty"`  // Ask the broker to keep the message for new subscribers
+
+	// Storage holds the endpoint and credentials of an ActionTypeStorage
+	// action, which uploads the file at Path to URL or downloads URL to Path,
+	// by Operation. URL is s3://bucket/key, gs://bucket/key or
+	// sftp://[user@]host[:port]/path; one ending in a slash names a directory
+	// the file keeps its name in.
+	Storage *StorageConfig `yaml:"storage,omitempty"`
+
+	// Git holds the ref and credentials of an ActionTypeGit action, which
+	// clones the repository at URL into Path, pulls it, or checks out a ref
+	// in it, by Operation
+	Git *GitConfig `yaml:"git,omitempty"`
+
 	// Retry configuration
 	Retry *RetryConfig `yaml:"retry,omitempty"`
+
+	// ForEach runs the action once per item, with the item available to its
+	// templates as {{ .item }} and its position as {{ .itemIndex }}
+	ForEach *ForEachConfig `yaml:"foreach,omitempty"`
+
+	// RunAsync starts the action without waiting for it; the next action runs
+	// immediately. Its result is still recorded against the execution.
+	RunAsync bool `yaml:"runAsync,omitempty"`
+
+	// ContinueOnError tolerates a failure of the action: instead of failing,
+	// the run ends as partial-success if no other action failed
+	ContinueOnError bool `yaml:"continueOnError,omitempty"`
+
+	// When is a template deciding whether the action runs, rendered when its
+	// turn comes, e.g. "{{ ne .actions.check.exitCode 0 }}". The action is
+	// skipped if it renders empty, false, 0 or no.
+	When string `yaml:"when,omitempty"`
+
+	// Raw leaves the action's fields as written instead of rendering them as
+	// templates, for commands whose own syntax uses {{ }}, e.g.
+	// docker ps --format '{{.Names}}'. Its when condition is still rendered.
+	Raw bool `yaml:"raw,omitempty"`
+
+	// Env holds extra environment variables for bash actions, set by the
+	// executor from the trigger event (AUTOZAP_EVENT_PATH, ...)
+	Env []string `yaml:"-"`
+
+	// Network is the workflow's network configuration, set by the executor
+	// for HTTP and download actions
+	Network *NetworkConfig `yaml:"-"`
+
+	// Attempts, if set by the executor, is incremented each time the action
+	// is tried, so retries can be reported
+	Attempts *int `yaml:"-"`
+
+	// OnOutput, if set by the executor, is called with a bash action's
+	// captured output while it runs, so its progress shows in the history
+	OnOutput func(output string) `yaml:"-"`
+}
+
+// ResponsePath returns the path extracting a value from the action's JSON
+// response, set with jsonPath or jq
+func (a *Action) ResponsePath() string {
+	if a.JSONPath != "" {
+		return a.JSONPath
+	}
+	return a.JQ
+}
+
+// WaitsForWorkflow reports whether a workflow action waits for the run it
+// starts, which it does unless wait is false
+func (a *Action) WaitsForWorkflow() bool {
+	return a.Wait == nil || *a.Wait
+}
+
+// FollowsRedirects reports whether an HTTP action follows redirects, which it
+// does unless followRedirects is false
+func (a *Action) FollowsRedirects() bool {
+	return a.FollowRedirects == nil || *a.FollowRedirects
+}
+
+// ArchiveFormat returns the format of the archive a file action writes to
+// path, by its extension: "zip", "tar" or "tar.gz", or "" for none of them
+func ArchiveFormat(path string) string {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	}
+	return ""
+}
+
+// ParseFileMode parses the octal permissions of a file, such as "0640" or
+// "600"
+func ParseFileMode(mode string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("must be octal permissions such as 0644")
+	}
+	if perm > 0o777 {
+		return 0, fmt.Errorf("must be at most 0777")
+	}
+	return os.FileMode(perm), nil
+}
+
+// AuthConfig authenticates the requests of an HTTP action. Type "basic" sends
+// Username and Password, "bearer" sends Token, and "oauth2" fetches a token
+// from TokenURL with the client credentials grant and caches it until it
+// expires. All fields but Type and Scopes are templated, so credentials can
+// come from {{ secret "name" }}.
+type AuthConfig struct {
+	Type string `yaml:"type" json:"type"` // "basic", "bearer" or "oauth2"
+
+	Username string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+
+	Token string `yaml:"token,omitempty" json:"token,omitempty"`
+
+	TokenURL     string   `yaml:"tokenUrl,omitempty" json:"tokenUrl,omitempty"`
+	ClientID     string   `yaml:"clientId,omitempty" json:"clientId,omitempty"`
+	ClientSecret string   `yaml:"clientSecret,omitempty" json:"clientSecret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	Audience     string   `yaml:"audience,omitempty" json:"audience,omitempty"` // Requested by some providers, e.g. Auth0
+}
+
+const (
+	AuthTypeBasic  = "basic"
+	AuthTypeBearer = "bearer"
+	AuthTypeOAuth2 = "oauth2"
+)
+
+// TLSConfig configures the TLS connections of an HTTP or download action.
+// CAFile adds a PEM bundle of CAs to the system ones, e.g. for services with
+// certificates from a private CA. CertFile and KeyFile hold a PEM client
+// certificate and its key for mutual TLS. The paths are templated.
+type TLSConfig struct {
+	CAFile   string `yaml:"caFile,omitempty" json:"caFile,omitempty"`
+	CertFile string `yaml:"certFile,omitempty" json:"certFile,omitempty"`
+	KeyFile  string `yaml:"keyFile,omitempty" json:"keyFile,omitempty"`
+
+	// ServerName verifies the certificate against this name instead of the
+	// URL's host, e.g. when connecting by IP address
+	ServerName string `yaml:"serverName,omitempty" json:"serverName,omitempty"`
+
+	// InsecureSkipVerify accepts any certificate. Only for testing: it makes
+	// the connection open to interception.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify,omitempty" json:"insecureSkipVerify,omitempty"`
+}
+
+// StorageConfig configures how a storage action reaches its bucket or
+// server. S3 and GCS, through its S3-compatible XML API with HMAC keys, use
+// AccessKey and SecretKey, or without them the AWS_* environment variables,
+// ~/.aws/credentials or the instance's IAM role. SFTP logs in as Username
+// with Password or PrivateKey and checks the server's host key against
+// HostKey or a known_hosts file. All fields are templated, so credentials can
+// come from {{ secret "name" }}.
+type StorageConfig struct {
+	// Endpoint is the URL of an S3-compatible service such as MinIO or
+	// Cloudflare R2; by default AWS S3 for s3:// and storage.googleapis.com for gs://
+	Endpoint     string `yaml:"endpoint,omitempty"`
+	Region       string `yaml:"region,omitempty"`
+	AccessKey    string `yaml:"accessKey,omitempty"`
+	SecretKey    string `yaml:"secretKey,omitempty"`
+	SessionToken string `yaml:"sessionToken,omitempty"` // For temporary credentials
+
+	Username   string `yaml:"username,omitempty"` // Defaults to the user in the URL
+	Password   string `yaml:"password,omitempty"`
+	PrivateKey string `yaml:"privateKey,omitempty"` // PEM private key, e.g. {{ secret "backup_ssh_key" }}
+	Passphrase string `yaml:"passphrase,omitempty"` // Decrypts PrivateKey
+
+	// HostKey is the SHA256 fingerprint of the server's host key, as printed
+	// by ssh-keygen -l; without it the key must be in KnownHosts
+	HostKey    string `yaml:"hostKey,omitempty"`
+	KnownHosts string `yaml:"knownHosts,omitempty"` // Default ~/.ssh/known_hosts
+}
+
+// GitConfig configures a git action. SSHKey authenticates ssh:// and
+// user@host:path URLs, with the server's key checked against KnownHosts;
+// Token authenticates https:// URLs, sent as the password of Username. Ref,
+// SSHKey, Token, Username and KnownHosts are templated, so credentials can
+// come from {{ secret "name" }}.
+type GitConfig struct {
+	// Ref is the branch, tag or commit to check out; by default the remote's
+	// default branch, or the current one for pull
+	Ref   string `yaml:"ref,omitempty"`
+	Depth int    `yaml:"depth,omitempty"` // Fetch only this many commits of history
+
+	SSHKey     string `yaml:"sshKey,omitempty"`     // PEM private key, e.g. {{ secret "deploy_key" }}
+	KnownHosts string `yaml:"knownHosts,omitempty"` // Default ~/.ssh/known_hosts
+	Token      string `yaml:"token,omitempty"`      // Access token for https:// URLs
+	Username   string `yaml:"username,omitempty"`   // Sent with Token, default x-access-token
+}
+
+// Storage URL schemes
+const (
+	StorageSchemeS3   = "s3"
+	StorageSchemeGCS  = "gs"
+	StorageSchemeSFTP = "sftp"
+)
+
+// ParseStorageURL parses the URL of a storage action, checking it has a
+// supported scheme, a bucket or host and a key or path
+func ParseStorageURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case StorageSchemeS3, StorageSchemeGCS, StorageSchemeSFTP:
+	default:
+		return nil, fmt.Errorf("unsupported scheme '%s': must be one of: %s://, %s://, %s://", u.Scheme, StorageSchemeS3, StorageSchemeGCS, StorageSchemeSFTP)
+	}
+	if u.Host == "" {
+		if u.Scheme == StorageSchemeSFTP {
+			return nil, fmt.Errorf("missing host")
+		}
+		return nil, fmt.Errorf("missing bucket")
+	}
+	if u.User != nil && u.Scheme != StorageSchemeSFTP {
+		return nil, fmt.Errorf("%s:// URLs cannot have a user; set storage.accessKey instead", u.Scheme)
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, fmt.Errorf("URLs cannot have a password; set storage.password instead")
+	}
+	if strings.TrimPrefix(u.Path, "/") == "" {
+		if u.Scheme == StorageSchemeSFTP {
+			return nil, fmt.Errorf("missing path after the host")
+		}
+		return nil, fmt.Errorf("missing object key after the bucket")
+	}
+	return u, nil
+}
+
+// ForEachConfig lists the items an action runs for: a static list, the files
+// matching a glob or the lines of a template such as the output of an earlier
+// action. A plain YAML list sets Items.
+type ForEachConfig struct {
+	Items []string `yaml:"items,omitempty"` // Static list of items (templated)
+	Glob  string   `yaml:"glob,omitempty"`  // Files matching a pattern (templated), e.g. "/data/in/*.csv"
+	From  string   `yaml:"from,omitempty"`  // One item per non-empty line (templated), e.g. "{{ .steps.hosts.stdout }}"
+
+	// MaxParallel runs up to this many items at once; by default they run
+	// one after another
+	MaxParallel int `yaml:"maxParallel,omitempty"`
+}
+
+func (f *ForEachConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		return value.Decode(&f.Items)
+	}
+	type plain ForEachConfig
+	return value.Decode((*plain)(f))
 }
 
 // RetryConfig defines retry behavior for an action
@@ -117,5 +687,5 @@ type RetryConfig struct {
 	InitialDelay string   `yaml:"initialDelay,omitempty"` // Initial delay before first retry (default: "1s")
 	MaxDelay     string   `yaml:"maxDelay,omitempty"`     // Maximum delay between retries (default: "60s")
 	Multiplier   float64  `yaml:"multiplier,omitempty"`   // Backoff multiplier (default: 2.0)
-	RetryOn      []string `yaml:"retryOn,omitempty"`      // Conditions to retry on: "timeout", "error", "status:500", etc.
+	RetryOn      []string `yaml:"retryOn,omitempty"`      // Conditions to retry on: "timeout", "network", "error", "status:500", "exit:75", etc.
 }
@@ -255,3 +255,49 @@ func TestEmptyOptionalFields(t *testing.T) {
 		t.Errorf("Expected command 'echo test', got '%s'", wf.Actions[0].Command)
 	}
 }
+
+func TestTriggerParseSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger Trigger
+		wantErr bool
+	}{
+		{"Standard", Trigger{Schedule: "*/5 * * * *"}, false},
+		{"Descriptor", Trigger{Schedule: "@hourly"}, false},
+		{"Seconds Without Flag", Trigger{Schedule: "*/10 * * * * *"}, true},
+		{"Seconds With Flag", Trigger{Schedule: "*/10 * * * * *", WithSeconds: true}, false},
+		{"Standard With Seconds Flag", Trigger{Schedule: "*/5 * * * *", WithSeconds: true}, true},
+		{"Every With Seconds Flag", Trigger{Schedule: "@every 10s", WithSeconds: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.trigger.ParseSchedule()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWorkflowIsEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name    string
+		enabled *bool
+		want    bool
+	}{
+		{"Unset", nil, true},
+		{"True", &enabled, true},
+		{"False", &disabled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := Workflow{Name: "test", Enabled: tt.enabled}
+			if got := wf.IsEnabled(); got != tt.want {
+				t.Errorf("Expected IsEnabled %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// Package autozap embeds the AutoZap engine in other Go programs, so they can
+// run workflows without shelling out to the CLI:
+//
+//	wf, err := autozap.LoadWorkflow("workflows/backup.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	engine := autozap.NewEngine()
+//	if err := engine.AddWorkflow(wf); err != nil {
+//		return err
+//	}
+//	return engine.Run(ctx)
+//
+// Run starts the trigger of each workflow and returns once ctx is done and
+// the runs in progress have finished, like the run and agent commands.
+package autozap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafted007/autozap/internal/database"
+	"github.com/codecrafted007/autozap/internal/executor"
+	"github.com/codecrafted007/autozap/internal/logger"
+	"github.com/codecrafted007/autozap/internal/parser"
+	"github.com/codecrafted007/autozap/internal/templating"
+	"github.com/codecrafted007/autozap/internal/trigger"
+	"github.com/codecrafted007/autozap/internal/workflow"
+)
+
+// Workflow is a parsed and validated workflow
+type Workflow = workflow.Workflow
+
+// ExecutionResult is the outcome of a workflow run and of each of its actions
+type ExecutionResult = workflow.ExecutionResult
+
+// Run statuses of an ExecutionResult
+const (
+	StatusSuccess        = workflow.StatusSuccess
+	StatusPartialSuccess = workflow.StatusPartialSuccess
+	StatusFailed         = workflow.StatusFailed
+	StatusTimeout        = workflow.StatusTimeout
+	StatusCancelled      = workflow.StatusCancelled
+	StatusAborted        = workflow.StatusAborted
+	StatusSkipped        = workflow.StatusSkipped
+	StatusThrottled      = workflow.StatusThrottled
+	StatusDeferred       = workflow.StatusDeferred
+	StatusBlocked        = workflow.StatusBlocked
+)
+
+// DefaultShutdownTimeout is how long Run waits for runs in progress once its
+// context is done, unless the engine's ShutdownTimeout is set
+const DefaultShutdownTimeout = 30 * time.Second
+
+// asyncActionTimeout bounds how long Run waits for runAsync actions
+const asyncActionTimeout = 30 * time.Second
+
+// LoadWorkflow parses and validates a workflow file, resolving its includes
+// and script files relative to it
+func LoadWorkflow(path string) (*Workflow, error) {
+	initLogger()
+	return parser.ParseWorkflowFile(path)
+}
+
+// ParseWorkflow parses and validates a workflow; source names it in errors
+func ParseWorkflow(data []byte, source string) (*Workflow, error) {
+	initLogger()
+	return parser.ParseWorkflow(data, source)
+}
+
+// SetStrict sets whether workflows with unknown fields, e.g. a misspelled
+// 'commnad', fail to load, which is the default. Turn it off to load
+// workflows written for a newer release, ignoring the fields it adds.
+func SetStrict(strict bool) {
+	parser.SetStrict(strict)
+}
+
+// OpenDatabase records the executions of all engines in a database, as the
+// agent does. driver is sqlite (the default), postgres or mysql; for sqlite
+// dsn is a file path. Without a database runs aren't recorded.
+func OpenDatabase(driver, dsn string) error {
+	initLogger()
+	return database.Open(driver, dsn)
+}
+
+// CloseDatabase closes the database opened by OpenDatabase
+func CloseDatabase() error {
+	return database.CloseDB()
+}
+
+// initLogger sets up the logger autozap logs to, unless the program did
+func initLogger() {
+	if !logger.Initialized() {
+		logger.InitLogger()
+	}
+}
+
+// Engine runs the triggers of the workflows added to it. Workflows run in
+// the process, sharing its concurrency limits, metrics and database with
+// other engines, so workflow names must be unique across them.
+type Engine struct {
+	// ShutdownTimeout bounds how long Run waits for runs in progress once
+	// its context is done; those still running are aborted. Defaults to
+	// DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
+	mu        sync.Mutex
+	workflows []*workflow.Workflow
+	names     map[string]bool
+	ctx       context.Context // of the triggers, while Run runs
+	triggers  []trigger.Trigger
+	running   bool
+}
+
+// NewEngine returns an engine without workflows
+func NewEngine() *Engine {
+	initLogger()
+	return &Engine{names: make(map[string]bool)}
+}
+
+// AddWorkflow adds a workflow to the engine. Added while Run runs, its
+// trigger starts right away.
+func (e *Engine) AddWorkflow(wf *Workflow) error {
+	if wf == nil {
+		return fmt.Errorf("workflow is nil")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.names[wf.Name] {
+		return fmt.Errorf("workflow '%s' already added", wf.Name)
+	}
+	if e.ctx != nil {
+		if err := e.startTrigger(e.ctx, wf); err != nil {
+			return err
+		}
+	}
+	e.names[wf.Name] = true
+	e.workflows = append(e.workflows, wf)
+	return nil
+}
+
+// Workflows returns the names of the workflows added to the engine, in the
+// order they were added
+func (e *Engine) Workflows() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	names := make([]string, 0, len(e.workflows))
+	for _, wf := range e.workflows {
+		names = append(names, wf.Name)
+	}
+	return names
+}
+
+// RunWorkflow runs the actions of an added workflow now, whatever its
+// trigger, and waits for them, like a manual trigger. payload is available
+// to the actions' templates as {{ .payload }} and {{ .event.payload }}.
+func (e *Engine) RunWorkflow(name string, payload map[string]interface{}) (ExecutionResult, error) {
+	e.mu.Lock()
+	var wf *workflow.Workflow
+	for _, added := range e.workflows {
+		if added.Name == name {
+			wf = added
+		}
+	}
+	e.mu.Unlock()
+
+	if wf == nil {
+		return ExecutionResult{}, fmt.Errorf("workflow '%s' not added", name)
+	}
+	data := executor.WithEvent(templating.Data{"payload": payload}, executor.Event{
+		Payload: payload,
+		Source:  map[string]string{"caller": "pkg/autozap"},
+	})
+	return executor.ExecuteAndSummarize(wf, executor.TriggerTypeManual, data), nil
+}
+
+// Run starts the trigger of each workflow and blocks until ctx is done. It
+// then runs the shutdown workflows, stops the triggers and waits for the
+// runs in progress. It returns an error if a trigger fails to start, after
+// stopping the others.
+//
+// Only the engine's own workflows are stopped, so other engines keep running,
+// and the engine can run again once Run returns.
+func (e *Engine) Run(ctx context.Context) error {
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		return fmt.Errorf("engine already running")
+	}
+	e.running = true
+
+	// The triggers outlive ctx until shutdown workflows have run, like in
+	// the agent
+	triggerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	for _, wf := range e.workflows {
+		if err := e.startTrigger(triggerCtx, wf); err != nil {
+			e.stopTriggers()
+			e.running = false
+			e.mu.Unlock()
+			return err
+		}
+	}
+	e.ctx = triggerCtx
+	e.mu.Unlock()
+
+	logger.L().Infow("Autozap engine started",
+		"workflows_count", len(e.Workflows()),
+	)
+	<-ctx.Done()
+
+	names := e.Workflows()
+	trigger.RunShutdownWorkflows(names)
+
+	e.mu.Lock()
+	e.ctx = nil
+	e.stopTriggers()
+	e.mu.Unlock()
+	cancel()
+
+	timeout := e.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	if aborted := executor.DrainWorkflows(names, timeout); aborted > 0 {
+		logger.L().Warnw("Aborted workflow runs still in progress at the shutdown timeout",
+			"aborted", aborted,
+			"shutdown_timeout", timeout.String())
+	}
+	if !executor.WaitForAsyncActions(asyncActionTimeout) {
+		logger.L().Warnw("Async actions still running at shutdown; their results will not be recorded",
+			"timeout", asyncActionTimeout.String())
+	}
+	logger.L().Info("Autozap engine stopped")
+
+	e.mu.Lock()
+	e.running = false
+	e.mu.Unlock()
+	return nil
+}
+
+// startTrigger starts the trigger of wf until ctx is done or the engine
+// stops. The caller holds e.mu.
+func (e *Engine) startTrigger(ctx context.Context, wf *workflow.Workflow) error {
+	t, err := trigger.New(wf)
+	if err != nil {
+		return err
+	}
+	if err := t.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start %s trigger of workflow '%s': %w", wf.Trigger.Type, wf.Name, err)
+	}
+	e.triggers = append(e.triggers, t)
+	return nil
+}
+
+// stopTriggers stops the started triggers. The caller holds e.mu.
+func (e *Engine) stopTriggers() {
+	for _, t := range e.triggers {
+		t.Stop()
+	}
+	e.triggers = nil
+}
@@ -0,0 +1,170 @@
+package autozap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadWorkflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.yaml")
+	if err := os.WriteFile(path, []byte(`
+name: hello
+trigger:
+  type: cron
+  schedule: "@hourly"
+actions:
+  - type: bash
+    name: greet
+    command: echo hello
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wf, err := LoadWorkflow(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflow failed: %v", err)
+	}
+	if wf.Name != "hello" || len(wf.Actions) != 1 {
+		t.Errorf("Unexpected workflow: %+v", wf)
+	}
+
+	if _, err := ParseWorkflow([]byte("name: broken\n"), "broken.yaml"); err == nil {
+		t.Error("Expected error for a workflow without trigger and actions")
+	}
+}
+
+func TestEngine(t *testing.T) {
+	dir := t.TempDir()
+	hourly, err := ParseWorkflow([]byte(`
+name: engine-hourly
+trigger:
+  type: cron
+  schedule: "@hourly"
+actions:
+  - type: bash
+    name: greet
+    command: echo "hello {{ .event.payload.who }} from {{ .payload.from }}"
+`), "hourly.yaml")
+	if err != nil {
+		t.Fatalf("ParseWorkflow failed: %v", err)
+	}
+	stopped := filepath.Join(dir, "stopped")
+	shutdown, err := ParseWorkflow([]byte(`
+name: engine-shutdown
+trigger:
+  type: shutdown
+actions:
+  - type: bash
+    name: record
+    command: echo stopped > `+stopped+`
+`), "shutdown.yaml")
+	if err != nil {
+		t.Fatalf("ParseWorkflow failed: %v", err)
+	}
+
+	engine := NewEngine()
+	engine.ShutdownTimeout = 5 * time.Second
+	if err := engine.AddWorkflow(hourly); err != nil {
+		t.Fatalf("AddWorkflow failed: %v", err)
+	}
+	if err := engine.AddWorkflow(hourly); err == nil {
+		t.Error("Expected error adding a workflow twice")
+	}
+
+	t.Run("Run Workflow", func(t *testing.T) {
+		result, err := engine.RunWorkflow("engine-hourly", map[string]interface{}{"who": "world", "from": "go"})
+		if err != nil {
+			t.Fatalf("RunWorkflow failed: %v", err)
+		}
+		if result.Status != StatusSuccess || len(result.Actions) != 1 {
+			t.Fatalf("Unexpected result: %+v", result)
+		}
+		if out := strings.TrimSpace(result.Actions[0].Output); out != "hello world from go" {
+			t.Errorf("Expected output 'hello world from go', got %q", out)
+		}
+		if _, err := engine.RunWorkflow("missing", nil); err == nil {
+			t.Error("Expected error running a workflow that wasn't added")
+		}
+	})
+
+	t.Run("Run Until Cancelled", func(t *testing.T) {
+		// Another engine keeps running while this one stops
+		otherStopped := filepath.Join(dir, "other-stopped")
+		otherShutdown, err := ParseWorkflow([]byte(`
+name: engine-other-shutdown
+trigger:
+  type: shutdown
+actions:
+  - type: bash
+    name: record
+    command: echo stopped > `+otherStopped+`
+`), "other-shutdown.yaml")
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+		other := NewEngine()
+		if err := other.AddWorkflow(otherShutdown); err != nil {
+			t.Fatalf("AddWorkflow failed: %v", err)
+		}
+		otherCtx, otherCancel := context.WithCancel(context.Background())
+		otherDone := make(chan error, 1)
+		go func() { otherDone <- other.Run(otherCtx) }()
+		defer func() {
+			otherCancel()
+			<-otherDone
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- engine.Run(ctx) }()
+
+		// Added while running, its trigger starts right away
+		time.Sleep(100 * time.Millisecond)
+		if err := engine.AddWorkflow(shutdown); err != nil {
+			t.Fatalf("AddWorkflow failed: %v", err)
+		}
+		if got := engine.Workflows(); len(got) != 2 {
+			t.Errorf("Expected 2 workflows, got %v", got)
+		}
+		if err := engine.Run(context.Background()); err == nil {
+			t.Error("Expected error running the engine while it runs")
+		}
+
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("Run didn't return after its context was cancelled")
+		}
+		if _, err := os.Stat(stopped); err != nil {
+			t.Errorf("Expected the shutdown workflow to run when the engine stopped: %v", err)
+		}
+		if _, err := os.Stat(otherStopped); err == nil {
+			t.Error("Expected the other engine's shutdown workflow not to run")
+		}
+	})
+
+	t.Run("Run Again", func(t *testing.T) {
+		// Once stopped, the engine's workflows run again
+		result, err := engine.RunWorkflow("engine-hourly", map[string]interface{}{"who": "again", "from": "go"})
+		if err != nil {
+			t.Fatalf("RunWorkflow failed: %v", err)
+		}
+		if result.Status != StatusSuccess {
+			t.Errorf("Expected status '%s' after the engine stopped, got '%s'", StatusSuccess, result.Status)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		if err := engine.Run(ctx); err != nil {
+			t.Errorf("Expected the engine to run again, got: %v", err)
+		}
+	})
+}